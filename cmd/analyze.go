@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/internal/keys"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+	"github.com/spf13/cobra"
+)
+
+var (
+	freqLine       string
+	freqMode       string
+	freqDirection  int
+	freqWeek       string
+	freqRecordings []string
+	freqSite       int
+	freqOutput     string
+)
+
+var analyzeCmd = &cobra.Command{
+	Use:   "analyze",
+	Short: "Analyze SL's timetable and recorded service",
+}
+
+var analyzeFrequencyCmd = &cobra.Command{
+	Use:   "frequency --line LINE",
+	Short: "Departures per hour of a line across the week",
+	Long: `Count the scheduled trips of a line leaving in each hour of a week,
+Monday to Sunday, from the GTFS Regional static timetable: the service
+level at a glance, and a record of it before and after a timetable change.
+Needs a Trafiklab key: sl keys set trafiklab-static <key>.
+
+The week is the seven days from --week (default today). With --recordings
+the departures logged with sl departures --log-csv are counted too, as the
+average per recorded day, next to what was scheduled. An hour counts as
+recorded on a day when anything at all was logged leaving in it.
+Cancelled departures don't count. The timetable counts trips leaving their
+first stop, so record a stop the whole line passes.
+
+--format png draws a heatmap, a row per day and a column per hour, with the
+observed grid below the scheduled one. csv, tsv and table give one row per
+hour of the week.
+
+Examples:
+  sl analyze frequency --line 4
+  sl analyze frequency --line 4 --format csv > line4.csv
+  sl analyze frequency --line 17 --mode METRO --week 2025-09-01 --format png -o line17.png
+  sl analyze frequency --line 55 --recordings tanto-*.csv --format png -o line55.png`,
+	Args:        cobra.NoArgs,
+	Annotations: formats(append([]string{"text", "png"}, format.TableFormats...)...),
+	RunE:        runAnalyzeFrequency,
+}
+
+func init() {
+	analyzeFrequencyCmd.Flags().StringVar(&freqLine, "line", "", "Line designation (e.g. 4, 17)")
+	analyzeFrequencyCmd.Flags().StringVar(&freqMode, "mode", "", "Transport mode, when buses and trains share the number: BUS, METRO, TRAIN, TRAM, SHIP")
+	analyzeFrequencyCmd.Flags().IntVar(&freqDirection, "direction", 0, "Only this direction (1 or 2)")
+	analyzeFrequencyCmd.Flags().StringVar(&freqWeek, "week", "", "First day of the week to count, e.g. 2025-09-01 (default today)")
+	analyzeFrequencyCmd.Flags().StringSliceVar(&freqRecordings, "recordings", nil, "Departure logs from sl departures --log-csv to compare against (repeatable)")
+	analyzeFrequencyCmd.Flags().IntVar(&freqSite, "site", 0, "With recordings of several stops, the site ID to count")
+	analyzeFrequencyCmd.Flags().StringVarP(&freqOutput, "output", "o", "", "Write to a file instead of stdout")
+	analyzeFrequencyCmd.MarkFlagRequired("line")
+
+	analyzeCmd.AddCommand(analyzeFrequencyCmd)
+	rootCmd.AddCommand(analyzeCmd)
+}
+
+func runAnalyzeFrequency(cmd *cobra.Command, args []string) error {
+	if freqDirection < 0 || freqDirection > 2 {
+		return usageErrorf("--direction must be 1 or 2")
+	}
+	if freqOutput != "" && textFormat() {
+		return usageErrorf("--output needs --format png, csv, tsv or table")
+	}
+
+	week := sl.StockholmTime(time.Now())
+	if freqWeek != "" {
+		var err error
+		if week, err = time.ParseInLocation("2006-01-02", freqWeek, week.Location()); err != nil {
+			return usageErrorf("--week must be a date like 2025-09-01")
+		}
+	}
+
+	var line, recorded []time.Time
+	if len(freqRecordings) > 0 {
+		var err error
+		if line, recorded, err = recordedDepartures(freqRecordings, freqSite); err != nil {
+			return err
+		}
+	}
+
+	key, err := apiKey(keys.TrafiklabStatic)
+	if err != nil {
+		return err
+	}
+	client := newClient()
+	tt, err := loadTimetable(context.Background(), client, key)
+	if err != nil {
+		return err
+	}
+
+	freq := sl.ScheduledFrequency(tt, freqLine, freqMode, freqDirection, week)
+	if len(freqRecordings) > 0 {
+		sl.ObserveFrequency(freq, line, recorded)
+	}
+
+	switch {
+	case jsonOutput:
+		return format.JSON(freq)
+	case textFormat():
+		format.Frequency(freqLine, freq)
+		return nil
+	}
+	write := func(w io.Writer) error { return format.Table(w, outputFormat, format.FrequencyColumns, freq) }
+	if outputFormat == "png" {
+		write = func(w io.Writer) error { return format.FrequencyPNG(w, freq) }
+	}
+	if freqOutput == "" {
+		if outputFormat == "png" && format.IsTerminal() {
+			return fmt.Errorf("not writing a PNG to a terminal; use -o FILE or redirect stdout")
+		}
+		return write(os.Stdout)
+	}
+	f, err := os.Create(freqOutput)
+	if err != nil {
+		return fmt.Errorf("creating output file: %w", err)
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return fmt.Errorf("writing frequency: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing frequency: %w", err)
+	}
+	fmt.Fprintf(format.Stderr(), "✓ Line %s departures per hour → %s\n", freqLine, freqOutput)
+	return nil
+}
+
+// recordedDepartures reads departure logs for --recordings and returns the
+// scheduled times of the analyzed line's departures that ran, and of every
+// departure logged. A departure logged several times, as its expected time
+// or state changed, counts once with its last state.
+func recordedDepartures(paths []string, site int) (line, recorded []time.Time, err error) {
+	type logged struct {
+		scheduled time.Time
+		matches   bool
+		cancelled bool
+	}
+	deps := map[string]*logged{}
+	var order []string
+	sites := map[int64]bool{}
+	for _, path := range paths {
+		rows, err := readDepartureLog(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, row := range rows {
+			siteID, _ := row[1].(int64)
+			scheduled, ok := row[7].(time.Time)
+			if !ok || site != 0 && siteID != int64(site) {
+				continue
+			}
+			sites[siteID] = true
+			dir, _ := row[6].(int64)
+			key := departureLogKey(int(siteID), row[3].(string), int(dir), csvTime(scheduled))
+			d := deps[key]
+			if d == nil {
+				d = &logged{
+					scheduled: scheduled,
+					matches: strings.EqualFold(row[3].(string), freqLine) &&
+						(freqMode == "" || strings.EqualFold(row[4].(string), freqMode)) &&
+						(freqDirection == 0 || dir == int64(freqDirection)),
+				}
+				deps[key] = d
+				order = append(order, key)
+			}
+			d.cancelled = row[11] == "CANCELLED"
+		}
+	}
+	if len(sites) > 1 {
+		ids := make([]string, 0, len(sites))
+		for id := range sites {
+			ids = append(ids, fmt.Sprint(id))
+		}
+		slices.Sort(ids)
+		return nil, nil, fmt.Errorf("the recordings cover several stops (sites %s); pick one with --site", strings.Join(ids, ", "))
+	}
+	if len(order) == 0 {
+		return nil, nil, sl.Errorf(sl.ErrNoDepartures, "no departures in the recordings")
+	}
+
+	for _, key := range order {
+		d := deps[key]
+		recorded = append(recorded, d.scheduled)
+		if d.matches && !d.cancelled {
+			line = append(line, d.scheduled)
+		}
+	}
+	return line, recorded, nil
+}
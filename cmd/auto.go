@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/glundgren93/sl-cli/internal/config"
+	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+	"github.com/spf13/cobra"
+)
+
+var (
+	autoNear   string
+	autoHere   bool
+	autoRadius float64
+)
+
+var autoCmd = &cobra.Command{
+	Use:   "auto",
+	Short: "Show the board for the favorite or stop you're at",
+	Long: `Pick the departure board that fits where you are: the nearest favorite
+from config.json within --radius, or failing that the nearest stop.
+
+--here locates you from your IP address. Over SSH that is the address you
+connect from (e.g. your phone), otherwise this machine's public address.
+IP locations are coarse — often just the city — so prefer --near when you
+can, and expect --here to work best with a generous --radius.
+
+Examples:
+  sl auto --near "59.3143,18.0735"
+  sl auto --near "Götgatan 40" --radius 0.5
+  sl auto --here --radius 3`,
+	Args: cobra.ArbitraryArgs,
+	RunE: runAuto,
+}
+
+func init() {
+	autoCmd.Flags().StringVar(&autoNear, "near", "", `Where you are: an address, place name or "lat,lon"`)
+	autoCmd.Flags().BoolVar(&autoHere, "here", false, "Locate yourself from your (SSH client's) IP address")
+	autoCmd.Flags().Float64VarP(&autoRadius, "radius", "r", 1.0, "How close (km) a favorite or stop must be")
+	autoCmd.Flags().BoolVar(&depNoDevs, "no-deviations", false, "Skip the inline deviation lookup")
+
+	autoCmd.RegisterFlagCompletionFunc("near", completeStops)
+
+	rootCmd.AddCommand(autoCmd)
+}
+
+func runAuto(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	client := newClient()
+
+	near := autoNear
+	if near == "" && len(args) > 0 {
+		near = strings.Join(args, " ")
+	}
+	if (near == "") == !autoHere {
+		return usageErrorf("provide either --near or --here")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	var lat, lon float64
+	if autoHere {
+		loc, err := client.LocateIP(ctx, sl.SSHClientIP())
+		if err != nil {
+			return fmt.Errorf("locating you: %w", err)
+		}
+		lat, lon = loc.Lat, loc.Lon
+		if !jsonOutput {
+			fmt.Fprintf(format.Stderr(), "📍 Located: %s (%.4f, %.4f)\n", loc.City, lat, lon)
+		}
+	} else if lat, lon, err = resolvePoint(ctx, client, near); err != nil {
+		return err
+	}
+
+	sites, err := client.GetSitesCached(ctx)
+	if err != nil {
+		return fmt.Errorf("fetching sites: %w", err)
+	}
+
+	if name, site, ok := nearestFavorite(ctx, client, cfg, sites, lat, lon); ok {
+		distM := int(sl.DistanceKm(lat, lon, site.Lat, site.Lon) * 1000)
+		if !jsonOutput {
+			fmt.Fprintf(format.Stderr(), "⭐ %s: %s (%dm)\n\n", name, site.Name, distM)
+		}
+		return fetchAndPrintDepartures(ctx, client, site.ID, site.Name, distM)
+	}
+
+	nearby := sl.FindNearestSites(sites, lat, lon, autoRadius)
+	if len(nearby) == 0 {
+		return sl.Errorf(sl.ErrNotFound, "no favorite or stop within %.1f km", autoRadius)
+	}
+	stop := nearby[0]
+	if !jsonOutput {
+		fmt.Fprintf(format.Stderr(), "🚏 %s (%dm)\n\n", stop.Site.Name, int(stop.DistanceKm*1000))
+	}
+	return fetchAndPrintDepartures(ctx, client, stop.Site.ID, stop.Site.Name, int(stop.DistanceKm*1000))
+}
+
+// nearestFavorite returns the favorite closest to lat/lon within --radius,
+// and the stop to show for it: the favorite itself if it names a stop,
+// otherwise the stop nearest to it. Favorites that can't be resolved are
+// skipped with a note on stderr.
+func nearestFavorite(ctx context.Context, client *sl.Client, cfg *config.Config, sites []sl.Site, lat, lon float64) (string, sl.Site, bool) {
+	names := make([]string, 0, len(cfg.Favorites))
+	for name := range cfg.Favorites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var (
+		bestName string
+		bestSite sl.Site
+		bestKm   = autoRadius
+		found    bool
+	)
+	for _, name := range names {
+		site, favLat, favLon, err := favoriteSite(ctx, client, sites, cfg.Favorites[name], autoRadius)
+		if err != nil {
+			fmt.Fprintf(format.Stderr(), "⚠️  skipping favorite %q: %v\n", name, err)
+			continue
+		}
+		if km := sl.DistanceKm(lat, lon, favLat, favLon); km <= bestKm {
+			bestName, bestSite, bestKm, found = name, site, km, true
+		}
+	}
+	return bestName, bestSite, found
+}
+
+// favoriteSite resolves a favorite's value — a stop ID, stop name, "lat,lon"
+// or address — to where it is and the stop whose board represents it: the
+// stop itself, or the one nearest to it within radiusKm.
+func favoriteSite(ctx context.Context, client *sl.Client, sites []sl.Site, value string, radiusKm float64) (site sl.Site, lat, lon float64, err error) {
+	if id, err := strconv.Atoi(value); err == nil {
+		for _, s := range sites {
+			if s.ID == id {
+				return s, s.Lat, s.Lon, nil
+			}
+		}
+		return site, 0, 0, sl.Errorf(sl.ErrNotFound, "no stop with id %d", id)
+	}
+	for _, s := range sites {
+		if strings.EqualFold(s.Name, value) {
+			return s, s.Lat, s.Lon, nil
+		}
+	}
+
+	lat, lon, ok := parseLatLon(value)
+	if !ok {
+		if lat, lon, _, err = lookupAddress(ctx, client, value); err != nil {
+			return site, 0, 0, err
+		}
+	}
+	nearest := sl.FindNearestSites(sites, lat, lon, radiusKm)
+	if len(nearest) == 0 {
+		return site, 0, 0, sl.Errorf(sl.ErrNotFound, "no stop within %.1f km", radiusKm)
+	}
+	return nearest[0].Site, lat, lon, nil
+}
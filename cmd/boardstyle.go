@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"time"
+
+	"github.com/glundgren93/sl-cli/internal/config"
+	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+// boardStyle works out how the HTML board should look at now: the theme,
+// with "auto" switching between day and night, and the night dimming.
+func boardStyle(b config.Board, now time.Time) (format.BoardStyle, error) {
+	day, err := parseWhen(cmp.Or(b.Day, "07:00"))
+	if err != nil {
+		return format.BoardStyle{}, fmt.Errorf("board day: %w", err)
+	}
+	night, err := parseWhen(cmp.Or(b.Night, "22:00"))
+	if err != nil {
+		return format.BoardStyle{}, fmt.Errorf("board night: %w", err)
+	}
+	if b.NightBrightness < 0 || b.NightBrightness > 1 {
+		return format.BoardStyle{}, fmt.Errorf("board night_brightness must be between 0 and 1, got %g", b.NightBrightness)
+	}
+
+	now = sl.StockholmTime(now)
+	mins := now.Hour()*60 + now.Minute()
+	from, to := day.hour*60+day.minute, night.hour*60+night.minute
+	isDay := mins >= from && mins < to
+	if from > to {
+		isDay = mins >= from || mins < to
+	}
+
+	style := format.BoardStyle{Theme: b.Theme, LineColors: b.LineColors}
+	switch b.Theme {
+	case "", format.ThemeDark, format.ThemeDay, format.ThemeNight:
+	case "auto":
+		style.Theme = format.ThemeDay
+		if !isDay {
+			style.Theme = format.ThemeNight
+		}
+	default:
+		return format.BoardStyle{}, fmt.Errorf("unknown theme %q (use dark, day, night or auto)", b.Theme)
+	}
+	if !isDay {
+		style.Brightness = b.NightBrightness
+	}
+	return style, nil
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/glundgren93/sl-cli/internal/config"
+	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+	"github.com/spf13/cobra"
+)
+
+// bookmarkRadiusKm is how far from an address or "lat,lon" bookmark its
+// stop may be.
+const bookmarkRadiusKm = 1.0
+
+var bookmarkCmd = &cobra.Command{
+	Use:   "bookmark",
+	Short: "Manage named stops and places",
+	Long: `Bookmarks are short names for stops and places, usable anywhere a stop,
+address or trip endpoint is accepted:
+
+  sl departures home
+  sl trip --from home --to work
+  sl trip home..work@08:15
+
+A bookmark can point at a site ID, a stop name, an address or "lat,lon".
+Bookmarks are the "favorites" in config.json and take precedence over
+stops with the same name.
+
+Examples:
+  sl bookmark add home 9530
+  sl bookmark add work "T-Centralen"
+  sl bookmark list
+  sl bookmark remove work`,
+	Aliases: []string{"bookmarks", "fav"},
+}
+
+var bookmarkAddCmd = &cobra.Command{
+	Use:         "add <name> <stop>",
+	Short:       "Add or replace a bookmark",
+	Args:        cobra.MinimumNArgs(2),
+	Annotations: changesSettings(),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, value := args[0], strings.Join(args[1:], " ")
+		if err := validBookmarkName(name); err != nil {
+			return err
+		}
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		if cfg.Favorites == nil {
+			cfg.Favorites = map[string]string{}
+		}
+		for k := range cfg.Favorites {
+			if strings.EqualFold(k, name) {
+				delete(cfg.Favorites, k)
+			}
+		}
+		cfg.Favorites[name] = value
+		if err := config.Save(cfg); err != nil {
+			return err
+		}
+		if !jsonOutput {
+			fmt.Fprintf(format.Stderr(), "✓ %s → %s\n", name, value)
+		}
+		return nil
+	},
+}
+
+// bookmark is one entry in the JSON output of bookmark list.
+type bookmark struct {
+	Name string `json:"name"`
+	Stop string `json:"stop"`
+}
+
+var bookmarkListCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "List bookmarks",
+	Aliases: []string{"ls"},
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		list := []bookmark{}
+		for name, value := range cfg.Favorites {
+			list = append(list, bookmark{name, value})
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+		if jsonOutput {
+			return format.JSON(list)
+		}
+		if len(list) == 0 {
+			fmt.Println("No bookmarks. Add one with: sl bookmark add home <stop>")
+			return nil
+		}
+		for _, b := range list {
+			fmt.Printf("%-12s %s\n", b.Name, b.Stop)
+		}
+		return nil
+	},
+}
+
+var bookmarkRemoveCmd = &cobra.Command{
+	Use:         "remove <name>",
+	Short:       "Delete a bookmark",
+	Aliases:     []string{"rm"},
+	Args:        cobra.ExactArgs(1),
+	Annotations: changesSettings(),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeFavorites(cmd, args, toComplete)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		removed := false
+		for k := range cfg.Favorites {
+			if strings.EqualFold(k, args[0]) {
+				delete(cfg.Favorites, k)
+				removed = true
+			}
+		}
+		if !removed {
+			return sl.Errorf(sl.ErrNotFound, "no bookmark %q", args[0])
+		}
+		if err := config.Save(cfg); err != nil {
+			return err
+		}
+		if !jsonOutput {
+			fmt.Fprintf(format.Stderr(), "✓ Removed %s\n", args[0])
+		}
+		return nil
+	},
+}
+
+func init() {
+	bookmarkCmd.AddCommand(bookmarkAddCmd, bookmarkListCmd, bookmarkRemoveCmd)
+	rootCmd.AddCommand(bookmarkCmd)
+}
+
+// validBookmarkName rejects names that would be read as something else: a
+// site ID, coordinates, or part of the FROM..TO[@HH:MM] trip syntax.
+func validBookmarkName(name string) error {
+	if _, err := strconv.Atoi(name); err == nil {
+		return fmt.Errorf("bookmark name %q would shadow a site ID", name)
+	}
+	if strings.ContainsAny(name, " ,@") || strings.Contains(name, "..") {
+		return fmt.Errorf("bookmark name %q may not contain spaces, commas, @ or ..", name)
+	}
+	return nil
+}
+
+// lookupBookmark returns what a bookmark points at. An unreadable config is
+// treated as having no bookmarks; the commands that edit it report it.
+func lookupBookmark(name string) (string, bool) {
+	cfg, err := config.Load()
+	if err != nil {
+		return "", false
+	}
+	return cfg.Favorite(name)
+}
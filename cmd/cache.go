@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the local cache of SL data",
+	Long: `sl keeps sites, stop points and lines on disk for a day (set "cache_ttl"
+in config.json to change that), recent trip plans for a couple of minutes,
+and the last departure board per stop as a fallback for outages.
+
+Examples:
+  sl cache clear`,
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Delete all cached SL data",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		n, err := newClient().ClearCache()
+		if err != nil {
+			return err
+		}
+		if jsonOutput {
+			return format.JSON(struct {
+				Removed int `json:"removed"`
+			}{n})
+		}
+		fmt.Fprintf(format.Stderr(), "✓ Removed %d cached file(s)\n", n)
+		return nil
+	},
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheClearCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
@@ -0,0 +1,1698 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glundgren93/sl-cli/internal/apitest"
+	"github.com/glundgren93/sl-cli/internal/config"
+	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// runCLI executes the root command with args and returns what it wrote to
+// stdout. Flag values are reset to their defaults afterwards, since cobra
+// keeps them in package-level variables between executions.
+func runCLI(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	t.Cleanup(resetFlags)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+
+	out := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		out <- string(data)
+	}()
+
+	rootCmd.SetArgs(args)
+	runErr := rootCmd.Execute()
+
+	w.Close()
+	os.Stdout = stdout
+	return <-out, runErr
+}
+
+func resetFlags() {
+	reset := func(fs *pflag.FlagSet) {
+		fs.VisitAll(func(f *pflag.Flag) {
+			if !f.Changed {
+				return
+			}
+			// Set appends to slice flags rather than replacing them.
+			if sv, ok := f.Value.(pflag.SliceValue); ok {
+				sv.Replace(nil)
+			} else {
+				f.Value.Set(f.DefValue)
+			}
+			f.Changed = false
+		})
+	}
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		reset(c.Flags())
+		reset(c.PersistentFlags())
+		for _, sub := range c.Commands() {
+			walk(sub)
+		}
+	}
+	walk(rootCmd)
+	depStopName = ""
+}
+
+func TestCLI_DeparturesJSON(t *testing.T) {
+	fake := apitest.New(t)
+
+	out, err := runCLI(t, "departures", "--site", "9191", "--line", "17", "--json")
+	if err != nil {
+		t.Fatalf("departures failed: %v", err)
+	}
+
+	var result departureResult
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if result.Stop != "Medborgarplatsen" || result.SiteID != 9191 {
+		t.Errorf("stop = %q (%d), want Medborgarplatsen (9191)", result.Stop, result.SiteID)
+	}
+	if len(result.Departures) != 2 {
+		t.Fatalf("expected 2 departures for line 17, got %d", len(result.Departures))
+	}
+	if mins := result.Departures[0].MinutesLeft; mins < 6 || mins > 8 {
+		t.Errorf("first departure minutes_left = %d, want ~7", mins)
+	}
+	if len(result.Deviations) != 1 || result.Deviations[0].Severity != "critical" {
+		t.Errorf("expected one critical deviation for line 17, got %+v", result.Deviations)
+	}
+	if fake.RequestCount("/transport/v1/sites/9191/departures") != 1 {
+		t.Errorf("expected a single departures request, got %v", fake.Requests)
+	}
+}
+
+func TestCLI_DeparturesPerLine(t *testing.T) {
+	apitest.New(t)
+
+	lineCounts := func(args ...string) map[string]int {
+		t.Helper()
+		out, err := runCLI(t, append([]string{"departures", "--site", "9191", "--no-deviations", "--json"}, args...)...)
+		if err != nil {
+			t.Fatalf("departures failed: %v", err)
+		}
+		var result departureResult
+		if err := json.Unmarshal([]byte(out), &result); err != nil {
+			t.Fatalf("invalid JSON output: %v\n%s", err, out)
+		}
+		counts := map[string]int{}
+		for _, d := range result.Departures {
+			counts[d.TransportMode+" "+d.Line]++
+		}
+		return counts
+	}
+
+	all := lineCounts("--limit", "0")
+	perLine := lineCounts("--per-line", "1")
+	if len(perLine) != len(all) {
+		t.Errorf("--per-line should keep every line: got %v, want the lines of %v", perLine, all)
+	}
+	for line, n := range perLine {
+		if n != 1 {
+			t.Errorf("line %s has %d departures, want 1", line, n)
+		}
+	}
+}
+
+func TestCLI_DeparturesWindow(t *testing.T) {
+	apitest.New(t)
+
+	if _, err := runCLI(t, "departures", "--site", "9191", "--after", "20m", "--within", "10m"); err == nil {
+		t.Error("--after later than --within should fail")
+	}
+
+	out, err := runCLI(t, "departures", "--site", "9191", "--no-deviations", "--limit", "0", "--after", "6m", "--within", "20m", "--json")
+	if err != nil {
+		t.Fatalf("departures failed: %v", err)
+	}
+	var result departureResult
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if len(result.Departures) == 0 {
+		t.Fatal("expected departures between 6 and 20 minutes away")
+	}
+	for _, d := range result.Departures {
+		if d.MinutesLeft < 6 || d.MinutesLeft > 20 {
+			t.Errorf("line %s leaves in %d min, outside --after 6m --within 20m", d.Line, d.MinutesLeft)
+		}
+	}
+}
+
+func TestCLI_DeparturesMetrics(t *testing.T) {
+	apitest.New(t)
+
+	out, err := runCLI(t, "departures", "--site", "9191", "--no-deviations", "--limit", "2", "--format", "influx")
+	if err != nil {
+		t.Fatalf("departures --format influx failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "sl_departure,site_id=9191,stop=Medborgarplatsen,") {
+		t.Errorf("expected two sl_departure points, got:\n%s", out)
+	}
+
+	var pushed, path string
+	gw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		pushed, path = string(body), r.Method+" "+r.URL.Path
+	}))
+	defer gw.Close()
+
+	out, err = runCLI(t, "departures", "--site", "9191", "--no-deviations", "--limit", "2", "--format", "", "--push-gateway", gw.URL)
+	if err != nil {
+		t.Fatalf("departures --push-gateway failed: %v", err)
+	}
+	if out != "" {
+		t.Errorf("expected nothing on stdout when pushing, got %q", out)
+	}
+	if path != "PUT /metrics/job/sl_departures/site/9191" {
+		t.Errorf("pushed to %q", path)
+	}
+	if !strings.Contains(pushed, "# TYPE sl_departure_minutes_left gauge") || !strings.Contains(pushed, `site_id="9191"`) {
+		t.Errorf("unexpected push body:\n%s", pushed)
+	}
+
+	if _, err := runCLI(t, "departures", "--site", "9191", "--push-gateway", gw.URL, "--format", "csv"); err == nil {
+		t.Error("expected --push-gateway with --format csv to fail")
+	}
+}
+
+func TestCLI_Next(t *testing.T) {
+	apitest.New(t)
+
+	out, err := runCLI(t, "next", "55", "--from", "Medborgarplatsen", "--towards", "Tanto")
+	if err != nil {
+		t.Fatalf("next failed: %v", err)
+	}
+	if !strings.HasPrefix(out, "55 to Tanto in ") || !strings.HasSuffix(out, " min from platform A\n") {
+		t.Errorf("unexpected line %q", out)
+	}
+
+	if _, err := runCLI(t, "next", "55", "--from", "9191", "--towards", "", "--within", "5m"); err == nil || !strings.Contains(err.Error(), "no 55 from 9191 within 5 min") {
+		t.Errorf("expected nothing within 5m, got %v", err)
+	}
+
+	out, err = runCLI(t, "next", "17", "--from", "9191", "--towards", "2", "--within", "1h", "--json")
+	if err != nil {
+		t.Fatalf("next --json failed: %v", err)
+	}
+	var result nextResult
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if result.SiteID != 9191 || result.Departure.Destination != "Skarpnäck" {
+		t.Errorf("expected 17 towards Skarpnäck, got %+v", result)
+	}
+}
+
+func TestCLI_DeparturesAt(t *testing.T) {
+	apitest.New(t)
+
+	// Within the real-time horizon --at trims the live board.
+	at := sl.StockholmTime(time.Now().Add(10 * time.Minute)).Truncate(time.Minute)
+	out, err := runCLI(t, "departures", "--site", "9191", "--no-deviations", "--limit", "0", "--json", "--at", at.Format("2006-01-02 15:04"))
+	if err != nil {
+		t.Fatalf("departures --at failed: %v", err)
+	}
+	var result departureResult
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if len(result.Departures) == 0 {
+		t.Fatal("expected departures after --at")
+	}
+	for _, d := range result.Departures {
+		if d.Expected.Before(at) {
+			t.Errorf("line %s leaves %s, before --at %s", d.Line, d.Expected.Format("15:04"), at.Format("15:04"))
+		}
+	}
+
+	if _, err := runCLI(t, "departures", "--site", "9191", "--at", "08:00", "--watch"); err == nil {
+		t.Error("--at with --watch should fail")
+	}
+}
+
+func TestCLI_DeparturesPlanner(t *testing.T) {
+	fake := apitest.New(t)
+
+	out, err := runCLI(t, "departures", "--site", "9191", "--source", "planner", "--mode", "METRO", "--no-deviations", "--json")
+	if err != nil {
+		t.Fatalf("departures --source planner failed: %v", err)
+	}
+	var result departureResult
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if result.Stop != "Medborgarplatsen" || len(result.Departures) != 3 {
+		t.Fatalf("got %q with %d departures, want Medborgarplatsen with the 3 metro departures", result.Stop, len(result.Departures))
+	}
+	if d := result.Departures[0]; d.Line != "17" || d.MinutesLeft < 5 || d.MinutesLeft > 7 {
+		t.Errorf("first departure = line %s in %d min, want 17 in ~6", d.Line, d.MinutesLeft)
+	}
+	if fake.RequestCount("/planner/v2/departure-monitor?") != 1 || fake.RequestCount("/transport/v1/sites/9191/departures") != 0 {
+		t.Errorf("expected only the departure monitor to be asked, got %v", fake.Requests)
+	}
+
+	if _, err := runCLI(t, "departures", "--site", "9191", "--source", "planner", "--direction", "1"); err == nil {
+		t.Error("--source planner with a direction code should fail")
+	}
+}
+
+func TestCLI_DeparturesTowardsFile(t *testing.T) {
+	apitest.New(t)
+	path := filepath.Join(t.TempDir(), "office.txt")
+	if err := os.WriteFile(path, []byte("# past the office\nmot Tanto\n\n  Hässelby strand\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := runCLI(t, "departures", "--site", "9191", "--towards-file", path, "--no-deviations", "--json")
+	if err != nil {
+		t.Fatalf("departures --towards-file failed: %v", err)
+	}
+	var result departureResult
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	var lines []string
+	for _, d := range result.Departures {
+		lines = append(lines, d.Line+" "+d.Destination)
+	}
+	if want := []string{"19 Hässelby strand", "55 Tanto"}; !slices.Equal(lines, want) {
+		t.Errorf("departures = %v, want %v", lines, want)
+	}
+
+	if _, err := runCLI(t, "departures", "--site", "9191", "--towards-file", path, "--direction", "1"); err == nil {
+		t.Error("--towards-file with --direction should fail")
+	}
+	empty := filepath.Join(t.TempDir(), "empty.txt")
+	os.WriteFile(empty, []byte("# nothing\n"), 0o644)
+	if _, err := runCLI(t, "departures", "--site", "9191", "--towards-file", empty, "--direction", ""); err == nil {
+		t.Error("a --towards-file without destinations should fail")
+	}
+}
+
+func TestCLI_DeparturesWatch(t *testing.T) {
+	fake := apitest.New(t)
+
+	out, err := runCLI(t, "departures", "--site", "9191", "--no-deviations", "--watch", "--count", "1", "--json")
+	if err != nil {
+		t.Fatalf("departures --watch failed: %v", err)
+	}
+	var result departureResult
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if len(result.Departures) == 0 {
+		t.Error("expected departures")
+	}
+	if n := fake.RequestCount("/transport/v1/sites/9191/departures"); n != 1 {
+		t.Errorf("--count 1 made %d departure requests, want 1", n)
+	}
+
+	if _, err := runCLI(t, "departures", "--site", "9191", "--watch", "--interval", "1s"); err == nil {
+		t.Error("expected an error for an interval under 5s")
+	}
+}
+
+func TestCLI_RandomFlags(t *testing.T) {
+	apitest.New(t)
+	if _, err := runCLI(t, "random", "--from", "Medborgarplatsen", "--minutes", "20", "--min-minutes", "30"); err == nil {
+		t.Error("--min-minutes above --minutes should fail")
+	}
+	if _, err := runCLI(t, "random", "--from", "Medborgarplatsen", "--minutes", "0", "--min-minutes", "0"); err == nil {
+		t.Error("--minutes 0 should fail")
+	}
+}
+
+func TestReadSeenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seen.txt")
+	if seen, err := readSeenFile(path); err != nil || len(seen) != 0 {
+		t.Fatalf("missing file: got %v, %v; want nothing seen", seen, err)
+	}
+	for _, name := range []string{"Slussen", "Gamla stan"} {
+		if err := appendSeen(path, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	seen, err := readSeenFile(path)
+	if err != nil || !seen["slussen"] || !seen["gamla stan"] || len(seen) != 2 {
+		t.Errorf("got %v, %v; want slussen and gamla stan", seen, err)
+	}
+}
+
+func TestCLI_CacheClear(t *testing.T) {
+	fake := apitest.New(t)
+
+	if _, err := runCLI(t, "search", "Medborg", "--json"); err != nil {
+		t.Fatal(err)
+	}
+	out, err := runCLI(t, "cache", "clear", "--json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result struct {
+		Removed int `json:"removed"`
+	}
+	if err := json.Unmarshal([]byte(out), &result); err != nil || result.Removed == 0 {
+		t.Fatalf("expected cached files to be removed, got %q (%v)", out, err)
+	}
+
+	if _, err := runCLI(t, "search", "Medborg", "--json"); err != nil {
+		t.Fatal(err)
+	}
+	if n := fake.RequestCount("/transport/v1/sites"); n != 2 {
+		t.Errorf("sites fetched %d times, want 2 (once before and once after clearing)", n)
+	}
+}
+
+func TestCLI_Bookmarks(t *testing.T) {
+	apitest.New(t)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	if _, err := runCLI(t, "bookmark", "add", "home", "9191"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runCLI(t, "bookmark", "add", "55", "Slussen"); err == nil {
+		t.Error("a numeric bookmark name should be rejected")
+	}
+
+	out, err := runCLI(t, "bookmark", "list", "--json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var list []bookmark
+	if err := json.Unmarshal([]byte(out), &list); err != nil || len(list) != 1 || list[0] != (bookmark{"home", "9191"}) {
+		t.Fatalf("bookmark list = %s (%v)", out, err)
+	}
+
+	out, err = runCLI(t, "departures", "home", "--no-deviations", "--json")
+	if err != nil {
+		t.Fatalf("departures home: %v", err)
+	}
+	var result departureResult
+	if err := json.Unmarshal([]byte(out), &result); err != nil || result.SiteID != 9191 {
+		t.Errorf("departures home resolved to site %d (%v), want 9191", result.SiteID, err)
+	}
+
+	if _, err := runCLI(t, "bookmark", "remove", "HOME"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runCLI(t, "bookmark", "remove", "home"); err == nil {
+		t.Error("removing a missing bookmark should fail")
+	}
+}
+
+func TestCLI_AutoPicksFavorite(t *testing.T) {
+	apitest.New(t)
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	os.MkdirAll(filepath.Join(dir, "sl-cli"), 0o755)
+	os.WriteFile(filepath.Join(dir, "sl-cli", "config.json"), []byte(`{"favorites": {"home": "Medborgarplatsen", "work": "9001"}}`), 0o644)
+
+	siteFor := func(args ...string) int {
+		t.Helper()
+		out, err := runCLI(t, append([]string{"auto", "--no-deviations", "--json"}, args...)...)
+		if err != nil {
+			t.Fatalf("auto failed: %v", err)
+		}
+		var result departureResult
+		if err := json.Unmarshal([]byte(out), &result); err != nil {
+			t.Fatalf("invalid JSON output: %v\n%s", err, out)
+		}
+		return result.SiteID
+	}
+
+	if got := siteFor("--near", "59.3145,18.0733"); got != 9191 {
+		t.Errorf("near home: got site %d, want 9191 (Medborgarplatsen)", got)
+	}
+	// No favorite within 300 m of Timmermansgränd: fall back to the nearest stop.
+	if got := siteFor("--near", "59.31869,18.0667", "--radius", "0.3"); got != 1080 {
+		t.Errorf("away from favorites: got site %d, want 1080 (Timmermansgränd)", got)
+	}
+}
+
+func TestCLI_TripSpec(t *testing.T) {
+	fake := apitest.New(t)
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	os.MkdirAll(filepath.Join(dir, "sl-cli"), 0o755)
+	os.WriteFile(filepath.Join(dir, "sl-cli", "config.json"), []byte(`{"favorites": {"home": "Medborgarplatsen"}}`), 0o644)
+
+	if _, err := runCLI(t, "trip", "home..59.3326,18.0649@08:15", "--json"); err != nil {
+		t.Fatalf("trip failed: %v", err)
+	}
+
+	var planned string
+	for _, r := range fake.Requests {
+		if strings.HasPrefix(r, "/planner/v2/trips") {
+			planned = r
+		}
+	}
+	u, err := url.Parse(planned)
+	if err != nil || planned == "" {
+		t.Fatalf("no trips request in %v", fake.Requests)
+	}
+	q := u.Query()
+	if q.Get("type_destination") != "coord" || q.Get("name_destination") != "18.064900:59.332600:WGS84[dd.ddddd]" {
+		t.Errorf("destination not sent as coordinates: %s", planned)
+	}
+	if q.Get("type_origin") != "any" || q.Get("name_origin") == "home" {
+		t.Errorf("favorite origin not expanded: %s", planned)
+	}
+	if q.Get("itd_time") != "0815" || q.Get("itd_trip_date_time_dep_arr") != "dep" {
+		t.Errorf("departure time not sent: %s", planned)
+	}
+}
+
+func TestCLI_TripWheelchair(t *testing.T) {
+	fake := apitest.New(t)
+	fake.Deviations = append(fake.Deviations, sl.Deviation{
+		DeviationCaseID: 2001,
+		Scope:           &sl.DeviationScope{StopAreas: []sl.DeviationStopArea{{ID: 10011, Name: "T-Centralen"}}},
+		MessageVariants: []sl.MessageVariant{{Language: "en", Header: "Elevator to the blue line out of service"}},
+	})
+
+	out, err := runCLI(t, "trip", "Medborgarplatsen..T-Centralen", "--wheelchair")
+	if err != nil {
+		t.Fatalf("trip failed: %v", err)
+	}
+	if !strings.Contains(out, "Elevator out of service at T-Centralen: Elevator to the blue line out of service") {
+		t.Errorf("expected an elevator warning, got:\n%s", out)
+	}
+
+	out, err = runCLI(t, "trip", "Medborgarplatsen..T-Centralen", "--wheelchair", "--json")
+	if err != nil {
+		t.Fatalf("trip --json failed: %v", err)
+	}
+	var result tripResult
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if got := result.Journeys[0].ElevatorOutages; len(got) != 1 || got[0].DeviationID != 2001 {
+		t.Errorf("expected the outage in JSON, got %+v", got)
+	}
+	for _, r := range fake.Requests {
+		if strings.HasPrefix(r, "/planner/v2/trips") && !strings.Contains(r, "noSolidStairs=1") {
+			t.Errorf("step-free routing not requested: %s", r)
+		}
+	}
+}
+
+func TestCLI_TripAccessibility(t *testing.T) {
+	fake := apitest.New(t)
+	fake.Journeys.Journeys[0].Legs[0].Origin.Properties = map[string]any{"wheelchairAccess": "true", "elevator": "false"}
+
+	out, err := runCLI(t, "trip", "Medborgarplatsen..T-Centralen", "--no-escalators", "--low-floor-only")
+	if err != nil {
+		t.Fatalf("trip failed: %v", err)
+	}
+	if !strings.Contains(out, "♿ Medborgarplatsen: step-free, no elevator") {
+		t.Errorf("expected the origin's accessibility, got:\n%s", out)
+	}
+	for _, r := range fake.Requests {
+		if !strings.HasPrefix(r, "/planner/v2/trips") {
+			continue
+		}
+		q, _ := url.ParseQuery(r[strings.Index(r, "?")+1:])
+		if q.Get("noEscalators") != "1" || q.Get("lowPlatformVhcl") != "1" || q.Get("imparedOptionsActive") != "1" {
+			t.Errorf("accessibility options not sent: %s", r)
+		}
+	}
+}
+
+func TestCLI_TripAtNamedTime(t *testing.T) {
+	fake := apitest.New(t)
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	os.MkdirAll(filepath.Join(dir, "sl-cli"), 0o755)
+	os.WriteFile(filepath.Join(dir, "sl-cli", "config.json"), []byte(`{"times": {"school-run": "Mon-Fri 07:40"}}`), 0o644)
+
+	if _, err := runCLI(t, "trip", "Medborgarplatsen..T-Centralen", "--at", "@school-run", "--json"); err != nil {
+		t.Fatalf("trip failed: %v", err)
+	}
+	var planned string
+	for _, r := range fake.Requests {
+		if strings.HasPrefix(r, "/planner/v2/trips") {
+			planned = r
+		}
+	}
+	u, err := url.Parse(planned)
+	if err != nil || planned == "" {
+		t.Fatalf("no trips request in %v", fake.Requests)
+	}
+	q := u.Query()
+	day, err := time.Parse("20060102", q.Get("itd_date"))
+	if err != nil || day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+		t.Errorf("school-run should fall on a weekday, got itd_date %q", q.Get("itd_date"))
+	}
+	if q.Get("itd_time") != "0740" {
+		t.Errorf("itd_time = %q, want 0740", q.Get("itd_time"))
+	}
+
+	if _, err := runCLI(t, "trip", "Medborgarplatsen..T-Centralen", "--at", "@gym"); err == nil {
+		t.Error("an undefined named time should fail")
+	}
+}
+
+func TestCLI_TripFollow(t *testing.T) {
+	apitest.New(t)
+
+	// The fixture journey lies in the past, so following it ends at once.
+	out, err := runCLI(t, "trip", "--from", "Medborgarplatsen", "--to", "T-Centralen", "--follow", "--json")
+	if err != nil {
+		t.Fatalf("trip --follow failed: %v", err)
+	}
+	var st sl.FollowStatus
+	if err := json.Unmarshal([]byte(out), &st); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if st.Phase != sl.PhaseArrived {
+		t.Errorf("phase = %q, want arrived", st.Phase)
+	}
+
+	if _, err := runCLI(t, "trip", "--from", "Medborgarplatsen", "--to", "T-Centralen", "--select", "9"); err == nil {
+		t.Error("expected an error selecting a missing itinerary")
+	}
+}
+
+func TestCLI_SearchJSON(t *testing.T) {
+	apitest.New(t)
+
+	out, err := runCLI(t, "search", "medborg", "--json")
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+
+	var results []siteResult
+	if err := json.Unmarshal([]byte(out), &results); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if len(results) != 1 || results[0].ID != 9191 {
+		t.Fatalf("expected Medborgarplatsen, got %+v", results)
+	}
+	if len(results[0].Types) != 2 {
+		t.Errorf("expected METROSTN and BUSTERM types, got %v", results[0].Types)
+	}
+}
+
+func TestCLI_ListSchemaVersionOutOfBand(t *testing.T) {
+	apitest.New(t)
+
+	// List results are plain arrays; their version comes from sl version.
+	out, err := runCLI(t, "lines", "--json")
+	if err != nil {
+		t.Fatalf("lines failed: %v", err)
+	}
+	var lines []sl.Line
+	if err := json.Unmarshal([]byte(out), &lines); err != nil || len(lines) == 0 {
+		t.Fatalf("want a JSON array of lines, got %v\n%s", err, out)
+	}
+
+	out, err = runCLI(t, "version", "--json")
+	if err != nil {
+		t.Fatalf("version failed: %v", err)
+	}
+	var info versionInfo
+	if err := json.Unmarshal([]byte(out), &info); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if info.CurrentSchemaVersion != format.SchemaVersion || info.OldestSchemaVersion != format.OldestSchemaVersion {
+		t.Errorf("sl version reports schema %d (oldest %d), want %d (oldest %d)",
+			info.CurrentSchemaVersion, info.OldestSchemaVersion, format.SchemaVersion, format.OldestSchemaVersion)
+	}
+}
+
+func TestCLI_SearchLoadsStopTypesOnlyForMatches(t *testing.T) {
+	apitest.New(t)
+	fake := http.DefaultTransport
+	stopPoints := 0
+	http.DefaultTransport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if strings.HasSuffix(r.URL.Path, "/stop-points") {
+			stopPoints++
+		}
+		return fake.RoundTrip(r)
+	})
+	t.Cleanup(func() { http.DefaultTransport = fake })
+
+	if _, err := runCLI(t, "search", "no such stop", "--json"); err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if stopPoints != 0 {
+		t.Errorf("search without matches fetched stop points %d time(s)", stopPoints)
+	}
+	if _, err := runCLI(t, "search", "medborg", "--json"); err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if stopPoints != 1 {
+		t.Errorf("search with a match fetched stop points %d time(s), want 1", stopPoints)
+	}
+}
+
+func TestCLI_DeviationsByLine(t *testing.T) {
+	apitest.New(t)
+
+	out, err := runCLI(t, "deviations", "--line", "55", "--json")
+	if err != nil {
+		t.Fatalf("deviations failed: %v", err)
+	}
+
+	var devs []struct {
+		DeviationCaseID int    `json:"deviation_case_id"`
+		Severity        string `json:"severity"`
+	}
+	if err := json.Unmarshal([]byte(out), &devs); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if len(devs) != 1 || devs[0].DeviationCaseID != 1001 {
+		t.Fatalf("expected deviation 1001, got %+v", devs)
+	}
+	if devs[0].Severity != "minor" {
+		t.Errorf("severity = %q, want minor", devs[0].Severity)
+	}
+}
+
+func TestCLI_NearbyByMode(t *testing.T) {
+	apitest.New(t)
+
+	out, err := runCLI(t, "nearby", "--lat", "59.3143", "--lon", "18.0735", "--radius", "1", "--mode", "TRAIN", "--json")
+	if err != nil {
+		t.Fatalf("nearby failed: %v", err)
+	}
+
+	var stops []struct {
+		Site struct {
+			ID int `json:"id"`
+		} `json:"site"`
+	}
+	if err := json.Unmarshal([]byte(out), &stops); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if len(stops) != 1 || stops[0].Site.ID != 9530 {
+		t.Errorf("expected only Stockholms södra, got %+v", stops)
+	}
+}
+
+func TestCLI_NearbyClusterOnlyOnRequestInJSON(t *testing.T) {
+	f := apitest.New(t)
+	// A second stop 10 m from Medborgarplatsen, part of the same interchange.
+	med := f.Sites[slices.IndexFunc(f.Sites, func(s sl.Site) bool { return s.ID == 9191 })]
+	f.Sites = append(f.Sites, sl.Site{ID: 9192, Name: "Medborgarplatsen buss", Lat: med.Lat + 0.0001, Lon: med.Lon})
+
+	ids := func(args ...string) []int {
+		t.Helper()
+		out, err := runCLI(t, append([]string{"nearby", "--lat", "59.3143", "--lon", "18.0735", "--radius", "0.03", "--json"}, args...)...)
+		if err != nil {
+			t.Fatalf("nearby failed: %v", err)
+		}
+		var stops []sl.SiteWithDistance
+		if err := json.Unmarshal([]byte(out), &stops); err != nil {
+			t.Fatalf("invalid JSON output: %v\n%s", err, out)
+		}
+		var got []int
+		for _, s := range stops {
+			got = append(got, s.Site.ID)
+			for _, m := range s.Colocated {
+				got = append(got, -m.Site.ID)
+			}
+		}
+		return got
+	}
+	if got := ids(); !slices.Equal(got, []int{9191, 9192}) {
+		t.Errorf("--json: stops %v, want both listed on their own", got)
+	}
+	if got := ids("--cluster"); !slices.Equal(got, []int{9191, -9192}) {
+		t.Errorf("--json --cluster: stops %v, want 9192 under 9191's colocated", got)
+	}
+}
+
+func TestCLI_NearbyStdin(t *testing.T) {
+	apitest.New(t)
+	rootCmd.SetIn(strings.NewReader(`# from gpsd
+59.3143,18.0735
+59.3143,18.0736
+not a position
+{"class":"SKY"}
+{"class":"TPV","lat":59.3143,"lon":18.0735}
+`))
+	t.Cleanup(func() { rootCmd.SetIn(nil) })
+
+	out, err := runCLI(t, "nearby", "--stdin", "--radius", "1", "--mode", "TRAIN")
+	if err != nil {
+		t.Fatalf("nearby --stdin failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected one update for positions a few metres apart, got %d:\n%s", len(lines), out)
+	}
+	var update struct {
+		Lat   float64 `json:"lat"`
+		Stops []struct {
+			Site struct {
+				ID int `json:"id"`
+			} `json:"site"`
+		} `json:"stops"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &update); err != nil {
+		t.Fatalf("invalid NDJSON line: %v\n%s", err, lines[0])
+	}
+	if update.Lat != 59.3143 || len(update.Stops) != 1 || update.Stops[0].Site.ID != 9530 {
+		t.Errorf("expected Stockholms södra near the first position, got %+v", update)
+	}
+
+	if _, err := runCLI(t, "nearby", "--stdin", "--lat", "59.3", "--lon", "18.0"); err == nil {
+		t.Error("expected --stdin with --lat to fail")
+	}
+}
+
+func TestCLI_StopInfoDeviations(t *testing.T) {
+	apitest.New(t)
+
+	out, err := runCLI(t, "stop-info", "--site", "9191", "--json")
+	if err != nil {
+		t.Fatalf("stop-info failed: %v", err)
+	}
+
+	var result stopInfoResult
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if len(result.Lines) != 3 {
+		t.Errorf("expected lines 19, 17 and 55, got %+v", result.Lines)
+	}
+	if len(result.Deviations) != 1 || result.Deviations[0].Header != "Replacement buses between Skanstull and Gullmarsplan" {
+		t.Errorf("expected the site-scoped deviation, got %+v", result.Deviations)
+	}
+}
+
+func TestCLI_ZonesByStop(t *testing.T) {
+	apitest.New(t)
+
+	out, err := runCLI(t, "zones", "--stop", "Medborgarplatsen", "--json")
+	if err != nil {
+		t.Fatalf("zones failed: %v", err)
+	}
+
+	var result stopZonesResult
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if result.SiteID != 9191 || len(result.Zones) != 1 || result.Zones[0] != "SL" || result.Supplement {
+		t.Errorf("expected Medborgarplatsen in zone SL only, got %+v", result)
+	}
+}
+
+func TestCLI_DeparturesCatchable(t *testing.T) {
+	apitest.New(t)
+
+	out, err := runCLI(t, "departures", "--site", "9191", "--line", "17", "--walk", "6m", "--no-deviations", "--json")
+	if err != nil {
+		t.Fatalf("departures failed: %v", err)
+	}
+
+	var result departureResult
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	// The first line 17 departure is ~7 minutes away: only just reachable.
+	if got := result.Departures[0].Catchable; got != sl.CatchMarginal {
+		t.Errorf("first departure catchable = %v, want marginal", got)
+	}
+}
+
+func TestCLI_Corridor(t *testing.T) {
+	apitest.New(t)
+
+	// Timmermansgränd lies about 200 m west of the straight line.
+	out, err := runCLI(t, "corridor", "--from", "59.3143,18.0735", "--to", "59.3311,18.0602", "--width", "100", "--json")
+	if err != nil {
+		t.Fatalf("corridor failed: %v", err)
+	}
+
+	var stops []format.CorridorStop
+	if err := json.Unmarshal([]byte(out), &stops); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if len(stops) == 0 || stops[len(stops)-1].SiteID != 9001 {
+		t.Fatalf("expected T-Centralen last, got %+v", stops)
+	}
+	for _, s := range stops {
+		switch s.SiteID {
+		case 1080:
+			t.Errorf("Timmermansgränd is outside the corridor but was listed")
+		case 9191:
+			if len(s.Lines) == 0 {
+				t.Error("expected lines for Medborgarplatsen")
+			}
+		}
+	}
+}
+
+func TestCLI_APIDownEnvelope(t *testing.T) {
+	apitest.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("<html><head><title>Maintenance</title></head></html>"))
+	}))
+	defer srv.Close()
+	// Send the Transport API to srv, everything else to the fake.
+	transportURL, _ := url.Parse(sl.TransportBaseURL)
+	srvURL, _ := url.Parse(srv.URL)
+	fake := http.DefaultTransport
+	http.DefaultTransport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Host == transportURL.Host {
+			r = r.Clone(r.Context())
+			r.URL.Scheme, r.URL.Host = srvURL.Scheme, srvURL.Host
+		}
+		return fake.RoundTrip(r)
+	})
+	t.Cleanup(func() { http.DefaultTransport = fake })
+
+	_, err := runCLI(t, "departures", "--site", "9191", "--json")
+	if err == nil {
+		t.Fatal("expected an error while the API is down")
+	}
+	env := newErrorEnvelope(err)
+	if env.APIStatus != "down" || env.DownSince == "" || env.Attempts != 1+sl.DefaultRetries || env.Error.Code != sl.CodeAPIUnavailable {
+		t.Errorf("unexpected envelope: %+v", env)
+	}
+	if strings.Contains(env.Error.Message, "<html") {
+		t.Errorf("raw HTML leaked into the error: %q", env.Error.Message)
+	}
+}
+
+func TestCLI_DeparturesFailureAbandonsDeviations(t *testing.T) {
+	apitest.New(t)
+	transportURL, _ := url.Parse(sl.TransportBaseURL)
+	deviationsURL, _ := url.Parse(sl.DeviationsBaseURL)
+	fake := http.DefaultTransport
+	http.DefaultTransport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		switch r.URL.Host {
+		case transportURL.Host:
+			return &http.Response{StatusCode: http.StatusBadRequest, Body: io.NopCloser(strings.NewReader("bad request")), Request: r}, nil
+		case deviationsURL.Host:
+			// Answers only once the command gives up on it.
+			<-r.Context().Done()
+			return nil, r.Context().Err()
+		}
+		return fake.RoundTrip(r)
+	})
+	t.Cleanup(func() { http.DefaultTransport = fake })
+
+	for _, args := range [][]string{{"departures", "--site", "9191"}, {"stop-info", "--site", "9191"}} {
+		done := make(chan error, 1)
+		go func() {
+			_, err := runCLI(t, args...)
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			if err == nil {
+				t.Errorf("sl %s: expected the departures error", strings.Join(args, " "))
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("sl %s: still waiting for deviations after departures failed", strings.Join(args, " "))
+		}
+	}
+}
+
+func TestCLI_WatchdogJSON(t *testing.T) {
+	apitest.New(t)
+
+	out, err := runCLI(t, "watchdog", "--count", "1", "--json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var seen []string
+	dec := json.NewDecoder(strings.NewReader(out))
+	for dec.More() {
+		var r sl.ProbeResult
+		if err := dec.Decode(&r); err != nil {
+			t.Fatalf("invalid NDJSON: %v\n%s", err, out)
+		}
+		if r.Status != sl.ProbeUp {
+			t.Errorf("%s: %s (%s)", r.Endpoint, r.Status, r.Error)
+		}
+		seen = append(seen, r.Endpoint)
+	}
+	if len(seen) != 3 {
+		t.Fatalf("expected three endpoints, got %v", seen)
+	}
+
+	out, err = runCLI(t, "watchdog", "--report", "--json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stats []sl.UptimeStats
+	if err := json.Unmarshal([]byte(out), &stats); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if len(stats) != 3 || stats[0].UptimePct != 100 || stats[0].Probes != 1 {
+		t.Errorf("unexpected report: %+v", stats)
+	}
+}
+
+func TestCLI_Where(t *testing.T) {
+	apitest.New(t)
+
+	out, err := runCLI(t, "where", "17", "--site", "9191", "--json")
+	if err != nil {
+		t.Fatalf("where failed: %v", err)
+	}
+	var result whereResult
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if result.Stop != "Medborgarplatsen" || len(result.Runs) != 2 {
+		t.Fatalf("got %+v, want 2 runs of line 17 at Medborgarplatsen", result)
+	}
+	for _, r := range result.Runs {
+		if r.Line != "17" || r.NextSiteID != 9191 {
+			t.Errorf("run %+v: want line 17 heading to 9191", r)
+		}
+	}
+
+	out, err = runCLI(t, "where", "17", "2024030100017003", "--site", "9191", "--json")
+	if err != nil {
+		t.Fatalf("where with journey ID failed: %v", err)
+	}
+	result = whereResult{}
+	json.Unmarshal([]byte(out), &result)
+	if len(result.Runs) != 1 || result.Runs[0].Destination != "Skarpnäck" {
+		t.Errorf("selecting a journey: got %+v, want the Skarpnäck run", result.Runs)
+	}
+
+	if _, err := runCLI(t, "where", "17", "23:59", "--site", "9191"); err == nil {
+		t.Error("expected an error for a run that doesn't exist")
+	}
+}
+
+func TestCLI_CompareLocations(t *testing.T) {
+	fake := apitest.New(t)
+
+	out, err := runCLI(t, "compare-locations", "--to", "T-Centralen", "Medborgarplatsen", "59.3121,18.0643", "--json")
+	if err != nil {
+		t.Fatalf("compare-locations failed: %v", err)
+	}
+	var result compareResult
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if result.To != "T-Centralen" || len(result.Commutes) != 2 {
+		t.Fatalf("got %+v, want 2 commutes to T-Centralen", result)
+	}
+	for _, c := range result.Commutes {
+		if c.Err != "" || c.Minutes != 10 {
+			t.Errorf("commute %+v: want a 10 min journey", c)
+		}
+	}
+
+	planned := 0
+	for _, r := range fake.Requests {
+		if strings.HasPrefix(r, "/planner/v2/trips") {
+			planned++
+		}
+	}
+	if planned != 2 {
+		t.Errorf("planned %d trips, want one per location", planned)
+	}
+}
+
+func TestCLI_NoEmoji(t *testing.T) {
+	apitest.New(t)
+
+	out, err := runCLI(t, "search", "medborg", "--no-emoji")
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if strings.Contains(out, "─") || !strings.Contains(out, "----") {
+		t.Errorf("expected an ASCII separator with --no-emoji:\n%s", out)
+	}
+}
+
+func TestCLI_CompleteStops(t *testing.T) {
+	apitest.New(t)
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	os.MkdirAll(filepath.Join(dir, "sl-cli"), 0o755)
+	os.WriteFile(filepath.Join(dir, "sl-cli", "config.json"), []byte(`{"favorites": {"medis": "9191"}}`), 0o644)
+
+	out, err := runCLI(t, "__complete", "trip", "--from", "med")
+	if err != nil {
+		t.Fatalf("completion failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 || lines[0] != "medis" || lines[1] != "Medborgarplatsen" || lines[2] != ":4" {
+		t.Errorf("got completions %q, want the favorite, then Medborgarplatsen, without file completion", lines)
+	}
+}
+
+func TestCLI_CompletionInstall(t *testing.T) {
+	apitest.New(t)
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	out, err := runCLI(t, "completion", "install", "--shell", "fish", "--json")
+	if err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+	var result struct {
+		Path  string `json:"path"`
+		Stops int    `json:"stops_cached"`
+	}
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if want := filepath.Join(dir, "fish", "completions", "sl.fish"); result.Path != want {
+		t.Errorf("installed to %s, want %s", result.Path, want)
+	}
+	script, err := os.ReadFile(result.Path)
+	if err != nil || !strings.Contains(string(script), "complete -c sl") {
+		t.Errorf("no fish completion script written: %v", err)
+	}
+	if result.Stops == 0 {
+		t.Error("stop names were not cached")
+	}
+}
+
+func TestCLI_CompletionInstallUnknownShell(t *testing.T) {
+	t.Setenv("SHELL", "/bin/tcsh")
+	if _, err := runCLI(t, "completion", "install"); err == nil || !strings.Contains(err.Error(), "tcsh") {
+		t.Errorf("expected an error naming tcsh, got %v", err)
+	}
+}
+
+func TestCLI_Hyperlinks(t *testing.T) {
+	apitest.New(t)
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	os.MkdirAll(filepath.Join(dir, "sl-cli"), 0o755)
+	os.WriteFile(filepath.Join(dir, "sl-cli", "config.json"), []byte(`{"hyperlinks": true}`), 0o644)
+	t.Setenv("FORCE_HYPERLINK", "")
+	t.Cleanup(func() { format.SetHyperlinks(false) })
+
+	out, err := runCLI(t, "search", "medborg")
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if !strings.Contains(out, "\x1b]8;;https://www.openstreetmap.org/") {
+		t.Errorf("expected stop names linked to the map with hyperlinks on in the config:\n%q", out)
+	}
+}
+
+func TestCLI_Color(t *testing.T) {
+	apitest.New(t)
+	t.Setenv("CLICOLOR_FORCE", "")
+	t.Cleanup(func() { format.SetColor("auto") })
+
+	out, err := runCLI(t, "departures", "--site", "9191", "--no-deviations", "--color", "always")
+	if err != nil {
+		t.Fatalf("departures failed: %v", err)
+	}
+	if !strings.Contains(out, "\x1b[") {
+		t.Errorf("expected colored output with --color always:\n%q", out)
+	}
+
+	t.Setenv("CLICOLOR_FORCE", "1")
+	out, err = runCLI(t, "departures", "--site", "9191", "--no-deviations", "--color", "never")
+	if err != nil {
+		t.Fatalf("departures failed: %v", err)
+	}
+	if strings.Contains(out, "\x1b[") {
+		t.Errorf("expected no color with --color never:\n%q", out)
+	}
+
+	if _, err := runCLI(t, "departures", "--site", "9191", "--color", "sometimes"); err == nil {
+		t.Error("expected an unknown --color to fail")
+	}
+}
+
+func TestCLI_Station(t *testing.T) {
+	apitest.New(t)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir()) // no timetable key: departures only
+
+	out, err := runCLI(t, "station", "--site", "9191", "--format", "csv")
+	if err != nil {
+		t.Fatalf("station --format csv failed: %v", err)
+	}
+	if !strings.HasPrefix(out, "kind,time,line,mode,from_to,track,delay_min,state\ndeparture,") {
+		t.Errorf("unexpected CSV:\n%s", out)
+	}
+
+	out, err = runCLI(t, "station", "--site", "9191", "--mode", "METRO", "--format", "", "--json")
+	if err != nil {
+		t.Fatalf("station failed: %v", err)
+	}
+	var result stationResult
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if result.Stop != "Medborgarplatsen" || len(result.Arrivals) != 0 || len(result.Departures) == 0 {
+		t.Fatalf("got %q with %d arrivals and %d departures, want Medborgarplatsen departures only", result.Stop, len(result.Arrivals), len(result.Departures))
+	}
+	for _, d := range result.Departures {
+		if d.TransportMode != "METRO" {
+			t.Errorf("--mode METRO kept a %s departure", d.TransportMode)
+		}
+	}
+}
+
+func TestCLI_Smoke(t *testing.T) {
+	apitest.New(t)
+
+	if _, err := runCLI(t, "smoke"); err == nil {
+		t.Fatal("expected smoke without --live to refuse")
+	}
+
+	out, err := runCLI(t, "smoke", "--live", "--pause", "0", "--json")
+	if err != nil {
+		t.Fatalf("smoke failed: %v\n%s", err, out)
+	}
+	var results []sl.SmokeResult
+	if err := json.Unmarshal([]byte(out), &results); err != nil {
+		t.Fatalf("parsing output: %v\n%s", err, out)
+	}
+	if len(results) != len(sl.NewClient().SmokeChecks()) {
+		t.Fatalf("got %d results, want one per check", len(results))
+	}
+	for _, r := range results {
+		if r.Status != "ok" {
+			t.Errorf("%s: status %s against the fake: %+v", r.Name, r.Status, r)
+		}
+	}
+}
+
+func TestCLI_FormatCSV(t *testing.T) {
+	apitest.New(t)
+
+	out, err := runCLI(t, "departures", "--site", "9191", "--format", "csv", "--no-deviations")
+	if err != nil {
+		t.Fatalf("departures failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) < 2 || !strings.HasPrefix(lines[0], "stop,line,mode,destination,") {
+		t.Fatalf("expected a CSV header and rows:\n%s", out)
+	}
+	if !strings.HasPrefix(lines[1], "Medborgarplatsen,") {
+		t.Errorf("expected rows to start with the stop name:\n%s", out)
+	}
+}
+
+func TestCLI_FormatUnsupported(t *testing.T) {
+	apitest.New(t)
+
+	if _, err := runCLI(t, "search", "medborg", "--format", "csv"); err == nil || !strings.Contains(err.Error(), "doesn't support --format") {
+		t.Errorf("expected search to reject --format, got %v", err)
+	}
+	if _, err := runCLI(t, "lines", "--format", "xml"); err == nil || !strings.Contains(err.Error(), "table, csv, tsv") {
+		t.Errorf("expected lines to list its formats, got %v", err)
+	}
+}
+
+func TestCLI_Template(t *testing.T) {
+	apitest.New(t)
+
+	out, err := runCLI(t, "departures", "--site", "9191", "--no-deviations", "--template", "{{.Stop}} {{.Line}} {{.MinutesLeft}}m")
+	if err != nil {
+		t.Fatalf("departures failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) == 0 || !strings.HasPrefix(lines[0], "Medborgarplatsen ") || !strings.HasSuffix(lines[0], "m") {
+		t.Errorf("expected one templated line per departure:\n%s", out)
+	}
+
+	if _, err := runCLI(t, "lines", "--template", "{{.Designation}}", "--format", "csv"); err == nil {
+		t.Error("expected --template with --format to be rejected")
+	}
+}
+
+func TestCLI_RawExtrasNeedsJSON(t *testing.T) {
+	apitest.New(t)
+
+	if _, err := runCLI(t, "departures", "--site", "9191", "--raw-extras"); err == nil || !strings.Contains(err.Error(), "--json") {
+		t.Errorf("expected --raw-extras without --json to be rejected, got %v", err)
+	}
+	out, err := runCLI(t, "departures", "--site", "9191", "--raw-extras", "--json")
+	if err != nil {
+		t.Fatalf("departures failed: %v", err)
+	}
+	if strings.Contains(out, "raw_extras") {
+		t.Errorf("the fake only sends modeled fields, so there should be no extras:\n%s", out)
+	}
+}
+
+func TestCLI_TripGeoJSON(t *testing.T) {
+	fake := apitest.New(t)
+	fake.Journeys.Journeys[0].Legs[0].Coords = [][2]float64{{59.3143, 18.0736}, {59.3190, 18.0710}, {59.3314, 18.0597}}
+
+	out, err := runCLI(t, "trip", "Medborgarplatsen..T-Centralen", "--select", "1", "--format", "geojson", "--fresh")
+	if err != nil {
+		t.Fatalf("trip failed: %v", err)
+	}
+	var fc struct {
+		Type     string
+		Features []struct {
+			Geometry struct {
+				Type        string
+				Coordinates json.RawMessage
+			}
+			Properties map[string]any
+		}
+	}
+	if err := json.Unmarshal([]byte(out), &fc); err != nil {
+		t.Fatalf("invalid GeoJSON: %v\n%s", err, out)
+	}
+	if fc.Type != "FeatureCollection" || len(fc.Features) == 0 || fc.Features[0].Geometry.Type != "LineString" {
+		t.Fatalf("want a FeatureCollection starting with the first leg, got:\n%s", out)
+	}
+	var path [][2]float64
+	json.Unmarshal(fc.Features[0].Geometry.Coordinates, &path)
+	if len(path) != 3 || path[0] != [2]float64{18.0736, 59.3143} {
+		t.Errorf("first leg should follow the planner's path as [lon, lat], got %v", path)
+	}
+	for _, r := range fake.Requests {
+		if strings.HasPrefix(r, "/planner/v2/trips") && !strings.Contains(r, "gen_c=true") {
+			t.Errorf("leg coordinates not requested: %s", r)
+		}
+	}
+
+	if _, err := runCLI(t, "nearby", "--lat", "59.3143", "--lon", "18.0736", "--lines", "--format", "geojson"); err == nil {
+		t.Error("nearby --lines --format geojson should be rejected")
+	}
+}
+
+func TestCLI_TripPlatforms(t *testing.T) {
+	fake := apitest.New(t)
+	leg := &fake.Journeys.Journeys[0].Legs[0]
+	leg.Origin.Properties = map[string]any{"platformName": "2", "entranceName": "Götgatan"}
+	leg.Destination.Properties = map[string]any{"platformName": "1"}
+
+	out, err := runCLI(t, "trip", "Medborgarplatsen..T-Centralen", "--fresh")
+	if err != nil {
+		t.Fatalf("trip failed: %v", err)
+	}
+	if !strings.Contains(out, "Medborgarplatsen: platform 2, entrance Götgatan → T-Centralen: platform 1") {
+		t.Errorf("expected platform and entrance hints, got:\n%s", out)
+	}
+
+	out, err = runCLI(t, "trip", "Medborgarplatsen..T-Centralen", "--fresh", "--json")
+	if err != nil {
+		t.Fatalf("trip --json failed: %v", err)
+	}
+	if !strings.Contains(out, `"platform": "2"`) || !strings.Contains(out, `"entrance": "Götgatan"`) {
+		t.Errorf("expected platform and entrance in JSON, got:\n%s", out)
+	}
+}
+
+func TestCLI_ExplainResolution(t *testing.T) {
+	apitest.New(t)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	_, runErr := runCLI(t, "departures", "--stop", "medborgarplatsen", "--explain-resolution", "--json", "--no-deviations")
+	w.Close()
+	os.Stderr = stderr
+	if runErr != nil {
+		t.Fatalf("departures failed: %v", runErr)
+	}
+	data, _ := io.ReadAll(r)
+
+	var got resolutionExplanation
+	for line := range strings.Lines(string(data)) {
+		if strings.Contains(line, `"event":"resolution"`) {
+			if err := json.Unmarshal([]byte(line), &got); err != nil {
+				t.Fatalf("bad explanation %q: %v", line, err)
+			}
+		}
+	}
+	if got.Source != sourceSiteCache || got.Reason != "exact name match" || len(got.Candidates) == 0 ||
+		got.Candidates[0].Score != scoreExact || got.Candidates[0].ID != "9191" {
+		t.Errorf("unexpected explanation %+v in stderr:\n%s", got, data)
+	}
+}
+
+func TestSiteCandidates(t *testing.T) {
+	sites := []sl.Site{{ID: 1, Name: "Gamla stan"}, {ID: 2, Name: "Slussen"}, {ID: 3, Name: "Slussen bussterminal"}, {ID: 4, Name: "Skeppsbron/Slussen"}}
+	var got []string
+	for _, c := range siteCandidates(sites, "slussen") {
+		got = append(got, fmt.Sprintf("%s:%d", c.ID, c.Score))
+	}
+	if want := "[2:100 3:75 4:50]"; fmt.Sprint(got) != want {
+		t.Errorf("siteCandidates = %v, want %v", got, want)
+	}
+}
+
+func TestCLI_Commute(t *testing.T) {
+	apitest.New(t)
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	os.MkdirAll(filepath.Join(dir, "sl-cli"), 0o755)
+	os.WriteFile(filepath.Join(dir, "sl-cli", "config.json"), []byte(`{"commutes": {"morning": {"from": "Medborgarplatsen", "to": "T-Centralen", "lines": ["17"]}}}`), 0o644)
+
+	out, err := runCLI(t, "commute", "morning", "--json")
+	if err != nil {
+		t.Fatalf("commute failed: %v", err)
+	}
+	var result commuteResult
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if result.SiteID != 9191 || len(result.Departures) == 0 || len(result.Journeys) == 0 {
+		t.Fatalf("unexpected commute %+v", result)
+	}
+	for _, d := range result.Departures {
+		if d.Line != "17" {
+			t.Errorf("departure of line %s, want only line 17", d.Line)
+		}
+	}
+
+	if _, err := runCLI(t, "commute", "evening"); err == nil || !strings.Contains(err.Error(), "morning") {
+		t.Errorf("unknown commute: got %v, want an error listing morning", err)
+	}
+}
+
+func TestCLI_DeparturesMergeNearby(t *testing.T) {
+	apitest.New(t)
+
+	out, err := runCLI(t, "departures", "--address", "Medborgarplatsen", "--merge-nearby", "--no-deviations", "--json")
+	if err != nil {
+		t.Fatalf("departures --merge-nearby failed: %v", err)
+	}
+	var result mergedResult
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	sites := map[int]bool{}
+	for i, d := range result.Departures {
+		sites[d.SiteID] = true
+		if d.Stop == "" {
+			t.Errorf("departure %d has no stop", i)
+		}
+		if i > 0 && departureTime(d.ParsedDeparture).Before(departureTime(result.Departures[i-1].ParsedDeparture)) {
+			t.Errorf("departure %d (%s) is out of time order", i, d.Expected)
+		}
+	}
+	if !sites[9191] || !sites[1080] {
+		t.Errorf("want departures from Medborgarplatsen and Timmermansgränd, got sites %v", sites)
+	}
+
+	resetFlags()
+	if _, err := runCLI(t, "departures", "--site", "9191", "--merge-nearby"); err == nil {
+		t.Error("--merge-nearby without --address should fail")
+	}
+}
+
+func TestCLI_TripAvoidLine(t *testing.T) {
+	fake := apitest.New(t)
+
+	out, err := runCLI(t, "trip", "Medborgarplatsen..T-Centralen", "--avoid-line", "55", "--results", "2", "--fresh", "--json")
+	if err != nil {
+		t.Fatalf("trip failed: %v", err)
+	}
+	var result tripResult
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if len(result.Journeys) != 1 {
+		t.Errorf("got %d journeys, want the fixture's one (line 17)", len(result.Journeys))
+	}
+	asked := false
+	for _, r := range fake.Requests {
+		asked = asked || strings.Contains(r, "calc_number_of_trips=4")
+	}
+	if !asked {
+		t.Errorf("expected twice --results to be planned, got %v", fake.Requests)
+	}
+
+	resetFlags()
+	if _, err := runCLI(t, "trip", "Medborgarplatsen..T-Centralen", "--avoid-line", "17", "--fresh"); err == nil || !strings.Contains(err.Error(), "--avoid-line 17") {
+		t.Errorf("avoiding the only line: got %v, want an error", err)
+	}
+}
+
+func TestCLI_AmbiguousStopEnvelope(t *testing.T) {
+	apitest.New(t)
+
+	_, err := runCLI(t, "departures", "--stop", "entral", "--json")
+	if err == nil {
+		t.Fatal("expected an ambiguous stop error")
+	}
+	env := newErrorEnvelope(err)
+	if env.Error.Code != sl.CodeAmbiguousStop || len(env.Error.Candidates) != 2 {
+		t.Fatalf("unexpected envelope: %+v", env)
+	}
+	data, _ := format.Encode(env)
+	if want := `"candidates":[{"name":"T-Centralen","site_id":9001},{"name":"Centralen","site_id":1002}]`; !strings.Contains(string(data), want) {
+		t.Errorf("envelope %s lacks %s", data, want)
+	}
+
+	resetFlags()
+	_, err = runCLI(t, "departures", "--stop", "nowhere at all", "--json")
+	if code := newErrorEnvelope(err).Error.Code; code != sl.CodeNotFound {
+		t.Errorf("unknown stop: code %q, want %q", code, sl.CodeNotFound)
+	}
+
+	// Schema 1 keeps the message as "error".
+	format.SetSchemaVersion(1)
+	defer format.SetSchemaVersion(0)
+	data, _ = format.Encode(newErrorEnvelope(err))
+	if want := `{"schema_version":1,"error":"no stop found matching \"nowhere at all\""}`; string(data) != want {
+		t.Errorf("schema 1 envelope = %s, want %s", data, want)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	apitest.New(t)
+
+	exit := func(args ...string) int {
+		t.Helper()
+		resetFlags()
+		rootCmd.SetArgs(args)
+		return ExitCode(Execute())
+	}
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{[]string{"lines", "--no-deviations"}, ExitUsageError},
+		{[]string{"departures", "--stop", "nowhere at all"}, ExitNotFound},
+		{[]string{"departures", "--stop", "entral"}, ExitAmbiguous},
+		{[]string{"explain", "--from", "Medborgarplatsen", "--to", "T-Centralen", "--route", "99"}, ExitNotFound},
+		{[]string{"where", "17", "99999", "--site", "9191"}, ExitNotFound},
+		{[]string{"next"}, ExitUsageError}, // --from is required
+		{[]string{"departures"}, ExitUsageError},
+		{[]string{"nearby"}, ExitUsageError},
+		{[]string{"trip", "--from", "a"}, ExitUsageError},
+		{[]string{"deviations", "--min-severity", "huge"}, ExitUsageError},
+		{[]string{"lines", "--badge", "png"}, ExitUsageError},
+		{[]string{"trip", "--from", "a", "--to", "b", "--at", "25:00"}, ExitUsageError},
+	}
+	for _, tt := range tests {
+		if got := exit(tt.args...); got != tt.want {
+			t.Errorf("sl %s: exit %d, want %d", strings.Join(tt.args, " "), got, tt.want)
+		}
+	}
+	t.Cleanup(resetFlags)
+
+	if got := ExitCode(nil); got != ExitOK {
+		t.Errorf("success: exit %d, want %d", got, ExitOK)
+	}
+	if got := ExitCode(fmt.Errorf("fetching departures: %w", &sl.APIDownError{Host: "transport.integration.sl.se", StatusCode: 503})); got != ExitAPIError {
+		t.Errorf("API down: exit %d, want %d", got, ExitAPIError)
+	}
+	if got := ExitCode(&sl.RequestError{StatusCode: 400}); got != ExitAPIError {
+		t.Errorf("API rejected the request: exit %d, want %d", got, ExitAPIError)
+	}
+}
+
+// sandboxEnv fails the test on any request that doesn't go through the
+// sandbox, and points the user config and cache dirs at temporary ones
+// holding a config with a "home" favorite. It returns the config file and
+// cache dir.
+func sandboxEnv(t *testing.T) (configFile, cacheDir string) {
+	t.Helper()
+	prev := http.DefaultTransport
+	http.DefaultTransport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		t.Errorf("sandbox reached the network: %s", r.URL)
+		return nil, errors.New("offline")
+	})
+	sl.ResetCaches()
+	t.Cleanup(func() {
+		http.DefaultTransport = prev
+		sl.ResetCaches()
+		config.Use(nil)
+	})
+
+	cacheDir = t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheDir)
+	configDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+	configFile = filepath.Join(configDir, "sl-cli", "config.json")
+	os.MkdirAll(filepath.Dir(configFile), 0o755)
+	os.WriteFile(configFile, []byte(`{"favorites": {"home": "9530"}}`), 0o644)
+	return configFile, cacheDir
+}
+
+func TestCLI_Sandbox(t *testing.T) {
+	configFile, cacheDir := sandboxEnv(t)
+
+	out, err := runCLI(t, "--sandbox", "departures", "--site", "9191", "--no-deviations", "--json")
+	if err != nil {
+		t.Fatalf("sandboxed departures failed: %v", err)
+	}
+	var result departureResult
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if result.SiteID != 9191 || len(result.Departures) == 0 {
+		t.Fatalf("unexpected sandbox board %+v", result)
+	}
+
+	out, err = runCLI(t, "--sandbox", "bookmark", "list", "--json")
+	if err != nil {
+		t.Fatalf("sandboxed bookmark list failed: %v", err)
+	}
+	if strings.Contains(out, "9530") || !strings.Contains(out, "Medborgarplatsen") {
+		t.Errorf("sandbox didn't use its sample config: bookmarks %s", out)
+	}
+	if _, err := runCLI(t, "--sandbox", "bookmark", "add", "work", "9001"); err == nil {
+		t.Error("bookmark add succeeded in the sandbox")
+	}
+	if data, _ := os.ReadFile(configFile); strings.Contains(string(data), "work") {
+		t.Errorf("sandbox wrote the config file: %s", data)
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, "sl-cli", "static")); err == nil {
+		t.Error("sandbox wrote to the live cache")
+	}
+	if _, err := runCLI(t, "--sandbox", "line-stops", "999"); ExitCode(err) != ExitNotFound {
+		t.Errorf("unknown line: exit %d (%v), want %d", ExitCode(err), err, ExitNotFound)
+	}
+}
+
+func TestCLI_SandboxEveryCommand(t *testing.T) {
+	sandboxEnv(t)
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "log.csv")
+
+	// Every runnable command, in the order run, with arguments that work
+	// against the bundled data. export recordings reads the log written by
+	// departures --log-csv.
+	runs := []struct {
+		path string
+		args []string
+	}{
+		{"analyze frequency", []string{"--line", "17"}},
+		{"auto", []string{"--here"}},
+		{"bookmark list", nil},
+		{"commute", []string{"morning"}},
+		{"compare-locations", []string{"--to", "T-Centralen", "Medborgarplatsen", "Timmermansgränd"}},
+		{"completion bash", nil},
+		{"completion fish", nil},
+		{"completion powershell", nil},
+		{"completion zsh", nil},
+		{"corridor", []string{"--from", "Medborgarplatsen", "--to", "T-Centralen"}},
+		{"departures", []string{"--site", "9191"}},
+		{"departures", []string{"--site", "9191", "--log-csv", logFile}},
+		{"deviations", nil},
+		{"explain", []string{"--from", "Medborgarplatsen", "--to", "T-Centralen"}},
+		{"export graph", nil},
+		{"export recordings", []string{logFile, "-o", filepath.Join(dir, "log.parquet")}},
+		{"keys list", nil},
+		{"line-stops", []string{"17"}},
+		{"lines", nil},
+		{"nearby", []string{"--lat", "59.3143", "--lon", "18.0735"}},
+		{"next", []string{"17", "--from", "Medborgarplatsen"}},
+		{"prefetch", nil},
+		{"random", []string{"--from", "Medborgarplatsen"}},
+		{"reach", []string{"--from", "Medborgarplatsen"}},
+		{"search", []string{"Medborgarplatsen"}},
+		{"smoke", []string{"--live"}},
+		{"station", []string{"Medborgarplatsen"}},
+		{"stop-info", []string{"Medborgarplatsen"}},
+		{"trip", []string{"--from", "Medborgarplatsen", "--to", "T-Centralen"}},
+		{"vehicles", []string{"--line", "17"}},
+		{"vehicles", []string{"--near", "Medborgarplatsen"}},
+		{"version", nil},
+		{"watchdog", []string{"--count", "1"}},
+		{"where", []string{"17", "--site", "9191"}},
+		{"zones", nil},
+		{"cache clear", nil},
+	}
+	// Commands that write the user's settings are refused; serve runs
+	// until interrupted.
+	refused := map[string][]string{
+		"bookmark add":    {"work", "9001"},
+		"bookmark remove": {"home"},
+		"keys set":        {"trafiklab-static", "x"},
+		"keys remove":     {"trafiklab-static"},
+	}
+	skipped := []string{"serve", "completion install"}
+
+	covered := map[string]bool{}
+	for _, r := range runs {
+		covered[r.path] = true
+		args := append([]string{"--sandbox"}, strings.Fields(r.path)...)
+		if _, err := runCLI(t, append(args, r.args...)...); err != nil {
+			t.Errorf("sl %s %s: %v", r.path, strings.Join(r.args, " "), err)
+		}
+		resetFlags()
+	}
+	for path, rest := range refused {
+		covered[path] = true
+		args := append([]string{"--sandbox"}, strings.Fields(path)...)
+		_, err := runCLI(t, append(args, rest...)...)
+		if err == nil || !strings.Contains(err.Error(), "--sandbox doesn't allow") {
+			t.Errorf("sl %s: want refusal in the sandbox, got %v", path, err)
+		}
+		resetFlags()
+	}
+	for _, path := range skipped {
+		covered[path] = true
+	}
+
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		for _, sub := range c.Commands() {
+			walk(sub)
+		}
+		path := strings.TrimPrefix(c.CommandPath(), rootCmd.Name()+" ")
+		if c.Runnable() && !c.Hidden && c != rootCmd && path != "help" && !covered[path] {
+			t.Errorf("sl %s isn't run under --sandbox by this test", path)
+		}
+	}
+	walk(rootCmd)
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/glundgren93/sl-cli/internal/config"
+	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/internal/i18n"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+	"github.com/spf13/cobra"
+)
+
+var (
+	commuteLimit   int
+	commuteResults int
+)
+
+var commuteCmd = &cobra.Command{
+	Use:   "commute NAME",
+	Short: "Next departures, trip and disruptions for a saved commute",
+	Long: `Run a commute saved in the config file: the next departures you can
+still make from its stop, the trip to where you're going, and the
+disruptions on the lines involved, in one view.
+
+Commutes live under "commutes" in sl-cli/config.json in your user config
+directory:
+
+  {"commutes": {"morning": {"from": "home", "to": "Kista",
+                            "lines": ["14"], "towards": "Mörby centrum", "walk": "6m"}}}
+
+"from" is the stop you board at (a stop name, site ID or favorite) and
+"to" where you're going (also an address or "lat,lon"). With "lines" only
+those lines' departures are shown and trips using them come first;
+"towards" keeps departures heading that way. "walk" is your walk to the
+stop: departures you can't make are left out, and the trip is planned
+from when you get there.
+
+Examples:
+  sl commute morning
+  sl commute evening --limit 3 --json`,
+	Args: cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		cfg, err := config.Load()
+		if err != nil || len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		names := make([]string, 0, len(cfg.Commutes))
+		for name := range cfg.Commutes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names, cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: runCommute,
+}
+
+func init() {
+	commuteCmd.Flags().IntVar(&commuteLimit, "limit", 5, "Max departures to show")
+	commuteCmd.Flags().IntVar(&commuteResults, "results", 2, "Number of trip alternatives")
+
+	rootCmd.AddCommand(commuteCmd)
+}
+
+// commuteResult is the JSON output of sl commute.
+type commuteResult struct {
+	Name       string                    `json:"name"`
+	Stop       string                    `json:"stop"`
+	SiteID     int                       `json:"site_id"`
+	To         string                    `json:"to"`
+	Departures []sl.ParsedDeparture      `json:"departures"`
+	Journeys   []sl.JourneyTrip          `json:"journeys"`
+	Deviations []format.DeviationWarning `json:"deviations"`
+}
+
+func runCommute(cmd *cobra.Command, args []string) error {
+	if commuteLimit <= 0 || commuteResults <= 0 {
+		return usageErrorf("--limit and --results must be positive")
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	commute, err := cfg.Commute(args[0])
+	if err != nil {
+		return err
+	}
+	if commute.From == "" || commute.To == "" {
+		return fmt.Errorf("commute %q needs both \"from\" and \"to\"", args[0])
+	}
+	walk := time.Duration(commute.Walk)
+
+	ctx := context.Background()
+	client := newClient()
+
+	siteID, err := strconv.Atoi(commute.From)
+	if err != nil {
+		if siteID, err = resolveSiteID(ctx, client, commute.From); err != nil {
+			return err
+		}
+	}
+	destID, destName, err := resolveTripEndpoint(ctx, client, cfg, commute.To)
+	if err != nil {
+		return fmt.Errorf("resolving destination: %w", err)
+	}
+
+	resp, err := client.GetDepartures(ctx, sl.DepartureOptions{SiteID: siteID, DirectionText: commute.Towards})
+	if err != nil {
+		return fmt.Errorf("fetching departures: %w", err)
+	}
+	parsed := sl.ParseDepartures(resp.Departures)
+	stop := commute.From
+	if len(parsed) > 0 && parsed[0].StopArea != "" {
+		stop = parsed[0].StopArea
+	}
+	deps := viableDepartures(parsed, commute.Lines, walk, commuteLimit)
+
+	journeys := commuteJourneys(ctx, client, siteID, destID, commute, walk)
+	warnings := commuteDeviations(ctx, client, commute.Lines, deps, journeys)
+
+	if jsonOutput {
+		return format.JSON(commuteResult{
+			Name:       args[0],
+			Stop:       stop,
+			SiteID:     siteID,
+			To:         destName,
+			Departures: deps,
+			Journeys:   journeys,
+			Deviations: warnings,
+		})
+	}
+	fmt.Fprintf(format.Stderr(), "🧭 %s: %s → %s\n\n", args[0], stop, destName)
+	format.Departures(deps, stop, false)
+	format.Trips(journeys, sl.Carriage{})
+	format.DeviationWarnings(warnings)
+	return nil
+}
+
+// viableDepartures keeps the departures of lines, or of every line when
+// none are given, that aren't cancelled and can still be caught after
+// walk, up to limit.
+func viableDepartures(deps []sl.ParsedDeparture, lines []string, walk time.Duration, limit int) []sl.ParsedDeparture {
+	if walk > 0 {
+		sl.MarkCatchable(deps, walk)
+	}
+	viable := []sl.ParsedDeparture{}
+	for _, d := range deps {
+		if d.State == "CANCELLED" || d.Catchable == sl.CatchNo {
+			continue
+		}
+		if len(lines) > 0 && !slices.ContainsFunc(lines, func(l string) bool { return strings.EqualFold(l, d.Line) }) {
+			continue
+		}
+		viable = append(viable, d)
+		if len(viable) == limit {
+			break
+		}
+	}
+	return viable
+}
+
+// commuteJourneys plans the commute from its stop, leaving once the walk
+// there is done, with trips on the preferred lines first. A failed plan
+// is a warning: the departures are still worth showing.
+func commuteJourneys(ctx context.Context, client *sl.Client, siteID int, destID string, commute config.Commute, walk time.Duration) []sl.JourneyTrip {
+	opts := sl.TripOptions{
+		OriginID:   sl.PlannerStopID(siteID),
+		DestID:     destID,
+		NumTrips:   commuteResults,
+		Language:   i18n.Language(),
+		MaxChanges: -1,
+	}
+	if walk > 0 {
+		opts.DepartAt = sl.StockholmTime(time.Now()).Add(walk)
+	}
+	planTrip := client.PlanTripCached
+	if freshData {
+		planTrip = client.PlanTrip
+	}
+	resp, err := planTrip(ctx, opts)
+	if err == nil {
+		err = plannerError(resp)
+	}
+	if err != nil {
+		client.Warn(sl.WarnPartialResponse, "could not plan the trip: %v", err)
+		return []sl.JourneyTrip{}
+	}
+
+	journeys := resp.Journeys
+	sl.PreferLines(journeys, commute.Lines)
+	sl.MarkPlatforms(journeys)
+	return journeys
+}
+
+// commuteDeviations returns the disruptions on the preferred lines and on
+// every line the departures and journeys use.
+func commuteDeviations(ctx context.Context, client *sl.Client, lines []string, deps []sl.ParsedDeparture, journeys []sl.JourneyTrip) []format.DeviationWarning {
+	lineSet := map[string]bool{}
+	for _, l := range lines {
+		lineSet[l] = true
+	}
+	modeSet := map[string]bool{}
+	for _, d := range deps {
+		lineSet[d.Line] = true
+		modeSet[d.TransportMode] = true
+	}
+	for _, j := range journeys {
+		for _, leg := range j.Legs {
+			if leg.Transport != nil && leg.Transport.Number != "" {
+				lineSet[leg.Transport.Number] = true
+				modeSet[sl.LegMode(leg)] = true
+			}
+		}
+	}
+	delete(lineSet, "")
+
+	// The preferred lines' modes aren't known, nor those of legs LegMode
+	// can't classify; then deviations of every mode are needed.
+	var modes []string
+	if len(lines) == 0 && !modeSet[""] {
+		for m := range modeSet {
+			modes = append(modes, m)
+		}
+		sort.Strings(modes)
+	}
+	if len(lineSet) == 0 {
+		return []format.DeviationWarning{}
+	}
+	devs, err := client.GetDeviations(ctx, sl.DeviationOptions{TransportModes: modes})
+	if err != nil {
+		client.Warn(sl.WarnPartialDeviations, "could not fetch deviations: %v", err)
+		return []format.DeviationWarning{}
+	}
+	return lineDeviations(devs, lineSet)
+}
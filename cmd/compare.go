@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/glundgren93/sl-cli/internal/config"
+	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/internal/i18n"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+	"github.com/spf13/cobra"
+)
+
+var (
+	compareTo string
+	compareAt string
+)
+
+var compareCmd = &cobra.Command{
+	Use:   "compare-locations --to DEST LOCATION...",
+	Short: "Compare commutes from several places to one destination",
+	Long: `Plan the commute from each of several candidate locations to a common
+destination and rank them by door-to-door time, then by number of changes.
+Handy when apartment hunting: which of these addresses gets me to work
+quickest?
+
+Each location, like --to, can be a stop name, address, stop ID, "lat,lon"
+or a favorite from the config file. --at sets the departure time as for
+sl trip: "08:15", "Mon-Fri 07:40" or a named time like @commute; without it
+everyone leaves now.
+
+Examples:
+  sl compare-locations --to work "Hornsgatan 120" "Sankt Eriksgatan 50" "Götgatan 80"
+  sl compare-locations --to "T-Centralen" --at "Mon 08:00" Solna Sundbyberg Hägersten`,
+	Aliases:           []string{"compare"},
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeStops,
+	RunE:              runCompare,
+}
+
+func init() {
+	compareCmd.Flags().StringVar(&compareTo, "to", "", "Common destination (stop name, address, stop ID or favorite)")
+	compareCmd.Flags().StringVar(&compareAt, "at", "", `Leave at HH:MM, "tomorrow 07:40", "Mon-Fri 07:40" or a named time like @commute (default now)`)
+	compareCmd.MarkFlagRequired("to")
+
+	compareCmd.RegisterFlagCompletionFunc("to", completeStops)
+
+	rootCmd.AddCommand(compareCmd)
+}
+
+// compareResult wraps ranked commutes with metadata for JSON output.
+type compareResult struct {
+	To       string       `json:"to"`
+	DepartAt time.Time    `json:"depart_at,omitzero"`
+	Commutes []sl.Commute `json:"commutes"`
+}
+
+func runCompare(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	client := newClient()
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	var departAt time.Time
+	if compareAt != "" {
+		departAt, err = resolveAt(cfg, compareAt, sl.StockholmTime(time.Now()))
+		if err != nil {
+			return &usageError{err}
+		}
+	}
+
+	destID, destName, err := resolveTripEndpoint(ctx, client, cfg, compareTo)
+	if err != nil {
+		return fmt.Errorf("resolving destination: %w", err)
+	}
+
+	if !jsonOutput {
+		if departAt.IsZero() {
+			fmt.Fprintf(format.Stderr(), "📍 %d location(s) → %s\n\n", len(args), destName)
+		} else {
+			fmt.Fprintf(format.Stderr(), "📍 %d location(s) → %s, leaving %s\n\n", len(args), destName, departAt.Format("Mon 15:04"))
+		}
+	}
+
+	commutes := planCommutes(ctx, client, cfg, args, sl.TripOptions{
+		DestID:     destID,
+		NumTrips:   3,
+		Language:   i18n.Language(),
+		MaxChanges: -1,
+		DepartAt:   departAt,
+	})
+	sl.RankCommutes(commutes)
+
+	if jsonOutput {
+		return format.JSON(compareResult{To: destName, DepartAt: departAt, Commutes: commutes})
+	}
+	format.Commutes(destName, commutes)
+	return nil
+}
+
+// planCommutes plans a trip from each location concurrently, using base for
+// everything but the origin. A location that can't be resolved or routed
+// gets a commute carrying the error, so one bad address doesn't sink the
+// comparison.
+func planCommutes(ctx context.Context, client *sl.Client, cfg *config.Config, locations []string, base sl.TripOptions) []sl.Commute {
+	commutes := make([]sl.Commute, len(locations))
+	sem := make(chan struct{}, maxConcurrentScans)
+	var wg sync.WaitGroup
+
+	// Repeated comparisons within a couple of minutes are served from the
+	// trip cache unless --fresh is given.
+	planTrip := client.PlanTripCached
+	if freshData {
+		planTrip = client.PlanTrip
+	}
+
+	for i, loc := range locations {
+		wg.Add(1)
+		go func(i int, loc string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			fail := func(err error) {
+				commutes[i] = sl.Commute{From: loc, Lines: []string{}, Err: err.Error()}
+			}
+			originID, originName, err := resolveTripEndpoint(ctx, client, cfg, loc)
+			if err != nil {
+				fail(fmt.Errorf("resolving location: %w", err))
+				return
+			}
+			opts := base
+			opts.OriginID = originID
+			resp, err := planTrip(ctx, opts)
+			if err == nil {
+				err = plannerError(resp)
+			}
+			if err != nil {
+				fail(fmt.Errorf("planning trip: %w", err))
+				return
+			}
+			c, ok := sl.BestCommute(originName, resp.Journeys)
+			if !ok {
+				fail(sl.Errorf(sl.ErrNotFound, "no route found"))
+				return
+			}
+			commutes[i] = c
+		}(i, loc)
+	}
+
+	wg.Wait()
+	return commutes
+}
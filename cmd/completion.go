@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/glundgren93/sl-cli/internal/config"
+	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+	"github.com/spf13/cobra"
+)
+
+// completionTimeout bounds how long a stop-name completion may wait on the
+// network before settling for the cached site list, so tab never hangs.
+const completionTimeout = time.Second
+
+var completionShell string
+
+var completionInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install the completion script for your shell",
+	Long: `Detect your shell from $SHELL, write the completion script where it
+is picked up, and cache SL's stop names so completing --stop, --from and
+--to is instant.
+
+Script locations:
+  bash  $XDG_DATA_HOME/bash-completion/completions/sl (needs bash-completion)
+  zsh   ~/.zfunc/_sl (add ~/.zfunc to fpath before compinit)
+  fish  $XDG_CONFIG_HOME/fish/completions/sl.fish
+
+Examples:
+  sl completion install
+  sl completion install --shell zsh`,
+	Args: cobra.NoArgs,
+	RunE: runCompletionInstall,
+}
+
+func init() {
+	completionInstallCmd.Flags().StringVar(&completionShell, "shell", "", "Shell to install for: bash, zsh or fish (default from $SHELL)")
+
+	// Create cobra's completion command now rather than at Execute, so
+	// install can sit beside its bash/zsh/fish/powershell subcommands.
+	rootCmd.InitDefaultCompletionCmd()
+	for _, c := range rootCmd.Commands() {
+		if c.Name() == "completion" {
+			c.AddCommand(completionInstallCmd)
+		}
+	}
+}
+
+func runCompletionInstall(cmd *cobra.Command, args []string) error {
+	shell := completionShell
+	if shell == "" {
+		shell = filepath.Base(os.Getenv("SHELL"))
+	}
+
+	var script bytes.Buffer
+	var err error
+	switch shell {
+	case "bash":
+		err = rootCmd.GenBashCompletionV2(&script, true)
+	case "zsh":
+		err = rootCmd.GenZshCompletion(&script)
+	case "fish":
+		err = rootCmd.GenFishCompletion(&script, true)
+	case "", ".":
+		return usageErrorf("could not detect your shell; pass --shell bash, zsh or fish")
+	default:
+		return usageErrorf("can't install completions for %s; use --shell bash, zsh or fish, or sl completion --help", shell)
+	}
+	if err != nil {
+		return err
+	}
+
+	path, hint, err := completionPath(shell)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, script.Bytes(), 0o644); err != nil {
+		return err
+	}
+
+	// Warm the site cache the completions read from.
+	sites, siteErr := newClient().GetSitesCached(context.Background())
+
+	if jsonOutput {
+		return format.JSON(struct {
+			Shell string `json:"shell"`
+			Path  string `json:"path"`
+			Stops int    `json:"stops_cached"`
+		}{shell, path, len(sites)})
+	}
+	fmt.Fprintf(format.Stderr(), "✓ Installed %s completions in %s\n", shell, path)
+	if siteErr != nil {
+		fmt.Fprintf(format.Stderr(), "⚠️  could not cache stop names: %v\n", siteErr)
+	} else {
+		fmt.Fprintf(format.Stderr(), "✓ Cached %d stop names\n", len(sites))
+	}
+	if hint != "" {
+		fmt.Fprintln(format.Stderr(), hint)
+	}
+	return nil
+}
+
+// completionPath returns where shell loads user completion scripts from,
+// and anything the user still has to do for it to be picked up.
+func completionPath(shell string) (path, hint string, err error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", "", err
+	}
+	xdg := func(env, def string) string {
+		if dir := os.Getenv(env); dir != "" {
+			return dir
+		}
+		return filepath.Join(home, def)
+	}
+
+	switch shell {
+	case "bash":
+		return filepath.Join(xdg("XDG_DATA_HOME", ".local/share"), "bash-completion", "completions", "sl"),
+			"Open a new shell to use them (requires the bash-completion package).", nil
+	case "zsh":
+		return filepath.Join(xdg("ZDOTDIR", ""), ".zfunc", "_sl"),
+			"Add these lines to ~/.zshrc if they aren't there yet, then open a new shell:\n  fpath+=~/.zfunc\n  autoload -U compinit && compinit", nil
+	case "fish":
+		return filepath.Join(xdg("XDG_CONFIG_HOME", ".config"), "fish", "completions", "sl.fish"), "", nil
+	}
+	return "", "", fmt.Errorf("unsupported shell %q", shell)
+}
+
+// completeStops offers favorites and SL stop names starting with what has
+// been typed. Stop names come from the site cache; the API is only asked
+// when the cache is stale, and never for longer than completionTimeout.
+func completeStops(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	prefix := strings.ToLower(toComplete)
+	names, _ := completeFavorites(cmd, args, toComplete)
+
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+	client := sl.NewClient()
+	client.SetWarningHandler(func(sl.Warning) {}) // stderr would garble the prompt
+	client.SetRetries(0)
+	if sites, err := client.GetSitesCached(ctx); err == nil {
+		seen := map[string]bool{}
+		for _, s := range sites {
+			if strings.HasPrefix(strings.ToLower(s.Name), prefix) && !seen[s.Name] {
+				seen[s.Name] = true
+				names = append(names, s.Name)
+			}
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeFavorites offers the favorites from the config file starting
+// with what has been typed.
+func completeFavorites(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for name := range cfg.Favorites {
+		if strings.HasPrefix(strings.ToLower(name), strings.ToLower(toComplete)) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+	"github.com/spf13/cobra"
+)
+
+var (
+	corridorFrom  string
+	corridorTo    string
+	corridorWidth int
+	corridorLimit int
+)
+
+var corridorCmd = &cobra.Command{
+	Use:   "corridor",
+	Short: "List stops along the way between two places",
+	Long: `List every stop within a given distance of the straight line between two
+places, in order from start to end, with the lines serving each. Handy for
+choosing which street to walk down to keep the most bus options open.
+
+Examples:
+  sl corridor --from "Medborgarplatsen" --to "Slussen"
+  sl corridor --from "59.3143,18.0735" --to "Odenplan" --width 150
+  sl corridor --from "Hornstull" --to "Zinkensdamm" --json`,
+	RunE: runCorridor,
+}
+
+func init() {
+	corridorCmd.Flags().StringVar(&corridorFrom, "from", "", `Start: an address, place name or "lat,lon"`)
+	corridorCmd.Flags().StringVar(&corridorTo, "to", "", `End: an address, place name or "lat,lon"`)
+	corridorCmd.Flags().IntVar(&corridorWidth, "width", 200, "Max distance from the path in meters")
+	corridorCmd.Flags().IntVar(&corridorLimit, "limit", 20, "Max stops")
+
+	corridorCmd.MarkFlagRequired("from")
+	corridorCmd.MarkFlagRequired("to")
+
+	corridorCmd.RegisterFlagCompletionFunc("from", completeStops)
+	corridorCmd.RegisterFlagCompletionFunc("to", completeStops)
+
+	rootCmd.AddCommand(corridorCmd)
+}
+
+func runCorridor(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	client := newClient()
+
+	lat1, lon1, err := resolvePoint(ctx, client, corridorFrom)
+	if err != nil {
+		return fmt.Errorf("resolving start: %w", err)
+	}
+	lat2, lon2, err := resolvePoint(ctx, client, corridorTo)
+	if err != nil {
+		return fmt.Errorf("resolving end: %w", err)
+	}
+
+	sites, err := client.GetSitesCached(ctx)
+	if err != nil {
+		return fmt.Errorf("fetching sites: %w", err)
+	}
+	emitProgress(progressEvent{Event: "sites-loaded", Count: len(sites)})
+
+	along := sl.FindSitesAlongPath(sites, lat1, lon1, lat2, lon2, float64(corridorWidth)/1000)
+	if corridorLimit > 0 && len(along) > corridorLimit {
+		along = along[:corridorLimit]
+	}
+
+	results := []format.CorridorStop{}
+	for i, s := range along {
+		emitProgress(progressEvent{Event: "scanning", Current: i + 1, Total: len(along), Stop: s.Site.Name, SiteID: s.Site.ID})
+		entry := format.CorridorStop{
+			Stop:    s.Site.Name,
+			SiteID:  s.Site.ID,
+			AlongM:  int(s.AlongKm * 1000),
+			OffsetM: int(s.OffsetKm * 1000),
+			Lines:   []format.StopInfoLine{},
+		}
+		if resp, err := client.GetDepartures(ctx, sl.DepartureOptions{SiteID: s.Site.ID}); err == nil {
+			entry.Lines = extractLines(sl.ParseDepartures(resp.Departures))
+		}
+		results = append(results, entry)
+	}
+
+	if jsonOutput {
+		return format.JSON(results)
+	}
+	format.Corridor(corridorFrom, corridorTo, results)
+	return nil
+}
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+// departureCSVHeader is the first row of a --log-csv file.
+var departureCSVHeader = []string{
+	"observed_at", "site_id", "stop", "line", "transport_mode", "destination",
+	"direction_code", "scheduled", "expected", "delay_min", "display", "state",
+}
+
+// departureLogKey identifies one departure across observations.
+func departureLogKey(siteID int, line string, directionCode int, scheduled string) string {
+	return fmt.Sprintf("%d|%s|%d|%s", siteID, line, directionCode, scheduled)
+}
+
+// appendDepartureCSV appends a row to path for every departure that is new,
+// or whose expected time or state changed since the last row logged for it,
+// and returns how many rows were written. Running it repeatedly — with
+// --watch or from cron — therefore builds up one row per observed update.
+func appendDepartureCSV(path string, siteID int, deps []sl.ParsedDeparture, now time.Time) (int, error) {
+	last, err := lastLoggedDepartures(path)
+	if err != nil {
+		return 0, err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return 0, fmt.Errorf("opening csv log: %w", err)
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	if last == nil {
+		w.Write(departureCSVHeader)
+	}
+	observed := sl.StockholmTime(now).Format(time.RFC3339)
+	written := 0
+	for _, d := range deps {
+		scheduled := csvTime(d.Scheduled)
+		expected := csvTime(d.Expected)
+		key := departureLogKey(siteID, d.Line, d.DirectionCode, scheduled)
+		if last[key] == expected+"|"+d.State {
+			continue
+		}
+		delay := ""
+		if !d.Scheduled.IsZero() && !d.Expected.IsZero() {
+			delay = strconv.Itoa(int(d.Expected.Sub(d.Scheduled).Minutes()))
+		}
+		w.Write([]string{
+			observed, strconv.Itoa(siteID), d.StopArea, d.Line, d.TransportMode, d.Destination,
+			strconv.Itoa(d.DirectionCode), scheduled, expected, delay, d.Display, d.State,
+		})
+		written++
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return written, fmt.Errorf("writing csv log: %w", err)
+	}
+	return written, nil
+}
+
+// lastLoggedDepartures reads an existing log and returns the latest
+// "expected|state" per departure key, or nil if the file doesn't exist yet.
+func lastLoggedDepartures(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading csv log: %w", err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = len(departureCSVHeader)
+	if _, err := r.Read(); err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading csv log %s: %w", path, err)
+	}
+	last := map[string]string{}
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading csv log %s: %w", path, err)
+		}
+		site, _ := strconv.Atoi(rec[1])
+		dir, _ := strconv.Atoi(rec[6])
+		last[departureLogKey(site, rec[3], dir, rec[7])] = rec[8] + "|" + rec[11]
+	}
+	return last, nil
+}
+
+// csvTime formats t in Stockholm time, or "" when unknown.
+func csvTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return sl.StockholmTime(t).Format(time.RFC3339)
+}
+
+// departureLogColumns types the --log-csv columns for export recordings.
+var departureLogColumns = []format.ParquetColumn{
+	{Name: "observed_at", Kind: format.ParquetTimestamp},
+	{Name: "site_id", Kind: format.ParquetInt64},
+	{Name: "stop", Kind: format.ParquetString},
+	{Name: "line", Kind: format.ParquetString},
+	{Name: "transport_mode", Kind: format.ParquetString},
+	{Name: "destination", Kind: format.ParquetString},
+	{Name: "direction_code", Kind: format.ParquetInt64},
+	{Name: "scheduled", Kind: format.ParquetTimestamp},
+	{Name: "expected", Kind: format.ParquetTimestamp},
+	{Name: "delay_min", Kind: format.ParquetInt64},
+	{Name: "display", Kind: format.ParquetString},
+	{Name: "state", Kind: format.ParquetString},
+}
+
+// readDepartureLog reads the rows of a --log-csv file, typed as
+// departureLogColumns. Empty times and numbers are nil.
+func readDepartureLog(path string) ([][]any, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading csv log: %w", err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = len(departureCSVHeader)
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading csv log %s: %w", path, err)
+	}
+	if !slices.Equal(header, departureCSVHeader) {
+		return nil, fmt.Errorf("%s isn't a departures --log-csv file", path)
+	}
+
+	var rows [][]any
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading csv log %s: %w", path, err)
+		}
+		row := make([]any, len(rec))
+		for i, v := range rec {
+			if row[i], err = logValue(departureLogColumns[i].Kind, v); err != nil {
+				line, _ := r.FieldPos(i)
+				return nil, fmt.Errorf("%s:%d: %s: %w", path, line, departureCSVHeader[i], err)
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+func logValue(kind format.ParquetKind, v string) (any, error) {
+	switch {
+	case kind == format.ParquetString:
+		return v, nil
+	case v == "":
+		return nil, nil
+	case kind == format.ParquetInt64:
+		return strconv.ParseInt(v, 10, 64)
+	default:
+		return time.Parse(time.RFC3339, v)
+	}
+}
@@ -4,24 +4,59 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
-	"github.com/glundgren93/sl-cli/internal/api"
+	"github.com/glundgren93/sl-cli/internal/config"
 	"github.com/glundgren93/sl-cli/internal/format"
-	"github.com/glundgren93/sl-cli/internal/model"
+	"github.com/glundgren93/sl-cli/internal/i18n"
+	"github.com/glundgren93/sl-cli/internal/keys"
+	"github.com/glundgren93/sl-cli/pkg/sl"
 	"github.com/spf13/cobra"
 )
 
 var (
-	depSiteID    int
-	depStopName  string
-	depAddress   string
-	depLine      string
-	depMode      string
-	depDirection int
-	depLimit     int
-	depRadius    float64
+	depSiteID      int
+	depStopName    string
+	depAddress     string
+	depLine        string
+	depMode        string
+	depDirection   string
+	depTowardsFile string
+	depTowards     []string // destinations read from --towards-file
+	depDirs        bool
+	depLimit       int
+	depPerLine     int
+	depLimitSet    bool // --limit given explicitly
+	depRadius      float64
+	depNoDevs      bool
+	depOutput      string
+	depRefresh     int
+	depTheme       string
+	depLineColor   bool
+	depBoard       config.Board // config board style with --theme/--line-colors applied
+	depScanDepth   int
+	depStrategy    string
+	depWalk        time.Duration
+	depSpeak       bool
+	depLogCSV      string
+	depWatch       bool
+	depInterval    time.Duration
+	depCount       int
+	depAt          string
+	depSource      string
+	depAfter       time.Duration
+	depWithin      time.Duration
+	depPushGateway string
+	depAtTime      time.Time // --at resolved; zero means now
+	depShowSched   bool
+	depMerge       bool
 )
 
 var departuresCmd = &cobra.Command{
@@ -31,9 +66,39 @@ var departuresCmd = &cobra.Command{
 
 When using --address, the CLI geocodes the address and finds nearby stops.
 Without --line or --mode, it returns departures from ALL nearby stops.
-With --line or --mode, it finds the nearest stop serving that line/mode.
+With --line or --mode, it finds the nearest stop serving that line/mode
+(or, with --strategy soonest, the stop with the soonest matching departure).
+--merge-nearby instead puts the departures of every stop within --radius
+(up to --scan-depth stops) on one board by time, each with its stop and
+distance.
 
-Also fetches relevant service deviations and shows them inline.
+With --walk (or, for --address, the estimated walk to each stop), every
+departure is marked catchable (✅), marginal (⚠️) or not catchable (❌).
+
+Also fetches relevant service deviations and shows them inline. Use
+--no-deviations to skip the deviation lookup when latency matters.
+
+With --at the board starts at a later time. Up to an hour ahead it is the
+real-time board from then on; further ahead the departures come from the
+GTFS timetable (needs sl keys set trafiklab-static <key>) and are marked
+as scheduled: delays and cancellations aren't known yet.
+
+--source planner reads the board from the journey planner's departure
+monitor instead of the Transport API. It answers for any time, so --at
+needs no timetable, and is handy when the Transport API's data for a stop
+looks wrong or thin. It knows no direction codes: --direction takes a
+destination only.
+
+Departures more than a minute off schedule show the difference next to
+their time, as "8 min (+3)". --show-scheduled shows timetable times
+instead of countdowns: "12:05 (+3)" leaves at 12:08.
+
+--towards-file reads destinations one per line (blank lines and lines
+starting with # are skipped) and keeps departures heading to any of them,
+matched like a --direction destination.
+
+With --watch the board is redrawn every --interval until Ctrl-C. Combined
+with --format html --output or --log-csv it keeps the file up to date instead.
 
 Examples:
   sl departures --site 9530                                  # By site ID
@@ -41,9 +106,27 @@ Examples:
   sl departures --address "Magnus Ladulåsgatan 7"            # All nearby stops
   sl departures --address "Magnus Ladulåsgatan 7" --line 55  # Nearest with line 55
   sl departures --address "Drottninggatan 45" --mode TRAIN   # Nearest train
-  sl departures --site 9530 --json                           # JSON for agents`,
-	Aliases: []string{"dep", "d"},
-	RunE:    runDepartures,
+  sl departures --address "Götgatan 36" --merge-nearby --radius 0.15  # One board for the corner
+  sl departures --site 9530 --directions                     # Destinations per direction
+  sl departures --site 9530 --line 17 --direction "towards Åkeshov"
+  sl departures --site 9530 --towards-file office.txt        # Every branch passing the office
+  sl departures --site 9530 --walk 6m                        # Can I make it?
+  sl departures --site 9530 --after 5m --within 30m          # Only what I can still catch
+  sl departures --site 9530 --at "tomorrow 07:30"            # Timetable for tomorrow morning
+  sl departures --site 9530 --source planner                 # From the journey planner
+  sl departures --site 9530 --show-scheduled                 # Timetable times with delays
+  sl departures --site 9530 --per-line 3                     # Three per line, every line shown
+  sl departures --site 9530 --speak                          # Read the next departures aloud
+  sl departures --site 9530 --watch --interval 30s          # Live board
+  sl departures --site 9530 --json                           # JSON for agents
+  sl departures --site 9530 --log-csv log.csv                # Append changes for a spreadsheet
+  sl departures --site 9530 --format csv                     # One row per departure
+  sl departures --site 9530 --format html --output board.html  # Kiosk board
+  sl departures --site 9530 --format influx                  # InfluxDB line protocol
+  sl departures --site 9530 --push-gateway http://pushgw:9091  # Push metrics from cron`,
+	Aliases:     []string{"dep", "d"},
+	Annotations: formats(append([]string{"text", "html", "influx", "prometheus"}, format.TableFormats...)...),
+	RunE:        runDepartures,
 }
 
 func init() {
@@ -52,19 +135,143 @@ func init() {
 	departuresCmd.Flags().StringVar(&depAddress, "address", "", "Street address (geocodes and finds nearest stops)")
 	departuresCmd.Flags().StringVar(&depLine, "line", "", "Filter by line designation (e.g. 55, 18)")
 	departuresCmd.Flags().StringVar(&depMode, "mode", "", "Filter by transport mode (BUS, METRO, TRAIN, TRAM, SHIP)")
-	departuresCmd.Flags().IntVar(&depDirection, "direction", 0, "Filter by direction (1 or 2)")
-	departuresCmd.Flags().IntVar(&depLimit, "limit", 20, "Max departures per stop")
+	departuresCmd.Flags().StringVar(&depDirection, "direction", "", "Filter by direction: 1, 2, or a destination (e.g. \"towards Akalla\")")
+	departuresCmd.Flags().StringVar(&depTowardsFile, "towards-file", "", "Only departures heading to a destination listed in this file, one per line")
+	departuresCmd.Flags().BoolVar(&depDirs, "directions", false, "List the destinations served by each direction at the stop")
+	departuresCmd.Flags().IntVar(&depLimit, "limit", 20, "Max departures per stop (0 = all)")
+	departuresCmd.Flags().IntVar(&depPerLine, "per-line", 0, "Max departures per line; the overall --limit then only applies if given explicitly")
 	departuresCmd.Flags().Float64Var(&depRadius, "radius", 1.0, "Search radius in km when using --address")
+	departuresCmd.Flags().IntVar(&depScanDepth, "scan-depth", 15, "Max stops to check with --address and --line/--mode or --merge-nearby")
+	departuresCmd.Flags().BoolVar(&depMerge, "merge-nearby", false, "With --address, merge the departures of all stops within --radius into one board by time")
+	departuresCmd.Flags().StringVar(&depStrategy, "strategy", "nearest", "Stop choice with --address and --line/--mode: nearest or soonest")
+	departuresCmd.Flags().DurationVar(&depAfter, "after", 0, "Only departures leaving at least this far ahead (e.g. 10m), such as your walk to the stop")
+	departuresCmd.Flags().DurationVar(&depWithin, "within", 0, "Only departures leaving within this long (e.g. 30m)")
+	departuresCmd.Flags().DurationVar(&depWalk, "walk", 0, "Your walking time to the stop (e.g. 5m); marks which departures you can catch")
+	departuresCmd.Flags().BoolVar(&depSpeak, "speak", false, "Announce the next departures via the OS text-to-speech")
+	departuresCmd.Flags().StringVar(&depLogCSV, "log-csv", "", "Append new or changed departures to a CSV file instead of printing them")
+	departuresCmd.Flags().BoolVar(&depWatch, "watch", false, "Keep refreshing the board until interrupted")
+	departuresCmd.Flags().DurationVar(&depInterval, "interval", 30*time.Second, "Refresh interval with --watch")
+	departuresCmd.Flags().StringVar(&depAt, "at", "", `Departures from HH:MM, "tomorrow 07:30", "2025-06-02 08:00" or a named time like @commute`)
+	departuresCmd.Flags().StringVar(&depSource, "source", "transport", "Where departures come from: transport (Transport API) or planner (journey planner)")
+	departuresCmd.Flags().IntVar(&depCount, "count", 0, "With --watch, stop after this many refreshes (0 = until interrupted)")
+	departuresCmd.Flags().StringVar(&depPushGateway, "push-gateway", "", "Push departure metrics to this Prometheus push gateway URL instead of printing them")
+	departuresCmd.Flags().BoolVar(&depShowSched, "show-scheduled", false, "Show timetable times instead of minutes left")
+	departuresCmd.Flags().BoolVar(&depNoDevs, "no-deviations", false, "Skip the inline deviation lookup")
+	departuresCmd.Flags().StringVarP(&depOutput, "output", "o", "", "Write output to a file instead of stdout (html only)")
+	departuresCmd.Flags().IntVar(&depRefresh, "refresh", 60, "Auto-refresh interval in seconds for html output (0 = off)")
+	departuresCmd.Flags().StringVar(&depTheme, "theme", "", "html theme: dark, day, night or auto (default from config, else dark)")
+	departuresCmd.Flags().BoolVar(&depLineColor, "line-colors", false, "Color html rows in SL's line colors")
+
+	departuresCmd.RegisterFlagCompletionFunc("stop", completeStops)
 
 	rootCmd.AddCommand(departuresCmd)
 }
 
 func runDepartures(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
-	client := api.NewClient()
+	client := newClient()
+
+	if depStrategy != "nearest" && depStrategy != "soonest" {
+		return usageErrorf("unknown strategy %q (use nearest or soonest)", depStrategy)
+	}
+	if err := checkPaging(depLimit, 0); err != nil {
+		return err
+	}
+	if depPerLine < 0 {
+		return usageErrorf("--per-line must be 0 (no limit) or greater")
+	}
+	if depAfter < 0 || depWithin < 0 {
+		return usageErrorf("--after and --within can't be negative")
+	}
+	if depWithin > 0 && depAfter > depWithin {
+		return usageErrorf("--after %s is later than --within %s", depAfter, depWithin)
+	}
+	if depAt != "" && (depAfter > 0 || depWithin > 0) {
+		return usageErrorf("--after and --within count from now and can't be combined with --at")
+	}
+	depLimitSet = cmd.Flags().Changed("limit")
+	depTowards = nil
+	if depTowardsFile != "" {
+		if depDirection != "" {
+			return usageErrorf("--towards-file can't be combined with --direction")
+		}
+		var err error
+		if depTowards, err = readTowardsFile(depTowardsFile); err != nil {
+			return err
+		}
+	}
+	if depMerge && (depAddress == "" || outputFormat == "html" || metricsOutput()) {
+		return usageErrorf("--merge-nearby needs --address and can't be combined with --format html, influx or prometheus")
+	}
+	if depLogCSV != "" && (depAddress != "" || depDirs || !textFormat() || depPushGateway != "") {
+		return usageErrorf("--log-csv needs a single stop (--site or --stop) and text output")
+	}
+	if depPushGateway != "" && (jsonOutput || !textFormat() && outputFormat != "prometheus") {
+		return usageErrorf("--push-gateway pushes Prometheus metrics and can't be combined with --json or another --format")
+	}
+	if outputFormat == "html" {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		depBoard = cfg.Board
+		if depTheme != "" {
+			depBoard.Theme = depTheme
+		}
+		if cmd.Flags().Changed("line-colors") {
+			depBoard.LineColors = depLineColor
+		}
+		if _, err := boardStyle(depBoard, time.Now()); err != nil {
+			return err
+		}
+	}
+	if depWatch {
+		if depDirs {
+			return usageErrorf("--watch can't be combined with --directions")
+		}
+		if depInterval < 5*time.Second {
+			return usageErrorf("--interval must be at least 5s")
+		}
+	}
+
+	switch depSource {
+	case "transport":
+	case "planner":
+		if depAddress != "" || depDirs {
+			return usageErrorf("--source planner needs a single stop (--site or --stop) and can't be combined with --directions")
+		}
+		if _, err := strconv.Atoi(depDirection); err == nil {
+			return usageErrorf("--source planner has no direction codes; give --direction a destination")
+		}
+	default:
+		return usageErrorf("unknown source %q (use transport or planner)", depSource)
+	}
+
+	depAtTime = time.Time{}
+	if depAt != "" {
+		if depAddress != "" || depDirs || depWatch || depLogCSV != "" {
+			return usageErrorf("--at needs a single stop (--site or --stop) and can't be combined with --directions, --watch or --log-csv")
+		}
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		if depAtTime, err = resolveAt(cfg, depAt, sl.StockholmTime(time.Now())); err != nil {
+			return &usageError{err}
+		}
+	}
 
 	if depAddress != "" {
-		return runDeparturesByAddress(ctx, client)
+		if depDirs {
+			return usageErrorf("--directions needs a single stop: use --site or --stop")
+		}
+		nearby, err := addressStops(ctx, client)
+		if err != nil {
+			return err
+		}
+		return showDepartures(ctx, client, func(ctx context.Context) error {
+			return runDeparturesByAddress(ctx, client, nearby)
+		})
 	}
 
 	siteID := depSiteID
@@ -75,7 +282,7 @@ func runDepartures(cmd *cobra.Command, args []string) error {
 
 	if siteID == 0 {
 		if depStopName == "" {
-			return fmt.Errorf("provide --site, --stop, or --address (use 'sl search <name>' to find stops)")
+			return usageErrorf("provide --site, --stop, or --address (use 'sl search <name>' to find stops)")
 		}
 
 		if id, err := strconv.Atoi(depStopName); err == nil {
@@ -89,27 +296,134 @@ func runDepartures(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	return fetchAndPrintDepartures(ctx, client, siteID, "", 0)
+	if depDirs {
+		return printDirections(ctx, client, siteID)
+	}
+	if depSource == "planner" {
+		return showDepartures(ctx, client, func(ctx context.Context) error {
+			return fetchAndPrintPlannerDepartures(ctx, client, siteID)
+		})
+	}
+	if time.Until(depAtTime) > sl.RealtimeHorizon {
+		return printScheduledDepartures(ctx, client, siteID)
+	}
+
+	return showDepartures(ctx, client, func(ctx context.Context) error {
+		return fetchAndPrintDepartures(ctx, client, siteID, "", 0)
+	})
+}
+
+// showDepartures runs show once or, with --watch, every --interval until
+// interrupted, redrawing the board each time. A failed refresh after the
+// first only warns, so a brief API hiccup doesn't end the watch.
+func showDepartures(ctx context.Context, client *sl.Client, show func(context.Context) error) error {
+	if !depWatch {
+		return show(ctx)
+	}
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	redraw := !jsonOutput && textFormat() && depLogCSV == "" && depPushGateway == ""
+	for round := 1; ; round++ {
+		if redraw {
+			format.ClearScreen()
+		}
+		err := show(ctx)
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case err != nil && round == 1:
+			return err
+		case err != nil:
+			client.Warn(sl.WarnStaleData, "could not refresh departures: %v", err)
+		}
+		if redraw {
+			format.WatchFooter(sl.StockholmTime(time.Now()), depInterval)
+		}
+
+		if depCount > 0 && round >= depCount {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(depInterval):
+		}
+	}
+}
+
+// departureOptions builds the API request for a site from the filter flags.
+// A non-numeric --direction is passed as text and resolved to direction codes
+// against the stop's departures.
+func departureOptions(siteID int) sl.DepartureOptions {
+	opts := sl.DepartureOptions{
+		SiteID:        siteID,
+		TransportMode: depMode,
+		Line:          depLine,
+		Towards:       depTowards,
+	}
+	if n, err := strconv.Atoi(depDirection); err == nil {
+		opts.Direction = n
+	} else {
+		opts.DirectionText = depDirection
+	}
+	return opts
+}
+
+// printDirections lists, per line, the destinations served by each direction code.
+func printDirections(ctx context.Context, client *sl.Client, siteID int) error {
+	resp, err := client.GetDepartures(ctx, sl.DepartureOptions{
+		SiteID:        siteID,
+		TransportMode: depMode,
+		Line:          depLine,
+	})
+	if err != nil {
+		return fmt.Errorf("fetching departures: %w", err)
+	}
+
+	parsed := sl.ParseDepartures(resp.Departures)
+	directions := sl.GroupDirections(parsed)
+
+	stopName := fmt.Sprintf("Site %d", siteID)
+	if len(parsed) > 0 {
+		stopName = parsed[0].StopArea
+	}
+
+	if jsonOutput {
+		return format.JSON(directions)
+	}
+
+	format.Directions(directions, stopName)
+	return nil
 }
 
-func runDeparturesByAddress(ctx context.Context, client *api.Client) error {
+// addressStops geocodes --address and returns the stops within --radius.
+func addressStops(ctx context.Context, client *sl.Client) ([]sl.SiteWithDistance, error) {
 	lat, lon, resolvedName, err := geocodeAddress(ctx, client, depAddress)
 	if err != nil {
-		return fmt.Errorf("geocoding address: %w", err)
+		return nil, fmt.Errorf("geocoding address: %w", err)
 	}
 
 	if !jsonOutput {
-		fmt.Fprintf(os.Stderr, "📍 Resolved: %s (%.4f, %.4f)\n", resolvedName, lat, lon)
+		fmt.Fprintf(format.Stderr(), "📍 Resolved: %s (%.4f, %.4f)\n", resolvedName, lat, lon)
 	}
 
 	sites, err := client.GetSitesCached(ctx)
 	if err != nil {
-		return fmt.Errorf("fetching sites: %w", err)
+		return nil, fmt.Errorf("fetching sites: %w", err)
 	}
+	emitProgress(progressEvent{Event: "sites-loaded", Count: len(sites)})
 
-	nearby := api.FindNearestSites(sites, lat, lon, depRadius)
+	nearby := sl.FindNearestSites(sites, lat, lon, depRadius)
 	if len(nearby) == 0 {
-		return fmt.Errorf("no stops found within %.0fm of %q", depRadius*1000, depAddress)
+		return nil, sl.Errorf(sl.ErrNotFound, "no stops found within %.0fm of %q", depRadius*1000, depAddress)
+	}
+	return nearby, nil
+}
+
+func runDeparturesByAddress(ctx context.Context, client *sl.Client, nearby []sl.SiteWithDistance) error {
+	if depMerge {
+		return mergedNearbyDepartures(ctx, client, nearby)
 	}
 
 	// With --line or --mode: find first matching stop (original behavior)
@@ -123,32 +437,29 @@ func runDeparturesByAddress(ctx context.Context, client *api.Client) error {
 
 // departuresFromAllNearby fetches departures from all nearby stops and returns
 // a consolidated result. This is the default when using --address without --line/--mode.
-func departuresFromAllNearby(ctx context.Context, client *api.Client, nearby []api.SiteWithDistance) error {
+func departuresFromAllNearby(ctx context.Context, client *sl.Client, nearby []sl.SiteWithDistance) error {
 	maxScan := 5
 	if len(nearby) < maxScan {
 		maxScan = len(nearby)
 	}
 
 	results := []departureResult{}
-	var allDeps []model.ParsedDeparture
+	var allDeps []sl.ParsedDeparture
 
-	for _, stop := range nearby[:maxScan] {
-		resp, err := client.GetDepartures(ctx, api.DepartureOptions{
-			SiteID:    stop.Site.ID,
-			Direction: depDirection,
-		})
+	for i, stop := range nearby[:maxScan] {
+		emitProgress(progressEvent{Event: "scanning", Current: i + 1, Total: maxScan, Stop: stop.Site.Name, SiteID: stop.Site.ID})
+		resp, asOf, err := client.GetDeparturesLastGood(ctx, departureOptions(stop.Site.ID))
 		if err != nil {
 			continue
 		}
 
-		parsed := api.ParseDepartures(resp.Departures)
+		parsed := sl.ParseDepartures(resp.Departures)
 		if len(parsed) == 0 {
 			continue
 		}
 
-		if depLimit > 0 && len(parsed) > depLimit {
-			parsed = parsed[:depLimit]
-		}
+		parsed = limitDepartures(parsed)
+		markCatchable(parsed, stop.DistanceKm)
 
 		allDeps = append(allDeps, parsed...)
 		deviations := fetchRelevantDeviations(ctx, client, parsed)
@@ -159,29 +470,45 @@ func departuresFromAllNearby(ctx context.Context, client *api.Client, nearby []a
 			DistanceM:  int(stop.DistanceKm * 1000),
 			Departures: parsed,
 			Deviations: deviations,
+			AsOf:       asOf,
 		})
 	}
 
 	if len(results) == 0 {
-		return fmt.Errorf("no departures found at any stop within %.0fm of %q", depRadius*1000, depAddress)
+		return sl.Errorf(sl.ErrNoDepartures, "no departures found at any stop within %.0fm of %q", depRadius*1000, depAddress)
+	}
+
+	if outputFormat == "html" {
+		return writeDeparturesHTML(results)
+	}
+	if metricsOutput() {
+		return departuresMetrics(ctx, results)
 	}
 
 	if jsonOutput {
 		return format.JSON(results)
 	}
+	if format.IsTable(outputFormat) {
+		return departuresTable(results)
+	}
 
 	// Human-readable: print each stop
 	for _, r := range results {
-		fmt.Fprintf(os.Stderr, "🚏 %s (%dm)\n", r.Stop, r.DistanceM)
-		format.Departures(r.Departures, r.Stop)
+		fmt.Fprintf(format.Stderr(), "🚏 %s (%dm)\n", r.Stop, r.DistanceM)
+		format.Departures(r.Departures, r.Stop, depShowSched)
 		format.DeviationWarnings(r.Deviations)
 	}
+	for _, r := range results {
+		if err := speakIfRequested(r.Departures, r.Stop); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func departuresFromNearestMatching(ctx context.Context, client *api.Client, nearby []api.SiteWithDistance) error {
-	maxScan := 15
-	if len(nearby) < maxScan {
+func departuresFromNearestMatching(ctx context.Context, client *sl.Client, nearby []sl.SiteWithDistance) error {
+	maxScan := depScanDepth
+	if maxScan <= 0 || len(nearby) < maxScan {
 		maxScan = len(nearby)
 	}
 
@@ -190,57 +517,253 @@ func departuresFromNearestMatching(ctx context.Context, client *api.Client, near
 		filterDesc = depMode
 	}
 
-	for _, stop := range nearby[:maxScan] {
-		resp, err := client.GetDepartures(ctx, api.DepartureOptions{
-			SiteID:        stop.Site.ID,
-			TransportMode: depMode,
-			Line:          depLine,
-			Direction:     depDirection,
-		})
-		if err != nil {
-			continue
+	scans := scanStops(ctx, client, nearby[:maxScan], depStrategy == "nearest")
+	best := pickStop(scans, depStrategy)
+	if best < 0 {
+		return sl.Errorf(sl.ErrNotFound, "%s not found at any stop within %.0fm of %q", filterDesc, depRadius*1000, depAddress)
+	}
+
+	stop, parsed := scans[best].stop, scans[best].parsed
+
+	if !jsonOutput && outputFormat != "html" {
+		fmt.Fprintf(format.Stderr(), "🚏 %s — %dm away (%s found)\n\n",
+			stop.Site.Name, int(stop.DistanceKm*1000), filterDesc)
+	}
+
+	deviations := fetchRelevantDeviations(ctx, client, parsed)
+
+	parsed = limitDepartures(parsed)
+	markCatchable(parsed, stop.DistanceKm)
+
+	result := departureResult{
+		Stop:       stop.Site.Name,
+		SiteID:     stop.Site.ID,
+		DistanceM:  int(stop.DistanceKm * 1000),
+		Departures: parsed,
+		Deviations: deviations,
+	}
+	if outputFormat == "html" {
+		return writeDeparturesHTML([]departureResult{result})
+	}
+	if metricsOutput() {
+		return departuresMetrics(ctx, []departureResult{result})
+	}
+	if jsonOutput {
+		return format.JSON(result)
+	}
+	if format.IsTable(outputFormat) {
+		return departuresTable([]departureResult{result})
+	}
+
+	format.Departures(parsed, stop.Site.Name, depShowSched)
+	format.DeviationWarnings(deviations)
+	return speakIfRequested(parsed, stop.Site.Name)
+}
+
+// mergedResult is the JSON output of departures --merge-nearby.
+type mergedResult struct {
+	Departures []format.NearbyDeparture  `json:"departures"`
+	Deviations []format.DeviationWarning `json:"deviations"`
+}
+
+// Rows makes --template render once per departure.
+func (r mergedResult) Rows() any {
+	return r.Departures
+}
+
+// mergedNearbyDepartures fetches the departures of the nearby stops (up to
+// --scan-depth) concurrently and shows them as one board sorted by time,
+// each marked catchable by the walk to its own stop.
+func mergedNearbyDepartures(ctx context.Context, client *sl.Client, nearby []sl.SiteWithDistance) error {
+	maxScan := depScanDepth
+	if maxScan <= 0 || len(nearby) < maxScan {
+		maxScan = len(nearby)
+	}
+
+	var deps []format.NearbyDeparture
+	var parsed []sl.ParsedDeparture
+	for _, scan := range scanStops(ctx, client, nearby[:maxScan], false) {
+		scan.parsed = sl.FilterByWindow(scan.parsed, depAfter, depWithin)
+		markCatchable(scan.parsed, scan.stop.DistanceKm)
+		parsed = append(parsed, scan.parsed...)
+		for _, d := range scan.parsed {
+			deps = append(deps, format.NearbyDeparture{
+				Stop:            scan.stop.Site.Name,
+				SiteID:          scan.stop.Site.ID,
+				DistanceM:       int(scan.stop.DistanceKm * 1000),
+				ParsedDeparture: d,
+			})
 		}
+	}
+	if len(deps) == 0 {
+		return sl.Errorf(sl.ErrNoDepartures, "no departures found at any stop within %.0fm of %q", depRadius*1000, depAddress)
+	}
 
-		if len(resp.Departures) == 0 {
-			continue
+	slices.SortStableFunc(deps, func(a, b format.NearbyDeparture) int {
+		if c := departureTime(a.ParsedDeparture).Compare(departureTime(b.ParsedDeparture)); c != 0 {
+			return c
 		}
+		return a.DistanceM - b.DistanceM
+	})
+	deps = limitNearbyDepartures(deps)
+	deviations := fetchRelevantDeviations(ctx, client, parsed)
 
-		parsed := api.ParseDepartures(resp.Departures)
-		if depMode != "" {
-			parsed = api.FilterByTransportMode(parsed, depMode)
+	if jsonOutput {
+		return format.JSON(mergedResult{Departures: deps, Deviations: deviations})
+	}
+	if format.IsTable(outputFormat) {
+		rows := make([]format.StopDeparture, len(deps))
+		for i, d := range deps {
+			rows[i] = format.StopDeparture{Stop: d.Stop, SiteID: d.SiteID, ParsedDeparture: d.ParsedDeparture}
 		}
+		return format.Table(os.Stdout, outputFormat, format.DepartureColumns, rows)
+	}
 
-		if len(parsed) == 0 {
+	format.MergedDepartures(deps, depShowSched)
+	format.DeviationWarnings(deviations)
+	return nil
+}
+
+// departureTime is when d is expected to leave, or its timetabled time
+// when there is no prediction.
+func departureTime(d sl.ParsedDeparture) time.Time {
+	if d.Expected.IsZero() {
+		return d.Scheduled
+	}
+	return d.Expected
+}
+
+// limitNearbyDepartures applies --per-line and --limit to a merged board
+// like limitDepartures does to a single stop's, counting a line at each
+// stop separately.
+func limitNearbyDepartures(deps []format.NearbyDeparture) []format.NearbyDeparture {
+	type lineKey struct {
+		siteID     int
+		mode, line string
+	}
+	counts := make(map[lineKey]int)
+	limit := depLimit
+	if depPerLine > 0 && !depLimitSet {
+		limit = 0
+	}
+	kept := []format.NearbyDeparture{}
+	for _, d := range deps {
+		if limit > 0 && len(kept) == limit {
+			break
+		}
+		key := lineKey{d.SiteID, d.TransportMode, d.Line}
+		if depPerLine > 0 && counts[key] == depPerLine {
 			continue
 		}
+		counts[key]++
+		kept = append(kept, d)
+	}
+	return kept
+}
 
-		if !jsonOutput {
-			fmt.Fprintf(os.Stderr, "🚏 %s — %dm away (%s found)\n\n",
-				stop.Site.Name, int(stop.DistanceKm*1000), filterDesc)
-		}
+// maxConcurrentScans bounds parallel departure requests during stop scans.
+const maxConcurrentScans = 5
 
-		deviations := fetchRelevantDeviations(ctx, client, parsed)
+// stopScan is the outcome of checking one candidate stop for matching departures.
+type stopScan struct {
+	stop   sl.SiteWithDistance
+	parsed []sl.ParsedDeparture // empty if the stop failed or had no match
+}
 
-		if depLimit > 0 && len(parsed) > depLimit {
-			parsed = parsed[:depLimit]
+// scanStops fetches filtered departures for all candidate stops concurrently,
+// starting in distance order. Results are index-aligned with stops, so
+// callers can still reason about distance order. With nearestOnly, the
+// scan stops once the closest matching stop is known: when a stop matches
+// and every closer one has answered without a match. Stops it didn't get
+// to are left empty.
+func scanStops(ctx context.Context, client *sl.Client, stops []sl.SiteWithDistance, nearestOnly bool) []stopScan {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	scans := make([]stopScan, len(stops))
+	for i, stop := range stops {
+		scans[i].stop = stop
+	}
+	done := make([]bool, len(stops))
+	var mu sync.Mutex
+	sem := make(chan struct{}, maxConcurrentScans)
+	var wg sync.WaitGroup
+
+	for i, stop := range stops {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
 		}
-
-		if jsonOutput {
-			return format.JSON(departureResult{
-				Stop:       stop.Site.Name,
-				SiteID:     stop.Site.ID,
-				DistanceM:  int(stop.DistanceKm * 1000),
-				Departures: parsed,
-				Deviations: deviations,
-			})
+		if ctx.Err() != nil {
+			break
 		}
+		wg.Add(1)
+		go func(i int, stop sl.SiteWithDistance) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			emitProgress(progressEvent{Event: "scanning", Current: i + 1, Total: len(stops), Stop: stop.Site.Name, SiteID: stop.Site.ID})
+			var parsed []sl.ParsedDeparture
+			resp, err := client.GetDepartures(ctx, departureOptions(stop.Site.ID))
+			if err == nil && len(resp.Departures) > 0 {
+				parsed = sl.ParseDepartures(resp.Departures)
+				if depMode != "" {
+					parsed = sl.FilterByTransportMode(parsed, depMode)
+				}
+			}
 
-		format.Departures(parsed, stop.Site.Name)
-		format.DeviationWarnings(deviations)
-		return nil
+			mu.Lock()
+			defer mu.Unlock()
+			scans[i].parsed = parsed
+			done[i] = true
+			if nearestOnly && nearestKnown(scans, done) {
+				cancel()
+			}
+		}(i, stop)
 	}
 
-	return fmt.Errorf("%s not found at any stop within %.0fm of %q", filterDesc, depRadius*1000, depAddress)
+	wg.Wait()
+	return scans
+}
+
+// nearestKnown reports whether the closest stop with a match is settled:
+// some stop matched and every stop before it has answered.
+func nearestKnown(scans []stopScan, done []bool) bool {
+	for i := range scans {
+		if !done[i] {
+			return false
+		}
+		if len(scans[i].parsed) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// pickStop chooses among scanned stops: "nearest" returns the closest stop
+// with any match, "soonest" the one with the earliest matching departure
+// (ties go to the closer stop). Returns -1 if no stop matched.
+func pickStop(scans []stopScan, strategy string) int {
+	best := -1
+	bestMins := 0
+	for i, s := range scans {
+		if len(s.parsed) == 0 {
+			continue
+		}
+		if strategy != "soonest" {
+			return i
+		}
+		mins := s.parsed[0].MinutesLeft
+		for _, d := range s.parsed[1:] {
+			if d.MinutesLeft < mins {
+				mins = d.MinutesLeft
+			}
+		}
+		if best < 0 || mins < bestMins {
+			best, bestMins = i, mins
+		}
+	}
+	return best
 }
 
 // departureResult is the consistent JSON output for departures queries.
@@ -248,30 +771,80 @@ type departureResult struct {
 	Stop       string                    `json:"stop"`
 	SiteID     int                       `json:"site_id"`
 	DistanceM  int                       `json:"distance_m"`
-	Departures []model.ParsedDeparture   `json:"departures"`
+	Departures []sl.ParsedDeparture      `json:"departures"`
 	Deviations []format.DeviationWarning `json:"deviations"`
+	// AsOf is when the departures were fetched, set only when SL failed to
+	// answer and the last good board was shown instead.
+	AsOf time.Time `json:"as_of,omitzero"`
 }
 
-func fetchAndPrintDepartures(ctx context.Context, client *api.Client, siteID int, stopName string, distanceM int) error {
-	resp, err := client.GetDepartures(ctx, api.DepartureOptions{
-		SiteID:        siteID,
-		TransportMode: depMode,
-		Line:          depLine,
-		Direction:     depDirection,
-	})
+// Rows makes --template render once per departure, with the stop's name
+// alongside the departure's fields.
+func (r departureResult) Rows() any {
+	return r.stopDepartures()
+}
+
+// stopDepartures pairs each departure with the stop's name.
+func (r departureResult) stopDepartures() []format.StopDeparture {
+	rows := make([]format.StopDeparture, len(r.Departures))
+	for i, d := range r.Departures {
+		rows[i] = format.StopDeparture{Stop: r.Stop, SiteID: r.SiteID, ParsedDeparture: d}
+	}
+	return rows
+}
+
+func fetchAndPrintDepartures(ctx context.Context, client *sl.Client, siteID int, stopName string, distanceM int) error {
+	// Deviations don't depend on the departures response, so fetch them
+	// concurrently and match them against the departed lines afterwards.
+	// If the departures fail, the deviations are no longer needed.
+	devCtx, cancelDevs := context.WithCancel(ctx)
+	defer cancelDevs()
+	var (
+		wg      sync.WaitGroup
+		devs    []sl.Deviation
+		devsErr error
+	)
+	if !depNoDevs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			opts := sl.DeviationOptions{}
+			if depMode != "" {
+				opts.TransportModes = []string{depMode}
+			}
+			devs, devsErr = client.GetDeviations(devCtx, opts)
+		}()
+	}
+
+	resp, asOf, err := client.GetDeparturesLastGood(ctx, departureOptions(siteID))
 	if err != nil {
 		return fmt.Errorf("fetching departures: %w", err)
 	}
+	wg.Wait()
 
-	parsed := api.ParseDepartures(resp.Departures)
+	parsed := sl.ParseDepartures(resp.Departures)
 	if depMode != "" {
-		parsed = api.FilterByTransportMode(parsed, depMode)
+		parsed = sl.FilterByTransportMode(parsed, depMode)
 	}
+	parsed = departuresFrom(parsed, depAtTime)
 
-	deviations := fetchRelevantDeviations(ctx, client, parsed)
+	deviations := []format.DeviationWarning{}
+	if devsErr != nil {
+		client.Warn(sl.WarnPartialDeviations, "could not fetch deviations: %v", devsErr)
+	} else {
+		deviations = matchDeviations(devs, parsed)
+	}
+
+	parsed = limitDepartures(parsed)
+	markCatchable(parsed, float64(distanceM)/1000)
 
-	if depLimit > 0 && len(parsed) > depLimit {
-		parsed = parsed[:depLimit]
+	if depLogCSV != "" {
+		n, err := appendDepartureCSV(depLogCSV, siteID, parsed, time.Now())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(format.Stderr(), "📝 %d row(s) appended to %s\n", n, depLogCSV)
+		return nil
 	}
 
 	if stopName == "" {
@@ -281,31 +854,280 @@ func fetchAndPrintDepartures(ctx context.Context, client *api.Client, siteID int
 		stopName = parsed[0].StopArea
 	}
 
+	result := departureResult{
+		Stop:       stopName,
+		SiteID:     siteID,
+		DistanceM:  distanceM,
+		Departures: parsed,
+		Deviations: deviations,
+		AsOf:       asOf,
+	}
+	return printDepartureResult(ctx, result)
+}
+
+// printDepartureResult writes one stop's board in the --format asked for.
+func printDepartureResult(ctx context.Context, result departureResult) error {
+	if outputFormat == "html" {
+		return writeDeparturesHTML([]departureResult{result})
+	}
+	if metricsOutput() {
+		return departuresMetrics(ctx, []departureResult{result})
+	}
 	if jsonOutput {
-		return format.JSON(departureResult{
-			Stop:       stopName,
-			SiteID:     siteID,
-			DistanceM:  distanceM,
-			Departures: parsed,
-			Deviations: deviations,
-		})
+		return format.JSON(result)
+	}
+	if format.IsTable(outputFormat) {
+		return departuresTable([]departureResult{result})
 	}
 
-	format.Departures(parsed, stopName)
-	format.DeviationWarnings(deviations)
-	return nil
+	format.Departures(result.Departures, result.Stop, depShowSched)
+	format.DeviationWarnings(result.Deviations)
+	return speakIfRequested(result.Departures, result.Stop)
 }
 
-// fetchRelevantDeviations fetches deviations for lines present in the departures.
-func fetchRelevantDeviations(ctx context.Context, client *api.Client, deps []model.ParsedDeparture) []format.DeviationWarning {
-	lineSet := make(map[string]bool)
+// departuresFrom drops departures leaving before at, for --at within the
+// real-time horizon. A zero at keeps them all.
+func departuresFrom(deps []sl.ParsedDeparture, at time.Time) []sl.ParsedDeparture {
+	if at.IsZero() {
+		return deps
+	}
+	var kept []sl.ParsedDeparture
 	for _, d := range deps {
-		if d.Line != "" {
-			lineSet[d.Line] = true
+		when := d.Expected
+		if when.IsZero() {
+			when = d.Scheduled
+		}
+		if !when.Before(at) {
+			kept = append(kept, d)
 		}
 	}
+	return kept
+}
 
-	if len(lineSet) == 0 {
+// printScheduledDepartures shows the timetabled board for --at beyond the
+// real-time horizon, filtered as the Transport API would filter live data.
+func printScheduledDepartures(ctx context.Context, client *sl.Client, siteID int) error {
+	key, err := apiKey(keys.TrafiklabStatic)
+	if err != nil {
+		return fmt.Errorf("departures more than %s ahead come from the timetable: %w", sl.RealtimeHorizon, err)
+	}
+	sites, err := client.GetSitesCached(ctx)
+	if err != nil {
+		return fmt.Errorf("fetching sites: %w", err)
+	}
+	i := slices.IndexFunc(sites, func(s sl.Site) bool { return s.ID == siteID })
+	if i < 0 {
+		return sl.Errorf(sl.ErrNotFound, "site %d not found", siteID)
+	}
+	site := sites[i]
+
+	tt, err := loadTimetable(ctx, client, key)
+	if err != nil {
+		return err
+	}
+
+	parsed := sl.ScheduledDepartures(tt, site, depAtTime, sl.RealtimeHorizon)
+	parsed = limitDepartures(filterDepartures(parsed))
+
+	if !jsonOutput && textFormat() {
+		fmt.Fprintf(format.Stderr(), "📅 Timetable for %s: scheduled times only, no real-time data\n", depAtTime.Format("Mon 02 Jan 15:04"))
+	}
+	return printDepartureResult(ctx, departureResult{
+		Stop:       site.Name,
+		SiteID:     siteID,
+		Departures: parsed,
+		Deviations: []format.DeviationWarning{},
+	})
+}
+
+// filterDepartures applies --mode, --line, --direction and --towards-file
+// to departures from a source that can't filter them itself.
+func filterDepartures(deps []sl.ParsedDeparture) []sl.ParsedDeparture {
+	if depMode != "" {
+		deps = sl.FilterByTransportMode(deps, depMode)
+	}
+	code, codeErr := strconv.Atoi(depDirection)
+	var needles []string
+	if depDirection != "" && codeErr != nil {
+		needles = append(needles, sl.DirectionNeedle(depDirection))
+	}
+	for _, t := range depTowards {
+		needles = append(needles, sl.DirectionNeedle(t))
+	}
+	var kept []sl.ParsedDeparture
+	for _, d := range deps {
+		if depLine != "" && !strings.EqualFold(d.Line, depLine) {
+			continue
+		}
+		if depDirection != "" && codeErr == nil && d.DirectionCode != code {
+			continue
+		}
+		if len(needles) > 0 && !slices.ContainsFunc(needles, func(n string) bool {
+			return strings.Contains(strings.ToLower(d.Destination), n)
+		}) {
+			continue
+		}
+		kept = append(kept, d)
+	}
+	return kept
+}
+
+// readTowardsFile reads the destinations for --towards-file.
+func readTowardsFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading --towards-file: %w", err)
+	}
+	dests := listLines(data)
+	if len(dests) == 0 {
+		return nil, fmt.Errorf("--towards-file %s lists no destinations", path)
+	}
+	return dests, nil
+}
+
+// listLines splits a list file into its entries, one per line, skipping
+// blank lines and # comments.
+func listLines(data []byte) []string {
+	var entries []string
+	for line := range strings.Lines(string(data)) {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			entries = append(entries, line)
+		}
+	}
+	return entries
+}
+
+// plannerMonitorLimit is how many departures are asked of the planner's
+// departure monitor, about as many as the Transport API lists in an hour
+// at a busy stop.
+const plannerMonitorLimit = 40
+
+// fetchAndPrintPlannerDepartures shows the board from the journey
+// planner's departure monitor, for --source planner.
+func fetchAndPrintPlannerDepartures(ctx context.Context, client *sl.Client, siteID int) error {
+	resp, err := client.GetDepartureMonitor(ctx, sl.DepartureMonitorOptions{
+		StopID:   sl.PlannerStopID(siteID),
+		At:       depAtTime,
+		Limit:    plannerMonitorLimit,
+		Language: i18n.Language(),
+	})
+	if err == nil {
+		err = plannerMessagesError(resp.SystemMessages)
+	}
+	if err != nil {
+		return fmt.Errorf("fetching departure monitor: %w", err)
+	}
+
+	parsed := filterDepartures(sl.ParseStopEvents(resp.StopEvents))
+	deviations := fetchRelevantDeviations(ctx, client, parsed)
+	parsed = limitDepartures(parsed)
+	markCatchable(parsed, 0)
+
+	stopName := fmt.Sprintf("Site %d", siteID)
+	if len(parsed) > 0 {
+		stopName = parsed[0].StopArea
+	}
+	return printDepartureResult(ctx, departureResult{
+		Stop:       stopName,
+		SiteID:     siteID,
+		Departures: parsed,
+		Deviations: deviations,
+	})
+}
+
+// departuresTable writes the departures of every stop as one table.
+func departuresTable(results []departureResult) error {
+	var rows []format.StopDeparture
+	for _, r := range results {
+		rows = append(rows, r.stopDepartures()...)
+	}
+	return format.Table(os.Stdout, outputFormat, format.DepartureColumns, rows)
+}
+
+// speakIfRequested announces departures when --speak is set.
+func speakIfRequested(deps []sl.ParsedDeparture, stopName string) error {
+	if !depSpeak {
+		return nil
+	}
+	return announceDepartures(deps, stopName)
+}
+
+// limitDepartures applies --after, --within, --per-line and --limit. With
+// --per-line the default --limit is ignored so it can't cut whole lines off
+// the board.
+func limitDepartures(parsed []sl.ParsedDeparture) []sl.ParsedDeparture {
+	parsed = sl.FilterByWindow(parsed, depAfter, depWithin)
+	parsed = sl.LimitPerLine(parsed, depPerLine)
+	if depPerLine > 0 && !depLimitSet {
+		return parsed
+	}
+	return window(parsed, depLimit, 0)
+}
+
+// markCatchable marks departures against --walk, or failing that the
+// estimated walk to a stop distanceKm away (known only for --address).
+func markCatchable(deps []sl.ParsedDeparture, distanceKm float64) {
+	walk := depWalk
+	if walk == 0 {
+		if depAddress == "" {
+			return
+		}
+		walk = sl.WalkingTime(distanceKm)
+	}
+	sl.MarkCatchable(deps, walk)
+}
+
+// writeDeparturesHTML renders results as a standalone HTML board to --output,
+// or stdout when no file is given.
+func writeDeparturesHTML(results []departureResult) error {
+	boards := make([]format.HTMLBoard, 0, len(results))
+	for _, r := range results {
+		boards = append(boards, format.HTMLBoard{
+			Stop:       r.Stop,
+			DistanceM:  r.DistanceM,
+			Departures: r.Departures,
+			Deviations: r.Deviations,
+			AsOf:       r.AsOf,
+		})
+	}
+	title := "Departures"
+	if len(results) == 1 {
+		title = results[0].Stop
+	}
+	style, err := boardStyle(depBoard, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if depOutput == "" {
+		return format.DeparturesHTML(os.Stdout, title, boards, depRefresh, style)
+	}
+
+	// Write to a temp file and rename, so a kiosk browser reloading the page
+	// never sees a half-written board.
+	tmp, err := os.CreateTemp(filepath.Dir(depOutput), ".sl-board-*.html")
+	if err != nil {
+		return fmt.Errorf("creating output file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := format.DeparturesHTML(tmp, title, boards, depRefresh, style); err != nil {
+		tmp.Close()
+		return fmt.Errorf("rendering html: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing output file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("writing output file: %w", err)
+	}
+	return os.Rename(tmp.Name(), depOutput)
+}
+
+// fetchRelevantDeviations fetches deviations for lines present in the departures.
+func fetchRelevantDeviations(ctx context.Context, client *sl.Client, deps []sl.ParsedDeparture) []format.DeviationWarning {
+	if depNoDevs || len(deps) == 0 {
 		return []format.DeviationWarning{}
 	}
 
@@ -319,31 +1141,52 @@ func fetchRelevantDeviations(ctx context.Context, client *api.Client, deps []mod
 	for m := range modeSet {
 		modes = append(modes, m)
 	}
+	sort.Strings(modes)
 
-	devs, err := client.GetDeviations(ctx, api.DeviationOptions{
+	devs, err := client.GetDeviations(ctx, sl.DeviationOptions{
 		TransportModes: modes,
 	})
 	if err != nil {
+		client.Warn(sl.WarnPartialDeviations, "could not fetch deviations: %v", err)
 		return []format.DeviationWarning{}
 	}
 
+	return matchDeviations(devs, deps)
+}
+
+// matchDeviations returns warnings for deviations affecting lines present in the departures.
+func matchDeviations(devs []sl.Deviation, deps []sl.ParsedDeparture) []format.DeviationWarning {
+	lineSet := make(map[string]bool)
+	for _, d := range deps {
+		if d.Line != "" {
+			lineSet[d.Line] = true
+		}
+	}
+	return lineDeviations(devs, lineSet)
+}
+
+// lineDeviations returns warnings for deviations affecting any of lineSet.
+func lineDeviations(devs []sl.Deviation, lineSet map[string]bool) []format.DeviationWarning {
 	results := []format.DeviationWarning{}
+	if len(lineSet) == 0 {
+		return results
+	}
+
 	for _, dev := range devs {
 		if dev.Scope == nil {
 			continue
 		}
 		for _, line := range dev.Scope.Lines {
 			if lineSet[line.Designation] {
-				for _, msg := range dev.MessageVariants {
-					if msg.Language == "en" || (msg.Language == "sv" && len(dev.MessageVariants) == 1) {
-						results = append(results, format.DeviationWarning{
-							Line:    line.Designation,
-							Header:  msg.Header,
-							Details: truncate(msg.Details, 150),
-							Scope:   msg.ScopeAlias,
-						})
-						break
-					}
+				if msg, ok := sl.MessageVariantFor(dev.MessageVariants, i18n.Language()); ok {
+					results = append(results, format.DeviationWarning{
+						Line:     line.Designation,
+						Header:   msg.Header,
+						Details:  truncate(msg.Details, 150),
+						Scope:    msg.ScopeAlias,
+						Severity: dev.Severity,
+						URL:      msg.Weblink,
+					})
 				}
 				break
 			}
@@ -360,54 +1203,92 @@ func truncate(s string, maxLen int) string {
 	return s[:maxLen] + "..."
 }
 
-func geocodeAddress(ctx context.Context, client *api.Client, address string) (lat, lon float64, name string, err error) {
+// geocodeAddress locates an address, place name or bookmark.
+func geocodeAddress(ctx context.Context, client *sl.Client, address string) (lat, lon float64, name string, err error) {
+	if fav, ok := lookupBookmark(address); ok {
+		if lat, lon, ok := parseLatLon(fav); ok {
+			explainResolved(resolutionExplanation{
+				Input:  address,
+				Source: sourceBookmark,
+				Winner: fav,
+				Reason: "the bookmark holds coordinates",
+			})
+			return lat, lon, address, nil
+		}
+		address = fav
+	}
+	return lookupAddress(ctx, client, address)
+}
+
+// lookupAddress geocodes an address or place name with the journey planner.
+func lookupAddress(ctx context.Context, client *sl.Client, address string) (lat, lon float64, name string, err error) {
 	locations, err := client.FindAddress(ctx, address)
 	if err != nil {
 		return 0, 0, "", err
 	}
 	if len(locations) == 0 {
-		return 0, 0, "", fmt.Errorf("no location found for %q", address)
+		return 0, 0, "", sl.Errorf(sl.ErrNotFound, "no location found for %q", address)
 	}
+	explainStopFinder(address, locations)
 	loc := locations[0]
+	emitProgress(progressEvent{Event: "geocoded", Name: loc.Name, Lat: loc.Coord[0], Lon: loc.Coord[1]})
 	return loc.Coord[0], loc.Coord[1], loc.Name, nil
 }
 
-func resolveSiteID(ctx context.Context, client *api.Client, name string) (int, error) {
+// resolveSiteID finds the site for a bookmark or a (partial) stop name.
+func resolveSiteID(ctx context.Context, client *sl.Client, name string) (int, error) {
 	sites, err := client.GetSitesCached(ctx)
 	if err != nil {
 		return 0, fmt.Errorf("fetching sites: %w", err)
 	}
 
-	nameLower := strings.ToLower(name)
-	var matches []struct {
-		id   int
-		name string
-	}
-
-	for _, s := range sites {
-		sNameLower := strings.ToLower(s.Name)
-		if sNameLower == nameLower {
-			return s.ID, nil
-		}
-		if strings.Contains(sNameLower, nameLower) {
-			matches = append(matches, struct {
-				id   int
-				name string
-			}{s.ID, s.Name})
+	if fav, ok := lookupBookmark(name); ok {
+		site, _, _, err := favoriteSite(ctx, client, sites, fav, bookmarkRadiusKm)
+		if err != nil {
+			return 0, fmt.Errorf("bookmark %q: %w", name, err)
 		}
+		explainResolved(resolutionExplanation{
+			Input:  name,
+			Source: sourceBookmark,
+			Winner: fmt.Sprintf("%s (id:%d)", site.Name, site.ID),
+			Reason: fmt.Sprintf("bookmark %q is %q; this is its stop", name, fav),
+		})
+		return site.ID, nil
 	}
 
-	if len(matches) == 1 {
-		return matches[0].id, nil
+	// An exact name wins outright; otherwise the name must pick out a
+	// single stop.
+	matches := siteCandidates(sites, name)
+	explain := func(winner, reason string) {
+		explainResolved(resolutionExplanation{Input: name, Source: sourceSiteCache, Candidates: matches, Winner: winner, Reason: reason})
 	}
-	if len(matches) > 1 {
-		fmt.Fprintf(os.Stderr, "Multiple matches found:\n")
+	switch {
+	case len(matches) > 0 && matches[0].Score == scoreExact:
+		explain(matches[0].Name+" (id:"+matches[0].ID+")", "exact name match")
+		id, _ := strconv.Atoi(matches[0].ID)
+		return id, nil
+	case len(matches) == 1:
+		explain(matches[0].Name+" (id:"+matches[0].ID+")", fmt.Sprintf("the only stop whose name contains %q", name))
+		id, _ := strconv.Atoi(matches[0].ID)
+		return id, nil
+	case len(matches) > 1:
+		explain("", fmt.Sprintf("ambiguous: %d stops contain %q and none is named exactly that", len(matches), name))
+		if !jsonOutput {
+			// --json lists the candidates in the error instead.
+			fmt.Fprintf(format.Stderr(), "Multiple matches found:\n")
+			for _, m := range matches {
+				fmt.Fprintf(format.Stderr(), "  %s (id:%s)\n", m.Name, m.ID)
+			}
+			fmt.Fprintf(format.Stderr(), "\nUse --site <id> to specify.\n")
+		}
+		ambiguous := &sl.AmbiguousStopError{Name: name}
 		for _, m := range matches {
-			fmt.Fprintf(os.Stderr, "  %s (id:%d)\n", m.name, m.id)
+			id, _ := strconv.Atoi(m.ID)
+			ambiguous.Candidates = append(ambiguous.Candidates, sl.Site{ID: id, Name: m.Name})
 		}
-		fmt.Fprintf(os.Stderr, "\nUse --site <id> to specify.\n")
-		return 0, fmt.Errorf("ambiguous stop name %q — %d matches", name, len(matches))
+		return 0, ambiguous
 	}
 
-	return 0, fmt.Errorf("no stop found matching %q", name)
+	explain("", fmt.Sprintf("no stop name contains %q", name))
+	return 0, sl.Errorf(sl.ErrNotFound, "no stop found matching %q", name)
 }
@@ -1,7 +1,20 @@
 package cmd
 
 import (
+	"context"
+	"encoding/csv"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/glundgren93/sl-cli/internal/config"
+	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/pkg/sl"
 )
 
 func TestTruncate(t *testing.T) {
@@ -24,3 +37,242 @@ func TestTruncate(t *testing.T) {
 		}
 	}
 }
+
+func TestMatchDeviations(t *testing.T) {
+	devs := []sl.Deviation{
+		{
+			MessageVariants: []sl.MessageVariant{
+				{Header: "Buss 55 försenad", Language: "sv"},
+				{Header: "Bus 55 delayed", Language: "en"},
+			},
+			Scope: &sl.DeviationScope{Lines: []sl.Line{{Designation: "55"}}},
+		},
+		{
+			MessageVariants: []sl.MessageVariant{{Header: "Spårarbete", Language: "sv"}},
+			Scope:           &sl.DeviationScope{Lines: []sl.Line{{Designation: "17"}}},
+		},
+		{
+			MessageVariants: []sl.MessageVariant{{Header: "Unrelated", Language: "en"}},
+			Scope:           &sl.DeviationScope{Lines: []sl.Line{{Designation: "4"}}},
+		},
+		{Scope: nil},
+	}
+	deps := []sl.ParsedDeparture{{Line: "55"}, {Line: "17"}, {Line: "55"}}
+
+	got := matchDeviations(devs, deps)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 warnings, got %d", len(got))
+	}
+	if got[0].Header != "Bus 55 delayed" {
+		t.Errorf("expected English variant, got %q", got[0].Header)
+	}
+	if got[1].Header != "Spårarbete" {
+		t.Errorf("expected sole Swedish variant, got %q", got[1].Header)
+	}
+
+	if got := matchDeviations(devs, nil); got == nil || len(got) != 0 {
+		t.Errorf("expected empty non-nil slice for no departures, got %v", got)
+	}
+}
+
+func TestPickStop(t *testing.T) {
+	scans := []stopScan{
+		{parsed: nil}, // closest, no match
+		{parsed: []sl.ParsedDeparture{{MinutesLeft: 9}, {MinutesLeft: 14}}},
+		{parsed: []sl.ParsedDeparture{{MinutesLeft: 3}}},
+		{parsed: []sl.ParsedDeparture{{MinutesLeft: 3}}}, // tie, further away
+	}
+
+	if got := pickStop(scans, "nearest"); got != 1 {
+		t.Errorf("nearest = %d, want 1", got)
+	}
+	if got := pickStop(scans, "soonest"); got != 2 {
+		t.Errorf("soonest = %d, want 2", got)
+	}
+	if got := pickStop([]stopScan{{}, {}}, "nearest"); got != -1 {
+		t.Errorf("no matches = %d, want -1", got)
+	}
+}
+
+func TestAppendDepartureCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.csv")
+	sched := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	deps := []sl.ParsedDeparture{
+		{Line: "17", DirectionCode: 1, Scheduled: sched, Expected: sched, State: "EXPECTED", Display: "5 min"},
+		{Line: "55", DirectionCode: 2, Scheduled: sched, Expected: sched, State: "EXPECTED", Display: "5 min"},
+	}
+	now := sched.Add(-5 * time.Minute)
+
+	if n, err := appendDepartureCSV(path, 9530, deps, now); err != nil || n != 2 {
+		t.Fatalf("first run wrote %d rows (err %v), want 2", n, err)
+	}
+	// Only the countdown changed: nothing new to log.
+	deps[0].Display = "4 min"
+	if n, _ := appendDepartureCSV(path, 9530, deps, now.Add(time.Minute)); n != 0 {
+		t.Errorf("unchanged departures wrote %d rows, want 0", n)
+	}
+	deps[1].Expected = sched.Add(3 * time.Minute)
+	if n, _ := appendDepartureCSV(path, 9530, deps, now.Add(2*time.Minute)); n != 1 {
+		t.Errorf("one delayed departure wrote %d rows, want 1", n)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 || rows[0][0] != "observed_at" {
+		t.Fatalf("want header and 3 rows, got %v", rows)
+	}
+	if got := rows[3][9]; got != "3" {
+		t.Errorf("delay_min = %q, want 3", got)
+	}
+}
+
+func TestReadDepartureLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.csv")
+	sched := time.Date(2024, 3, 1, 8, 10, 0, 0, time.UTC)
+	deps := []sl.ParsedDeparture{
+		{Line: "55", Destination: "Tanto", DirectionCode: 1, Scheduled: sched, Expected: sched.Add(2 * time.Minute), State: "EXPECTED"},
+		{Line: "17", Destination: "Åkeshov", DirectionCode: 2, Scheduled: sched, State: "CANCELLED"},
+	}
+	if _, err := appendDepartureCSV(path, 9530, deps, sched.Add(-5*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := readDepartureLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("want 2 rows, got %v", rows)
+	}
+	if rows[0][1] != int64(9530) || rows[0][9] != int64(2) || !rows[0][7].(time.Time).Equal(sched) {
+		t.Errorf("first row not typed: %v", rows[0])
+	}
+	if rows[1][8] != nil || rows[1][9] != nil {
+		t.Errorf("cancelled departure should have null expected and delay: %v", rows[1])
+	}
+
+	if err := os.WriteFile(path, []byte("a,b\n1,2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readDepartureLog(path); err == nil {
+		t.Error("expected an error for a file that isn't a departure log")
+	}
+}
+
+func TestRecordedDepartures(t *testing.T) {
+	dir := t.TempDir()
+	sched := time.Date(2024, 3, 4, 8, 10, 0, 0, time.UTC)
+	tanto := sl.ParsedDeparture{Line: "55", DirectionCode: 1, Scheduled: sched, Expected: sched, State: "EXPECTED"}
+	log := func(name string, site int, at time.Time, deps ...sl.ParsedDeparture) string {
+		path := filepath.Join(dir, name)
+		if _, err := appendDepartureCSV(path, site, deps, at); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	delayed := tanto
+	delayed.Expected = sched.Add(3 * time.Minute)
+	cancelled := tanto
+	cancelled.Scheduled, cancelled.State = sched.Add(20*time.Minute), "CANCELLED"
+	other := sl.ParsedDeparture{Line: "17", DirectionCode: 2, Scheduled: sched.Add(5 * time.Minute), State: "EXPECTED"}
+
+	a := log("a.csv", 9530, sched.Add(-10*time.Minute), tanto, cancelled, other)
+	log("a.csv", 9530, sched.Add(-5*time.Minute), delayed)
+	b := log("b.csv", 9191, sched, tanto)
+
+	freqLine = "55"
+	t.Cleanup(func() { freqLine = "" })
+	line, recorded, err := recordedDepartures([]string{a}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(line) != 1 || !line[0].Equal(sched) || len(recorded) != 3 {
+		t.Errorf("got line %v of %d recorded, want the one 55 that ran of 3", line, len(recorded))
+	}
+
+	if _, _, err := recordedDepartures([]string{a, b}, 0); err == nil {
+		t.Error("expected an error for recordings of several stops without --site")
+	}
+	if line, _, err := recordedDepartures([]string{a, b}, 9191); err != nil || len(line) != 1 {
+		t.Errorf("--site 9191: got %v, %v; want one departure", line, err)
+	}
+}
+
+func TestBoardStyle(t *testing.T) {
+	at := func(clock string) time.Time {
+		loc, _ := time.LoadLocation("Europe/Stockholm")
+		ts, _ := time.ParseInLocation("2006-01-02 15:04", "2024-03-01 "+clock, loc)
+		return ts
+	}
+	auto := config.Board{Theme: "auto", NightBrightness: 0.3}
+	tests := []struct {
+		name       string
+		board      config.Board
+		now        string
+		theme      string
+		brightness float64
+	}{
+		{"default is dark", config.Board{}, "12:00", "", 0},
+		{"auto by day", auto, "12:00", format.ThemeDay, 0},
+		{"auto at night", auto, "23:30", format.ThemeNight, 0.3},
+		{"auto before dawn", auto, "06:59", format.ThemeNight, 0.3},
+		{"fixed theme still dims", config.Board{Theme: "day", NightBrightness: 0.5}, "02:00", format.ThemeDay, 0.5},
+		{"day window across midnight", config.Board{Theme: "auto", Day: "20:00", Night: "08:00"}, "23:00", format.ThemeDay, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := boardStyle(tt.board, at(tt.now))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Theme != tt.theme || got.Brightness != tt.brightness {
+				t.Errorf("got %+v, want theme %q brightness %g", got, tt.theme, tt.brightness)
+			}
+		})
+	}
+
+	for _, bad := range []config.Board{{Theme: "sepia"}, {Night: "25:00"}, {NightBrightness: 2}} {
+		if _, err := boardStyle(bad, at("12:00")); err == nil {
+			t.Errorf("boardStyle(%+v): expected an error", bad)
+		}
+	}
+}
+
+func TestScanStopsNearestStopsEarly(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	var mu sync.Mutex
+	var requested []string
+	retries := 0
+	client := sl.New(sl.Options{Retries: &retries, Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		mu.Lock()
+		requested = append(requested, r.URL.Path)
+		mu.Unlock()
+		if !strings.Contains(r.URL.Path, "/sites/1/") {
+			// Further stops answer only once the scan gives up on them.
+			<-r.Context().Done()
+			return nil, r.Context().Err()
+		}
+		body := `{"departures":[{"display":"3 min","line":{"designation":"17","transport_mode":"METRO"}}]}`
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Content-Type": {"application/json"}},
+			Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+	})})
+
+	var stops []sl.SiteWithDistance
+	for id := 1; id <= 3*maxConcurrentScans; id++ {
+		stops = append(stops, sl.SiteWithDistance{Site: sl.Site{ID: id}, DistanceKm: float64(id) / 10})
+	}
+	scans := scanStops(context.Background(), client, stops, true)
+	if got := pickStop(scans, "nearest"); got != 0 {
+		t.Errorf("nearest = %d, want 0", got)
+	}
+	if len(requested) > maxConcurrentScans {
+		t.Errorf("scanned %d stops after the nearest matched: %v", len(requested), requested)
+	}
+}
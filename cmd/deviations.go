@@ -3,20 +3,29 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
 	"strconv"
 	"strings"
+	"time"
 
-	"github.com/glundgren93/sl-cli/internal/api"
 	"github.com/glundgren93/sl-cli/internal/format"
-	"github.com/glundgren93/sl-cli/internal/model"
+	"github.com/glundgren93/sl-cli/pkg/sl"
 	"github.com/spf13/cobra"
 )
 
 var (
-	devLines  string
-	devSites  string
-	devModes  string
-	devFuture bool
+	devLines    string
+	devSites    string
+	devModes    string
+	devFuture   bool
+	devCalendar bool
+	devWeeks    int
+	devICal     bool
+	devMinSev   string
+	devLimit    int
+	devPage     int
+	devOffset   int
+	devFull     bool
 )
 
 var deviationsCmd = &cobra.Command{
@@ -32,9 +41,17 @@ Examples:
   sl deviations --line 55                      # Line 55 only
   sl deviations --line 17,18,19                # Multiple lines
   sl deviations --future                       # Include planned deviations
+  sl deviations --min-severity major           # Only major and critical
+  sl deviations --limit 10 --page 2            # Second page of ten
+  sl deviations --limit 10 --offset 5          # Ten, skipping the first five
+  sl deviations --line 55 --full               # Full details
+  sl deviations --future --calendar --line 17  # Planned works as a week calendar
+  sl deviations --future --ical > works.ics    # Planned works as iCalendar
+  sl deviations --format csv > devs.csv        # One row per deviation
   sl deviations --json                         # JSON output`,
-	Aliases: []string{"dev", "status"},
-	RunE:    runDeviations,
+	Aliases:     []string{"dev", "status"},
+	Annotations: formats(format.TableFormats...),
+	RunE:        runDeviations,
 }
 
 func init() {
@@ -42,18 +59,36 @@ func init() {
 	deviationsCmd.Flags().StringVar(&devSites, "site", "", "Filter by site ID(s), comma-separated")
 	deviationsCmd.Flags().StringVar(&devModes, "mode", "", "Filter by transport mode(s): BUS,METRO,TRAIN,TRAM,SHIP")
 	deviationsCmd.Flags().BoolVar(&devFuture, "future", false, "Include future/planned deviations")
+	deviationsCmd.Flags().BoolVar(&devCalendar, "calendar", false, "Show deviations as a week-by-week calendar")
+	deviationsCmd.Flags().IntVar(&devWeeks, "weeks", 4, "Number of weeks shown with --calendar")
+	deviationsCmd.Flags().BoolVar(&devICal, "ical", false, "Output deviations as an iCalendar feed")
+	deviationsCmd.Flags().IntVar(&devLimit, "limit", 0, "Max deviations per page (0 = all)")
+	deviationsCmd.Flags().IntVar(&devPage, "page", 1, "Page number when using --limit")
+	deviationsCmd.Flags().IntVar(&devOffset, "offset", 0, "Skip this many deviations (instead of --page)")
+	deviationsCmd.MarkFlagsMutuallyExclusive("page", "offset")
+	deviationsCmd.Flags().BoolVar(&devFull, "full", false, "Show full details instead of one line per deviation")
+	deviationsCmd.Flags().StringVar(&devMinSev, "min-severity", "", "Minimum severity: info, minor, major, critical")
 
 	rootCmd.AddCommand(deviationsCmd)
 }
 
 func runDeviations(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
-	client := api.NewClient()
+	client := newClient()
 
-	opts := api.DeviationOptions{
+	opts := sl.DeviationOptions{
 		Future: devFuture,
 	}
 
+	minSeverity := sl.SeverityInfo
+	if devMinSev != "" {
+		sev, err := sl.ParseSeverity(devMinSev)
+		if err != nil {
+			return &usageError{err}
+		}
+		minSeverity = sev
+	}
+
 	// Parse line designations to filter client-side (API uses internal IDs, not designations)
 	var lineDesignations []string
 	if devLines != "" {
@@ -84,23 +119,76 @@ func runDeviations(cmd *cobra.Command, args []string) error {
 	if len(lineDesignations) > 0 {
 		devs = filterDeviationsByLine(devs, lineDesignations)
 	}
+	if minSeverity > sl.SeverityInfo {
+		devs = sl.FilterBySeverity(devs, minSeverity)
+	}
+
+	if devICal {
+		return format.DeviationICal(os.Stdout, format.CalendarEntries(devs))
+	}
+
+	if devCalendar && !jsonOutput {
+		format.DeviationCalendar(format.CalendarEntries(devs), time.Now(), devWeeks)
+		return nil
+	}
+
+	if devPage < 1 {
+		return usageErrorf("--page must be 1 or greater")
+	}
+	if err := checkPaging(devLimit, devOffset); err != nil {
+		return err
+	}
+	total := len(devs)
+	start, end := pageBounds(total, devLimit, devPage)
+	if devOffset > 0 {
+		start, end = windowBounds(total, devLimit, devOffset)
+	}
+	page := devs[start:end]
 
 	if jsonOutput {
+		// Paging wraps the list with counts; the plain array is kept otherwise
+		// so existing consumers don't break.
+		if devLimit > 0 || devOffset > 0 {
+			env := deviationsPage{
+				Total:      total,
+				Offset:     start,
+				Limit:      devLimit,
+				Deviations: page,
+			}
+			if devLimit > 0 {
+				env.Page = start/devLimit + 1
+				env.Pages = (total + devLimit - 1) / devLimit
+			}
+			return format.JSON(env)
+		}
 		return format.JSON(devs)
 	}
+	if format.IsTable(outputFormat) {
+		return format.Table(os.Stdout, outputFormat, format.DeviationColumns, page)
+	}
 
-	format.Deviations(devs)
+	format.Deviations(page, format.DeviationPage{Total: total, Offset: start, Full: devFull})
 	return nil
 }
 
+// deviationsPage is the JSON output for a paged deviations listing.
+type deviationsPage struct {
+	Total      int            `json:"total"`
+	Offset     int            `json:"offset"`
+	Page       int            `json:"page,omitempty"`
+	Pages      int            `json:"pages,omitempty"`
+	Limit      int            `json:"limit"`
+	Deviations []sl.Deviation `json:"deviations"`
+}
+
 // filterDeviationsByLine filters deviations to only those affecting the given line designations.
-func filterDeviationsByLine(devs []model.Deviation, designations []string) []model.Deviation {
+func filterDeviationsByLine(devs []sl.Deviation, designations []string) []sl.Deviation {
 	designSet := make(map[string]bool)
 	for _, d := range designations {
 		designSet[strings.ToLower(d)] = true
 	}
 
-	filtered := []model.Deviation{}
+	filtered := []sl.Deviation{}
 	for _, dev := range devs {
 		if dev.Scope == nil {
 			continue
@@ -3,30 +3,30 @@ package cmd
 import (
 	"testing"
 
-	"github.com/glundgren93/sl-cli/internal/model"
+	"github.com/glundgren93/sl-cli/pkg/sl"
 )
 
 func TestFilterDeviationsByLine(t *testing.T) {
-	devs := []model.Deviation{
+	devs := []sl.Deviation{
 		{
 			DeviationCaseID: 1,
-			MessageVariants: []model.MessageVariant{{Header: "Bus 55 delayed", Language: "en"}},
-			Scope: &model.DeviationScope{
-				Lines: []model.Line{{Designation: "55", TransportMode: "BUS"}},
+			MessageVariants: []sl.MessageVariant{{Header: "Bus 55 delayed", Language: "en"}},
+			Scope: &sl.DeviationScope{
+				Lines: []sl.Line{{Designation: "55", TransportMode: "BUS"}},
 			},
 		},
 		{
 			DeviationCaseID: 2,
-			MessageVariants: []model.MessageVariant{{Header: "Metro 17 works", Language: "en"}},
-			Scope: &model.DeviationScope{
-				Lines: []model.Line{{Designation: "17", TransportMode: "METRO"}},
+			MessageVariants: []sl.MessageVariant{{Header: "Metro 17 works", Language: "en"}},
+			Scope: &sl.DeviationScope{
+				Lines: []sl.Line{{Designation: "17", TransportMode: "METRO"}},
 			},
 		},
 		{
 			DeviationCaseID: 3,
-			MessageVariants: []model.MessageVariant{{Header: "Train 43 issue", Language: "en"}},
-			Scope: &model.DeviationScope{
-				Lines: []model.Line{{Designation: "43", TransportMode: "TRAIN"}},
+			MessageVariants: []sl.MessageVariant{{Header: "Train 43 issue", Language: "en"}},
+			Scope: &sl.DeviationScope{
+				Lines: []sl.Line{{Designation: "43", TransportMode: "TRAIN"}},
 			},
 		},
 		{
@@ -68,3 +68,43 @@ func TestFilterDeviationsByLine(t *testing.T) {
 		t.Errorf("expected 0 deviations for empty filter, got %d", len(filtered))
 	}
 }
+
+func TestPageBounds(t *testing.T) {
+	tests := []struct {
+		total, limit, page int
+		start, end         int
+	}{
+		{25, 0, 1, 0, 25},
+		{25, 10, 1, 0, 10},
+		{25, 10, 3, 20, 25},
+		{25, 10, 4, 25, 25},
+		{0, 10, 1, 0, 0},
+	}
+	for _, tt := range tests {
+		start, end := pageBounds(tt.total, tt.limit, tt.page)
+		if start != tt.start || end != tt.end {
+			t.Errorf("pageBounds(%d, %d, %d) = %d, %d, want %d, %d",
+				tt.total, tt.limit, tt.page, start, end, tt.start, tt.end)
+		}
+	}
+}
+
+func TestWindowBounds(t *testing.T) {
+	tests := []struct {
+		total, limit, offset int
+		start, end           int
+	}{
+		{25, 0, 0, 0, 25},
+		{25, 0, 5, 5, 25},
+		{25, 10, 5, 5, 15},
+		{25, 10, 20, 20, 25},
+		{25, 10, 30, 25, 25},
+	}
+	for _, tt := range tests {
+		start, end := windowBounds(tt.total, tt.limit, tt.offset)
+		if start != tt.start || end != tt.end {
+			t.Errorf("windowBounds(%d, %d, %d) = %d, %d, want %d, %d",
+				tt.total, tt.limit, tt.offset, start, end, tt.start, tt.end)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+// Exit statuses, by failure class, for scripts to branch on.
+const (
+	ExitOK         = 0
+	ExitError      = 1 // any other failure
+	ExitNotFound   = 2 // no such stop, address or line, or no departures
+	ExitAmbiguous  = 3 // a stop name matched several stops
+	ExitAPIError   = 4 // an SL API failed or couldn't be reached
+	ExitUsageError = 5 // unknown command, bad flags or arguments
+	codeUsageError = "USAGE"
+)
+
+// commandStarted is set once a command's flags and arguments have been
+// validated, so Execute can tell usage errors from runtime ones.
+var commandStarted bool
+
+// usageError is an unknown command or bad flags or arguments: every error
+// raised before the command ran, and those its RunE makes with usageErrorf.
+type usageError struct{ err error }
+
+func (e *usageError) Error() string { return e.err.Error() }
+func (e *usageError) Unwrap() error { return e.err }
+
+// usageErrorf is fmt.Errorf for flags or arguments a command rejects once
+// running, such as a missing stop or a flag combination it doesn't support.
+func usageErrorf(format string, args ...any) error {
+	return &usageError{fmt.Errorf(format, args...)}
+}
+
+// ExitCode returns the exit status for an error returned by Execute.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var usage *usageError
+	if errors.As(err, &usage) {
+		return ExitUsageError
+	}
+	switch sl.ErrorCode(err) {
+	case sl.CodeNotFound, sl.CodeNoDepartures:
+		return ExitNotFound
+	case sl.CodeAmbiguousStop:
+		return ExitAmbiguous
+	case sl.CodeAPIUnavailable:
+		return ExitAPIError
+	}
+	if sl.APIStatus(err) != "" {
+		return ExitAPIError
+	}
+	return ExitError
+}
+
+// errorCode is the code of err in the JSON error envelope.
+func errorCode(err error) string {
+	var usage *usageError
+	if errors.As(err, &usage) {
+		return codeUsageError
+	}
+	return sl.ErrorCode(err)
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/internal/i18n"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+	"github.com/spf13/cobra"
+)
+
+var (
+	explainFrom  string
+	explainTo    string
+	explainRoute int
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain",
+	Short: "Explain the changes in a planned trip",
+	Long: `Drill down into the interchanges of a trip: where you change, which
+platforms are involved, the walk between them, and whether the connection
+still holds given current delays.
+
+Routes are numbered as in 'sl trip'; the same plan is reused from the trip
+cache when it was looked up in the last couple of minutes.
+
+Examples:
+  sl explain --from "Medborgarplatsen" --to "Södertälje C"
+  sl explain --from "Slussen" --to "Arlanda" --route 2 --json`,
+	RunE: runExplain,
+}
+
+func init() {
+	explainCmd.Flags().StringVar(&explainFrom, "from", "", "Origin (stop name, address, or stop ID)")
+	explainCmd.Flags().StringVar(&explainTo, "to", "", "Destination (stop name, address, or stop ID)")
+	explainCmd.Flags().IntVar(&explainRoute, "route", 1, "Which route of the trip plan to explain (1 = first)")
+
+	explainCmd.MarkFlagRequired("from")
+	explainCmd.MarkFlagRequired("to")
+
+	explainCmd.RegisterFlagCompletionFunc("from", completeStops)
+	explainCmd.RegisterFlagCompletionFunc("to", completeStops)
+
+	rootCmd.AddCommand(explainCmd)
+}
+
+// explainResult is the JSON output for explain.
+type explainResult struct {
+	From         string           `json:"from"`
+	To           string           `json:"to"`
+	Route        int              `json:"route"`
+	Interchanges []sl.Interchange `json:"interchanges"`
+}
+
+func runExplain(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	client := newClient()
+
+	originID, originName, err := resolveLocation(ctx, client, explainFrom)
+	if err != nil {
+		return fmt.Errorf("resolving origin: %w", err)
+	}
+	destID, destName, err := resolveLocation(ctx, client, explainTo)
+	if err != nil {
+		return fmt.Errorf("resolving destination: %w", err)
+	}
+
+	if !jsonOutput {
+		fmt.Fprintf(format.Stderr(), "📍 %s → %s\n\n", originName, destName)
+	}
+
+	// Same options as sl trip's defaults, so the plan (and its route
+	// numbering) comes from the trip cache when possible.
+	opts := sl.TripOptions{
+		OriginID:   originID,
+		DestID:     destID,
+		NumTrips:   3,
+		Language:   i18n.Language(),
+		MaxChanges: -1,
+	}
+	planTrip := client.PlanTripCached
+	if freshData {
+		planTrip = client.PlanTrip
+	}
+	resp, err := planTrip(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("planning trip: %w", err)
+	}
+	if err := plannerError(resp); err != nil {
+		return err
+	}
+
+	if explainRoute < 1 || explainRoute > len(resp.Journeys) {
+		return sl.Errorf(sl.ErrNotFound, "route %d not found: the plan has %d route(s)", explainRoute, len(resp.Journeys))
+	}
+	xs := sl.Interchanges(resp.Journeys[explainRoute-1])
+
+	if jsonOutput {
+		return format.JSON(explainResult{From: originName, To: destName, Route: explainRoute, Interchanges: xs})
+	}
+	format.Interchanges(explainRoute, xs)
+	return nil
+}
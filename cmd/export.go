@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/internal/keys"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+	"github.com/spf13/cobra"
+)
+
+var exportOutput string
+
+// gtfsFeedTimeout bounds the GTFS feed download, which is far larger than
+// any API response.
+const gtfsFeedTimeout = 5 * time.Minute
+
+// loadTimetable reads the GTFS static timetable with key, telling the
+// user it may take a while since the first run downloads the feed.
+func loadTimetable(ctx context.Context, client *sl.Client, key string) (*sl.Timetable, error) {
+	client.SetTimeout(gtfsFeedTimeout)
+	if !jsonOutput {
+		fmt.Fprintln(format.Stderr(), "Loading timetable (the first run downloads the GTFS feed)...")
+	}
+	tt, err := client.GetTimetable(ctx, key, freshData)
+	if err != nil {
+		return nil, fmt.Errorf("loading timetable: %w", err)
+	}
+	return tt, nil
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export SL network data for analysis",
+}
+
+var exportGraphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Export the stop connectivity graph",
+	Long: `Export SL's network as a directed graph: stations and stops are nodes,
+and each line adds an edge between every two stops it serves one after
+the other. Edges carry the line, transport mode and number of scheduled
+trips making the hop.
+
+Built from the GTFS Regional static feed, so it needs a Trafiklab key:
+sl keys set trafiklab-static <key>. The graph is cached like sites and
+lines; use --fresh to rebuild it.
+
+Examples:
+  sl export graph -o sl.dot
+  sl export graph --format graphml -o sl.graphml
+  sl export graph --json > sl.json`,
+	Args:        cobra.NoArgs,
+	Annotations: formats("dot", "graphml"),
+	RunE:        runExportGraph,
+}
+
+var exportRecordingsCmd = &cobra.Command{
+	Use:   "recordings LOG.csv...",
+	Short: "Convert recorded departures to Parquet",
+	Long: `Combine departure logs recorded with sl departures --log-csv into one
+Parquet file, so months of observed departures load straight into pandas,
+DuckDB or Spark with proper types: observation, scheduled and expected
+times as timestamps, site, direction and delay as integers.
+
+Examples:
+  sl export recordings slussen-*.csv -o slussen.parquet
+  duckdb -c "SELECT line, avg(delay_min) FROM 'slussen.parquet' GROUP BY line"`,
+	Args:        cobra.MinimumNArgs(1),
+	Annotations: formats("parquet"),
+	RunE:        runExportRecordings,
+}
+
+func init() {
+	exportGraphCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Write to a file instead of stdout")
+	exportRecordingsCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Write to a file instead of stdout")
+
+	exportCmd.AddCommand(exportGraphCmd)
+	exportCmd.AddCommand(exportRecordingsCmd)
+	rootCmd.AddCommand(exportCmd)
+}
+
+func runExportGraph(cmd *cobra.Command, args []string) error {
+	write := format.GraphDOT
+	if outputFormat == "graphml" {
+		write = format.GraphML
+	}
+
+	key, err := apiKey(keys.TrafiklabStatic)
+	if err != nil {
+		return err
+	}
+	client := newClient()
+	client.SetTimeout(gtfsFeedTimeout)
+	if !jsonOutput {
+		fmt.Fprintln(format.Stderr(), "Building network graph (the first run downloads the GTFS feed)...")
+	}
+	g, err := client.GetNetworkGraph(context.Background(), key, freshData)
+	if err != nil {
+		return fmt.Errorf("building network graph: %w", err)
+	}
+
+	if jsonOutput {
+		return format.JSON(g)
+	}
+	if exportOutput == "" {
+		return write(os.Stdout, g)
+	}
+	f, err := os.Create(exportOutput)
+	if err != nil {
+		return fmt.Errorf("creating output file: %w", err)
+	}
+	if err := write(f, g); err != nil {
+		f.Close()
+		return fmt.Errorf("writing graph: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing graph: %w", err)
+	}
+	fmt.Fprintf(format.Stderr(), "✓ %d stops, %d edges → %s\n", len(g.Stops), len(g.Edges), exportOutput)
+	return nil
+}
+
+func runExportRecordings(cmd *cobra.Command, args []string) error {
+	var rows [][]any
+	for _, path := range args {
+		logRows, err := readDepartureLog(path)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, logRows...)
+	}
+
+	if exportOutput == "" {
+		if format.IsTerminal() {
+			return fmt.Errorf("not writing binary Parquet to a terminal; use -o FILE or redirect stdout")
+		}
+		return format.Parquet(os.Stdout, departureLogColumns, rows)
+	}
+	f, err := os.Create(exportOutput)
+	if err != nil {
+		return fmt.Errorf("creating output file: %w", err)
+	}
+	if err := format.Parquet(f, departureLogColumns, rows); err != nil {
+		f.Close()
+		return fmt.Errorf("writing recordings: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing recordings: %w", err)
+	}
+	fmt.Fprintf(format.Stderr(), "✓ %d departure observation(s) from %d log(s) → %s\n", len(rows), len(args), exportOutput)
+	return nil
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/internal/keys"
+	"github.com/spf13/cobra"
+)
+
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Manage Trafiklab API keys for keyed integrations",
+	Long: `Manage API keys for integrations that need them (GTFS-RT realtime,
+GTFS static, ResRobot, Trafikverket). The core commands need no key.
+
+Keys are stored in the OS keychain where available (macOS Keychain,
+Secret Service via secret-tool on Linux), otherwise in a file only
+readable by you under your config directory.
+
+Known keys: ` + strings.Join(keys.Names, ", ") + `
+
+Examples:
+  sl keys set trafiklab-realtime <key>
+  echo "$KEY" | sl keys set resrobot     # read from stdin, keeps it out of shell history
+  sl keys list
+  sl keys remove trafikverket`,
+}
+
+var keysSetCmd = &cobra.Command{
+	Use:         "set <name> [key]",
+	Short:       "Store an API key (reads stdin when key is omitted)",
+	Args:        cobra.RangeArgs(1, 2),
+	Annotations: changesSettings(),
+	RunE:        runKeysSet,
+}
+
+var keysListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Show which API keys are configured",
+	Args:  cobra.NoArgs,
+	RunE:  runKeysList,
+}
+
+var keysRemoveCmd = &cobra.Command{
+	Use:         "remove <name>",
+	Short:       "Delete a stored API key",
+	Aliases:     []string{"rm"},
+	Args:        cobra.ExactArgs(1),
+	Annotations: changesSettings(),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := keys.Remove(args[0]); err != nil {
+			return err
+		}
+		if !jsonOutput {
+			fmt.Fprintf(format.Stderr(), "✓ Removed %s key\n", args[0])
+		}
+		return nil
+	},
+}
+
+func init() {
+	keysCmd.AddCommand(keysSetCmd, keysListCmd, keysRemoveCmd)
+	rootCmd.AddCommand(keysCmd)
+}
+
+func runKeysSet(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	if err := keys.Validate(name); err != nil {
+		return err
+	}
+
+	var value string
+	if len(args) == 2 {
+		value = args[1]
+	} else {
+		line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("reading key from stdin: %w", err)
+		}
+		value = line
+	}
+
+	backend, err := keys.Set(name, value)
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		return format.JSON(keys.Status{Name: name, Set: true, Backend: backend})
+	}
+	fmt.Fprintf(format.Stderr(), "✓ Stored %s key in %s\n", name, backend)
+	return nil
+}
+
+func runKeysList(cmd *cobra.Command, args []string) error {
+	statuses, err := keys.List()
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		return format.JSON(statuses)
+	}
+	for _, s := range statuses {
+		if s.Set {
+			fmt.Fprintf(format.Stdout(), "  ✓ %-20s (%s)\n", s.Name, s.Backend)
+		} else {
+			fmt.Fprintf(format.Stdout(), "  ✗ %-20s not set\n", s.Name)
+		}
+	}
+	return nil
+}
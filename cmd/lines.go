@@ -3,39 +3,73 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
+	"slices"
 	"strings"
 
-	"github.com/glundgren93/sl-cli/internal/api"
 	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/pkg/sl"
 	"github.com/spf13/cobra"
 )
 
-var linesMode string
+var (
+	linesMode   string
+	linesBadge  string
+	linesLimit  int
+	linesOffset int
+)
 
 var linesCmd = &cobra.Command{
-	Use:   "lines",
+	Use:   "lines [designation]",
 	Short: "List all transit lines",
-	Long: `List all transit lines in the SL network, optionally filtered by transport mode.
+	Long: `List all transit lines in the SL network, optionally filtered by transport mode
+or designation.
+
+With --badge svg and a single line, prints an SL-style line badge as SVG,
+handy for dashboards and home automation UIs. For the line's route as a
+strip map, see sl line-stops <line> --format svg.
 
 Examples:
   sl lines                    # All lines
   sl lines --mode BUS         # Bus lines only
   sl lines --mode METRO       # Metro lines only
+  sl lines --limit 50 --offset 50  # Lines 51–100
+  sl line 17 --badge svg      # Line badge as SVG
+  sl lines --format tsv       # Tab-separated, for awk
   sl lines --json             # JSON output`,
-	Aliases: []string{"line", "l"},
-	RunE:    runLines,
+	Aliases:     []string{"line", "l"},
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: formats(format.TableFormats...),
+	RunE:        runLines,
 }
 
 func init() {
 	linesCmd.Flags().StringVar(&linesMode, "mode", "", "Filter by transport mode: BUS, METRO, TRAIN, TRAM, SHIP")
+	linesCmd.Flags().StringVar(&linesBadge, "badge", "", "Render a line badge instead of listing (svg)")
+	linesCmd.Flags().IntVar(&linesLimit, "limit", 0, "Max lines (0 = all)")
+	linesCmd.Flags().IntVar(&linesOffset, "offset", 0, "Skip this many lines")
 	rootCmd.AddCommand(linesCmd)
 }
 
 func runLines(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
-	client := api.NewClient()
+	client := newClient()
 
-	lines, err := client.GetLines(ctx)
+	if err := checkPaging(linesLimit, linesOffset); err != nil {
+		return err
+	}
+	if linesBadge != "" && linesBadge != "svg" {
+		return usageErrorf("unknown badge format %q (use svg)", linesBadge)
+	}
+	if linesBadge != "" && len(args) == 0 {
+		return usageErrorf("--badge requires a line designation (e.g. sl line 17 --badge svg)")
+	}
+
+	getLines := client.GetLinesCached
+	if freshData {
+		getLines = client.GetLines
+	}
+	lines, err := getLines(ctx)
 	if err != nil {
 		return fmt.Errorf("fetching lines: %w", err)
 	}
@@ -52,10 +86,62 @@ func runLines(cmd *cobra.Command, args []string) error {
 		lines = lines[:n]
 	}
 
+	if len(args) > 0 {
+		lines = filterLinesByDesignation(lines, args[0])
+	}
+
+	if linesBadge != "" {
+		if len(lines) == 0 {
+			return sl.Errorf(sl.ErrNotFound, "no line %q found", args[0])
+		}
+		if len(lines) > 1 {
+			var modes []string
+			for _, l := range lines {
+				modes = append(modes, l.TransportMode)
+			}
+			return fmt.Errorf("line %q exists for several modes (%s) — pick one with --mode", args[0], strings.Join(modes, ", "))
+		}
+		return format.LineBadgeSVG(os.Stdout, lines[0].Designation, lines[0].TransportMode)
+	}
+
+	sortLines(lines)
+	lines = window(lines, linesLimit, linesOffset)
+
 	if jsonOutput {
 		return format.JSON(lines)
 	}
+	if format.IsTable(outputFormat) {
+		return format.Table(os.Stdout, outputFormat, format.LineColumns, lines)
+	}
 
 	format.Lines(lines)
 	return nil
 }
+
+// filterLinesByDesignation keeps lines whose designation matches exactly (case-insensitive).
+func filterLinesByDesignation(lines []sl.Line, designation string) []sl.Line {
+	filtered := []sl.Line{}
+	for _, l := range lines {
+		if strings.EqualFold(l.Designation, designation) {
+			filtered = append(filtered, l)
+		}
+	}
+	return filtered
+}
+
+// sortLines sorts lines by designation within each transport mode, keeping
+// the modes in the order they first appear.
+func sortLines(lines []sl.Line) {
+	rank := map[string]int{}
+	for _, l := range lines {
+		if _, ok := rank[l.TransportMode]; !ok {
+			rank[l.TransportMode] = len(rank)
+		}
+	}
+	slices.SortStableFunc(lines, func(a, b sl.Line) int {
+		if c := rank[a.TransportMode] - rank[b.TransportMode]; c != 0 {
+			return c
+		}
+		return format.Collate(a.Designation, b.Designation)
+	})
+}
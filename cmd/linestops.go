@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"os"
+
+	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/internal/keys"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+	"github.com/spf13/cobra"
+)
+
+var (
+	lineStopsDirection int
+	lineStopsMode      string
+)
+
+var lineStopsCmd = &cobra.Command{
+	Use:   "line-stops <line>",
+	Short: "List the stops a line serves, in order",
+	Long: `List every stop a line calls at, in order, with the scheduled minutes
+from the first stop: where a bus actually goes. The inverse of stop-info.
+
+SL's Transport API has no stop sequences, so this reads the GTFS Regional
+static timetable and needs a Trafiklab key: sl keys set trafiklab-static
+<key>. Each direction shows the sequence most trips run; short turns and
+branches are counted as variants.
+
+--format geojson draws each direction as a line through its stops, with
+the stops as points, for geojson.io, QGIS or a Leaflet map. --format svg
+draws a strip map of each direction in the line's color, for dashboards
+and home automation panels.
+
+Examples:
+  sl line-stops 17
+  sl line-stops 55 --direction 1
+  sl line-stops 17 --mode BUS
+  sl line-stops 4 --format geojson > line4.geojson
+  sl line-stops 17 --direction 1 --format svg > line17.svg`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: formats("text", "geojson", "svg"),
+	RunE:        runLineStops,
+}
+
+func init() {
+	lineStopsCmd.Flags().IntVar(&lineStopsDirection, "direction", 0, "Only this direction (1 or 2)")
+	lineStopsCmd.Flags().StringVar(&lineStopsMode, "mode", "", "Transport mode, when buses and trains share the number: BUS, METRO, TRAIN, TRAM, SHIP")
+
+	rootCmd.AddCommand(lineStopsCmd)
+}
+
+func runLineStops(cmd *cobra.Command, args []string) error {
+	if lineStopsDirection < 0 || lineStopsDirection > 2 {
+		return usageErrorf("--direction must be 1 or 2")
+	}
+	key, err := apiKey(keys.TrafiklabStatic)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	client := newClient()
+	tt, err := loadTimetable(ctx, client, key)
+	if err != nil {
+		return err
+	}
+
+	routes := sl.LineRoutes(tt, args[0], lineStopsMode, lineStopsDirection)
+	if len(routes) == 0 {
+		return sl.Errorf(sl.ErrNotFound, "no scheduled trips for line %s in the timetable", args[0])
+	}
+	switch {
+	case outputFormat == "geojson":
+		return format.LineRoutesGeoJSON(os.Stdout, routes)
+	case outputFormat == "svg":
+		return format.LineRoutesSVG(os.Stdout, routes)
+	case jsonOutput:
+		return format.JSON(routes)
+	}
+	format.LineRoutes(routes)
+	return nil
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+// pushJob is the Prometheus job departures are pushed under.
+const pushJob = "sl_departures"
+
+// metricsOutput reports whether departures are written as metrics rather
+// than a board: --format influx or prometheus, or --push-gateway.
+func metricsOutput() bool {
+	return outputFormat == "influx" || outputFormat == "prometheus" || depPushGateway != ""
+}
+
+// departuresMetrics writes the departures of every stop as metrics, or
+// pushes them to --push-gateway.
+func departuresMetrics(ctx context.Context, results []departureResult) error {
+	var rows []format.StopDeparture
+	sites := make([]string, len(results))
+	for i, r := range results {
+		rows = append(rows, r.stopDepartures()...)
+		sites[i] = strconv.Itoa(r.SiteID)
+	}
+
+	if outputFormat == "influx" {
+		return format.Influx(os.Stdout, rows, time.Now())
+	}
+	if depPushGateway == "" {
+		return format.Prometheus(os.Stdout, rows)
+	}
+
+	var body bytes.Buffer
+	if err := format.Prometheus(&body, rows); err != nil {
+		return err
+	}
+	if err := pushMetrics(ctx, depPushGateway, strings.Join(sites, ","), &body); err != nil {
+		return err
+	}
+	fmt.Fprintf(format.Stderr(), "📤 %d departure(s) pushed to %s\n", len(rows), depPushGateway)
+	return nil
+}
+
+// pushMetrics replaces the metrics grouped under pushJob and site on a
+// Prometheus push gateway, so a stop's departures that have left disappear
+// on the next push while other stops pushed by other jobs stay.
+func pushMetrics(ctx context.Context, gateway, site string, body io.Reader) error {
+	u := strings.TrimRight(gateway, "/") + "/metrics/job/" + pushJob + "/site/" + url.PathEscape(site)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u, body)
+	if err != nil {
+		return fmt.Errorf("creating push request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+
+	resp, err := (&http.Client{Timeout: sl.DefaultTimeout}).Do(req)
+	if err != nil {
+		return fmt.Errorf("pushing metrics: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("pushing metrics: push gateway answered %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
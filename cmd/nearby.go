@@ -3,12 +3,15 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 
-	"github.com/glundgren93/sl-cli/internal/api"
-	"github.com/glundgren93/sl-cli/internal/model"
 	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/pkg/sl"
 	"github.com/spf13/cobra"
 )
 
@@ -17,8 +20,16 @@ var (
 	nearbyLon       float64
 	nearbyRadius    float64
 	nearbyLimit     int
+	nearbyOffset    int
 	nearbyAddr      string
 	nearbyShowLines bool
+	nearbyType      string
+	nearbyMode      string
+	nearbySort      string
+	nearbyStdin     bool
+	nearbyExpand    bool
+	nearbyNoCluster bool
+	nearbyCluster   bool
 )
 
 var nearbyCmd = &cobra.Command{
@@ -28,30 +39,88 @@ var nearbyCmd = &cobra.Command{
 
 Use --lines to also show which transit lines serve each stop (slower, makes API calls per stop).
 
+Stops of the same interchange, within 50m of each other or sharing a stop
+area, are shown as one entry with their lines combined; --expand lists
+each of them underneath, and --no-cluster shows them separately. Table
+formats always list every stop.
+
+--format geojson writes the stops as points, for geojson.io, QGIS or a
+Leaflet map.
+
+With --stdin, positions are read from standard input, one "lat,lon" per
+line or gpsd's JSON (gpspipe -w), and the stops around each are written as
+a line of JSON, for a screen in a moving van or on a bike. Positions less
+than 25m from the last one written are skipped for 30s.
+
 Examples:
   sl nearby --lat 59.3121 --lon 18.0643         # By coordinates
   sl nearby --address "Magnus Ladulåsgatan"      # By address
   sl nearby --lat 59.3121 --lon 18.0643 -r 0.3  # 300m radius
   sl nearby --address "Stureplan" --lines        # Show lines per stop
-  sl nearby --lat 59.3121 --lon 18.0643 --json   # JSON output`,
-	Aliases: []string{"near", "n"},
-	RunE:    runNearby,
+  sl nearby --address "Slussen" --lines --expand # Lines of each stop at the hub
+  sl nearby --address "Stureplan" --type METROSTN # Nearest metro station
+  sl nearby --address "Stureplan" --mode METRO   # Stops served by the metro
+  sl nearby --address "Stureplan" --sort soonest # Stop with the next departure first
+  sl nearby --lat 59.3121 --lon 18.0643 --json   # JSON output
+  sl nearby --address "Slussen" --format geojson > stops.geojson
+  gpspipe -w | sl nearby --stdin --lines         # Follow a GPS receiver`,
+	Aliases:     []string{"near", "n"},
+	Annotations: formats(append([]string{"geojson"}, format.TableFormats...)...),
+	RunE:        runNearby,
 }
 
 func init() {
 	nearbyCmd.Flags().Float64Var(&nearbyLat, "lat", 0, "Latitude (WGS84)")
 	nearbyCmd.Flags().Float64Var(&nearbyLon, "lon", 0, "Longitude (WGS84)")
 	nearbyCmd.Flags().Float64VarP(&nearbyRadius, "radius", "r", 0.5, "Search radius in km (default 0.5)")
-	nearbyCmd.Flags().IntVar(&nearbyLimit, "limit", 10, "Max results")
+	nearbyCmd.Flags().IntVar(&nearbyLimit, "limit", 10, "Max results (0 = all)")
+	nearbyCmd.Flags().IntVar(&nearbyOffset, "offset", 0, "Skip this many of the nearest stops")
 	nearbyCmd.Flags().StringVar(&nearbyAddr, "address", "", "Address to geocode (uses SL stop-finder)")
 	nearbyCmd.Flags().BoolVar(&nearbyShowLines, "lines", false, "Show which lines serve each stop (slower)")
+	nearbyCmd.Flags().StringVar(&nearbySort, "sort", "distance", "Order stops by distance or soonest departure (soonest implies --lines)")
+	nearbyCmd.Flags().StringVar(&nearbyMode, "mode", "", "Only stops served by this transport mode (BUS, METRO, TRAIN, TRAM, SHIP)")
+	nearbyCmd.Flags().BoolVar(&nearbyExpand, "expand", false, "List each stop of a clustered interchange")
+	nearbyCmd.Flags().BoolVar(&nearbyNoCluster, "no-cluster", false, "Show co-located stops separately instead of as one interchange")
+	nearbyCmd.Flags().BoolVar(&nearbyCluster, "cluster", false, "Merge co-located stops into one interchange in JSON output too")
+	nearbyCmd.Flags().BoolVar(&nearbyStdin, "stdin", false, "Read positions from stdin and stream the stops near each as NDJSON")
+	nearbyCmd.Flags().StringVar(&nearbyType, "type", "", "Filter by stop area type (e.g. METROSTN, BUSTERM, RAILWSTN)")
 
 	rootCmd.AddCommand(nearbyCmd)
 }
 
 func runNearby(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
-	client := api.NewClient()
+	client := newClient()
+
+	if err := checkPaging(nearbyLimit, nearbyOffset); err != nil {
+		return err
+	}
+
+	switch nearbySort {
+	case "distance":
+	case "soonest":
+		nearbyShowLines = true
+	default:
+		return usageErrorf("unknown sort %q (use distance or soonest)", nearbySort)
+	}
+	if nearbyCluster && nearbyNoCluster {
+		return usageErrorf("--cluster and --no-cluster can't be combined")
+	}
+	if nearbyShowLines && outputFormat == "geojson" {
+		return usageErrorf("--format geojson lists stops only; drop --lines and --sort soonest")
+	}
+
+	if nearbyStdin {
+		if nearbyLat != 0 || nearbyLon != 0 || nearbyAddr != "" || len(args) > 0 {
+			return usageErrorf("--stdin reads positions from stdin; drop --lat, --lon and --address")
+		}
+		if outputFormat != "" {
+			return usageErrorf("--stdin always writes NDJSON and can't be combined with --format")
+		}
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+		defer stop()
+		return streamNearby(ctx, client, cmd.InOrStdin())
+	}
 
 	lat, lon := nearbyLat, nearbyLon
 
@@ -62,7 +131,7 @@ func runNearby(cmd *cobra.Command, args []string) error {
 			addr = strings.Join(args, " ")
 		}
 		if addr == "" {
-			return fmt.Errorf("provide --lat/--lon coordinates or --address")
+			return usageErrorf("provide --lat/--lon coordinates or --address")
 		}
 
 		// Try parsing as "lat,lon"
@@ -70,6 +139,7 @@ func runNearby(cmd *cobra.Command, args []string) error {
 			if la, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64); err == nil {
 				if lo, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64); err == nil {
 					lat, lon = la, lo
+					explainResolved(resolutionExplanation{Input: addr, Source: sourceCoordinates, Winner: addr, Reason: `read as "lat,lon"`})
 				}
 			}
 		}
@@ -80,11 +150,13 @@ func runNearby(cmd *cobra.Command, args []string) error {
 				return fmt.Errorf("geocoding address: %w", err)
 			}
 			if len(locations) == 0 {
-				return fmt.Errorf("no location found for %q", addr)
+				return sl.Errorf(sl.ErrNotFound, "no location found for %q", addr)
 			}
+			explainStopFinder(addr, locations)
 			loc := locations[0]
 			lat, lon = loc.Coord[0], loc.Coord[1]
-			fmt.Fprintf(cmd.ErrOrStderr(), "📍 Resolved: %s (%.4f, %.4f)\n\n", loc.Name, lat, lon)
+			emitProgress(progressEvent{Event: "geocoded", Name: loc.Name, Lat: lat, Lon: lon})
+			fmt.Fprintf(format.Stderr(), "📍 Resolved: %s (%.4f, %.4f)\n\n", loc.Name, lat, lon)
 		}
 	}
 
@@ -92,87 +164,192 @@ func runNearby(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("fetching sites: %w", err)
 	}
+	emitProgress(progressEvent{Event: "sites-loaded", Count: len(sites)})
 
-	nearby := api.FindNearestSites(sites, lat, lon, nearbyRadius)
-
-	for i := range nearby {
-		nearby[i].DistanceM = int(nearby[i].DistanceKm * 1000)
-	}
-
-	if nearbyLimit > 0 && len(nearby) > nearbyLimit {
-		nearby = nearby[:nearbyLimit]
+	nearby, err := nearbyStops(ctx, client, sites, lat, lon)
+	if err != nil {
+		return err
 	}
 
 	if !nearbyShowLines {
+		if outputFormat == "geojson" {
+			return format.NearbyGeoJSON(os.Stdout, nearby)
+		}
 		if jsonOutput {
 			return format.JSON(nearby)
 		}
-		format.NearbyStops(nearby)
+		if format.IsTable(outputFormat) {
+			return format.Table(os.Stdout, outputFormat, format.NearbyColumns, nearby)
+		}
+		format.NearbyStops(nearby, nearbyExpand)
 		return nil
 	}
 
-	// Enrich with line info
-	results := []format.NearbyStopWithLines{}
+	results := nearbyLines(ctx, client, nearby)
+	if jsonOutput {
+		return format.JSON(results)
+	}
+	if format.IsTable(outputFormat) {
+		return format.Table(os.Stdout, outputFormat, format.NearbyLinesColumns, results)
+	}
+
+	format.NearbyStopsWithLines(results, nearbyExpand)
+	return nil
+}
+
+// nearbyStops returns the stops within --radius of lat,lon that pass
+// --type and --mode, nearest first, clustered into interchanges where
+// clusterNearby says so, and paged by --limit and --offset. Stop types are only loaded when there is a stop to tag or
+// filter.
+func nearbyStops(ctx context.Context, client *sl.Client, sites []sl.Site, lat, lon float64) ([]sl.SiteWithDistance, error) {
+	nearby := sl.FindNearestSites(sites, lat, lon, nearbyRadius)
+	if len(nearby) == 0 {
+		return nearby, nil
+	}
+	areaTypes, err := loadStopAreaTypes(ctx, client, nearbyType != "" || nearbyMode != "")
+	if err != nil {
+		return nil, err
+	}
+
+	n := 0
 	for _, s := range nearby {
-		entry := format.NearbyStopWithLines{
-			Stop:      s.Site.Name,
-			SiteID:    s.Site.ID,
-			DistanceM: s.DistanceM,
+		s.DistanceM = int(s.DistanceKm * 1000)
+		s.Types = sl.SiteTypes(s.Site, areaTypes)
+		if nearbyType != "" && !sl.HasType(s.Types, nearbyType) {
+			continue
 		}
-
-		resp, err := client.GetDepartures(ctx, api.DepartureOptions{SiteID: s.Site.ID})
-		if err != nil {
-			results = append(results, entry)
+		if nearbyMode != "" && !sl.ServesMode(s.Types, nearbyMode) {
 			continue
 		}
+		nearby[n] = s
+		n++
+	}
+	nearby = nearby[:n]
+	if clusterNearby() {
+		nearby = sl.ClusterSites(nearby)
+	}
+	return window(nearby, nearbyLimit, nearbyOffset), nil
+}
+
+// clusterNearby reports whether co-located stops are merged into one
+// interchange: by default in the text listing, but in JSON and the --stdin
+// stream only with --cluster, since it changes what an entry stands for.
+func clusterNearby() bool {
+	if jsonOutput || nearbyStdin {
+		return nearbyCluster
+	}
+	return !nearbyNoCluster && !format.IsTable(outputFormat) && outputFormat != "geojson"
+}
 
-		parsed := api.ParseDepartures(resp.Departures)
-		entry.Lines = extractLines(parsed)
+// nearbyLines looks up the lines serving each stop and its next departure,
+// for --lines, ordered by --sort. An interchange gets the lines of all its
+// stops, with each stop's own in Colocated.
+func nearbyLines(ctx context.Context, client *sl.Client, nearby []sl.SiteWithDistance) []format.NearbyStopWithLines {
+	results := []format.NearbyStopWithLines{}
+	for i, s := range nearby {
+		emitProgress(progressEvent{Event: "scanning", Current: i + 1, Total: len(nearby), Stop: s.Site.Name, SiteID: s.Site.ID})
+		entry, all := stopLines(ctx, client, s)
+		for _, m := range s.Colocated {
+			member, parsed := stopLines(ctx, client, m)
+			entry.Colocated = append(entry.Colocated, member)
+			all = append(all, parsed...)
+		}
+		if len(entry.Colocated) > 0 {
+			entry.Types = s.Types
+			entry.Lines = extractLines(all)
+			entry.NextDepartureMin = soonestMinutes(all)
+		}
 		results = append(results, entry)
 	}
 
-	if jsonOutput {
-		return format.JSON(results)
+	if nearbySort == "soonest" {
+		sortBySoonest(results)
 	}
+	return results
+}
 
-	format.NearbyStopsWithLines(results)
-	return nil
+// stopLines looks up the lines serving one stop and its next departure,
+// and returns the departures they came from.
+func stopLines(ctx context.Context, client *sl.Client, s sl.SiteWithDistance) (format.NearbyStopWithLines, []sl.ParsedDeparture) {
+	entry := format.NearbyStopWithLines{
+		Stop:      s.Site.Name,
+		SiteID:    s.Site.ID,
+		DistanceM: s.DistanceM,
+		Types:     s.Types,
+		Lines:     []format.StopInfoLine{},
+	}
+	resp, err := client.GetDepartures(ctx, sl.DepartureOptions{SiteID: s.Site.ID})
+	if err != nil {
+		return entry, nil
+	}
+	parsed := sl.ParseDepartures(resp.Departures)
+	if nearbyMode != "" {
+		parsed = sl.FilterByTransportMode(parsed, nearbyMode)
+	}
+	entry.Lines = extractLines(parsed)
+	entry.NextDepartureMin = soonestMinutes(parsed)
+	return entry, parsed
+}
+
+// soonestMinutes returns the minutes until the earliest departure, or nil if there are none.
+func soonestMinutes(parsed []sl.ParsedDeparture) *int {
+	if len(parsed) == 0 {
+		return nil
+	}
+	mins := parsed[0].MinutesLeft
+	for _, d := range parsed[1:] {
+		if d.MinutesLeft < mins {
+			mins = d.MinutesLeft
+		}
+	}
+	return &mins
+}
+
+// sortBySoonest orders stops by their next departure. Stops without departures
+// go last; ties keep distance order.
+func sortBySoonest(stops []format.NearbyStopWithLines) {
+	sort.SliceStable(stops, func(i, j int) bool {
+		a, b := stops[i].NextDepartureMin, stops[j].NextDepartureMin
+		if a == nil || b == nil {
+			return a != nil && b == nil
+		}
+		return *a < *b
+	})
 }
 
 // extractLines groups parsed departures into unique lines with destinations.
-func extractLines(parsed []model.ParsedDeparture) []format.StopInfoLine {
+// Lines keep the order they first appear in (i.e. by departure time) and
+// destinations are sorted by name, so output is stable between runs.
+func extractLines(parsed []sl.ParsedDeparture) []format.StopInfoLine {
 	type lineKey struct {
 		designation   string
 		transportMode string
 		groupOfLines  string
 	}
-	lineMap := make(map[lineKey]map[string]bool)
-	var lineOrder []lineKey
+	index := make(map[lineKey]int)
+	seen := make(map[lineKey]map[string]bool)
 
+	lines := []format.StopInfoLine{}
 	for _, d := range parsed {
 		key := lineKey{d.Line, d.TransportMode, d.GroupOfLines}
-		if _, exists := lineMap[key]; !exists {
-			lineMap[key] = make(map[string]bool)
-			lineOrder = append(lineOrder, key)
+		i, exists := index[key]
+		if !exists {
+			i = len(lines)
+			index[key] = i
+			seen[key] = make(map[string]bool)
+			lines = append(lines, format.StopInfoLine{
+				Designation:   key.designation,
+				TransportMode: key.transportMode,
+				GroupOfLines:  key.groupOfLines,
+			})
 		}
-		if d.Destination != "" {
-			lineMap[key][d.Destination] = true
+		if d.Destination != "" && !seen[key][d.Destination] {
+			seen[key][d.Destination] = true
+			lines[i].Destinations = append(lines[i].Destinations, d.Destination)
 		}
 	}
-
-	lines := []format.StopInfoLine{}
-	for _, key := range lineOrder {
-		dests := lineMap[key]
-		var destList []string
-		for d := range dests {
-			destList = append(destList, d)
-		}
-		lines = append(lines, format.StopInfoLine{
-			Designation:   key.designation,
-			TransportMode: key.transportMode,
-			GroupOfLines:  key.groupOfLines,
-			Destinations:  destList,
-		})
+	for _, l := range lines {
+		slices.SortFunc(l.Destinations, format.Collate)
 	}
 	return lines
 }
@@ -4,11 +4,11 @@ import (
 	"testing"
 
 	"github.com/glundgren93/sl-cli/internal/format"
-	"github.com/glundgren93/sl-cli/internal/model"
+	"github.com/glundgren93/sl-cli/pkg/sl"
 )
 
 func TestExtractLines(t *testing.T) {
-	parsed := []model.ParsedDeparture{
+	parsed := []sl.ParsedDeparture{
 		{Line: "55", TransportMode: "BUS", GroupOfLines: "", Destination: "Tanto"},
 		{Line: "55", TransportMode: "BUS", GroupOfLines: "", Destination: "Henriksdalsberget"},
 		{Line: "55", TransportMode: "BUS", GroupOfLines: "", Destination: "Tanto"}, // duplicate dest
@@ -31,6 +31,11 @@ func TestExtractLines(t *testing.T) {
 
 	// Verify line 17
 	assertLine(t, lines[2], "17", "METRO", 1) // Åkeshov
+
+	// Destinations are sorted by name so output is stable run to run
+	if got := lines[0].Destinations; got[0] != "Henriksdalsberget" || got[1] != "Tanto" {
+		t.Errorf("expected [Henriksdalsberget Tanto], got %v", got)
+	}
 }
 
 func TestExtractLines_Empty(t *testing.T) {
@@ -39,7 +44,7 @@ func TestExtractLines_Empty(t *testing.T) {
 		t.Errorf("expected 0 lines from nil input, got %d", len(lines))
 	}
 
-	lines = extractLines([]model.ParsedDeparture{})
+	lines = extractLines([]sl.ParsedDeparture{})
 	if len(lines) != 0 {
 		t.Errorf("expected 0 lines from empty input, got %d", len(lines))
 	}
@@ -58,3 +63,32 @@ func assertLine(t *testing.T, line format.StopInfoLine, designation, mode string
 			minDests, designation, len(line.Destinations), line.Destinations)
 	}
 }
+
+func TestSortBySoonest(t *testing.T) {
+	mins := func(n int) *int { return &n }
+	stops := []format.NearbyStopWithLines{
+		{Stop: "Closest, nothing running"},
+		{Stop: "Near", NextDepartureMin: mins(8)},
+		{Stop: "Mid", NextDepartureMin: mins(2)},
+		{Stop: "Far", NextDepartureMin: mins(2)},
+	}
+
+	sortBySoonest(stops)
+
+	want := []string{"Mid", "Far", "Near", "Closest, nothing running"}
+	for i, w := range want {
+		if stops[i].Stop != w {
+			t.Errorf("position %d = %q, want %q", i, stops[i].Stop, w)
+		}
+	}
+}
+
+func TestSoonestMinutes(t *testing.T) {
+	if soonestMinutes(nil) != nil {
+		t.Error("expected nil for no departures")
+	}
+	got := soonestMinutes([]sl.ParsedDeparture{{MinutesLeft: 7}, {MinutesLeft: 3}, {MinutesLeft: 5}})
+	if got == nil || *got != 3 {
+		t.Errorf("expected 3, got %v", got)
+	}
+}
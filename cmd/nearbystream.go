@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+// A position within streamMinMoveKm of the last one written is skipped
+// unless streamRefresh has passed, so a GPS fix every second while
+// waiting at a light doesn't flood the output or SL.
+const (
+	streamMinMoveKm = 0.025
+	streamRefresh   = 30 * time.Second
+)
+
+// nearbyUpdate is one NDJSON line of nearby --stdin: the stops around a
+// position, as nearby --json lists them, or with --lines as nearby --lines
+// --json does.
+type nearbyUpdate struct {
+	Lat   float64   `json:"lat"`
+	Lon   float64   `json:"lon"`
+	Time  time.Time `json:"time"`
+	Stops any       `json:"stops"`
+}
+
+// streamNearby reads positions from r, one per line, and writes the stops
+// near each as NDJSON until r ends. Lines are "lat,lon" or gpsd JSON as
+// from gpspipe -w; other lines are reported on stderr and skipped.
+func streamNearby(ctx context.Context, client *sl.Client, r io.Reader) error {
+	sites, err := client.GetSitesCached(ctx)
+	if err != nil {
+		return fmt.Errorf("fetching sites: %w", err)
+	}
+
+	var last nearbyUpdate
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return nil
+		}
+		lat, lon, ok, err := parsePosition(scanner.Text())
+		if err != nil {
+			fmt.Fprintf(format.Stderr(), "⚠️  %v\n", err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		now := time.Now()
+		if !last.Time.IsZero() && now.Sub(last.Time) < streamRefresh &&
+			sl.DistanceKm(last.Lat, last.Lon, lat, lon) < streamMinMoveKm {
+			continue
+		}
+
+		nearby, err := nearbyStops(ctx, client, sites, lat, lon)
+		if err != nil {
+			return err
+		}
+		update := nearbyUpdate{Lat: lat, Lon: lon, Time: now, Stops: nearby}
+		if nearbyShowLines {
+			update.Stops = nearbyLines(ctx, client, nearby)
+		}
+		if err := format.JSONLine(format.Stdout(), update); err != nil {
+			return err
+		}
+		last = update
+	}
+	return scanner.Err()
+}
+
+// parsePosition reads a position from a line of input. Blank lines,
+// comments and gpsd reports other than a located TPV give ok false.
+func parsePosition(line string) (lat, lon float64, ok bool, err error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return 0, 0, false, nil
+	}
+	if strings.HasPrefix(line, "{") {
+		var tpv struct {
+			Class string   `json:"class"`
+			Lat   *float64 `json:"lat"`
+			Lon   *float64 `json:"lon"`
+		}
+		if err := json.Unmarshal([]byte(line), &tpv); err != nil {
+			return 0, 0, false, fmt.Errorf("unreadable position: %v", err)
+		}
+		if tpv.Class != "" && tpv.Class != "TPV" || tpv.Lat == nil || tpv.Lon == nil {
+			return 0, 0, false, nil
+		}
+		return *tpv.Lat, *tpv.Lon, true, nil
+	}
+	lat, lon, ok = parseLatLon(line)
+	if !ok {
+		return 0, 0, false, fmt.Errorf("unreadable position %q (want lat,lon)", line)
+	}
+	return lat, lon, true, nil
+}
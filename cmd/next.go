@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+	"github.com/spf13/cobra"
+)
+
+var (
+	nextFrom    string
+	nextTowards string
+	nextWithin  time.Duration
+)
+
+var nextCmd = &cobra.Command{
+	Use:   "next LINE --from STOP [--towards DEST]",
+	Short: "Print the next departure of a line as one line",
+	Long: `Print when a line next leaves a stop as a single line, such as
+"55 to Tanto in 4 min from platform A", and nothing else. Cancelled
+departures are skipped.
+
+If nothing leaves within --within, sl next prints the reason to stderr and
+exits with status 1, so it can drive shell prompts, status bars and
+scripts:
+
+  sl next 55 --from Timmermansgränd --within 10m || echo "walk instead"
+
+--from takes a stop name, site ID or bookmark; --towards a destination
+("Tanto") or direction code (1 or 2).
+
+Examples:
+  sl next 55 --from "Timmermansgränd" --towards Tanto
+  sl next 17 --from Medborgarplatsen --towards 1 --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runNext,
+}
+
+func init() {
+	nextCmd.Flags().StringVar(&nextFrom, "from", "", "Stop to leave from (name, site ID or bookmark)")
+	nextCmd.Flags().StringVar(&nextTowards, "towards", "", "Destination or direction code (1 or 2)")
+	nextCmd.Flags().DurationVar(&nextWithin, "within", time.Hour, "Fail unless the line leaves within this long")
+	nextCmd.MarkFlagRequired("from")
+
+	nextCmd.RegisterFlagCompletionFunc("from", completeStops)
+
+	rootCmd.AddCommand(nextCmd)
+}
+
+// nextResult is the JSON output of sl next.
+type nextResult struct {
+	Stop      string             `json:"stop"`
+	SiteID    int                `json:"site_id"`
+	Departure sl.ParsedDeparture `json:"departure"`
+}
+
+func runNext(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	client := newClient()
+	line := args[0]
+
+	if nextWithin <= 0 {
+		return usageErrorf("--within must be positive")
+	}
+
+	siteID, err := strconv.Atoi(nextFrom)
+	if err != nil {
+		if siteID, err = resolveSiteID(ctx, client, nextFrom); err != nil {
+			return err
+		}
+	}
+
+	opts := sl.DepartureOptions{SiteID: siteID, Line: line}
+	if n, err := strconv.Atoi(nextTowards); err == nil {
+		opts.Direction = n
+	} else {
+		opts.DirectionText = nextTowards
+	}
+	resp, err := client.GetDepartures(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("fetching departures: %w", err)
+	}
+
+	next, ok := nextDeparture(sl.FilterByWindow(sl.ParseDepartures(resp.Departures), 0, nextWithin))
+	if !ok {
+		what := line
+		if nextTowards != "" {
+			what += " towards " + nextTowards
+		}
+		return sl.Errorf(sl.ErrNoDepartures, "no %s from %s within %.0f min", what, nextFrom, nextWithin.Minutes())
+	}
+
+	if jsonOutput {
+		return format.JSON(nextResult{Stop: next.StopArea, SiteID: siteID, Departure: next})
+	}
+	fmt.Fprintln(format.Stdout(), format.NextDeparture(next))
+	return nil
+}
+
+// nextDeparture returns the soonest departure that isn't cancelled.
+func nextDeparture(deps []sl.ParsedDeparture) (sl.ParsedDeparture, bool) {
+	var next sl.ParsedDeparture
+	found := false
+	for _, d := range deps {
+		if d.State == "CANCELLED" {
+			continue
+		}
+		if !found || d.MinutesLeft < next.MinutesLeft {
+			next, found = d, true
+		}
+	}
+	return next, found
+}
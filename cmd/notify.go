@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// notifyCommand returns the OS desktop notification command: osascript on
+// macOS, otherwise libnotify's notify-send.
+func notifyCommand(title, body string) (*exec.Cmd, error) {
+	if path, err := exec.LookPath("osascript"); err == nil {
+		script := `on run argv
+display notification (item 2 of argv) with title (item 1 of argv)
+end run`
+		return exec.Command(path, "-e", script, title, body), nil
+	}
+	if path, err := exec.LookPath("notify-send"); err == nil {
+		return exec.Command(path, title, body), nil
+	}
+	return nil, errors.New("--notify needs a notification program (osascript or notify-send)")
+}
+
+// sendNotification shows a desktop notification.
+func sendNotification(title, body string) error {
+	cmd, err := notifyCommand(title, body)
+	if err != nil {
+		return err
+	}
+	return cmd.Run()
+}
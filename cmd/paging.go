@@ -0,0 +1,41 @@
+package cmd
+
+// Listing commands share the same paging flags: --limit caps the number of
+// results (0 = no cap) and --offset skips that many results first.
+
+// checkPaging rejects negative --limit and --offset values.
+func checkPaging(limit, offset int) error {
+	if limit < 0 {
+		return usageErrorf("--limit must be 0 (no limit) or greater")
+	}
+	if offset < 0 {
+		return usageErrorf("--offset must be 0 or greater")
+	}
+	return nil
+}
+
+// window returns up to limit items starting at offset. A limit of 0 means
+// the rest of the list; offsets past the end give an empty slice.
+func window[T any](items []T, limit, offset int) []T {
+	start, end := windowBounds(len(items), limit, offset)
+	return items[start:end]
+}
+
+// windowBounds returns the slice bounds for limit and offset over total items.
+func windowBounds(total, limit, offset int) (start, end int) {
+	start = min(offset, total)
+	end = total
+	if limit > 0 {
+		end = min(start+limit, total)
+	}
+	return start, end
+}
+
+// pageBounds returns the slice bounds of a 1-based page. A limit of 0 means
+// everything; pages past the end are empty.
+func pageBounds(total, limit, page int) (start, end int) {
+	if limit <= 0 {
+		return 0, total
+	}
+	return windowBounds(total, limit, (page-1)*limit)
+}
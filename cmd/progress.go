@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	progressJSON  bool
+	progressMu    sync.Mutex
+	progressStart = time.Now()
+)
+
+// progressEvent is one NDJSON line emitted on stderr with --progress-json.
+type progressEvent struct {
+	Event     string  `json:"event"`
+	Current   int     `json:"current,omitempty"`
+	Total     int     `json:"total,omitempty"`
+	Stop      string  `json:"stop,omitempty"`
+	SiteID    int     `json:"site_id,omitempty"`
+	Name      string  `json:"name,omitempty"`
+	Lat       float64 `json:"lat,omitempty"`
+	Lon       float64 `json:"lon,omitempty"`
+	Count     int     `json:"count,omitempty"`
+	Error     string  `json:"error,omitempty"`
+	ElapsedMs int64   `json:"elapsed_ms"`
+}
+
+// emitProgress writes a progress event to stderr when --progress-json is set.
+// Safe for concurrent use.
+func emitProgress(ev progressEvent) {
+	if !progressJSON {
+		return
+	}
+	ev.ElapsedMs = time.Since(progressStart).Milliseconds()
+
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	enc := json.NewEncoder(os.Stderr)
+	enc.SetEscapeHTML(false)
+	enc.Encode(ev)
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/glundgren93/sl-cli/internal/config"
+	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/internal/i18n"
+	"github.com/glundgren93/sl-cli/internal/keys"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+	"github.com/spf13/cobra"
+)
+
+var (
+	randomFrom     string
+	randomMinutes  int
+	randomMin      int
+	randomAt       string
+	randomSeenFile string
+)
+
+var randomCmd = &cobra.Command{
+	Use:   "random --from PLACE",
+	Short: "Pick a random destination within reach and plan the trip there",
+	Long: `Take me somewhere: pick a random station that can be reached from a
+place within --minutes, by the same timetable estimate as sl reach, and
+plan the trip there. --min-minutes keeps it from picking the station next
+door.
+
+With --seen-file the destination is appended to the file and stations
+already listed in it are skipped, so every run goes somewhere new. The
+file is a plain list of station names, one per line.
+
+Uses the GTFS Regional static timetable, so it needs a Trafiklab key:
+sl keys set trafiklab-static <key>.
+
+Examples:
+  sl random --from "T-Centralen"
+  sl random --from hotel --minutes 45 --seen-file ~/.sl-seen.txt
+  sl random --from "59.3326,18.0649" --at "Sat 10:00" --json`,
+	Args: cobra.NoArgs,
+	RunE: runRandom,
+}
+
+func init() {
+	randomCmd.Flags().StringVar(&randomFrom, "from", "", `Start: a stop, address, bookmark or "lat,lon"`)
+	randomCmd.Flags().IntVar(&randomMinutes, "minutes", 30, "Travel time budget in minutes")
+	randomCmd.Flags().IntVar(&randomMin, "min-minutes", 10, "Only pick stations at least this many minutes away")
+	randomCmd.Flags().StringVar(&randomAt, "at", "", `Leave at HH:MM, "tomorrow 07:40", "Sat 10:00" or a named time (default now)`)
+	randomCmd.Flags().StringVar(&randomSeenFile, "seen-file", "", "Skip stations listed in this file and add the pick to it")
+	randomCmd.MarkFlagRequired("from")
+
+	randomCmd.RegisterFlagCompletionFunc("from", completeStops)
+
+	rootCmd.AddCommand(randomCmd)
+}
+
+// randomResult is the picked destination and the trip there, for JSON
+// output.
+type randomResult struct {
+	From        string           `json:"from"`
+	Destination sl.ReachableStop `json:"destination"`
+	Journeys    []sl.JourneyTrip `json:"journeys"`
+}
+
+func runRandom(cmd *cobra.Command, args []string) error {
+	if randomMinutes <= 0 || randomMinutes > 180 {
+		return usageErrorf("--minutes must be between 1 and 180")
+	}
+	if randomMin < 0 || randomMin >= randomMinutes {
+		return usageErrorf("--min-minutes must be at least 0 and less than --minutes")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	depart := sl.StockholmTime(time.Now())
+	var departAt time.Time // zero plans the trip for now
+	if randomAt != "" {
+		if departAt, err = resolveAt(cfg, randomAt, depart); err != nil {
+			return &usageError{err}
+		}
+		depart = departAt
+	}
+
+	seen, err := readSeenFile(randomSeenFile)
+	if err != nil {
+		return err
+	}
+
+	key, err := apiKey(keys.TrafiklabStatic)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	client := newClient()
+	from := randomFrom
+	if fav, ok := cfg.Favorite(from); ok {
+		from = fav
+	}
+	originID, originName, err := resolveTripEndpoint(ctx, client, cfg, from)
+	if err != nil {
+		return fmt.Errorf("resolving origin: %w", err)
+	}
+	lat, lon, err := resolvePoint(ctx, client, from)
+	if err != nil {
+		return err
+	}
+
+	tt, err := loadTimetable(ctx, client, key)
+	if err != nil {
+		return err
+	}
+
+	stops := sl.Reach(tt, lat, lon, depart, time.Duration(randomMinutes)*time.Minute)
+	dest, ok := sl.PickDestination(stops, randomMin, seen, rand.IntN)
+	if !ok {
+		if len(seen) > 0 {
+			return sl.Errorf(sl.ErrNotFound, "every station %d–%d min away is already in %s; raise --minutes", randomMin, randomMinutes, randomSeenFile)
+		}
+		return sl.Errorf(sl.ErrNotFound, "no station %d–%d min away", randomMin, randomMinutes)
+	}
+
+	if !jsonOutput {
+		fmt.Fprintf(format.Stderr(), "🎲 %s → %s (about %d min)\n\n", originName, dest.Name, dest.Minutes)
+	}
+	resp, err := client.PlanTrip(ctx, sl.TripOptions{
+		OriginID:   originID,
+		DestID:     sl.CoordLocation(dest.Lat, dest.Lon),
+		NumTrips:   1,
+		Language:   i18n.Language(),
+		MaxChanges: -1,
+		DepartAt:   departAt,
+	})
+	if err == nil {
+		err = plannerError(resp)
+	}
+	if err != nil {
+		return fmt.Errorf("planning trip to %s: %w", dest.Name, err)
+	}
+
+	sl.MarkPlatforms(resp.Journeys)
+
+	if randomSeenFile != "" {
+		if err := appendSeen(randomSeenFile, dest.Name); err != nil {
+			return err
+		}
+	}
+
+	if jsonOutput {
+		return format.JSON(randomResult{From: originName, Destination: dest, Journeys: resp.Journeys})
+	}
+	format.Trips(resp.Journeys, sl.Carriage{})
+	return nil
+}
+
+// readSeenFile reads the station names in a --seen-file, lower-cased. A
+// file that doesn't exist yet has seen nothing.
+func readSeenFile(path string) (map[string]bool, error) {
+	seen := map[string]bool{}
+	if path == "" {
+		return seen, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return seen, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading --seen-file: %w", err)
+	}
+	for _, name := range listLines(data) {
+		seen[strings.ToLower(name)] = true
+	}
+	return seen, nil
+}
+
+// appendSeen adds a station to a --seen-file, creating it if needed.
+func appendSeen(path, name string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("updating --seen-file: %w", err)
+	}
+	if _, err := fmt.Fprintln(f, name); err != nil {
+		f.Close()
+		return fmt.Errorf("updating --seen-file: %w", err)
+	}
+	return f.Close()
+}
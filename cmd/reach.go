@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/glundgren93/sl-cli/internal/config"
+	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/internal/keys"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reachFrom    string
+	reachMinutes int
+	reachAt      string
+)
+
+var reachCmd = &cobra.Command{
+	Use:   "reach",
+	Short: "Show which stops can be reached within a travel time",
+	Long: `Estimate which stops can be reached from a place within a number of
+minutes, walking to a first stop and riding and changing by the timetable.
+Handy for apartment hunting: how far does 30 minutes from work get you?
+
+Uses the GTFS Regional static timetable, so it needs a Trafiklab key:
+sl keys set trafiklab-static <key>. Delays and disruptions are not taken
+into account. --format geojson writes the stops as points, with their
+convex hull as a rough outline of the area, for geojson.io or QGIS.
+
+Examples:
+  sl reach --from "Medborgarplatsen" --minutes 30
+  sl reach --from work --minutes 45 --at "Mon 08:00"
+  sl reach --from "59.3326,18.0649" --format geojson > reach.geojson`,
+	Args:        cobra.NoArgs,
+	Annotations: formats("text", "geojson"),
+	RunE:        runReach,
+}
+
+func init() {
+	reachCmd.Flags().StringVar(&reachFrom, "from", "", `Start: a stop, address, bookmark or "lat,lon"`)
+	reachCmd.Flags().IntVar(&reachMinutes, "minutes", 30, "Travel time budget in minutes")
+	reachCmd.Flags().StringVar(&reachAt, "at", "", `Leave at HH:MM, "tomorrow 07:40", "Mon-Fri 07:40" or a named time like @school-run (default now)`)
+	reachCmd.MarkFlagRequired("from")
+
+	reachCmd.RegisterFlagCompletionFunc("from", completeStops)
+
+	rootCmd.AddCommand(reachCmd)
+}
+
+func runReach(cmd *cobra.Command, args []string) error {
+	if reachMinutes <= 0 || reachMinutes > 180 {
+		return usageErrorf("--minutes must be between 1 and 180")
+	}
+
+	depart := sl.StockholmTime(time.Now())
+	if reachAt != "" {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		depart, err = resolveAt(cfg, reachAt, depart)
+		if err != nil {
+			return &usageError{err}
+		}
+	}
+
+	key, err := apiKey(keys.TrafiklabStatic)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	client := newClient()
+	lat, lon, err := resolvePoint(ctx, client, reachFrom)
+	if err != nil {
+		return err
+	}
+
+	tt, err := loadTimetable(ctx, client, key)
+	if err != nil {
+		return err
+	}
+	stops := sl.Reach(tt, lat, lon, depart, time.Duration(reachMinutes)*time.Minute)
+
+	switch {
+	case outputFormat == "geojson":
+		return format.ReachGeoJSON(os.Stdout, stops)
+	case jsonOutput:
+		return format.JSON(stops)
+	}
+	format.Reach(reachFrom, reachMinutes, stops)
+	return nil
+}
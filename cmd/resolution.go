@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+var (
+	explainResolution bool
+	resolutionMu      sync.Mutex
+)
+
+// Where a resolution's answer came from.
+const (
+	sourceBookmark    = "bookmark"
+	sourceCoordinates = "coordinates"
+	sourceSiteCache   = "site-cache"  // the locally cached site list
+	sourceStopFinder  = "stop-finder" // the journey planner's stop-finder
+	sourcePlannerID   = "planner-id"  // an ID passed to the planner unchanged
+	sourceAsIs        = "as-is"       // free text passed to the planner unchanged
+)
+
+// Scores of site cache matches: the exact name wins, then names starting
+// with the input, then names containing it.
+const (
+	scoreExact    = 100
+	scorePrefix   = 75
+	scoreContains = 50
+)
+
+// maxExplainedCandidates caps the candidates printed per resolution.
+const maxExplainedCandidates = 10
+
+// resolutionCandidate is one possible answer to a resolution. Score is
+// sl-cli's own for site cache matches and the stop-finder's match quality
+// for its results.
+type resolutionCandidate struct {
+	Name  string `json:"name"`
+	ID    string `json:"id,omitempty"`
+	Type  string `json:"type,omitempty"`
+	Score int    `json:"score"`
+}
+
+// resolutionExplanation is written on stderr with --explain-resolution for
+// every stop name, address or place the command resolves: as text, or as
+// an NDJSON line with --json.
+type resolutionExplanation struct {
+	Event      string                `json:"event"` // always "resolution"
+	Input      string                `json:"input"`
+	Source     string                `json:"source"`
+	Candidates []resolutionCandidate `json:"candidates,omitempty"`
+	Winner     string                `json:"winner,omitempty"`
+	Reason     string                `json:"reason"`
+}
+
+// explainResolved writes e when --explain-resolution is set. Safe for
+// concurrent use.
+func explainResolved(e resolutionExplanation) {
+	if !explainResolution {
+		return
+	}
+	e.Event = "resolution"
+
+	resolutionMu.Lock()
+	defer resolutionMu.Unlock()
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stderr)
+		enc.SetEscapeHTML(false)
+		enc.Encode(e)
+		return
+	}
+
+	w := format.Stderr()
+	fmt.Fprintf(w, "🔎 %q via %s", e.Input, e.Source)
+	if e.Winner != "" {
+		fmt.Fprintf(w, " → %s", e.Winner)
+	}
+	fmt.Fprintf(w, "\n   %s\n", e.Reason)
+	for i, c := range e.Candidates {
+		if i == maxExplainedCandidates {
+			fmt.Fprintf(w, "   … and %d more\n", len(e.Candidates)-i)
+			break
+		}
+		mark := " "
+		if i == 0 && e.Winner != "" {
+			mark = "✓"
+		}
+		fmt.Fprintf(w, "   %s %3d  %s", mark, c.Score, c.Name)
+		if c.ID != "" {
+			fmt.Fprintf(w, " (id:%s)", c.ID)
+		}
+		if c.Type != "" {
+			fmt.Fprintf(w, " [%s]", c.Type)
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintln(w)
+}
+
+// siteMatchScore scores a cached site's name against a lower-cased stop
+// name, or returns 0 when it doesn't match.
+func siteMatchScore(siteName, nameLower string) int {
+	siteLower := strings.ToLower(siteName)
+	switch {
+	case siteLower == nameLower:
+		return scoreExact
+	case strings.HasPrefix(siteLower, nameLower):
+		return scorePrefix
+	case strings.Contains(siteLower, nameLower):
+		return scoreContains
+	}
+	return 0
+}
+
+// siteCandidates lists the sites matching a stop name, best first.
+func siteCandidates(sites []sl.Site, name string) []resolutionCandidate {
+	nameLower := strings.ToLower(name)
+	var candidates []resolutionCandidate
+	for _, s := range sites {
+		if score := siteMatchScore(s.Name, nameLower); score > 0 {
+			candidates = append(candidates, resolutionCandidate{Name: s.Name, ID: strconv.Itoa(s.ID), Score: score})
+		}
+	}
+	slices.SortStableFunc(candidates, func(a, b resolutionCandidate) int { return cmp.Compare(b.Score, a.Score) })
+	return candidates
+}
+
+// explainStopFinder explains taking the stop-finder's first result for
+// input. It does nothing when there were no results.
+func explainStopFinder(input string, locations []sl.Location) {
+	if !explainResolution || len(locations) == 0 {
+		return
+	}
+	candidates := make([]resolutionCandidate, len(locations))
+	for i, l := range locations {
+		candidates[i] = resolutionCandidate{Name: l.Name, ID: l.ID, Type: l.Type, Score: l.MatchQuality}
+	}
+	reason := fmt.Sprintf("the stop-finder's first of %d result(s)", len(locations))
+	if locations[0].IsBest {
+		reason += ", which it marks as the best match"
+	}
+	explainResolved(resolutionExplanation{
+		Input:      input,
+		Source:     sourceStopFinder,
+		Candidates: candidates,
+		Winner:     locations[0].Name,
+		Reason:     reason,
+	})
+}
@@ -1,17 +1,75 @@
 package cmd
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/glundgren93/sl-cli/internal/config"
+	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/internal/i18n"
+	"github.com/glundgren93/sl-cli/pkg/sl"
 	"github.com/spf13/cobra"
 )
 
 var (
 	jsonOutput bool
+	freshData  bool
+	language   string
+	noEmoji    bool
+	schemaVer  int
+	colorMode  string
+
+	// outputFormat is --format; each command lists the values it accepts
+	// in its formats annotation, and "" means its default output.
+	outputFormat string
+
+	// outputTemplate is --template: JSON results are rendered through it.
+	outputTemplate string
+
+	rawExtras bool
+
+	// verbosity is how many times --verbose/-v was given: 1 traces each
+	// API request, 2 adds headers and bodies.
+	verbosity int
+
+	// sortLocale is --sort-locale, the collation stop, destination and
+	// line names are sorted by.
+	sortLocale string
 )
 
+// formatsAnnotation is the command annotation listing the --format values
+// the command accepts, space-separated.
+const formatsAnnotation = "formats"
+
+// formats returns the annotations declaring a command's --format values.
+func formats(values ...string) map[string]string {
+	return map[string]string{formatsAnnotation: strings.Join(values, " ")}
+}
+
+// checkFormat rejects a --format the command doesn't list.
+func checkFormat(cmd *cobra.Command) error {
+	if outputFormat == "" {
+		return nil
+	}
+	accepted := strings.Fields(cmd.Annotations[formatsAnnotation])
+	if len(accepted) == 0 {
+		return fmt.Errorf("%s doesn't support --format", cmd.CommandPath())
+	}
+	if !slices.Contains(accepted, outputFormat) {
+		return fmt.Errorf("unknown format %q (use %s)", outputFormat, strings.Join(accepted, ", "))
+	}
+	return nil
+}
+
+// textFormat reports whether --format asks for the default text output.
+func textFormat() bool {
+	return outputFormat == "" || outputFormat == "text"
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "sl",
 	Short: "Stockholm public transport CLI",
@@ -20,18 +78,28 @@ var rootCmd = &cobra.Command{
 Query real-time departures, plan journeys, find nearby stops, and check
 service deviations. Designed for both humans and AI agents.
 
-No API key required. Data sourced from SL via Trafiklab.`,
+No API key required for the core commands. Data sourced from SL via Trafiklab.`,
 	SilenceErrors: true,
 }
 
-// Execute runs the root command and handles errors.
+// Execute runs the root command and handles errors. Errors raised before
+// the command itself runs (unknown commands, bad flags or arguments) are
+// returned as usage errors; see ExitCode.
 func Execute() error {
+	commandStarted = false
 	err := rootCmd.Execute()
+	if err != nil && !commandStarted {
+		err = &usageError{err}
+	}
+	done := progressEvent{Event: "done"}
+	if err != nil {
+		done.Error = err.Error()
+	}
+	emitProgress(done)
 	if err != nil {
 		if jsonOutput {
-			enc := json.NewEncoder(os.Stderr)
-			enc.SetEscapeHTML(false)
-			enc.Encode(map[string]string{"error": err.Error()})
+			data, _ := format.Encode(newErrorEnvelope(err))
+			os.Stderr.Write(append(data, '\n'))
 		} else {
 			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
 		}
@@ -39,13 +107,172 @@ func Execute() error {
 	return err
 }
 
+// errorEnvelope is the JSON written to stderr when a command fails.
+type errorEnvelope struct {
+	Error errorDetail `json:"error"`
+	// APIStatus is "down" when an SL API is unavailable (maintenance, 503,
+	// HTML error page), so agents can back off instead of retrying at once.
+	APIStatus string `json:"api_status,omitempty"`
+	DownSince string `json:"api_down_since,omitempty"`
+	// Attempts is how many times the failing request was sent, retries
+	// included.
+	Attempts int `json:"api_attempts,omitempty"`
+}
+
+// errorDetail says what failed. Code is one of the sl.Code constants or
+// USAGE, for agents to branch on; Candidates lists the stops an AMBIGUOUS_STOP name
+// matched.
+type errorDetail struct {
+	Code       string          `json:"code"`
+	Message    string          `json:"message"`
+	Candidates []stopCandidate `json:"candidates,omitempty"`
+}
+
+type stopCandidate struct {
+	Name   string `json:"name"`
+	SiteID int    `json:"site_id"`
+}
+
+// errorEnvelopeV1 is the schema 1 shape, with the message as "error".
+type errorEnvelopeV1 struct {
+	Error     string `json:"error"`
+	APIStatus string `json:"api_status,omitempty"`
+	DownSince string `json:"api_down_since,omitempty"`
+	Attempts  int    `json:"api_attempts,omitempty"`
+}
+
+func (e errorEnvelope) ForSchema(version int) any {
+	return errorEnvelopeV1{Error: e.Error.Message, APIStatus: e.APIStatus, DownSince: e.DownSince, Attempts: e.Attempts}
+}
+
+func newErrorEnvelope(err error) errorEnvelope {
+	env := errorEnvelope{
+		Error:     errorDetail{Code: errorCode(err), Message: err.Error()},
+		APIStatus: sl.APIStatus(err),
+		Attempts:  sl.Attempts(err),
+	}
+	var ambiguous *sl.AmbiguousStopError
+	if errors.As(err, &ambiguous) {
+		for _, s := range ambiguous.Candidates {
+			env.Error.Candidates = append(env.Error.Candidates, stopCandidate{Name: s.Name, SiteID: s.ID})
+		}
+	}
+	var down *sl.APIDownError
+	if errors.As(err, &down) && !down.Since.IsZero() {
+		env.DownSince = down.Since.Format(time.RFC3339)
+	}
+	return env
+}
+
+// newClient creates an API client whose non-fatal warnings are printed to
+// stderr for humans. JSON output stays clean; agents get the data only.
+// With --verbose each API request is traced on stderr.
+// The static cache TTL comes from the config file; a config that can't be
+// read is reported by the commands that need it, not here.
+func newClient() *sl.Client {
+	opts := sl.Options{
+		OnWarning: func(w sl.Warning) {
+			if !jsonOutput {
+				fmt.Fprintf(format.Stderr(), "⚠️  %s\n", w.Message)
+			}
+		},
+		Trace:         os.Stderr,
+		TraceLevel:    verbosity,
+		CacheDir:      cacheDir,
+		CaptureExtras: rawExtras,
+	}
+	if cfg, err := config.Load(); err == nil {
+		opts.StaticCacheTTL = time.Duration(cfg.CacheTTL)
+		opts.Retries = cfg.Retries
+	}
+	if sandboxMode {
+		// Fixtures don't fail intermittently; retrying only delays the
+		// error for requests the sandbox can't answer.
+		noRetries := 0
+		opts.Transport = sandboxServer.Transport()
+		opts.Retries = &noRetries
+	}
+	return sl.New(opts)
+}
+
+// hyperlinksEnabled decides whether stop names and deviation headers are
+// clickable: FORCE_HYPERLINK wins, then "hyperlinks" in the config file,
+// then whether the terminal is known to support them.
+func hyperlinksEnabled() bool {
+	if os.Getenv("FORCE_HYPERLINK") != "" {
+		return format.TerminalHyperlinks()
+	}
+	if cfg, err := config.Load(); err == nil && cfg.Hyperlinks != nil {
+		return *cfg.Hyperlinks
+	}
+	return format.TerminalHyperlinks()
+}
+
 func init() {
 	rootCmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "Output in JSON format (for agent/machine consumption)")
+	rootCmd.PersistentFlags().BoolVar(&freshData, "fresh", false, "Bypass local caches and always query the API")
+	rootCmd.PersistentFlags().BoolVar(&progressJSON, "progress-json", false, "Emit NDJSON progress events on stderr")
+	rootCmd.PersistentFlags().BoolVar(&explainResolution, "explain-resolution", false, "Explain on stderr how each stop name or address was resolved: candidates, scores, source and why the winner won")
+	rootCmd.PersistentFlags().StringVar(&language, "lang", "en", "Language for output and planner results (sv or en; x-pseudo for layout testing)")
+	rootCmd.PersistentFlags().BoolVar(&noEmoji, "no-emoji", false, "Plain ASCII output: [BUS]-style labels instead of emoji and box drawing (also NO_EMOJI=1 or ASCII=1)")
+	rootCmd.PersistentFlags().IntVar(&schemaVer, "schema-version", 0, "Write JSON in this schema version (default the current one)")
+	rootCmd.PersistentFlags().BoolVar(&noEmoji, "ascii", false, "Same as --no-emoji")
+	rootCmd.PersistentFlags().StringVar(&colorMode, "color", "auto", "Color output: auto (terminals, honouring NO_COLOR and CLICOLOR_FORCE), always or never")
+	rootCmd.PersistentFlags().StringVar(&outputTemplate, "template", "", `Render results through a Go template, e.g. '{{.Line}} {{.MinutesLeft}}m' (fields as in --json)`)
+	rootCmd.PersistentFlags().BoolVar(&rawExtras, "raw-extras", false, "With --json, include response fields sl-cli doesn't know yet under raw_extras")
+	rootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "Log each API request's URL, status, size and latency to stderr; -vv adds headers and bodies")
+	rootCmd.PersistentFlags().StringVar(&sortLocale, "sort-locale", "sv", "Sort names by sv (å, ä, ö after z), en (å and ä as a, ö as o) or C (byte order)")
+	rootCmd.PersistentFlags().BoolVar(&sandboxMode, "sandbox", false, "Serve every command from bundled sample data, offline and with a sample config instead of yours (for demos, CI and docs)")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "format", "", "Output format: table, csv or tsv for listings; some commands add their own (see their help)")
 
 	// Silence usage on RunE errors (not flag errors).
 	// Cobra shows usage by default on all errors; we only want it for bad flags/args.
-	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
 		// If we got past flag parsing, silence usage for runtime errors
 		cmd.SilenceUsage = true
+		if err := setSandbox(cmd); err != nil {
+			return err
+		}
+		if err := format.SetColor(colorMode); err != nil {
+			return err
+		}
+		plain := noEmoji || format.PlainFromEnv()
+		format.SetPlain(plain)
+		format.SetHyperlinks(!plain && hyperlinksEnabled())
+		if err := format.SetSchemaVersion(schemaVer); err != nil {
+			return err
+		}
+		if err := format.SetSortLocale(sortLocale); err != nil {
+			return err
+		}
+		if err := checkFormat(cmd); err != nil {
+			return err
+		}
+		if outputTemplate != "" {
+			if outputFormat != "" {
+				return fmt.Errorf("--template can't be combined with --format")
+			}
+			// Templates render what --json would write.
+			cmd.Flags().Set("json", "true")
+		}
+		if err := format.SetTemplate(outputTemplate); err != nil {
+			return err
+		}
+		if rawExtras && !jsonOutput {
+			return fmt.Errorf("--raw-extras needs --json")
+		}
+		if err := i18n.SetLanguage(language); err != nil {
+			return err
+		}
+		// Cobra checks these after this hook; checking them first lets
+		// every error from here on count as a runtime one.
+		if err := cmd.ValidateRequiredFlags(); err != nil {
+			return err
+		}
+		if err := cmd.ValidateFlagGroups(); err != nil {
+			return err
+		}
+		commandStarted = true
+		return nil
 	}
 }
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/glundgren93/sl-cli/internal/config"
+	"github.com/glundgren93/sl-cli/internal/keys"
+	"github.com/glundgren93/sl-cli/internal/sandbox"
+	"github.com/spf13/cobra"
+)
+
+// sandboxMode is --sandbox: every command is served from the bundled
+// fixtures, without network access or the user's config file.
+var sandboxMode bool
+
+// sandboxServer answers API requests under --sandbox, loaded on first use.
+var sandboxServer *sandbox.Server
+
+// cacheDir is where newClient's clients keep their caches; "" is the
+// default, sl-cli under the user's cache dir.
+var cacheDir string
+
+// changesSettingsAnnotation marks commands that write the user's config
+// or API keys, which --sandbox refuses to run.
+const changesSettingsAnnotation = "changes-settings"
+
+// changesSettings returns the annotations of a command that writes the
+// user's settings.
+func changesSettings() map[string]string {
+	return map[string]string{changesSettingsAnnotation: "true"}
+}
+
+// setSandbox turns the sandbox on or off for this run. In the sandbox,
+// the bundled sample config stands in for the user's and caches are kept
+// apart from the real ones, so fixture data never leaks into later live
+// runs.
+func setSandbox(cmd *cobra.Command) error {
+	if !sandboxMode {
+		config.Use(nil)
+		cacheDir = ""
+		return nil
+	}
+	if cmd.Annotations[changesSettingsAnnotation] != "" {
+		return fmt.Errorf("%s changes your settings, which --sandbox doesn't allow", cmd.CommandPath())
+	}
+	if sandboxServer == nil {
+		s, err := sandbox.New()
+		if err != nil {
+			return fmt.Errorf("loading sandbox data: %w", err)
+		}
+		sandboxServer = s
+	}
+	config.Use(&sandboxServer.Config)
+	base, err := os.UserCacheDir()
+	if err != nil {
+		base = os.TempDir()
+	}
+	cacheDir = filepath.Join(base, "sl-cli", "sandbox")
+	return nil
+}
+
+// apiKey returns the stored key for a keyed integration. The sandbox
+// serves those feeds itself and needs none.
+func apiKey(name string) (string, error) {
+	if sandboxMode {
+		return "sandbox", nil
+	}
+	return keys.Get(name)
+}
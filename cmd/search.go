@@ -3,14 +3,19 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 
-	"github.com/glundgren93/sl-cli/internal/api"
 	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/pkg/sl"
 	"github.com/spf13/cobra"
 )
 
-var searchLimit int
+var (
+	searchLimit  int
+	searchOffset int
+	searchType   string
+)
 
 var searchCmd = &cobra.Command{
 	Use:   "search <query>",
@@ -20,6 +25,8 @@ var searchCmd = &cobra.Command{
 Examples:
   sl search Medborgarplatsen
   sl search "Stockholm City"
+  sl search Slussen --type METROSTN
+  sl search Station --limit 20 --offset 20   # Results 21–40
   sl search Slussen --json`,
 	Aliases: []string{"find", "s"},
 	Args:    cobra.MinimumNArgs(1),
@@ -27,22 +34,43 @@ Examples:
 }
 
 func init() {
-	searchCmd.Flags().IntVar(&searchLimit, "limit", 20, "Max results")
+	searchCmd.Flags().IntVar(&searchLimit, "limit", 20, "Max results (0 = all)")
+	searchCmd.Flags().IntVar(&searchOffset, "offset", 0, "Skip this many results")
+	searchCmd.Flags().StringVar(&searchType, "type", "", "Filter by stop area type (e.g. METROSTN, BUSTERM, RAILWSTN, TRAMSTN, SHIPBER)")
 	rootCmd.AddCommand(searchCmd)
 }
 
 type siteResult struct {
-	ID   int     `json:"id"`
-	Name string  `json:"name"`
-	Lat  float64 `json:"lat"`
-	Lon  float64 `json:"lon"`
+	ID    int      `json:"id"`
+	Name  string   `json:"name"`
+	Lat   float64  `json:"lat"`
+	Lon   float64  `json:"lon"`
+	Types []string `json:"types,omitempty"`
+}
+
+// loadStopAreaTypes fetches the stop area type mapping. When the filter
+// depends on it a failure is fatal; otherwise types are just left off.
+func loadStopAreaTypes(ctx context.Context, client *sl.Client, required bool) (map[int]string, error) {
+	areaTypes, err := client.GetStopAreaTypesCached(ctx)
+	if err != nil {
+		if required {
+			return nil, fmt.Errorf("fetching stop area types: %w", err)
+		}
+		client.Warn(sl.WarnPartialResponse, "stop types unavailable: %v", err)
+		return nil, nil
+	}
+	return areaTypes, nil
 }
 
 func runSearch(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
-	client := api.NewClient()
+	client := newClient()
 	query := strings.Join(args, " ")
 
+	if err := checkPaging(searchLimit, searchOffset); err != nil {
+		return err
+	}
+
 	sites, err := client.GetSitesCached(ctx)
 	if err != nil {
 		return fmt.Errorf("fetching sites: %w", err)
@@ -50,8 +78,7 @@ func runSearch(cmd *cobra.Command, args []string) error {
 
 	queryLower := strings.ToLower(query)
 	seen := make(map[int]bool)
-	results := []siteResult{}
-
+	var matches []sl.Site
 	for _, s := range sites {
 		if seen[s.ID] {
 			continue
@@ -69,19 +96,38 @@ func runSearch(cmd *cobra.Command, args []string) error {
 
 		if matched {
 			seen[s.ID] = true
-			results = append(results, siteResult{
-				ID:   s.ID,
-				Name: s.Name,
-				Lat:  s.Lat,
-				Lon:  s.Lon,
-			})
+			matches = append(matches, s)
+		}
+	}
+
+	// Stop types come from the large stop points list; only load them
+	// when there is a match to tag or filter.
+	var areaTypes map[int]string
+	if len(matches) > 0 {
+		areaTypes, err = loadStopAreaTypes(ctx, client, searchType != "")
+		if err != nil {
+			return err
 		}
 	}
 
-	if searchLimit > 0 && len(results) > searchLimit {
-		results = results[:searchLimit]
+	results := []siteResult{}
+	for _, s := range matches {
+		types := sl.SiteTypes(s, areaTypes)
+		if searchType != "" && !sl.HasType(types, searchType) {
+			continue
+		}
+		results = append(results, siteResult{
+			ID:    s.ID,
+			Name:  s.Name,
+			Lat:   s.Lat,
+			Lon:   s.Lon,
+			Types: types,
+		})
 	}
 
+	slices.SortStableFunc(results, func(a, b siteResult) int { return format.Collate(a.Name, b.Name) })
+	results = window(results, searchLimit, searchOffset)
+
 	if jsonOutput {
 		return format.JSON(results)
 	}
@@ -92,9 +138,9 @@ func runSearch(cmd *cobra.Command, args []string) error {
 	}
 
 	fmt.Printf("Found %d stop(s) matching %q\n", len(results), query)
-	fmt.Println(strings.Repeat("─", 60))
+	fmt.Fprintln(format.Stdout(), strings.Repeat("─", 60))
 	for i, s := range results {
-		fmt.Printf("  %d. %-35s (id:%d)\n", i+1, s.Name, s.ID)
+		fmt.Printf("  %d. %s (id:%d)%s\n", i+1, format.PadLink(format.MapURL(s.Lat, s.Lon), s.Name, 35), s.ID, format.TypeTags(s.Types))
 	}
 	fmt.Println()
 	return nil
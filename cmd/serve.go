@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveAddr  string
+	serveMax   int
+	serveCache time.Duration
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run a local HTTP API and chat webhook server",
+	Long: `Run an HTTP server with a JSON API for dashboards and home automation,
+and answering chat queries, so one long-running process serves a wall
+display, a Home Assistant REST sensor and a whole office's Slack.
+
+JSON endpoints, answering with what the matching command writes with --json:
+  GET /departures  site=ID or stop=NAME; line, mode, direction, limit (10)
+  GET /nearby      lat, lon; radius in km (0.5), limit (10)
+  GET /trip        from, to (as for sl trip); at
+  GET /deviations  mode, site, line (line ID); each may repeat
+
+Responses are cached for --cache, so clients polling the same query share
+one request to SL. Errors are JSON like --json errors, with status 400 for
+a bad request, 502 when SL fails and 503 when SL is down.
+
+Chat endpoints:
+  POST /slack     Slack slash command (e.g. /sl next 55 medborgarplatsen)
+  POST /webhook   Generic webhook: JSON {"text": "..."} or a form field "text";
+                  GET /webhook?text=... works too. Replies with plain text.
+
+Queries are "[next] [line] <stop>", e.g. "next 55 medborgarplatsen",
+"17 slussen" or just "T-Centralen". The stop can also be a site ID.
+
+Set SLACK_SIGNING_SECRET to the app's signing secret to verify that
+requests to /slack really come from Slack.
+
+Examples:
+  sl serve --addr :8080
+  curl 'localhost:8080/departures?site=9191&line=17'
+  curl 'localhost:8080/webhook?text=next+55+medborgarplatsen'`,
+	Args: cobra.NoArgs,
+	RunE: runServe,
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "localhost:8080", "Address to listen on")
+	serveCmd.Flags().IntVar(&serveMax, "max", 5, "Departures per chat reply")
+	serveCmd.Flags().DurationVar(&serveCache, "cache", 30*time.Second, "How long JSON API responses are reused (0 = never)")
+
+	rootCmd.AddCommand(serveCmd)
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	secret := os.Getenv("SLACK_SIGNING_SECRET")
+	if secret == "" {
+		fmt.Fprintln(format.Stderr(), "⚠️  SLACK_SIGNING_SECRET is not set; /slack requests are not verified")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	client := newClient()
+	mux := chatMux(client, secret)
+	apiRoutes(mux, client, serveCache)
+	srv := &http.Server{
+		Addr:              serveAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Fprintf(format.Stderr(), "Listening on %s\n", serveAddr)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// chatMux routes the chat endpoints. secret, when set, is the Slack signing
+// secret /slack requests must be signed with.
+func chatMux(client *sl.Client, secret string) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /slack", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(io.LimitReader(r.Body, 64<<10))
+		if err != nil {
+			http.Error(w, "unreadable body", http.StatusBadRequest)
+			return
+		}
+		if secret != "" && !validSlackSignature(secret, r.Header, body, time.Now()) {
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		reply := answerChat(r.Context(), client, r.PostFormValue("text"))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"response_type": "in_channel", "text": reply})
+	})
+	webhook := func(w http.ResponseWriter, r *http.Request) {
+		text := r.URL.Query().Get("text")
+		if r.Method == http.MethodPost {
+			if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+				var payload struct {
+					Text string `json:"text"`
+				}
+				if err := json.NewDecoder(io.LimitReader(r.Body, 64<<10)).Decode(&payload); err != nil {
+					http.Error(w, "invalid JSON", http.StatusBadRequest)
+					return
+				}
+				text = payload.Text
+			} else {
+				text = r.PostFormValue("text")
+			}
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, answerChat(r.Context(), client, text))
+	}
+	mux.HandleFunc("GET /webhook", webhook)
+	mux.HandleFunc("POST /webhook", webhook)
+	return mux
+}
+
+// slackMaxSkew is how old a signed Slack request may be, to stop replays.
+const slackMaxSkew = 5 * time.Minute
+
+// validSlackSignature checks Slack's v0 request signature: an HMAC-SHA256
+// of "v0:<timestamp>:<body>" keyed with the app's signing secret.
+func validSlackSignature(secret string, h http.Header, body []byte, now time.Time) bool {
+	ts, err := strconv.ParseInt(h.Get("X-Slack-Request-Timestamp"), 10, 64)
+	if err != nil {
+		return false
+	}
+	if skew := now.Sub(time.Unix(ts, 0)); skew > slackMaxSkew || skew < -slackMaxSkew {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%d:%s", ts, body)
+	want := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(want), []byte(h.Get("X-Slack-Signature")))
+}
+
+// chatQuery is a parsed "[next] [line] <stop>" query.
+type chatQuery struct {
+	Line string
+	Stop string
+}
+
+// parseChatQuery splits a chat query into an optional line and a stop. A
+// leading word is taken as the line when it contains a digit and more
+// words follow, so "55 medborgarplatsen" asks for line 55 but "T-Centralen"
+// is just a stop.
+func parseChatQuery(text string) chatQuery {
+	fields := strings.Fields(text)
+	if len(fields) > 0 && (strings.EqualFold(fields[0], "next") || strings.EqualFold(fields[0], "nästa")) {
+		fields = fields[1:]
+	}
+	var q chatQuery
+	if len(fields) > 1 && len(fields[0]) <= 4 && strings.IndexFunc(fields[0], unicode.IsDigit) >= 0 {
+		q.Line, fields = fields[0], fields[1:]
+	}
+	q.Stop = strings.Join(fields, " ")
+	return q
+}
+
+// answerChat runs a chat query and returns the reply text. Errors are
+// replied rather than returned: the person asking needs to see them.
+func answerChat(ctx context.Context, client *sl.Client, text string) string {
+	q := parseChatQuery(text)
+	if q.Stop == "" {
+		return "Usage: [next] [line] <stop>, e.g. next 55 medborgarplatsen"
+	}
+
+	siteID, err := strconv.Atoi(q.Stop)
+	if err != nil {
+		if siteID, err = resolveSiteID(ctx, client, q.Stop); err != nil {
+			return fmt.Sprintf("⚠️ %v", err)
+		}
+	}
+	resp, asOf, err := client.GetDeparturesLastGood(ctx, sl.DepartureOptions{SiteID: siteID, Line: q.Line})
+	if err != nil {
+		return fmt.Sprintf("⚠️ fetching departures: %v", err)
+	}
+
+	parsed := sl.ParseDepartures(resp.Departures)
+	stopName := fmt.Sprintf("Site %d", siteID)
+	if len(parsed) > 0 {
+		stopName = parsed[0].StopArea
+	}
+	reply := format.ChatDepartures(parsed, stopName, serveMax)
+	if !asOf.IsZero() {
+		reply += fmt.Sprintf("\n⚠️ SL is not responding — as of %s", sl.StockholmTime(asOf).Format("15:04"))
+	}
+	return reply
+}
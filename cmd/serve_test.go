@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glundgren93/sl-cli/internal/apitest"
+	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+func TestParseChatQuery(t *testing.T) {
+	tests := []struct {
+		text string
+		want chatQuery
+	}{
+		{"next 55 medborgarplatsen", chatQuery{Line: "55", Stop: "medborgarplatsen"}},
+		{"17 Slussen", chatQuery{Line: "17", Stop: "Slussen"}},
+		{"T-Centralen", chatQuery{Stop: "T-Centralen"}},
+		{"9191", chatQuery{Stop: "9191"}},
+		{"nästa 43X Stockholms södra", chatQuery{Line: "43X", Stop: "Stockholms södra"}},
+		{"next", chatQuery{}},
+	}
+	for _, tt := range tests {
+		if got := parseChatQuery(tt.text); got != tt.want {
+			t.Errorf("parseChatQuery(%q) = %+v, want %+v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestChatWebhook(t *testing.T) {
+	apitest.New(t)
+	srv := httptest.NewServer(chatMux(newClient(), ""))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/webhook?text=" + url.QueryEscape("next 17 Medborgarplatsen"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "Medborgarplatsen") || !strings.Contains(string(body), " 17 ") {
+		t.Errorf("unexpected reply:\n%s", body)
+	}
+	if strings.Contains(string(body), " 55 ") {
+		t.Errorf("reply should only list line 17:\n%s", body)
+	}
+
+	resp, err = http.Post(srv.URL+"/webhook", "application/json", strings.NewReader(`{"text": ""}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.HasPrefix(string(body), "Usage:") {
+		t.Errorf("empty query should get usage, got %q", body)
+	}
+}
+
+func TestChatSlackSignature(t *testing.T) {
+	apitest.New(t)
+	const secret = "s3cret"
+	srv := httptest.NewServer(chatMux(newClient(), secret))
+	defer srv.Close()
+
+	form := url.Values{"text": {"9191"}}.Encode()
+	post := func(sign bool) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/slack", strings.NewReader(form))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set("X-Slack-Request-Timestamp", ts)
+		if sign {
+			mac := hmac.New(sha256.New, []byte(secret))
+			fmt.Fprintf(mac, "v0:%s:%s", ts, form)
+			req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	if resp := post(false); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("unsigned request: status %d, want 401", resp.StatusCode)
+	}
+
+	resp := post(true)
+	defer resp.Body.Close()
+	var reply struct {
+		ResponseType string `json:"response_type"`
+		Text         string `json:"text"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		t.Fatalf("status %d: %v", resp.StatusCode, err)
+	}
+	if reply.ResponseType != "in_channel" || !strings.Contains(reply.Text, "Medborgarplatsen") {
+		t.Errorf("unexpected reply %+v", reply)
+	}
+}
+
+func TestServeAPI(t *testing.T) {
+	fake := apitest.New(t)
+	client := newClient()
+	mux := chatMux(client, "")
+	apiRoutes(mux, client, time.Minute)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	get := func(path string, v any) *http.Response {
+		t.Helper()
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("GET %s: invalid JSON: %v", path, err)
+		}
+		return resp
+	}
+
+	var deps departureResult
+	resp := get("/departures?site=9191&line=17", &deps)
+	if resp.StatusCode != http.StatusOK || deps.Stop != "Medborgarplatsen" || len(deps.Departures) != 2 {
+		t.Fatalf("GET /departures: %d, %q with %d departures", resp.StatusCode, deps.Stop, len(deps.Departures))
+	}
+	if resp := get("/departures?line=17&site=9191", &deps); resp.Header.Get("X-Cache") != "hit" {
+		t.Errorf("repeated query should be served from the cache, got X-Cache %q", resp.Header.Get("X-Cache"))
+	}
+	if n := fake.RequestCount("/transport/v1/sites/9191/departures"); n != 1 {
+		t.Errorf("expected one departures request to SL, got %d", n)
+	}
+
+	var env errorEnvelope
+	if resp := get("/departures?line=17", &env); resp.StatusCode != http.StatusBadRequest || env.Error.Message == "" {
+		t.Errorf("GET /departures without a stop: %d %+v, want 400 with an error", resp.StatusCode, env)
+	}
+
+	var nearby []sl.SiteWithDistance
+	resp = get("/nearby?lat=59.3143&lon=18.0735&radius=0.3", &nearby)
+	if len(nearby) == 0 || nearby[0].Site.ID != 9191 {
+		t.Errorf("GET /nearby: want Medborgarplatsen first, got %+v", nearby)
+	}
+	if v := resp.Header.Get("X-Schema-Version"); v != strconv.Itoa(format.SchemaVersion) {
+		t.Errorf("GET /nearby: X-Schema-Version %q, want %d", v, format.SchemaVersion)
+	}
+
+	var trip tripResult
+	if resp := get("/trip?from=9091001000009191&to=9091001000009001", &trip); resp.StatusCode != http.StatusOK || len(trip.Journeys) == 0 {
+		t.Errorf("GET /trip: %d with %d journeys", resp.StatusCode, len(trip.Journeys))
+	}
+
+	var devs []sl.Deviation
+	if resp := get("/deviations?mode=METRO", &devs); resp.StatusCode != http.StatusOK || len(devs) == 0 {
+		t.Errorf("GET /deviations: %d with %d deviations", resp.StatusCode, len(devs))
+	}
+}
@@ -0,0 +1,315 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/glundgren93/sl-cli/internal/config"
+	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/internal/i18n"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+// apiHandler answers a REST API request with the value to write as JSON.
+type apiHandler func(ctx context.Context, client *sl.Client, q queryParams) (any, error)
+
+// badRequestError is an API error caused by the request, answered with 400
+// rather than 502.
+type badRequestError struct{ msg string }
+
+func (e *badRequestError) Error() string { return e.msg }
+
+func badRequest(msg string, args ...any) error {
+	return &badRequestError{fmt.Sprintf(msg, args...)}
+}
+
+// apiRoutes adds the REST endpoints to mux. Responses are the JSON the
+// matching command writes with --json, and are cached for ttl so several
+// dashboards polling the same query share one request to SL.
+func apiRoutes(mux *http.ServeMux, client *sl.Client, ttl time.Duration) {
+	cache := &responseCache{ttl: ttl, entries: map[string]cachedResponse{}}
+	for path, h := range map[string]apiHandler{
+		"/departures": apiDepartures,
+		"/nearby":     apiNearby,
+		"/trip":       apiTrip,
+		"/deviations": apiDeviations,
+	} {
+		mux.HandleFunc("GET "+path, cache.serve(client, h))
+	}
+}
+
+// responseCache holds encoded API responses by path and query.
+type responseCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]cachedResponse
+}
+
+type cachedResponse struct {
+	body    []byte
+	expires time.Time
+}
+
+func (c *responseCache) serve(client *sl.Client, h apiHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Path + "?" + r.URL.Query().Encode()
+		now := time.Now()
+
+		c.mu.Lock()
+		entry, ok := c.entries[key]
+		c.mu.Unlock()
+		if ok && now.Before(entry.expires) {
+			writeAPIResponse(w, http.StatusOK, entry.body, "hit")
+			return
+		}
+
+		v, err := h(r.Context(), client, queryParams(r.URL.Query()))
+		if err != nil {
+			status := http.StatusBadGateway
+			var bad *badRequestError
+			switch {
+			case errors.As(err, &bad):
+				status = http.StatusBadRequest
+			case sl.APIStatus(err) == "down":
+				status = http.StatusServiceUnavailable
+			}
+			body, _ := format.Encode(newErrorEnvelope(err))
+			writeAPIResponse(w, status, body, "")
+			return
+		}
+		body, err := format.Encode(v)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		c.mu.Lock()
+		for k, e := range c.entries {
+			if now.After(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if c.ttl > 0 {
+			c.entries[key] = cachedResponse{body: body, expires: now.Add(c.ttl)}
+		}
+		c.mu.Unlock()
+		writeAPIResponse(w, http.StatusOK, body, "miss")
+	}
+}
+
+// writeAPIResponse sends a JSON body with its schema version in a header,
+// since list results are plain arrays with no schema_version field.
+func writeAPIResponse(w http.ResponseWriter, status int, body []byte, cache string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Schema-Version", strconv.Itoa(format.Schema()))
+	if cache != "" {
+		w.Header().Set("X-Cache", cache)
+	}
+	w.WriteHeader(status)
+	w.Write(append(body, '\n'))
+}
+
+// queryParams reads typed query parameters, reporting bad values as
+// badRequestErrors.
+type queryParams map[string][]string
+
+func (q queryParams) get(name string) string {
+	if v := q[name]; len(v) > 0 {
+		return strings.TrimSpace(v[0])
+	}
+	return ""
+}
+
+func (q queryParams) getInt(name string, def int) (int, error) {
+	s := q.get(name)
+	if s == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, badRequest("%s: not a number: %q", name, s)
+	}
+	return n, nil
+}
+
+func (q queryParams) getFloat(name string) (float64, bool, error) {
+	s := q.get(name)
+	if s == "" {
+		return 0, false, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false, badRequest("%s: not a number: %q", name, s)
+	}
+	return f, true, nil
+}
+
+// apiSite resolves the site=ID or stop=NAME parameter.
+func apiSite(ctx context.Context, client *sl.Client, q queryParams) (int, error) {
+	if q.get("site") != "" {
+		return q.getInt("site", 0)
+	}
+	if stop := q.get("stop"); stop != "" {
+		return resolveSiteID(ctx, client, stop)
+	}
+	return 0, badRequest("give site=ID or stop=NAME")
+}
+
+// apiDepartures serves GET /departures?site=|stop=&line=&mode=&direction=&limit=.
+func apiDepartures(ctx context.Context, client *sl.Client, q queryParams) (any, error) {
+	siteID, err := apiSite(ctx, client, q)
+	if err != nil {
+		return nil, err
+	}
+	limit, err := q.getInt("limit", 10)
+	if err != nil {
+		return nil, err
+	}
+	mode := strings.ToUpper(q.get("mode"))
+	opts := sl.DepartureOptions{SiteID: siteID, TransportMode: mode, Line: q.get("line")}
+	if dir := q.get("direction"); dir != "" {
+		if n, err := strconv.Atoi(dir); err == nil {
+			opts.Direction = n
+		} else {
+			opts.DirectionText = dir
+		}
+	}
+
+	resp, asOf, err := client.GetDeparturesLastGood(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("fetching departures: %w", err)
+	}
+	parsed := sl.ParseDepartures(resp.Departures)
+	if mode != "" {
+		parsed = sl.FilterByTransportMode(parsed, mode)
+	}
+	parsed = window(parsed, limit, 0)
+	if parsed == nil {
+		parsed = []sl.ParsedDeparture{}
+	}
+
+	stopName := fmt.Sprintf("Site %d", siteID)
+	if len(parsed) > 0 {
+		stopName = parsed[0].StopArea
+	}
+	return departureResult{
+		Stop:       stopName,
+		SiteID:     siteID,
+		Departures: parsed,
+		Deviations: fetchRelevantDeviations(ctx, client, parsed),
+		AsOf:       asOf,
+	}, nil
+}
+
+// apiNearby serves GET /nearby?lat=&lon=&radius=&limit=, radius in km.
+func apiNearby(ctx context.Context, client *sl.Client, q queryParams) (any, error) {
+	lat, okLat, err := q.getFloat("lat")
+	if err != nil {
+		return nil, err
+	}
+	lon, okLon, err := q.getFloat("lon")
+	if err != nil {
+		return nil, err
+	}
+	if !okLat || !okLon {
+		return nil, badRequest("give lat and lon")
+	}
+	radius, ok, err := q.getFloat("radius")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		radius = 0.5
+	}
+	limit, err := q.getInt("limit", 10)
+	if err != nil {
+		return nil, err
+	}
+
+	sites, err := client.GetSitesCached(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetching sites: %w", err)
+	}
+	nearby := window(sl.FindNearestSites(sites, lat, lon, radius), limit, 0)
+	for i := range nearby {
+		nearby[i].DistanceM = int(nearby[i].DistanceKm * 1000)
+	}
+	if nearby == nil {
+		nearby = []sl.SiteWithDistance{}
+	}
+	return nearby, nil
+}
+
+// apiTrip serves GET /trip?from=&to=&at=, with from and to as for sl trip.
+func apiTrip(ctx context.Context, client *sl.Client, q queryParams) (any, error) {
+	from, to := q.get("from"), q.get("to")
+	if from == "" || to == "" {
+		return nil, badRequest("give from and to")
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	var departAt time.Time
+	if at := q.get("at"); at != "" {
+		if departAt, err = resolveAt(cfg, at, sl.StockholmTime(time.Now())); err != nil {
+			return nil, badRequest("at: %v", err)
+		}
+	}
+	originID, originName, err := resolveTripEndpoint(ctx, client, cfg, from)
+	if err != nil {
+		return nil, badRequest("resolving origin: %v", err)
+	}
+	destID, destName, err := resolveTripEndpoint(ctx, client, cfg, to)
+	if err != nil {
+		return nil, badRequest("resolving destination: %v", err)
+	}
+
+	resp, err := client.PlanTripCached(ctx, sl.TripOptions{
+		OriginID:   originID,
+		DestID:     destID,
+		NumTrips:   3,
+		Language:   i18n.Language(),
+		MaxChanges: -1,
+		DepartAt:   departAt,
+	})
+	if err == nil {
+		err = plannerError(resp)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("planning trip: %w", err)
+	}
+	return tripResult{From: originName, To: destName, Journeys: resp.Journeys}, nil
+}
+
+// apiDeviations serves GET /deviations?mode=&site=&line=, where line is a
+// line ID and each parameter may repeat.
+func apiDeviations(ctx context.Context, client *sl.Client, q queryParams) (any, error) {
+	opts := sl.DeviationOptions{}
+	for _, m := range q["mode"] {
+		opts.TransportModes = append(opts.TransportModes, strings.ToUpper(m))
+	}
+	for name, ids := range map[string]*[]int{"site": &opts.SiteIDs, "line": &opts.LineIDs} {
+		for _, s := range q[name] {
+			id, err := strconv.Atoi(s)
+			if err != nil {
+				return nil, badRequest("%s: not an ID: %q", name, s)
+			}
+			*ids = append(*ids, id)
+		}
+	}
+	devs, err := client.GetDeviations(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("fetching deviations: %w", err)
+	}
+	if devs == nil {
+		devs = []sl.Deviation{}
+	}
+	return devs, nil
+}
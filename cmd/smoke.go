@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+	"github.com/spf13/cobra"
+)
+
+var (
+	smokeLive  bool
+	smokePause time.Duration
+)
+
+var smokeCmd = &cobra.Command{
+	Use:   "smoke --live",
+	Short: "Check SL's live APIs still match the shapes sl-cli expects",
+	Long: `Run a small, fixed set of real queries (departures, lines, deviations,
+stop finder and one trip) and compare each response with the model it is
+decoded into. For maintainers and packagers: upstream schema drift shows up
+here instead of as a puzzling parse error in front of a user.
+
+Each check reports new fields (in the response, unknown to sl-cli) and
+missing fields (expected by sl-cli, absent from the response). New fields
+are informational; a failed request, a response that no longer decodes or
+a missing field makes the command exit non-zero.
+
+Requests are sent one at a time, --pause apart, and never from a cache.
+--live is required so the command is never run against SL by accident.
+
+Examples:
+  sl smoke --live
+  sl smoke --live --json`,
+	Args: cobra.NoArgs,
+	RunE: runSmoke,
+}
+
+func init() {
+	smokeCmd.Flags().BoolVar(&smokeLive, "live", false, "Query SL's live APIs (required)")
+	smokeCmd.Flags().DurationVar(&smokePause, "pause", 500*time.Millisecond, "Wait this long between requests")
+	rootCmd.AddCommand(smokeCmd)
+}
+
+func runSmoke(cmd *cobra.Command, args []string) error {
+	if !smokeLive {
+		return usageErrorf("sl smoke queries SL's live APIs; pass --live to run it")
+	}
+
+	client := newClient()
+	results := client.Smoke(context.Background(), client.SmokeChecks(), smokePause)
+	if jsonOutput {
+		if err := format.JSON(results); err != nil {
+			return err
+		}
+	} else {
+		format.Smoke(results)
+	}
+	return sl.SmokeSummary(results)
+}
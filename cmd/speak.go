@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"errors"
+	"os/exec"
+
+	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/internal/i18n"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+// spokenDepartureCount is how many departures --speak announces per stop.
+const spokenDepartureCount = 3
+
+// ttsCommand returns the OS text-to-speech command for text: say(1) on
+// macOS, otherwise espeak-ng, espeak or speech-dispatcher's spd-say.
+func ttsCommand(text string) (*exec.Cmd, error) {
+	lang := i18n.Language()
+	if path, err := exec.LookPath("say"); err == nil {
+		return exec.Command(path, text), nil
+	}
+	for _, name := range []string{"espeak-ng", "espeak"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return exec.Command(path, "-v", lang, text), nil
+		}
+	}
+	if path, err := exec.LookPath("spd-say"); err == nil {
+		return exec.Command(path, "--wait", "-l", lang, text), nil
+	}
+	return nil, errors.New("--speak needs a text-to-speech program (say, espeak-ng, espeak or spd-say)")
+}
+
+// announceDepartures speaks the next departures at a stop and waits until
+// the announcement is finished.
+func announceDepartures(deps []sl.ParsedDeparture, stopName string) error {
+	cmd, err := ttsCommand(format.SpokenDepartures(deps, stopName, spokenDepartureCount))
+	if err != nil {
+		return err
+	}
+	return cmd.Run()
+}
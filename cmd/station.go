@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/internal/keys"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stationSiteID int
+	stationMode   string
+	stationLimit  int
+)
+
+var stationCmd = &cobra.Command{
+	Use:   "station NAME",
+	Short: "Arrivals and departures board for a station",
+	Long: `Show a station's arrivals and departures for the next hour, split like
+the boards in a big station's hall: by time, with the line, where it comes
+from or goes to, the track and any delay.
+
+Departures are real-time. SL's Transport API has no arrivals, so those
+come from the GTFS timetable (needs sl keys set trafiklab-static <key>)
+and show no delays or tracks; without the key only departures are shown.
+
+Examples:
+  sl station "Stockholm City"
+  sl station Flemingsberg --mode TRAIN
+  sl station --site 9001 --format csv`,
+	Annotations:       formats(append([]string{"text"}, format.TableFormats...)...),
+	ValidArgsFunction: completeStops,
+	RunE:              runStation,
+}
+
+func init() {
+	stationCmd.Flags().IntVar(&stationSiteID, "site", 0, "Site ID")
+	stationCmd.Flags().StringVar(&stationMode, "mode", "", "Only this transport mode (BUS, METRO, TRAIN, TRAM, SHIP)")
+	stationCmd.Flags().IntVar(&stationLimit, "limit", 10, "Max arrivals and max departures to show (0 = all)")
+
+	rootCmd.AddCommand(stationCmd)
+}
+
+// stationResult is the JSON output of station.
+type stationResult struct {
+	Stop       string               `json:"stop"`
+	SiteID     int                  `json:"site_id"`
+	Arrivals   []sl.Arrival         `json:"arrivals"`
+	Departures []sl.ParsedDeparture `json:"departures"`
+}
+
+func runStation(cmd *cobra.Command, args []string) error {
+	if err := checkPaging(stationLimit, 0); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	client := newClient()
+
+	siteID := stationSiteID
+	if siteID == 0 {
+		if len(args) == 0 {
+			return usageErrorf("provide a station name or --site (use 'sl search <name>' to find stops)")
+		}
+		resolved, err := resolveSiteID(ctx, client, strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		siteID = resolved
+	}
+	sites, err := client.GetSitesCached(ctx)
+	if err != nil {
+		return fmt.Errorf("fetching sites: %w", err)
+	}
+	i := slices.IndexFunc(sites, func(s sl.Site) bool { return s.ID == siteID })
+	if i < 0 {
+		return sl.Errorf(sl.ErrNotFound, "site %d not found", siteID)
+	}
+	site := sites[i]
+
+	resp, err := client.GetDepartures(ctx, sl.DepartureOptions{SiteID: siteID, TransportMode: stationMode})
+	if err != nil {
+		return fmt.Errorf("fetching departures: %w", err)
+	}
+	deps := []sl.ParsedDeparture{}
+	for _, d := range sl.ParseDepartures(resp.Departures) {
+		if stationMode == "" || strings.EqualFold(d.TransportMode, stationMode) {
+			deps = append(deps, d)
+		}
+	}
+	// Boards list trains by the timetable; the delay is shown beside it.
+	sort.SliceStable(deps, func(i, j int) bool { return deps[i].Scheduled.Before(deps[j].Scheduled) })
+
+	arrs, err := stationArrivals(ctx, client, site)
+	if err != nil {
+		client.Warn(sl.WarnPartialResponse, "%v", err)
+		arrs = []sl.Arrival{}
+	}
+
+	result := stationResult{
+		Stop:       site.Name,
+		SiteID:     siteID,
+		Arrivals:   window(arrs, stationLimit, 0),
+		Departures: window(deps, stationLimit, 0),
+	}
+	if jsonOutput {
+		return format.JSON(result)
+	}
+	rows := format.StationRows(result.Arrivals, result.Departures)
+	if format.IsTable(outputFormat) {
+		return format.Table(format.Stdout(), outputFormat, format.StationColumns, rows)
+	}
+	format.Station(result.Stop, rows)
+	return nil
+}
+
+// stationArrivals lists the next hour's arrivals at site from the GTFS
+// timetable, filtered by --mode.
+func stationArrivals(ctx context.Context, client *sl.Client, site sl.Site) ([]sl.Arrival, error) {
+	key, err := apiKey(keys.TrafiklabStatic)
+	if err != nil {
+		return nil, fmt.Errorf("arrivals need the GTFS timetable: %w", err)
+	}
+	tt, err := loadTimetable(ctx, client, key)
+	if err != nil {
+		return nil, err
+	}
+
+	arrs := sl.ScheduledArrivals(tt, site, time.Now(), time.Hour)
+	if stationMode == "" {
+		return arrs, nil
+	}
+	return slices.DeleteFunc(arrs, func(a sl.Arrival) bool {
+		return !strings.EqualFold(a.TransportMode, stationMode)
+	}), nil
+}
@@ -3,11 +3,13 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"os"
+	"slices"
 	"strings"
+	"sync"
 
-	"github.com/glundgren93/sl-cli/internal/api"
 	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/internal/i18n"
+	"github.com/glundgren93/sl-cli/pkg/sl"
 	"github.com/spf13/cobra"
 )
 
@@ -15,6 +17,7 @@ var (
 	stopInfoSite    int
 	stopInfoStop    string
 	stopInfoAddress string
+	stopInfoNoDevs  bool
 )
 
 var stopInfoCmd = &cobra.Command{
@@ -23,7 +26,8 @@ var stopInfoCmd = &cobra.Command{
 	Long: `Show all transit lines that serve a specific stop.
 
 Uses real-time departure data to identify which lines currently operate at the stop.
-Results are grouped by transport mode (Metro, Bus, Train, etc).
+Results are grouped by transport mode (Metro, Bus, Train, etc), followed by
+any active disruptions at the stop itself (closed entrances, relocated stops).
 
 Examples:
   sl stop-info --site 9530                          # By site ID
@@ -38,21 +42,26 @@ func init() {
 	stopInfoCmd.Flags().IntVar(&stopInfoSite, "site", 0, "Site ID")
 	stopInfoCmd.Flags().StringVar(&stopInfoStop, "stop", "", "Stop name (fuzzy search)")
 	stopInfoCmd.Flags().StringVar(&stopInfoAddress, "address", "", "Street address (finds nearest stop)")
+	stopInfoCmd.Flags().BoolVar(&stopInfoNoDevs, "no-deviations", false, "Skip the disruption lookup for the stop")
+
+	stopInfoCmd.RegisterFlagCompletionFunc("stop", completeStops)
 
 	rootCmd.AddCommand(stopInfoCmd)
 }
 
 // stopInfoResult is the JSON output for stop-info.
 type stopInfoResult struct {
-	Stop      string               `json:"stop"`
-	SiteID    int                  `json:"site_id"`
-	DistanceM int                  `json:"distance_m,omitempty"`
-	Lines     []format.StopInfoLine `json:"lines"`
+	Stop       string                    `json:"stop"`
+	SiteID     int                       `json:"site_id"`
+	DistanceM  int                       `json:"distance_m,omitempty"`
+	Types      []string                  `json:"types,omitempty"`
+	Lines      []format.StopInfoLine     `json:"lines"`
+	Deviations []format.DeviationWarning `json:"deviations"`
 }
 
 func runStopInfo(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
-	client := api.NewClient()
+	client := newClient()
 
 	siteID := stopInfoSite
 	stopName := ""
@@ -66,7 +75,7 @@ func runStopInfo(cmd *cobra.Command, args []string) error {
 		}
 
 		if !jsonOutput {
-			fmt.Fprintf(os.Stderr, "📍 Resolved: %s (%.4f, %.4f)\n", resolvedName, lat, lon)
+			fmt.Fprintf(format.Stderr(), "📍 Resolved: %s (%.4f, %.4f)\n", resolvedName, lat, lon)
 		}
 
 		sites, err := client.GetSitesCached(ctx)
@@ -74,9 +83,9 @@ func runStopInfo(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("fetching sites: %w", err)
 		}
 
-		nearby := api.FindNearestSites(sites, lat, lon, 1.0)
+		nearby := sl.FindNearestSites(sites, lat, lon, 1.0)
 		if len(nearby) == 0 {
-			return fmt.Errorf("no stops found near %q", stopInfoAddress)
+			return sl.Errorf(sl.ErrNotFound, "no stops found near %q", stopInfoAddress)
 		}
 
 		siteID = nearby[0].Site.ID
@@ -84,7 +93,7 @@ func runStopInfo(cmd *cobra.Command, args []string) error {
 		distanceM = int(nearby[0].DistanceKm * 1000)
 
 		if !jsonOutput {
-			fmt.Fprintf(os.Stderr, "🚏 Nearest stop: %s (%dm)\n\n", stopName, distanceM)
+			fmt.Fprintf(format.Stderr(), "🚏 Nearest stop: %s (%dm)\n\n", stopName, distanceM)
 		}
 	}
 
@@ -95,7 +104,7 @@ func runStopInfo(cmd *cobra.Command, args []string) error {
 			name = strings.Join(args, " ")
 		}
 		if name == "" {
-			return fmt.Errorf("provide --site, --stop, or --address")
+			return usageErrorf("provide --site, --stop, or --address")
 		}
 
 		resolved, err := resolveSiteID(ctx, client, name)
@@ -105,51 +114,35 @@ func runStopInfo(cmd *cobra.Command, args []string) error {
 		siteID = resolved
 	}
 
+	// Site-scoped deviations (closed entrances, relocated stops) are fetched
+	// alongside the departures, and abandoned if those fail.
+	devCtx, cancelDevs := context.WithCancel(ctx)
+	defer cancelDevs()
+	var (
+		wg      sync.WaitGroup
+		devs    []sl.Deviation
+		devsErr error
+	)
+	if !stopInfoNoDevs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			devs, devsErr = client.GetDeviations(devCtx, sl.DeviationOptions{SiteIDs: []int{siteID}})
+		}()
+	}
+
 	// Fetch departures (all modes, no line filter)
-	resp, err := client.GetDepartures(ctx, api.DepartureOptions{
+	resp, err := client.GetDepartures(ctx, sl.DepartureOptions{
 		SiteID: siteID,
 	})
 	if err != nil {
 		return fmt.Errorf("fetching departures: %w", err)
 	}
+	wg.Wait()
 
-	parsed := api.ParseDepartures(resp.Departures)
-
-	// Group by line
-	type lineKey struct {
-		designation   string
-		transportMode string
-		groupOfLines  string
-	}
-	lineMap := make(map[lineKey]map[string]bool)
-	var lineOrder []lineKey
-
-	for _, d := range parsed {
-		key := lineKey{d.Line, d.TransportMode, d.GroupOfLines}
-		if _, exists := lineMap[key]; !exists {
-			lineMap[key] = make(map[string]bool)
-			lineOrder = append(lineOrder, key)
-		}
-		if d.Destination != "" {
-			lineMap[key][d.Destination] = true
-		}
-	}
+	parsed := sl.ParseDepartures(resp.Departures)
 
-	// Build result
-	lines := []format.StopInfoLine{}
-	for _, key := range lineOrder {
-		dests := lineMap[key]
-		var destList []string
-		for d := range dests {
-			destList = append(destList, d)
-		}
-		lines = append(lines, format.StopInfoLine{
-			Designation:   key.designation,
-			TransportMode: key.transportMode,
-			GroupOfLines:  key.groupOfLines,
-			Destinations:  destList,
-		})
-	}
+	lines := extractLines(parsed)
 
 	if stopName == "" {
 		stopName = fmt.Sprintf("Site %d", siteID)
@@ -158,15 +151,59 @@ func runStopInfo(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	types := stopTypes(ctx, client, siteID)
+
+	deviations := []format.DeviationWarning{}
+	if devsErr != nil {
+		client.Warn(sl.WarnPartialDeviations, "could not fetch deviations: %v", devsErr)
+	} else {
+		deviations = siteDeviationWarnings(devs)
+	}
+
 	if jsonOutput {
 		return format.JSON(stopInfoResult{
-			Stop:      stopName,
-			SiteID:    siteID,
-			DistanceM: distanceM,
-			Lines:     lines,
+			Stop:       stopName,
+			SiteID:     siteID,
+			DistanceM:  distanceM,
+			Types:      types,
+			Lines:      lines,
+			Deviations: deviations,
 		})
 	}
 
-	format.StopInfo(stopName, siteID, lines)
+	format.StopInfo(stopName, siteID, types, lines)
+	format.StopDeviationWarnings(deviations)
 	return nil
 }
+
+// siteDeviationWarnings converts site-scoped deviations into inline warnings,
+// in the selected language like matchDeviations does.
+func siteDeviationWarnings(devs []sl.Deviation) []format.DeviationWarning {
+	results := []format.DeviationWarning{}
+	for _, dev := range devs {
+		if msg, ok := sl.MessageVariantFor(dev.MessageVariants, i18n.Language()); ok {
+			results = append(results, format.DeviationWarning{
+				Header:   msg.Header,
+				Details:  truncate(msg.Details, 150),
+				Scope:    msg.ScopeAlias,
+				Severity: dev.Severity,
+				URL:      msg.Weblink,
+			})
+		}
+	}
+	return results
+}
+
+// stopTypes looks up the stop area types for a site. Failures only cost the tag.
+func stopTypes(ctx context.Context, client *sl.Client, siteID int) []string {
+	sites, err := client.GetSitesCached(ctx)
+	if err != nil {
+		return nil
+	}
+	i := slices.IndexFunc(sites, func(s sl.Site) bool { return s.ID == siteID })
+	if i < 0 || len(sites[i].StopAreas) == 0 {
+		return nil
+	}
+	areaTypes, _ := loadStopAreaTypes(ctx, client, false)
+	return sl.SiteTypes(sites[i], areaTypes)
+}
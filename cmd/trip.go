@@ -4,94 +4,307 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
+	"time"
 
-	"github.com/glundgren93/sl-cli/internal/api"
+	"github.com/glundgren93/sl-cli/internal/config"
 	"github.com/glundgren93/sl-cli/internal/format"
-	"github.com/glundgren93/sl-cli/internal/model"
+	"github.com/glundgren93/sl-cli/internal/i18n"
+	"github.com/glundgren93/sl-cli/pkg/sl"
 	"github.com/spf13/cobra"
 )
 
 var (
-	tripFrom       string
-	tripTo         string
-	tripNumTrips   int
-	tripLang       string
-	tripMaxChanges int
-	tripRouteType  string
+	tripFrom        string
+	tripTo          string
+	tripNumTrips    int
+	tripMaxChanges  int
+	tripRouteType   string
+	tripWithBike    bool
+	tripStroller    bool
+	tripWheelchair  bool
+	tripNoEscalator bool
+	tripLowFloor    bool
+	tripBuffer      time.Duration
+	tripMaxWalk     time.Duration
+	tripNoStairs    bool
+	tripPreset      string
+	tripSelect      int
+	tripFollow      bool
+	tripInterval    time.Duration
+	tripMinTransfer time.Duration
+	tripAt          string
+	tripAvoidLines  []string
+	tripPreferLines []string
 )
 
 var tripCmd = &cobra.Command{
-	Use:   "trip",
+	Use:   "trip [FROM..TO[@HH:MM]]",
 	Short: "Plan a journey between two locations",
-	Long: `Plan a trip from A to B. Accepts stop names, stop IDs, or street addresses.
+	Long: `Plan a trip from A to B. Accepts stop names, stop IDs, street addresses,
+"lat,lon" coordinates, or favorites from the config file.
+
+Instead of --from and --to, give both ends as one argument, FROM..TO,
+optionally followed by @HH:MM to leave at that time (tomorrow if it has
+already passed today).
+
+--at sets the departure time on its own: "08:15", with days
+("Mon-Fri 07:40", "Sat,Sun 10:00" — the next matching day), or "@name"
+for a named time from the config file's "times".
 
 Examples:
+  sl trip home..work@08:15
+  sl trip Slussen..59.3326,18.0649
+  sl trip home..school --at @school-run
   sl trip --from "Medborgarplatsen" --to "T-Centralen"
   sl trip --from "Magnus Ladulåsgatan 7" --to "Stureplan"
   sl trip --from "Drottninggatan 45" --to "Arlanda" --results 5
-  sl trip --from "Medborgarplatsen" --to "T-Centralen" --json`,
-	Aliases: []string{"plan", "route"},
-	RunE:    runTrip,
+  sl trip --from "Medborgarplatsen" --to "T-Centralen" --json
+  sl trip --from "Slussen" --to "Södertälje C" --with-bike
+  sl trip --from "Slussen" --to "Kista" --buffer 3m
+  sl trip --from "Slussen" --to "Kista" --preset gentle
+
+--preset applies a named set of options from the config file
+(sl-cli/config.json in your user config directory); flags given on the
+command line still win. Favorites live in the same file. For example:
+
+  {"presets": {"gentle": {"route_type": "leastwalking", "max_walk": "8m", "no_stairs": true,
+                          "avoid_lines": ["55"]}},
+   "favorites": {"home": "Magnus Ladulåsgatan 7", "work": "59.3326,18.0649"},
+   "times": {"school-run": "Mon-Fri 07:40"}}
+
+--select N keeps only the Nth itinerary. With --follow, sl then tracks it
+live: which leg you are on, when the next vehicle really leaves, and
+whether each remaining change still holds. It re-checks every --interval
+until you arrive or press Ctrl-C.
+
+  sl trip home..work --select 2 --follow
+
+Each change is rated safe, tight or risky from its slack and how reliable
+the times are (real-time or timetable, and whether the arriving vehicle is
+already late). --min-transfer drops itineraries with any change shorter
+than the given time, counted from arrival to the next departure.
+
+--avoid-line leaves out itineraries riding the given lines, e.g. a bus
+you've given up on, and --prefer-line lists those riding them first. The
+planner can't exclude single lines, so with --avoid-line sl asks it for
+twice as many alternatives and filters them, keeping --results.
+
+  sl trip Slussen..Kista --avoid-line 55 --prefer-line 17,18,19
+
+--buffer pads every walk and change by the given time, showing the
+buffered door-to-door time next to the planner's optimistic one.
+
+--with-bike and --stroller prefer routes that permit them and flag legs
+that don't (e.g. no bikes on metro or pendeltåg during weekday rush hours).
+--wheelchair does the same for step-free travel, and also warns about
+elevators SL reports out of service at the stations where you board,
+change or alight. --no-escalators and --low-floor-only narrow the routes
+further. Where the planner reports a stop's step-free access, elevator,
+escalator or platform height, it is shown under the leg (and under
+"accessibility" in --json).
+
+--format geojson writes the itineraries as a line per leg, following the
+streets and tracks, with the stops as points: for geojson.io, QGIS or a
+Leaflet map. Each feature's "journey" property numbers the alternatives.
+
+  sl trip Slussen..Kista --select 1 --format geojson > trip.geojson
+
+Identical requests within ~2 minutes are answered from a local cache;
+pass --fresh to always query the planner.`,
+	Aliases:     []string{"plan", "route"},
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: formats("text", "geojson"),
+	RunE:        runTrip,
 }
 
 func init() {
 	tripCmd.Flags().StringVar(&tripFrom, "from", "", "Origin (stop name, address, or stop ID)")
 	tripCmd.Flags().StringVar(&tripTo, "to", "", "Destination (stop name, address, or stop ID)")
 	tripCmd.Flags().IntVar(&tripNumTrips, "results", 3, "Number of trip alternatives")
-	tripCmd.Flags().StringVar(&tripLang, "lang", "en", "Language (sv or en)")
 	tripCmd.Flags().IntVar(&tripMaxChanges, "max-changes", -1, "Max number of changes (-1 = unlimited)")
 	tripCmd.Flags().StringVar(&tripRouteType, "route-type", "", "Route preference: leasttime, leastinterchange, leastwalking")
+	tripCmd.Flags().BoolVar(&tripWithBike, "with-bike", false, "Prefer routes where a bike may be taken along")
+	tripCmd.Flags().DurationVar(&tripMaxWalk, "max-walk", 0, "Longest acceptable walk per footpath (e.g. 8m)")
+	tripCmd.Flags().BoolVar(&tripNoStairs, "no-stairs", false, "Avoid routes with stairs")
+	tripCmd.Flags().StringVar(&tripAt, "at", "", `Leave at HH:MM, "tomorrow 07:40", "Mon-Fri 07:40" or a named time like @school-run`)
+	tripCmd.Flags().StringVar(&tripPreset, "preset", "", "Apply a named routing preset from the config file")
+	tripCmd.Flags().DurationVar(&tripBuffer, "buffer", 0, "Time to add at every walk and change (e.g. 3m)")
+	tripCmd.Flags().DurationVar(&tripMinTransfer, "min-transfer", 0, "Skip itineraries with a change shorter than this (e.g. 4m)")
+	tripCmd.Flags().BoolVar(&tripStroller, "stroller", false, "Prefer step-free routes suitable for a stroller")
+	tripCmd.Flags().BoolVar(&tripWheelchair, "wheelchair", false, "Plan wheelchair-accessible routes and warn about elevators out of service")
+	tripCmd.Flags().BoolVar(&tripNoEscalator, "no-escalators", false, "Avoid routes with escalators")
+	tripCmd.Flags().BoolVar(&tripLowFloor, "low-floor-only", false, "Only use low-floor vehicles")
+	tripCmd.Flags().StringSliceVar(&tripAvoidLines, "avoid-line", nil, "Leave out itineraries riding this line (repeatable, e.g. 55)")
+	tripCmd.Flags().StringSliceVar(&tripPreferLines, "prefer-line", nil, "List itineraries riding this line first (repeatable, e.g. 17)")
+	tripCmd.Flags().IntVar(&tripSelect, "select", 0, "Keep only this itinerary (1 = first)")
+	tripCmd.Flags().BoolVar(&tripFollow, "follow", false, "Track the selected itinerary live, leg by leg")
+	tripCmd.Flags().DurationVar(&tripInterval, "interval", 30*time.Second, "Refresh interval with --follow")
 
-	tripCmd.MarkFlagRequired("from")
-	tripCmd.MarkFlagRequired("to")
+	tripCmd.RegisterFlagCompletionFunc("from", completeStops)
+	tripCmd.RegisterFlagCompletionFunc("to", completeStops)
 
 	rootCmd.AddCommand(tripCmd)
 }
 
 // tripResult wraps journey results with metadata for JSON output.
 type tripResult struct {
-	From     string              `json:"from"`
-	To       string              `json:"to"`
-	Journeys []model.JourneyTrip `json:"journeys"`
+	From     string           `json:"from"`
+	To       string           `json:"to"`
+	Journeys []sl.JourneyTrip `json:"journeys"`
 }
 
 func runTrip(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
-	client := api.NewClient()
+	client := newClient()
 
-	originID, originName, err := resolveLocation(ctx, client, tripFrom)
+	from, to := tripFrom, tripTo
+	var departAt time.Time
+	if len(args) == 1 {
+		if from != "" || to != "" {
+			return usageErrorf("give either FROM..TO or --from and --to, not both")
+		}
+		spec, err := parseTripSpec(args[0], sl.StockholmTime(time.Now()))
+		if err != nil {
+			return &usageError{err}
+		}
+		from, to, departAt = spec.From, spec.To, spec.At
+	}
+	if from == "" || to == "" {
+		return usageErrorf("need an origin and a destination: sl trip FROM..TO or --from and --to")
+	}
+	if tripSelect < 0 {
+		return usageErrorf("--select must be 1 or greater")
+	}
+	selected := tripSelect
+	if tripFollow {
+		if outputFormat == "geojson" {
+			return usageErrorf("--follow can't be combined with --format geojson")
+		}
+		if selected == 0 {
+			selected = 1
+		}
+		if tripInterval < 5*time.Second {
+			return usageErrorf("--interval must be at least 5s")
+		}
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	if tripAt != "" {
+		if !departAt.IsZero() {
+			return usageErrorf("give the time either as @HH:MM or with --at, not both")
+		}
+		departAt, err = resolveAt(cfg, tripAt, sl.StockholmTime(time.Now()))
+		if err != nil {
+			return &usageError{err}
+		}
+	}
+
+	originID, originName, err := resolveTripEndpoint(ctx, client, cfg, from)
 	if err != nil {
 		return fmt.Errorf("resolving origin: %w", err)
 	}
 
-	destID, destName, err := resolveLocation(ctx, client, tripTo)
+	destID, destName, err := resolveTripEndpoint(ctx, client, cfg, to)
 	if err != nil {
 		return fmt.Errorf("resolving destination: %w", err)
 	}
 
+	if tripPreset != "" {
+		if err := applyTripPreset(cmd, cfg, tripPreset); err != nil {
+			return err
+		}
+	}
+
 	if !jsonOutput {
-		fmt.Fprintf(os.Stderr, "📍 %s → %s\n\n", originName, destName)
+		if departAt.IsZero() {
+			fmt.Fprintf(format.Stderr(), "📍 %s → %s\n\n", originName, destName)
+		} else {
+			fmt.Fprintf(format.Stderr(), "📍 %s → %s, leaving %s\n\n", originName, destName, departAt.Format("Mon 15:04"))
+		}
 	}
 
-	resp, err := client.PlanTrip(ctx, api.TripOptions{
-		OriginID:   originID,
-		DestID:     destID,
-		NumTrips:   tripNumTrips,
-		Language:   tripLang,
-		MaxChanges: tripMaxChanges,
-		RouteType:  tripRouteType,
-	})
+	opts := sl.TripOptions{
+		OriginID:     originID,
+		DestID:       destID,
+		NumTrips:     tripNumTrips,
+		Language:     i18n.Language(),
+		MaxChanges:   tripMaxChanges,
+		RouteType:    tripRouteType,
+		MaxWalk:      tripMaxWalk,
+		NoStairs:     tripNoStairs,
+		NoEscalators: tripNoEscalator,
+		LowFloorOnly: tripLowFloor,
+		Carry:        sl.Carriage{Bike: tripWithBike, Stroller: tripStroller, Wheelchair: tripWheelchair},
+		DepartAt:     departAt,
+		Coords:       outputFormat == "geojson",
+	}
+	if len(tripAvoidLines) > 0 {
+		// Ask for spares to make up for the itineraries filtered out.
+		opts.NumTrips *= 2
+	}
+
+	// Repeated lookups of the same journey within a couple of minutes are
+	// served from the trip cache unless --fresh is given.
+	planTrip := client.PlanTripCached
+	if freshData {
+		planTrip = client.PlanTrip
+	}
+
+	resp, err := planTrip(ctx, opts)
 	if err != nil {
 		return fmt.Errorf("planning trip: %w", err)
 	}
 
-	for _, msg := range resp.SystemMessages {
-		if msg.Type == "error" {
-			return fmt.Errorf("journey planner: %s", msg.Text)
+	if err := plannerError(resp); err != nil {
+		return err
+	}
+
+	if len(tripAvoidLines) > 0 {
+		planned := len(resp.Journeys)
+		resp.Journeys = sl.AvoidLines(resp.Journeys, tripAvoidLines)
+		if len(resp.Journeys) == 0 {
+			return fmt.Errorf("all %d itinerary(ies) found ride --avoid-line %s", planned, strings.Join(tripAvoidLines, ","))
+		}
+		resp.Journeys = resp.Journeys[:min(len(resp.Journeys), tripNumTrips)]
+	}
+	sl.PreferLines(resp.Journeys, tripPreferLines)
+	sl.SortByCarriage(resp.Journeys, opts.Carry)
+	sl.MarkAccessibility(resp.Journeys)
+	sl.MarkPlatforms(resp.Journeys)
+	if tripWheelchair {
+		markElevatorOutages(ctx, client, resp.Journeys)
+	}
+	sl.ApplyBuffer(resp.Journeys, tripBuffer)
+	if tripMinTransfer > 0 {
+		planned := len(resp.Journeys)
+		resp.Journeys = sl.FilterByMinTransfer(resp.Journeys, tripMinTransfer)
+		if dropped := planned - len(resp.Journeys); dropped > 0 && !jsonOutput {
+			fmt.Fprintf(format.Stderr(), "%d itinerary(ies) hidden by --min-transfer %s\n\n", dropped, tripMinTransfer)
+		}
+	}
+
+	if selected > 0 {
+		if selected > len(resp.Journeys) {
+			return fmt.Errorf("--select %d: only %d itinerary(ies) found", selected, len(resp.Journeys))
 		}
+		resp.Journeys = resp.Journeys[selected-1 : selected]
+	}
+	if tripFollow {
+		if !jsonOutput {
+			format.Trips(resp.Journeys, opts.Carry)
+		}
+		return followJourney(ctx, client, opts, resp.Journeys[0])
 	}
 
+	if outputFormat == "geojson" {
+		return format.TripsGeoJSON(os.Stdout, resp.Journeys)
+	}
 	if jsonOutput {
 		return format.JSON(tripResult{
 			From:     originName,
@@ -100,14 +313,151 @@ func runTrip(cmd *cobra.Command, args []string) error {
 		})
 	}
 
-	format.Trips(resp.Journeys)
+	format.Trips(resp.Journeys, opts.Carry)
+	return nil
+}
+
+// markElevatorOutages flags elevators reported out of service at the
+// journeys' stations. If deviations can't be fetched the trips are still
+// shown, with a warning that they weren't checked.
+func markElevatorOutages(ctx context.Context, client *sl.Client, journeys []sl.JourneyTrip) {
+	devs, err := client.GetDeviations(ctx, sl.DeviationOptions{})
+	if err != nil {
+		client.Warn(sl.WarnPartialDeviations, "could not check for elevator outages: %v", err)
+		return
+	}
+	sites, err := client.GetSitesCached(ctx)
+	if err != nil {
+		client.Warn(sl.WarnPartialDeviations, "could not check for elevator outages: %v", err)
+		return
+	}
+	sl.MarkElevatorOutages(journeys, devs, sites, i18n.Language())
+}
+
+// followJourney tracks j until arrival or Ctrl-C, re-planning every
+// --interval to pick up real-time changes. Each update is printed as a
+// status line, or as one JSON object per line with --json.
+func followJourney(ctx context.Context, client *sl.Client, opts sl.TripOptions, j sl.JourneyTrip) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	sig := sl.JourneySignature(j)
+	// Plan from just before the journey's own start so the planner keeps
+	// offering it after it has left.
+	if start := sl.JourneyStart(j); !start.IsZero() {
+		opts.DepartAt = start.Add(-time.Minute)
+	}
+
+	for {
+		st := sl.Follow(j, sl.StockholmTime(time.Now()))
+		if jsonOutput {
+			format.JSONLine(os.Stdout, st)
+		} else {
+			format.Follow(st)
+		}
+		if st.Phase == sl.PhaseArrived {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(tripInterval):
+		}
+
+		resp, err := client.PlanTrip(ctx, opts)
+		if err == nil {
+			err = plannerError(resp)
+		}
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case err != nil:
+			client.Warn(sl.WarnStaleData, "could not refresh the itinerary: %v", err)
+		default:
+			if fresh, ok := sl.FindJourney(resp.Journeys, sig); ok {
+				j = fresh
+			} else {
+				client.Warn(sl.WarnStaleData, "the planner no longer lists this itinerary; showing the last known times")
+			}
+		}
+	}
+}
+
+// applyTripPreset fills in trip flags from a config preset, leaving any flag
+// given explicitly on the command line alone.
+func applyTripPreset(cmd *cobra.Command, cfg *config.Config, name string) error {
+	p, err := cfg.Preset(name)
+	if err != nil {
+		return err
+	}
+
+	set := func(flag string) bool { return !cmd.Flags().Changed(flag) }
+	if p.RouteType != "" && set("route-type") {
+		tripRouteType = p.RouteType
+	}
+	if p.MaxChanges != nil && set("max-changes") {
+		tripMaxChanges = *p.MaxChanges
+	}
+	if p.MaxWalk > 0 && set("max-walk") {
+		tripMaxWalk = time.Duration(p.MaxWalk)
+	}
+	if p.Buffer > 0 && set("buffer") {
+		tripBuffer = time.Duration(p.Buffer)
+	}
+	if p.NoStairs && set("no-stairs") {
+		tripNoStairs = true
+	}
+	if p.WithBike && set("with-bike") {
+		tripWithBike = true
+	}
+	if p.Stroller && set("stroller") {
+		tripStroller = true
+	}
+	if p.Wheelchair && set("wheelchair") {
+		tripWheelchair = true
+	}
+	if p.NoEscalators && set("no-escalators") {
+		tripNoEscalator = true
+	}
+	if p.LowFloorOnly && set("low-floor-only") {
+		tripLowFloor = true
+	}
+	if len(p.AvoidLines) > 0 && set("avoid-line") {
+		tripAvoidLines = p.AvoidLines
+	}
+	if len(p.PreferLines) > 0 && set("prefer-line") {
+		tripPreferLines = p.PreferLines
+	}
+	return nil
+}
+
+// plannerError returns the first error the journey planner reported, if any.
+func plannerError(resp *sl.JourneyResponse) error {
+	return plannerMessagesError(resp.SystemMessages)
+}
+
+// plannerMessagesError returns the first error among a planner response's
+// system messages.
+func plannerMessagesError(msgs []sl.SystemMessage) error {
+	for _, msg := range msgs {
+		if msg.Type == "error" {
+			return fmt.Errorf("journey planner: %s", msg.Text)
+		}
+	}
 	return nil
 }
 
 // resolveLocation resolves a user input (name, address, or ID) to a journey planner location ID.
-func resolveLocation(ctx context.Context, client *api.Client, input string) (id string, name string, err error) {
+func resolveLocation(ctx context.Context, client *sl.Client, input string) (id string, name string, err error) {
 	// If it looks like a stop-finder ID (long numeric starting with 9), use directly
 	if strings.HasPrefix(input, "9") && len(input) > 8 {
+		explainResolved(resolutionExplanation{
+			Input:  input,
+			Source: sourcePlannerID,
+			Winner: input,
+			Reason: "a long number starting with 9 is taken as a stop-finder ID",
+		})
 		return input, input, nil
 	}
 
@@ -119,6 +469,7 @@ func resolveLocation(ctx context.Context, client *api.Client, input string) (id
 	}
 
 	if len(locations) > 0 {
+		explainStopFinder(input, locations)
 		loc := locations[0]
 		displayName := loc.Name
 		if loc.DisassembledName != "" && loc.DisassembledName != loc.Name {
@@ -128,5 +479,12 @@ func resolveLocation(ctx context.Context, client *api.Client, input string) (id
 	}
 
 	// Fallback: let the journey planner try to resolve it
+	client.Warn(sl.WarnGeocoderFallback, "no match for %q, passing it to the planner as-is", input)
+	explainResolved(resolutionExplanation{
+		Input:  input,
+		Source: sourceAsIs,
+		Winner: input,
+		Reason: "the stop-finder found nothing, so the planner gets the text to resolve itself",
+	})
 	return input, input, nil
 }
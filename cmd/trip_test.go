@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/glundgren93/sl-cli/internal/config"
+)
+
+func TestParseTripSpec(t *testing.T) {
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in       string
+		from, to string
+		at       time.Time
+	}{
+		{"home..work", "home", "work", time.Time{}},
+		{"home..work@09:30", "home", "work", time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)},
+		{"home..work@8:15", "home", "work", time.Date(2024, 3, 2, 8, 15, 0, 0, time.UTC)},
+		{"Slussen..59.3326,18.0649", "Slussen", "59.3326,18.0649", time.Time{}},
+		{"T-Centralen .. Kista@23:05", "T-Centralen", "Kista", time.Date(2024, 3, 1, 23, 5, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		spec, err := parseTripSpec(tt.in, now)
+		if err != nil {
+			t.Errorf("parseTripSpec(%q): %v", tt.in, err)
+			continue
+		}
+		if spec.From != tt.from || spec.To != tt.to || !spec.At.Equal(tt.at) {
+			t.Errorf("parseTripSpec(%q) = %+v, want %s..%s@%s", tt.in, spec, tt.from, tt.to, tt.at)
+		}
+	}
+
+	for _, bad := range []string{"home", "home..", "..work", "a..b..c", "home..work@25:00", "home..work@noon"} {
+		if _, err := parseTripSpec(bad, now); err == nil {
+			t.Errorf("parseTripSpec(%q) should fail", bad)
+		}
+	}
+}
+
+func TestWhenNext(t *testing.T) {
+	// Friday 1 March 2024, 09:00.
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"09:30", time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)},
+		{"08:15", time.Date(2024, 3, 2, 8, 15, 0, 0, time.UTC)},
+		{"Mon-Fri 07:40", time.Date(2024, 3, 4, 7, 40, 0, 0, time.UTC)},
+		{"Mon-Fri 09:30", time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)},
+		{"Sat,Sun 10:00", time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC)},
+		{"fri-mon 08:00", time.Date(2024, 3, 2, 8, 0, 0, 0, time.UTC)},
+		{"Wed 09:00", time.Date(2024, 3, 6, 9, 0, 0, 0, time.UTC)},
+		{"Fri 08:59", time.Date(2024, 3, 1, 8, 59, 0, 0, time.UTC)}, // within the minute's grace
+		{"Fri 08:58", time.Date(2024, 3, 8, 8, 58, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		w, err := parseWhen(tt.spec)
+		if err != nil {
+			t.Errorf("parseWhen(%q): %v", tt.spec, err)
+			continue
+		}
+		if got := w.next(now); !got.Equal(tt.want) {
+			t.Errorf("%q next = %s, want %s", tt.spec, got.Format("Mon 2006-01-02 15:04"), tt.want.Format("Mon 2006-01-02 15:04"))
+		}
+	}
+
+	for _, bad := range []string{"", "noon", "25:00", "7:5", "Funday 07:40", "Mon-Fri", "Mon Tue 07:40"} {
+		if _, err := parseWhen(bad); err == nil {
+			t.Errorf("parseWhen(%q) should fail", bad)
+		}
+	}
+}
+
+func TestResolveAt(t *testing.T) {
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	cfg := &config.Config{}
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"08:15", time.Date(2024, 3, 2, 8, 15, 0, 0, time.UTC)},
+		{"today 08:15", time.Date(2024, 3, 1, 8, 15, 0, 0, time.UTC)},
+		{"tomorrow 07:30", time.Date(2024, 3, 2, 7, 30, 0, 0, time.UTC)},
+		{"2024-03-10 22:05", time.Date(2024, 3, 10, 22, 5, 0, 0, time.UTC)},
+		{"Mon 07:40", time.Date(2024, 3, 4, 7, 40, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := resolveAt(cfg, tt.in, now)
+		if err != nil {
+			t.Errorf("resolveAt(%q): %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("resolveAt(%q) = %s, want %s", tt.in, got.Format("Mon 2006-01-02 15:04"), tt.want.Format("Mon 2006-01-02 15:04"))
+		}
+	}
+
+	for _, bad := range []string{"tomorrow", "tomorrow noon", "2024-03-10 25:00"} {
+		if _, err := resolveAt(cfg, bad, now); err == nil {
+			t.Errorf("resolveAt(%q) should fail", bad)
+		}
+	}
+}
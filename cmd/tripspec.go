@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/glundgren93/sl-cli/internal/config"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+// tripSpec is the compact positional form of sl trip:
+//
+//	FROM..TO[@HH:MM]
+//
+// where FROM and TO are favorites, stop names, addresses, stop IDs or
+// "lat,lon", and the optional time is the departure time.
+type tripSpec struct {
+	From string
+	To   string
+	At   time.Time // zero = now
+}
+
+var tripSpecTime = regexp.MustCompile(`@(\d{1,2}):(\d{2})$`)
+
+// parseTripSpec parses FROM..TO[@HH:MM]. A time that has already passed
+// today means that time tomorrow.
+func parseTripSpec(s string, now time.Time) (tripSpec, error) {
+	var spec tripSpec
+	s = strings.TrimSpace(s)
+
+	if m := tripSpecTime.FindStringSubmatch(s); m != nil {
+		hour, _ := strconv.Atoi(m[1])
+		minute, _ := strconv.Atoi(m[2])
+		if hour > 23 || minute > 59 {
+			return spec, fmt.Errorf("invalid time %q in %q", m[0][1:], s)
+		}
+		at := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+		if at.Before(now.Add(-time.Minute)) {
+			at = at.AddDate(0, 0, 1)
+		}
+		spec.At = at
+		s = strings.TrimSuffix(s, m[0])
+	} else if strings.Contains(s, "@") {
+		return spec, fmt.Errorf("invalid time in %q (use @HH:MM)", s)
+	}
+
+	from, to, ok := strings.Cut(s, "..")
+	from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+	if !ok || from == "" || to == "" || strings.Contains(to, "..") {
+		return spec, fmt.Errorf("expected FROM..TO[@HH:MM], got %q", s)
+	}
+	spec.From, spec.To = from, to
+	return spec, nil
+}
+
+// parseLatLon parses "lat,lon".
+func parseLatLon(input string) (lat, lon float64, ok bool) {
+	parts := strings.Split(input, ",")
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	lat, errLat := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	lon, errLon := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	return lat, lon, errLat == nil && errLon == nil
+}
+
+// resolveTripEndpoint resolves a trip origin or destination, expanding
+// favorites from the config file and accepting "lat,lon" before falling
+// back to resolveLocation.
+func resolveTripEndpoint(ctx context.Context, client *sl.Client, cfg *config.Config, input string) (id, name string, err error) {
+	if fav, ok := cfg.Favorite(input); ok {
+		explainResolved(resolutionExplanation{
+			Input:  input,
+			Source: sourceBookmark,
+			Winner: fav,
+			Reason: "a favorite in the config file; resolving its value",
+		})
+		input = fav
+	}
+	if lat, lon, ok := parseLatLon(input); ok {
+		explainResolved(resolutionExplanation{Input: input, Source: sourceCoordinates, Winner: input, Reason: `read as "lat,lon"`})
+		return sl.CoordLocation(lat, lon), fmt.Sprintf("%.5f, %.5f", lat, lon), nil
+	}
+	return resolveLocation(ctx, client, input)
+}
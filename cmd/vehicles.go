@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/internal/keys"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+	"github.com/glundgren93/sl-cli/pkg/sl/gtfsrt"
+	"github.com/spf13/cobra"
+)
+
+var (
+	vehiclesNear   string
+	vehiclesLat    float64
+	vehiclesLon    float64
+	vehiclesRadius float64
+	vehiclesLine   string
+	vehiclesMode   string
+)
+
+var vehiclesCmd = &cobra.Command{
+	Use:   "vehicles",
+	Short: "Show live vehicle positions near a location or on a line",
+	Long: `Show buses, trams and trains currently heading toward stops near you,
+with an ETA computed from their live GPS position, heading and speed.
+Useful when a stop's display is broken.
+
+With --line, list every vehicle running the line instead: the stop it is
+at or heading for and its delay. --json includes each vehicle's position
+for mapping. Matching vehicles to lines takes the GTFS Regional static
+timetable too: sl keys set trafiklab-static <key>.
+
+Needs a Trafiklab realtime key (GTFS Regional): sl keys set trafiklab-realtime <key>
+
+Examples:
+  sl vehicles --near "Medborgarplatsen"
+  sl vehicles --near "59.3143,18.0735" --radius 0.3
+  sl vehicles --lat 59.3143 --lon 18.0735 --json
+  sl vehicles --line 55
+  sl vehicles --line 17 --mode METRO --json`,
+	RunE: runVehicles,
+}
+
+func init() {
+	vehiclesCmd.Flags().StringVar(&vehiclesNear, "near", "", `Where you are: an address, place name or "lat,lon"`)
+	vehiclesCmd.Flags().Float64Var(&vehiclesLat, "lat", 0, "Latitude (WGS84)")
+	vehiclesCmd.Flags().Float64Var(&vehiclesLon, "lon", 0, "Longitude (WGS84)")
+	vehiclesCmd.Flags().Float64VarP(&vehiclesRadius, "radius", "r", 0.5, "Stops within this radius in km")
+	vehiclesCmd.Flags().StringVar(&vehiclesLine, "line", "", "List the vehicles running this line instead (e.g. 55)")
+	vehiclesCmd.Flags().StringVar(&vehiclesMode, "mode", "", "With --line, the transport mode when buses and trains share the number: BUS, METRO, TRAIN, TRAM, SHIP")
+
+	vehiclesCmd.RegisterFlagCompletionFunc("near", completeStops)
+
+	rootCmd.AddCommand(vehiclesCmd)
+}
+
+// vehiclesResult is the JSON output for vehicles.
+type vehiclesResult struct {
+	Lat      float64       `json:"lat"`
+	Lon      float64       `json:"lon"`
+	Stops    int           `json:"stops"`
+	Vehicles []sl.Approach `json:"vehicles"`
+}
+
+func runVehicles(cmd *cobra.Command, args []string) error {
+	key, err := apiKey(keys.TrafiklabRealtime)
+	if err != nil {
+		return err
+	}
+	if vehiclesLine != "" {
+		if vehiclesNear != "" || vehiclesLat != 0 || vehiclesLon != 0 || len(args) > 0 {
+			return usageErrorf("give either --line or a location, not both")
+		}
+		return runLineVehicles(key)
+	}
+
+	ctx := context.Background()
+	client := newClient()
+
+	lat, lon := vehiclesLat, vehiclesLon
+	if lat == 0 && lon == 0 {
+		near := vehiclesNear
+		if near == "" && len(args) > 0 {
+			near = strings.Join(args, " ")
+		}
+		if near == "" {
+			return usageErrorf("provide --near or --lat/--lon")
+		}
+		lat, lon, err = resolvePoint(ctx, client, near)
+		if err != nil {
+			return err
+		}
+	}
+
+	sites, err := client.GetSitesCached(ctx)
+	if err != nil {
+		return fmt.Errorf("fetching sites: %w", err)
+	}
+	stops := sl.FindNearestSites(sites, lat, lon, vehiclesRadius)
+	if len(stops) == 0 {
+		return sl.Errorf(sl.ErrNotFound, "no stops within %.1f km", vehiclesRadius)
+	}
+
+	feed, err := client.GetVehiclePositions(ctx, key)
+	if err != nil {
+		return fmt.Errorf("fetching vehicle positions: %w", err)
+	}
+	approaches := sl.ApproachingVehicles(feed.Vehicles, stops)
+
+	if jsonOutput {
+		return format.JSON(vehiclesResult{Lat: lat, Lon: lon, Stops: len(stops), Vehicles: approaches})
+	}
+	format.Vehicles(approaches)
+	return nil
+}
+
+// lineVehiclesResult is the JSON output for vehicles --line.
+type lineVehiclesResult struct {
+	Line      string           `json:"line"`
+	Timestamp time.Time        `json:"timestamp,omitzero"`
+	Vehicles  []sl.LineVehicle `json:"vehicles"`
+}
+
+// runLineVehicles lists the vehicles running --line. The static timetable
+// says which trips belong to the line; delays are left out if the trip
+// updates can't be fetched.
+func runLineVehicles(key string) error {
+	staticKey, err := apiKey(keys.TrafiklabStatic)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	client := newClient()
+
+	feed, err := client.GetVehiclePositions(ctx, key)
+	if err != nil {
+		return fmt.Errorf("fetching vehicle positions: %w", err)
+	}
+	var updates []gtfsrt.TripUpdate
+	if tu, err := client.GetTripUpdates(ctx, key); err != nil {
+		client.Warn(sl.WarnPartialResponse, "delays unavailable: %v", err)
+	} else {
+		updates = tu.TripUpdates
+	}
+
+	tt, err := loadTimetable(ctx, client, staticKey)
+	if err != nil {
+		return err
+	}
+
+	vehicles := sl.LineVehicles(tt, feed.Vehicles, updates, vehiclesLine, vehiclesMode)
+	if jsonOutput {
+		return format.JSON(lineVehiclesResult{Line: vehiclesLine, Timestamp: feed.Timestamp, Vehicles: vehicles})
+	}
+	format.LineVehicles(vehiclesLine, vehicles)
+	return nil
+}
+
+// resolvePoint turns "lat,lon" or an address into coordinates.
+func resolvePoint(ctx context.Context, client *sl.Client, input string) (lat, lon float64, err error) {
+	if lat, lon, ok := parseLatLon(input); ok {
+		explainResolved(resolutionExplanation{Input: input, Source: sourceCoordinates, Winner: input, Reason: `read as "lat,lon"`})
+		return lat, lon, nil
+	}
+	lat, lon, name, err := geocodeAddress(ctx, client, input)
+	if err != nil {
+		return 0, 0, fmt.Errorf("geocoding address: %w", err)
+	}
+	if !jsonOutput {
+		fmt.Fprintf(format.Stderr(), "📍 Resolved: %s (%.4f, %.4f)\n\n", name, lat, lon)
+	}
+	return lat, lon, nil
+}
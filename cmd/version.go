@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/glundgren93/sl-cli/internal/format"
 	"github.com/spf13/cobra"
 )
 
@@ -11,11 +12,29 @@ var (
 	Commit  = "dev"
 )
 
+// versionInfo is the JSON form of sl version, telling agents which JSON
+// schema versions this build can write.
+type versionInfo struct {
+	Version              string `json:"version"`
+	Commit               string `json:"commit"`
+	CurrentSchemaVersion int    `json:"current_schema_version"`
+	OldestSchemaVersion  int    `json:"oldest_schema_version"`
+}
+
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Print version information",
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Printf("sl-cli %s (%s)\n", Version, Commit)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if jsonOutput {
+			return format.JSON(versionInfo{
+				Version:              Version,
+				Commit:               Commit,
+				CurrentSchemaVersion: format.SchemaVersion,
+				OldestSchemaVersion:  format.OldestSchemaVersion,
+			})
+		}
+		fmt.Printf("sl-cli %s (%s), JSON schema version %d\n", Version, Commit, format.SchemaVersion)
+		return nil
 	},
 }
 
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchdogInterval time.Duration
+	watchdogCount    int
+	watchdogNotify   bool
+	watchdogReport   bool
+	watchdogSince    time.Duration
+)
+
+var watchdogCmd = &cobra.Command{
+	Use:   "watchdog",
+	Short: "Monitor SL API availability",
+	Long: `Probe the transport, deviations and journey planner APIs on an
+interval and record status and latency in the local cache dir. When an API
+goes down or recovers, a line is printed to stderr and, with --notify, a
+desktop notification is shown.
+
+With --report, summarise the recorded probes instead: uptime and median/p95
+latency per API over --since.
+
+With --json, each probe result is printed as one JSON line.
+
+Examples:
+  sl watchdog --interval 30s --notify
+  sl watchdog --report --since 168h`,
+	Args: cobra.NoArgs,
+	RunE: runWatchdog,
+}
+
+func init() {
+	watchdogCmd.Flags().DurationVar(&watchdogInterval, "interval", time.Minute, "Time between probe rounds")
+	watchdogCmd.Flags().IntVar(&watchdogCount, "count", 0, "Stop after this many rounds (0 = run until interrupted)")
+	watchdogCmd.Flags().BoolVar(&watchdogNotify, "notify", false, "Show a desktop notification when an API goes down or recovers")
+	watchdogCmd.Flags().BoolVar(&watchdogReport, "report", false, "Summarise recorded probes instead of probing")
+	watchdogCmd.Flags().DurationVar(&watchdogSince, "since", 24*time.Hour, "Window for --report")
+	rootCmd.AddCommand(watchdogCmd)
+}
+
+func runWatchdog(cmd *cobra.Command, args []string) error {
+	if watchdogReport {
+		return watchdogSummary()
+	}
+	if watchdogInterval < time.Second {
+		return usageErrorf("--interval must be at least 1s")
+	}
+	if watchdogNotify {
+		// Fail up front rather than at the first outage.
+		if _, err := notifyCommand("", ""); err != nil {
+			return err
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// One request per probe, so latency and outages are measured as seen.
+	client := newClient()
+	client.SetRetries(0)
+	last := map[string]string{}
+	for round := 1; ; round++ {
+		var results []sl.ProbeResult
+		for _, target := range client.ProbeTargets() {
+			results = append(results, client.Probe(ctx, target))
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err := client.RecordProbes(results); err != nil {
+			fmt.Fprintf(format.Stderr(), "⚠️  could not record probes: %v\n", err)
+		}
+
+		if jsonOutput {
+			for _, r := range results {
+				format.JSONLine(os.Stdout, r)
+			}
+		} else {
+			format.ProbeRound(results)
+		}
+		for _, r := range results {
+			announceTransition(last[r.Endpoint], r)
+			last[r.Endpoint] = r.Status
+		}
+
+		if watchdogCount > 0 && round >= watchdogCount {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(watchdogInterval):
+		}
+	}
+}
+
+// announceTransition reports an endpoint going down or coming back. The
+// first probe only counts as a transition when the endpoint is failing.
+func announceTransition(prev string, r sl.ProbeResult) {
+	if prev == r.Status || (prev == "" && r.Status == sl.ProbeUp) {
+		return
+	}
+	var msg string
+	switch {
+	case r.Status == sl.ProbeUp:
+		msg = fmt.Sprintf("SL %s API recovered", r.Endpoint)
+	case r.Status == sl.ProbeDown:
+		msg = fmt.Sprintf("SL %s API is down", r.Endpoint)
+	case prev == sl.ProbeDown:
+		// down → error is still an outage; not worth a second alert.
+		return
+	default:
+		msg = fmt.Sprintf("SL %s API is failing", r.Endpoint)
+	}
+	if !jsonOutput {
+		if r.Status == sl.ProbeUp {
+			fmt.Fprintf(format.Stderr(), "✓ %s\n", msg)
+		} else {
+			fmt.Fprintf(format.Stderr(), "⚠️  %s: %s\n", msg, r.Error)
+		}
+	}
+	if watchdogNotify {
+		if err := sendNotification("sl watchdog", msg); err != nil {
+			fmt.Fprintf(format.Stderr(), "⚠️  notification failed: %v\n", err)
+		}
+	}
+}
+
+func watchdogSummary() error {
+	results, err := newClient().LoadProbes(time.Now().Add(-watchdogSince))
+	if err != nil {
+		return fmt.Errorf("reading probe log: %w", err)
+	}
+	stats := sl.SummarizeProbes(results)
+	if jsonOutput {
+		if stats == nil {
+			stats = []sl.UptimeStats{}
+		}
+		return format.JSON(stats)
+	}
+	format.UptimeReport(stats, watchdogSince)
+	return nil
+}
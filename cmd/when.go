@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/glundgren93/sl-cli/internal/config"
+)
+
+// weekdayNames maps the day abbreviations accepted in time specs.
+var weekdayNames = map[string]time.Weekday{
+	"mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday, "sun": time.Sunday,
+}
+
+// when is a recurring clock time, optionally limited to some weekdays.
+type when struct {
+	days         [7]bool // indexed by time.Weekday; all false = every day
+	hour, minute int
+}
+
+// parseWhen parses "[DAYS ]HH:MM", where DAYS is a day ("Sat"), a range
+// ("Mon-Fri") or a comma-separated list of either ("Mon,Wed-Thu").
+func parseWhen(s string) (when, error) {
+	var w when
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields) > 2 {
+		return w, fmt.Errorf("invalid time %q (use HH:MM or e.g. \"Mon-Fri 07:40\")", s)
+	}
+	clock := fields[len(fields)-1]
+	h, m, ok := strings.Cut(clock, ":")
+	hour, errH := strconv.Atoi(h)
+	minute, errM := strconv.Atoi(m)
+	if !ok || errH != nil || errM != nil || hour < 0 || hour > 23 || minute < 0 || minute > 59 || len(m) != 2 {
+		return w, fmt.Errorf("invalid time %q in %q (use HH:MM)", clock, s)
+	}
+	w.hour, w.minute = hour, minute
+
+	if len(fields) == 2 {
+		for _, part := range strings.Split(strings.ToLower(fields[0]), ",") {
+			first, last, isRange := strings.Cut(part, "-")
+			if !isRange {
+				last = first
+			}
+			from, ok1 := weekdayNames[first]
+			to, ok2 := weekdayNames[last]
+			if !ok1 || !ok2 {
+				return w, fmt.Errorf("invalid days %q in %q (use e.g. Mon-Fri or Sat,Sun)", fields[0], s)
+			}
+			for d := from; ; d = (d + 1) % 7 {
+				w.days[d] = true
+				if d == to {
+					break
+				}
+			}
+		}
+	}
+	return w, nil
+}
+
+// next returns the next time w happens, allowing a minute's grace so
+// "07:40" asked at 07:40:30 still means today.
+func (w when) next(now time.Time) time.Time {
+	anyDay := w.days == [7]bool{}
+	t := time.Date(now.Year(), now.Month(), now.Day(), w.hour, w.minute, 0, 0, now.Location())
+	for t.Before(now.Add(-time.Minute)) || !(anyDay || w.days[t.Weekday()]) {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t
+}
+
+// resolveAt parses a one-off --at time: a time spec or @name as for
+// resolveWhen, or a clock time after "today", "tomorrow" or a date
+// (YYYY-MM-DD), and returns when it next happens after now.
+func resolveAt(cfg *config.Config, s string, now time.Time) (time.Time, error) {
+	if day, clock, ok := strings.Cut(strings.TrimSpace(s), " "); ok {
+		var date time.Time
+		switch strings.ToLower(day) {
+		case "today":
+			date = now
+		case "tomorrow":
+			date = now.AddDate(0, 0, 1)
+		default:
+			date, _ = time.ParseInLocation("2006-01-02", day, now.Location())
+		}
+		if !date.IsZero() {
+			w, err := parseWhen(strings.TrimSpace(clock))
+			if err != nil {
+				return time.Time{}, err
+			}
+			return time.Date(date.Year(), date.Month(), date.Day(), w.hour, w.minute, 0, 0, now.Location()), nil
+		}
+	}
+	w, err := resolveWhen(cfg, s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return w.next(now), nil
+}
+
+// resolveWhen parses a --at value: a time spec, or "@name" for a named time
+// from the config file.
+func resolveWhen(cfg *config.Config, s string) (when, error) {
+	if name, ok := strings.CutPrefix(s, "@"); ok {
+		spec, err := cfg.Time(name)
+		if err != nil {
+			return when{}, err
+		}
+		s = spec
+	}
+	return parseWhen(s)
+}
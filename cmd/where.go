@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+	"github.com/spf13/cobra"
+)
+
+var (
+	whereSite      int
+	whereStop      string
+	whereDirection int
+	whereRadius    float64
+)
+
+var whereCmd = &cobra.Command{
+	Use:   "where <line> [HH:MM|journey-id]",
+	Short: "Show where the runs of a line are around a stop",
+	Long: `List the runs of a line around a stop, with when each is due at the stop
+(or that it has already left) and the next stop it reaches. Handy for
+telling whether the bus you just missed was the 14:02 or the delayed 13:54.
+
+Positions are worked out from the departure boards of the stop and the
+stops around it: a run drops off a stop's board once it has left, so the
+stop where it is expected soonest is where it is heading.
+
+Give a scheduled time at the stop or a journey ID to show just that run.
+
+Examples:
+  sl where 4 --stop Medborgarplatsen
+  sl where 17 --site 9191 --direction 2
+  sl where 4 14:02 --stop Medborgarplatsen
+  sl where 4 --stop home --radius 2 --json`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runWhere,
+}
+
+func init() {
+	whereCmd.Flags().IntVar(&whereSite, "site", 0, "Site ID of the stop you're at")
+	whereCmd.Flags().StringVar(&whereStop, "stop", "", "Stop you're at (name or bookmark)")
+	whereCmd.Flags().IntVar(&whereDirection, "direction", 0, "Direction code (1 or 2); both when omitted")
+	whereCmd.Flags().Float64Var(&whereRadius, "radius", 1.5, "Scan stops within this radius in km")
+
+	whereCmd.RegisterFlagCompletionFunc("stop", completeStops)
+
+	rootCmd.AddCommand(whereCmd)
+}
+
+// whereResult is the JSON output for where.
+type whereResult struct {
+	Line   string               `json:"line"`
+	Stop   string               `json:"stop"`
+	SiteID int                  `json:"site_id"`
+	Runs   []sl.JourneyPosition `json:"runs"`
+}
+
+func runWhere(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	client := newClient()
+	line := args[0]
+
+	siteID := whereSite
+	if siteID == 0 {
+		if whereStop == "" {
+			return usageErrorf("provide --stop or --site")
+		}
+		var err error
+		if siteID, err = resolveSiteID(ctx, client, whereStop); err != nil {
+			return err
+		}
+	}
+
+	sites, err := client.GetSitesCached(ctx)
+	if err != nil {
+		return fmt.Errorf("fetching sites: %w", err)
+	}
+	emitProgress(progressEvent{Event: "sites-loaded", Count: len(sites)})
+
+	stops := []sl.SiteWithDistance{}
+	for _, s := range sites {
+		if s.ID == siteID {
+			stops = append(stops, sl.SiteWithDistance{Site: s})
+			for _, n := range sl.FindNearestSites(sites, s.Lat, s.Lon, whereRadius) {
+				if n.Site.ID != siteID {
+					stops = append(stops, n)
+				}
+			}
+			break
+		}
+	}
+	if len(stops) == 0 {
+		return sl.Errorf(sl.ErrNotFound, "no stop with site ID %d", siteID)
+	}
+
+	boards, err := lineBoards(ctx, client, stops, line)
+	if err != nil {
+		return err
+	}
+	runs := sl.LocateJourneys(boards, line, whereDirection)
+	if len(args) > 1 {
+		if runs, err = selectRuns(runs, args[1]); err != nil {
+			return err
+		}
+	}
+
+	if jsonOutput {
+		return format.JSON(whereResult{Line: line, Stop: boards[0].Name, SiteID: siteID, Runs: runs})
+	}
+	format.JourneyPositions(line, boards[0].Name, siteID, runs, time.Now())
+	return nil
+}
+
+// lineBoards fetches the departures of line at each stop concurrently. The
+// reference stop, stops[0], must answer; the others are best effort.
+func lineBoards(ctx context.Context, client *sl.Client, stops []sl.SiteWithDistance, line string) ([]sl.StopBoard, error) {
+	boards := make([]sl.StopBoard, len(stops))
+	errs := make([]error, len(stops))
+	sem := make(chan struct{}, maxConcurrentScans)
+	var wg sync.WaitGroup
+
+	for i, stop := range stops {
+		boards[i] = sl.StopBoard{SiteID: stop.Site.ID, Name: stop.Site.Name}
+		wg.Add(1)
+		go func(i int, stop sl.SiteWithDistance) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			emitProgress(progressEvent{Event: "scanning", Current: i + 1, Total: len(stops), Stop: stop.Site.Name, SiteID: stop.Site.ID})
+			resp, err := client.GetDepartures(ctx, sl.DepartureOptions{SiteID: stop.Site.ID, Line: line})
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			boards[i].Departures = resp.Departures
+		}(i, stop)
+	}
+
+	wg.Wait()
+	if errs[0] != nil {
+		return nil, fmt.Errorf("fetching departures: %w", errs[0])
+	}
+	return boards, nil
+}
+
+// selectRuns keeps the run with the given journey ID, or those scheduled at
+// the reference stop at the given HH:MM.
+func selectRuns(runs []sl.JourneyPosition, sel string) ([]sl.JourneyPosition, error) {
+	id, idErr := strconv.ParseInt(sel, 10, 64)
+	at, timeErr := time.Parse("15:04", sel)
+	if idErr != nil && timeErr != nil {
+		return nil, fmt.Errorf("invalid run %q (want HH:MM or a journey ID)", sel)
+	}
+
+	var kept []sl.JourneyPosition
+	for _, r := range runs {
+		switch {
+		case idErr == nil && r.JourneyID == id:
+			kept = append(kept, r)
+		case timeErr == nil && !r.Scheduled.IsZero() && r.Scheduled.Format("15:04") == at.Format("15:04"):
+			kept = append(kept, r)
+		}
+	}
+	if len(kept) == 0 {
+		return nil, sl.Errorf(sl.ErrNotFound, "no run %s found", sel)
+	}
+	return kept, nil
+}
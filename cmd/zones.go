@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/glundgren93/sl-cli/internal/format"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+	"github.com/spf13/cobra"
+)
+
+var (
+	zonesSite int
+	zonesStop string
+)
+
+var zonesCmd = &cobra.Command{
+	Use:   "zones",
+	Short: "List fare zones and show which zone a stop is in",
+	Long: `List SL's fare zones, or show the zone(s) a stop belongs to.
+
+SL is a single zone within Stockholm County. Pendeltåg stations across the
+county border (Knivsta, Uppsala C, Bålsta, Gnesta) belong to neighbouring
+zones and may need a supplement on top of an SL ticket.
+
+Examples:
+  sl zones
+  sl zones --stop "Bålsta"
+  sl zones --site 9001 --json`,
+	RunE: runZones,
+}
+
+func init() {
+	zonesCmd.Flags().IntVar(&zonesSite, "site", 0, "Site ID")
+	zonesCmd.Flags().StringVar(&zonesStop, "stop", "", "Stop name (fuzzy search)")
+	zonesCmd.RegisterFlagCompletionFunc("stop", completeStops)
+
+	rootCmd.AddCommand(zonesCmd)
+}
+
+// stopZonesResult is the JSON output for zones --stop/--site.
+type stopZonesResult struct {
+	Stop   string `json:"stop"`
+	SiteID int    `json:"site_id"`
+	sl.StopZones
+}
+
+func runZones(cmd *cobra.Command, args []string) error {
+	name := zonesStop
+	if name == "" && len(args) > 0 {
+		name = strings.Join(args, " ")
+	}
+
+	if zonesSite == 0 && name == "" {
+		if jsonOutput {
+			return format.JSON(sl.FareZones)
+		}
+		format.Zones(sl.FareZones)
+		return nil
+	}
+
+	ctx := context.Background()
+	client := newClient()
+
+	siteID := zonesSite
+	if siteID == 0 {
+		resolved, err := resolveSiteID(ctx, client, name)
+		if err != nil {
+			return err
+		}
+		siteID = resolved
+	}
+
+	sites, err := client.GetSitesCached(ctx)
+	if err != nil {
+		return fmt.Errorf("fetching sites: %w", err)
+	}
+	for _, s := range sites {
+		if s.ID != siteID {
+			continue
+		}
+		result := stopZonesResult{Stop: s.Name, SiteID: s.ID, StopZones: sl.SiteZones(s)}
+		if jsonOutput {
+			return format.JSON(result)
+		}
+		format.StopZones(result.Stop, result.SiteID, result.StopZones)
+		return nil
+	}
+	return sl.Errorf(sl.ErrNotFound, "no stop found with site ID %d", siteID)
+}
@@ -0,0 +1,64 @@
+// Package apitest provides a fake of the SL Transport, Deviations and
+// Journey Planner APIs and the feeds around them, seeded from the
+// sandbox's recorded fixtures, so commands can be exercised end to end
+// without network access.
+package apitest
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/glundgren93/sl-cli/internal/sandbox"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+// Fake is an in-memory SL API. Fields may be modified between requests.
+type Fake struct {
+	*sandbox.Server
+}
+
+// New starts a fake seeded from the bundled fixtures and sends the
+// requests of every sl client to it for the duration of the test. It also
+// isolates the on-disk cache and clears in-memory caches.
+func New(t testing.TB) *Fake {
+	t.Helper()
+	s, err := sandbox.New()
+	if err != nil {
+		t.Fatalf("loading fixtures: %v", err)
+	}
+	f := &Fake{Server: s}
+	f.Start(t)
+	return f
+}
+
+// Start answers requests to sl's default base URLs from the fake until
+// the test ends, by swapping http.DefaultTransport, which sl clients use
+// unless given another. Other requests, such as to a test's own
+// httptest server, go through as before.
+func (f *Fake) Start(t testing.TB) {
+	t.Helper()
+
+	prev := http.DefaultTransport
+	http.DefaultTransport = transport{fake: f.Server.Transport(), next: prev}
+	sl.ResetCaches()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	t.Cleanup(func() {
+		http.DefaultTransport = prev
+		sl.ResetCaches()
+	})
+}
+
+// transport sends what the fake stands in for to it and the rest on.
+type transport struct {
+	fake, next http.RoundTripper
+}
+
+func (t transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.fake.RoundTrip(req)
+	if errors.Is(err, sandbox.ErrOffline) {
+		return t.next.RoundTrip(req)
+	}
+	return resp, err
+}
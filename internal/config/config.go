@@ -0,0 +1,233 @@
+// Package config reads the user's optional sl-cli configuration file.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Duration is a time.Duration written as a string like "8m" in the file.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"8m\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Preset is a named set of trip planning options. Unset fields leave the
+// corresponding flag at its default.
+type Preset struct {
+	RouteType    string   `json:"route_type,omitempty"`
+	MaxChanges   *int     `json:"max_changes,omitempty"`
+	MaxWalk      Duration `json:"max_walk,omitempty"`
+	NoStairs     bool     `json:"no_stairs,omitempty"`
+	NoEscalators bool     `json:"no_escalators,omitempty"`
+	LowFloorOnly bool     `json:"low_floor_only,omitempty"`
+	WithBike     bool     `json:"with_bike,omitempty"`
+	Stroller     bool     `json:"stroller,omitempty"`
+	Wheelchair   bool     `json:"wheelchair,omitempty"`
+	Buffer       Duration `json:"buffer,omitempty"`
+	AvoidLines   []string `json:"avoid_lines,omitempty"`
+	PreferLines  []string `json:"prefer_lines,omitempty"`
+}
+
+// Board styles the HTML departure board.
+type Board struct {
+	// Theme is dark, day, night or auto; auto is day between Day and
+	// Night and night otherwise.
+	Theme      string `json:"theme,omitempty"`
+	LineColors bool   `json:"line_colors,omitempty"`
+	// Day and Night are "HH:MM" (default 07:00 and 22:00).
+	Day   string `json:"day,omitempty"`
+	Night string `json:"night,omitempty"`
+	// NightBrightness dims the board between Night and Day, whatever the
+	// theme: 0.3 is a third of full brightness. Unset means no dimming.
+	NightBrightness float64 `json:"night_brightness,omitempty"`
+}
+
+// Commute is a saved journey for sl commute: where you board, where
+// you're going, the lines you'd rather take and your walk to the stop.
+type Commute struct {
+	// From is the stop you leave from and To where you're going: a stop
+	// name, site ID, favorite or, for To, an address or "lat,lon".
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Lines are the preferred lines; departures show only these when set.
+	Lines []string `json:"lines,omitempty"`
+	// Towards keeps departures heading to this destination, when the
+	// lines run both ways past From.
+	Towards string   `json:"towards,omitempty"`
+	Walk    Duration `json:"walk,omitempty"`
+}
+
+// Config is the contents of config.json.
+type Config struct {
+	Presets map[string]Preset `json:"presets,omitempty"`
+	// Favorites maps short names like "home" to a stop name, address,
+	// stop ID or "lat,lon".
+	Favorites map[string]string `json:"favorites,omitempty"`
+	// CacheTTL is how long sites, stop points and lines are served from the
+	// disk cache before asking the API again (default 24h).
+	CacheTTL Duration `json:"cache_ttl,omitempty"`
+	// Retries is how many times a request failing with 429, a 5xx status
+	// or a network error is retried (default 2; 0 disables retries).
+	Retries *int `json:"retries,omitempty"`
+	// Times maps names like "school-run" to a recurring time such as
+	// "Mon-Fri 07:40", used as --at @school-run.
+	Times map[string]string `json:"times,omitempty"`
+	// Board styles the HTML departure board (departures --format html).
+	Board Board `json:"board,omitzero"`
+	// Hyperlinks turns clickable stop names and deviation headers on or
+	// off; unset means on in terminals known to support them.
+	Hyperlinks *bool `json:"hyperlinks,omitempty"`
+	// Commutes maps names like "morning" to saved journeys for sl commute.
+	Commutes map[string]Commute `json:"commutes,omitempty"`
+}
+
+// userConfigDir is swapped out in tests.
+var userConfigDir = os.UserConfigDir
+
+// Path returns the config file location under the user's config dir.
+func Path() (string, error) {
+	base, err := userConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "sl-cli", "config.json"), nil
+}
+
+// fixed is set by Use.
+var fixed *Config
+
+// Use makes Load return a copy of cfg instead of reading the file, for
+// runs that mustn't depend on the user's settings. Use(nil) goes back to
+// the file.
+func Use(cfg *Config) {
+	fixed = cfg
+}
+
+// Load reads the config file. A missing file is an empty config.
+func Load() (*Config, error) {
+	if fixed != nil {
+		cfg := *fixed
+		return &cfg, nil
+	}
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Preset looks up a preset by name.
+func (c *Config) Preset(name string) (Preset, error) {
+	if p, ok := c.Presets[name]; ok {
+		return p, nil
+	}
+	if len(c.Presets) == 0 {
+		path, _ := Path()
+		return Preset{}, fmt.Errorf("unknown preset %q: no presets defined in %s", name, path)
+	}
+	names := make([]string, 0, len(c.Presets))
+	for n := range c.Presets {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return Preset{}, fmt.Errorf("unknown preset %q (defined: %s)", name, strings.Join(names, ", "))
+}
+
+// Time looks up a named time by name.
+func (c *Config) Time(name string) (string, error) {
+	if t, ok := c.Times[name]; ok {
+		return t, nil
+	}
+	if len(c.Times) == 0 {
+		path, _ := Path()
+		return "", fmt.Errorf("unknown time %q: no times defined in %s", name, path)
+	}
+	names := make([]string, 0, len(c.Times))
+	for n := range c.Times {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return "", fmt.Errorf("unknown time %q (defined: %s)", name, strings.Join(names, ", "))
+}
+
+// Commute looks up a saved commute by name.
+func (c *Config) Commute(name string) (Commute, error) {
+	if cm, ok := c.Commutes[name]; ok {
+		return cm, nil
+	}
+	if len(c.Commutes) == 0 {
+		path, _ := Path()
+		return Commute{}, fmt.Errorf("unknown commute %q: no commutes defined in %s", name, path)
+	}
+	names := make([]string, 0, len(c.Commutes))
+	for n := range c.Commutes {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return Commute{}, fmt.Errorf("unknown commute %q (defined: %s)", name, strings.Join(names, ", "))
+}
+
+// Favorite looks up a favorite by name, ignoring case.
+func (c *Config) Favorite(name string) (string, bool) {
+	if v, ok := c.Favorites[name]; ok {
+		return v, true
+	}
+	for k, v := range c.Favorites {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// Save writes the config file, replacing it atomically.
+func Save(cfg *Config) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadPreset(t *testing.T) {
+	dir := t.TempDir()
+	userConfigDir = func() (string, error) { return dir, nil }
+	defer func() { userConfigDir = os.UserConfigDir }()
+
+	cfg, err := Load()
+	if err != nil || len(cfg.Presets) != 0 {
+		t.Fatalf("missing file should load empty, got %+v, %v", cfg, err)
+	}
+
+	os.MkdirAll(filepath.Join(dir, "sl-cli"), 0o755)
+	data := `{"presets": {"gentle": {"route_type": "leastwalking", "max_walk": "8m", "no_stairs": true, "max_changes": 1}}}`
+	if err := os.WriteFile(filepath.Join(dir, "sl-cli", "config.json"), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err = Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := cfg.Preset("gentle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.RouteType != "leastwalking" || time.Duration(p.MaxWalk) != 8*time.Minute || !p.NoStairs || p.MaxChanges == nil || *p.MaxChanges != 1 {
+		t.Errorf("preset = %+v", p)
+	}
+	if _, err := cfg.Preset("fast"); err == nil {
+		t.Error("expected error for undefined preset")
+	}
+}
+
+func TestFavorite(t *testing.T) {
+	cfg := &Config{Favorites: map[string]string{"home": "Magnus Ladulåsgatan 7", "Work": "59.3326,18.0649"}}
+	if v, ok := cfg.Favorite("home"); !ok || v != "Magnus Ladulåsgatan 7" {
+		t.Errorf("home = %q, %v", v, ok)
+	}
+	if v, ok := cfg.Favorite("work"); !ok || v != "59.3326,18.0649" {
+		t.Errorf("work should match case-insensitively, got %q, %v", v, ok)
+	}
+	if _, ok := cfg.Favorite("gym"); ok {
+		t.Error("gym is not a favorite")
+	}
+}
+
+func TestSave(t *testing.T) {
+	dir := t.TempDir()
+	userConfigDir = func() (string, error) { return dir, nil }
+	defer func() { userConfigDir = os.UserConfigDir }()
+
+	one := 1
+	cfg := &Config{
+		Presets:   map[string]Preset{"gentle": {MaxChanges: &one, MaxWalk: Duration(8 * time.Minute)}},
+		Favorites: map[string]string{"home": "9530"},
+	}
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := got.Favorite("home"); v != "9530" {
+		t.Errorf("favorite home = %q, want 9530", v)
+	}
+	if p := got.Presets["gentle"]; time.Duration(p.MaxWalk) != 8*time.Minute || p.MaxChanges == nil || *p.MaxChanges != 1 {
+		t.Errorf("preset not round-tripped: %+v", p)
+	}
+}
+
+func TestCommute(t *testing.T) {
+	var cfg Config
+	data := `{"commutes": {"morning": {"from": "home", "to": "Kista", "lines": ["14"], "towards": "Mörby centrum", "walk": "6m"}}}`
+	if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	c, err := cfg.Commute("morning")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.From != "home" || c.To != "Kista" || len(c.Lines) != 1 || c.Towards != "Mörby centrum" || time.Duration(c.Walk) != 6*time.Minute {
+		t.Errorf("commute = %+v", c)
+	}
+	if _, err := cfg.Commute("evening"); err == nil || !strings.Contains(err.Error(), "defined: morning") {
+		t.Errorf("expected an error listing the defined commutes, got %v", err)
+	}
+}
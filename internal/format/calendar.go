@@ -0,0 +1,183 @@
+package format
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+// CalendarEntry is a deviation with a resolved publish window.
+type CalendarEntry struct {
+	Deviation sl.Deviation
+	From      time.Time
+	Upto      time.Time // zero if open-ended
+}
+
+// CalendarEntries resolves publish windows, dropping deviations without a start,
+// and sorts the result by start time.
+func CalendarEntries(devs []sl.Deviation) []CalendarEntry {
+	entries := []CalendarEntry{}
+	for _, d := range devs {
+		if d.Publish == nil {
+			continue
+		}
+		from, ok := sl.ParseDeviationTime(d.Publish.From)
+		if !ok {
+			continue
+		}
+		upto, _ := sl.ParseDeviationTime(d.Publish.Upto)
+		entries = append(entries, CalendarEntry{Deviation: d, From: from, Upto: upto})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].From.Before(entries[j].From)
+	})
+	return entries
+}
+
+// activeOn reports whether the entry covers any part of the given day.
+func (e CalendarEntry) activeOn(day time.Time) bool {
+	next := day.AddDate(0, 0, 1)
+	if !e.From.Before(next) {
+		return false
+	}
+	return e.Upto.IsZero() || e.Upto.After(day)
+}
+
+// deviationMessage picks the English message variant, falling back to the first one.
+func deviationMessage(d sl.Deviation) sl.MessageVariant {
+	for _, m := range d.MessageVariants {
+		if m.Language == "en" {
+			return m
+		}
+	}
+	if len(d.MessageVariants) > 0 {
+		return d.MessageVariants[0]
+	}
+	return sl.MessageVariant{}
+}
+
+// DeviationCalendar prints planned disruptions as a week-by-week grid starting
+// from the Monday of the week containing start. Each disruption gets one
+// numbered row per week it touches, so multi-day closures read as a bar.
+func DeviationCalendar(entries []CalendarEntry, start time.Time, weeks int) {
+	// Day boundaries must match the entries' (Stockholm) time zone.
+	if len(entries) > 0 {
+		start = start.In(entries[0].From.Location())
+	}
+	monday := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	monday = monday.AddDate(0, 0, -((int(monday.Weekday()) + 6) % 7))
+	end := monday.AddDate(0, 0, 7*weeks)
+
+	bold.Fprintf(Stdout(), "📅 Planned disruptions %s – %s\n", monday.Format("2 Jan"), end.AddDate(0, 0, -1).Format("2 Jan 2006"))
+	fmt.Fprintln(Stdout(), strings.Repeat("─", 60))
+
+	// Number only entries visible in the range, in start order.
+	var visible []CalendarEntry
+	for _, e := range entries {
+		if e.From.Before(end) && (e.Upto.IsZero() || e.Upto.After(monday)) {
+			visible = append(visible, e)
+		}
+	}
+	if len(visible) == 0 {
+		green.Fprintln(Stdout(), "✓ No planned disruptions in this period.")
+		return
+	}
+
+	for w := 0; w < weeks; w++ {
+		weekStart := monday.AddDate(0, 0, 7*w)
+		_, isoWeek := weekStart.ISOWeek()
+
+		var rows []string
+		for i, e := range visible {
+			var cells strings.Builder
+			touched := false
+			for d := 0; d < 7; d++ {
+				if e.activeOn(weekStart.AddDate(0, 0, d)) {
+					cells.WriteString("  ■")
+					touched = true
+				} else {
+					cells.WriteString("  ·")
+				}
+			}
+			if touched {
+				rows = append(rows, fmt.Sprintf("  %-8s%s", fmt.Sprintf("[%d]", i+1), cells.String()))
+			}
+		}
+		if len(rows) == 0 {
+			continue
+		}
+
+		bold.Fprintf(Stdout(), "\nWeek %-5d Mo Tu We Th Fr Sa Su\n", isoWeek)
+		var dates strings.Builder
+		for d := 0; d < 7; d++ {
+			dates.WriteString(fmt.Sprintf(" %2d", weekStart.AddDate(0, 0, d).Day()))
+		}
+		dim.Fprintf(Stdout(), "%10s%s\n", "", dates.String())
+		for _, r := range rows {
+			yellow.Fprintln(Stdout(), r)
+		}
+	}
+
+	fmt.Fprintln(Stdout())
+	for i, e := range visible {
+		msg := deviationMessage(e.Deviation)
+		span := e.From.Format("Mon 2 Jan 15:04")
+		if !e.Upto.IsZero() {
+			span += " – " + e.Upto.Format("Mon 2 Jan 15:04")
+		} else {
+			span += " – until further notice"
+		}
+		bold.Fprintf(Stdout(), "  [%d] ", i+1)
+		fmt.Fprintln(Stdout(), msg.Header)
+		dim.Fprintf(Stdout(), "      %s\n", span)
+		if msg.ScopeAlias != "" {
+			dim.Fprintf(Stdout(), "      Affects: %s\n", msg.ScopeAlias)
+		}
+	}
+	fmt.Fprintln(Stdout())
+}
+
+// DeviationICal writes the entries as an iCalendar (RFC 5545) feed.
+// Open-ended disruptions are given a one-day duration.
+func DeviationICal(w io.Writer, entries []CalendarEntry) error {
+	var b strings.Builder
+	b.WriteString("BEGIN:VCALENDAR\r\n")
+	b.WriteString("VERSION:2.0\r\n")
+	b.WriteString("PRODID:-//sl-cli//deviations//EN\r\n")
+	b.WriteString("CALSCALE:GREGORIAN\r\n")
+
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	for _, e := range entries {
+		msg := deviationMessage(e.Deviation)
+		upto := e.Upto
+		if upto.IsZero() {
+			upto = e.From.AddDate(0, 0, 1)
+		}
+		b.WriteString("BEGIN:VEVENT\r\n")
+		fmt.Fprintf(&b, "UID:%d-%d@sl-cli\r\n", e.Deviation.DeviationCaseID, e.Deviation.Version)
+		fmt.Fprintf(&b, "DTSTAMP:%s\r\n", stamp)
+		fmt.Fprintf(&b, "DTSTART:%s\r\n", e.From.UTC().Format("20060102T150405Z"))
+		fmt.Fprintf(&b, "DTEND:%s\r\n", upto.UTC().Format("20060102T150405Z"))
+		fmt.Fprintf(&b, "SUMMARY:%s\r\n", icalEscape(msg.Header))
+		if msg.Details != "" {
+			fmt.Fprintf(&b, "DESCRIPTION:%s\r\n", icalEscape(msg.Details))
+		}
+		if msg.ScopeAlias != "" {
+			fmt.Fprintf(&b, "LOCATION:%s\r\n", icalEscape(msg.ScopeAlias))
+		}
+		b.WriteString("END:VEVENT\r\n")
+	}
+	b.WriteString("END:VCALENDAR\r\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func icalEscape(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return r.Replace(s)
+}
@@ -0,0 +1,70 @@
+package format
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+func TestCalendarEntries(t *testing.T) {
+	devs := []sl.Deviation{
+		{DeviationCaseID: 2, Publish: &sl.PublishWindow{From: "2024-03-09T04:00:00", Upto: "2024-03-11T01:00:00"}},
+		{DeviationCaseID: 1, Publish: &sl.PublishWindow{From: "2024-03-02T04:00:00"}},
+		{DeviationCaseID: 3, Publish: nil},
+		{DeviationCaseID: 4, Publish: &sl.PublishWindow{From: "garbage"}},
+	}
+
+	entries := CalendarEntries(devs)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Deviation.DeviationCaseID != 1 {
+		t.Errorf("entries should be sorted by start, got case %d first", entries[0].Deviation.DeviationCaseID)
+	}
+	if !entries[0].Upto.IsZero() {
+		t.Error("missing upto should be left zero (open-ended)")
+	}
+
+	weekend := entries[1]
+	loc := weekend.From.Location()
+	for day, want := range map[int]bool{8: false, 9: true, 10: true, 11: true, 12: false} {
+		d := time.Date(2024, 3, day, 0, 0, 0, 0, loc)
+		if got := weekend.activeOn(d); got != want {
+			t.Errorf("activeOn(Mar %d) = %v, want %v", day, got, want)
+		}
+	}
+}
+
+func TestDeviationICal(t *testing.T) {
+	entries := CalendarEntries([]sl.Deviation{{
+		DeviationCaseID: 42,
+		Version:         3,
+		Publish:         &sl.PublishWindow{From: "2024-03-09T04:00:00"},
+		MessageVariants: []sl.MessageVariant{
+			{Header: "Spårarbete", Language: "sv"},
+			{Header: "Track work; buses replace trains, Slussen", Language: "en"},
+		},
+	}})
+
+	var buf bytes.Buffer
+	if err := DeviationICal(&buf, entries); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"UID:42-3@sl-cli\r\n",
+		"DTSTART:20240309T030000Z\r\n",
+		"DTEND:20240310T030000Z\r\n",
+		`SUMMARY:Track work\; buses replace trains\, Slussen`,
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("ical output missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+// ChatDepartures renders the next departures as a short plain-text message
+// for chat: one line per departure, no colors or column padding.
+func ChatDepartures(deps []sl.ParsedDeparture, stopName string, max int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "📍 %s\n", stopName)
+	if len(deps) == 0 {
+		b.WriteString("No departures found.")
+		return b.String()
+	}
+	if max > 0 && len(deps) > max {
+		deps = deps[:max]
+	}
+	for _, d := range deps {
+		when := d.Display
+		if when == "" {
+			when = fmt.Sprintf("%d min", d.MinutesLeft)
+		}
+		fmt.Fprintf(&b, "%s %s %s — %s", ModeIcon(d.TransportMode), d.Line, d.Destination, when)
+		if d.State == "CANCELLED" {
+			b.WriteString(" (cancelled)")
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
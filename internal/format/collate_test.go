@@ -0,0 +1,39 @@
+package format
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestCollate(t *testing.T) {
+	defer SetSortLocale("sv")
+	names := []string{"Östermalmstorg", "Zinkensdamm", "Åkeshov", "Abrahamsberg", "Älvsjö", "Odenplan", "alvik"}
+	for _, tc := range []struct {
+		locale string
+		want   string
+	}{
+		{"sv", "[Abrahamsberg alvik Odenplan Zinkensdamm Åkeshov Älvsjö Östermalmstorg]"},
+		{"en", "[Abrahamsberg Åkeshov alvik Älvsjö Odenplan Östermalmstorg Zinkensdamm]"},
+		{"C", "[Abrahamsberg Odenplan Zinkensdamm alvik Älvsjö Åkeshov Östermalmstorg]"},
+	} {
+		if err := SetSortLocale(tc.locale); err != nil {
+			t.Fatal(err)
+		}
+		got := slices.Clone(names)
+		slices.SortFunc(got, Collate)
+		if fmt.Sprint(got) != tc.want {
+			t.Errorf("%s: got %v, want %s", tc.locale, got, tc.want)
+		}
+	}
+
+	SetSortLocale("sv")
+	lines := []string{"17", "4", "172", "4B", "14"}
+	slices.SortFunc(lines, Collate)
+	if want := "[4 4B 14 17 172]"; fmt.Sprint(lines) != want {
+		t.Errorf("line designations: got %v, want %s", lines, want)
+	}
+	if err := SetSortLocale("fr"); err == nil {
+		t.Error("unknown locale should fail")
+	}
+}
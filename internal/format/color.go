@@ -0,0 +1,42 @@
+package format
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+)
+
+// SetColor turns colored terminal output on or off for --color: "always",
+// "never", or "auto" (also "") to follow ColorFromEnv. Colors are written
+// to stderr as well as stdout, but only stdout is checked for a terminal.
+func SetColor(mode string) error {
+	switch mode {
+	case "", "auto":
+		color.NoColor = !ColorFromEnv()
+	case "always":
+		color.NoColor = false
+	case "never":
+		color.NoColor = true
+	default:
+		return fmt.Errorf("unknown color mode %q (use auto, always or never)", mode)
+	}
+	return nil
+}
+
+// ColorFromEnv reports whether --color auto colors output. NO_COLOR turns
+// color off (https://no-color.org); otherwise CLICOLOR_FORCE turns it on
+// even through a pipe, e.g. into less -R. Failing both, a terminal gets
+// color unless CLICOLOR=0 or TERM=dumb.
+func ColorFromEnv() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if v := os.Getenv("CLICOLOR_FORCE"); v != "" && v != "0" {
+		return true
+	}
+	if os.Getenv("CLICOLOR") == "0" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return IsTerminal()
+}
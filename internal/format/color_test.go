@@ -0,0 +1,47 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestSetColor(t *testing.T) {
+	noColor := color.NoColor
+	defer func() { color.NoColor = noColor }()
+	for _, name := range []string{"NO_COLOR", "CLICOLOR_FORCE", "CLICOLOR"} {
+		t.Setenv(name, "")
+	}
+
+	// Test output goes to a pipe, not a terminal.
+	tests := []struct {
+		mode string
+		env  map[string]string
+		want bool
+	}{
+		{"auto", nil, false},
+		{"auto", map[string]string{"CLICOLOR_FORCE": "1"}, true},
+		{"auto", map[string]string{"CLICOLOR_FORCE": "0"}, false},
+		{"auto", map[string]string{"CLICOLOR_FORCE": "1", "NO_COLOR": "1"}, false},
+		{"always", map[string]string{"NO_COLOR": "1"}, true},
+		{"never", map[string]string{"CLICOLOR_FORCE": "1"}, false},
+	}
+	for _, tt := range tests {
+		for name, v := range tt.env {
+			t.Setenv(name, v)
+		}
+		if err := SetColor(tt.mode); err != nil {
+			t.Fatal(err)
+		}
+		if got := !color.NoColor; got != tt.want {
+			t.Errorf("SetColor(%q) with %v: color %v, want %v", tt.mode, tt.env, got, tt.want)
+		}
+		for name := range tt.env {
+			t.Setenv(name, "")
+		}
+	}
+
+	if err := SetColor("sometimes"); err == nil {
+		t.Error("SetColor should reject an unknown mode")
+	}
+}
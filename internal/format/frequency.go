@@ -0,0 +1,133 @@
+package format
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+// FrequencyColumns are the columns of --format csv|tsv|table for
+// sl analyze frequency: one row per hour of the week.
+var FrequencyColumns = []Column[sl.HourlyFrequency]{
+	{"day", func(f sl.HourlyFrequency) string { return f.Day }},
+	{"hour", func(f sl.HourlyFrequency) string { return strconv.Itoa(f.Hour) }},
+	{"scheduled", func(f sl.HourlyFrequency) string { return strconv.Itoa(f.Scheduled) }},
+	{"observed", func(f sl.HourlyFrequency) string {
+		if f.Observed == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*f.Observed, 'f', 1, 64)
+	}},
+}
+
+// Frequency prints departures per hour as a week grid, Monday to Sunday
+// down and hours across, followed by the observed averages if any.
+func Frequency(line string, freq []sl.HourlyFrequency) {
+	bold.Fprintf(Stdout(), "📊 Line %s departures per hour\n", line)
+	frequencyGrid("Scheduled", freq, func(f sl.HourlyFrequency) (float64, bool) {
+		return float64(f.Scheduled), true
+	})
+	for _, f := range freq {
+		if f.Observed != nil {
+			frequencyGrid("Observed (average per day recorded)", freq, func(f sl.HourlyFrequency) (float64, bool) {
+				if f.Observed == nil {
+					return 0, false
+				}
+				return *f.Observed, true
+			})
+			break
+		}
+	}
+}
+
+func frequencyGrid(title string, freq []sl.HourlyFrequency, value func(sl.HourlyFrequency) (float64, bool)) {
+	fmt.Fprintln(Stdout(), strings.Repeat("─", 76))
+	dim.Fprintf(Stdout(), "%s\n    ", title)
+	for h := range 24 {
+		dim.Fprintf(Stdout(), "%3d", h)
+	}
+	fmt.Fprintln(Stdout())
+	for d := range len(freq) / 24 {
+		fmt.Fprintf(Stdout(), "%s ", freq[d*24].Day)
+		for _, f := range freq[d*24 : d*24+24] {
+			switch v, ok := value(f); {
+			case !ok:
+				dim.Fprint(Stdout(), "  -")
+			case v == 0:
+				dim.Fprint(Stdout(), "  ·")
+			default:
+				cyan.Fprintf(Stdout(), "%3.0f", v)
+			}
+		}
+		fmt.Fprintln(Stdout())
+	}
+	fmt.Fprintln(Stdout())
+}
+
+// Heatmap cell size and the gap between the scheduled and observed grids,
+// in pixels.
+const (
+	heatCell = 24
+	heatGap  = 12
+)
+
+// FrequencyPNG draws departures per hour as a heatmap: a row per day,
+// Monday at the top, and a column per hour from midnight. The darker the
+// cell, the more departures. With observations a second grid below shows
+// them on the same scale; hours not recorded are grey.
+func FrequencyPNG(w io.Writer, freq []sl.HourlyFrequency) error {
+	days := len(freq) / 24
+	observed := false
+	peak := 1.0
+	for _, f := range freq {
+		peak = max(peak, float64(f.Scheduled))
+		if f.Observed != nil {
+			observed = true
+			peak = max(peak, *f.Observed)
+		}
+	}
+
+	grids := 1
+	if observed {
+		grids = 2
+	}
+	gridHeight := days * heatCell
+	img := image.NewRGBA(image.Rect(0, 0, 24*heatCell, grids*gridHeight+(grids-1)*heatGap))
+	fill(img, img.Bounds(), color.RGBA{255, 255, 255, 255})
+
+	for i, f := range freq {
+		x, y := i%24*heatCell, i/24*heatCell
+		fill(img, image.Rect(x, y, x+heatCell-1, y+heatCell-1), heatColor(float64(f.Scheduled)/peak))
+		if !observed {
+			continue
+		}
+		y += gridHeight + heatGap
+		c := color.RGBA{200, 200, 200, 255}
+		if f.Observed != nil {
+			c = heatColor(*f.Observed / peak)
+		}
+		fill(img, image.Rect(x, y, x+heatCell-1, y+heatCell-1), c)
+	}
+	return png.Encode(w, img)
+}
+
+// heatColor shades from pale yellow at 0 to dark blue at 1.
+func heatColor(v float64) color.RGBA {
+	v = min(max(v, 0), 1)
+	lerp := func(a, b uint8) uint8 { return uint8(float64(a) + (float64(b)-float64(a))*v) }
+	return color.RGBA{lerp(255, 8), lerp(255, 48), lerp(217, 107), 255}
+}
+
+func fill(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
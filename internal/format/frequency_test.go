@@ -0,0 +1,37 @@
+package format
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+func TestFrequencyPNG(t *testing.T) {
+	freq := make([]sl.HourlyFrequency, 7*24)
+	freq[8].Scheduled = 6
+
+	var buf bytes.Buffer
+	if err := FrequencyPNG(&buf, freq); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("not a PNG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 24*heatCell || b.Dy() != 7*heatCell {
+		t.Errorf("got %v, want one 24×7 grid", b)
+	}
+	if r, _, _, _ := img.At(8*heatCell+1, 1).RGBA(); r>>8 != 8 {
+		t.Errorf("Monday 08, the busiest hour, should be darkest; red = %d", r>>8)
+	}
+
+	observed := 3.0
+	freq[8].Observed = &observed
+	buf.Reset()
+	FrequencyPNG(&buf, freq)
+	if img, _ := png.Decode(&buf); img.Bounds().Dy() != 14*heatCell+heatGap {
+		t.Errorf("got %v, want a second grid for the observations", img.Bounds())
+	}
+}
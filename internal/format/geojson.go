@@ -0,0 +1,193 @@
+package format
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+// geoFeature is a GeoJSON Feature. Coordinates are [lon, lat], as GeoJSON
+// orders them.
+type geoFeature struct {
+	Type       string         `json:"type"`
+	Geometry   map[string]any `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+func pointFeature(lat, lon float64, props map[string]any) geoFeature {
+	return geoFeature{
+		Type:       "Feature",
+		Geometry:   map[string]any{"type": "Point", "coordinates": [2]float64{lon, lat}},
+		Properties: props,
+	}
+}
+
+func lineFeature(line [][2]float64, props map[string]any) geoFeature {
+	return geoFeature{
+		Type:       "Feature",
+		Geometry:   map[string]any{"type": "LineString", "coordinates": line},
+		Properties: props,
+	}
+}
+
+// writeGeoJSON writes features as an indented FeatureCollection.
+func writeGeoJSON(w io.Writer, features []geoFeature) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]any{"type": "FeatureCollection", "features": features})
+}
+
+// ReachGeoJSON writes reachable stops as a GeoJSON FeatureCollection: a
+// Point per stop with its name and travel time, and the convex hull of all
+// of them as a Polygon — a rough outline of the area within reach.
+func ReachGeoJSON(w io.Writer, stops []sl.ReachableStop) error {
+	features := []geoFeature{}
+
+	points := make([][2]float64, 0, len(stops))
+	for _, s := range stops {
+		points = append(points, [2]float64{s.Lon, s.Lat})
+	}
+	if hull := convexHull(points); len(hull) >= 3 {
+		ring := append(hull, hull[0])
+		features = append(features, geoFeature{
+			Type:       "Feature",
+			Geometry:   map[string]any{"type": "Polygon", "coordinates": [][][2]float64{ring}},
+			Properties: map[string]any{"kind": "hull", "stops": len(stops)},
+		})
+	}
+	for _, s := range stops {
+		features = append(features, pointFeature(s.Lat, s.Lon,
+			map[string]any{"kind": "stop", "id": s.ID, "name": s.Name, "minutes": s.Minutes}))
+	}
+	return writeGeoJSON(w, features)
+}
+
+// NearbyGeoJSON writes nearby stops as a GeoJSON FeatureCollection, a
+// Point per stop with its name, site ID, distance and stop types.
+func NearbyGeoJSON(w io.Writer, stops []sl.SiteWithDistance) error {
+	features := []geoFeature{}
+	for _, s := range stops {
+		props := map[string]any{"kind": "stop", "id": s.Site.ID, "name": s.Site.Name, "distance_m": s.DistanceM}
+		if len(s.Types) > 0 {
+			props["types"] = s.Types
+		}
+		features = append(features, pointFeature(s.Site.Lat, s.Site.Lon, props))
+	}
+	return writeGeoJSON(w, features)
+}
+
+// LineRoutesGeoJSON writes line routes as a GeoJSON FeatureCollection: a
+// LineString per direction through its stops, followed by a Point per stop
+// with its scheduled minutes from the first.
+func LineRoutesGeoJSON(w io.Writer, routes []sl.LineRoute) error {
+	features := []geoFeature{}
+	for _, r := range routes {
+		line := make([][2]float64, 0, len(r.Stops))
+		for _, s := range r.Stops {
+			line = append(line, [2]float64{s.Lon, s.Lat})
+		}
+		features = append(features, lineFeature(line, map[string]any{
+			"kind": "route", "line": r.Line, "transport_mode": r.Mode,
+			"direction_code": r.Direction, "headsign": r.Headsign, "trips": r.Trips,
+		}))
+	}
+	for _, r := range routes {
+		for _, s := range r.Stops {
+			features = append(features, pointFeature(s.Lat, s.Lon, map[string]any{
+				"kind": "stop", "id": s.ID, "name": s.Name, "line": r.Line,
+				"direction_code": r.Direction, "minutes": s.Minutes,
+			}))
+		}
+	}
+	return writeGeoJSON(w, features)
+}
+
+// TripsGeoJSON writes itineraries as a GeoJSON FeatureCollection: a
+// LineString per leg, along the planner's path when the trip was planned
+// with coordinates and straight from stop to stop otherwise, and a Point
+// per stop where a leg starts or the journey ends. Every feature carries
+// the 1-based number of its journey, to tell alternatives apart.
+func TripsGeoJSON(w io.Writer, journeys []sl.JourneyTrip) error {
+	features := []geoFeature{}
+	var stops []geoFeature
+	for i, j := range journeys {
+		for n, leg := range j.Legs {
+			if leg.Origin == nil || leg.Destination == nil {
+				continue
+			}
+			line := make([][2]float64, 0, len(leg.Coords))
+			for _, c := range leg.Coords {
+				line = append(line, [2]float64{c[1], c[0]})
+			}
+			if len(line) < 2 {
+				line = [][2]float64{
+					{leg.Origin.Coord[1], leg.Origin.Coord[0]},
+					{leg.Destination.Coord[1], leg.Destination.Coord[0]},
+				}
+			}
+			props := map[string]any{
+				"kind": "walk", "journey": i + 1, "leg": n + 1,
+				"from": leg.Origin.Name, "to": leg.Destination.Name,
+				"departure": leg.Origin.DepartureTimePlanned, "arrival": leg.Destination.ArrivalTimePlanned,
+			}
+			if leg.Transport != nil && leg.Transport.Name != "" {
+				props["kind"] = "ride"
+				props["line"] = leg.Transport.Number
+				if mode := sl.LegMode(leg); mode != "" {
+					props["transport_mode"] = mode
+				}
+			}
+			features = append(features, lineFeature(line, props))
+
+			stops = append(stops, tripStopFeature(leg.Origin, i+1))
+			if n == len(j.Legs)-1 {
+				stops = append(stops, tripStopFeature(leg.Destination, i+1))
+			}
+		}
+	}
+	return writeGeoJSON(w, append(features, stops...))
+}
+
+func tripStopFeature(s *sl.JourneyStop, journey int) geoFeature {
+	props := map[string]any{"kind": "stop", "journey": journey, "id": s.ID, "name": s.Name}
+	if s.Platform != "" {
+		props["platform"] = s.Platform
+	}
+	return pointFeature(s.Coord[0], s.Coord[1], props)
+}
+
+// convexHull returns the hull of points counter-clockwise (Andrew's
+// monotone chain), without repeating the first point.
+func convexHull(points [][2]float64) [][2]float64 {
+	pts := append([][2]float64(nil), points...)
+	sort.Slice(pts, func(i, j int) bool {
+		if pts[i][0] != pts[j][0] {
+			return pts[i][0] < pts[j][0]
+		}
+		return pts[i][1] < pts[j][1]
+	})
+	if len(pts) < 3 {
+		return pts
+	}
+	cross := func(o, a, b [2]float64) float64 {
+		return (a[0]-o[0])*(b[1]-o[1]) - (a[1]-o[1])*(b[0]-o[0])
+	}
+	hull := make([][2]float64, 0, 2*len(pts))
+	for _, p := range pts {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	lower := len(hull) + 1
+	for i := len(pts) - 2; i >= 0; i-- {
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], pts[i]) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, pts[i])
+	}
+	return hull[:len(hull)-1]
+}
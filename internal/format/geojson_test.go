@@ -0,0 +1,122 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+func TestReachGeoJSON(t *testing.T) {
+	stop := func(id string, lat, lon float64, mins int) sl.ReachableStop {
+		return sl.ReachableStop{GraphStop: sl.GraphStop{ID: id, Name: id, Lat: lat, Lon: lon}, Minutes: mins}
+	}
+	stops := []sl.ReachableStop{
+		stop("A", 59.0, 18.0, 0), stop("B", 59.0, 18.2, 5), stop("C", 59.2, 18.2, 9),
+		stop("D", 59.2, 18.0, 12), stop("inside", 59.1, 18.1, 7),
+	}
+
+	var buf bytes.Buffer
+	if err := ReachGeoJSON(&buf, stops); err != nil {
+		t.Fatal(err)
+	}
+	var fc struct {
+		Type     string
+		Features []struct {
+			Geometry struct {
+				Type        string
+				Coordinates json.RawMessage
+			}
+			Properties map[string]any
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &fc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if fc.Type != "FeatureCollection" || len(fc.Features) != 6 {
+		t.Fatalf("got %s with %d features, want a hull and 5 points", fc.Type, len(fc.Features))
+	}
+	hull := fc.Features[0]
+	var rings [][][2]float64
+	json.Unmarshal(hull.Geometry.Coordinates, &rings)
+	if hull.Geometry.Type != "Polygon" || len(rings) != 1 || len(rings[0]) != 5 || rings[0][0] != rings[0][4] {
+		t.Errorf("hull should be the closed square around the corners, got %v", rings)
+	}
+	if p := fc.Features[5].Properties; p["name"] != "inside" || p["minutes"] != 7.0 {
+		t.Errorf("last point properties = %v", p)
+	}
+}
+
+func TestTripsGeoJSON(t *testing.T) {
+	stop := func(name string, lat, lon float64) *sl.JourneyStop {
+		return &sl.JourneyStop{ID: name, Name: name, Coord: [2]float64{lat, lon}}
+	}
+	journeys := []sl.JourneyTrip{{Legs: []sl.JourneyLeg{
+		{Origin: stop("Home", 59.31, 18.07), Destination: stop("Slussen", 59.32, 18.07)},
+		{
+			Origin: stop("Slussen", 59.32, 18.07), Destination: stop("T-Centralen", 59.33, 18.06),
+			Transport: &sl.JourneyTransport{Name: "Tunnelbana 17", Number: "17", Product: &sl.TransportProduct{CatOutL: "Metro"}},
+			Coords:    [][2]float64{{59.32, 18.07}, {59.325, 18.068}, {59.33, 18.06}},
+		},
+	}}}
+
+	var buf bytes.Buffer
+	if err := TripsGeoJSON(&buf, journeys); err != nil {
+		t.Fatal(err)
+	}
+	var fc struct {
+		Features []struct {
+			Geometry struct {
+				Type        string
+				Coordinates json.RawMessage
+			}
+			Properties map[string]any
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &fc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(fc.Features) != 5 {
+		t.Fatalf("got %d features, want 2 legs and 3 stops", len(fc.Features))
+	}
+	var walk, ride [][2]float64
+	json.Unmarshal(fc.Features[0].Geometry.Coordinates, &walk)
+	json.Unmarshal(fc.Features[1].Geometry.Coordinates, &ride)
+	if fc.Features[0].Properties["kind"] != "walk" || len(walk) != 2 || walk[0] != [2]float64{18.07, 59.31} {
+		t.Errorf("walk without a path should go straight from stop to stop, got %v %v", fc.Features[0].Properties, walk)
+	}
+	if p := fc.Features[1].Properties; p["kind"] != "ride" || p["line"] != "17" || p["transport_mode"] != "METRO" || len(ride) != 3 {
+		t.Errorf("ride = %v along %v", p, ride)
+	}
+	if p := fc.Features[4].Properties; fc.Features[4].Geometry.Type != "Point" || p["name"] != "T-Centralen" || p["journey"] != 1.0 {
+		t.Errorf("last feature should be the destination, got %v", p)
+	}
+}
+
+func TestLineRoutesGeoJSON(t *testing.T) {
+	routes := []sl.LineRoute{{Line: "4", Mode: "BUS", Direction: 1, Stops: []sl.LineStop{
+		{GraphStop: sl.GraphStop{ID: "A", Name: "Odenplan", Lat: 59.343, Lon: 18.049}},
+		{GraphStop: sl.GraphStop{ID: "B", Name: "Fridhemsplan", Lat: 59.332, Lon: 18.029}, Minutes: 6},
+	}}}
+
+	var buf bytes.Buffer
+	if err := LineRoutesGeoJSON(&buf, routes); err != nil {
+		t.Fatal(err)
+	}
+	var fc struct {
+		Features []struct {
+			Geometry   struct{ Type string }
+			Properties map[string]any
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &fc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(fc.Features) != 3 || fc.Features[0].Geometry.Type != "LineString" || fc.Features[0].Properties["line"] != "4" {
+		t.Fatalf("want the route then its 2 stops, got %+v", fc.Features)
+	}
+	if p := fc.Features[2].Properties; p["name"] != "Fridhemsplan" || p["minutes"] != 6.0 {
+		t.Errorf("last stop properties = %v", p)
+	}
+}
@@ -0,0 +1,261 @@
+package format
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/glundgren93/sl-cli/internal/i18n"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata/")
+
+// captureOutput runs fn with stdout (and color output) redirected, colors off.
+func captureOutput(t *testing.T, fn func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, colorOut, noColor := os.Stdout, color.Output, color.NoColor
+	os.Stdout, color.Output, color.NoColor = w, w, true
+	defer func() {
+		os.Stdout, color.Output, color.NoColor = stdout, colorOut, noColor
+	}()
+
+	out := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		out <- string(data)
+	}()
+
+	fn()
+	w.Close()
+	return <-out
+}
+
+// assertGolden compares got with testdata/<name>.golden, rewriting it with -update.
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file (run with -update to create): %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("output differs from %s (run with -update if intentional)\n--- got ---\n%s\n--- want ---\n%s", path, got, want)
+	}
+}
+
+// goldenDepartures prints a board with inline deviation warnings.
+func goldenDepartures() {
+	deps := []sl.ParsedDeparture{
+		{Line: "17", TransportMode: "METRO", GroupOfLines: "Gröna linjen", Destination: "Åkeshov", MinutesLeft: 0, Display: "Nu", State: "ATSTOP", Platform: "1"},
+		{Line: "17", TransportMode: "METRO", GroupOfLines: "Gröna linjen", Destination: "Skarpnäck", MinutesLeft: 4, State: "EXPECTED", Platform: "2",
+			Deviations: []sl.DepartureDeviation{{ImportanceLevel: 5, Message: "Ersättningsbuss mellan Gullmarsplan och Skarpnäck."}}},
+		{Line: "55", TransportMode: "BUS", Destination: "Tanto", MinutesLeft: 12, State: "CANCELLED"},
+	}
+	Departures(deps, "Medborgarplatsen", false)
+	DeviationWarnings([]DeviationWarning{{Line: "55", Header: "Bus 55 diverted", Details: "Road works."}})
+}
+
+func TestGolden_NearbyStops(t *testing.T) {
+	stops := []sl.SiteWithDistance{
+		{Site: sl.Site{ID: 9191, Name: "Medborgarplatsen"}, DistanceKm: 0.12, Types: []string{"METROSTN", "BUSTERM"}},
+		{Site: sl.Site{ID: 1080, Name: "Timmermansgränd"}, DistanceKm: 0.45},
+	}
+	out := captureOutput(t, func() { NearbyStops(stops, false) })
+	assertGolden(t, "nearby", out)
+}
+
+func TestGolden_NearbyStopsWithLines(t *testing.T) {
+	stops := []NearbyStopWithLines{
+		{Stop: "Medborgarplatsen", SiteID: 9191, DistanceM: 120, Lines: []StopInfoLine{
+			{Designation: "17", TransportMode: "METRO", Destinations: []string{"Åkeshov", "Skarpnäck"}},
+		}},
+		{Stop: "Timmermansgränd", SiteID: 1080, DistanceM: 450},
+	}
+	out := captureOutput(t, func() { NearbyStopsWithLines(stops, false) })
+	assertGolden(t, "nearby_lines", out)
+}
+
+func TestGolden_StopInfo(t *testing.T) {
+	lines := []StopInfoLine{
+		{Designation: "17", TransportMode: "METRO", GroupOfLines: "Gröna linjen", Destinations: []string{"Åkeshov", "Skarpnäck"}},
+		{Designation: "55", TransportMode: "BUS", Destinations: []string{"Tanto"}},
+		{Designation: "19", TransportMode: "METRO", Destinations: []string{"Hässelby strand"}},
+	}
+	out := captureOutput(t, func() { StopInfo("Medborgarplatsen", 9191, []string{"METROSTN"}, lines) })
+	assertGolden(t, "stopinfo", out)
+}
+
+func TestGolden_Deviations(t *testing.T) {
+	devs := []sl.Deviation{
+		{Severity: "major", MessageVariants: []sl.MessageVariant{
+			{Header: "Buss 55 omdirigerad", Language: "sv"},
+			{Header: "Bus 55 diverted", Details: "Due to road works.", ScopeAlias: "Bus 55", Language: "en"},
+			{Header: "Ignored", Language: "de"},
+		}},
+	}
+	out := captureOutput(t, func() { Deviations(devs, DeviationPage{Total: 1, Full: true}) })
+	assertGolden(t, "deviations", out)
+}
+
+func TestGolden_DeviationsCompact(t *testing.T) {
+	devs := []sl.Deviation{
+		{Severity: "major", MessageVariants: []sl.MessageVariant{
+			{Header: "Buss 55 omdirigerad", Language: "sv"},
+			{Header: "Bus 55 diverted", ScopeAlias: "Bus 55", Language: "en"},
+		}},
+		{MessageVariants: []sl.MessageVariant{{Header: "Hissen ur funktion", Language: "sv"}}},
+	}
+	out := captureOutput(t, func() { Deviations(devs, DeviationPage{Total: 7, Offset: 2}) })
+	assertGolden(t, "deviations_compact", out)
+}
+
+func TestGolden_Lines(t *testing.T) {
+	lines := []sl.Line{
+		{Designation: "55", TransportMode: "BUS"},
+		{Designation: "17", TransportMode: "METRO"},
+		{Designation: "66", TransportMode: "BUS"},
+	}
+	out := captureOutput(t, func() { Lines(lines) })
+	assertGolden(t, "lines", out)
+}
+
+// goldenJourneys is a walk-then-metro trip shared by the Trips golden tests.
+var goldenJourneys = []sl.JourneyTrip{{
+	TripDuration: 900, TripRtDuration: 960, Interchanges: 1,
+	Legs: []sl.JourneyLeg{
+		{Duration: 180, Origin: &sl.JourneyStop{Name: "Götgatan 1"}, Destination: &sl.JourneyStop{Name: "Medborgarplatsen"}},
+		{
+			Duration:    420,
+			Origin:      &sl.JourneyStop{Name: "Medborgarplatsen", DepartureTimePlanned: "2024-03-01T08:03:00"},
+			Destination: &sl.JourneyStop{Name: "T-Centralen", ArrivalTimePlanned: "2024-03-01T08:10:00"},
+			Transport:   &sl.JourneyTransport{Name: "Tunnelbana 17", Product: &sl.TransportProduct{CatOutL: "Metro"}},
+		},
+	},
+}}
+
+// goldenChangeJourneys is a metro-then-bus trip with a tight change at Slussen.
+var goldenChangeJourneys = []sl.JourneyTrip{{
+	TripDuration: 1500, TripRtDuration: 1500, Interchanges: 1,
+	Legs: []sl.JourneyLeg{
+		{
+			Duration:    300,
+			Origin:      &sl.JourneyStop{Name: "T-Centralen", DepartureTimePlanned: "2024-03-01T08:00:00Z"},
+			Destination: &sl.JourneyStop{Name: "Slussen", ArrivalTimePlanned: "2024-03-01T08:05:00Z"},
+			Transport:   &sl.JourneyTransport{Name: "Tunnelbana 17", Product: &sl.TransportProduct{CatOutL: "Metro"}},
+		},
+		{
+			Duration:    900,
+			Origin:      &sl.JourneyStop{Name: "Slussen", DepartureTimePlanned: "2024-03-01T08:07:00Z"},
+			Destination: &sl.JourneyStop{Name: "Danvikstull", ArrivalTimePlanned: "2024-03-01T08:22:00Z"},
+			Transport:   &sl.JourneyTransport{Name: "Buss 53", Product: &sl.TransportProduct{CatOutL: "Bus"}},
+		},
+	},
+}}
+
+// localizedGoldens are formatters whose text goes through i18n. Each is
+// checked in every language and in the pseudo-locale, which catches
+// alignment that only holds for short English strings. English output is
+// <name>.golden, other languages <name>_<lang>.golden.
+var localizedGoldens = []struct {
+	name string
+	fn   func()
+}{
+	{"departures", goldenDepartures},
+	{"trips", func() { Trips(goldenJourneys, sl.Carriage{}) }},
+	{"trips_change", func() { Trips(goldenChangeJourneys, sl.Carriage{}) }},
+}
+
+func TestGolden_Localized(t *testing.T) {
+	defer i18n.SetLanguage("en")
+
+	for _, lang := range append(i18n.Languages(), i18n.Pseudo) {
+		if err := i18n.SetLanguage(lang); err != nil {
+			t.Fatal(err)
+		}
+		for _, g := range localizedGoldens {
+			name := g.name
+			if lang != "en" {
+				name += "_" + lang
+			}
+			t.Run(name, func(t *testing.T) {
+				assertGolden(t, name, captureOutput(t, g.fn))
+			})
+		}
+	}
+}
+
+func TestGolden_DeviationCalendar(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/Stockholm")
+	entries := CalendarEntries([]sl.Deviation{
+		{Publish: &sl.PublishWindow{From: "2024-03-09T04:00:00", Upto: "2024-03-11T01:00:00"},
+			MessageVariants: []sl.MessageVariant{{Header: "Track work", ScopeAlias: "Line 17", Language: "en"}}},
+	})
+	out := captureOutput(t, func() { DeviationCalendar(entries, time.Date(2024, 3, 1, 12, 0, 0, 0, loc), 2) })
+	assertGolden(t, "calendar", out)
+}
+
+func TestGolden_Plain(t *testing.T) {
+	SetPlain(true)
+	defer SetPlain(false)
+
+	out := captureOutput(t, func() {
+		goldenDepartures()
+		Trips(goldenChangeJourneys, sl.Carriage{})
+	})
+	for _, r := range out {
+		if r > 0x7f && !strings.ContainsRune("ÅÄÖåäöé", r) {
+			t.Errorf("plain output contains %q", r)
+		}
+	}
+	assertGolden(t, "plain", out)
+}
+
+func TestFormatTime(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = noColor }()
+
+	loc, _ := time.LoadLocation("Europe/Stockholm")
+	sched := time.Date(2025, 6, 2, 12, 5, 0, 0, loc)
+	dep := func(expected time.Duration, state string) sl.ParsedDeparture {
+		return sl.ParsedDeparture{Scheduled: sched, Expected: sched.Add(expected), MinutesLeft: 8, Display: "8 min", State: state}
+	}
+	tests := []struct {
+		d             sl.ParsedDeparture
+		showScheduled bool
+		want          string
+	}{
+		{dep(3*time.Minute, "EXPECTED"), false, "8 min (+3)"},
+		{dep(3*time.Minute, "EXPECTED"), true, "12:05 (+3)"},
+		{dep(-2*time.Minute, "EXPECTED"), true, "12:05 (-2)"},
+		{dep(time.Minute, "EXPECTED"), true, "12:05"},
+		{dep(5*time.Minute, "CANCELLED"), false, "8 min"},
+	}
+	for _, tt := range tests {
+		if got := formatTime(tt.d, tt.showScheduled); got != tt.want {
+			t.Errorf("formatTime(%v late, %t) = %q, want %q", tt.d.Expected.Sub(tt.d.Scheduled), tt.showScheduled, got, tt.want)
+		}
+	}
+}
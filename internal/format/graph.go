@@ -0,0 +1,108 @@
+package format
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+// GraphDOT writes the network graph in Graphviz DOT. Edges carry the line,
+// mode and trip count as attributes and are colored like the line.
+func GraphDOT(w io.Writer, g *sl.NetworkGraph) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph sl {")
+	for _, s := range g.Stops {
+		fmt.Fprintf(bw, "  %s [label=%s, lat=%s, lon=%s];\n", strconv.Quote(s.ID), strconv.Quote(s.Name),
+			strconv.FormatFloat(s.Lat, 'f', -1, 64), strconv.FormatFloat(s.Lon, 'f', -1, 64))
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(bw, "  %s -> %s [line=%s, mode=%s, trips=%d, color=%s];\n", strconv.Quote(e.From), strconv.Quote(e.To),
+			strconv.Quote(e.Line), strconv.Quote(e.Mode), e.Trips, strconv.Quote(LineColor(e.Line, e.Mode)))
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// graphML mirrors the subset of GraphML written by GraphML.
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	NS      string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// GraphML writes the network graph as GraphML, for Gephi, NetworkX, igraph
+// and the like.
+func GraphML(w io.Writer, g *sl.NetworkGraph) error {
+	doc := graphML{
+		NS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{"name", "node", "name", "string"},
+			{"lat", "node", "lat", "double"},
+			{"lon", "node", "lon", "double"},
+			{"line", "edge", "line", "string"},
+			{"mode", "edge", "mode", "string"},
+			{"trips", "edge", "trips", "int"},
+		},
+		Graph: graphMLGraph{ID: "sl", EdgeDefault: "directed"},
+	}
+	for _, s := range g.Stops {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{ID: s.ID, Data: []graphMLData{
+			{"name", s.Name},
+			{"lat", strconv.FormatFloat(s.Lat, 'f', -1, 64)},
+			{"lon", strconv.FormatFloat(s.Lon, 'f', -1, 64)},
+		}})
+	}
+	for _, e := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{Source: e.From, Target: e.To, Data: []graphMLData{
+			{"line", e.Line},
+			{"mode", e.Mode},
+			{"trips", strconv.Itoa(e.Trips)},
+		}})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
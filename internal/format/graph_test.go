@@ -0,0 +1,53 @@
+package format
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+var testGraph = &sl.NetworkGraph{
+	Stops: []sl.GraphStop{
+		{ID: "A", Name: "Slussen", Lat: 59.3195, Lon: 18.0722},
+		{ID: "B", Name: `Medborgarplatsen "M"`, Lat: 59.3143, Lon: 18.0735},
+	},
+	Edges: []sl.GraphEdge{{From: "A", To: "B", Line: "17", Mode: "METRO", Trips: 2}},
+}
+
+func TestGraphDOT(t *testing.T) {
+	var buf bytes.Buffer
+	if err := GraphDOT(&buf, testGraph); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"digraph sl {",
+		`"B" [label="Medborgarplatsen \"M\"", lat=59.3143, lon=18.0735];`,
+		`"A" -> "B" [line="17", mode="METRO", trips=2, color="#4ba946"];`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestGraphML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := GraphML(&buf, testGraph); err != nil {
+		t.Fatal(err)
+	}
+	var doc graphML
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, buf.String())
+	}
+	if len(doc.Graph.Nodes) != 2 || len(doc.Graph.Edges) != 1 || doc.Graph.EdgeDefault != "directed" {
+		t.Fatalf("got %+v", doc.Graph)
+	}
+	e := doc.Graph.Edges[0]
+	if e.Source != "A" || e.Target != "B" || e.Data[0] != (graphMLData{"line", "17"}) {
+		t.Errorf("edge = %+v", e)
+	}
+}
@@ -0,0 +1,159 @@
+package format
+
+import (
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+// HTMLBoard is one stop's section in an HTML departure board.
+type HTMLBoard struct {
+	Stop       string
+	DistanceM  int
+	Departures []sl.ParsedDeparture
+	Deviations []DeviationWarning
+	AsOf       time.Time // set when showing a remembered board during an outage
+}
+
+// Board themes. ThemeDark is SL's own yellow-on-black look; ThemeDay is
+// black on white for sunlit hallways; ThemeNight is low-contrast and dark.
+const (
+	ThemeDark  = "dark"
+	ThemeDay   = "day"
+	ThemeNight = "night"
+)
+
+// BoardStyle controls how an HTML board looks. The zero value is the dark
+// theme at full brightness without line colors.
+type BoardStyle struct {
+	Theme      string
+	LineColors bool    // color each row in its line's color from the SL palette
+	Brightness float64 // 0 < Brightness < 1 dims the whole board
+}
+
+var htmlBoardTmpl = template.Must(template.New("board").Funcs(template.FuncMap{
+	"icon":      ModeIcon,
+	"notes":     departureNotes,
+	"lineColor": func(d sl.ParsedDeparture) string { return LineColor(d.Line, d.TransportMode) },
+	"rowClass": func(colored bool, d sl.ParsedDeparture) string {
+		var classes []string
+		if colored {
+			classes = append(classes, "colored")
+		}
+		if d.State == "CANCELLED" {
+			classes = append(classes, "cancelled")
+		}
+		return strings.Join(classes, " ")
+	},
+	"age": func(t time.Time) int { return int(time.Since(t).Minutes()) },
+	"clock": func(d sl.ParsedDeparture) string {
+		t := d.Expected
+		if t.IsZero() {
+			t = d.Scheduled
+		}
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format("15:04")
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="sv">
+<head>
+<meta charset="utf-8">
+{{- if gt .Refresh 0}}
+<meta http-equiv="refresh" content="{{.Refresh}}">
+{{- end}}
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+  body { --bg: #111; --fg: #f5c400; --head: #fff; --muted: #888; --rule: #333; }
+  body.day { --bg: #fff; --fg: #000; --head: #000; --muted: #555; --rule: #ccc; }
+  body.night { --bg: #000; --fg: #8a7020; --head: #999; --muted: #555; --rule: #1a1a1a; }
+  body { margin: 0; padding: 2vh 3vw; background: var(--bg); color: var(--fg); font-family: "DejaVu Sans Mono", Menlo, monospace; }
+  h1 { font-size: 4vh; margin: 2vh 0 1vh; color: var(--head); }
+  h1 small { color: var(--muted); font-size: 2.5vh; }
+  table { width: 100%; border-collapse: collapse; font-size: 3.5vh; }
+  td { padding: 0.6vh 1vw; border-bottom: 1px solid var(--rule); }
+  td.line { width: 12%; font-weight: bold; }
+  td.time { width: 14%; text-align: right; }
+  td.mins { width: 12%; text-align: right; color: var(--head); }
+  tr.colored { background: color-mix(in srgb, var(--line) 22%, var(--bg)); }
+  tr.colored td.line { background: var(--line); color: #fff; }
+  tr.cancelled td { color: #e5484d; text-decoration: line-through; }
+  tr.note td { padding-top: 0; color: var(--fg); font-size: 2.5vh; font-style: italic; }
+  .plat { color: var(--muted); font-size: 2.5vh; }
+  .stale { margin: 1vh 0; padding: 1vh 1vw; background: #4a1010; color: #ff9b9b; font-size: 2.5vh; }
+  .dev { margin: 1vh 0; padding: 1vh 1vw; background: #3a2a00; color: #ffd866; font-size: 2.5vh; }
+  .empty { color: var(--muted); font-size: 3vh; }
+  footer { margin-top: 3vh; color: var(--muted); font-size: 2vh; }
+{{- if .Dim}}
+  html { filter: brightness({{.Dim}}); background: #000; }
+{{- end}}
+</style>
+</head>
+<body class="{{.Theme}}">
+{{- range .Boards}}
+<h1>{{.Stop}}{{if gt .DistanceM 0}} <small>{{.DistanceM}} m</small>{{end}}</h1>
+{{- if not .AsOf.IsZero}}
+<div class="stale">⚠ SL is not responding — departures as of {{.AsOf.Format "15:04"}} ({{age .AsOf}} min ago)</div>
+{{- end}}
+{{- if .Departures}}
+<table>
+{{- range .Departures}}
+<tr{{with rowClass $.LineColors .}} class="{{.}}"{{end}}{{if $.LineColors}} style="--line: {{lineColor .}}"{{end}}>
+  <td class="line">{{icon .TransportMode}} {{.Line}}</td>
+  <td>{{.Destination}}{{if .Platform}} <span class="plat">plat {{.Platform}}</span>{{end}}</td>
+  <td class="time">{{clock .}}</td>
+  <td class="mins">{{if eq .MinutesLeft 0}}Nu{{else}}{{.MinutesLeft}} min{{end}}</td>
+</tr>
+{{- range notes .}}
+<tr class="note"><td></td><td colspan="3">⚠ {{.}}</td></tr>
+{{- end}}
+{{- end}}
+</table>
+{{- else}}
+<p class="empty">No departures found.</p>
+{{- end}}
+{{- range .Deviations}}
+<div class="dev">⚠ {{if .Line}}[Line {{.Line}}] {{end}}{{.Header}}</div>
+{{- end}}
+{{- end}}
+<footer>Updated {{.Generated}}</footer>
+</body>
+</html>
+`))
+
+// DeparturesHTML writes a standalone, styled HTML departure board. When
+// refreshSecs is positive the page reloads itself on that interval, so a
+// kiosk browser can point at a file regenerated by cron.
+func DeparturesHTML(w io.Writer, title string, boards []HTMLBoard, refreshSecs int, style BoardStyle) error {
+	theme := style.Theme
+	if theme == "" {
+		theme = ThemeDark
+	}
+	dim := ""
+	if style.Brightness > 0 && style.Brightness < 1 {
+		dim = strconv.FormatFloat(style.Brightness, 'f', 2, 64)
+	}
+	return htmlBoardTmpl.Execute(w, struct {
+		Title      string
+		Refresh    int
+		Theme      string
+		LineColors bool
+		Dim        string
+		Boards     []HTMLBoard
+		Generated  string
+	}{
+		Title:      title,
+		Refresh:    refreshSecs,
+		Theme:      theme,
+		LineColors: style.LineColors,
+		Dim:        dim,
+		Boards:     boards,
+		Generated:  time.Now().Format("2006-01-02 15:04:05"),
+	})
+}
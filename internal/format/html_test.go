@@ -0,0 +1,154 @@
+package format
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+func TestDeparturesHTML(t *testing.T) {
+	boards := []HTMLBoard{{
+		Stop: "Medborgarplatsen",
+		Departures: []sl.ParsedDeparture{
+			{Line: "17", TransportMode: "METRO", Destination: "Åkeshov", MinutesLeft: 4,
+				Deviations: []sl.DepartureDeviation{{Message: "Kort tåg"}}},
+			{Line: "55", TransportMode: "BUS", Destination: "<Tanto>", State: "CANCELLED"},
+		},
+		Deviations: []DeviationWarning{{Line: "17", Header: "Delays"}},
+	}}
+
+	var buf bytes.Buffer
+	if err := DeparturesHTML(&buf, "Medborgarplatsen", boards, 30, BoardStyle{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		`<meta http-equiv="refresh" content="30">`,
+		"Åkeshov",
+		"4 min",
+		`class="cancelled"`,
+		"&lt;Tanto&gt;",
+		"[Line 17] Delays",
+		`<tr class="note"><td></td><td colspan="3">⚠ Kort tåg</td></tr>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q", want)
+		}
+	}
+
+	buf.Reset()
+	if err := DeparturesHTML(&buf, "x", boards, 0, BoardStyle{}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "http-equiv") {
+		t.Error("refresh 0 should omit the refresh meta tag")
+	}
+}
+
+func TestDeparturesHTML_Stale(t *testing.T) {
+	boards := []HTMLBoard{{Stop: "Medborgarplatsen", AsOf: time.Now().Add(-12 * time.Minute)}}
+
+	var buf bytes.Buffer
+	if err := DeparturesHTML(&buf, "Medborgarplatsen", boards, 30, BoardStyle{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "(12 min ago)") {
+		t.Errorf("stale board should show its age:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	DeparturesHTML(&buf, "x", []HTMLBoard{{Stop: "x"}}, 0, BoardStyle{})
+	if strings.Contains(buf.String(), `class="stale"`) {
+		t.Error("fresh board should have no stale banner")
+	}
+}
+
+func TestLineBadgeSVG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := LineBadgeSVG(&buf, "17", "METRO"); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, `fill="#4ba946"`) {
+		t.Errorf("expected green line color, got %s", out)
+	}
+	if !strings.Contains(out, ">17</text>") {
+		t.Errorf("expected designation in badge, got %s", out)
+	}
+}
+
+func TestLineColor(t *testing.T) {
+	tests := []struct {
+		designation, mode, want string
+	}{
+		{"14", "METRO", "#e3000b"},
+		{"43", "TRAIN", "#ec619f"},
+		{"4", "BUS", "#0089ca"},
+		{"55", "BUS", "#e3000b"},
+		{"999", "TRAM", "#555555"},
+	}
+	for _, tt := range tests {
+		if got := LineColor(tt.designation, tt.mode); got != tt.want {
+			t.Errorf("LineColor(%q, %q) = %q, want %q", tt.designation, tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestDeparturesHTML_Style(t *testing.T) {
+	boards := []HTMLBoard{{
+		Stop: "Medborgarplatsen",
+		Departures: []sl.ParsedDeparture{
+			{Line: "17", TransportMode: "METRO", Destination: "Åkeshov"},
+			{Line: "55", TransportMode: "BUS", Destination: "Tanto", State: "CANCELLED"},
+		},
+	}}
+
+	var buf bytes.Buffer
+	if err := DeparturesHTML(&buf, "x", boards, 0, BoardStyle{Theme: ThemeNight, LineColors: true, Brightness: 0.4}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		`<body class="night">`,
+		`style="--line: #4ba946"`,
+		`class="colored cancelled" style="--line: #e3000b"`,
+		"filter: brightness(0.40)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q", want)
+		}
+	}
+
+	buf.Reset()
+	DeparturesHTML(&buf, "x", boards, 0, BoardStyle{})
+	out = buf.String()
+	if !strings.Contains(out, `<body class="dark">`) || strings.Contains(out, "--line:") || strings.Contains(out, "filter:") {
+		t.Errorf("zero style should be the plain dark board:\n%s", out)
+	}
+}
+
+func TestLineRoutesSVG(t *testing.T) {
+	routes := []sl.LineRoute{{Line: "17", Mode: "METRO", Direction: 1, Headsign: "Åkeshov", Stops: []sl.LineStop{
+		{GraphStop: sl.GraphStop{ID: "A", Name: "Skarpnäck"}},
+		{GraphStop: sl.GraphStop{ID: "B", Name: "Gamla stan & Slussen"}, Minutes: 14},
+	}}}
+
+	var buf bytes.Buffer
+	if err := LineRoutesSVG(&buf, routes); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, `stroke="#4ba946"`) {
+		t.Errorf("expected the route drawn in the line color, got %s", out)
+	}
+	if strings.Count(out, "<circle") != 2 {
+		t.Errorf("expected a dot per stop, got %s", out)
+	}
+	if !strings.Contains(out, ">Gamla stan &amp; Slussen</text>") || !strings.Contains(out, ">14</text>") {
+		t.Errorf("expected escaped stop names and minutes, got %s", out)
+	}
+}
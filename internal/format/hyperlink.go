@@ -0,0 +1,77 @@
+package format
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+)
+
+// hyperlinks is set by SetHyperlinks: stop names and deviation headers are
+// written as OSC 8 terminal hyperlinks.
+var hyperlinks bool
+
+// SetHyperlinks turns terminal hyperlinks on or off.
+func SetHyperlinks(on bool) {
+	hyperlinks = on
+}
+
+// TerminalHyperlinks reports whether stdout looks like a terminal that
+// renders OSC 8 hyperlinks. FORCE_HYPERLINK=1 or 0 overrides the guess.
+// Terminals that don't understand the sequence mostly swallow it, but a
+// few print it, so only known ones are trusted.
+func TerminalHyperlinks() bool {
+	if v := os.Getenv("FORCE_HYPERLINK"); v != "" {
+		return v != "0"
+	}
+	// color turns itself off when stdout isn't a terminal, for NO_COLOR
+	// and for TERM=dumb: no escape sequences of any kind then.
+	if color.NoColor {
+		return false
+	}
+	switch os.Getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode", "ghostty", "Hyper", "Tabby":
+		return true
+	}
+	for _, name := range []string{"WT_SESSION", "KITTY_WINDOW_ID", "KONSOLE_VERSION", "DOMTERM"} {
+		if os.Getenv(name) != "" {
+			return true
+		}
+	}
+	if v, _ := strconv.Atoi(os.Getenv("VTE_VERSION")); v >= 5000 {
+		return true
+	}
+	term := os.Getenv("TERM")
+	for _, name := range []string{"kitty", "alacritty", "foot", "ghostty"} {
+		if strings.Contains(term, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Hyperlink returns text as a terminal hyperlink to url when hyperlinks
+// are on and url is set, and text unchanged otherwise.
+func Hyperlink(url, text string) string {
+	if !hyperlinks || url == "" {
+		return text
+	}
+	return "\x1b]8;;" + url + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
+
+// PadLink is Hyperlink with text padded to width, padding outside the
+// link, since escape sequences would throw off fmt's own padding.
+func PadLink(url, text string, width int) string {
+	return Hyperlink(url, text) + strings.Repeat(" ", max(width-utf8.RuneCountInString(text), 0))
+}
+
+// MapURL links to a point on OpenStreetMap.
+func MapURL(lat, lon float64) string {
+	if lat == 0 && lon == 0 {
+		return ""
+	}
+	return fmt.Sprintf("https://www.openstreetmap.org/?mlat=%.5f&mlon=%.5f#map=17/%.5f/%.5f", lat, lon, lat, lon)
+}
@@ -0,0 +1,64 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestHyperlink(t *testing.T) {
+	if got := Hyperlink("https://sl.se", "SL"); got != "SL" {
+		t.Errorf("Hyperlink with hyperlinks off = %q", got)
+	}
+
+	SetHyperlinks(true)
+	defer SetHyperlinks(false)
+
+	if got, want := Hyperlink("https://sl.se", "SL"), "\x1b]8;;https://sl.se\x1b\\SL\x1b]8;;\x1b\\"; got != want {
+		t.Errorf("Hyperlink = %q, want %q", got, want)
+	}
+	if got := Hyperlink("", "SL"); got != "SL" {
+		t.Errorf("Hyperlink without a URL = %q", got)
+	}
+	if got, want := PadLink("https://sl.se", "Söder", 8), Hyperlink("https://sl.se", "Söder")+"   "; got != want {
+		t.Errorf("PadLink = %q, want %q", got, want)
+	}
+}
+
+func TestMapURL(t *testing.T) {
+	want := "https://www.openstreetmap.org/?mlat=59.31422&mlon=18.07353#map=17/59.31422/18.07353"
+	if got := MapURL(59.314223, 18.073533); got != want {
+		t.Errorf("MapURL = %q, want %q", got, want)
+	}
+	if got := MapURL(0, 0); got != "" {
+		t.Errorf("MapURL without coordinates = %q", got)
+	}
+}
+
+func TestTerminalHyperlinks(t *testing.T) {
+	noColor := color.NoColor
+	defer func() { color.NoColor = noColor }()
+	color.NoColor = false
+	for _, name := range []string{"FORCE_HYPERLINK", "TERM_PROGRAM", "WT_SESSION", "KITTY_WINDOW_ID", "KONSOLE_VERSION", "DOMTERM", "VTE_VERSION"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("TERM", "xterm-256color")
+
+	if TerminalHyperlinks() {
+		t.Error("hyperlinks in an unknown terminal")
+	}
+	t.Setenv("VTE_VERSION", "6003")
+	if !TerminalHyperlinks() {
+		t.Error("VTE 0.60 supports hyperlinks")
+	}
+	t.Setenv("FORCE_HYPERLINK", "0")
+	if TerminalHyperlinks() {
+		t.Error("FORCE_HYPERLINK=0 should turn hyperlinks off")
+	}
+	t.Setenv("FORCE_HYPERLINK", "1")
+	t.Setenv("VTE_VERSION", "")
+	color.NoColor = true
+	if !TerminalHyperlinks() {
+		t.Error("FORCE_HYPERLINK=1 should turn hyperlinks on")
+	}
+}
@@ -0,0 +1,95 @@
+package format
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// metric is one departure metric, written the same way as Prometheus text
+// and as an InfluxDB field.
+type metric struct {
+	name, help string
+	value      func(StopDeparture) int
+}
+
+var departureMetrics = []metric{
+	{"minutes_left", "Minutes until the departure leaves.", func(d StopDeparture) int { return d.MinutesLeft }},
+	{"delay_seconds", "How far the expected time is behind the scheduled time.", delaySeconds},
+	{"cancelled", "1 if the departure is cancelled.", func(d StopDeparture) int {
+		if d.State == "CANCELLED" {
+			return 1
+		}
+		return 0
+	}},
+}
+
+func delaySeconds(d StopDeparture) int {
+	if d.Scheduled.IsZero() || d.Expected.IsZero() {
+		return 0
+	}
+	return int(d.Expected.Sub(d.Scheduled).Seconds())
+}
+
+// metricTags are the labels identifying a departure. The scheduled time
+// keeps two departures of the same line and destination apart.
+func metricTags(d StopDeparture) [][2]string {
+	return [][2]string{
+		{"site_id", strconv.Itoa(d.SiteID)},
+		{"stop", d.Stop},
+		{"line", d.Line},
+		{"mode", d.TransportMode},
+		{"direction_code", strconv.Itoa(d.DirectionCode)},
+		{"destination", d.Destination},
+		{"scheduled", clock(d.Scheduled)},
+	}
+}
+
+// Prometheus writes departures in the Prometheus text exposition format,
+// as a gauge per metric named sl_departure_<metric>, for a push gateway
+// or node_exporter's textfile collector.
+func Prometheus(w io.Writer, rows []StopDeparture) error {
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	var b strings.Builder
+	for _, m := range departureMetrics {
+		name := "sl_departure_" + m.name
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", name, m.help, name)
+		for _, row := range rows {
+			labels := make([]string, 0, 7)
+			for _, t := range metricTags(row) {
+				labels = append(labels, t[0]+`="`+escape.Replace(t[1])+`"`)
+			}
+			fmt.Fprintf(&b, "%s{%s} %d\n", name, strings.Join(labels, ","), m.value(row))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Influx writes departures in InfluxDB line protocol, one sl_departure
+// point per departure stamped at, with the metrics as integer fields.
+func Influx(w io.Writer, rows []StopDeparture, at time.Time) error {
+	escape := strings.NewReplacer(`,`, `\,`, `=`, `\=`, ` `, `\ `, "\n", " ")
+	var b strings.Builder
+	for _, row := range rows {
+		b.WriteString("sl_departure")
+		for _, t := range metricTags(row) {
+			// Influx rejects empty tag values; leave the tag out instead.
+			if t[1] != "" {
+				b.WriteString("," + t[0] + "=" + escape.Replace(t[1]))
+			}
+		}
+		for i, m := range departureMetrics {
+			sep := ","
+			if i == 0 {
+				sep = " "
+			}
+			fmt.Fprintf(&b, "%s%s=%di", sep, m.name, m.value(row))
+		}
+		fmt.Fprintf(&b, " %d\n", at.UnixNano())
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
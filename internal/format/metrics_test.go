@@ -0,0 +1,56 @@
+package format
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+func metricRows() []StopDeparture {
+	at := time.Date(2024, 3, 1, 7, 5, 0, 0, time.UTC) // 08:05 in Stockholm
+	return []StopDeparture{
+		{Stop: "Medborgarplatsen", SiteID: 9191, ParsedDeparture: sl.ParsedDeparture{
+			Line: "17", TransportMode: "METRO", DirectionCode: 1, Destination: "Åkeshov",
+			Scheduled: at, Expected: at.Add(90 * time.Second), MinutesLeft: 6, State: "EXPECTED",
+		}},
+		{Stop: "Medborgarplatsen", SiteID: 9191, ParsedDeparture: sl.ParsedDeparture{
+			Line: "55", TransportMode: "BUS", DirectionCode: 2, Destination: `Tanto "strand", norra`,
+			Scheduled: at, MinutesLeft: 5, State: "CANCELLED",
+		}},
+	}
+}
+
+func TestPrometheus(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Prometheus(&buf, metricRows()); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"# TYPE sl_departure_minutes_left gauge\n",
+		`sl_departure_minutes_left{site_id="9191",stop="Medborgarplatsen",line="17",mode="METRO",direction_code="1",destination="Åkeshov",scheduled="08:05"} 6` + "\n",
+		`sl_departure_delay_seconds{site_id="9191",stop="Medborgarplatsen",line="17",mode="METRO",direction_code="1",destination="Åkeshov",scheduled="08:05"} 90` + "\n",
+		`sl_departure_cancelled{site_id="9191",stop="Medborgarplatsen",line="55",mode="BUS",direction_code="2",destination="Tanto \"strand\", norra",scheduled="08:05"} 1` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestInflux(t *testing.T) {
+	var buf bytes.Buffer
+	at := time.Unix(1709276400, 0)
+	if err := Influx(&buf, metricRows(), at); err != nil {
+		t.Fatal(err)
+	}
+	want := `sl_departure,site_id=9191,stop=Medborgarplatsen,line=17,mode=METRO,direction_code=1,destination=Åkeshov,scheduled=08:05 minutes_left=6i,delay_seconds=90i,cancelled=0i 1709276400000000000
+sl_departure,site_id=9191,stop=Medborgarplatsen,line=55,mode=BUS,direction_code=2,destination=Tanto\ "strand"\,\ norra,scheduled=08:05 minutes_left=5i,delay_seconds=0i,cancelled=1i 1709276400000000000
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
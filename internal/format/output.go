@@ -1,14 +1,13 @@
 package format
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
-	"github.com/glundgren93/sl-cli/internal/api"
-	"github.com/glundgren93/sl-cli/internal/model"
+	"github.com/glundgren93/sl-cli/internal/i18n"
+	"github.com/glundgren93/sl-cli/pkg/sl"
 )
 
 var (
@@ -16,6 +15,7 @@ var (
 	green     = color.New(color.FgGreen, color.Bold)
 	yellow    = color.New(color.FgYellow)
 	red       = color.New(color.FgRed)
+	redBold   = color.New(color.FgRed, color.Bold)
 	cyan      = color.New(color.FgCyan)
 	dim       = color.New(color.Faint)
 	busIcon   = "🚌"
@@ -43,29 +43,21 @@ func ModeIcon(mode string) string {
 	}
 }
 
-// JSON outputs any value as formatted JSON.
-func JSON(v any) error {
-	enc := json.NewEncoder(os.Stdout)
-	enc.SetIndent("", "  ")
-	enc.SetEscapeHTML(false)
-	return enc.Encode(v)
-}
-
 // Departures prints departures in human-readable format.
-func Departures(deps []model.ParsedDeparture, stopName string) {
+func Departures(deps []sl.ParsedDeparture, stopName string, showScheduled bool) {
 	if len(deps) == 0 {
-		dim.Println("No departures found.")
+		dim.Fprintln(Stdout(), "No departures found.")
 		return
 	}
 
-	bold.Printf("📍 %s\n", stopName)
-	fmt.Println(strings.Repeat("─", 60))
+	bold.Fprintf(Stdout(), "📍 %s\n", stopName)
+	fmt.Fprintln(Stdout(), strings.Repeat("─", 60))
 
 	type lineKey struct {
 		mode string
 		line string
 	}
-	groups := make(map[lineKey][]model.ParsedDeparture)
+	groups := make(map[lineKey][]sl.ParsedDeparture)
 	var order []lineKey
 
 	for _, d := range deps {
@@ -79,26 +71,164 @@ func Departures(deps []model.ParsedDeparture, stopName string) {
 	for _, key := range order {
 		lineDeps := groups[key]
 		icon := ModeIcon(key.mode)
-		bold.Printf("\n%s Line %s", icon, key.line)
+		bold.Fprintf(Stdout(), "\n%s Line %s", icon, key.line)
 		if lineDeps[0].GroupOfLines != "" {
-			dim.Printf(" (%s)", lineDeps[0].GroupOfLines)
+			dim.Fprintf(Stdout(), " (%s)", lineDeps[0].GroupOfLines)
 		}
-		fmt.Println()
+		fmt.Fprintln(Stdout())
 
 		for _, d := range lineDeps {
-			timeStr := formatTime(d)
+			timeStr := formatTime(d, showScheduled)
 			stateStr := formatState(d.State)
+			if d.ScheduledOnly {
+				stateStr = dim.Sprint("scheduled")
+			}
 			platform := ""
 			if d.Platform != "" {
 				platform = dim.Sprintf(" [plat %s]", d.Platform)
 			}
-			fmt.Printf("  → %-25s %s %s%s\n", d.Destination, timeStr, stateStr, platform)
+			fmt.Fprintf(Stdout(), "  %s %-25s %s %s%s\n", catchMarker(d.Catchable), d.Destination, timeStr, stateStr, platform)
+			for _, note := range departureNotes(d) {
+				yellow.Fprintf(Stdout(), "     ⚠️  %s\n", note)
+			}
 		}
 	}
-	fmt.Println()
+	fmt.Fprintln(Stdout())
 }
 
-func formatTime(d model.ParsedDeparture) string {
+// NearbyDeparture is a departure on a board merging several nearby stops,
+// with the stop it leaves from and its distance.
+type NearbyDeparture struct {
+	Stop      string `json:"stop"`
+	SiteID    int    `json:"site_id"`
+	DistanceM int    `json:"distance_m"`
+	sl.ParsedDeparture
+}
+
+// MergedDepartures prints the departures of several nearby stops as one
+// board in the order given, each with its stop and distance.
+func MergedDepartures(deps []NearbyDeparture, showScheduled bool) {
+	if len(deps) == 0 {
+		dim.Fprintln(Stdout(), "No departures found.")
+		return
+	}
+
+	stops := map[int]bool{}
+	for _, d := range deps {
+		stops[d.SiteID] = true
+	}
+	bold.Fprintf(Stdout(), "📍 %d nearby stop(s)\n", len(stops))
+	fmt.Fprintln(Stdout(), strings.Repeat("─", 60))
+
+	for _, d := range deps {
+		stateStr := formatState(d.State)
+		if d.ScheduledOnly {
+			stateStr = dim.Sprint("scheduled")
+		}
+		fmt.Fprintf(Stdout(), "  %s %s %-5s %-25s %s %s", catchMarker(d.Catchable), ModeIcon(d.TransportMode), d.Line, d.Destination, formatTime(d.ParsedDeparture, showScheduled), stateStr)
+		dim.Fprintf(Stdout(), "  %s (%dm)", d.Stop, d.DistanceM)
+		if d.Platform != "" {
+			dim.Fprintf(Stdout(), " [plat %s]", d.Platform)
+		}
+		fmt.Fprintln(Stdout())
+		for _, note := range departureNotes(d.ParsedDeparture) {
+			yellow.Fprintf(Stdout(), "     ⚠️  %s\n", note)
+		}
+	}
+	fmt.Fprintln(Stdout())
+}
+
+// departureNotes lists the notes shown under a departure: its vehicle
+// notes, then its own deviation messages, minus short-train notices the
+// vehicle notes already cover.
+func departureNotes(d sl.ParsedDeparture) []string {
+	notes := d.VehicleNotes
+	for _, dev := range d.Deviations {
+		if dev.Message == "" || len(d.VehicleNotes) > 0 && sl.IsShortTrainNotice(dev.Message) {
+			continue
+		}
+		notes = append(notes, dev.Message)
+	}
+	return notes
+}
+
+// Directions prints the destinations served by each direction of each line.
+func Directions(dirs []sl.LineDirection, stopName string) {
+	if len(dirs) == 0 {
+		dim.Fprintln(Stdout(), "No departures found.")
+		return
+	}
+
+	bold.Fprintf(Stdout(), "📍 %s\n", stopName)
+	fmt.Fprintln(Stdout(), strings.Repeat("─", 60))
+
+	prev := ""
+	for _, d := range dirs {
+		if key := d.TransportMode + "/" + d.Line; key != prev {
+			bold.Fprintf(Stdout(), "\n%s Line %s\n", ModeIcon(d.TransportMode), d.Line)
+			prev = key
+		}
+		cyan.Fprintf(Stdout(), "  --direction %d", d.DirectionCode)
+		fmt.Fprintf(Stdout(), "  → %s\n", strings.Join(d.Destinations, ", "))
+	}
+	fmt.Fprintln(Stdout())
+}
+
+// catchMarker replaces the departure arrow when catchability is known.
+func catchMarker(c sl.Catchability) string {
+	switch c {
+	case sl.CatchYes:
+		return "✅"
+	case sl.CatchMarginal:
+		return "⚠️"
+	case sl.CatchNo:
+		return "❌"
+	}
+	return "→"
+}
+
+// legStopHints describes the platforms and entrances of a leg's stops,
+// e.g. "Slussen: platform 2, entrance Götgatan → T-Centralen: platform 1",
+// or returns "" when the planner named none. Walks only name entrances;
+// their platforms are those of the rides either side.
+func legStopHints(leg sl.JourneyLeg, ride bool) string {
+	var stops []string
+	for _, s := range []*sl.JourneyStop{leg.Origin, leg.Destination} {
+		if s == nil {
+			continue
+		}
+		var hints []string
+		if s.Platform != "" && ride {
+			hints = append(hints, i18n.T(i18n.Platform, s.Platform))
+		}
+		if s.Entrance != "" {
+			hints = append(hints, i18n.T(i18n.Entrance, s.Entrance))
+		}
+		if len(hints) > 0 {
+			stops = append(stops, s.Name+": "+strings.Join(hints, ", "))
+		}
+	}
+	return strings.Join(stops, " → ")
+}
+
+// formatTime shows when a departure leaves: a countdown, or with
+// showScheduled its timetable time. Departures more than a minute off
+// schedule get the difference, as in "12:05 (+3)".
+func formatTime(d sl.ParsedDeparture, showScheduled bool) string {
+	if d.ScheduledOnly {
+		return cyan.Sprint(d.Display)
+	}
+	t := formatCountdown(d)
+	if showScheduled && !d.Scheduled.IsZero() {
+		t = cyan.Sprint(sl.StockholmTime(d.Scheduled).Format("15:04"))
+	}
+	if delay := sl.DelayMinutes(d); d.State != "CANCELLED" && (delay > 1 || delay < -1) {
+		t += red.Sprintf(" (%+d)", delay)
+	}
+	return t
+}
+
+func formatCountdown(d sl.ParsedDeparture) string {
 	if d.Display == "Nu" || d.MinutesLeft == 0 {
 		return green.Sprint("NOW")
 	}
@@ -124,104 +254,189 @@ func formatState(state string) string {
 // DeviationWarning is a simplified deviation for inline display.
 // Shared between cmd and format packages to avoid JSON round-trip hacks.
 type DeviationWarning struct {
-	Line    string `json:"line,omitempty"`
-	Header  string `json:"header"`
-	Details string `json:"details,omitempty"`
-	Scope   string `json:"scope,omitempty"`
+	Line     string `json:"line,omitempty"`
+	Header   string `json:"header"`
+	Details  string `json:"details,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+	Severity string `json:"severity,omitempty"`
+	URL      string `json:"url,omitempty"`
 }
 
 // DeviationWarnings prints inline deviation warnings below departures.
 func DeviationWarnings(warnings []DeviationWarning) {
+	printWarnings(i18n.DisruptionsLines, warnings)
+}
+
+// StopDeviationWarnings prints disruptions scoped to a stop below its line listing.
+func StopDeviationWarnings(warnings []DeviationWarning) {
+	printWarnings(i18n.DisruptionsAtStop, warnings)
+}
+
+// printWarnings prints warnings under a heading given by a catalog key.
+func printWarnings(headingKey string, warnings []DeviationWarning) {
 	if len(warnings) == 0 {
 		return
 	}
 
-	yellow.Printf("⚠️  %d disruption(s) affecting these lines:\n", len(warnings))
+	yellow.Fprintf(Stdout(), "⚠️  %s\n", i18n.T(headingKey, len(warnings)))
 	for _, w := range warnings {
 		linePrefix := ""
 		if w.Line != "" {
-			linePrefix = fmt.Sprintf("[Line %s] ", w.Line)
+			linePrefix = i18n.T(i18n.LinePrefix, w.Line)
 		}
-		yellow.Printf("  • %s%s\n", linePrefix, w.Header)
+		severityColor(w.Severity).Fprintf(Stdout(), "  • %s%s\n", linePrefix, Hyperlink(w.URL, w.Header))
 		if w.Details != "" {
-			dim.Printf("    %s\n", w.Details)
+			dim.Fprintf(Stdout(), "    %s\n", w.Details)
 		}
 	}
-	fmt.Println()
+	fmt.Fprintln(Stdout())
 }
 
-// NearbyStops prints nearby stops in human-readable format.
-func NearbyStops(stops []api.SiteWithDistance) {
+// severityColor maps a severity name to its display color.
+func severityColor(severity string) *color.Color {
+	switch severity {
+	case "critical":
+		return redBold
+	case "major":
+		return red
+	default:
+		return yellow
+	}
+}
+
+// TypeTags renders stop area types as dimmed tags, e.g. " [METROSTN]".
+func TypeTags(types []string) string {
+	if len(types) == 0 {
+		return ""
+	}
+	return dim.Sprintf(" [%s]", strings.Join(types, ","))
+}
+
+// NearbyStops prints nearby stops in human-readable format. An interchange
+// notes how many stops it clusters, or with expand lists them.
+func NearbyStops(stops []sl.SiteWithDistance, expand bool) {
 	if len(stops) == 0 {
-		dim.Println("No stops found nearby.")
+		dim.Fprintln(Stdout(), "No stops found nearby.")
 		return
 	}
 
-	bold.Println("📍 Nearby stops")
-	fmt.Println(strings.Repeat("─", 60))
+	bold.Fprintln(Stdout(), "📍 Nearby stops")
+	fmt.Fprintln(Stdout(), strings.Repeat("─", 60))
 
 	for i, s := range stops {
 		distStr := fmt.Sprintf("%dm", int(s.DistanceKm*1000))
-		bold.Printf("  %d. ", i+1)
-		fmt.Printf("%-35s ", s.Site.Name)
-		cyan.Printf("%-8s", distStr)
-		dim.Printf(" (id:%d)\n", s.Site.ID)
+		bold.Fprintf(Stdout(), "  %d. ", i+1)
+		fmt.Fprint(Stdout(), PadLink(MapURL(s.Site.Lat, s.Site.Lon), s.Site.Name, 35), " ")
+		cyan.Fprintf(Stdout(), "%-8s", distStr)
+		dim.Fprintf(Stdout(), " (id:%d)", s.Site.ID)
+		if len(s.Colocated) > 0 && !expand {
+			dim.Fprintf(Stdout(), " (+%d co-located)", len(s.Colocated))
+		}
+		fmt.Fprintln(Stdout(), TypeTags(s.Types))
+		if !expand {
+			continue
+		}
+		for _, m := range s.Colocated {
+			dim.Fprint(Stdout(), "     ↳ ")
+			fmt.Fprint(Stdout(), PadLink(MapURL(m.Site.Lat, m.Site.Lon), m.Site.Name, 32), " ")
+			cyan.Fprintf(Stdout(), "%-8s", fmt.Sprintf("%dm", m.DistanceM))
+			dim.Fprintf(Stdout(), " (id:%d)", m.Site.ID)
+			fmt.Fprintln(Stdout(), TypeTags(m.Types))
+		}
 	}
-	fmt.Println()
+	fmt.Fprintln(Stdout())
+}
+
+// DeviationPage describes which slice of a deviation listing is being shown.
+type DeviationPage struct {
+	Total  int  // deviations matching the filters
+	Offset int  // index of the first shown deviation
+	Full   bool // print every message variant with details
 }
 
-// Deviations prints deviations in human-readable format.
-func Deviations(devs []model.Deviation) {
-	if len(devs) == 0 {
-		green.Println("✓ No deviations found.")
+// Deviations prints deviations in human-readable format: one line each, or
+// with details per message variant when page.Full is set.
+func Deviations(devs []sl.Deviation, page DeviationPage) {
+	if page.Total == 0 {
+		green.Fprintln(Stdout(), "✓ No deviations found.")
 		return
 	}
 
-	bold.Printf("⚠️  %d deviation(s)\n", len(devs))
-	fmt.Println(strings.Repeat("─", 60))
+	bold.Fprintf(Stdout(), "⚠️  %d deviation(s)", page.Total)
+	if len(devs) < page.Total {
+		if len(devs) == 0 {
+			dim.Fprintf(Stdout(), " — none on this page")
+		} else {
+			dim.Fprintf(Stdout(), " — showing %d–%d", page.Offset+1, page.Offset+len(devs))
+		}
+	}
+	fmt.Fprintln(Stdout())
+	fmt.Fprintln(Stdout(), strings.Repeat("─", 60))
+
+	if !page.Full {
+		for _, d := range devs {
+			msg := deviationMessage(d)
+			severityColor(d.Severity).Fprintf(Stdout(), "  • %s", Hyperlink(msg.Weblink, msg.Header))
+			if msg.ScopeAlias != "" {
+				dim.Fprintf(Stdout(), " — %s", msg.ScopeAlias)
+			}
+			fmt.Fprintln(Stdout())
+		}
+		fmt.Fprintln(Stdout())
+		return
+	}
 
 	for _, d := range devs {
 		for _, msg := range d.MessageVariants {
 			if msg.Language != "sv" && msg.Language != "en" {
 				continue
 			}
-			yellow.Printf("\n  %s\n", msg.Header)
+			c := severityColor(d.Severity)
+			c.Fprintf(Stdout(), "\n  %s", Hyperlink(msg.Weblink, msg.Header))
+			if d.Severity != "" {
+				dim.Fprintf(Stdout(), "  [%s]", d.Severity)
+			}
+			fmt.Fprintln(Stdout())
 			if msg.ScopeAlias != "" {
-				dim.Printf("  Affects: %s\n", msg.ScopeAlias)
+				dim.Fprintf(Stdout(), "  Affects: %s\n", msg.ScopeAlias)
 			}
 			if msg.Details != "" {
 				details := msg.Details
 				if len(details) > 200 {
 					details = details[:200] + "..."
 				}
-				fmt.Printf("  %s\n", details)
+				fmt.Fprintf(Stdout(), "  %s\n", details)
 			}
 		}
 	}
-	fmt.Println()
+	fmt.Fprintln(Stdout())
 }
 
-// Trips prints journey plans in human-readable format.
-func Trips(journeys []model.JourneyTrip) {
+// Trips prints journey plans in human-readable format. Legs that don't permit
+// what carry brings along are flagged.
+func Trips(journeys []sl.JourneyTrip, carry sl.Carriage) {
 	if len(journeys) == 0 {
-		dim.Println("No routes found.")
+		dim.Fprintln(Stdout(), i18n.T(i18n.NoRoutes))
 		return
 	}
 
-	bold.Printf("🗺️  %d route(s) found\n", len(journeys))
-	fmt.Println(strings.Repeat("─", 60))
+	bold.Fprintf(Stdout(), "🗺️  %s\n", i18n.T(i18n.RoutesFound, len(journeys)))
+	fmt.Fprintln(Stdout(), strings.Repeat("─", 60))
 
 	for i, j := range journeys {
 		durationMin := j.TripRtDuration / 60
 		if durationMin == 0 {
 			durationMin = j.TripDuration / 60
 		}
-		bold.Printf("\nRoute %d", i+1)
-		cyan.Printf(" — %d min", durationMin)
+		bold.Fprintf(Stdout(), "\n%s", i18n.T(i18n.Route, i+1))
+		cyan.Fprintf(Stdout(), " — %s", i18n.T(i18n.Minutes, durationMin))
+		if j.BufferedDuration > 0 {
+			yellow.Fprintf(Stdout(), " / %s", i18n.T(i18n.WithBuffer, j.BufferedDuration/60))
+		}
 		if j.Interchanges > 0 {
-			dim.Printf(" (%d change(s))", j.Interchanges)
+			dim.Fprintf(Stdout(), " (%s)", i18n.T(i18n.Changes, j.Interchanges))
 		}
-		fmt.Println()
+		fmt.Fprintln(Stdout())
 
 		for _, leg := range j.Legs {
 			origin := "?"
@@ -261,17 +476,65 @@ func Trips(journeys []model.JourneyTrip) {
 						icon = tramIcon
 					}
 				}
-				fmt.Printf("  %s %s: %s → %s (%s – %s)\n", icon, leg.Transport.Name, origin, dest, depTime, arrTime)
+				fmt.Fprintf(Stdout(), "  %s %s: %s → %s (%s – %s)\n", icon, leg.Transport.Name, origin, dest, depTime, arrTime)
+				for _, note := range sl.CarriageNotes(leg, carry) {
+					red.Fprintf(Stdout(), "     🚫 %s\n", note)
+				}
+				for _, s := range []*sl.JourneyStop{leg.Origin, leg.Destination} {
+					if s == nil || s.Accessibility == nil {
+						continue
+					}
+					notes, lacking := s.Accessibility.Notes()
+					c := dim
+					if lacking {
+						c = red
+					}
+					c.Fprintf(Stdout(), "     ♿ %s: %s\n", s.Name, strings.Join(notes, ", "))
+				}
 			} else {
 				walkMin := leg.Duration / 60
 				if walkMin == 0 {
 					walkMin = 1
 				}
-				fmt.Printf("  🚶 Walk: %s → %s (%d min)\n", origin, dest, walkMin)
+				fmt.Fprintf(Stdout(), "  🚶 %s: %s → %s (%s)\n", i18n.T(i18n.Walk), origin, dest, i18n.T(i18n.Minutes, walkMin))
+			}
+			if hints := legStopHints(leg, leg.Transport != nil && leg.Transport.Name != ""); hints != "" {
+				dim.Fprintf(Stdout(), "     🚉 %s\n", hints)
+			}
+		}
+		for _, o := range j.ElevatorOutages {
+			redBold.Fprintf(Stdout(), "  ♿ %s\n", i18n.T(i18n.ElevatorOut, o.Station, Hyperlink(o.URL, o.Header)))
+		}
+		for _, x := range sl.Interchanges(j) {
+			if x.RiskLevel != "" {
+				riskColor(x.RiskLevel).Fprintf(Stdout(), "  🔀 %s\n", changeRisk(x))
 			}
 		}
 	}
-	fmt.Println()
+	fmt.Fprintln(Stdout())
+}
+
+// changeRisk describes an interchange's risk level and slack.
+func changeRisk(x sl.Interchange) string {
+	level := i18n.T(map[string]string{
+		sl.RiskSafe:  i18n.RiskSafe,
+		sl.RiskTight: i18n.RiskTight,
+		sl.RiskRisky: i18n.RiskRisky,
+	}[x.RiskLevel])
+	if x.SlackMin < 0 {
+		return i18n.T(i18n.ChangeShort, x.At, level, -x.SlackMin)
+	}
+	return i18n.T(i18n.ChangeSpare, x.At, level, x.SlackMin)
+}
+
+func riskColor(level string) *color.Color {
+	switch level {
+	case sl.RiskSafe:
+		return green
+	case sl.RiskTight:
+		return yellow
+	}
+	return red
 }
 
 func formatISOTime(isoTime string) string {
@@ -282,13 +545,13 @@ func formatISOTime(isoTime string) string {
 }
 
 // Lines prints lines in human-readable format.
-func Lines(lines []model.Line) {
+func Lines(lines []sl.Line) {
 	if len(lines) == 0 {
-		dim.Println("No lines found.")
+		dim.Fprintln(Stdout(), "No lines found.")
 		return
 	}
 
-	groups := make(map[string][]model.Line)
+	groups := make(map[string][]sl.Line)
 	var modes []string
 	for _, l := range lines {
 		if _, exists := groups[l.TransportMode]; !exists {
@@ -297,20 +560,20 @@ func Lines(lines []model.Line) {
 		groups[l.TransportMode] = append(groups[l.TransportMode], l)
 	}
 
-	bold.Printf("Found %d line(s)\n", len(lines))
-	fmt.Println(strings.Repeat("─", 60))
+	bold.Fprintf(Stdout(), "Found %d line(s)\n", len(lines))
+	fmt.Fprintln(Stdout(), strings.Repeat("─", 60))
 
 	for _, mode := range modes {
 		icon := ModeIcon(mode)
-		bold.Printf("\n%s %s\n", icon, mode)
+		bold.Fprintf(Stdout(), "\n%s %s\n", icon, mode)
 		modeLines := groups[mode]
 		lineDesigs := make([]string, 0, len(modeLines))
 		for _, l := range modeLines {
 			lineDesigs = append(lineDesigs, l.Designation)
 		}
-		fmt.Printf("  %s\n", strings.Join(lineDesigs, ", "))
+		fmt.Fprintf(Stdout(), "  %s\n", strings.Join(lineDesigs, ", "))
 	}
-	fmt.Println()
+	fmt.Fprintln(Stdout())
 }
 
 // StopInfoLine is the data for a single line serving a stop (used by StopInfo formatter).
@@ -322,16 +585,17 @@ type StopInfoLine struct {
 }
 
 // StopInfo prints a summary of lines serving a stop.
-func StopInfo(stopName string, siteID int, lines []StopInfoLine) {
+func StopInfo(stopName string, siteID int, types []string, lines []StopInfoLine) {
 	if len(lines) == 0 {
-		dim.Printf("No lines currently serving %s.\n", stopName)
-		dim.Println("(This uses real-time departures — try again during operating hours)")
+		dim.Fprintf(Stdout(), "No lines currently serving %s.\n", stopName)
+		dim.Fprintln(Stdout(), "(This uses real-time departures — try again during operating hours)")
 		return
 	}
 
-	bold.Printf("📍 %s", stopName)
-	dim.Printf(" (id:%d)\n", siteID)
-	fmt.Println(strings.Repeat("─", 60))
+	bold.Fprintf(Stdout(), "📍 %s", stopName)
+	dim.Fprintf(Stdout(), " (id:%d)", siteID)
+	fmt.Fprintln(Stdout(), TypeTags(types))
+	fmt.Fprintln(Stdout(), strings.Repeat("─", 60))
 
 	// Group by transport mode
 	groups := make(map[string][]StopInfoLine)
@@ -345,57 +609,457 @@ func StopInfo(stopName string, siteID int, lines []StopInfoLine) {
 
 	for _, mode := range modes {
 		icon := ModeIcon(mode)
-		bold.Printf("\n%s %s\n", icon, mode)
+		bold.Fprintf(Stdout(), "\n%s %s\n", icon, mode)
 		for _, l := range groups[mode] {
-			fmt.Printf("  Line %-6s", l.Designation)
+			fmt.Fprintf(Stdout(), "  Line %-6s", l.Designation)
 			if l.GroupOfLines != "" {
-				dim.Printf(" (%s)", l.GroupOfLines)
+				dim.Fprintf(Stdout(), " (%s)", l.GroupOfLines)
 			}
 			if len(l.Destinations) > 0 {
-				dim.Printf("  → %s", strings.Join(l.Destinations, ", "))
+				dim.Fprintf(Stdout(), "  → %s", strings.Join(l.Destinations, ", "))
 			}
-			fmt.Println()
+			fmt.Fprintln(Stdout())
 		}
 	}
-	fmt.Println()
+	fmt.Fprintln(Stdout())
 }
 
 // NearbyStopWithLines is a nearby stop enriched with line information.
 type NearbyStopWithLines struct {
-	Stop      string        `json:"stop"`
-	SiteID    int           `json:"site_id"`
-	DistanceM int           `json:"distance_m"`
+	Stop      string         `json:"stop"`
+	SiteID    int            `json:"site_id"`
+	DistanceM int            `json:"distance_m"`
+	Types     []string       `json:"types,omitempty"`
 	Lines     []StopInfoLine `json:"lines"`
+
+	// NextDepartureMin is minutes until the stop's soonest departure (nil if none).
+	NextDepartureMin *int `json:"next_departure_min,omitempty"`
+
+	// Colocated are the other stops of the interchange, each with its own
+	// lines; Lines above combines them all.
+	Colocated []NearbyStopWithLines `json:"colocated,omitempty"`
 }
 
-// NearbyStopsWithLines prints nearby stops with their serving lines.
-func NearbyStopsWithLines(stops []NearbyStopWithLines) {
+// NearbyStopsWithLines prints nearby stops with their serving lines. With
+// expand an interchange also lists each of its stops and their lines.
+func NearbyStopsWithLines(stops []NearbyStopWithLines, expand bool) {
 	if len(stops) == 0 {
-		dim.Println("No stops found nearby.")
+		dim.Fprintln(Stdout(), "No stops found nearby.")
 		return
 	}
 
-	bold.Println("📍 Nearby stops")
-	fmt.Println(strings.Repeat("─", 60))
+	bold.Fprintln(Stdout(), "📍 Nearby stops")
+	fmt.Fprintln(Stdout(), strings.Repeat("─", 60))
 
 	for i, s := range stops {
-		bold.Printf("\n  %d. %s", i+1, s.Stop)
-		cyan.Printf("  %dm", s.DistanceM)
-		dim.Printf("  (id:%d)\n", s.SiteID)
+		bold.Fprintf(Stdout(), "\n  %d. %s", i+1, s.Stop)
+		cyan.Fprintf(Stdout(), "  %dm", s.DistanceM)
+		if s.NextDepartureMin != nil {
+			yellow.Fprintf(Stdout(), "  next in %d min", *s.NextDepartureMin)
+		}
+		dim.Fprintf(Stdout(), "  (id:%d)", s.SiteID)
+		if len(s.Colocated) > 0 && !expand {
+			dim.Fprintf(Stdout(), "  (+%d co-located)", len(s.Colocated))
+		}
+		fmt.Fprintln(Stdout(), TypeTags(s.Types))
 
-		if len(s.Lines) == 0 {
-			dim.Println("     No departures right now")
+		if !expand || len(s.Colocated) == 0 {
+			stopLines(s.Lines, "     ")
 			continue
 		}
+		for _, m := range append([]NearbyStopWithLines{s}, s.Colocated...) {
+			dim.Fprint(Stdout(), "     ↳ ")
+			fmt.Fprint(Stdout(), m.Stop)
+			cyan.Fprintf(Stdout(), "  %dm", m.DistanceM)
+			dim.Fprintf(Stdout(), "  (id:%d)\n", m.SiteID)
+			stopLines(m.Lines, "       ")
+		}
+	}
+	fmt.Fprintln(Stdout())
+}
+
+// stopLines prints the lines serving a stop, one per row.
+func stopLines(lines []StopInfoLine, indent string) {
+	if len(lines) == 0 {
+		dim.Fprintf(Stdout(), "%sNo departures right now\n", indent)
+		return
+	}
+	for _, l := range lines {
+		fmt.Fprintf(Stdout(), "%s%s %-6s", indent, ModeIcon(l.TransportMode), l.Designation)
+		if len(l.Destinations) > 0 {
+			dim.Fprintf(Stdout(), " → %s", strings.Join(l.Destinations, ", "))
+		}
+		fmt.Fprintln(Stdout())
+	}
+}
 
+// Zones prints the known fare zones.
+func Zones(zones []sl.FareZone) {
+	bold.Fprintln(Stdout(), "🎫 Fare zones")
+	fmt.Fprintln(Stdout(), strings.Repeat("─", 60))
+	for _, z := range zones {
+		cyan.Fprintf(Stdout(), "  %-9s", z.Code)
+		fmt.Fprintf(Stdout(), " %s\n", z.Name)
+		dim.Fprintf(Stdout(), "            %s\n", z.Description)
+	}
+	fmt.Fprintln(Stdout())
+}
+
+// StopZones prints the fare zone membership of a stop.
+func StopZones(stopName string, siteID int, zones sl.StopZones) {
+	bold.Fprintf(Stdout(), "📍 %s", stopName)
+	dim.Fprintf(Stdout(), " (id:%d)\n", siteID)
+	fmt.Fprintf(Stdout(), "  Zone(s): %s\n", strings.Join(zones.Zones, ", "))
+	if zones.Supplement {
+		yellow.Fprintln(Stdout(), "  ⚠️  SL ticket not sufficient — supplement required")
+	}
+	if zones.Note != "" {
+		dim.Fprintf(Stdout(), "  %s\n", zones.Note)
+	}
+	fmt.Fprintln(Stdout())
+}
+
+// Vehicles prints vehicles approaching nearby stops, soonest first.
+func Vehicles(approaches []sl.Approach) {
+	if len(approaches) == 0 {
+		dim.Fprintln(Stdout(), "No vehicles approaching nearby stops.")
+		return
+	}
+
+	bold.Fprintf(Stdout(), "🛰️  %d vehicle(s) approaching\n", len(approaches))
+	fmt.Fprintln(Stdout(), strings.Repeat("─", 60))
+	for _, a := range approaches {
+		name := a.Vehicle.Label
+		if name == "" {
+			name = a.Vehicle.RouteID
+		}
+		if name == "" {
+			name = a.Vehicle.VehicleID
+		}
+		eta := cyan.Sprintf("%d min", a.ETAMin)
+		if a.ETAMin <= 1 {
+			eta = green.Sprint("NOW")
+		}
+		fmt.Fprintf(Stdout(), "  → %-25s %s ", name, eta)
+		dim.Fprintf(Stdout(), "to %s (%dm away)\n", a.Stop, a.DistanceM)
+	}
+	fmt.Fprintln(Stdout())
+}
+
+// LineVehicles prints the vehicles running a line: where each is and how
+// late it runs.
+func LineVehicles(line string, vehicles []sl.LineVehicle) {
+	if len(vehicles) == 0 {
+		dim.Fprintf(Stdout(), "No vehicles on line %s right now.\n", line)
+		return
+	}
+
+	bold.Fprintf(Stdout(), "🛰️  %d vehicle(s) on line %s\n", len(vehicles), line)
+	fmt.Fprintln(Stdout(), strings.Repeat("─", 60))
+	for _, v := range vehicles {
+		fmt.Fprintf(Stdout(), "  %s %-4s %-22s", ModeIcon(v.Mode), v.Line, v.Destination)
+		switch {
+		case v.NextStop == "":
+			dim.Fprint(Stdout(), " position only")
+		case v.AtStop:
+			fmt.Fprintf(Stdout(), " at %s", v.NextStop)
+		default:
+			fmt.Fprintf(Stdout(), " → %s", v.NextStop)
+		}
+		if v.DelayMin != nil {
+			switch d := *v.DelayMin; {
+			case d > 0:
+				yellow.Fprintf(Stdout(), "  +%d min", d)
+			case d < 0:
+				cyan.Fprintf(Stdout(), "  %d min", d)
+			default:
+				green.Fprint(Stdout(), "  on time")
+			}
+		}
+		fmt.Fprintln(Stdout())
+	}
+	fmt.Fprintln(Stdout())
+}
+
+// Interchanges prints the changes within one journey and whether each
+// connection holds given current delays.
+func Interchanges(route int, xs []sl.Interchange) {
+	bold.Fprintf(Stdout(), "🔀 Route %d", route)
+	dim.Fprintf(Stdout(), " — %d change(s)\n", len(xs))
+	fmt.Fprintln(Stdout(), strings.Repeat("─", 60))
+	if len(xs) == 0 {
+		dim.Fprintln(Stdout(), "Direct journey, no changes.")
+		fmt.Fprintln(Stdout())
+		return
+	}
+
+	for _, x := range xs {
+		bold.Fprintf(Stdout(), "\n%s\n", x.At)
+		fmt.Fprintf(Stdout(), "  %s%s → %s%s\n", x.FromLine, platformSuffix(x.FromPlatform), x.ToLine, platformSuffix(x.ToPlatform))
+		if x.ToStop != "" {
+			fmt.Fprintf(Stdout(), "  🚶 Walk to %s (%d min)\n", x.ToStop, x.WalkMin)
+		} else if x.WalkMin > 0 {
+			fmt.Fprintf(Stdout(), "  🚶 %d min between platforms\n", x.WalkMin)
+		}
+		if !x.Arrive.IsZero() && !x.Depart.IsZero() {
+			fmt.Fprintf(Stdout(), "  Arrive %s, depart %s — ", x.Arrive.Format("15:04"), x.Depart.Format("15:04"))
+			switch x.Connection {
+			case sl.CatchYes:
+				green.Fprintf(Stdout(), "%d min to spare", x.SlackMin)
+			case sl.CatchMarginal:
+				yellow.Fprintf(Stdout(), "tight, %d min to spare", x.SlackMin)
+			default:
+				red.Fprintf(Stdout(), "at risk, %d min short", -x.SlackMin)
+			}
+			riskColor(x.RiskLevel).Fprintf(Stdout(), " — %s (risk %d)", x.RiskLevel, x.Risk)
+			if !x.RealtimeBased {
+				dim.Fprint(Stdout(), " (timetable)")
+			}
+			fmt.Fprintln(Stdout())
+		}
+	}
+	fmt.Fprintln(Stdout())
+}
+
+func platformSuffix(platform string) string {
+	if platform == "" {
+		return ""
+	}
+	return dim.Sprintf(" [plat %s]", platform)
+}
+
+// CorridorStop is a stop along a walking path, with the lines serving it.
+type CorridorStop struct {
+	Stop    string         `json:"stop"`
+	SiteID  int            `json:"site_id"`
+	AlongM  int            `json:"along_m"`
+	OffsetM int            `json:"offset_m"`
+	Lines   []StopInfoLine `json:"lines"`
+}
+
+// Corridor prints stops along a path from start to end.
+func Corridor(from, to string, stops []CorridorStop) {
+	bold.Fprintf(Stdout(), "🚶 %s → %s\n", from, to)
+	fmt.Fprintln(Stdout(), strings.Repeat("─", 60))
+	if len(stops) == 0 {
+		dim.Fprintln(Stdout(), "No stops along the way.")
+		return
+	}
+	for _, s := range stops {
+		fmt.Fprintf(Stdout(), "  %5dm  %-30s", s.AlongM, s.Stop)
+		dim.Fprintf(Stdout(), " %dm off path\n", s.OffsetM)
 		for _, l := range s.Lines {
-			icon := ModeIcon(l.TransportMode)
-			fmt.Printf("     %s %-6s", icon, l.Designation)
+			fmt.Fprintf(Stdout(), "          %s %-6s", ModeIcon(l.TransportMode), l.Designation)
 			if len(l.Destinations) > 0 {
-				dim.Printf(" → %s", strings.Join(l.Destinations, ", "))
+				dim.Fprintf(Stdout(), " → %s", strings.Join(l.Destinations, ", "))
 			}
-			fmt.Println()
+			fmt.Fprintln(Stdout())
+		}
+	}
+	fmt.Fprintln(Stdout())
+}
+
+// ProbeRound prints one watchdog round on a single line.
+func ProbeRound(results []sl.ProbeResult) {
+	if len(results) == 0 {
+		return
+	}
+	dim.Fprintf(Stdout(), "%s ", results[0].Time.Local().Format("15:04:05"))
+	for _, r := range results {
+		fmt.Fprintf(Stdout(), " %s ", r.Endpoint)
+		switch r.Status {
+		case sl.ProbeUp:
+			green.Fprint(Stdout(), "✓")
+			dim.Fprintf(Stdout(), " %dms ", r.LatencyMs)
+		case sl.ProbeDown:
+			redBold.Fprint(Stdout(), "✗ down ")
+		default:
+			yellow.Fprint(Stdout(), "✗ error ")
+		}
+	}
+	fmt.Fprintln(Stdout())
+}
+
+// UptimeReport prints per-endpoint uptime and latency from the probe log.
+func UptimeReport(stats []sl.UptimeStats, window time.Duration) {
+	if len(stats) == 0 {
+		dim.Fprintln(Stdout(), "No probes recorded. Run 'sl watchdog' first.")
+		return
+	}
+	bold.Fprintf(Stdout(), "📈 SL API availability, last %s\n", window)
+	fmt.Fprintln(Stdout(), strings.Repeat("─", 60))
+	for _, s := range stats {
+		uptime := green.Sprintf("%6.2f%%", s.UptimePct)
+		if s.UptimePct < 99 {
+			uptime = red.Sprintf("%6.2f%%", s.UptimePct)
+		}
+		fmt.Fprintf(Stdout(), "  %-16s %s", s.Endpoint, uptime)
+		dim.Fprintf(Stdout(), "  median %dms  p95 %dms  (%d probes)\n", s.MedianMs, s.P95Ms, s.Probes)
+		if s.LastFailure != "" {
+			dim.Fprintf(Stdout(), "  %-16s last failure %s\n", "", s.LastFailure)
+		}
+	}
+	fmt.Fprintln(Stdout())
+}
+
+// Follow prints where the traveller is along a followed journey and the
+// changes still ahead.
+func Follow(st sl.FollowStatus) {
+	dim.Fprintf(Stdout(), "%s  ", st.Time.Format("15:04:05"))
+	if st.Phase == sl.PhaseArrived {
+		green.Fprintln(Stdout(), "🏁 Arrived")
+		return
+	}
+	bold.Fprintf(Stdout(), "Leg %d/%d  ", st.Leg, st.Legs)
+
+	until := func(t time.Time) string {
+		mins := int(t.Sub(st.Time).Minutes())
+		if mins <= 0 {
+			return green.Sprint("now")
+		}
+		return cyan.Sprintf("in %d min", mins)
+	}
+	switch st.Phase {
+	case sl.PhaseWalk:
+		fmt.Fprintf(Stdout(), "🚶 Walk to %s, arrive %s\n", st.To, until(st.Arrives))
+	case sl.PhaseWait:
+		fmt.Fprintf(Stdout(), "⏳ %s from %s leaves %s %s", st.Line, st.From, st.Departs.Format("15:04"), until(st.Departs))
+		if !st.Realtime {
+			dim.Fprint(Stdout(), " (timetable)")
+		}
+		fmt.Fprintln(Stdout())
+	case sl.PhaseRide:
+		fmt.Fprintf(Stdout(), "🚆 %s to %s, arrive %s %s\n", st.Line, st.To, st.Arrives.Format("15:04"), until(st.Arrives))
+	}
+
+	for _, x := range st.Changes {
+		fmt.Fprintf(Stdout(), "    %s change at %s to %s", catchMarker(x.Connection), x.At, x.ToLine)
+		switch x.Connection {
+		case sl.CatchYes, sl.CatchMarginal:
+			dim.Fprintf(Stdout(), " — %d min to spare\n", x.SlackMin)
+		case sl.CatchNo:
+			red.Fprintf(Stdout(), " — %d min short\n", -x.SlackMin)
+		default:
+			fmt.Fprintln(Stdout())
+		}
+	}
+}
+
+// JourneyPositions prints the runs of a line around a stop: when each is
+// due at the stop, or that it has left, and the next stop it reaches.
+func JourneyPositions(line, stopName string, siteID int, runs []sl.JourneyPosition, now time.Time) {
+	bold.Fprintf(Stdout(), "📍 Line %s at %s\n", line, stopName)
+	fmt.Fprintln(Stdout(), strings.Repeat("─", 60))
+	if len(runs) == 0 {
+		dim.Fprintln(Stdout(), "No runs of this line found nearby.")
+		fmt.Fprintln(Stdout())
+		return
+	}
+
+	delay := func(mins int) string {
+		switch {
+		case mins >= 3:
+			return red.Sprintf(" +%d min", mins)
+		case mins > 0:
+			return yellow.Sprintf(" +%d min", mins)
+		}
+		return ""
+	}
+	for _, r := range runs {
+		fmt.Fprintf(Stdout(), "  %-20.20s ", r.Destination)
+		if r.Expected.IsZero() {
+			dim.Fprint(Stdout(), "left       ")
+		} else {
+			fmt.Fprintf(Stdout(), "%s → %s", r.Scheduled.Format("15:04"), cyan.Sprint(r.Expected.Format("15:04")))
+		}
+		fmt.Fprint(Stdout(), delay(r.DelayMin))
+		if r.NextSiteID != siteID {
+			mins := int(r.NextExpected.Sub(now).Minutes())
+			dim.Fprintf(Stdout(), "  next %s in %d min", r.NextStop, max(mins, 0))
+		}
+		dim.Fprintf(Stdout(), "  #%d\n", r.JourneyID)
+	}
+	fmt.Fprintln(Stdout())
+}
+
+// Reach prints the stops reachable from a place, nearest in time first.
+func Reach(from string, budget int, stops []sl.ReachableStop) {
+	bold.Fprintf(Stdout(), "⏱️  Within %d min of %s", budget, from)
+	dim.Fprintf(Stdout(), " — %d stop(s)\n", len(stops))
+	fmt.Fprintln(Stdout(), strings.Repeat("─", 60))
+	if len(stops) == 0 {
+		dim.Fprintln(Stdout(), "No stops within reach.")
+		fmt.Fprintln(Stdout())
+		return
+	}
+	for _, s := range stops {
+		fmt.Fprintf(Stdout(), "  %s  %s\n", cyan.Sprintf("%3d min", s.Minutes), Hyperlink(MapURL(s.Lat, s.Lon), s.Name))
+	}
+	fmt.Fprintln(Stdout())
+}
+
+// Commutes prints commutes from candidate locations to one destination as a
+// ranked table.
+func Commutes(to string, commutes []sl.Commute) {
+	bold.Fprintf(Stdout(), "🏠 Commutes to %s\n", to)
+	fmt.Fprintln(Stdout(), strings.Repeat("─", 60))
+	for i, c := range commutes {
+		if c.Err != "" {
+			red.Fprintf(Stdout(), "  –  %s: %s\n", c.From, c.Err)
+			continue
+		}
+		fmt.Fprintf(Stdout(), "%3d. %s  %s", i+1, cyan.Sprintf("%3d min", c.Minutes), c.From)
+		dim.Fprintf(Stdout(), "  %d change(s), %d min walk", c.Changes, c.WalkMinutes)
+		if !c.Depart.IsZero() && !c.Arrive.IsZero() {
+			dim.Fprintf(Stdout(), ", %s–%s", c.Depart.Format("15:04"), c.Arrive.Format("15:04"))
+		}
+		if len(c.Lines) > 0 {
+			dim.Fprintf(Stdout(), " via %s", strings.Join(c.Lines, ", "))
+		}
+		fmt.Fprintln(Stdout())
+	}
+	fmt.Fprintln(Stdout())
+}
+
+// LineRoutes prints the stops of a line in each direction, in order.
+func LineRoutes(routes []sl.LineRoute) {
+	for _, r := range routes {
+		bold.Fprintf(Stdout(), "%s Line %s → %s", ModeIcon(r.Mode), r.Line, r.Headsign)
+		dim.Fprintf(Stdout(), " (direction %d) — %d stop(s)\n", r.Direction, len(r.Stops))
+		fmt.Fprintln(Stdout(), strings.Repeat("─", 60))
+		for _, s := range r.Stops {
+			fmt.Fprintf(Stdout(), "  %s  %s\n", cyan.Sprintf("%3d min", s.Minutes), Hyperlink(MapURL(s.Lat, s.Lon), s.Name))
+		}
+		if r.Variants > 0 {
+			dim.Fprintf(Stdout(), "  %d other stop sequence(s), e.g. short turns\n", r.Variants)
+		}
+		fmt.Fprintln(Stdout())
+	}
+}
+
+// Smoke prints the outcome of each sl smoke check with its field drift.
+func Smoke(results []sl.SmokeResult) {
+	bold.Fprintln(Stdout(), "Live API smoke test")
+	fmt.Fprintln(Stdout(), strings.Repeat("─", 60))
+	for _, r := range results {
+		switch r.Status {
+		case "ok":
+			green.Fprintf(Stdout(), "  ✓ %-12s", r.Name)
+		case "drift":
+			yellow.Fprintf(Stdout(), "  ⚠ %-12s", r.Name)
+		default:
+			red.Fprintf(Stdout(), "  ✗ %-12s", r.Name)
+		}
+		dim.Fprintf(Stdout(), " %5d ms\n", r.DurationMs)
+		if r.Error != "" {
+			red.Fprintf(Stdout(), "      %s\n", r.Error)
+		}
+		for _, f := range r.Missing {
+			red.Fprintf(Stdout(), "      - %s (missing)\n", f)
+		}
+		for _, f := range r.New {
+			dim.Fprintf(Stdout(), "      + %s (new)\n", f)
 		}
 	}
-	fmt.Println()
+	fmt.Fprintln(Stdout())
 }
@@ -0,0 +1,315 @@
+package format
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ParquetKind is the type of a Parquet column.
+type ParquetKind int
+
+const (
+	ParquetString    ParquetKind = iota // UTF-8 string
+	ParquetInt64                        // 64-bit integer
+	ParquetTimestamp                    // instant, stored as UTC milliseconds
+)
+
+// ParquetColumn is one column of a Parquet file.
+type ParquetColumn struct {
+	Name string
+	Kind ParquetKind
+}
+
+// parquetRowGroup is how many rows go into each row group, so readers can
+// skip through months of data without loading it all.
+const parquetRowGroup = 100_000
+
+// Parquet writes rows as an uncompressed Parquet file that pandas, DuckDB
+// and Spark read directly. Every column is optional: a nil value is null,
+// anything else must be a string, int64 or time.Time matching the column.
+func Parquet(w io.Writer, cols []ParquetColumn, rows [][]any) error {
+	pw := &parquetWriter{w: w}
+	pw.write([]byte("PAR1"))
+
+	var groups []rowGroup
+	for start := 0; start < len(rows); start += parquetRowGroup {
+		g, err := pw.rowGroup(cols, rows[start:min(start+parquetRowGroup, len(rows))])
+		if err != nil {
+			return err
+		}
+		groups = append(groups, g)
+	}
+
+	footer := fileMetaData(cols, len(rows), groups)
+	pw.write(footer)
+	pw.write(binary.LittleEndian.AppendUint32(nil, uint32(len(footer))))
+	pw.write([]byte("PAR1"))
+	return pw.err
+}
+
+// parquetWriter tracks the file offset, which the footer refers to.
+type parquetWriter struct {
+	w   io.Writer
+	off int64
+	err error
+}
+
+func (pw *parquetWriter) write(b []byte) {
+	if pw.err != nil {
+		return
+	}
+	n, err := pw.w.Write(b)
+	pw.off += int64(n)
+	pw.err = err
+}
+
+// rowGroup and columnChunk record where the data went for the footer.
+type rowGroup struct {
+	rows    int
+	size    int64
+	columns []columnChunk
+}
+
+type columnChunk struct {
+	offset int64
+	size   int64
+	values int
+}
+
+// rowGroup writes one data page per column for rows.
+func (pw *parquetWriter) rowGroup(cols []ParquetColumn, rows [][]any) (rowGroup, error) {
+	g := rowGroup{rows: len(rows)}
+	for i, col := range cols {
+		page, err := dataPage(col, i, rows)
+		if err != nil {
+			return g, err
+		}
+		header := pageHeader(len(rows), len(page))
+		chunk := columnChunk{offset: pw.off, size: int64(len(header) + len(page)), values: len(rows)}
+		pw.write(header)
+		pw.write(page)
+		g.columns = append(g.columns, chunk)
+		g.size += chunk.size
+	}
+	return g, pw.err
+}
+
+// dataPage encodes column i of rows: definition levels marking nulls,
+// then the non-null values, PLAIN encoded.
+func dataPage(col ParquetColumn, i int, rows [][]any) ([]byte, error) {
+	var levels []byte
+	var values bytes.Buffer
+	run, runLevel := 0, byte(0)
+	flush := func() {
+		if run > 0 {
+			levels = binary.AppendUvarint(levels, uint64(run)<<1)
+			levels = append(levels, runLevel)
+		}
+	}
+
+	for _, row := range rows {
+		level := byte(0)
+		if i < len(row) && row[i] != nil {
+			level = 1
+			if err := plainValue(&values, col, row[i]); err != nil {
+				return nil, err
+			}
+		}
+		if level != runLevel {
+			flush()
+			run, runLevel = 0, level
+		}
+		run++
+	}
+	flush()
+
+	page := binary.LittleEndian.AppendUint32(nil, uint32(len(levels)))
+	page = append(page, levels...)
+	return append(page, values.Bytes()...), nil
+}
+
+func plainValue(buf *bytes.Buffer, col ParquetColumn, v any) error {
+	switch col.Kind {
+	case ParquetString:
+		if s, ok := v.(string); ok {
+			buf.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(s))))
+			buf.WriteString(s)
+			return nil
+		}
+	case ParquetInt64:
+		if n, ok := v.(int64); ok {
+			buf.Write(binary.LittleEndian.AppendUint64(nil, uint64(n)))
+			return nil
+		}
+	case ParquetTimestamp:
+		if t, ok := v.(time.Time); ok {
+			buf.Write(binary.LittleEndian.AppendUint64(nil, uint64(t.UnixMilli())))
+			return nil
+		}
+	}
+	return fmt.Errorf("parquet column %s: unexpected value %v (%T)", col.Name, v, v)
+}
+
+// Parquet's metadata is Thrift, compact protocol. These are the enum
+// values and field types used here.
+const (
+	parquetInt64     = 2
+	parquetByteArray = 6
+	parquetOptional  = 1
+	parquetUTF8      = 0
+	parquetMillis    = 9 // converted type TIMESTAMP_MILLIS
+	parquetPlain     = 0
+	parquetRLE       = 3
+
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+func physicalType(k ParquetKind) int64 {
+	if k == ParquetString {
+		return parquetByteArray
+	}
+	return parquetInt64
+}
+
+// pageHeader encodes the PageHeader of an uncompressed DATA_PAGE.
+func pageHeader(values, size int) []byte {
+	var t thrift
+	t.i32(1, 0) // DATA_PAGE
+	t.i32(2, int64(size))
+	t.i32(3, int64(size))
+	t.beginStruct(5)
+	t.i32(1, int64(values))
+	t.i32(2, parquetPlain)
+	t.i32(3, parquetRLE)
+	t.i32(4, parquetRLE)
+	t.endStruct()
+	t.stop()
+	return t.buf
+}
+
+// fileMetaData encodes the footer describing the schema and row groups.
+func fileMetaData(cols []ParquetColumn, rows int, groups []rowGroup) []byte {
+	var t thrift
+	t.i32(1, 1)
+
+	t.list(2, thriftStruct, len(cols)+1)
+	t.beginElem()
+	t.binary(4, "schema")
+	t.i32(5, int64(len(cols)))
+	t.endStruct()
+	for _, c := range cols {
+		t.beginElem()
+		t.i32(1, physicalType(c.Kind))
+		t.i32(3, parquetOptional)
+		t.binary(4, c.Name)
+		switch c.Kind {
+		case ParquetString:
+			t.i32(6, parquetUTF8)
+		case ParquetTimestamp:
+			t.i32(6, parquetMillis)
+		}
+		t.endStruct()
+	}
+
+	t.i64(3, int64(rows))
+
+	t.list(4, thriftStruct, len(groups))
+	for _, g := range groups {
+		t.beginElem()
+		t.list(1, thriftStruct, len(g.columns))
+		for i, c := range g.columns {
+			t.beginElem()
+			t.i64(2, c.offset)
+			t.beginStruct(3)
+			t.i32(1, physicalType(cols[i].Kind))
+			t.list(2, thriftI32, 2)
+			t.varint(parquetPlain)
+			t.varint(parquetRLE)
+			t.list(3, thriftBinary, 1)
+			t.str(cols[i].Name)
+			t.i32(4, 0) // UNCOMPRESSED
+			t.i64(5, int64(c.values))
+			t.i64(6, c.size)
+			t.i64(7, c.size)
+			t.i64(9, c.offset)
+			t.endStruct()
+			t.endStruct()
+		}
+		t.i64(2, g.size)
+		t.i64(3, int64(g.rows))
+		t.endStruct()
+	}
+
+	t.binary(6, "sl-cli")
+	t.stop()
+	return t.buf
+}
+
+// thrift is a minimal Thrift compact protocol encoder: enough for
+// Parquet's page headers and footer.
+type thrift struct {
+	buf   []byte
+	last  int16   // id of the previous field in the current struct
+	stack []int16 // last of each enclosing struct
+}
+
+func (t *thrift) field(id int16, typ byte) {
+	if delta := id - t.last; delta > 0 && delta <= 15 {
+		t.buf = append(t.buf, byte(delta)<<4|typ)
+	} else {
+		t.buf = append(t.buf, typ)
+		t.varint(int64(id))
+	}
+	t.last = id
+}
+
+// varint writes n zigzag encoded, as compact protocol integers are.
+func (t *thrift) varint(n int64) {
+	t.buf = binary.AppendUvarint(t.buf, uint64(n<<1^n>>63))
+}
+
+func (t *thrift) str(s string) {
+	t.buf = binary.AppendUvarint(t.buf, uint64(len(s)))
+	t.buf = append(t.buf, s...)
+}
+
+func (t *thrift) i32(id int16, n int64) { t.field(id, thriftI32); t.varint(n) }
+func (t *thrift) i64(id int16, n int64) { t.field(id, thriftI64); t.varint(n) }
+
+func (t *thrift) binary(id int16, s string) { t.field(id, thriftBinary); t.str(s) }
+
+func (t *thrift) list(id int16, elem byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.buf = append(t.buf, byte(n)<<4|elem)
+	} else {
+		t.buf = append(t.buf, 0xf0|elem)
+		t.buf = binary.AppendUvarint(t.buf, uint64(n))
+	}
+}
+
+// beginStruct starts a struct field; beginElem a struct in a list.
+func (t *thrift) beginStruct(id int16) {
+	t.field(id, thriftStruct)
+	t.beginElem()
+}
+
+func (t *thrift) beginElem() {
+	t.stack = append(t.stack, t.last)
+	t.last = 0
+}
+
+func (t *thrift) endStruct() {
+	t.stop()
+	t.last = t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+}
+
+func (t *thrift) stop() { t.buf = append(t.buf, 0) }
@@ -0,0 +1,78 @@
+package format
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+func TestParquet(t *testing.T) {
+	cols := []ParquetColumn{
+		{Name: "line", Kind: ParquetString},
+		{Name: "delay_min", Kind: ParquetInt64},
+		{Name: "expected", Kind: ParquetTimestamp},
+	}
+	at := time.Date(2024, 3, 1, 7, 7, 0, 0, time.UTC)
+	rows := [][]any{
+		{"17", int64(1), at},
+		{"55", nil, nil},
+	}
+
+	var buf bytes.Buffer
+	if err := Parquet(&buf, cols, rows); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	if !bytes.HasPrefix(data, []byte("PAR1")) || !bytes.HasSuffix(data, []byte("PAR1")) {
+		t.Fatalf("missing PAR1 magic: %q", data)
+	}
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footer := data[len(data)-8-footerLen : len(data)-8]
+	for _, name := range []string{"line", "delay_min", "expected", "sl-cli"} {
+		if !bytes.Contains(footer, []byte(name)) {
+			t.Errorf("footer lacks %q", name)
+		}
+	}
+
+	// The line column's page comes first: a page header, then definition
+	// levels (one RLE run of two 1s) and the two PLAIN strings.
+	page := []byte{2, 0, 0, 0, 2 << 1, 1, 2, 0, 0, 0, '1', '7', 2, 0, 0, 0, '5', '5'}
+	if i := bytes.Index(data, page); i < 0 || i > 4+32 {
+		t.Errorf("line page not found after the header: %q", data[:64])
+	}
+	// The expected column has one value and a null: runs of one 1 and one 0.
+	millis := binary.LittleEndian.AppendUint64(nil, uint64(at.UnixMilli()))
+	if !bytes.Contains(data, append([]byte{4, 0, 0, 0, 1 << 1, 1, 1 << 1, 0}, millis...)) {
+		t.Error("expected column page not found")
+	}
+
+	if err := Parquet(&bytes.Buffer{}, cols, [][]any{{int64(17), nil, nil}}); err == nil {
+		t.Error("expected an error for an int in a string column")
+	}
+}
+
+func TestThriftCompact(t *testing.T) {
+	var th thrift
+	th.i32(1, 1)
+	th.i32(3, -2)
+	th.beginStruct(20)
+	th.binary(1, "ab")
+	th.endStruct()
+	th.list(21, thriftI32, 2)
+	th.varint(0)
+	th.varint(3)
+	th.stop()
+
+	want := []byte{
+		0x15, 0x02, // field 1, i32, zigzag 1
+		0x25, 0x03, // field 3 (delta 2), i32, zigzag -2
+		0x0c, 0x28, // field 20 (delta 17): long form, zigzag 20
+		0x18, 0x02, 'a', 'b', 0x00, // nested field 1, binary; stop
+		0x19, 0x25, 0x00, 0x06, // field 21 (delta 1), list of 2 i32
+		0x00,
+	}
+	if !bytes.Equal(th.buf, want) {
+		t.Errorf("got  % x\nwant % x", th.buf, want)
+	}
+}
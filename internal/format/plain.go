@@ -0,0 +1,121 @@
+package format
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// plain is set by SetPlain: emoji and box-drawing characters in terminal
+// output are replaced with ASCII labels.
+var plain bool
+
+// SetPlain turns plain ASCII output on or off, for dumb terminals, screen
+// readers and CI logs. JSON and HTML output are left alone.
+func SetPlain(on bool) {
+	plain = on
+}
+
+// PlainFromEnv reports whether the environment asks for plain output:
+// NO_EMOJI or ASCII set to anything but "0", or TERM=dumb.
+func PlainFromEnv() bool {
+	for _, name := range []string{"NO_EMOJI", "ASCII"} {
+		if v := os.Getenv(name); v != "" && v != "0" {
+			return true
+		}
+	}
+	return os.Getenv("TERM") == "dumb"
+}
+
+// labels maps symbols to their plain-text stand-in. Purely decorative
+// emoji in front of a heading are dropped.
+var labels = []struct{ symbol, label string }{
+	{"🚌", "[BUS]"},
+	{"🚇", "[METRO]"},
+	{"🚆", "[TRAIN]"},
+	{"🚋", "[TRAM]"},
+	{"⛴", "[SHIP]"},
+	{"🚏", "[STOP]"},
+	{"🚶", "[WALK]"},
+	{"🔀", "[CHANGE]"},
+	{"🚫", "[NO]"},
+	{"🎫", "[ZONES]"},
+	{"🏁", "[ARRIVED]"},
+	{"⏳", "[WAIT]"},
+	{"⭐", "*"},
+	{"⚠", "[!]"},
+	{"✅", "[OK]"},
+	{"✓", "[OK]"},
+	{"❌", "[X]"},
+	{"✗", "[X]"},
+	{"●", "*"},
+	{"•", "-"},
+	{"■", "#"},
+	{"📍", ""},
+	{"📅", ""},
+	{"📈", ""},
+	{"📝", ""},
+	{"🗺", ""},
+	{"🛰", ""},
+	{"⏱", ""},
+	{"🏠", ""},
+	{"─", "-"},
+	{"→", "->"},
+	{"—", "-"},
+	{"–", "-"},
+	{"·", "|"},
+	{"…", "..."},
+}
+
+// plainReplacer applies labels. Each symbol is also matched with the emoji
+// variation selector and the spacing that follows it, so dropping a
+// decorative emoji doesn't leave a heading indented.
+var plainReplacer = func() *strings.Replacer {
+	var pairs []string
+	for _, l := range labels {
+		for _, sym := range []string{l.symbol + "️", l.symbol} {
+			if l.label == "" {
+				pairs = append(pairs, sym+"  ", "", sym+" ", "")
+			}
+			pairs = append(pairs, sym, l.label)
+		}
+	}
+	return strings.NewReplacer(pairs...)
+}()
+
+// PlainText returns s with symbols replaced by labels when plain output is
+// on, and unchanged otherwise.
+func PlainText(s string) string {
+	if !plain {
+		return s
+	}
+	return plainReplacer.Replace(s)
+}
+
+// plainWriter writes through PlainText. Each formatted print is a single
+// Write, so symbols are never split between calls.
+type plainWriter struct{ w io.Writer }
+
+func (p plainWriter) Write(b []byte) (int, error) {
+	if _, err := io.WriteString(p.w, plainReplacer.Replace(string(b))); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Stdout is where formatted output goes: os.Stdout, with symbols replaced
+// in plain mode.
+func Stdout() io.Writer {
+	if plain {
+		return plainWriter{os.Stdout}
+	}
+	return os.Stdout
+}
+
+// Stderr is Stdout for status messages.
+func Stderr() io.Writer {
+	if plain {
+		return plainWriter{os.Stderr}
+	}
+	return os.Stderr
+}
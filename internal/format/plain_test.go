@@ -0,0 +1,49 @@
+package format
+
+import "testing"
+
+func TestPlainText(t *testing.T) {
+	SetPlain(true)
+	defer SetPlain(false)
+
+	tests := []struct{ in, want string }{
+		{"🚌 55: Slussen → Tanto", "[BUS] 55: Slussen -> Tanto"},
+		{"⛴️ Vaxholm", "[SHIP] Vaxholm"},
+		{"🗺️  3 routes found", "3 routes found"},
+		{"  ⚠️  SL ticket not sufficient — supplement required", "  [!]  SL ticket not sufficient - supplement required"},
+		{"────", "----"},
+		{"Södermalm", "Södermalm"},
+	}
+	for _, tt := range tests {
+		if got := PlainText(tt.in); got != tt.want {
+			t.Errorf("PlainText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	SetPlain(false)
+	if got := PlainText("🚌"); got != "🚌" {
+		t.Errorf("PlainText with plain output off = %q", got)
+	}
+}
+
+func TestPlainFromEnv(t *testing.T) {
+	t.Setenv("TERM", "xterm-256color")
+	t.Setenv("NO_EMOJI", "")
+	t.Setenv("ASCII", "")
+	if PlainFromEnv() {
+		t.Error("plain output without any variable set")
+	}
+	t.Setenv("ASCII", "0")
+	if PlainFromEnv() {
+		t.Error("ASCII=0 should keep emoji")
+	}
+	t.Setenv("NO_EMOJI", "1")
+	if !PlainFromEnv() {
+		t.Error("NO_EMOJI=1 should turn plain output on")
+	}
+	t.Setenv("NO_EMOJI", "")
+	t.Setenv("TERM", "dumb")
+	if !PlainFromEnv() {
+		t.Error("TERM=dumb should turn plain output on")
+	}
+}
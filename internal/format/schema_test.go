@@ -0,0 +1,75 @@
+package format
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestJSONLine_SchemaVersion(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+		want string
+	}{
+		{"object", struct {
+			Stop string `json:"stop"`
+		}{"Slussen & Co"}, `{"schema_version":2,"stop":"Slussen & Co"}`},
+		{"empty object", struct{}{}, `{"schema_version":2}`},
+		{"array", []int{1, 2}, `[1,2]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := JSONLine(&buf, tt.v); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want+"\n" {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// renamed stands in for a result whose field was renamed in the current
+// schema version.
+type renamed struct {
+	SiteID int `json:"site_id"`
+}
+
+func (r renamed) ForSchema(version int) any {
+	return struct {
+		ID int `json:"id"`
+	}{r.SiteID}
+}
+
+func TestJSONLine_Downgrade(t *testing.T) {
+	defer func() { schemaVersion = SchemaVersion }()
+
+	var buf bytes.Buffer
+	JSONLine(&buf, renamed{9191})
+	if got := buf.String(); got != `{"schema_version":2,"site_id":9191}`+"\n" {
+		t.Errorf("current version: got %s", got)
+	}
+
+	// Pretend an older version is still supported.
+	schemaVersion = SchemaVersion - 1
+	buf.Reset()
+	JSONLine(&buf, renamed{9191})
+	if got := buf.String(); got != `{"schema_version":1,"id":9191}`+"\n" {
+		t.Errorf("older version: got %s", got)
+	}
+}
+
+func TestSetSchemaVersion(t *testing.T) {
+	defer SetSchemaVersion(0)
+
+	if err := SetSchemaVersion(SchemaVersion + 1); err == nil {
+		t.Error("expected an error for a future schema version")
+	}
+	if err := SetSchemaVersion(-1); err == nil {
+		t.Error("expected an error for a negative schema version")
+	}
+	if err := SetSchemaVersion(0); err != nil || Schema() != SchemaVersion {
+		t.Errorf("0 should select the current version, got %d (%v)", Schema(), err)
+	}
+}
@@ -0,0 +1,43 @@
+package format
+
+import (
+	"strings"
+
+	"github.com/glundgren93/sl-cli/internal/i18n"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+// NextDeparture renders a departure as one plain line, such as "55 to
+// Tanto in 4 min from platform A", for prompts and status bars.
+func NextDeparture(d sl.ParsedDeparture) string {
+	var s string
+	if d.Display == "Nu" || d.MinutesLeft == 0 {
+		s = i18n.T(i18n.NextNow, d.Line, d.Destination)
+	} else {
+		s = i18n.T(i18n.NextDeparture, d.Line, d.Destination, d.MinutesLeft)
+	}
+	if d.Platform != "" {
+		s += i18n.T(i18n.NextPlatform, d.Platform)
+	}
+	return s
+}
+
+// SpokenDepartures renders the next departures as plain sentences for
+// text-to-speech: no emoji, colors or column padding.
+func SpokenDepartures(deps []sl.ParsedDeparture, stopName string, max int) string {
+	if len(deps) == 0 {
+		return i18n.T(i18n.SpokenNone, stopName)
+	}
+	if max > 0 && len(deps) > max {
+		deps = deps[:max]
+	}
+	sentences := make([]string, 0, len(deps))
+	for _, d := range deps {
+		if d.Display == "Nu" || d.MinutesLeft == 0 {
+			sentences = append(sentences, i18n.T(i18n.SpokenNow, d.Line, d.Destination))
+		} else {
+			sentences = append(sentences, i18n.T(i18n.SpokenDeparture, d.Line, d.Destination, d.MinutesLeft))
+		}
+	}
+	return strings.Join(sentences, " ")
+}
@@ -0,0 +1,49 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/glundgren93/sl-cli/internal/i18n"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+func TestSpokenDepartures(t *testing.T) {
+	deps := []sl.ParsedDeparture{
+		{Line: "17", Destination: "Åkeshov", Display: "Nu"},
+		{Line: "17", Destination: "Åkeshov", MinutesLeft: 7},
+		{Line: "19", Destination: "Hässelby strand", MinutesLeft: 9},
+	}
+
+	got := SpokenDepartures(deps, "Medborgarplatsen", 2)
+	want := "Line 17 to Åkeshov is leaving now. Line 17 to Åkeshov in 7 minutes."
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	i18n.SetLanguage("sv")
+	defer i18n.SetLanguage("en")
+	if got := SpokenDepartures(nil, "Medborgarplatsen", 2); got != "Inga avgångar från Medborgarplatsen." {
+		t.Errorf("sv empty: got %q", got)
+	}
+}
+
+func TestNextDeparture(t *testing.T) {
+	tests := []struct {
+		d    sl.ParsedDeparture
+		want string
+	}{
+		{sl.ParsedDeparture{Line: "55", Destination: "Tanto", MinutesLeft: 4, Platform: "A"}, "55 to Tanto in 4 min from platform A"},
+		{sl.ParsedDeparture{Line: "17", Destination: "Åkeshov", Display: "Nu"}, "17 to Åkeshov now"},
+	}
+	for _, tt := range tests {
+		if got := NextDeparture(tt.d); got != tt.want {
+			t.Errorf("got %q, want %q", got, tt.want)
+		}
+	}
+
+	i18n.SetLanguage("sv")
+	defer i18n.SetLanguage("en")
+	if got := NextDeparture(tests[0].d); got != "55 mot Tanto om 4 min från läge A" {
+		t.Errorf("sv: got %q", got)
+	}
+}
@@ -0,0 +1,122 @@
+package format
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+// StationRow is one row of a station board: an arrival or a departure.
+// Place is where an arrival comes from or a departure goes to.
+type StationRow struct {
+	Kind      string // "arrival" or "departure"
+	Line      string
+	Mode      string
+	Place     string
+	Track     string
+	Scheduled time.Time
+	DelayMin  int
+	State     string
+	// Timetabled rows have no real-time data: no delay or track.
+	Timetabled bool
+}
+
+// StationRows turns arrivals and departures into station board rows,
+// arrivals first.
+func StationRows(arrs []sl.Arrival, deps []sl.ParsedDeparture) []StationRow {
+	var rows []StationRow
+	for _, a := range arrs {
+		rows = append(rows, StationRow{
+			Kind:       "arrival",
+			Line:       a.Line,
+			Mode:       a.TransportMode,
+			Place:      a.Origin,
+			Scheduled:  a.Scheduled,
+			Timetabled: true,
+		})
+	}
+	for _, d := range deps {
+		row := StationRow{
+			Kind:       "departure",
+			Line:       d.Line,
+			Mode:       d.TransportMode,
+			Place:      d.Destination,
+			Track:      d.Platform,
+			Scheduled:  d.Scheduled,
+			State:      d.State,
+			Timetabled: d.ScheduledOnly,
+		}
+		if !d.Scheduled.IsZero() && !d.Expected.IsZero() {
+			row.DelayMin = int(math.Round(d.Expected.Sub(d.Scheduled).Minutes()))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// StationColumns are the columns of station --format table/csv/tsv.
+var StationColumns = []Column[StationRow]{
+	{"kind", func(r StationRow) string { return r.Kind }},
+	{"time", func(r StationRow) string { return clock(r.Scheduled) }},
+	{"line", func(r StationRow) string { return r.Line }},
+	{"mode", func(r StationRow) string { return r.Mode }},
+	{"from_to", func(r StationRow) string { return r.Place }},
+	{"track", func(r StationRow) string { return r.Track }},
+	{"delay_min", func(r StationRow) string { return strconv.Itoa(r.DelayMin) }},
+	{"state", func(r StationRow) string { return r.State }},
+}
+
+// Station prints the classic big-station display: arrivals above
+// departures, each by timetabled time with the track and any delay.
+func Station(stopName string, rows []StationRow) {
+	bold.Fprintf(Stdout(), "📍 %s\n", stopName)
+	fmt.Fprintln(Stdout(), strings.Repeat("─", 60))
+
+	for _, section := range []struct{ kind, title, place, none string }{
+		{"arrival", "Arrivals", "From", "No arrivals found."},
+		{"departure", "Departures", "To", "No departures found."},
+	} {
+		bold.Fprintf(Stdout(), "\n%s\n", section.title)
+		dim.Fprintf(Stdout(), "  %-5s  %-8s %-26s %-5s\n", "Time", "Line", section.place, "Track")
+		n := 0
+		for _, r := range rows {
+			if r.Kind != section.kind {
+				continue
+			}
+			n++
+			fmt.Fprintf(Stdout(), "  %s  %s %-5s %-26s %-5s %s\n",
+				cyan.Sprint(clock(r.Scheduled)), ModeIcon(r.Mode), r.Line, truncateRunes(r.Place, 26), r.Track, stationNote(r))
+		}
+		if n == 0 {
+			dim.Fprintf(Stdout(), "  %s\n", section.none)
+		}
+	}
+	fmt.Fprintln(Stdout())
+}
+
+// stationNote is a row's status column: cancelled, its delay, or whether
+// it is on time as far as anyone knows.
+func stationNote(r StationRow) string {
+	switch {
+	case r.State == "CANCELLED":
+		return red.Sprint("✗ cancelled")
+	case r.Timetabled:
+		return dim.Sprint("scheduled")
+	case r.DelayMin > 0:
+		return yellow.Sprintf("+%d min", r.DelayMin)
+	case r.DelayMin < 0:
+		return green.Sprintf("%d min", r.DelayMin)
+	}
+	return green.Sprint("on time")
+}
+
+func truncateRunes(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
@@ -0,0 +1,25 @@
+package format
+
+import (
+	"testing"
+	"time"
+
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+func TestStationRows(t *testing.T) {
+	at := time.Date(2024, 3, 1, 7, 40, 0, 0, time.UTC)
+	rows := StationRows(
+		[]sl.Arrival{{Line: "40", TransportMode: "TRAIN", Origin: "Södertälje centrum", Scheduled: at}},
+		[]sl.ParsedDeparture{{Line: "41", TransportMode: "TRAIN", Destination: "Uppsala C", Platform: "3", Scheduled: at, Expected: at.Add(4 * time.Minute)}},
+	)
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(rows))
+	}
+	if r := rows[0]; r.Kind != "arrival" || r.Place != "Södertälje centrum" || !r.Timetabled {
+		t.Errorf("arrival row = %+v", r)
+	}
+	if r := rows[1]; r.Kind != "departure" || r.Place != "Uppsala C" || r.Track != "3" || r.DelayMin != 4 || r.Timetabled {
+		t.Errorf("departure row = %+v", r)
+	}
+}
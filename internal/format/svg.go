@@ -0,0 +1,114 @@
+package format
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+// lineColors is SL's line palette for rail lines, keyed by designation.
+var lineColors = map[string]string{
+	// Tunnelbana
+	"10": "#0089ca", "11": "#0089ca",
+	"13": "#e3000b", "14": "#e3000b",
+	"17": "#4ba946", "18": "#4ba946", "19": "#4ba946",
+	// Lokalbanor
+	"7":  "#878a83",
+	"12": "#778da7",
+	"21": "#b76020",
+	"25": "#21b6ba", "26": "#21b6ba",
+	"27": "#a86dae", "28": "#a86dae", "29": "#a86dae",
+	"30": "#e08a32", "31": "#e08a32",
+	// Pendeltåg
+	"40": "#ec619f", "41": "#ec619f", "42": "#ec619f", "43": "#ec619f", "44": "#ec619f", "48": "#ec619f",
+}
+
+// blueBusLines are the trunk ("blåbuss") routes drawn in blue rather than red.
+var blueBusLines = map[string]bool{
+	"1": true, "2": true, "3": true, "4": true, "6": true,
+	"172": true, "173": true, "176": true, "177": true, "178": true, "179": true,
+}
+
+// LineColor returns the badge background color for a line.
+func LineColor(designation, mode string) string {
+	switch strings.ToUpper(mode) {
+	case "BUS":
+		if blueBusLines[designation] {
+			return "#0089ca"
+		}
+		return "#e3000b"
+	case "SHIP", "FERRY":
+		return "#0e6eb5"
+	}
+	if c, ok := lineColors[designation]; ok {
+		return c
+	}
+	return "#555555"
+}
+
+// LineBadgeSVG writes a standalone SVG badge for a line: a rounded
+// rectangle in the line's color with the designation in white.
+func LineBadgeSVG(w io.Writer, designation, mode string) error {
+	const height = 48
+	width := 24 + 22*len([]rune(designation))
+	if width < height {
+		width = height
+	}
+	_, err := fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">
+  <rect width="%d" height="%d" rx="8" fill="%s"/>
+  <text x="50%%" y="50%%" dominant-baseline="central" text-anchor="middle" font-family="Helvetica, Arial, sans-serif" font-weight="bold" font-size="30" fill="#ffffff">%s</text>
+</svg>
+`, width, height, width, height, width, height, LineColor(designation, mode), html.EscapeString(designation))
+	return err
+}
+
+// LineRoutesSVG writes line routes as a standalone SVG strip map: one row
+// per direction with the line's badge, a bar in its color through a dot
+// per stop, the stop names slanted above and the scheduled minutes from
+// the first stop below.
+func LineRoutesSVG(w io.Writer, routes []sl.LineRoute) error {
+	const (
+		margin  = 24
+		badge   = 48
+		gap     = 56
+		labelH  = 150
+		rowH    = labelH + 56
+		stopsX0 = margin + badge + 32
+	)
+	most := 1
+	for _, r := range routes {
+		most = max(most, len(r.Stops))
+	}
+	width := stopsX0 + gap*(most-1) + labelH
+	height := rowH*len(routes) + margin
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="Helvetica, Arial, sans-serif">
+`, width, height, width, height)
+	for i, r := range routes {
+		color := LineColor(r.Line, r.Mode)
+		y := margin + rowH*i + labelH
+		fmt.Fprintf(&b, "  <g>\n    <title>%s</title>\n", html.EscapeString(strings.TrimSpace(r.Line+" "+r.Headsign)))
+		fmt.Fprintf(&b, `    <rect x="%d" y="%d" width="%d" height="36" rx="6" fill="%s"/>
+    <text x="%d" y="%d" dominant-baseline="central" text-anchor="middle" font-weight="bold" font-size="20" fill="#ffffff">%s</text>
+`, margin, y-18, badge, color, margin+badge/2, y, html.EscapeString(r.Line))
+		if n := len(r.Stops); n > 1 {
+			fmt.Fprintf(&b, `    <line x1="%d" y1="%d" x2="%d" y2="%d" stroke="%s" stroke-width="8" stroke-linecap="round"/>
+`, stopsX0, y, stopsX0+gap*(n-1), y, color)
+		}
+		for j, s := range r.Stops {
+			x := stopsX0 + gap*j
+			fmt.Fprintf(&b, `    <circle cx="%d" cy="%d" r="7" fill="#ffffff" stroke="%s" stroke-width="4"/>
+    <text x="%d" y="%d" transform="rotate(-45 %d %d)" font-size="14" fill="#222222">%s</text>
+    <text x="%d" y="%d" text-anchor="middle" font-size="12" fill="#666666">%d</text>
+`, x, y, color, x+4, y-16, x+4, y-16, html.EscapeString(s.Name), x, y+30, s.Minutes)
+		}
+		b.WriteString("  </g>\n")
+	}
+	b.WriteString("</svg>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
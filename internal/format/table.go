@@ -0,0 +1,182 @@
+package format
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+// TableFormats are the --format values written by Table.
+var TableFormats = []string{"table", "csv", "tsv"}
+
+// IsTable reports whether f is one of TableFormats.
+func IsTable(f string) bool {
+	return f == "table" || f == "csv" || f == "tsv"
+}
+
+// Column is one column of tabular output: a snake_case header and how to
+// render a row's value.
+type Column[T any] struct {
+	Header string
+	Value  func(T) string
+}
+
+// Table writes rows as an aligned table with upper-case headers, as CSV,
+// or as TSV, for awk and spreadsheets. TSV values have tabs and newlines
+// replaced by spaces so every row stays one line.
+func Table[T any](w io.Writer, f string, cols []Column[T], rows []T) error {
+	record := func(row T) []string {
+		rec := make([]string, len(cols))
+		for i, c := range cols {
+			rec[i] = c.Value(row)
+		}
+		return rec
+	}
+	headers := make([]string, len(cols))
+	for i, c := range cols {
+		headers[i] = c.Header
+	}
+
+	switch f {
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write(headers)
+		for _, row := range rows {
+			cw.Write(record(row))
+		}
+		cw.Flush()
+		return cw.Error()
+	case "tsv":
+		clean := strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ", "\r", " ")
+		lines := [][]string{headers}
+		for _, row := range rows {
+			lines = append(lines, record(row))
+		}
+		for _, rec := range lines {
+			for i := range rec {
+				rec[i] = clean.Replace(rec[i])
+			}
+			if _, err := fmt.Fprintln(w, strings.Join(rec, "\t")); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.ToUpper(strings.Join(headers, "\t")))
+		for _, row := range rows {
+			fmt.Fprintln(tw, strings.Join(record(row), "\t"))
+		}
+		return tw.Flush()
+	}
+	return fmt.Errorf("unknown table format %q", f)
+}
+
+// StopDeparture is a departure with the stop it leaves from, one row of
+// departures output.
+type StopDeparture struct {
+	Stop   string
+	SiteID int
+	sl.ParsedDeparture
+}
+
+// DepartureColumns are the columns of departures --format table/csv/tsv.
+var DepartureColumns = []Column[StopDeparture]{
+	{"stop", func(d StopDeparture) string { return d.Stop }},
+	{"line", func(d StopDeparture) string { return d.Line }},
+	{"mode", func(d StopDeparture) string { return d.TransportMode }},
+	{"destination", func(d StopDeparture) string { return d.Destination }},
+	{"direction_code", func(d StopDeparture) string { return strconv.Itoa(d.DirectionCode) }},
+	{"platform", func(d StopDeparture) string { return d.Platform }},
+	{"scheduled", func(d StopDeparture) string { return clock(d.Scheduled) }},
+	{"expected", func(d StopDeparture) string { return clock(d.Expected) }},
+	{"minutes_left", func(d StopDeparture) string { return strconv.Itoa(d.MinutesLeft) }},
+	{"display", func(d StopDeparture) string { return d.Display }},
+	{"state", func(d StopDeparture) string { return d.State }},
+}
+
+// NearbyColumns are the columns of nearby --format table/csv/tsv.
+var NearbyColumns = []Column[sl.SiteWithDistance]{
+	{"site_id", func(s sl.SiteWithDistance) string { return strconv.Itoa(s.Site.ID) }},
+	{"stop", func(s sl.SiteWithDistance) string { return s.Site.Name }},
+	{"distance_m", func(s sl.SiteWithDistance) string { return strconv.Itoa(s.DistanceM) }},
+	{"lat", func(s sl.SiteWithDistance) string { return coord(s.Site.Lat) }},
+	{"lon", func(s sl.SiteWithDistance) string { return coord(s.Site.Lon) }},
+	{"types", func(s sl.SiteWithDistance) string { return strings.Join(s.Types, ",") }},
+}
+
+// NearbyLinesColumns are the columns of nearby --lines --format table/csv/tsv.
+var NearbyLinesColumns = []Column[NearbyStopWithLines]{
+	{"site_id", func(s NearbyStopWithLines) string { return strconv.Itoa(s.SiteID) }},
+	{"stop", func(s NearbyStopWithLines) string { return s.Stop }},
+	{"distance_m", func(s NearbyStopWithLines) string { return strconv.Itoa(s.DistanceM) }},
+	{"types", func(s NearbyStopWithLines) string { return strings.Join(s.Types, ",") }},
+	{"next_departure_min", func(s NearbyStopWithLines) string {
+		if s.NextDepartureMin == nil {
+			return ""
+		}
+		return strconv.Itoa(*s.NextDepartureMin)
+	}},
+	{"lines", func(s NearbyStopWithLines) string {
+		names := make([]string, len(s.Lines))
+		for i, l := range s.Lines {
+			names[i] = l.Designation
+		}
+		return strings.Join(names, ",")
+	}},
+}
+
+// DeviationColumns are the columns of deviations --format table/csv/tsv.
+var DeviationColumns = []Column[sl.Deviation]{
+	{"id", func(d sl.Deviation) string { return strconv.Itoa(d.DeviationCaseID) }},
+	{"severity", func(d sl.Deviation) string { return d.Severity }},
+	{"from", func(d sl.Deviation) string {
+		if d.Publish == nil {
+			return ""
+		}
+		return d.Publish.From
+	}},
+	{"upto", func(d sl.Deviation) string {
+		if d.Publish == nil {
+			return ""
+		}
+		return d.Publish.Upto
+	}},
+	{"lines", func(d sl.Deviation) string {
+		if d.Scope == nil {
+			return ""
+		}
+		names := make([]string, len(d.Scope.Lines))
+		for i, l := range d.Scope.Lines {
+			names[i] = l.Designation
+		}
+		return strings.Join(names, ",")
+	}},
+	{"header", func(d sl.Deviation) string { return deviationMessage(d).Header }},
+	{"scope", func(d sl.Deviation) string { return deviationMessage(d).ScopeAlias }},
+}
+
+// LineColumns are the columns of lines --format table/csv/tsv.
+var LineColumns = []Column[sl.Line]{
+	{"id", func(l sl.Line) string { return strconv.Itoa(l.ID) }},
+	{"line", func(l sl.Line) string { return l.Designation }},
+	{"mode", func(l sl.Line) string { return l.TransportMode }},
+	{"group", func(l sl.Line) string { return l.GroupOfLines }},
+}
+
+func clock(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return sl.StockholmTime(t).Format("15:04")
+}
+
+func coord(f float64) string {
+	return strconv.FormatFloat(f, 'f', 6, 64)
+}
@@ -0,0 +1,37 @@
+package format
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTable(t *testing.T) {
+	type row struct{ name, note string }
+	cols := []Column[row]{
+		{"name", func(r row) string { return r.name }},
+		{"note", func(r row) string { return r.note }},
+	}
+	rows := []row{{"Slussen", "closed, \"partly\""}, {"T-Centralen", "line 1\tline 2\nmore"}}
+
+	tests := []struct{ format, want string }{
+		{"csv", "name,note\nSlussen,\"closed, \"\"partly\"\"\"\nT-Centralen,\"line 1\tline 2\nmore\"\n"},
+		{"tsv", "name\tnote\nSlussen\tclosed, \"partly\"\nT-Centralen\tline 1 line 2 more\n"},
+		{"table", "NAME         NOTE\nSlussen      closed, \"partly\"\nT-Centralen  line 1\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if tt.format == "table" {
+			rows[1].note = "line 1"
+		}
+		if err := Table(&buf, tt.format, cols, rows); err != nil {
+			t.Fatalf("%s: %v", tt.format, err)
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.format, got, tt.want)
+		}
+	}
+
+	if err := Table(&bytes.Buffer{}, "xml", cols, rows); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
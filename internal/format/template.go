@@ -0,0 +1,85 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/template"
+)
+
+// tmpl is set by SetTemplate: results are rendered through it instead of
+// being written as JSON.
+var tmpl *template.Template
+
+var templateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// SetTemplate parses a text/template that JSON and JSONLine render results
+// through, like kubectl's -o go-template; "" goes back to JSON. Fields are
+// the Go names of the JSON output's fields, e.g. {{.MinutesLeft}}.
+func SetTemplate(text string) error {
+	if text == "" {
+		tmpl = nil
+		return nil
+	}
+	t, err := template.New("template").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return fmt.Errorf("parsing --template: %w", err)
+	}
+	tmpl = t
+	return nil
+}
+
+// Lister is implemented by results made of rows, such as a departure
+// board. The template is executed once per row instead of on the result.
+type Lister interface {
+	Rows() any
+}
+
+// executeTemplate renders v through tmpl: once per element of a list or
+// row of a Lister, each on its own line. Rows rendering to nothing are
+// skipped, so {{if}} can filter.
+func executeTemplate(w io.Writer, v any) error {
+	var out bytes.Buffer
+	for _, item := range templateItems(v) {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, item); err != nil {
+			return fmt.Errorf("executing --template: %w", err)
+		}
+		if buf.Len() == 0 {
+			continue
+		}
+		out.Write(buf.Bytes())
+		if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			out.WriteByte('\n')
+		}
+	}
+	_, err := out.WriteTo(w)
+	return err
+}
+
+// templateItems flattens v into the values the template is executed on.
+func templateItems(v any) []any {
+	if l, ok := v.(Lister); ok {
+		v = l.Rows()
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return []any{v}
+	}
+	var items []any
+	for i := range rv.Len() {
+		items = append(items, templateItems(rv.Index(i).Interface())...)
+	}
+	return items
+}
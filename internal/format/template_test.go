@@ -0,0 +1,62 @@
+package format
+
+import (
+	"bytes"
+	"testing"
+)
+
+type testBoard struct {
+	Stop  string
+	Lines []testLine
+}
+
+type testLine struct {
+	Line        string
+	MinutesLeft int
+}
+
+func (b testBoard) Rows() any { return b.Lines }
+
+func TestTemplate(t *testing.T) {
+	defer SetTemplate("")
+
+	board := testBoard{Stop: "Slussen", Lines: []testLine{{"17", 2}, {"55", 0}, {"4", 9}}}
+	tests := []struct {
+		template string
+		v        any
+		want     string
+		wantErr  bool
+	}{
+		{"{{.Line}} {{.MinutesLeft}}m", board, "17 2m\n55 0m\n4 9m\n", false},
+		{"{{if lt .MinutesLeft 5}}{{.Line}}{{end}}", board, "17\n55\n", false},
+		{"{{.Stop}}", []testBoard{board, {Stop: "Kista"}}, "", true}, // rows have no Stop
+		{"{{.Line | upper}}", []testLine{{"17a", 1}}, "17A\n", false},
+		{"{{.Stop}}: {{len .Lines}}", struct {
+			Stop  string
+			Lines []testLine
+		}{"Kista", board.Lines}, "Kista: 3\n", false},
+	}
+	for _, tt := range tests {
+		if err := SetTemplate(tt.template); err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		err := executeTemplate(&buf, tt.v)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%q: expected an error", tt.template)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%q: %v", tt.template, err)
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%q = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+
+	if err := SetTemplate("{{.Line"); err == nil {
+		t.Error("expected a parse error")
+	}
+}
@@ -0,0 +1,27 @@
+package format
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// IsTerminal reports whether stdout is a terminal rather than a pipe or file.
+func IsTerminal() bool {
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// ClearScreen clears the terminal and moves the cursor home, so a watch
+// refresh redraws the board in place. Piped output is left alone and just
+// accumulates one board after another.
+func ClearScreen() {
+	if IsTerminal() {
+		fmt.Fprint(Stdout(), "\033[H\033[2J")
+	}
+}
+
+// WatchFooter prints when a watched board was last updated.
+func WatchFooter(updated time.Time, interval time.Duration) {
+	dim.Fprintf(Stdout(), "\nUpdated %s · refreshing every %s · Ctrl-C to quit\n", updated.Format("15:04:05"), interval)
+}
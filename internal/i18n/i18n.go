@@ -0,0 +1,231 @@
+// Package i18n holds the message catalogs for user-facing CLI strings.
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Message keys shared by the formatters and the API helpers that build notes.
+const (
+	NoRoutes          = "no_routes"
+	RoutesFound       = "routes_found"
+	Route             = "route"
+	Minutes           = "minutes"
+	Changes           = "changes"
+	WithBuffer        = "with_buffer"
+	Walk              = "walk"
+	ShortTrain        = "short_train"
+	ShortTrainNoCount = "short_train_no_count"
+	BikeNotAllowed    = "bike_not_allowed"
+	NotStepFree       = "not_step_free"
+	NoWheelchair      = "no_wheelchair"
+	ElevatorOut       = "elevator_out"
+	StepFree          = "step_free"
+	NotStepFreeStop   = "not_step_free_stop"
+	Elevator          = "elevator"
+	NoElevator        = "no_elevator"
+	Escalator         = "escalator"
+	NoEscalator       = "no_escalator"
+	LowPlatform       = "low_platform"
+	NoLowPlatform     = "no_low_platform"
+	DisruptionsLines  = "disruptions_lines"
+	DisruptionsAtStop = "disruptions_at_stop"
+	LinePrefix        = "line_prefix"
+	SpokenDeparture   = "spoken_departure"
+	SpokenNow         = "spoken_now"
+	SpokenNone        = "spoken_none"
+	NextDeparture     = "next_departure"
+	NextNow           = "next_now"
+	NextPlatform      = "next_platform"
+	ChangeSpare       = "change_spare"
+	ChangeShort       = "change_short"
+	RiskSafe          = "risk_safe"
+	RiskTight         = "risk_tight"
+	RiskRisky         = "risk_risky"
+	Platform          = "platform"
+	Entrance          = "entrance"
+)
+
+var catalogs = map[string]map[string]string{
+	"en": {
+		NoRoutes:          "No routes found.",
+		RoutesFound:       "%d route(s) found",
+		Route:             "Route %d",
+		Minutes:           "%d min",
+		Changes:           "%d change(s)",
+		WithBuffer:        "%d min with buffer",
+		Walk:              "Walk",
+		ShortTrain:        "short train (%d cars)",
+		ShortTrainNoCount: "short train",
+		BikeNotAllowed:    "bikes not allowed on this leg",
+		NotStepFree:       "not step-free, stroller may need to be carried",
+		NoWheelchair:      "not step-free, no wheelchair access",
+		ElevatorOut:       "Elevator out of service at %s: %s",
+		StepFree:          "step-free",
+		NotStepFreeStop:   "not step-free",
+		Elevator:          "elevator",
+		NoElevator:        "no elevator",
+		Escalator:         "escalator",
+		NoEscalator:       "no escalator",
+		LowPlatform:       "low platform",
+		NoLowPlatform:     "high platform",
+		DisruptionsLines:  "%d disruption(s) affecting these lines:",
+		DisruptionsAtStop: "%d disruption(s) at this stop:",
+		LinePrefix:        "[Line %s] ",
+		SpokenDeparture:   "Line %s to %s in %d minutes.",
+		SpokenNow:         "Line %s to %s is leaving now.",
+		SpokenNone:        "No departures from %s.",
+		NextDeparture:     "%s to %s in %d min",
+		NextNow:           "%s to %s now",
+		NextPlatform:      " from platform %s",
+		ChangeSpare:       "Change at %s: %s, %d min to spare",
+		ChangeShort:       "Change at %s: %s, %d min short",
+		RiskSafe:          "safe",
+		RiskTight:         "tight",
+		RiskRisky:         "risky",
+		Platform:          "platform %s",
+		Entrance:          "entrance %s",
+	},
+	"sv": {
+		NoRoutes:          "Inga resor hittades.",
+		RoutesFound:       "%d resförslag hittades",
+		Route:             "Resa %d",
+		Minutes:           "%d min",
+		Changes:           "%d byte",
+		WithBuffer:        "%d min med marginal",
+		Walk:              "Gång",
+		ShortTrain:        "kort tåg (%d vagnar)",
+		ShortTrainNoCount: "kort tåg",
+		BikeNotAllowed:    "cykel får inte medföras på denna delsträcka",
+		NotStepFree:       "ej steglöst, barnvagnen kan behöva bäras",
+		NoWheelchair:      "ej steglöst, ej tillgängligt med rullstol",
+		ElevatorOut:       "Hiss ur funktion vid %s: %s",
+		StepFree:          "steglöst",
+		NotStepFreeStop:   "ej steglöst",
+		Elevator:          "hiss",
+		NoElevator:        "ingen hiss",
+		Escalator:         "rulltrappa",
+		NoEscalator:       "ingen rulltrappa",
+		LowPlatform:       "låg plattform",
+		NoLowPlatform:     "hög plattform",
+		DisruptionsLines:  "%d störning(ar) på dessa linjer:",
+		DisruptionsAtStop: "%d störning(ar) vid denna hållplats:",
+		LinePrefix:        "[Linje %s] ",
+		SpokenDeparture:   "Linje %s mot %s om %d minuter.",
+		SpokenNow:         "Linje %s mot %s går nu.",
+		SpokenNone:        "Inga avgångar från %s.",
+		NextDeparture:     "%s mot %s om %d min",
+		NextNow:           "%s mot %s nu",
+		NextPlatform:      " från läge %s",
+		ChangeSpare:       "Byte vid %s: %s, %d min marginal",
+		ChangeShort:       "Byte vid %s: %s, %d min för lite",
+		RiskSafe:          "säkert",
+		RiskTight:         "knappt",
+		RiskRisky:         "riskabelt",
+		Platform:          "läge %s",
+		Entrance:          "uppgång %s",
+	},
+}
+
+// Pseudo is a pseudo-locale for layout testing: every English message is
+// accented, bracketed and padded to about 140% of its length, so truncation
+// and column alignment problems with longer translations show up in English.
+const Pseudo = "x-pseudo"
+
+func init() {
+	pseudo := make(map[string]string, len(catalogs["en"]))
+	for key, msg := range catalogs["en"] {
+		pseudo[key] = pseudoize(msg)
+	}
+	catalogs[Pseudo] = pseudo
+}
+
+var pseudoLetters = map[rune]rune{
+	'a': 'á', 'e': 'é', 'i': 'í', 'o': 'ö', 'u': 'ü', 'y': 'ý', 'c': 'ç', 'n': 'ñ', 's': 'š', 'z': 'ž',
+	'A': 'Å', 'E': 'É', 'I': 'Í', 'O': 'Ö', 'U': 'Ü', 'Y': 'Ý', 'C': 'Ç', 'N': 'Ñ', 'S': 'Š', 'Z': 'Ž',
+}
+
+// pseudoize rewrites a message for the pseudo-locale, leaving fmt verbs
+// such as %d and %-5s intact.
+func pseudoize(msg string) string {
+	var b strings.Builder
+	b.WriteRune('[')
+	letters := 0
+	runes := []rune(msg)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r == '%' {
+			// Copy the verb through its terminating letter (or a literal %%).
+			j := i + 1
+			for j < len(runes) && !unicode.IsLetter(runes[j]) && runes[j] != '%' {
+				j++
+			}
+			b.WriteString(string(runes[i:min(j+1, len(runes))]))
+			i = j
+			continue
+		}
+		if unicode.IsLetter(r) {
+			letters++
+		}
+		if p, ok := pseudoLetters[r]; ok {
+			r = p
+		}
+		b.WriteRune(r)
+	}
+	b.WriteString(strings.Repeat("~", (letters*2+4)/5))
+	b.WriteRune(']')
+	return b.String()
+}
+
+var current = "en"
+
+// SetLanguage selects the catalog used by T. Supported: sv, en, and the
+// x-pseudo layout-testing locale.
+func SetLanguage(lang string) error {
+	lang = strings.ToLower(lang)
+	if _, ok := catalogs[lang]; !ok {
+		return fmt.Errorf("unsupported language %q (use %s)", lang, strings.Join(Languages(), " or "))
+	}
+	current = lang
+	return nil
+}
+
+// Language returns the selected language code, as sent to the SL APIs and
+// used to pick deviation texts. The pseudo-locale reports en.
+func Language() string {
+	if current == Pseudo {
+		return "en"
+	}
+	return current
+}
+
+// Languages lists the supported language codes, not counting the
+// pseudo-locale.
+func Languages() []string {
+	langs := make([]string, 0, len(catalogs))
+	for l := range catalogs {
+		if l != Pseudo {
+			langs = append(langs, l)
+		}
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// T returns the message for key in the selected language, formatted with
+// args. Missing translations fall back to English, then to the key itself.
+func T(key string, args ...any) string {
+	msg, ok := catalogs[current][key]
+	if !ok {
+		if msg, ok = catalogs["en"][key]; !ok {
+			msg = key
+		}
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
@@ -0,0 +1,54 @@
+package i18n
+
+import "testing"
+
+func TestCatalogsComplete(t *testing.T) {
+	for lang, msgs := range catalogs {
+		for key := range catalogs["en"] {
+			if _, ok := msgs[key]; !ok {
+				t.Errorf("%s catalog is missing %q", lang, key)
+			}
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	defer SetLanguage("en")
+
+	if got := T(ShortTrain, 6); got != "short train (6 cars)" {
+		t.Errorf("en: got %q", got)
+	}
+	if err := SetLanguage("SV"); err != nil {
+		t.Fatal(err)
+	}
+	if got := T(ShortTrain, 6); got != "kort tåg (6 vagnar)" {
+		t.Errorf("sv: got %q", got)
+	}
+	if err := SetLanguage("de"); err == nil {
+		t.Error("expected error for unsupported language")
+	}
+	if Language() != "sv" {
+		t.Errorf("failed SetLanguage should keep the previous language, got %q", Language())
+	}
+}
+
+func TestPseudo(t *testing.T) {
+	defer SetLanguage("en")
+
+	if got := pseudoize("%d change(s)"); got != "[%d çháñgé(š)~~~]" {
+		t.Errorf("pseudoize = %q", got)
+	}
+	if got := pseudoize("100%% %-5s done"); got != "[100%% %-5s döñé~~]" {
+		t.Errorf("pseudoize kept verbs wrong: %q", got)
+	}
+
+	if err := SetLanguage(Pseudo); err != nil {
+		t.Fatal(err)
+	}
+	if got := T(ShortTrain, 6); got != "[šhört tráíñ (6 çárš)~~~~~~]" {
+		t.Errorf("pseudo: got %q", got)
+	}
+	if Language() != "en" {
+		t.Errorf("pseudo-locale should report en to the APIs, got %q", Language())
+	}
+}
@@ -0,0 +1,98 @@
+package keys
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// keychainService is the service name keys are filed under in the OS keychain.
+const keychainService = "sl-cli"
+
+// keychain is an OS secret store driven through its command-line tool.
+type keychain interface {
+	get(name string) (string, error)
+	set(name, value string) error
+	delete(name string) error
+}
+
+// detectKeychain returns the OS keychain, or nil when none is available:
+// the macOS login keychain via security(1), or the freedesktop Secret
+// Service via secret-tool(1).
+func detectKeychain() keychain {
+	switch runtime.GOOS {
+	case "darwin":
+		if _, err := exec.LookPath("security"); err == nil {
+			return macKeychain{}
+		}
+	case "linux", "freebsd", "openbsd":
+		if _, err := exec.LookPath("secret-tool"); err == nil {
+			return secretTool{}
+		}
+	}
+	return nil
+}
+
+type macKeychain struct{}
+
+func (macKeychain) get(name string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", keychainService, "-a", name, "-w").Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+func (macKeychain) set(name, value string) error {
+	// -w with the secret would put it in argv, for ps to see; security -i
+	// reads the command from stdin instead. It reports a failing command
+	// on stderr but still exits 0.
+	script, err := macAddCommand(name, value)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(script)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return err
+	}
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		return errors.New(msg)
+	}
+	return nil
+}
+
+// macAddCommand is the security -i command line storing value for name.
+func macAddCommand(name, value string) (string, error) {
+	if strings.ContainsAny(value, "\r\n") {
+		return "", errors.New("key contains a line break")
+	}
+	quote := func(s string) string {
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+	}
+	return fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n", quote(keychainService), quote(name), quote(value)), nil
+}
+
+func (macKeychain) delete(name string) error {
+	return exec.Command("security", "delete-generic-password", "-s", keychainService, "-a", name).Run()
+}
+
+type secretTool struct{}
+
+func (secretTool) get(name string) (string, error) {
+	out, err := exec.Command("secret-tool", "lookup", "service", keychainService, "account", name).Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+func (secretTool) set(name, value string) error {
+	// secret-tool reads the secret from stdin, keeping it out of argv.
+	cmd := exec.Command("secret-tool", "store", "--label", keychainService+" "+name, "service", keychainService, "account", name)
+	cmd.Stdin = strings.NewReader(value)
+	return cmd.Run()
+}
+
+func (secretTool) delete(name string) error {
+	return exec.Command("secret-tool", "clear", "service", keychainService, "account", name).Run()
+}
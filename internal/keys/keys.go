@@ -0,0 +1,195 @@
+// Package keys stores API keys for the integrations that need them, in the
+// OS keychain where one is available and in a mode-600 file otherwise.
+package keys
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Names of the keyed integrations.
+const (
+	TrafiklabRealtime = "trafiklab-realtime"
+	TrafiklabStatic   = "trafiklab-static"
+	ResRobot          = "resrobot"
+	Trafikverket      = "trafikverket"
+)
+
+// Names lists every key the CLI knows about.
+var Names = []string{TrafiklabRealtime, TrafiklabStatic, ResRobot, Trafikverket}
+
+// Storage backends reported by Set and List.
+const (
+	BackendKeychain = "keychain"
+	BackendFile     = "file"
+)
+
+// MissingKeyError is returned when a keyed feature is used without a key.
+type MissingKeyError struct {
+	Name string
+}
+
+func (e *MissingKeyError) Error() string {
+	return fmt.Sprintf("no %s API key configured — get one at https://developer.trafiklab.se and run: sl keys set %s <key>", e.Name, e.Name)
+}
+
+// userConfigDir and systemKeychain are swapped out in tests.
+var (
+	userConfigDir  = os.UserConfigDir
+	systemKeychain = detectKeychain
+)
+
+// Validate reports an error for key names the CLI doesn't use.
+func Validate(name string) error {
+	if !slices.Contains(Names, name) {
+		return fmt.Errorf("unknown key %q (use %s)", name, strings.Join(Names, ", "))
+	}
+	return nil
+}
+
+// Get returns the stored key, or a *MissingKeyError when none is set.
+func Get(name string) (string, error) {
+	if kc := systemKeychain(); kc != nil {
+		if v, err := kc.get(name); err == nil && v != "" {
+			return v, nil
+		}
+	}
+	stored, err := readFile()
+	if err != nil {
+		return "", err
+	}
+	if v := stored[name]; v != "" {
+		return v, nil
+	}
+	return "", &MissingKeyError{Name: name}
+}
+
+// Set stores a key, preferring the OS keychain, and returns the backend used.
+func Set(name, value string) (string, error) {
+	if err := Validate(name); err != nil {
+		return "", err
+	}
+	if value = strings.TrimSpace(value); value == "" {
+		return "", errors.New("key is empty")
+	}
+	if kc := systemKeychain(); kc != nil {
+		if err := kc.set(name, value); err == nil {
+			// Don't leave a stale copy behind in the fallback file.
+			if err := removeFromFile(name); err != nil {
+				return BackendKeychain, fmt.Errorf("stored in the keychain, but an old copy is left in the keys file: %w", err)
+			}
+			return BackendKeychain, nil
+		}
+	}
+	stored, err := readFile()
+	if err != nil {
+		return "", err
+	}
+	stored[name] = value
+	return BackendFile, writeFile(stored)
+}
+
+// Remove deletes a key from every backend.
+func Remove(name string) error {
+	if err := Validate(name); err != nil {
+		return err
+	}
+	if kc := systemKeychain(); kc != nil {
+		kc.delete(name)
+	}
+	return removeFromFile(name)
+}
+
+// Status describes whether a key is configured and where.
+type Status struct {
+	Name    string `json:"name"`
+	Set     bool   `json:"set"`
+	Backend string `json:"backend,omitempty"`
+}
+
+// List reports the status of every known key. Values are never returned.
+func List() ([]Status, error) {
+	stored, err := readFile()
+	if err != nil {
+		return nil, err
+	}
+	kc := systemKeychain()
+	result := make([]Status, 0, len(Names))
+	for _, name := range Names {
+		s := Status{Name: name}
+		if kc != nil {
+			if v, err := kc.get(name); err == nil && v != "" {
+				s.Set, s.Backend = true, BackendKeychain
+			}
+		}
+		if !s.Set && stored[name] != "" {
+			s.Set, s.Backend = true, BackendFile
+		}
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+// keysPath returns the fallback key file under the user's config dir.
+func keysPath() (string, error) {
+	base, err := userConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "sl-cli", "keys.json"), nil
+}
+
+func readFile() (map[string]string, error) {
+	path, err := keysPath()
+	if err != nil {
+		return nil, err
+	}
+	stored := make(map[string]string)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return stored, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading keys: %w", err)
+	}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return stored, nil
+}
+
+func writeFile(stored map[string]string) error {
+	path, err := keysPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("writing keys: %w", err)
+	}
+	// WriteFile keeps the mode of an existing file; tighten it regardless.
+	return os.Chmod(path, 0o600)
+}
+
+func removeFromFile(name string) error {
+	stored, err := readFile()
+	if err != nil {
+		return err
+	}
+	if _, ok := stored[name]; !ok {
+		return nil
+	}
+	delete(stored, name)
+	return writeFile(stored)
+}
@@ -0,0 +1,121 @@
+package keys
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// useFileBackend points the store at a temp dir with no OS keychain.
+func useFileBackend(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	userConfigDir = func() (string, error) { return dir, nil }
+	systemKeychain = func() keychain { return nil }
+	t.Cleanup(func() {
+		userConfigDir = os.UserConfigDir
+		systemKeychain = detectKeychain
+	})
+	return filepath.Join(dir, "sl-cli", "keys.json")
+}
+
+func TestSetGet_FileFallback(t *testing.T) {
+	path := useFileBackend(t)
+
+	var missing *MissingKeyError
+	if _, err := Get(ResRobot); !errors.As(err, &missing) {
+		t.Fatalf("expected MissingKeyError, got %v", err)
+	}
+
+	backend, err := Set(ResRobot, " abc123 \n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backend != BackendFile {
+		t.Errorf("backend = %q, want file", backend)
+	}
+	if v, err := Get(ResRobot); err != nil || v != "abc123" {
+		t.Errorf("Get = %q, %v", v, err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("key file mode = %o, want 600", perm)
+	}
+
+	if err := Remove(ResRobot); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Get(ResRobot); !errors.As(err, &missing) {
+		t.Errorf("expected key to be removed, got %v", err)
+	}
+}
+
+func TestSet_UnknownName(t *testing.T) {
+	useFileBackend(t)
+	if _, err := Set("google", "x"); err == nil {
+		t.Error("expected error for unknown key name")
+	}
+}
+
+func TestList(t *testing.T) {
+	useFileBackend(t)
+	Set(Trafikverket, "tv-key")
+
+	statuses, err := List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != len(Names) {
+		t.Fatalf("got %d statuses, want %d", len(statuses), len(Names))
+	}
+	for _, s := range statuses {
+		if want := s.Name == Trafikverket; s.Set != want {
+			t.Errorf("%s: set = %v, want %v", s.Name, s.Set, want)
+		}
+	}
+}
+
+// memKeychain is an in-memory keychain.
+type memKeychain map[string]string
+
+func (k memKeychain) get(name string) (string, error) { return k[name], nil }
+func (k memKeychain) set(name, value string) error    { k[name] = value; return nil }
+func (k memKeychain) delete(name string) error        { delete(k, name); return nil }
+
+func TestSet_KeychainReportsStaleFileCopy(t *testing.T) {
+	path := useFileBackend(t)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	kc := memKeychain{}
+	systemKeychain = func() keychain { return kc }
+
+	backend, err := Set(ResRobot, "abc123")
+	if err == nil {
+		t.Error("expected an error when the old file copy can't be removed")
+	}
+	if backend != BackendKeychain || kc[ResRobot] != "abc123" {
+		t.Errorf("backend = %q, keychain = %v; the key should still be in the keychain", backend, kc)
+	}
+}
+
+func TestMacAddCommand(t *testing.T) {
+	got, err := macAddCommand(ResRobot, `a"b\c`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `add-generic-password -U -s "sl-cli" -a "resrobot" -w "a\"b\\c"` + "\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if _, err := macAddCommand(ResRobot, "a\nb"); err == nil {
+		t.Error("expected an error for a key with a line break")
+	}
+}
@@ -0,0 +1,263 @@
+package sandbox
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+// gtfsFeed is the bundled GTFS static feed, and what the sandbox needs of
+// it to make up vehicle positions.
+type gtfsFeed struct {
+	zip   []byte
+	stops map[string][2]float64 // stop_id → lat, lon
+	trips []gtfsTrip
+}
+
+// gtfsTrip is one run of a line, with its calls in order.
+type gtfsTrip struct {
+	id, route, line string
+	direction       int // direction_id
+	calls           []gtfsCall
+}
+
+type gtfsCall struct {
+	stop string
+	secs int // seconds after midnight of the service day
+}
+
+// csvTable is a GTFS file: its header and rows.
+type csvTable struct {
+	header []string
+	rows   [][]string
+}
+
+func (t *csvTable) col(name string) int {
+	for i, h := range t.header {
+		if h == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// loadGTFS reads the feed in dir. Trips listed in frequencies.txt are
+// templates, their stop times counting from 00:00:00: each is expanded
+// into one trip per headway, since sl's timetable reader only knows
+// stop_times.txt.
+func loadGTFS(fsys fs.FS, dir string) (*gtfsFeed, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	tables := map[string]*csvTable{}
+	for _, e := range entries {
+		data, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("gtfs/%s: %w", e.Name(), err)
+		}
+		if len(records) == 0 {
+			return nil, fmt.Errorf("gtfs/%s is empty", e.Name())
+		}
+		tables[e.Name()] = &csvTable{header: records[0], rows: records[1:]}
+	}
+	if err := expandFrequencies(tables); err != nil {
+		return nil, err
+	}
+
+	feed := &gtfsFeed{stops: map[string][2]float64{}}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, t := range tables {
+		w, err := zw.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		cw := csv.NewWriter(w)
+		cw.Write(t.header)
+		cw.WriteAll(t.rows)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	feed.zip = buf.Bytes()
+
+	if t := tables["stops.txt"]; t != nil {
+		id, lat, lon := t.col("stop_id"), t.col("stop_lat"), t.col("stop_lon")
+		for _, r := range t.rows {
+			la, _ := strconv.ParseFloat(r[lat], 64)
+			lo, _ := strconv.ParseFloat(r[lon], 64)
+			feed.stops[r[id]] = [2]float64{la, lo}
+		}
+	}
+	feed.trips = readTrips(tables)
+	return feed, nil
+}
+
+// expandFrequencies replaces each template trip in frequencies.txt by its
+// runs, named <trip_id>-HHMM after their first departure, and drops
+// frequencies.txt.
+func expandFrequencies(tables map[string]*csvTable) error {
+	freq, trips, times := tables["frequencies.txt"], tables["trips.txt"], tables["stop_times.txt"]
+	if freq == nil {
+		return nil
+	}
+	if trips == nil || times == nil {
+		return errors.New("gtfs: frequencies.txt needs trips.txt and stop_times.txt")
+	}
+	delete(tables, "frequencies.txt")
+
+	starts := map[string][]int{}
+	fTrip, fStart, fEnd, fHeadway := freq.col("trip_id"), freq.col("start_time"), freq.col("end_time"), freq.col("headway_secs")
+	for _, r := range freq.rows {
+		start, ok1 := parseTime(r[fStart])
+		end, ok2 := parseTime(r[fEnd])
+		headway, err := strconv.Atoi(r[fHeadway])
+		if !ok1 || !ok2 || err != nil || headway <= 0 {
+			return fmt.Errorf("gtfs: bad frequency for %s", r[fTrip])
+		}
+		for t := start; t < end; t += headway {
+			starts[r[fTrip]] = append(starts[r[fTrip]], t)
+		}
+	}
+	runID := func(trip string, start int) string {
+		return fmt.Sprintf("%s-%02d%02d", trip, start/3600, start/60%60)
+	}
+
+	tTrip := trips.col("trip_id")
+	var tripRows [][]string
+	for _, r := range trips.rows {
+		if len(starts[r[tTrip]]) == 0 {
+			tripRows = append(tripRows, r)
+			continue
+		}
+		for _, start := range starts[r[tTrip]] {
+			run := append([]string(nil), r...)
+			run[tTrip] = runID(r[tTrip], start)
+			tripRows = append(tripRows, run)
+		}
+	}
+	trips.rows = tripRows
+
+	sTrip, sArr, sDep := times.col("trip_id"), times.col("arrival_time"), times.col("departure_time")
+	var timeRows [][]string
+	for _, r := range times.rows {
+		if len(starts[r[sTrip]]) == 0 {
+			timeRows = append(timeRows, r)
+			continue
+		}
+		arr, _ := parseTime(r[sArr])
+		dep, _ := parseTime(r[sDep])
+		for _, start := range starts[r[sTrip]] {
+			run := append([]string(nil), r...)
+			run[sTrip] = runID(r[sTrip], start)
+			run[sArr] = formatTime(start + arr)
+			run[sDep] = formatTime(start + dep)
+			timeRows = append(timeRows, run)
+		}
+	}
+	times.rows = timeRows
+	return nil
+}
+
+// readTrips collects the trips of the (expanded) feed with their calls.
+func readTrips(tables map[string]*csvTable) []gtfsTrip {
+	trips, times, routes := tables["trips.txt"], tables["stop_times.txt"], tables["routes.txt"]
+	if trips == nil || times == nil || routes == nil {
+		return nil
+	}
+	lines := map[string]string{}
+	rID, rName := routes.col("route_id"), routes.col("route_short_name")
+	for _, r := range routes.rows {
+		lines[r[rID]] = r[rName]
+	}
+
+	index := map[string]int{}
+	var out []gtfsTrip
+	tID, tRoute, tDir := trips.col("trip_id"), trips.col("route_id"), trips.col("direction_id")
+	for _, r := range trips.rows {
+		dir, _ := strconv.Atoi(r[tDir])
+		index[r[tID]] = len(out)
+		out = append(out, gtfsTrip{id: r[tID], route: r[tRoute], line: lines[r[tRoute]], direction: dir})
+	}
+	// The fixture lists stop times in sequence order.
+	sTrip, sStop, sDep := times.col("trip_id"), times.col("stop_id"), times.col("departure_time")
+	for _, r := range times.rows {
+		i, ok := index[r[sTrip]]
+		if !ok {
+			continue
+		}
+		secs, _ := parseTime(r[sDep])
+		out[i].calls = append(out[i].calls, gtfsCall{stop: r[sStop], secs: secs})
+	}
+	return out
+}
+
+// vehiclesAt places a vehicle on every trip under way at now, between the
+// stops it last left and calls at next.
+func (f *gtfsFeed) vehiclesAt(now time.Time) []vehicle {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	secs := int(now.Sub(midnight).Seconds())
+	var out []vehicle
+	// Trips of yesterday's service day run past midnight as 24:00 and on.
+	for _, day := range []int{secs, secs + 24*3600} {
+		for _, t := range f.trips {
+			for i := 1; i < len(t.calls); i++ {
+				from, to := t.calls[i-1], t.calls[i]
+				if day < from.secs || day >= to.secs {
+					continue
+				}
+				a, b := f.stops[from.stop], f.stops[to.stop]
+				frac := float64(day-from.secs) / float64(to.secs-from.secs)
+				km := sl.DistanceKm(a[0], a[1], b[0], b[1])
+				out = append(out, vehicle{
+					trip:      t,
+					lat:       a[0] + (b[0]-a[0])*frac,
+					lon:       a[1] + (b[1]-a[1])*frac,
+					bearing:   sl.BearingDeg(a[0], a[1], b[0], b[1]),
+					speedMS:   km * 1000 / float64(to.secs-from.secs),
+					nextStop:  to.stop,
+					timestamp: now,
+				})
+				break
+			}
+		}
+	}
+	return out
+}
+
+func parseTime(s string) (int, bool) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return 0, false
+	}
+	secs := 0
+	for _, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return 0, false
+		}
+		secs = secs*60 + n
+	}
+	return secs, true
+}
+
+func formatTime(secs int) string {
+	return fmt.Sprintf("%02d:%02d:%02d", secs/3600, secs/60%60, secs%60)
+}
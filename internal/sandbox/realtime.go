@@ -0,0 +1,110 @@
+package sandbox
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+)
+
+// vehicle is a made-up position report for a trip under way.
+type vehicle struct {
+	trip      gtfsTrip
+	lat, lon  float64
+	bearing   float64
+	speedMS   float64
+	nextStop  string
+	timestamp time.Time
+}
+
+// GTFS Realtime field numbers and values written by encodeVehicles; see
+// https://gtfs.org/realtime/reference/.
+const (
+	feedHeader      = 1
+	feedEntity      = 2
+	headerVersion   = 1
+	headerTime      = 3
+	entityID        = 1
+	entityVehicle   = 4
+	vehicleTrip     = 1
+	vehiclePos      = 2
+	vehicleStatus   = 4
+	vehicleTime     = 5
+	vehicleStop     = 7
+	vehicleDesc     = 8
+	tripID          = 1
+	tripRoute       = 5
+	tripDirection   = 6
+	posLat          = 1
+	posLon          = 2
+	posBearing      = 3
+	posSpeed        = 5
+	descID          = 1
+	descLabel       = 2
+	statusInTransit = 2
+)
+
+// encodeVehicles writes a GTFS-RT FeedMessage of vehicle positions, or an
+// empty one (a trip updates feed with no updates) for no vehicles.
+func encodeVehicles(vehicles []vehicle, now time.Time) []byte {
+	header := concat(
+		bytesField(headerVersion, []byte("2.0")),
+		varintField(headerTime, uint64(now.Unix())),
+	)
+	msg := bytesField(feedHeader, header)
+	for _, v := range vehicles {
+		trip := concat(
+			bytesField(tripID, []byte(v.trip.id)),
+			bytesField(tripRoute, []byte(v.trip.route)),
+			varintField(tripDirection, uint64(v.trip.direction)),
+		)
+		pos := concat(
+			floatField(posLat, v.lat),
+			floatField(posLon, v.lon),
+			floatField(posBearing, v.bearing),
+			floatField(posSpeed, v.speedMS),
+		)
+		desc := concat(
+			bytesField(descID, []byte("sandbox-"+v.trip.id)),
+			bytesField(descLabel, []byte(v.trip.line)),
+		)
+		vp := concat(
+			bytesField(vehicleTrip, trip),
+			bytesField(vehiclePos, pos),
+			varintField(vehicleStatus, statusInTransit),
+			varintField(vehicleTime, uint64(v.timestamp.Unix())),
+			bytesField(vehicleStop, []byte(v.nextStop)),
+			bytesField(vehicleDesc, desc),
+		)
+		entity := concat(
+			bytesField(entityID, []byte("v-"+v.trip.id)),
+			bytesField(entityVehicle, vp),
+		)
+		msg = append(msg, bytesField(feedEntity, entity)...)
+	}
+	return msg
+}
+
+func key(num, wire int) []byte {
+	return binary.AppendUvarint(nil, uint64(num<<3|wire))
+}
+
+func varintField(num int, v uint64) []byte {
+	return binary.AppendUvarint(key(num, 0), v)
+}
+
+func bytesField(num int, b []byte) []byte {
+	out := binary.AppendUvarint(key(num, 2), uint64(len(b)))
+	return append(out, b...)
+}
+
+func floatField(num int, f float64) []byte {
+	return binary.LittleEndian.AppendUint32(key(num, 5), math.Float32bits(float32(f)))
+}
+
+func concat(parts ...[]byte) []byte {
+	var out []byte
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
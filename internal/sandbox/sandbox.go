@@ -0,0 +1,419 @@
+// Package sandbox serves the SL Transport, Deviations and Journey Planner
+// APIs from a bundled snapshot of recorded responses, for sl --sandbox and,
+// through internal/apitest, for the command tests. Departure times are
+// replayed relative to the current time, so boards never go stale.
+//
+// It also stands in for the services around them: a small GTFS static
+// feed for the same stops and lines, GTFS-RT vehicle positions made up
+// from its timetable, and IP geolocation.
+package sandbox
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/glundgren93/sl-cli/internal/config"
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+//go:embed fixtures
+var fixtures embed.FS
+
+// FixtureBase is the wall-clock time the recorded departure fixtures were
+// captured at. Served departure times are shifted by (now - FixtureBase), so a
+// departure recorded at 08:05 is always five minutes away.
+var FixtureBase = time.Date(2024, 3, 1, 8, 0, 0, 0, stockholm())
+
+// ErrOffline is returned for requests to services the sandbox doesn't
+// stand in for.
+var ErrOffline = errors.New("no network access in the sandbox")
+
+// Server is an in-memory SL API. Fields may be modified between requests.
+type Server struct {
+	mu sync.Mutex
+
+	Sites      []sl.Site
+	StopPoints []sl.StopPointDetail
+	Lines      map[string][]sl.Line
+	Departures map[int]sl.DeparturesResponse
+	Deviations []sl.Deviation
+	Locations  []sl.Location
+	Journeys   sl.JourneyResponse
+	// StopEvents are the planner's departure monitor; a request gets those
+	// at the name_dm stop or one of its platforms.
+	StopEvents []sl.StopEvent
+
+	// GeoIP is the IP geolocation response.
+	GeoIP json.RawMessage
+
+	// Config is the sample config.json sl --sandbox runs with instead of
+	// the user's: favorites and a commute among the bundled stops.
+	Config config.Config
+
+	// Requests records the path and query of every request served.
+	Requests []string
+
+	gtfs *gtfsFeed
+	mux  *http.ServeMux
+}
+
+// New returns a server seeded from the bundled fixtures.
+func New() (*Server, error) {
+	sub, err := fs.Sub(fixtures, "fixtures")
+	if err != nil {
+		return nil, err
+	}
+	return Load(sub)
+}
+
+// Load reads fixtures from fsys: sites.json, stop-points.json, lines.json,
+// deviations.json, stop-finder.json, trips.json, departure-monitor.json,
+// geoip.json, config.json, departures/<site>.json and the GTFS files in
+// gtfs/.
+// Missing files leave the corresponding data empty.
+func Load(fsys fs.FS) (*Server, error) {
+	s := &Server{
+		Lines:      map[string][]sl.Line{},
+		Departures: map[int]sl.DeparturesResponse{},
+	}
+
+	var finder sl.StopFinderResponse
+	var monitor sl.DepartureMonitorResponse
+	files := map[string]any{
+		"sites.json":             &s.Sites,
+		"stop-points.json":       &s.StopPoints,
+		"lines.json":             &s.Lines,
+		"deviations.json":        &s.Deviations,
+		"stop-finder.json":       &finder,
+		"trips.json":             &s.Journeys,
+		"departure-monitor.json": &monitor,
+		"geoip.json":             &s.GeoIP,
+		"config.json":            &s.Config,
+	}
+	for name, dst := range files {
+		if err := readJSON(fsys, name, dst); err != nil {
+			return nil, err
+		}
+	}
+	s.Locations = finder.Locations
+	s.StopEvents = monitor.StopEvents
+
+	entries, err := fs.ReadDir(fsys, "departures")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	for _, e := range entries {
+		id, err := strconv.Atoi(strings.TrimSuffix(e.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+		var resp sl.DeparturesResponse
+		if err := readJSON(fsys, path.Join("departures", e.Name()), &resp); err != nil {
+			return nil, err
+		}
+		s.Departures[id] = resp
+	}
+	if s.gtfs, err = loadGTFS(fsys, "gtfs"); err != nil {
+		return nil, err
+	}
+
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("GET /transport/v1/sites", s.handleSites)
+	s.mux.HandleFunc("GET /transport/v1/stop-points", s.handleStopPoints)
+	s.mux.HandleFunc("GET /transport/v1/lines", s.handleLines)
+	s.mux.HandleFunc("GET /transport/v1/sites/{id}/departures", s.handleDepartures)
+	s.mux.HandleFunc("GET /deviations/v1/messages", s.handleDeviations)
+	s.mux.HandleFunc("GET /planner/v2/stop-finder", s.handleStopFinder)
+	s.mux.HandleFunc("GET /planner/v2/trips", s.handleTrips)
+	s.mux.HandleFunc("GET /planner/v2/departure-monitor", s.handleDepartureMonitor)
+	s.mux.HandleFunc("GET /gtfs/sl/sl.zip", s.handleGTFS)
+	s.mux.HandleFunc("GET /gtfs-rt/sl/VehiclePositions.pb", s.handleVehiclePositions)
+	s.mux.HandleFunc("GET /gtfs-rt/sl/TripUpdates.pb", s.handleTripUpdates)
+	s.mux.HandleFunc("GET /geoip/", s.handleGeoIP) // /json/ and /{ip}/json/
+	return s, nil
+}
+
+func readJSON(fsys fs.FS, name string, dst any) error {
+	data, err := fs.ReadFile(fsys, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, dst); err != nil {
+		return fmt.Errorf("%s: %w", path.Base(name), err)
+	}
+	return nil
+}
+
+// ServeHTTP answers the SL APIs mounted at /transport/v1, /deviations/v1
+// and /planner/v2, the GTFS feeds at /gtfs/sl and /gtfs-rt/sl, and IP
+// geolocation at /geoip.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.Requests = append(s.Requests, r.URL.RequestURI())
+	s.mu.Unlock()
+	s.mux.ServeHTTP(w, r)
+}
+
+// RequestCount returns how many requests were made to paths starting with prefix
+// (e.g. "/transport/v1/sites/9191/departures").
+func (s *Server) RequestCount(prefix string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, r := range s.Requests {
+		if strings.HasPrefix(r, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, v any) {
+	s.mu.Lock()
+	data, err := json.Marshal(v)
+	s.mu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+func (s *Server) handleSites(w http.ResponseWriter, r *http.Request) {
+	s.writeJSON(w, s.Sites)
+}
+
+func (s *Server) handleStopPoints(w http.ResponseWriter, r *http.Request) {
+	s.writeJSON(w, s.StopPoints)
+}
+
+func (s *Server) handleLines(w http.ResponseWriter, r *http.Request) {
+	s.writeJSON(w, s.Lines)
+}
+
+func (s *Server) handleDepartures(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, `{"message":"invalid site id"}`, http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	resp, ok := s.Departures[id]
+	s.mu.Unlock()
+	if !ok {
+		resp = sl.DeparturesResponse{Departures: []sl.Departure{}}
+	}
+
+	shift := time.Now().In(stockholm()).Sub(FixtureBase)
+	mode := r.URL.Query().Get("transport")
+	direction, _ := strconv.Atoi(r.URL.Query().Get("direction"))
+
+	out := sl.DeparturesResponse{Departures: []sl.Departure{}, StopDeviations: resp.StopDeviations}
+	for _, d := range resp.Departures {
+		if mode != "" && (d.Line == nil || !strings.EqualFold(d.Line.TransportMode, mode)) {
+			continue
+		}
+		if direction != 0 && d.DirectionCode != direction {
+			continue
+		}
+		d.Scheduled = shiftTime(d.Scheduled, shift)
+		d.Expected = shiftTime(d.Expected, shift)
+		out.Departures = append(out.Departures, d)
+	}
+	s.writeJSON(w, out)
+}
+
+func (s *Server) handleDeviations(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	modes := q["transport_mode"]
+	sites := q["site"]
+	lines := q["line"]
+
+	s.mu.Lock()
+	all := s.Deviations
+	areas := siteStopAreas(s.Sites, sites)
+	s.mu.Unlock()
+
+	out := []sl.Deviation{}
+	for _, d := range all {
+		if len(modes) > 0 && !deviationHasMode(d, modes) {
+			continue
+		}
+		if len(sites) > 0 && !deviationHasStopArea(d, areas) {
+			continue
+		}
+		if len(lines) > 0 && !deviationHasLineID(d, lines) {
+			continue
+		}
+		out = append(out, d)
+	}
+	s.writeJSON(w, out)
+}
+
+func (s *Server) handleStopFinder(w http.ResponseWriter, r *http.Request) {
+	name := strings.ToLower(r.URL.Query().Get("name_sf"))
+
+	s.mu.Lock()
+	all := s.Locations
+	s.mu.Unlock()
+
+	out := sl.StopFinderResponse{Locations: []sl.Location{}}
+	for _, l := range all {
+		if name == "" || strings.Contains(strings.ToLower(l.Name), name) {
+			out.Locations = append(out.Locations, l)
+		}
+	}
+	s.writeJSON(w, out)
+}
+
+func (s *Server) handleTrips(w http.ResponseWriter, r *http.Request) {
+	s.writeJSON(w, s.Journeys)
+}
+
+func (s *Server) handleDepartureMonitor(w http.ResponseWriter, r *http.Request) {
+	stop := r.URL.Query().Get("name_dm")
+
+	s.mu.Lock()
+	all := s.StopEvents
+	s.mu.Unlock()
+
+	shift := time.Now().In(stockholm()).Sub(FixtureBase)
+	out := sl.DepartureMonitorResponse{StopEvents: []sl.StopEvent{}}
+	for _, e := range all {
+		if e.Location == nil || (e.Location.ID != stop && (e.Location.Parent == nil || e.Location.Parent.ID != stop)) {
+			continue
+		}
+		e.DepartureTimePlanned = shiftPlannerTime(e.DepartureTimePlanned, shift)
+		e.DepartureTimeEstimated = shiftPlannerTime(e.DepartureTimeEstimated, shift)
+		out.StopEvents = append(out.StopEvents, e)
+	}
+	s.writeJSON(w, out)
+}
+
+func (s *Server) handleGTFS(w http.ResponseWriter, r *http.Request) {
+	if s.gtfs == nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Write(s.gtfs.zip)
+}
+
+func (s *Server) handleVehiclePositions(w http.ResponseWriter, r *http.Request) {
+	if s.gtfs == nil {
+		http.NotFound(w, r)
+		return
+	}
+	now := time.Now().In(stockholm())
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.Write(encodeVehicles(s.gtfs.vehiclesAt(now), now))
+}
+
+// handleTripUpdates serves a feed without updates: vehicles run on time.
+func (s *Server) handleTripUpdates(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.Write(encodeVehicles(nil, time.Now()))
+}
+
+func (s *Server) handleGeoIP(w http.ResponseWriter, r *http.Request) {
+	s.writeJSON(w, s.GeoIP)
+}
+
+func deviationHasMode(d sl.Deviation, modes []string) bool {
+	if d.Scope == nil {
+		return false
+	}
+	for _, m := range modes {
+		for _, l := range d.Scope.Lines {
+			if strings.EqualFold(l.TransportMode, m) {
+				return true
+			}
+		}
+		for _, sa := range d.Scope.StopAreas {
+			if strings.EqualFold(sa.TransportMode, m) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// siteStopAreas maps site ID query values to the stop areas they contain.
+func siteStopAreas(all []sl.Site, ids []string) map[int]bool {
+	areas := make(map[int]bool)
+	for _, s := range all {
+		for _, id := range ids {
+			if strconv.Itoa(s.ID) == id {
+				for _, a := range s.StopAreas {
+					areas[a] = true
+				}
+			}
+		}
+	}
+	return areas
+}
+
+func deviationHasStopArea(d sl.Deviation, areas map[int]bool) bool {
+	if d.Scope == nil {
+		return false
+	}
+	for _, sa := range d.Scope.StopAreas {
+		if areas[sa.ID] {
+			return true
+		}
+	}
+	return false
+}
+
+func deviationHasLineID(d sl.Deviation, ids []string) bool {
+	if d.Scope == nil {
+		return false
+	}
+	for _, id := range ids {
+		for _, l := range d.Scope.Lines {
+			if strconv.Itoa(l.ID) == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func shiftTime(s string, by time.Duration) string {
+	const layout = "2006-01-02T15:04:05"
+	t, err := time.ParseInLocation(layout, s, stockholm())
+	if err != nil {
+		return s
+	}
+	return t.Add(by).Format(layout)
+}
+
+func shiftPlannerTime(s string, by time.Duration) string {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return s
+	}
+	return t.Add(by).UTC().Format(time.RFC3339)
+}
+
+func stockholm() *time.Location {
+	loc, err := time.LoadLocation("Europe/Stockholm")
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
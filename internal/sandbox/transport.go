@@ -0,0 +1,93 @@
+package sandbox
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+// Transport returns an http.RoundTripper that answers requests to the
+// services ServeHTTP stands in for, at sl's default base URLs, from s, in
+// process. Any other request fails with ErrOffline, so nothing reaches
+// the network.
+func (s *Server) Transport() http.RoundTripper {
+	return roundTripper{s}
+}
+
+type roundTripper struct{ s *Server }
+
+func (rt roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	p, ok := mountPath(req.URL)
+	if !ok {
+		// Without the query: it may hold an API key.
+		return nil, fmt.Errorf("%w: %s://%s%s", ErrOffline, req.URL.Scheme, req.URL.Host, req.URL.Path)
+	}
+	in := req.Clone(req.Context())
+	in.URL = &url.URL{Path: p, RawQuery: req.URL.RawQuery}
+	in.RequestURI = in.URL.RequestURI()
+
+	rec := &recorder{header: http.Header{}}
+	rt.s.ServeHTTP(rec, in)
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rec.status, http.StatusText(rec.status)),
+		StatusCode:    rec.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        rec.header,
+		Body:          io.NopCloser(bytes.NewReader(rec.body.Bytes())),
+		ContentLength: int64(rec.body.Len()),
+		Request:       req,
+	}, nil
+}
+
+// mountPath maps a request to one of sl's default base URLs onto the path the
+// server mounts that service at.
+func mountPath(u *url.URL) (string, bool) {
+	apis := []struct{ base, mount string }{
+		{sl.TransportBaseURL, "/transport/v1"},
+		{sl.DeviationsBaseURL, "/deviations/v1"},
+		{sl.JourneyPlannerBaseURL, "/planner/v2"},
+		{sl.GTFSStaticBaseURL, "/gtfs/sl"},
+		{sl.GTFSRealtimeBaseURL, "/gtfs-rt/sl"},
+		{sl.GeoIPBaseURL, "/geoip"},
+	}
+	for _, api := range apis {
+		base, err := url.Parse(api.base)
+		if err != nil || base.Host != u.Host {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(u.Path, base.Path); ok && (rest == "" || rest[0] == '/') {
+			return api.mount + rest, true
+		}
+	}
+	return "", false
+}
+
+// recorder is the http.ResponseWriter a request is served into.
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) Header() http.Header { return r.header }
+
+func (r *recorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.body.Write(p)
+}
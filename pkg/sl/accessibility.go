@@ -0,0 +1,113 @@
+package sl
+
+import (
+	"strings"
+
+	"github.com/glundgren93/sl-cli/internal/i18n"
+)
+
+// StopAccessibility is what the journey planner reports about getting
+// on and off at a stop. Nil fields are not reported.
+type StopAccessibility struct {
+	StepFree    *bool `json:"stepFree,omitempty"`
+	Elevator    *bool `json:"elevator,omitempty"`
+	Escalator   *bool `json:"escalator,omitempty"`
+	LowPlatform *bool `json:"lowPlatform,omitempty"`
+}
+
+// Planner stop property keys for accessibility, where the planner
+// exposes them.
+var (
+	stopStepFreeKeys    = []string{"wheelchairAccess", "WHEELCHAIR_ACCESS", "stepFree", "PLANNED_WHEELCHAIR"}
+	stopElevatorKeys    = []string{"elevator", "hasElevator", "ELEVATOR"}
+	stopEscalatorKeys   = []string{"escalator", "hasEscalator", "ESCALATOR"}
+	stopLowPlatformKeys = []string{"lowPlatform", "LOW_PLATFORM", "platformLowFloor"}
+)
+
+// ReadStopAccessibility reads a stop's accessibility properties, or
+// returns nil when the planner reports none.
+func ReadStopAccessibility(s *JourneyStop) *StopAccessibility {
+	if s == nil {
+		return nil
+	}
+	var a StopAccessibility
+	found := false
+	for _, f := range []struct {
+		field *(*bool)
+		keys  []string
+	}{
+		{&a.StepFree, stopStepFreeKeys},
+		{&a.Elevator, stopElevatorKeys},
+		{&a.Escalator, stopEscalatorKeys},
+		{&a.LowPlatform, stopLowPlatformKeys},
+	} {
+		if v, ok := boolProperty(s.Properties, f.keys); ok {
+			*f.field = &v
+			found = true
+		}
+	}
+	if !found {
+		return nil
+	}
+	return &a
+}
+
+// MarkAccessibility sets Accessibility on the stops of every leg that
+// report any.
+func MarkAccessibility(journeys []JourneyTrip) {
+	for i := range journeys {
+		for k := range journeys[i].Legs {
+			leg := &journeys[i].Legs[k]
+			for _, s := range []*JourneyStop{leg.Origin, leg.Destination} {
+				if s != nil {
+					s.Accessibility = ReadStopAccessibility(s)
+				}
+			}
+		}
+	}
+}
+
+// Notes describes the reported accessibility, e.g. "step-free, no
+// escalator". It also reports whether any of it is a lack.
+func (a StopAccessibility) Notes() (notes []string, lacking bool) {
+	for _, f := range []struct {
+		v        *bool
+		has, not string
+	}{
+		{a.StepFree, i18n.StepFree, i18n.NotStepFreeStop},
+		{a.Elevator, i18n.Elevator, i18n.NoElevator},
+		{a.Escalator, i18n.Escalator, i18n.NoEscalator},
+		{a.LowPlatform, i18n.LowPlatform, i18n.NoLowPlatform},
+	} {
+		switch {
+		case f.v == nil:
+		case *f.v:
+			notes = append(notes, i18n.T(f.has))
+		default:
+			notes = append(notes, i18n.T(f.not))
+			lacking = true
+		}
+	}
+	return notes, lacking
+}
+
+// boolProperty reads the first of keys present in props as a boolean,
+// accepting the encodings the planner uses.
+func boolProperty(props map[string]any, keys []string) (value, known bool) {
+	for _, k := range keys {
+		switch v := props[k].(type) {
+		case bool:
+			return v, true
+		case string:
+			switch strings.ToLower(v) {
+			case "true", "1", "yes":
+				return true, true
+			case "false", "0", "no":
+				return false, true
+			}
+		case float64:
+			return v != 0, true
+		}
+	}
+	return false, false
+}
@@ -0,0 +1,40 @@
+package sl
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReadStopAccessibility(t *testing.T) {
+	if a := ReadStopAccessibility(&JourneyStop{Properties: map[string]any{"platform": "2"}}); a != nil {
+		t.Errorf("no accessibility properties: got %+v", a)
+	}
+
+	a := ReadStopAccessibility(&JourneyStop{Properties: map[string]any{
+		"wheelchairAccess": "true",
+		"elevator":         float64(0),
+		"escalator":        true,
+	}})
+	if a == nil || !*a.StepFree || *a.Elevator || !*a.Escalator || a.LowPlatform != nil {
+		t.Fatalf("got %+v", a)
+	}
+	notes, lacking := a.Notes()
+	if want := []string{"step-free", "no elevator", "escalator"}; !reflect.DeepEqual(notes, want) || !lacking {
+		t.Errorf("notes = %v (lacking %v), want %v and lacking", notes, lacking, want)
+	}
+}
+
+func TestMarkAccessibility(t *testing.T) {
+	journeys := []JourneyTrip{{Legs: []JourneyLeg{{
+		Origin:      &JourneyStop{Name: "Slussen", Properties: map[string]any{"lowPlatform": "1"}},
+		Destination: &JourneyStop{Name: "Kista"},
+	}}}}
+	MarkAccessibility(journeys)
+	leg := journeys[0].Legs[0]
+	if leg.Origin.Accessibility == nil || !*leg.Origin.Accessibility.LowPlatform {
+		t.Errorf("origin: got %+v", leg.Origin.Accessibility)
+	}
+	if leg.Destination.Accessibility != nil {
+		t.Errorf("destination reports nothing: got %+v", leg.Destination.Accessibility)
+	}
+}
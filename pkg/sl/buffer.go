@@ -0,0 +1,32 @@
+package sl
+
+import (
+	"time"
+)
+
+// BufferPoints counts the places in a journey a time buffer applies to:
+// every walk, plus every change that doesn't involve one.
+func BufferPoints(j JourneyTrip) int {
+	n := 0
+	prevRide := false
+	for _, leg := range j.Legs {
+		ride := leg.Transport != nil && leg.Transport.Name != ""
+		if !ride || prevRide {
+			n++
+		}
+		prevRide = ride
+	}
+	return n
+}
+
+// ApplyBuffer sets BufferedDuration on each journey, padding its duration by
+// buffer at every walk and change. A zero buffer leaves journeys untouched.
+func ApplyBuffer(journeys []JourneyTrip, buffer time.Duration) {
+	if buffer <= 0 {
+		return
+	}
+	for i := range journeys {
+		j := &journeys[i]
+		j.BufferedDuration = journeyDuration(*j) + BufferPoints(*j)*int(buffer.Seconds())
+	}
+}
@@ -0,0 +1,28 @@
+package sl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApplyBuffer(t *testing.T) {
+	ride := func(name string) JourneyLeg {
+		return JourneyLeg{Transport: &JourneyTransport{Name: name}}
+	}
+	walk := JourneyLeg{Duration: 180}
+
+	journeys := []JourneyTrip{
+		// walk, metro, same-platform change to another metro, walk: 3 points.
+		{TripDuration: 1200, Legs: []JourneyLeg{walk, ride("17"), ride("18"), walk}},
+		// direct ride: nothing to pad.
+		{TripDuration: 600, TripRtDuration: 660, Legs: []JourneyLeg{ride("55")}},
+	}
+	ApplyBuffer(journeys, 3*time.Minute)
+
+	if got := journeys[0].BufferedDuration; got != 1200+3*180 {
+		t.Errorf("buffered = %d, want %d", got, 1200+3*180)
+	}
+	if got := journeys[1].BufferedDuration; got != 660 {
+		t.Errorf("direct ride buffered = %d, want 660", got)
+	}
+}
@@ -0,0 +1,166 @@
+package sl
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const siteCacheTTL = 5 * time.Minute
+
+// The in-memory caches are shared by every goroutine in the process (the
+// serve and exporter modes run many handlers against one client). Readers
+// take a read lock only long enough to check freshness; the lock is never
+// held across a fetch. Concurrent misses are collapsed by staticFlights so
+// the API sees one request per cache, not one per handler.
+var staticFlights flightGroup
+
+// SiteCache caches the full sites list to avoid repeated API calls.
+type SiteCache struct {
+	mu        sync.RWMutex
+	sites     []Site
+	fetchedAt time.Time
+}
+
+var globalSiteCache = &SiteCache{}
+
+func (sc *SiteCache) fresh() ([]Site, bool) {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	if len(sc.sites) > 0 && time.Since(sc.fetchedAt) < siteCacheTTL {
+		return sc.sites, true
+	}
+	return nil, false
+}
+
+// GetSitesCached returns sites from the in-memory cache if fresh, then from
+// the disk cache, and only then from the API.
+func (c *Client) GetSitesCached(ctx context.Context) ([]Site, error) {
+	if sites, ok := globalSiteCache.fresh(); ok {
+		return sites, nil
+	}
+
+	v, err := staticFlights.Do("sites", func() (any, error) {
+		// Another caller may have filled the cache while we queued.
+		if sites, ok := globalSiteCache.fresh(); ok {
+			return sites, nil
+		}
+		sites, err := fetchStatic(ctx, c, "sites", c.sitesURL(), false, parseSites)
+		if err != nil {
+			return nil, err
+		}
+		globalSiteCache.mu.Lock()
+		globalSiteCache.sites = sites
+		globalSiteCache.fetchedAt = time.Now()
+		globalSiteCache.mu.Unlock()
+		return sites, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]Site), nil
+}
+
+// StopAreaTypeCache caches the stop area ID → type mapping (METROSTN, BUSTERM, ...).
+type StopAreaTypeCache struct {
+	mu        sync.RWMutex
+	types     map[int]string
+	fetchedAt time.Time
+}
+
+var globalStopAreaTypeCache = &StopAreaTypeCache{}
+
+func (tc *StopAreaTypeCache) fresh() (map[int]string, bool) {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	if len(tc.types) > 0 && time.Since(tc.fetchedAt) < siteCacheTTL {
+		return tc.types, true
+	}
+	return nil, false
+}
+
+// GetStopAreaTypesCached returns a map of stop area ID to stop area type,
+// derived from the stop points list. The map is shared; callers must not
+// modify it.
+func (c *Client) GetStopAreaTypesCached(ctx context.Context) (map[int]string, error) {
+	if types, ok := globalStopAreaTypeCache.fresh(); ok {
+		return types, nil
+	}
+
+	v, err := staticFlights.Do("stop-area-types", func() (any, error) {
+		if types, ok := globalStopAreaTypeCache.fresh(); ok {
+			return types, nil
+		}
+		types, err := fetchStatic(ctx, c, "stop-area-types", c.stopPointsURL(), false, parseStopAreaTypes)
+		if err != nil {
+			return nil, err
+		}
+		globalStopAreaTypeCache.mu.Lock()
+		globalStopAreaTypeCache.types = types
+		globalStopAreaTypeCache.fetchedAt = time.Now()
+		globalStopAreaTypeCache.mu.Unlock()
+		return types, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(map[int]string), nil
+}
+
+// parseStopAreaTypes reduces a stop points response to stop area types.
+func parseStopAreaTypes(body []byte) (map[int]string, error) {
+	points, err := parseStopPoints(body)
+	if err != nil {
+		return nil, err
+	}
+	types := make(map[int]string)
+	for _, p := range points {
+		if p.StopArea != nil && p.StopArea.Type != "" {
+			types[p.StopArea.ID] = p.StopArea.Type
+		}
+	}
+	return types, nil
+}
+
+// GetLinesCached returns all SL lines, from the disk cache when fresh.
+func (c *Client) GetLinesCached(ctx context.Context) ([]Line, error) {
+	v, err := staticFlights.Do("lines", func() (any, error) {
+		return fetchStatic(ctx, c, "lines", c.linesURL(), false, c.parseLines)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]Line), nil
+}
+
+// RefreshStatic revalidates every static disk cache (sites, stop area types,
+// lines) against the API, using conditional requests so unchanged data costs
+// no download. The in-memory caches are dropped so this process sees the
+// refreshed data too.
+func (c *Client) RefreshStatic(ctx context.Context) error {
+	var errs []error
+	if _, err := fetchStatic(ctx, c, "sites", c.sitesURL(), true, parseSites); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := fetchStatic(ctx, c, "stop-area-types", c.stopPointsURL(), true, parseStopAreaTypes); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := fetchStatic(ctx, c, "lines", c.linesURL(), true, c.parseLines); err != nil {
+		errs = append(errs, err)
+	}
+	ResetCaches()
+	return errors.Join(errs...)
+}
+
+// ResetCaches drops all in-memory caches. Intended for tests that swap the
+// API under the client.
+func ResetCaches() {
+	globalSiteCache.mu.Lock()
+	globalSiteCache.sites = nil
+	globalSiteCache.mu.Unlock()
+
+	globalStopAreaTypeCache.mu.Lock()
+	globalStopAreaTypeCache.types = nil
+	globalStopAreaTypeCache.mu.Unlock()
+}
@@ -0,0 +1,79 @@
+package sl
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetSitesCached_ConcurrentMissFetchesOnce(t *testing.T) {
+	dir := t.TempDir()
+	userCacheDir = func() (string, error) { return dir, nil }
+	defer func() { userCacheDir = os.UserCacheDir }()
+
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte(`[{"id": 9191, "name": "Medborgarplatsen"}]`))
+	}))
+	defer srv.Close()
+
+	ResetCaches()
+	defer ResetCaches()
+
+	c := New(Options{BaseURLs: BaseURLs{Transport: srv.URL}})
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sites, err := c.GetSitesCached(context.Background())
+			if err != nil || len(sites) != 1 {
+				t.Errorf("GetSitesCached: %v, %v", sites, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := requests.Load(); n != 1 {
+		t.Errorf("expected one API request for concurrent misses, got %d", n)
+	}
+}
+
+func TestFlightGroup_SharesResult(t *testing.T) {
+	var g flightGroup
+	release := make(chan struct{})
+	var calls atomic.Int32
+
+	var wg sync.WaitGroup
+	results := make([]any, 5)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = g.Do("k", func() (any, error) {
+				calls.Add(1)
+				<-release
+				return 42, nil
+			})
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("fn ran %d times, want 1", calls.Load())
+	}
+	for i, r := range results {
+		if r != 42 {
+			t.Errorf("result %d = %v, want 42", i, r)
+		}
+	}
+}
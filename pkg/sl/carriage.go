@@ -0,0 +1,164 @@
+package sl
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/glundgren93/sl-cli/internal/i18n"
+)
+
+// Carriage describes what the traveller brings along on a trip.
+type Carriage struct {
+	Bike       bool
+	Stroller   bool
+	Wheelchair bool
+}
+
+// Any reports whether anything is being brought along.
+func (c Carriage) Any() bool {
+	return c.Bike || c.Stroller || c.Wheelchair
+}
+
+// StepFree reports whether the traveller needs step-free vehicles.
+func (c Carriage) StepFree() bool {
+	return c.Stroller || c.Wheelchair
+}
+
+// Planner property keys that state whether bikes may be taken along or the
+// vehicle is step-free, where the journey planner exposes them.
+var (
+	bikeAllowedKeys = []string{"bikeTakeAlong", "isBikeTakeAlongAllowed", "BIKE_TAKE_ALONG"}
+	stepFreeKeys    = []string{"lowFloor", "wheelchairAccess", "PLANNED_WHEELCHAIR"}
+)
+
+// isRushHour reports whether t falls within SL's weekday peak periods
+// (06:00–09:00 and 15:00–18:00), when bikes are banned on rail services.
+func isRushHour(t time.Time) bool {
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return false
+	}
+	h := t.Hour()
+	return (h >= 6 && h < 9) || (h >= 15 && h < 18)
+}
+
+// LegMode classifies a journey leg's vehicle as one of the transport API's
+// modes (METRO, BUS, TRAIN, TRAM, SHIP). Walking legs return "".
+func LegMode(leg JourneyLeg) string {
+	if leg.Transport == nil || leg.Transport.Product == nil {
+		return ""
+	}
+	cat := strings.ToLower(leg.Transport.Product.CatOutL + " " + leg.Transport.Product.Name)
+	switch {
+	case strings.Contains(cat, "metro"), strings.Contains(cat, "tunnelbana"):
+		return "METRO"
+	case strings.Contains(cat, "bus"):
+		return "BUS"
+	case strings.Contains(cat, "train"), strings.Contains(cat, "pendel"), strings.Contains(cat, "tåg"):
+		return "TRAIN"
+	case strings.Contains(cat, "tram"), strings.Contains(cat, "spårväg"):
+		return "TRAM"
+	case strings.Contains(cat, "ship"), strings.Contains(cat, "ferry"), strings.Contains(cat, "boat"), strings.Contains(cat, "båt"):
+		return "SHIP"
+	}
+	return ""
+}
+
+// CarriageNotes returns warnings for a leg that does not permit what is being
+// brought along. Planner properties win when present; otherwise SL's policy
+// applies: no bikes on buses, and none on metro, pendeltåg or light rail
+// during weekday rush hours. Strollers and wheelchairs are allowed on every
+// SL vehicle, so they are only flagged when the planner reports a vehicle
+// as not step-free.
+func CarriageNotes(leg JourneyLeg, carry Carriage) []string {
+	mode := LegMode(leg)
+	if mode == "" {
+		return nil
+	}
+
+	var notes []string
+	if carry.Bike {
+		allowed, known := legBoolProperty(leg, bikeAllowedKeys)
+		if !known {
+			allowed = bikePolicyAllows(mode, legDeparture(leg))
+		}
+		if !allowed {
+			notes = append(notes, i18n.T(i18n.BikeNotAllowed))
+		}
+	}
+	if carry.StepFree() {
+		if stepFree, known := legBoolProperty(leg, stepFreeKeys); known && !stepFree {
+			if carry.Wheelchair {
+				notes = append(notes, i18n.T(i18n.NoWheelchair))
+			} else {
+				notes = append(notes, i18n.T(i18n.NotStepFree))
+			}
+		}
+	}
+	return notes
+}
+
+// bikePolicyAllows applies SL's built-in bike rules for a mode at a time.
+func bikePolicyAllows(mode string, dep time.Time) bool {
+	switch mode {
+	case "BUS":
+		return false
+	case "METRO", "TRAIN", "TRAM":
+		return dep.IsZero() || !isRushHour(dep)
+	}
+	return true
+}
+
+// SortByCarriage stably reorders journeys so those with the fewest legs
+// flagged by CarriageNotes come first.
+func SortByCarriage(journeys []JourneyTrip, carry Carriage) {
+	if !carry.Any() {
+		return
+	}
+	flagged := func(j JourneyTrip) int {
+		n := 0
+		for _, leg := range j.Legs {
+			if len(CarriageNotes(leg, carry)) > 0 {
+				n++
+			}
+		}
+		return n
+	}
+	sort.SliceStable(journeys, func(i, k int) bool {
+		return flagged(journeys[i]) < flagged(journeys[k])
+	})
+}
+
+// legDeparture returns a leg's departure in Stockholm time, or the zero time.
+func legDeparture(leg JourneyLeg) time.Time {
+	if leg.Origin == nil {
+		return time.Time{}
+	}
+	raw := leg.Origin.DepartureTimeEstimated
+	if raw == "" {
+		raw = leg.Origin.DepartureTimePlanned
+	}
+	return parsePlannerTime(raw)
+}
+
+// parsePlannerTime parses a journey planner timestamp into Stockholm time,
+// returning the zero time if it can't be parsed.
+func parsePlannerTime(raw string) time.Time {
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}
+	}
+	if loc, err := time.LoadLocation(stockholmTZ); err == nil {
+		t = t.In(loc)
+	}
+	return t
+}
+
+// legBoolProperty looks up a boolean-ish planner property on the leg's
+// transport, returning whether it was present at all.
+func legBoolProperty(leg JourneyLeg, keys []string) (value, known bool) {
+	if leg.Transport == nil {
+		return false, false
+	}
+	return boolProperty(leg.Transport.Properties, keys)
+}
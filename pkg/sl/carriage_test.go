@@ -0,0 +1,59 @@
+package sl
+
+import (
+	"testing"
+)
+
+func carriageLeg(catOutL, departure string, props map[string]any) JourneyLeg {
+	return JourneyLeg{
+		Origin: &JourneyStop{DepartureTimePlanned: departure},
+		Transport: &JourneyTransport{
+			Product:    &TransportProduct{CatOutL: catOutL},
+			Properties: props,
+		},
+	}
+}
+
+func TestCarriageNotes_Bike(t *testing.T) {
+	bike := Carriage{Bike: true}
+	tests := []struct {
+		name string
+		leg  JourneyLeg
+		want bool // flagged
+	}{
+		// 2024-03-01 is a Friday; 07:03Z is 08:03 in Stockholm.
+		{"metro at rush hour", carriageLeg("Metro", "2024-03-01T07:03:00Z", nil), true},
+		{"metro midday", carriageLeg("Metro", "2024-03-01T11:00:00Z", nil), false},
+		{"metro saturday morning", carriageLeg("Metro", "2024-03-02T07:03:00Z", nil), false},
+		{"bus", carriageLeg("Bus", "2024-03-01T11:00:00Z", nil), true},
+		{"ferry at rush hour", carriageLeg("Ferry", "2024-03-01T07:03:00Z", nil), false},
+		{"planner says allowed", carriageLeg("Bus", "2024-03-01T11:00:00Z", map[string]any{"bikeTakeAlong": true}), false},
+		{"walking", JourneyLeg{Duration: 300}, false},
+	}
+	for _, tt := range tests {
+		if got := len(CarriageNotes(tt.leg, bike)) > 0; got != tt.want {
+			t.Errorf("%s: flagged = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCarriageNotes_Stroller(t *testing.T) {
+	stroller := Carriage{Stroller: true}
+	if notes := CarriageNotes(carriageLeg("Bus", "", nil), stroller); len(notes) != 0 {
+		t.Errorf("strollers are allowed by default, got %v", notes)
+	}
+	if notes := CarriageNotes(carriageLeg("Bus", "", map[string]any{"lowFloor": "false"}), stroller); len(notes) != 1 {
+		t.Errorf("non-step-free vehicle should be flagged, got %v", notes)
+	}
+}
+
+func TestSortByCarriage(t *testing.T) {
+	journeys := []JourneyTrip{
+		{TripDuration: 600, Legs: []JourneyLeg{carriageLeg("Bus", "2024-03-01T11:00:00Z", nil)}},
+		{TripDuration: 900, Legs: []JourneyLeg{carriageLeg("Train", "2024-03-01T11:00:00Z", nil)}},
+	}
+	SortByCarriage(journeys, Carriage{Bike: true})
+	if journeys[0].TripDuration != 900 {
+		t.Errorf("bike-friendly journey should come first, got %+v", journeys)
+	}
+}
@@ -0,0 +1,38 @@
+package sl
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// walkingSpeedKmh is a brisk but unhurried walking pace.
+	walkingSpeedKmh = 5.0
+	// walkDetourFactor turns straight-line distance into a street distance.
+	walkDetourFactor = 1.3
+	// catchMarginMin is the slack below which a departure is only marginal.
+	catchMarginMin = 2
+)
+
+// WalkingTime estimates the walk to a stop from its straight-line distance.
+func WalkingTime(distanceKm float64) time.Duration {
+	hours := distanceKm * walkDetourFactor / walkingSpeedKmh
+	return time.Duration(hours * float64(time.Hour)).Round(time.Minute)
+}
+
+// MarkCatchable sets Catchable on each departure given the walk to the stop:
+// catchable with at least catchMarginMin minutes to spare, marginal with less,
+// and not catchable if it leaves before you arrive.
+func MarkCatchable(deps []ParsedDeparture, walk time.Duration) {
+	walkMin := int(math.Ceil(walk.Minutes()))
+	for i := range deps {
+		switch margin := deps[i].MinutesLeft - walkMin; {
+		case margin >= catchMarginMin:
+			deps[i].Catchable = CatchYes
+		case margin >= 0:
+			deps[i].Catchable = CatchMarginal
+		default:
+			deps[i].Catchable = CatchNo
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package sl
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestMarkCatchable(t *testing.T) {
+	deps := []ParsedDeparture{{MinutesLeft: 2}, {MinutesLeft: 5}, {MinutesLeft: 6}, {MinutesLeft: 12}}
+	MarkCatchable(deps, 5*time.Minute)
+
+	want := []Catchability{CatchNo, CatchMarginal, CatchMarginal, CatchYes}
+	for i, d := range deps {
+		if d.Catchable != want[i] {
+			t.Errorf("departure in %d min: catchable = %v, want %v", d.MinutesLeft, d.Catchable, want[i])
+		}
+	}
+
+	data, _ := json.Marshal(deps[1])
+	var back struct {
+		Catchable any `json:"catchable"`
+	}
+	json.Unmarshal(data, &back)
+	if back.Catchable != "marginal" {
+		t.Errorf("catchable JSON = %v, want \"marginal\"", back.Catchable)
+	}
+}
+
+func TestWalkingTime(t *testing.T) {
+	// 500 m straight line ≈ 650 m of streets ≈ 8 min at 5 km/h.
+	if got := WalkingTime(0.5); got != 8*time.Minute {
+		t.Errorf("WalkingTime(0.5) = %v, want 8m", got)
+	}
+}
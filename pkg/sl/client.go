@@ -0,0 +1,656 @@
+package sl
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Base URLs for the SL APIs, unless changed with Options.BaseURLs.
+const (
+	TransportBaseURL      = "https://transport.integration.sl.se/v1"
+	DeviationsBaseURL     = "https://deviations.integration.sl.se/v1"
+	JourneyPlannerBaseURL = "https://journeyplanner.integration.sl.se/v2"
+)
+
+const DefaultTimeout = 15 * time.Second
+
+// API is what a Client answers, for programs that want to substitute a
+// fake in their tests or wrap the client. *Client implements it.
+type API interface {
+	GetSites(ctx context.Context) ([]Site, error)
+	GetSitesCached(ctx context.Context) ([]Site, error)
+	GetStopPoints(ctx context.Context) ([]StopPointDetail, error)
+	GetLines(ctx context.Context) ([]Line, error)
+	GetLinesCached(ctx context.Context) ([]Line, error)
+	GetDepartures(ctx context.Context, opts DepartureOptions) (*DeparturesResponse, error)
+	GetDeparturesLastGood(ctx context.Context, opts DepartureOptions) (*DeparturesResponse, time.Time, error)
+	GetDeviations(ctx context.Context, opts DeviationOptions) ([]Deviation, error)
+	FindStops(ctx context.Context, query string) ([]Location, error)
+	FindAddress(ctx context.Context, query string) ([]Location, error)
+	GetDepartureMonitor(ctx context.Context, opts DepartureMonitorOptions) (*DepartureMonitorResponse, error)
+	PlanTrip(ctx context.Context, opts TripOptions) (*JourneyResponse, error)
+	PlanTripCached(ctx context.Context, opts TripOptions) (*JourneyResponse, error)
+}
+
+var _ API = (*Client)(nil)
+
+// Client is the SL API client.
+type Client struct {
+	httpClient    *http.Client
+	onWarning     func(Warning)
+	staticTTL     time.Duration // 0 = staticCacheTTL
+	retries       int
+	retryBase     time.Duration
+	base          BaseURLs
+	cacheRoot     string // "" = sl-cli under the user's cache dir
+	captureExtras bool
+}
+
+// BaseURLs points a client at other hosts than SL's and Trafiklab's, such
+// as a fake server in tests. Empty fields keep the default.
+type BaseURLs struct {
+	Transport      string // default TransportBaseURL
+	Deviations     string // default DeviationsBaseURL
+	JourneyPlanner string // default JourneyPlannerBaseURL
+	GTFSStatic     string // default GTFSStaticBaseURL
+	GTFSRealtime   string // default GTFSRealtimeBaseURL
+	GeoIP          string // default GeoIPBaseURL
+}
+
+// withDefaults fills in the empty fields of b.
+func (b BaseURLs) withDefaults() BaseURLs {
+	set := func(field *string, def string) {
+		if *field == "" {
+			*field = def
+		}
+	}
+	set(&b.Transport, TransportBaseURL)
+	set(&b.Deviations, DeviationsBaseURL)
+	set(&b.JourneyPlanner, JourneyPlannerBaseURL)
+	set(&b.GTFSStatic, GTFSStaticBaseURL)
+	set(&b.GTFSRealtime, GTFSRealtimeBaseURL)
+	set(&b.GeoIP, GeoIPBaseURL)
+	return b
+}
+
+// NewClient creates a new SL API client.
+func NewClient() *Client {
+	return &Client{
+		httpClient: &http.Client{
+			Timeout: DefaultTimeout,
+		},
+		retries:   DefaultRetries,
+		retryBase: defaultRetryDelay,
+		base:      BaseURLs{}.withDefaults(),
+	}
+}
+
+// Options configures a client made with New. The zero value gives the
+// same client as NewClient.
+type Options struct {
+	// Timeout bounds each request (default DefaultTimeout).
+	Timeout time.Duration
+	// Retries is how many times a failing request is retried; nil means
+	// DefaultRetries and 0 disables retries.
+	Retries *int
+	// StaticCacheTTL is how long sites, stop points and lines are served
+	// from the disk cache (default 24h).
+	StaticCacheTTL time.Duration
+	// OnWarning receives non-fatal conditions; nil discards them.
+	OnWarning func(Warning)
+	// Trace receives a log of every HTTP request at TraceLevel (see
+	// SetTrace); nil traces nothing.
+	Trace      io.Writer
+	TraceLevel int
+	// Transport sends the client's requests; nil means
+	// http.DefaultTransport.
+	Transport http.RoundTripper
+	// BaseURLs overrides where the APIs are reached.
+	BaseURLs BaseURLs
+	// RetryDelay is the backoff before the first retry, doubling with each
+	// further one (default DefaultRetryDelay).
+	RetryDelay time.Duration
+	// CacheDir holds the on-disk caches (default sl-cli under
+	// os.UserCacheDir).
+	CacheDir string
+	// CaptureExtras keeps the fields of SL's responses that Departure,
+	// Deviation and JourneyLeg don't model, in their Extras, so new
+	// upstream fields are visible before a release adds them.
+	CaptureExtras bool
+}
+
+// New creates a client configured by opts.
+func New(opts Options) *Client {
+	c := NewClient()
+	c.httpClient.Transport = opts.Transport
+	if opts.Timeout > 0 {
+		c.SetTimeout(opts.Timeout)
+	}
+	if opts.Retries != nil {
+		c.SetRetries(*opts.Retries)
+	}
+	if opts.RetryDelay > 0 {
+		c.retryBase = opts.RetryDelay
+	}
+	c.base = opts.BaseURLs.withDefaults()
+	c.cacheRoot = opts.CacheDir
+	c.captureExtras = opts.CaptureExtras
+	c.SetStaticCacheTTL(opts.StaticCacheTTL)
+	c.SetWarningHandler(opts.OnWarning)
+	c.SetTrace(opts.Trace, opts.TraceLevel)
+	return c
+}
+
+// NewClientWithTimeout creates a client with a custom timeout.
+func NewClientWithTimeout(timeout time.Duration) *Client {
+	c := NewClient()
+	c.SetTimeout(timeout)
+	return c
+}
+
+// SetTimeout changes the per-request timeout, for the few requests that
+// download more than an API response.
+func (c *Client) SetTimeout(d time.Duration) {
+	c.httpClient.Timeout = d
+}
+
+func (c *Client) get(ctx context.Context, rawURL string) ([]byte, error) {
+	body, _, err := c.getConditional(ctx, rawURL, validators{})
+	return body, err
+}
+
+// validators are the cache validators sent with a conditional request.
+type validators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// errNotModified is returned by getConditional when the server answers 304.
+var errNotModified = errors.New("not modified")
+
+// getConditional is get with If-None-Match/If-Modified-Since set from v. It
+// returns the response's validators alongside the body, or errNotModified.
+// Transient failures are retried (see SetRetries); the error finally
+// returned records how many attempts were made.
+func (c *Client) getConditional(ctx context.Context, rawURL string, v validators) ([]byte, validators, error) {
+	for attempt := 1; ; attempt++ {
+		body, got, retryAfter, err := c.getOnce(ctx, rawURL, v)
+		if err == nil || errors.Is(err, errNotModified) {
+			return body, got, err
+		}
+		delay, retry := c.retryDelay(ctx, err, attempt, retryAfter)
+		if !retry {
+			return nil, validators{}, withAttempts(err, attempt)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, validators{}, withAttempts(err, attempt)
+		case <-timer.C:
+		}
+	}
+}
+
+// getOnce makes a single attempt of getConditional. It also returns how
+// long the server asked us to wait before trying again, if it did.
+func (c *Client) getOnce(ctx context.Context, rawURL string, v validators) ([]byte, validators, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, validators{}, 0, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Accept-Encoding", "gzip")
+	if v.ETag != "" {
+		req.Header.Set("If-None-Match", v.ETag)
+	}
+	if v.LastModified != "" {
+		req.Header.Set("If-Modified-Since", v.LastModified)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, validators{}, 0, &RequestError{Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, v, 0, errNotModified
+	}
+	retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	got := validators{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
+
+	var reader io.Reader = resp.Body
+	if resp.Header.Get("Content-Encoding") == "gzip" {
+		gr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, validators{}, 0, fmt.Errorf("creating gzip reader: %w", err)
+		}
+		defer gr.Close()
+		reader = gr
+	}
+
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, validators{}, 0, fmt.Errorf("reading response: %w", err)
+	}
+
+	if err := c.checkAvailability(rawURL, resp, body); err != nil {
+		return nil, validators{}, retryAfter, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, validators{}, retryAfter, &RequestError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	return body, got, 0, nil
+}
+
+// --- Transport API ---
+
+// GetSites returns all sites (stops/stations) in SL's network.
+func (c *Client) GetSites(ctx context.Context) ([]Site, error) {
+	body, err := c.get(ctx, c.sitesURL())
+	if err != nil {
+		return nil, err
+	}
+	return parseSites(body)
+}
+
+func (c *Client) sitesURL() string { return c.base.Transport + "/sites?expand=true" }
+
+func parseSites(body []byte) ([]Site, error) {
+	var sites []Site
+	if err := json.Unmarshal(body, &sites); err != nil {
+		return nil, fmt.Errorf("parsing sites: %w", err)
+	}
+	return sites, nil
+}
+
+// GetStopPoints returns all stop points with their parent stop areas.
+func (c *Client) GetStopPoints(ctx context.Context) ([]StopPointDetail, error) {
+	body, err := c.get(ctx, c.stopPointsURL())
+	if err != nil {
+		return nil, err
+	}
+	return parseStopPoints(body)
+}
+
+func (c *Client) stopPointsURL() string { return c.base.Transport + "/stop-points" }
+
+func parseStopPoints(body []byte) ([]StopPointDetail, error) {
+	var points []StopPointDetail
+	if err := json.Unmarshal(body, &points); err != nil {
+		return nil, fmt.Errorf("parsing stop points: %w", err)
+	}
+	return points, nil
+}
+
+// GetLines returns all lines for SL (transport_authority_id=1).
+// The API returns a dict grouped by transport mode, so we flatten it.
+func (c *Client) GetLines(ctx context.Context) ([]Line, error) {
+	body, err := c.get(ctx, c.linesURL())
+	if err != nil {
+		return nil, err
+	}
+	return c.parseLines(body)
+}
+
+func (c *Client) linesURL() string {
+	return c.base.Transport + "/lines?transport_authority_id=1"
+}
+
+// nonLineGroups are groups of the lines response that hold something other
+// than transit lines, such as the taxi operators, and are skipped silently.
+var nonLineGroups = map[string]bool{"taxi": true}
+
+func (c *Client) parseLines(body []byte) ([]Line, error) {
+	// API returns {"metro": [...], "bus": [...], ...}
+	var grouped map[string]json.RawMessage
+	if err := json.Unmarshal(body, &grouped); err != nil {
+		return nil, fmt.Errorf("parsing lines response: %w", err)
+	}
+
+	// Walk modes in a fixed order so listings are stable between runs.
+	modes := make([]string, 0, len(grouped))
+	for mode := range grouped {
+		modes = append(modes, mode)
+	}
+	sort.Strings(modes)
+
+	var allLines []Line
+	for _, mode := range modes {
+		if nonLineGroups[mode] {
+			continue
+		}
+		var lines []Line
+		if err := json.Unmarshal(grouped[mode], &lines); err != nil {
+			c.Warn(WarnPartialResponse, "skipped unparseable %s lines", mode)
+			continue
+		}
+		allLines = append(allLines, lines...)
+	}
+
+	return allLines, nil
+}
+
+// DepartureOptions configures a departures request.
+type DepartureOptions struct {
+	SiteID        int
+	TransportMode string   // BUS, METRO, TRAM, TRAIN, SHIP, FERRY
+	Line          string   // filter by line designation
+	Direction     int      // 1 or 2
+	DirectionText string   // destination text, e.g. "towards Akalla"; resolved to a code, then sent as Direction
+	Towards       []string // destinations; keeps directions heading to any of them, like DirectionText
+}
+
+// GetDepartures returns departures from a site.
+func (c *Client) GetDepartures(ctx context.Context, opts DepartureOptions) (*DeparturesResponse, error) {
+	if opts.SiteID == 0 {
+		return nil, fmt.Errorf("site ID is required")
+	}
+	if opts.Direction == 0 && opts.DirectionText != "" {
+		return c.getDeparturesByDirectionText(ctx, opts)
+	}
+	return c.fetchDepartures(ctx, opts)
+}
+
+// fetchDepartures requests a board and applies the filters the API lacks.
+func (c *Client) fetchDepartures(ctx context.Context, opts DepartureOptions) (*DeparturesResponse, error) {
+	u := fmt.Sprintf("%s/sites/%d/departures", c.base.Transport, opts.SiteID)
+
+	params := url.Values{}
+	if opts.TransportMode != "" {
+		params.Set("transport", strings.ToUpper(opts.TransportMode))
+	}
+	if opts.Direction > 0 {
+		params.Set("direction", strconv.Itoa(opts.Direction))
+	}
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+
+	body, err := c.get(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	var resp DeparturesResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("parsing departures: %w", err)
+	}
+	c.dropExtras(resp.Departures, nil, nil)
+
+	// Filter by line if specified
+	if opts.Line != "" {
+		var filtered []Departure
+		for _, d := range resp.Departures {
+			if d.Line != nil && strings.EqualFold(d.Line.Designation, opts.Line) {
+				filtered = append(filtered, d)
+			}
+		}
+		resp.Departures = filtered
+	}
+
+	if opts.Direction == 0 && opts.DirectionText != "" {
+		resp.Departures = FilterByDirectionText(resp.Departures, opts.DirectionText)
+	}
+	if len(opts.Towards) > 0 {
+		resp.Departures = FilterByDestinations(resp.Departures, opts.Towards)
+	}
+
+	return &resp, nil
+}
+
+// --- Deviations API ---
+
+// DeviationOptions configures a deviations request.
+type DeviationOptions struct {
+	Future         bool
+	SiteIDs        []int
+	LineIDs        []int
+	TransportModes []string // BUS, METRO, TRAM, TRAIN, SHIP, FERRY, TAXI
+}
+
+// GetDeviations returns current service deviations.
+func (c *Client) GetDeviations(ctx context.Context, opts DeviationOptions) ([]Deviation, error) {
+	params := url.Values{}
+	if opts.Future {
+		params.Set("future", "true")
+	}
+	for _, id := range opts.SiteIDs {
+		params.Add("site", strconv.Itoa(id))
+	}
+	for _, id := range opts.LineIDs {
+		params.Add("line", strconv.Itoa(id))
+	}
+	for _, mode := range opts.TransportModes {
+		params.Add("transport_mode", strings.ToUpper(mode))
+	}
+
+	u := c.base.Deviations + "/messages"
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+
+	body, err := c.get(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	var devs []Deviation
+	if err := json.Unmarshal(body, &devs); err != nil {
+		return nil, fmt.Errorf("parsing deviations: %w", err)
+	}
+	c.dropExtras(nil, devs, nil)
+	for i := range devs {
+		devs[i].Severity = DeviationSeverity(devs[i]).String()
+	}
+	return devs, nil
+}
+
+// --- Journey Planner API ---
+
+// FindStops searches for stops by name.
+func (c *Client) FindStops(ctx context.Context, query string) ([]Location, error) {
+	body, err := c.get(ctx, c.stopFinderURL(query, "2")) // stops only
+	if err != nil {
+		return nil, err
+	}
+	var resp StopFinderResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("parsing stop finder: %w", err)
+	}
+	return resp.Locations, nil
+}
+
+// FindAddress searches for addresses/streets/POIs (broader than FindStops).
+func (c *Client) FindAddress(ctx context.Context, query string) ([]Location, error) {
+	body, err := c.get(ctx, c.stopFinderURL(query, "46")) // stops + addresses + POI
+	if err != nil {
+		return nil, err
+	}
+	var resp StopFinderResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("parsing stop finder: %w", err)
+	}
+	return resp.Locations, nil
+}
+
+func (c *Client) stopFinderURL(query, filter string) string {
+	params := url.Values{}
+	params.Set("name_sf", query)
+	params.Set("type_sf", "any")
+	params.Set("any_obj_filter_sf", filter)
+	return c.base.JourneyPlanner + "/stop-finder?" + params.Encode()
+}
+
+// PlannerStopID is the journey planner's global ID for a Transport API
+// site, e.g. 9091001000009191 for site 9191.
+func PlannerStopID(siteID int) string {
+	return fmt.Sprintf("9091001000%06d", siteID)
+}
+
+// DepartureMonitorOptions configures a planner departure monitor request.
+type DepartureMonitorOptions struct {
+	StopID   string    // planner stop ID, see PlannerStopID
+	At       time.Time // zero = now
+	Limit    int       // 0 = planner default
+	Language string    // "sv" or "en"
+}
+
+// GetDepartureMonitor fetches departures from the journey planner's
+// departure monitor. Unlike the Transport API it answers for any time, not
+// just the next hour, mixing real-time and timetable data.
+func (c *Client) GetDepartureMonitor(ctx context.Context, opts DepartureMonitorOptions) (*DepartureMonitorResponse, error) {
+	body, err := c.get(ctx, c.departureMonitorURL(opts))
+	if err != nil {
+		return nil, err
+	}
+	var resp DepartureMonitorResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("parsing departure monitor: %w", err)
+	}
+	return &resp, nil
+}
+
+func (c *Client) departureMonitorURL(opts DepartureMonitorOptions) string {
+	params := url.Values{}
+	params.Set("type_dm", "any")
+	params.Set("name_dm", opts.StopID)
+	params.Set("mode", "direct")
+	params.Set("useRealtime", "1")
+	if opts.Limit > 0 {
+		params.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Language != "" {
+		params.Set("language", opts.Language)
+	}
+	if !opts.At.IsZero() {
+		at := StockholmTime(opts.At)
+		params.Set("itd_date", at.Format("20060102"))
+		params.Set("itd_time", at.Format("1504"))
+	}
+	return c.base.JourneyPlanner + "/departure-monitor?" + params.Encode()
+}
+
+// TripOptions configures a trip planning request.
+type TripOptions struct {
+	OriginID     string
+	OriginName   string
+	DestID       string
+	DestName     string
+	NumTrips     int
+	Language     string        // "sv" or "en"
+	MaxChanges   int           // -1 = unset
+	RouteType    string        // "leasttime", "leastinterchange", "leastwalking"
+	MaxWalk      time.Duration // longest walk per footpath; 0 = planner default
+	NoStairs     bool
+	NoEscalators bool
+	LowFloorOnly bool // low-floor vehicles only
+	Carry        Carriage
+	DepartAt     time.Time // zero = now
+	Coords       bool      // include each leg's path in JourneyLeg.Coords
+}
+
+// CoordLocation formats a WGS84 position as a planner location, usable as
+// TripOptions.OriginID or DestID.
+func CoordLocation(lat, lon float64) string {
+	return fmt.Sprintf("%.6f:%.6f:WGS84[dd.ddddd]", lon, lat)
+}
+
+// plannerLocationType returns the planner's type for a location given by
+// ID: coordinates from CoordLocation, otherwise "any".
+func plannerLocationType(id string) string {
+	if strings.HasSuffix(id, ":WGS84[dd.ddddd]") {
+		return "coord"
+	}
+	return "any"
+}
+
+// PlanTrip plans a journey between two locations.
+func (c *Client) PlanTrip(ctx context.Context, opts TripOptions) (*JourneyResponse, error) {
+	body, err := c.get(ctx, c.tripsURL(opts))
+	if err != nil {
+		return nil, err
+	}
+	var resp JourneyResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("parsing trips: %w", err)
+	}
+	c.dropExtras(nil, nil, resp.Journeys)
+	return &resp, nil
+}
+
+func (c *Client) tripsURL(opts TripOptions) string {
+	params := url.Values{}
+
+	if opts.OriginID != "" {
+		params.Set("type_origin", plannerLocationType(opts.OriginID))
+		params.Set("name_origin", opts.OriginID)
+	} else if opts.OriginName != "" {
+		params.Set("type_origin", "any")
+		params.Set("name_origin", opts.OriginName)
+	}
+
+	if opts.DestID != "" {
+		params.Set("type_destination", plannerLocationType(opts.DestID))
+		params.Set("name_destination", opts.DestID)
+	} else if opts.DestName != "" {
+		params.Set("type_destination", "any")
+		params.Set("name_destination", opts.DestName)
+	}
+
+	if opts.NumTrips > 0 {
+		params.Set("calc_number_of_trips", strconv.Itoa(opts.NumTrips))
+	}
+	if opts.Language != "" {
+		params.Set("language", opts.Language)
+	}
+	if opts.MaxChanges >= 0 {
+		params.Set("max_changes", strconv.Itoa(opts.MaxChanges))
+	}
+	if opts.RouteType != "" {
+		params.Set("route_type", opts.RouteType)
+	}
+	if opts.Carry.Bike {
+		params.Set("bikeTakeAlong", "1")
+	}
+	if opts.Carry.StepFree() || opts.NoStairs {
+		params.Set("noSolidStairs", "1")
+	}
+	if opts.Carry.Wheelchair {
+		params.Set("wheelchair", "1")
+	}
+	if opts.NoEscalators {
+		params.Set("noEscalators", "1")
+	}
+	if opts.LowFloorOnly {
+		params.Set("lowPlatformVhcl", "1")
+	}
+	if opts.Carry.Wheelchair || opts.NoEscalators || opts.LowFloorOnly {
+		// The planner ignores the options above without this switch.
+		params.Set("imparedOptionsActive", "1")
+	}
+	if opts.MaxWalk > 0 {
+		params.Set("trITMOTvalue100", strconv.Itoa(int(opts.MaxWalk.Minutes())))
+	}
+	if !opts.DepartAt.IsZero() {
+		at := StockholmTime(opts.DepartAt)
+		params.Set("itd_date", at.Format("20060102"))
+		params.Set("itd_time", at.Format("1504"))
+		params.Set("itd_trip_date_time_dep_arr", "dep")
+	}
+	if opts.Coords {
+		params.Set("gen_c", "true")
+	}
+
+	return c.base.JourneyPlanner + "/trips?" + params.Encode()
+}
@@ -0,0 +1,86 @@
+package sl
+
+import (
+	"sort"
+	"time"
+)
+
+// Commute is the best journey found from one candidate location to a common
+// destination. Err is set instead when no journey could be planned.
+type Commute struct {
+	From        string    `json:"from"`
+	Minutes     int       `json:"minutes"`
+	Changes     int       `json:"changes"`
+	WalkMinutes int       `json:"walk_minutes"`
+	Depart      time.Time `json:"depart,omitzero"`
+	Arrive      time.Time `json:"arrive,omitzero"`
+	Lines       []string  `json:"lines"`
+	Err         string    `json:"error,omitempty"`
+}
+
+// BestCommute summarises the quickest of journeys door to door, preferring
+// fewer changes between equally quick ones. It reports false when there
+// are no journeys.
+func BestCommute(from string, journeys []JourneyTrip) (Commute, bool) {
+	if len(journeys) == 0 {
+		return Commute{From: from, Lines: []string{}}, false
+	}
+	best := journeys[0]
+	for _, j := range journeys[1:] {
+		d, bd := journeyDuration(j), journeyDuration(best)
+		if d < bd || d == bd && j.Interchanges < best.Interchanges {
+			best = j
+		}
+	}
+
+	c := Commute{
+		From:    from,
+		Minutes: (journeyDuration(best) + 59) / 60,
+		Changes: best.Interchanges,
+		Lines:   []string{},
+	}
+	walk := 0
+	for _, leg := range best.Legs {
+		if leg.Transport != nil && leg.Transport.Name != "" {
+			c.Lines = append(c.Lines, leg.Transport.Name)
+		} else {
+			walk += leg.Duration
+		}
+	}
+	c.WalkMinutes = (walk + 59) / 60
+	if len(best.Legs) > 0 {
+		c.Depart = legDeparture(best.Legs[0])
+		c.Arrive = legArrival(best.Legs[len(best.Legs)-1])
+	}
+	return c, true
+}
+
+// RankCommutes sorts commutes quickest first, then by fewest changes and
+// least walking. Candidates without a journey go last, in their given order.
+func RankCommutes(cs []Commute) {
+	sort.SliceStable(cs, func(i, j int) bool {
+		a, b := cs[i], cs[j]
+		if (a.Err == "") != (b.Err == "") {
+			return a.Err == ""
+		}
+		if a.Err != "" {
+			return false
+		}
+		if a.Minutes != b.Minutes {
+			return a.Minutes < b.Minutes
+		}
+		if a.Changes != b.Changes {
+			return a.Changes < b.Changes
+		}
+		return a.WalkMinutes < b.WalkMinutes
+	})
+}
+
+// journeyDuration is a journey's real-time duration in seconds, or its
+// planned one when the planner has no real-time data.
+func journeyDuration(j JourneyTrip) int {
+	if j.TripRtDuration > 0 {
+		return j.TripRtDuration
+	}
+	return j.TripDuration
+}
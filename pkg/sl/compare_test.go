@@ -0,0 +1,51 @@
+package sl
+
+import (
+	"testing"
+)
+
+func TestBestCommute(t *testing.T) {
+	ride := func(name string) JourneyLeg {
+		return JourneyLeg{Transport: &JourneyTransport{Name: name}}
+	}
+	walk := JourneyLeg{Duration: 150}
+
+	journeys := []JourneyTrip{
+		{TripDuration: 1500, Interchanges: 0, Legs: []JourneyLeg{walk, ride("55"), walk}},
+		// As quick once real-time is counted, but with a change.
+		{TripDuration: 1400, TripRtDuration: 1320, Interchanges: 1, Legs: []JourneyLeg{ride("17"), ride("4")}},
+		{TripDuration: 1320, Interchanges: 0, Legs: []JourneyLeg{walk, ride("T14"), walk}},
+	}
+	c, ok := BestCommute("Hornstull", journeys)
+	if !ok {
+		t.Fatal("no commute")
+	}
+	if c.Minutes != 22 || c.Changes != 0 || c.WalkMinutes != 5 {
+		t.Errorf("got %d min, %d changes, %d min walk; want 22, 0, 5", c.Minutes, c.Changes, c.WalkMinutes)
+	}
+	if len(c.Lines) != 1 || c.Lines[0] != "T14" {
+		t.Errorf("lines = %v, want [T14]", c.Lines)
+	}
+
+	if _, ok := BestCommute("Nowhere", nil); ok {
+		t.Error("expected no commute without journeys")
+	}
+}
+
+func TestRankCommutes(t *testing.T) {
+	cs := []Commute{
+		{From: "failed", Err: "no route"},
+		{From: "slow", Minutes: 40},
+		{From: "walky", Minutes: 25, WalkMinutes: 12},
+		{From: "changes", Minutes: 25, Changes: 2},
+		{From: "best", Minutes: 25},
+	}
+	RankCommutes(cs)
+
+	want := []string{"best", "walky", "changes", "slow", "failed"}
+	for i, c := range cs {
+		if c.From != want[i] {
+			t.Errorf("rank %d = %s, want %s", i+1, c.From, want[i])
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package sl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// displayTolerance is how many minutes Display and Expected may differ
+// before they count as a discrepancy. Signs round and are computed at a
+// slightly different moment than the response, so small gaps are normal.
+const displayTolerance = 2
+
+// displayMinutes reads the minutes until departure from SL's display text:
+// "Nu", "5 min" or a clock time "08:15".
+func displayMinutes(display string, now time.Time) (int, bool) {
+	s := strings.TrimSpace(strings.ToLower(display))
+	switch s {
+	case "nu", "now":
+		return 0, true
+	}
+	if n, ok := strings.CutSuffix(s, "min"); ok {
+		if m, err := strconv.Atoi(strings.TrimSpace(n)); err == nil {
+			return m, true
+		}
+		return 0, false
+	}
+	if t, err := time.ParseInLocation("15:04", s, now.Location()); err == nil {
+		at := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+		if at.Before(now.Add(-time.Hour)) { // clock times are upcoming; an earlier one is tomorrow
+			at = at.AddDate(0, 0, 1)
+		}
+		return max(0, int(at.Sub(now).Minutes()+0.5)), true
+	}
+	return 0, false
+}
+
+// reconcileDisplay makes Display and MinutesLeft agree. When they are more
+// than displayTolerance apart, "Nu" on a vehicle reported at the stop wins
+// (the vehicle is physically there and Expected is stale); otherwise the
+// expected time wins and Display is rewritten to match. The disagreement is
+// recorded in DataQuality.
+func reconcileDisplay(pd *ParsedDeparture, now time.Time) {
+	if pd.Expected.IsZero() {
+		return
+	}
+	shown, ok := displayMinutes(pd.Display, now)
+	if !ok || abs(shown-pd.MinutesLeft) <= displayTolerance {
+		return
+	}
+
+	dq := &DataQuality{
+		Issue:       fmt.Sprintf("display %q but expected in %d min", pd.Display, pd.MinutesLeft),
+		RawDisplay:  pd.Display,
+		ExpectedMin: pd.MinutesLeft,
+	}
+	if shown == 0 && (pd.State == "ATSTOP" || pd.State == "BOARDING") {
+		dq.Preferred = "display"
+		pd.MinutesLeft = 0
+	} else {
+		dq.Preferred = "expected"
+		pd.Display = fmt.Sprintf("%d min", pd.MinutesLeft)
+		if pd.MinutesLeft == 0 {
+			pd.Display = "Nu"
+		}
+	}
+	pd.DataQuality = dq
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,59 @@
+package sl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDisplayMinutes(t *testing.T) {
+	now := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		display string
+		want    int
+		ok      bool
+	}{
+		{"Nu", 0, true},
+		{"5 min", 5, true},
+		{"08:15", 15, true},
+		{"00:10", 970, true}, // after midnight
+		{"-", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := displayMinutes(tt.display, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("displayMinutes(%q) = %d, %v, want %d, %v", tt.display, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestReconcileDisplay(t *testing.T) {
+	now := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	// Consistent within tolerance: untouched.
+	d := ParsedDeparture{Display: "3 min", Expected: now.Add(4 * time.Minute), MinutesLeft: 4, State: "EXPECTED"}
+	reconcileDisplay(&d, now)
+	if d.DataQuality != nil || d.Display != "3 min" {
+		t.Errorf("consistent departure changed: %+v", d)
+	}
+
+	// "Nu" while expected is minutes away: expected wins.
+	d = ParsedDeparture{Display: "Nu", Expected: now.Add(6 * time.Minute), MinutesLeft: 6, State: "EXPECTED"}
+	reconcileDisplay(&d, now)
+	if d.Display != "6 min" || d.MinutesLeft != 6 || d.DataQuality == nil || d.DataQuality.Preferred != "expected" {
+		t.Errorf("expected to prefer the expected time: %+v", d)
+	}
+
+	// "Nu" with the vehicle at the stop: display wins.
+	d = ParsedDeparture{Display: "Nu", Expected: now.Add(5 * time.Minute), MinutesLeft: 5, State: "ATSTOP"}
+	reconcileDisplay(&d, now)
+	if d.MinutesLeft != 0 || d.DataQuality == nil || d.DataQuality.Preferred != "display" || d.DataQuality.ExpectedMin != 5 {
+		t.Errorf("expected to prefer the display: %+v", d)
+	}
+
+	// "8 min" while expected now.
+	d = ParsedDeparture{Display: "8 min", Expected: now, MinutesLeft: 0, State: "EXPECTED"}
+	reconcileDisplay(&d, now)
+	if d.Display != "Nu" || d.DataQuality == nil {
+		t.Errorf("expected Nu: %+v", d)
+	}
+}
@@ -0,0 +1,108 @@
+package sl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SL's departures API filters by direction code but not by destination,
+// so a DirectionText is first matched client-side against the whole board.
+// The code it resolves to is remembered on disk per stop, mode, line and
+// text; later requests pass it as direction= and only match the smaller
+// board that comes back.
+
+// directionsCacheName is the static cache holding resolved direction codes.
+const directionsCacheName = "directions"
+
+// directionsMu serializes reading and rewriting the directions cache.
+var directionsMu sync.Mutex
+
+// getDeparturesByDirectionText serves a DirectionText request with a
+// remembered direction code when there is one. If the code no longer
+// matches anything, or there is none yet, it falls back to matching the
+// whole board and remembers the code the matches share, if they share one.
+func (c *Client) getDeparturesByDirectionText(ctx context.Context, opts DepartureOptions) (*DeparturesResponse, error) {
+	if code, ok := c.knownDirection(opts); ok {
+		byCode := opts
+		byCode.Direction = code
+		resp, err := c.fetchDepartures(ctx, byCode)
+		if err != nil {
+			return nil, err
+		}
+		resp.Departures = FilterByDirectionText(resp.Departures, opts.DirectionText)
+		if len(resp.Departures) > 0 {
+			return resp, nil
+		}
+	}
+
+	resp, err := c.fetchDepartures(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	c.rememberDirection(opts, directionCode(resp.Departures))
+	return resp, nil
+}
+
+// directionKey identifies what a DirectionText resolved against: the same
+// destination can be direction 1 at one stop or line and 2 at another.
+func directionKey(opts DepartureOptions) string {
+	return fmt.Sprintf("%d|%s|%s|%s", opts.SiteID, strings.ToUpper(opts.TransportMode),
+		strings.ToLower(opts.Line), DirectionNeedle(opts.DirectionText))
+}
+
+// knownDirection returns the direction code opts' DirectionText resolved
+// to before, if any.
+func (c *Client) knownDirection(opts DepartureOptions) (int, bool) {
+	directionsMu.Lock()
+	defer directionsMu.Unlock()
+	e, err := loadDiskEntry[map[string]int](c, directionsCacheName)
+	if err != nil {
+		return 0, false
+	}
+	code, ok := e.Data[directionKey(opts)]
+	return code, ok
+}
+
+// rememberDirection records the direction code opts' DirectionText
+// resolved to; code 0 forgets it.
+func (c *Client) rememberDirection(opts DepartureOptions, code int) {
+	directionsMu.Lock()
+	defer directionsMu.Unlock()
+	e, err := loadDiskEntry[map[string]int](c, directionsCacheName)
+	if err != nil || e.Data == nil {
+		e = &diskEntry[map[string]int]{Data: map[string]int{}}
+	}
+	key := directionKey(opts)
+	if old, ok := e.Data[key]; (ok && old == code) || (!ok && code == 0) {
+		return
+	}
+	if code == 0 {
+		delete(e.Data, key)
+	} else {
+		e.Data[key] = code
+	}
+	e.FetchedAt = time.Now()
+	if err := saveDiskEntry(c, directionsCacheName, e); err != nil {
+		c.Warn(WarnCacheUnavailable, "could not write %s cache: %v", directionsCacheName, err)
+	}
+}
+
+// directionCode returns the direction code every departure in deps shares,
+// or 0 when there are none or they travel in different directions.
+func directionCode(deps []Departure) int {
+	code := 0
+	for _, d := range deps {
+		switch {
+		case d.DirectionCode == 0:
+			return 0
+		case code == 0:
+			code = d.DirectionCode
+		case d.DirectionCode != code:
+			return 0
+		}
+	}
+	return code
+}
@@ -0,0 +1,68 @@
+package sl
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetDeparturesDirectionText(t *testing.T) {
+	var queries []string
+	board := map[string]string{
+		"":  `{"departures": [{"destination": "Akalla", "direction_code": 1, "line": {"designation": "11"}}, {"destination": "Kungsträdgården", "direction_code": 2, "line": {"designation": "11"}}]}`,
+		"1": `{"departures": [{"destination": "Akalla", "direction_code": 1, "line": {"designation": "11"}}]}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dir := r.URL.Query().Get("direction")
+		queries = append(queries, dir)
+		w.Write([]byte(board[dir]))
+	}))
+	defer srv.Close()
+
+	c := New(Options{BaseURLs: BaseURLs{Transport: srv.URL}, CacheDir: t.TempDir()})
+	opts := DepartureOptions{SiteID: 9302, DirectionText: "towards Akalla"}
+	for i := range 2 {
+		resp, err := c.GetDepartures(context.Background(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Departures) != 1 || resp.Departures[0].Destination != "Akalla" {
+			t.Fatalf("request %d: got %+v", i+1, resp.Departures)
+		}
+	}
+	if len(queries) != 2 || queries[0] != "" || queries[1] != "1" {
+		t.Errorf("direction= sent %q, want the whole board then direction 1", queries)
+	}
+
+	// A code that no longer matches falls back to the whole board.
+	board["1"] = `{"departures": [{"destination": "Hjulsta", "direction_code": 1, "line": {"designation": "10"}}]}`
+	queries = nil
+	if resp, err := c.GetDepartures(context.Background(), opts); err != nil || len(resp.Departures) != 1 {
+		t.Fatalf("fallback: %+v, %v", resp, err)
+	}
+	if len(queries) != 2 || queries[1] != "" {
+		t.Errorf("direction= sent %q, want direction 1 then the whole board", queries)
+	}
+}
+
+func TestDirectionCode(t *testing.T) {
+	tests := []struct {
+		codes []int
+		want  int
+	}{
+		{nil, 0},
+		{[]int{2, 2}, 2},
+		{[]int{1, 2}, 0},
+		{[]int{1, 0}, 0},
+	}
+	for _, tt := range tests {
+		var deps []Departure
+		for _, code := range tt.codes {
+			deps = append(deps, Departure{DirectionCode: code})
+		}
+		if got := directionCode(deps); got != tt.want {
+			t.Errorf("directionCode(%v) = %d, want %d", tt.codes, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package sl
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// staticCacheTTL is how long the slow-changing network data (sites, stop
+// points, lines) is served from disk without asking the API again, unless
+// changed with SetStaticCacheTTL.
+const staticCacheTTL = 24 * time.Hour
+
+// SetStaticCacheTTL changes how long sites, stop points and lines are served
+// from the disk cache. Zero restores the default of a day.
+func (c *Client) SetStaticCacheTTL(d time.Duration) {
+	c.staticTTL = d
+}
+
+func (c *Client) staticCacheTTL() time.Duration {
+	if c.staticTTL > 0 {
+		return c.staticTTL
+	}
+	return staticCacheTTL
+}
+
+// Static caches are gzipped gob behind a small header: a magic string and a
+// schema version. Bump staticCacheVersion whenever a cached model type
+// changes shape; files written with another version are discarded and
+// refetched instead of being decoded into the wrong layout.
+const (
+	staticCacheMagic   = "SLC\x00"
+	staticCacheVersion = 3
+)
+
+// errCacheVersion marks a cache file written by another schema version.
+var errCacheVersion = errors.New("cache schema version changed")
+
+// diskEntry is a cached API response together with the validators needed
+// to revalidate it with a conditional request.
+type diskEntry[T any] struct {
+	FetchedAt  time.Time  `json:"fetched_at"`
+	Validators validators `json:"validators"`
+	Data       T          `json:"data"`
+}
+
+// staticCachePath returns the on-disk location of a named static cache.
+func (c *Client) staticCachePath(name string) (string, error) {
+	dir, err := c.cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "static", name+".gob.gz"), nil
+}
+
+func loadDiskEntry[T any](c *Client, name string) (*diskEntry[T], error) {
+	path, err := c.staticCachePath(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+
+	header := make([]byte, len(staticCacheMagic)+2)
+	if _, err := io.ReadFull(r, header); err != nil || string(header[:len(staticCacheMagic)]) != staticCacheMagic {
+		return nil, fmt.Errorf("%s: not a cache file", path)
+	}
+	if binary.BigEndian.Uint16(header[len(staticCacheMagic):]) != staticCacheVersion {
+		return nil, errCacheVersion
+	}
+
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	var e diskEntry[T]
+	if err := gob.NewDecoder(zr).Decode(&e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func saveDiskEntry[T any](c *Client, name string, e *diskEntry[T]) error {
+	path, err := c.staticCachePath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(staticCacheMagic)
+	binary.Write(&buf, binary.BigEndian, uint16(staticCacheVersion))
+	zw := gzip.NewWriter(&buf)
+	if err := gob.NewEncoder(zw).Encode(e); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	// Write-then-rename so a concurrent reader never sees a partial file.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	// Drop the JSON file earlier versions wrote, if any.
+	os.Remove(filepath.Join(filepath.Dir(path), name+".json"))
+	return os.Rename(tmp, path)
+}
+
+// fetchStatic returns static data from the disk cache while it is younger
+// than the static cache TTL, otherwise revalidates it against the API with a
+// conditional request. With force, the disk copy is always revalidated.
+// If the API is unreachable, a stale disk copy is served with a warning.
+// Cache failures are never fatal.
+func fetchStatic[T any](ctx context.Context, c *Client, name, rawURL string, force bool, parse func([]byte) (T, error)) (T, error) {
+	cached, cacheErr := loadDiskEntry[T](c, name)
+	if errors.Is(cacheErr, errCacheVersion) {
+		// Written by another version of sl; quietly refetch.
+		cached = nil
+	} else if cacheErr != nil && !errors.Is(cacheErr, os.ErrNotExist) {
+		c.Warn(WarnCacheUnavailable, "%s cache unreadable: %v", name, cacheErr)
+		cached = nil
+	}
+	if cached != nil && !force && time.Since(cached.FetchedAt) < c.staticCacheTTL() {
+		return cached.Data, nil
+	}
+
+	var v validators
+	if cached != nil {
+		v = cached.Validators
+	}
+	body, got, err := c.getConditional(ctx, rawURL, v)
+	switch {
+	case errors.Is(err, errNotModified):
+		cached.FetchedAt = time.Now()
+		if err := saveDiskEntry(c, name, cached); err != nil {
+			c.Warn(WarnCacheUnavailable, "could not update %s cache: %v", name, err)
+		}
+		return cached.Data, nil
+	case err != nil && cached != nil:
+		c.Warn(WarnStaleData, "using %s cached %s ago: %v", name, time.Since(cached.FetchedAt).Round(time.Minute), err)
+		return cached.Data, nil
+	case err != nil:
+		var zero T
+		return zero, err
+	}
+
+	data, err := parse(body)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	if err := saveDiskEntry(c, name, &diskEntry[T]{FetchedAt: time.Now(), Validators: got, Data: data}); err != nil {
+		c.Warn(WarnCacheUnavailable, "could not write %s cache: %v", name, err)
+	}
+	return data, nil
+}
+
+// cacheSubdirs are the directories under the cache dir that ClearCache
+// empties. Watchdog history and outage bookkeeping sit beside them and
+// are kept.
+var cacheSubdirs = []string{"static", "trips", "departures"}
+
+// ClearCache deletes cached sites, stop points and lines, cached trip plans
+// and remembered departure boards, on disk and in memory. It returns how
+// many files were removed.
+func (c *Client) ClearCache() (int, error) {
+	ResetCaches()
+	dir, err := c.cacheDir()
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, sub := range cacheSubdirs {
+		path := filepath.Join(dir, sub)
+		filepath.WalkDir(path, func(_ string, d os.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				removed++
+			}
+			return nil
+		})
+		if err := os.RemoveAll(path); err != nil {
+			return removed, fmt.Errorf("clearing cache: %w", err)
+		}
+	}
+	return removed, nil
+}
@@ -0,0 +1,156 @@
+package sl
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestFetchStatic_Revalidate(t *testing.T) {
+	dir := t.TempDir()
+	userCacheDir = func() (string, error) { return dir, nil }
+	defer func() { userCacheDir = os.UserCacheDir }()
+
+	requests, notModified := 0, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`[{"id": 9191, "name": "Medborgarplatsen"}]`))
+	}))
+	defer srv.Close()
+
+	c := NewClient()
+	ctx := context.Background()
+
+	sites, err := fetchStatic(ctx, c, "sites", srv.URL, false, parseSites)
+	if err != nil || len(sites) != 1 {
+		t.Fatalf("first fetch: %v, %v", sites, err)
+	}
+
+	// Fresh on disk: no request at all.
+	if _, err := fetchStatic(ctx, c, "sites", srv.URL, false, parseSites); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 1 {
+		t.Errorf("fresh disk cache should not hit the API, got %d requests", requests)
+	}
+
+	// Forced: revalidated with the stored ETag, answered 304.
+	sites, err = fetchStatic(ctx, c, "sites", srv.URL, true, parseSites)
+	if err != nil || len(sites) != 1 || sites[0].ID != 9191 {
+		t.Fatalf("revalidated fetch: %v, %v", sites, err)
+	}
+	if notModified != 1 {
+		t.Errorf("expected a conditional request answered 304, got %d", notModified)
+	}
+}
+
+func TestFetchStatic_StaleFallback(t *testing.T) {
+	dir := t.TempDir()
+	userCacheDir = func() (string, error) { return dir, nil }
+	defer func() { userCacheDir = os.UserCacheDir }()
+
+	var warned []Warning
+	c := NewClient()
+	saveDiskEntry(c, "sites", &diskEntry[[]int]{Data: []int{1, 2}})
+	c.SetWarningHandler(func(w Warning) { warned = append(warned, w) })
+
+	got, err := fetchStatic(context.Background(), c, "sites", "http://127.0.0.1:1/unreachable", false,
+		func([]byte) ([]int, error) { return nil, nil })
+	if err != nil || len(got) != 2 {
+		t.Fatalf("expected stale data, got %v, %v", got, err)
+	}
+	if len(warned) != 1 || warned[0].Code != WarnStaleData {
+		t.Errorf("expected a stale_data warning, got %+v", warned)
+	}
+}
+
+func TestDiskEntry_VersionMismatch(t *testing.T) {
+	dir := t.TempDir()
+	userCacheDir = func() (string, error) { return dir, nil }
+	defer func() { userCacheDir = os.UserCacheDir }()
+
+	c := NewClient()
+	if err := saveDiskEntry(c, "sites", &diskEntry[[]int]{Data: []int{1, 2}}); err != nil {
+		t.Fatal(err)
+	}
+	e, err := loadDiskEntry[[]int](c, "sites")
+	if err != nil || len(e.Data) != 2 {
+		t.Fatalf("round trip: %+v, %v", e, err)
+	}
+
+	// Rewrite the version field as if an older sl had written the file.
+	path, _ := c.staticCachePath("sites")
+	data, _ := os.ReadFile(path)
+	data[len(staticCacheMagic)+1]++
+	os.WriteFile(path, data, 0o644)
+
+	if _, err := loadDiskEntry[[]int](c, "sites"); !errors.Is(err, errCacheVersion) {
+		t.Fatalf("expected errCacheVersion, got %v", err)
+	}
+
+	// fetchStatic discards it silently and refetches.
+	var warned []Warning
+	c.SetWarningHandler(func(w Warning) { warned = append(warned, w) })
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[3]`))
+	}))
+	defer srv.Close()
+	got, err := fetchStatic(context.Background(), c, "sites", srv.URL, false,
+		func(b []byte) ([]int, error) { return []int{3}, nil })
+	if err != nil || len(got) != 1 || got[0] != 3 {
+		t.Fatalf("expected refetch, got %v, %v", got, err)
+	}
+	if len(warned) != 0 {
+		t.Errorf("version change should not warn, got %+v", warned)
+	}
+}
+
+func TestFetchStatic_TTLAndClear(t *testing.T) {
+	dir := t.TempDir()
+	userCacheDir = func() (string, error) { return dir, nil }
+	defer func() { userCacheDir = os.UserCacheDir }()
+
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`[{"id": 9191, "name": "Medborgarplatsen"}]`))
+	}))
+	defer srv.Close()
+
+	c := NewClient()
+	ctx := context.Background()
+	if _, err := fetchStatic(ctx, c, "sites", srv.URL, false, parseSites); err != nil {
+		t.Fatal(err)
+	}
+
+	// A TTL shorter than the entry's age sends the next call to the API.
+	c.SetStaticCacheTTL(time.Nanosecond)
+	if _, err := fetchStatic(ctx, c, "sites", srv.URL, false, parseSites); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 {
+		t.Errorf("expired entry should be refetched, got %d requests", requests)
+	}
+
+	c.SetStaticCacheTTL(0)
+	n, err := c.ClearCache()
+	if err != nil || n != 1 {
+		t.Fatalf("ClearCache removed %d files (err %v), want 1", n, err)
+	}
+	if _, err := fetchStatic(ctx, c, "sites", srv.URL, false, parseSites); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 3 {
+		t.Errorf("cleared cache should be refetched, got %d requests", requests)
+	}
+}
@@ -0,0 +1,26 @@
+// Package sl is a client for Stockholm public transport (SL): real-time
+// departures, service deviations, journey planning and stop search, plus
+// the helpers the sl command is built from.
+//
+// Every request takes a context. A Client needs no API key for the
+// Transport, Deviations and Journey Planner APIs; GTFS-based calls take a
+// Trafiklab key as an argument. Non-fatal conditions, such as falling back
+// to cached data, are reported through Options.OnWarning instead of
+// failing the call. Everything a client depends on, from base URLs to the
+// cache dir, is set through Options, so clients with different settings
+// can live side by side. Code that only needs the queries can take the
+// API interface, which *Client implements, and be handed a fake in tests.
+//
+//	client := sl.New(sl.Options{Timeout: 10 * time.Second})
+//	resp, err := client.GetDepartures(ctx, sl.DepartureOptions{SiteID: 9192})
+//	if err != nil {
+//		return err
+//	}
+//	for _, d := range sl.ParseDepartures(resp.Departures) {
+//		fmt.Println(d.Line, d.Destination, d.Display)
+//	}
+//
+// Exported identifiers only change incompatibly in a new major version of
+// the module. Fields may be added to result and options structs at any
+// time.
+package sl
@@ -0,0 +1,124 @@
+package sl
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ElevatorOutage is a deviation reporting an elevator out of service at a
+// station where a journey boards, changes or alights.
+type ElevatorOutage struct {
+	Station     string `json:"station"`
+	DeviationID int    `json:"deviationCaseId"`
+	Header      string `json:"header"`
+	Details     string `json:"details,omitempty"`
+	URL         string `json:"url,omitempty"`
+}
+
+// elevatorWords finds elevators in deviation texts: "hiss" also matches
+// Swedish compounds such as "plattformshissen".
+var elevatorWords = regexp.MustCompile(`(?i)hiss|\belevators?\b|\blifts?\b`)
+
+// IsElevatorDeviation reports whether any variant of d is about an elevator.
+func IsElevatorDeviation(d Deviation) bool {
+	for _, m := range d.MessageVariants {
+		if elevatorWords.MatchString(m.Header) || elevatorWords.MatchString(m.Details) {
+			return true
+		}
+	}
+	return false
+}
+
+// MarkElevatorOutages sets ElevatorOutages on each journey from the
+// elevator deviations scoped to a station the journey boards, changes or
+// alights at. Stations are matched by site, through sites' global IDs and
+// stop areas, and failing that by name. Messages are in lang if available.
+func MarkElevatorOutages(journeys []JourneyTrip, devs []Deviation, sites []Site, lang string) {
+	areaSite := map[int]int{}
+	gidSite := map[string]int{}
+	for _, s := range sites {
+		for _, a := range s.StopAreas {
+			areaSite[a] = s.ID
+		}
+		if s.GID != 0 {
+			gidSite[strconv.FormatInt(s.GID, 10)] = s.ID
+		}
+	}
+
+	var elevators []Deviation
+	for _, d := range devs {
+		if d.Scope != nil && len(d.Scope.StopAreas) > 0 && IsElevatorDeviation(d) {
+			elevators = append(elevators, d)
+		}
+	}
+
+	for i := range journeys {
+		journeys[i].ElevatorOutages = nil
+		seen := map[string]bool{}
+		for _, stop := range journeyStations(journeys[i]) {
+			site := gidSite[stop.ID]
+			if site == 0 && stop.Parent != nil {
+				site = gidSite[stop.Parent.ID]
+			}
+			name := stationName(stop)
+			for _, d := range elevators {
+				key := strconv.Itoa(d.DeviationCaseID) + "|" + name
+				if seen[key] || !affectsStation(d, areaSite, site, name) {
+					continue
+				}
+				seen[key] = true
+				msg, _ := MessageVariantFor(d.MessageVariants, lang)
+				journeys[i].ElevatorOutages = append(journeys[i].ElevatorOutages, ElevatorOutage{
+					Station:     stop.Name,
+					DeviationID: d.DeviationCaseID,
+					Header:      msg.Header,
+					Details:     msg.Details,
+					URL:         msg.Weblink,
+				})
+			}
+		}
+	}
+}
+
+// journeyStations returns the stops where a journey's vehicle legs start
+// and end, in order.
+func journeyStations(j JourneyTrip) []JourneyStop {
+	var stops []JourneyStop
+	for _, leg := range j.Legs {
+		if leg.Transport == nil || leg.Transport.Name == "" {
+			continue
+		}
+		for _, s := range []*JourneyStop{leg.Origin, leg.Destination} {
+			if s != nil {
+				stops = append(stops, *s)
+			}
+		}
+	}
+	return stops
+}
+
+// affectsStation reports whether a deviation's stop areas include the
+// station, by site when it is known and by name otherwise.
+func affectsStation(d Deviation, areaSite map[int]int, site int, name string) bool {
+	for _, a := range d.Scope.StopAreas {
+		if site != 0 && areaSite[a.ID] == site {
+			return true
+		}
+		if strings.EqualFold(stationName(JourneyStop{Name: a.Name}), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// stationName is a stop's station name without the municipality the
+// planner appends, e.g. "Slussen" for "Slussen, Stockholm".
+func stationName(s JourneyStop) string {
+	name := s.Name
+	if s.Parent != nil && s.Parent.Name != "" {
+		name = s.Parent.Name
+	}
+	name, _, _ = strings.Cut(name, ",")
+	return strings.ToLower(strings.TrimSpace(name))
+}
@@ -0,0 +1,62 @@
+package sl
+
+import "testing"
+
+func TestMarkElevatorOutages(t *testing.T) {
+	sites := []Site{
+		{ID: 9191, GID: 9091001000009191, Name: "Medborgarplatsen", StopAreas: []int{10191}},
+		{ID: 9192, GID: 9091001000009192, Name: "Slussen", StopAreas: []int{10192}},
+	}
+	stop := func(id, name string) *JourneyStop { return &JourneyStop{ID: id, Name: name} }
+	metro := &JourneyTransport{Name: "Tunnelbana 17"}
+	journeys := []JourneyTrip{{Legs: []JourneyLeg{
+		{Origin: stop("home", "Götgatan 1"), Destination: stop("9091001000009191", "Medborgarplatsen")},
+		{Origin: stop("9091001000009191", "Medborgarplatsen"), Destination: stop("x", "Slussen, Stockholm"), Transport: metro},
+	}}}
+	devs := []Deviation{
+		{DeviationCaseID: 1, Scope: &DeviationScope{StopAreas: []DeviationStopArea{{ID: 10191}}},
+			MessageVariants: []MessageVariant{
+				{Language: "sv", Header: "Hissen till plattformen ur funktion"},
+				{Language: "en", Header: "Platform elevator out of service"},
+			}},
+		{DeviationCaseID: 2, Scope: &DeviationScope{StopAreas: []DeviationStopArea{{ID: 99, Name: "Slussen"}}},
+			MessageVariants: []MessageVariant{{Language: "sv", Header: "Plattformshissen är avstängd"}}},
+		// Not an elevator, and an elevator elsewhere.
+		{DeviationCaseID: 3, Scope: &DeviationScope{StopAreas: []DeviationStopArea{{ID: 10191}}},
+			MessageVariants: []MessageVariant{{Language: "en", Header: "Replacement buses"}}},
+		{DeviationCaseID: 4, Scope: &DeviationScope{StopAreas: []DeviationStopArea{{ID: 5, Name: "Kista"}}},
+			MessageVariants: []MessageVariant{{Language: "en", Header: "Lift closed"}}},
+	}
+
+	MarkElevatorOutages(journeys, devs, sites, "en")
+	got := journeys[0].ElevatorOutages
+	if len(got) != 2 {
+		t.Fatalf("want outages at Medborgarplatsen and Slussen, got %+v", got)
+	}
+	if got[0].Station != "Medborgarplatsen" || got[0].Header != "Platform elevator out of service" {
+		t.Errorf("by site: got %+v", got[0])
+	}
+	if got[1].Station != "Slussen, Stockholm" || got[1].DeviationID != 2 {
+		t.Errorf("by name: got %+v", got[1])
+	}
+}
+
+func TestIsElevatorDeviation(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"Hiss ur funktion", true},
+		{"Plattformshissen är avstängd", true},
+		{"Elevators closed for repair", true},
+		{"The lift to platform 3 is out of order", true},
+		{"Uplifting news: trains on time", false},
+		{"Replacement buses", false},
+	}
+	for _, tt := range tests {
+		d := Deviation{MessageVariants: []MessageVariant{{Details: tt.text}}}
+		if got := IsElevatorDeviation(d); got != tt.want {
+			t.Errorf("%q: got %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package sl
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Kinds of failure, for errors.Is. ErrorCode turns them into the codes
+// machine consumers branch on.
+var (
+	ErrNotFound       = errors.New("not found")
+	ErrAmbiguousStop  = errors.New("ambiguous stop")
+	ErrAPIUnavailable = errors.New("SL API unavailable")
+	ErrNoDepartures   = errors.New("no departures")
+)
+
+// Error codes returned by ErrorCode.
+const (
+	CodeNotFound       = "NOT_FOUND"
+	CodeAmbiguousStop  = "AMBIGUOUS_STOP"
+	CodeAPIUnavailable = "API_UNAVAILABLE"
+	CodeNoDepartures   = "NO_DEPARTURES"
+	CodeError          = "ERROR" // any other failure
+)
+
+// AmbiguousStopError reports a stop name matching several stops, none of
+// them exactly.
+type AmbiguousStopError struct {
+	Name       string
+	Candidates []Site
+}
+
+func (e *AmbiguousStopError) Error() string {
+	return fmt.Sprintf("ambiguous stop name %q — %d matches", e.Name, len(e.Candidates))
+}
+
+func (e *AmbiguousStopError) Is(target error) bool { return target == ErrAmbiguousStop }
+
+func (e *APIDownError) Is(target error) bool { return target == ErrAPIUnavailable }
+
+// Is makes network failures, rate limiting and server errors match
+// ErrAPIUnavailable.
+func (e *RequestError) Is(target error) bool {
+	if target != ErrAPIUnavailable {
+		return false
+	}
+	switch APIStatus(e) {
+	case "down", "unreachable", "rate_limited":
+		return true
+	}
+	return false
+}
+
+// Errorf is fmt.Errorf for a failure of a known kind (one of the Err
+// variables): the message is the formatted one, and errors.Is matches kind
+// as well as any error wrapped with %w.
+func Errorf(kind error, format string, args ...any) error {
+	return &kindError{kind: kind, err: fmt.Errorf(format, args...)}
+}
+
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string   { return e.err.Error() }
+func (e *kindError) Unwrap() []error { return []error{e.kind, e.err} }
+
+// ErrorCode classifies err as one of the Code constants, CodeError when
+// it is of no known kind.
+func ErrorCode(err error) string {
+	switch {
+	case errors.Is(err, ErrAmbiguousStop):
+		return CodeAmbiguousStop
+	case errors.Is(err, ErrAPIUnavailable):
+		return CodeAPIUnavailable
+	case errors.Is(err, ErrNoDepartures):
+		return CodeNoDepartures
+	case errors.Is(err, ErrNotFound):
+		return CodeNotFound
+	}
+	return CodeError
+}
+
+// redactKey keeps an API key sent in a URL's query out of err's message.
+// Unlike rewriting the message into a new error, the result still matches
+// err's kind and unwraps to it, so errors.Is and errors.As see through it.
+// The URL of a *url.Error in the chain is masked too, for callers that
+// print the unwrapped error.
+func redactKey(err error, key string) error {
+	if err == nil || key == "" {
+		return err
+	}
+	secret := url.QueryEscape(key)
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = strings.ReplaceAll(urlErr.URL, secret, "***")
+	}
+	return &redactedError{err: err, secret: secret}
+}
+
+type redactedError struct {
+	err    error
+	secret string
+}
+
+func (e *redactedError) Error() string { return strings.ReplaceAll(e.err.Error(), e.secret, "***") }
+func (e *redactedError) Unwrap() error { return e.err }
@@ -0,0 +1,73 @@
+package sl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{&AmbiguousStopError{Name: "centralen", Candidates: []Site{{ID: 9001}, {ID: 1002}}}, CodeAmbiguousStop},
+		{fmt.Errorf("fetching departures: %w", &APIDownError{Host: "transport.integration.sl.se", StatusCode: 503}), CodeAPIUnavailable},
+		{&RequestError{StatusCode: 502}, CodeAPIUnavailable},
+		{&RequestError{Err: errors.New("connection refused")}, CodeAPIUnavailable},
+		{&RequestError{StatusCode: 404}, CodeError},
+		{Errorf(ErrNotFound, "no stop found matching %q", "x"), CodeNotFound},
+		{Errorf(ErrNoDepartures, "no departures found"), CodeNoDepartures},
+		{errors.New("boom"), CodeError},
+	}
+	for _, tt := range tests {
+		if got := ErrorCode(tt.err); got != tt.want {
+			t.Errorf("ErrorCode(%v) = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+
+	cause := &RequestError{StatusCode: 503}
+	err := Errorf(ErrNotFound, "bookmark %q: %w", "home", cause)
+	if err.Error() != `bookmark "home": `+cause.Error() || !errors.Is(err, ErrNotFound) || !errors.As(err, &cause) {
+		t.Errorf("Errorf should keep the message and match both its kind and the wrapped error: %v", err)
+	}
+}
+
+func TestGetTimetableErrorsKeepKindWithoutKey(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	retries := 0
+	c := New(Options{Retries: &retries, BaseURLs: BaseURLs{GTFSStatic: srv.URL}, CacheDir: t.TempDir()})
+
+	const key = "s3cret+key"
+	_, err := c.GetTimetable(context.Background(), key, false)
+	if !errors.Is(err, ErrAPIUnavailable) {
+		t.Errorf("a 503 should be ErrAPIUnavailable, got %v", err)
+	}
+	if strings.Contains(err.Error(), "s3cret") {
+		t.Errorf("key leaked into %q", err)
+	}
+
+	// Unreachable: the transport error quotes the URL, key included.
+	srv.Close()
+	_, err = c.GetTimetable(context.Background(), key, false)
+	var reqErr *RequestError
+	if !errors.Is(err, ErrAPIUnavailable) || !errors.As(err, &reqErr) {
+		t.Fatalf("an unreachable host should be an ErrAPIUnavailable RequestError, got %v", err)
+	}
+	if strings.Contains(err.Error(), "s3cret") || strings.Contains(reqErr.Error(), "s3cret") {
+		t.Errorf("key leaked into %q", reqErr)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.GetTimetable(ctx, key, false); !errors.Is(err, context.Canceled) {
+		t.Errorf("an interrupted download should be context.Canceled, got %v", err)
+	}
+}
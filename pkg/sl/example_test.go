@@ -0,0 +1,36 @@
+package sl_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/glundgren93/sl-cli/pkg/sl"
+)
+
+func Example() {
+	ctx := context.Background()
+	client := sl.New(sl.Options{
+		Timeout:   10 * time.Second,
+		OnWarning: func(w sl.Warning) { log.Println(w) },
+	})
+
+	// The stops within 300 m of Slussen, nearest first.
+	sites, err := client.GetSitesCached(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	nearby := sl.FindNearestSites(sites, 59.3199, 18.0719, 0.3)
+	if len(nearby) == 0 {
+		log.Fatal("no stops nearby")
+	}
+
+	resp, err := client.GetDepartures(ctx, sl.DepartureOptions{SiteID: nearby[0].Site.ID, TransportMode: "METRO"})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, d := range sl.ParseDepartures(resp.Departures) {
+		fmt.Printf("%s %s %s\n", d.Line, d.Destination, d.Display)
+	}
+}
@@ -0,0 +1,99 @@
+package sl
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Extras holds unmodeled response fields verbatim, by name. Decoding a
+// Departure, Deviation or JourneyLeg always fills it; a Client clears it
+// again unless made with Options.CaptureExtras.
+type Extras map[string]json.RawMessage
+
+// knownFields caches the JSON field names of each captured type.
+var knownFields sync.Map // reflect.Type -> map[string]bool
+
+// captureExtras adds the fields of data that t has no JSON field for to
+// extras, which may already hold extras decoded from a cached copy.
+func captureExtras(data []byte, t reflect.Type, extras *Extras) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	known := jsonNames(t)
+	for name, raw := range fields {
+		if known[name] {
+			continue
+		}
+		if *extras == nil {
+			*extras = Extras{}
+		}
+		(*extras)[name] = raw
+	}
+	return nil
+}
+
+// dropExtras clears the extras captured in deps, devs and the legs of
+// journeys, unless the client keeps them.
+func (c *Client) dropExtras(deps []Departure, devs []Deviation, journeys []JourneyTrip) {
+	if c.captureExtras {
+		return
+	}
+	for i := range deps {
+		deps[i].Extras = nil
+	}
+	for i := range devs {
+		devs[i].Extras = nil
+	}
+	for i := range journeys {
+		for j := range journeys[i].Legs {
+			journeys[i].Legs[j].Extras = nil
+		}
+	}
+}
+
+func jsonNames(t reflect.Type) map[string]bool {
+	if names, ok := knownFields.Load(t); ok {
+		return names.(map[string]bool)
+	}
+	names := map[string]bool{}
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		names[name] = true
+	}
+	knownFields.Store(t, names)
+	return names
+}
+
+func (d *Departure) UnmarshalJSON(data []byte) error {
+	type plain Departure
+	if err := json.Unmarshal(data, (*plain)(d)); err != nil {
+		return err
+	}
+	return captureExtras(data, reflect.TypeFor[Departure](), &d.Extras)
+}
+
+func (d *Deviation) UnmarshalJSON(data []byte) error {
+	type plain Deviation
+	if err := json.Unmarshal(data, (*plain)(d)); err != nil {
+		return err
+	}
+	return captureExtras(data, reflect.TypeFor[Deviation](), &d.Extras)
+}
+
+func (l *JourneyLeg) UnmarshalJSON(data []byte) error {
+	type plain JourneyLeg
+	if err := json.Unmarshal(data, (*plain)(l)); err != nil {
+		return err
+	}
+	return captureExtras(data, reflect.TypeFor[JourneyLeg](), &l.Extras)
+}
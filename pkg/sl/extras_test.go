@@ -0,0 +1,70 @@
+package sl
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCaptureExtras(t *testing.T) {
+	body := []byte(`{"destination": "Tanto", "display": "3 min", "vehicle_length": 2, "line": {"id": 55}}`)
+
+	var d Departure
+	if err := json.Unmarshal(body, &d); err != nil {
+		t.Fatal(err)
+	}
+	if d.Destination != "Tanto" || d.Line == nil || d.Line.ID != 55 {
+		t.Errorf("modeled fields not decoded: %+v", d)
+	}
+	if len(d.Extras) != 1 || string(d.Extras["vehicle_length"]) != "2" {
+		t.Errorf("Extras = %s, want only vehicle_length", d.Extras)
+	}
+
+	// A cached copy keeps its extras.
+	cached, err := json.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again Departure
+	if err := json.Unmarshal(cached, &again); err != nil {
+		t.Fatal(err)
+	}
+	if len(again.Extras) != 1 || string(again.Extras["vehicle_length"]) != "2" {
+		t.Errorf("Extras after a round trip = %s", again.Extras)
+	}
+
+	var dev Deviation
+	if err := json.Unmarshal([]byte(`{"version": 2, "tags": ["elevator"]}`), &dev); err != nil {
+		t.Fatal(err)
+	}
+	if dev.Version != 2 || string(dev.Extras["tags"]) != `["elevator"]` {
+		t.Errorf("Deviation = %+v", dev)
+	}
+}
+
+func TestClientCaptureExtras(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"departures": [{"destination": "Tanto", "vehicle_length": 2}]}`))
+	}))
+	defer srv.Close()
+	base := BaseURLs{Transport: srv.URL}
+	opts := DepartureOptions{SiteID: 9191}
+
+	resp, err := New(Options{BaseURLs: base}).GetDepartures(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Departures[0].Extras != nil {
+		t.Errorf("extras kept without CaptureExtras: %s", resp.Departures[0].Extras)
+	}
+
+	resp, err = New(Options{BaseURLs: base, CaptureExtras: true}).GetDepartures(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Departures[0].Extras["vehicle_length"]) != "2" {
+		t.Errorf("Extras = %s, want vehicle_length", resp.Departures[0].Extras)
+	}
+}
@@ -0,0 +1,105 @@
+package sl
+
+import (
+	"strings"
+	"time"
+)
+
+// Follow phases: what the traveller should be doing right now.
+const (
+	PhaseWalk    = "walk"    // on a footpath leg
+	PhaseWait    = "wait"    // at a stop, waiting for the next vehicle
+	PhaseRide    = "ride"    // on board
+	PhaseArrived = "arrived" // journey complete
+)
+
+// FollowStatus is where a traveller is along a journey at a moment in time.
+type FollowStatus struct {
+	Time     time.Time `json:"time"`
+	Phase    string    `json:"phase"`
+	Leg      int       `json:"leg"` // 1-based index into the journey's legs; 0 once arrived
+	Legs     int       `json:"legs"`
+	Line     string    `json:"line,omitempty"`
+	From     string    `json:"from,omitempty"`
+	To       string    `json:"to,omitempty"`
+	Departs  time.Time `json:"departs,omitzero"`
+	Arrives  time.Time `json:"arrives,omitzero"`
+	Realtime bool      `json:"realtime"`
+	// Changes are the interchanges still ahead, with current slack.
+	Changes []Interchange `json:"changes"`
+}
+
+// JourneySignature identifies a journey across re-plans: the vehicles it
+// uses and their planned departures. Estimated times are left out so the
+// same itinerary matches as delays change.
+func JourneySignature(j JourneyTrip) string {
+	var parts []string
+	for _, leg := range j.Legs {
+		if leg.Transport == nil || leg.Transport.Name == "" || leg.Origin == nil {
+			continue
+		}
+		parts = append(parts, leg.Transport.Name+"@"+leg.Origin.DepartureTimePlanned)
+	}
+	return strings.Join(parts, "|")
+}
+
+// FindJourney returns the journey with signature sig, if the planner still
+// offers it.
+func FindJourney(journeys []JourneyTrip, sig string) (JourneyTrip, bool) {
+	for _, j := range journeys {
+		if JourneySignature(j) == sig {
+			return j, true
+		}
+	}
+	return JourneyTrip{}, false
+}
+
+// JourneyStart returns the planned departure of a journey's first leg.
+func JourneyStart(j JourneyTrip) time.Time {
+	for _, leg := range j.Legs {
+		if leg.Origin != nil && leg.Origin.DepartureTimePlanned != "" {
+			return parsePlannerTime(leg.Origin.DepartureTimePlanned)
+		}
+	}
+	return time.Time{}
+}
+
+// Follow works out which leg of j is in progress at now. Legs without
+// times (some footpaths) are skipped over.
+func Follow(j JourneyTrip, now time.Time) FollowStatus {
+	st := FollowStatus{Time: now, Phase: PhaseArrived, Legs: len(j.Legs), Changes: []Interchange{}}
+	for i, leg := range j.Legs {
+		dep, arr := legDeparture(leg), legArrival(leg)
+		if arr.IsZero() || !now.Before(arr) {
+			continue
+		}
+		transit := leg.Transport != nil && leg.Transport.Name != ""
+		switch {
+		case !transit:
+			st.Phase = PhaseWalk
+		case !dep.IsZero() && now.Before(dep):
+			st.Phase = PhaseWait
+		default:
+			st.Phase = PhaseRide
+		}
+		st.Leg = i + 1
+		if transit {
+			st.Line = leg.Transport.Name
+		}
+		if leg.Origin != nil {
+			st.From = leg.Origin.Name
+			st.Realtime = leg.Origin.DepartureTimeEstimated != ""
+		}
+		if leg.Destination != nil {
+			st.To = leg.Destination.Name
+		}
+		st.Departs, st.Arrives = dep, arr
+		break
+	}
+	for _, x := range Interchanges(j) {
+		if x.Depart.IsZero() || x.Depart.After(now) {
+			st.Changes = append(st.Changes, x)
+		}
+	}
+	return st
+}
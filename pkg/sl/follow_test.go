@@ -0,0 +1,80 @@
+package sl
+
+import (
+	"testing"
+	"time"
+)
+
+// followJourney walks to Medborgarplatsen, takes the 17 to Slussen and
+// changes to the 19 to T-Centralen (times in UTC; Stockholm is UTC+1 in March).
+var followJourney = JourneyTrip{Legs: []JourneyLeg{
+	{
+		Duration:    180,
+		Origin:      &JourneyStop{Name: "Götgatan 1", DepartureTimePlanned: "2024-03-01T07:00:00Z"},
+		Destination: &JourneyStop{Name: "Medborgarplatsen", ArrivalTimePlanned: "2024-03-01T07:03:00Z"},
+	},
+	{
+		Origin:      &JourneyStop{Name: "Medborgarplatsen", DepartureTimePlanned: "2024-03-01T07:05:00Z", DepartureTimeEstimated: "2024-03-01T07:06:00Z"},
+		Destination: &JourneyStop{Name: "Slussen", ArrivalTimePlanned: "2024-03-01T07:07:00Z", ArrivalTimeEstimated: "2024-03-01T07:08:00Z"},
+		Transport:   &JourneyTransport{Name: "Tunnelbana 17"},
+	},
+	{
+		Origin:      &JourneyStop{Name: "Slussen", DepartureTimePlanned: "2024-03-01T07:11:00Z"},
+		Destination: &JourneyStop{Name: "T-Centralen", ArrivalTimePlanned: "2024-03-01T07:14:00Z"},
+		Transport:   &JourneyTransport{Name: "Tunnelbana 19"},
+	},
+}}
+
+func TestFollow(t *testing.T) {
+	at := func(hhmm string) time.Time {
+		tm, _ := time.Parse(time.RFC3339, "2024-03-01T"+hhmm+":00Z")
+		return tm
+	}
+	tests := []struct {
+		now     string
+		phase   string
+		leg     int
+		changes int
+	}{
+		{"07:01", PhaseWalk, 1, 1},
+		{"07:04", PhaseWait, 2, 1},
+		{"07:07", PhaseRide, 2, 1},
+		{"07:09", PhaseWait, 3, 1},
+		{"07:12", PhaseRide, 3, 0},
+		{"07:20", PhaseArrived, 0, 0},
+	}
+	for _, tt := range tests {
+		st := Follow(followJourney, at(tt.now))
+		if st.Phase != tt.phase || st.Leg != tt.leg || len(st.Changes) != tt.changes {
+			t.Errorf("%s: got phase %s leg %d with %d change(s), want %s leg %d with %d",
+				tt.now, st.Phase, st.Leg, len(st.Changes), tt.phase, tt.leg, tt.changes)
+		}
+	}
+
+	st := Follow(followJourney, at("07:04"))
+	if st.Line != "Tunnelbana 17" || !st.Realtime || st.Departs.Minute() != 6 {
+		t.Errorf("waiting status should use the estimated departure: %+v", st)
+	}
+}
+
+func TestFindJourney(t *testing.T) {
+	sig := JourneySignature(followJourney)
+	if sig != "Tunnelbana 17@2024-03-01T07:05:00Z|Tunnelbana 19@2024-03-01T07:11:00Z" {
+		t.Fatalf("signature = %q", sig)
+	}
+
+	// A re-plan with new estimates still matches.
+	delayed := followJourney
+	delayed.Legs = append([]JourneyLeg(nil), followJourney.Legs...)
+	origin := *delayed.Legs[2].Origin
+	origin.DepartureTimeEstimated = "2024-03-01T07:13:00Z"
+	delayed.Legs[2].Origin = &origin
+
+	got, ok := FindJourney([]JourneyTrip{{}, delayed}, sig)
+	if !ok || got.Legs[2].Origin.DepartureTimeEstimated == "" {
+		t.Errorf("expected to find the delayed journey, got %+v, %v", got, ok)
+	}
+	if _, ok := FindJourney([]JourneyTrip{{}}, sig); ok {
+		t.Error("unexpected match")
+	}
+}
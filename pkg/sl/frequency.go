@@ -0,0 +1,92 @@
+package sl
+
+import (
+	"strings"
+	"time"
+)
+
+// HourlyFrequency is how many trips of a line leave in one hour of the
+// week. Observed is the average number of departures seen in that hour in
+// recordings, when there are any covering it.
+type HourlyFrequency struct {
+	Day       string   `json:"day"` // Mon..Sun
+	Hour      int      `json:"hour"`
+	Scheduled int      `json:"scheduled"`
+	Observed  *float64 `json:"observed,omitempty"`
+}
+
+// frequencySlot indexes the 7×24 hours of a week, Monday 00 first.
+func frequencySlot(t time.Time) int {
+	return (int(t.Weekday())+6)%7*24 + t.Hour()
+}
+
+// ScheduledFrequency counts the trips of line leaving their first stop in
+// each hour of the seven days from week, Monday to Sunday. mode, when
+// given, picks one of several modes sharing the designation; direction,
+// when non-zero, one direction.
+func ScheduledFrequency(tt *Timetable, line, mode string, direction int, week time.Time) []HourlyFrequency {
+	freq := make([]HourlyFrequency, 7*24)
+	for i := range freq {
+		freq[i] = HourlyFrequency{Day: time.Weekday((i/24 + 1) % 7).String()[:3], Hour: i % 24}
+	}
+
+	week = StockholmTime(week)
+	start := time.Date(week.Year(), week.Month(), week.Day(), 0, 0, 0, 0, week.Location())
+	end := start.AddDate(0, 0, 7)
+	// Trips from the service day before the week that run past midnight
+	// leave in it too.
+	for d := -1; d < 7; d++ {
+		day := start.AddDate(0, 0, d)
+		for _, trip := range tt.Trips {
+			if !strings.EqualFold(trip.Line, line) || len(trip.Calls) < 2 ||
+				mode != "" && !strings.EqualFold(trip.Mode, mode) ||
+				direction != 0 && trip.Direction != direction ||
+				!tt.RunsOn(trip.Service, day) {
+				continue
+			}
+			dep := day.Add(time.Duration(trip.Calls[0].Dep) * time.Second)
+			if !dep.Before(start) && dep.Before(end) {
+				freq[frequencySlot(dep)].Scheduled++
+			}
+		}
+	}
+	return freq
+}
+
+// ObserveFrequency sets Observed on freq from recorded departures: line
+// holds the scheduled times of the line's departures that ran, and
+// recorded those of every departure in the recordings. An hour on a date
+// counts as covered when anything was recorded leaving in it, and Observed
+// is the line's average over the covered dates of each hour of the week.
+func ObserveFrequency(freq []HourlyFrequency, line, recorded []time.Time) {
+	type slot struct {
+		date string
+		hour int
+	}
+	key := func(t time.Time) slot {
+		t = StockholmTime(t)
+		return slot{t.Format(time.DateOnly), frequencySlot(t)}
+	}
+
+	covered := map[slot]bool{}
+	days := make([]int, len(freq))
+	for _, t := range recorded {
+		if s := key(t); !covered[s] {
+			covered[s] = true
+			days[s.hour]++
+		}
+	}
+	counts := make([]int, len(freq))
+	for _, t := range line {
+		if s := key(t); covered[s] {
+			counts[s.hour]++
+		}
+	}
+	for i := range freq {
+		freq[i].Observed = nil
+		if days[i] > 0 {
+			avg := float64(counts[i]) / float64(days[i])
+			freq[i].Observed = &avg
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package sl
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestScheduledFrequency(t *testing.T) {
+	tt := &Timetable{
+		Stops: []GraphStop{{ID: "A", Name: "Odenplan"}, {ID: "B", Name: "Fridhemsplan"}},
+		// 2024-03-04 is a Monday.
+		Services: map[string][]int32{"wk": {20240304, 20240305}, "sun": {20240303, 20240310}},
+	}
+	hm := func(h, m int) int32 { return int32(h*3600 + m*60) }
+	trip := func(line, service string, dir int, dep int32) {
+		tt.Trips = append(tt.Trips, TimetableTrip{Line: line, Mode: "BUS", Service: service, Direction: dir,
+			Calls: []TripCall{{0, dep, dep}, {1, dep + 300, dep + 300}}})
+	}
+	trip("4", "wk", 1, hm(8, 0))
+	trip("4", "wk", 1, hm(8, 30))
+	trip("4", "wk", 2, hm(8, 15))
+	trip("4", "sun", 1, hm(24, 30)) // Sunday 3 March's night trip leaves Monday 00:30
+	trip("1", "wk", 1, hm(8, 10))
+
+	loc, _ := time.LoadLocation("Europe/Stockholm")
+	freq := ScheduledFrequency(tt, "4", "", 0, time.Date(2024, 3, 4, 12, 0, 0, 0, loc))
+	if len(freq) != 7*24 {
+		t.Fatalf("got %d hours, want a week", len(freq))
+	}
+	got := map[string]int{}
+	for _, f := range freq {
+		if f.Scheduled > 0 {
+			got[fmt.Sprintf("%s %02d", f.Day, f.Hour)] = f.Scheduled
+		}
+	}
+	// Sunday 10 March's night trip leaves after the week ends.
+	want := map[string]int{"Mon 00": 1, "Mon 08": 3, "Tue 08": 3}
+	if len(got) != len(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	for k, n := range want {
+		if got[k] != n {
+			t.Errorf("%s: got %d, want %d", k, got[k], n)
+		}
+	}
+
+	if f := ScheduledFrequency(tt, "4", "", 2, time.Date(2024, 3, 4, 0, 0, 0, 0, loc)); f[8].Scheduled != 1 {
+		t.Errorf("direction 2 on Monday 08: got %d, want 1", f[8].Scheduled)
+	}
+}
+
+func TestObserveFrequency(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/Stockholm")
+	at := func(day, h, m int) time.Time { return time.Date(2024, 3, day, h, m, 0, 0, loc) }
+	freq := make([]HourlyFrequency, 7*24)
+
+	// Two Mondays recorded at 08: three buses on the 4th, one on the 11th.
+	line := []time.Time{at(4, 8, 0), at(4, 8, 20), at(4, 8, 40), at(11, 8, 5)}
+	recorded := append([]time.Time{at(11, 8, 50), at(5, 9, 0)}, line...)
+	ObserveFrequency(freq, line, recorded)
+
+	if o := freq[8].Observed; o == nil || *o != 2 {
+		t.Errorf("Mon 08: got %v, want an average of 2", o)
+	}
+	if o := freq[24+9].Observed; o == nil || *o != 0 {
+		t.Errorf("Tue 09: got %v, want 0: covered, but no bus", o)
+	}
+	if freq[10].Observed != nil {
+		t.Error("Mon 10 wasn't recorded and should have no observation")
+	}
+}
@@ -0,0 +1,65 @@
+package sl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// GeoIPBaseURL is the IP geolocation service used by sl auto --here.
+const GeoIPBaseURL = "https://ipapi.co"
+
+// IPLocation is the coarse, city-level position of an IP address.
+type IPLocation struct {
+	IP   string  `json:"ip"`
+	City string  `json:"city"`
+	Lat  float64 `json:"latitude"`
+	Lon  float64 `json:"longitude"`
+}
+
+// LocateIP geolocates ip, or this machine's public address when ip is empty.
+// Mobile and VPN addresses often resolve only to the operator's city.
+func (c *Client) LocateIP(ctx context.Context, ip string) (*IPLocation, error) {
+	u := c.base.GeoIP + "/json/"
+	if ip != "" {
+		u = c.base.GeoIP + "/" + ip + "/json/"
+	}
+	body, err := c.get(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		IPLocation
+		Error  bool   `json:"error"`
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("parsing geolocation: %w", err)
+	}
+	who := ip
+	if who == "" {
+		who = "this machine"
+	}
+	if resp.Error {
+		return nil, fmt.Errorf("geolocating %s: %s", who, resp.Reason)
+	}
+	if resp.Lat == 0 && resp.Lon == 0 {
+		return nil, fmt.Errorf("no location known for %s", who)
+	}
+	return &resp.IPLocation, nil
+}
+
+// SSHClientIP returns the address of the SSH client this process runs
+// under, from $SSH_CONNECTION or $SSH_CLIENT, or "" outside an SSH session.
+func SSHClientIP() string {
+	for _, env := range []string{"SSH_CONNECTION", "SSH_CLIENT"} {
+		fields := strings.Fields(os.Getenv(env))
+		if len(fields) > 0 && net.ParseIP(fields[0]) != nil {
+			return fields[0]
+		}
+	}
+	return ""
+}
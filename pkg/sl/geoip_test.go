@@ -0,0 +1,44 @@
+package sl
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLocateIP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/198.51.100.7/json/":
+			w.Write([]byte(`{"ip": "198.51.100.7", "city": "Stockholm", "latitude": 59.33, "longitude": 18.06}`))
+		default:
+			w.Write([]byte(`{"error": true, "reason": "Reserved IP Address"}`))
+		}
+	}))
+	defer srv.Close()
+	c := New(Options{BaseURLs: BaseURLs{GeoIP: srv.URL}})
+
+	loc, err := c.LocateIP(context.Background(), "198.51.100.7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loc.City != "Stockholm" || loc.Lat != 59.33 || loc.Lon != 18.06 {
+		t.Errorf("got %+v", loc)
+	}
+	if _, err := c.LocateIP(context.Background(), "10.0.0.1"); err == nil {
+		t.Error("expected an error for an unlocatable address")
+	}
+}
+
+func TestSSHClientIP(t *testing.T) {
+	t.Setenv("SSH_CLIENT", "")
+	t.Setenv("SSH_CONNECTION", "198.51.100.7 52144 192.0.2.1 22")
+	if got := SSHClientIP(); got != "198.51.100.7" {
+		t.Errorf("got %q, want the client address", got)
+	}
+	t.Setenv("SSH_CONNECTION", "")
+	if got := SSHClientIP(); got != "" {
+		t.Errorf("outside SSH got %q, want empty", got)
+	}
+}
@@ -0,0 +1,402 @@
+package sl
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GTFSStaticBaseURL is Trafiklab's GTFS Regional static feed for SL.
+// Requests need a Trafiklab static API key.
+const GTFSStaticBaseURL = "https://opendata.samtrafiken.se/gtfs/sl"
+
+// GraphStop is a node in the network graph: a station or stop area, with
+// its platforms merged into it.
+type GraphStop struct {
+	ID   string  `json:"id"`
+	Name string  `json:"name"`
+	Lat  float64 `json:"lat"`
+	Lon  float64 `json:"lon"`
+}
+
+// GraphEdge links two stops a line serves one after the other. Trips is
+// how many scheduled trips in the feed make that hop.
+type GraphEdge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Line  string `json:"line"`
+	Mode  string `json:"mode"`
+	Trips int    `json:"trips"`
+}
+
+// NetworkGraph is SL's stop connectivity: stops as nodes and one directed
+// edge per line between consecutive stops.
+type NetworkGraph struct {
+	Stops []GraphStop `json:"stops"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// GetNetworkGraph builds the network graph from the GTFS static feed (see
+// GetTimetable).
+func (c *Client) GetNetworkGraph(ctx context.Context, key string, force bool) (*NetworkGraph, error) {
+	tt, err := c.GetTimetable(ctx, key, force)
+	if err != nil {
+		return nil, err
+	}
+	return BuildNetworkGraph(tt), nil
+}
+
+// BuildNetworkGraph links every two stops a trip calls at one after the
+// other, once per line.
+func BuildNetworkGraph(tt *Timetable) *NetworkGraph {
+	edges := map[GraphEdge]int{}
+	used := map[int32]bool{}
+	for _, trip := range tt.Trips {
+		for i := 1; i < len(trip.Calls); i++ {
+			from, to := trip.Calls[i-1].Stop, trip.Calls[i].Stop
+			if from == to {
+				continue
+			}
+			edges[GraphEdge{From: tt.Stops[from].ID, To: tt.Stops[to].ID, Line: trip.Line, Mode: trip.Mode}]++
+			used[from], used[to] = true, true
+		}
+	}
+
+	g := &NetworkGraph{Stops: []GraphStop{}, Edges: []GraphEdge{}}
+	for i := range used {
+		g.Stops = append(g.Stops, tt.Stops[i])
+	}
+	for e, n := range edges {
+		e.Trips = n
+		g.Edges = append(g.Edges, e)
+	}
+	sort.Slice(g.Stops, func(i, j int) bool { return g.Stops[i].ID < g.Stops[j].ID })
+	sort.Slice(g.Edges, func(i, j int) bool {
+		a, b := g.Edges[i], g.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Line < b.Line
+	})
+	return g
+}
+
+// Timetable is the scheduled service in the GTFS static feed, compacted
+// for routing: stops merged into their parent stations, and each trip as
+// its sequence of calls.
+type Timetable struct {
+	Stops []GraphStop
+	Trips []TimetableTrip
+	// Services maps a GTFS service_id to the dates it runs on, as YYYYMMDD.
+	Services map[string][]int32
+	// Platforms maps the stop_id of each stop merged into a station to
+	// its index in Stops, for the stop IDs realtime feeds report.
+	Platforms map[string]int32
+}
+
+// TimetableTrip is one scheduled run of a line. Direction is GTFS's
+// direction_id plus one, so 1 or 2 like the direction codes on departure
+// boards.
+type TimetableTrip struct {
+	ID        string // GTFS trip_id
+	Line      string
+	Mode      string
+	Service   string
+	Direction int
+	Headsign  string
+	Calls     []TripCall
+}
+
+// TripCall is a trip's stop at Stops[Stop]. Arr and Dep are seconds after
+// midnight of the service day and run past 24h for trips after midnight.
+type TripCall struct {
+	Stop     int32
+	Arr, Dep int32
+}
+
+// GetTimetable downloads and compacts the GTFS static feed. The feed is
+// large and its key has a small monthly quota, so the timetable is kept
+// in the static cache and revalidated like sites and lines; force always
+// revalidates it.
+func (c *Client) GetTimetable(ctx context.Context, key string, force bool) (*Timetable, error) {
+	u := c.base.GTFSStatic + "/sl.zip?key=" + url.QueryEscape(key)
+	tt, err := fetchStatic(ctx, c, "timetable", u, force, parseTimetable)
+	if err != nil {
+		// Transport errors quote the URL; keep the key out of them.
+		return nil, redactKey(err, key)
+	}
+	return tt, nil
+}
+
+// parseTimetable reads a GTFS zip into a Timetable.
+func parseTimetable(body []byte) (*Timetable, error) {
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return nil, fmt.Errorf("opening GTFS feed: %w", err)
+	}
+
+	tt := &Timetable{Services: map[string][]int32{}, Platforms: map[string]int32{}}
+	stopIndex := map[string]int32{}
+	parent := map[string]string{}
+	var stops []GraphStop
+	err = readGTFSTable(zr, "stops.txt", []string{"stop_id", "stop_name", "stop_lat", "stop_lon", "parent_station"}, func(r []string) {
+		lat, _ := strconv.ParseFloat(r[2], 64)
+		lon, _ := strconv.ParseFloat(r[3], 64)
+		stops = append(stops, GraphStop{ID: r[0], Name: r[1], Lat: lat, Lon: lon})
+		if r[4] != "" {
+			parent[r[0]] = r[4]
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Only stations and stops without one become nodes.
+	for _, s := range stops {
+		if _, ok := parent[s.ID]; !ok {
+			stopIndex[s.ID] = int32(len(tt.Stops))
+			tt.Stops = append(tt.Stops, s)
+		}
+	}
+	for child, station := range parent {
+		if idx, ok := stopIndex[station]; ok {
+			tt.Platforms[child] = idx
+		}
+	}
+
+	type route struct{ line, mode string }
+	routes := map[string]route{}
+	err = readGTFSTable(zr, "routes.txt", []string{"route_id", "route_short_name", "route_type"}, func(r []string) {
+		t, _ := strconv.Atoi(r[2])
+		routes[r[0]] = route{r[1], gtfsMode(t)}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tripIndex := map[string]int{}
+	err = readGTFSTable(zr, "trips.txt", []string{"trip_id", "route_id", "service_id", "direction_id", "trip_headsign"}, func(r []string) {
+		rt, ok := routes[r[1]]
+		if !ok {
+			return
+		}
+		dir, _ := strconv.Atoi(r[3])
+		tripIndex[r[0]] = len(tt.Trips)
+		tt.Trips = append(tt.Trips, TimetableTrip{ID: r[0], Line: rt.line, Mode: rt.mode, Service: r[2], Direction: dir + 1, Headsign: r[4]})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	seqs := make([][]int, len(tt.Trips))
+	err = readGTFSTable(zr, "stop_times.txt", []string{"trip_id", "stop_id", "stop_sequence", "arrival_time", "departure_time"}, func(r []string) {
+		i, ok := tripIndex[r[0]]
+		if !ok {
+			return
+		}
+		stop := r[1]
+		if p, ok := parent[stop]; ok {
+			stop = p
+		}
+		idx, ok := stopIndex[stop]
+		if !ok {
+			return
+		}
+		arr, okArr := parseGTFSTime(r[3])
+		dep, okDep := parseGTFSTime(r[4])
+		switch {
+		case !okArr && !okDep:
+			return
+		case !okArr:
+			arr = dep
+		case !okDep:
+			dep = arr
+		}
+		seq, _ := strconv.Atoi(r[2])
+		tt.Trips[i].Calls = append(tt.Trips[i].Calls, TripCall{Stop: idx, Arr: arr, Dep: dep})
+		seqs[i] = append(seqs[i], seq)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range tt.Trips {
+		sort.Sort(callsBySeq{tt.Trips[i].Calls, seqs[i]})
+	}
+
+	if err := readServices(zr, tt.Services); err != nil {
+		return nil, err
+	}
+	return tt, nil
+}
+
+// callsBySeq sorts a trip's calls by their stop_sequence.
+type callsBySeq struct {
+	calls []TripCall
+	seq   []int
+}
+
+func (s callsBySeq) Len() int           { return len(s.calls) }
+func (s callsBySeq) Less(i, j int) bool { return s.seq[i] < s.seq[j] }
+func (s callsBySeq) Swap(i, j int) {
+	s.calls[i], s.calls[j] = s.calls[j], s.calls[i]
+	s.seq[i], s.seq[j] = s.seq[j], s.seq[i]
+}
+
+// readServices fills services from calendar.txt and calendar_dates.txt.
+// Feeds may have either or both; SL's lists every date in calendar_dates.
+func readServices(zr *zip.Reader, services map[string][]int32) error {
+	dates := map[string]map[int32]bool{}
+	add := func(service string, date int32, on bool) {
+		if dates[service] == nil {
+			dates[service] = map[int32]bool{}
+		}
+		if on {
+			dates[service][date] = true
+		} else {
+			delete(dates[service], date)
+		}
+	}
+
+	cols := []string{"service_id", "start_date", "end_date", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
+	err := readGTFSTable(zr, "calendar.txt", cols, func(r []string) {
+		start, ok1 := parseGTFSDate(r[1])
+		end, ok2 := parseGTFSDate(r[2])
+		if !ok1 || !ok2 {
+			return
+		}
+		for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+			// r[3] is Monday; time.Weekday counts from Sunday.
+			if r[3+(int(d.Weekday())+6)%7] == "1" {
+				add(r[0], gtfsDate(d), true)
+			}
+		}
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	err = readGTFSTable(zr, "calendar_dates.txt", []string{"service_id", "date", "exception_type"}, func(r []string) {
+		d, ok := parseGTFSDate(r[1])
+		if !ok {
+			return
+		}
+		add(r[0], gtfsDate(d), r[2] == "1")
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	for service, set := range dates {
+		list := make([]int32, 0, len(set))
+		for d := range set {
+			list = append(list, d)
+		}
+		slices.Sort(list)
+		services[service] = list
+	}
+	return nil
+}
+
+// RunsOn reports whether a service runs on the given day.
+func (tt *Timetable) RunsOn(service string, day time.Time) bool {
+	_, found := slices.BinarySearch(tt.Services[service], gtfsDate(day))
+	return found
+}
+
+// parseGTFSTime parses a GTFS "H:MM:SS" time into seconds after midnight.
+// Hours may exceed 23.
+func parseGTFSTime(s string) (int32, bool) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) != 3 {
+		return 0, false
+	}
+	var secs int
+	for _, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return 0, false
+		}
+		secs = secs*60 + n
+	}
+	return int32(secs), true
+}
+
+func parseGTFSDate(s string) (time.Time, bool) {
+	t, err := time.Parse("20060102", s)
+	return t, err == nil
+}
+
+// gtfsDate is a calendar day as YYYYMMDD.
+func gtfsDate(t time.Time) int32 {
+	return int32(t.Year()*10000 + int(t.Month())*100 + t.Day())
+}
+
+// readGTFSTable calls fn with the named columns of every row of a GTFS
+// file, in the order given. Columns missing from the file read as "".
+func readGTFSTable(zr *zip.Reader, name string, columns []string, fn func([]string)) error {
+	f, err := zr.Open(name)
+	if err != nil {
+		return fmt.Errorf("GTFS feed: %w", err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.ReuseRecord = true
+	header, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", name, err)
+	}
+	index := map[string]int{}
+	for i, h := range header {
+		index[strings.TrimPrefix(strings.TrimSpace(h), "\ufeff")] = i
+	}
+
+	row := make([]string, len(columns))
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", name, err)
+		}
+		for i, col := range columns {
+			row[i] = ""
+			if j, ok := index[col]; ok && j < len(rec) {
+				row[i] = rec[j]
+			}
+		}
+		fn(row)
+	}
+}
+
+// gtfsMode maps a GTFS route_type, basic or extended, to SL's transport
+// mode names.
+func gtfsMode(routeType int) string {
+	switch {
+	case routeType == 0 || routeType/100 == 9:
+		return "TRAM"
+	case routeType == 1 || routeType == 401 || routeType == 402:
+		return "METRO"
+	case routeType == 2 || routeType/100 == 1:
+		return "TRAIN"
+	case routeType == 3 || routeType/100 == 7:
+		return "BUS"
+	case routeType == 4 || routeType/100 == 10 || routeType == 1200:
+		return "SHIP"
+	}
+	return ""
+}
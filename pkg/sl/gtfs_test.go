@@ -0,0 +1,74 @@
+package sl
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+)
+
+func gtfsZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(body))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestBuildNetworkGraph(t *testing.T) {
+	body := gtfsZip(t, map[string]string{
+		"stops.txt": "\ufeffstop_id,stop_name,stop_lat,stop_lon,location_type,parent_station\n" +
+			"A,Slussen,59.3195,18.0722,1,\n" +
+			"A1,Slussen,59.3195,18.0722,0,A\n" +
+			"A2,Slussen,59.3196,18.0723,0,A\n" +
+			"B,Medborgarplatsen,59.3143,18.0735,0,\n" +
+			"C,Skanstull,59.3079,18.0763,0,\n",
+		"routes.txt": "route_id,agency_id,route_short_name,route_type\nr17,sl,17,401\nr4,sl,4,700\n",
+		"trips.txt":  "route_id,service_id,trip_id\nr17,s,t1\nr17,s,t2\nr4,s,t3\n",
+		// t2 is listed out of order; stop_sequence decides.
+		"stop_times.txt": "trip_id,arrival_time,departure_time,stop_id,stop_sequence\n" +
+			"t1,08:00:00,08:00:00,A1,1\nt1,08:02:00,08:02:00,B,2\nt1,08:04:00,08:04:00,C,3\n" +
+			"t2,08:12:00,08:12:00,B,2\nt2,08:10:00,08:10:00,A2,1\n" +
+			"t3,08:05:00,08:05:00,B,1\nt3,08:07:00,08:07:00,A1,2\n",
+	})
+
+	tt, err := parseTimetable(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx, ok := tt.Platforms["A2"]; !ok || tt.Stops[idx].ID != "A" {
+		t.Errorf("platform A2 should map to station A: got %v", tt.Platforms)
+	}
+	if tt.Trips[2].ID != "t3" {
+		t.Errorf("trip IDs = %q, want t3 third", tt.Trips[2].ID)
+	}
+	g := BuildNetworkGraph(tt)
+	if len(g.Stops) != 3 || g.Stops[0].ID != "A" || g.Stops[0].Name != "Slussen" {
+		t.Errorf("platforms should merge into their station: got %+v", g.Stops)
+	}
+	want := []GraphEdge{
+		{From: "A", To: "B", Line: "17", Mode: "METRO", Trips: 2},
+		{From: "B", To: "A", Line: "4", Mode: "BUS", Trips: 1},
+		{From: "B", To: "C", Line: "17", Mode: "METRO", Trips: 1},
+	}
+	if len(g.Edges) != len(want) {
+		t.Fatalf("got edges %+v, want %+v", g.Edges, want)
+	}
+	for i := range want {
+		if g.Edges[i] != want[i] {
+			t.Errorf("edge %d = %+v, want %+v", i, g.Edges[i], want[i])
+		}
+	}
+
+	if _, err := parseTimetable(gtfsZip(t, map[string]string{"stops.txt": "stop_id\n"})); err == nil {
+		t.Error("expected an error for a feed without routes.txt")
+	}
+}
@@ -0,0 +1,339 @@
+// Package gtfsrt decodes the parts of GTFS Realtime feeds the CLI uses.
+//
+// Feeds are protocol buffers; rather than pull in a protobuf runtime and
+// generated bindings, this reads the wire format directly and skips every
+// field it doesn't know. See https://gtfs.org/realtime/reference/.
+package gtfsrt
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// VehicleStatus is a vehicle's relation to its current stop.
+type VehicleStatus int
+
+const (
+	IncomingAt VehicleStatus = iota
+	StoppedAt
+	InTransitTo
+)
+
+func (s VehicleStatus) String() string {
+	switch s {
+	case IncomingAt:
+		return "INCOMING_AT"
+	case StoppedAt:
+		return "STOPPED_AT"
+	default:
+		return "IN_TRANSIT_TO"
+	}
+}
+
+// VehiclePosition is a single vehicle's reported position.
+type VehiclePosition struct {
+	EntityID    string        `json:"entity_id"`
+	VehicleID   string        `json:"vehicle_id,omitempty"`
+	Label       string        `json:"label,omitempty"`
+	TripID      string        `json:"trip_id,omitempty"`
+	RouteID     string        `json:"route_id,omitempty"`
+	DirectionID int           `json:"direction_id"`
+	Lat         float64       `json:"lat"`
+	Lon         float64       `json:"lon"`
+	Bearing     float64       `json:"bearing"`
+	HasBearing  bool          `json:"-"`
+	SpeedMS     float64       `json:"speed_ms,omitempty"`
+	StopID      string        `json:"stop_id,omitempty"`
+	Status      VehicleStatus `json:"-"`
+	Timestamp   time.Time     `json:"timestamp"`
+}
+
+// TripUpdate is the predicted progress of one trip. Delays are in seconds,
+// positive when late.
+type TripUpdate struct {
+	EntityID    string           `json:"entity_id"`
+	TripID      string           `json:"trip_id,omitempty"`
+	RouteID     string           `json:"route_id,omitempty"`
+	VehicleID   string           `json:"vehicle_id,omitempty"`
+	Delay       int              `json:"delay"`
+	HasDelay    bool             `json:"-"`
+	StopUpdates []StopTimeUpdate `json:"stop_time_updates,omitempty"`
+	Timestamp   time.Time        `json:"timestamp"`
+}
+
+// StopTimeUpdate is the prediction for one of a trip's remaining stops.
+type StopTimeUpdate struct {
+	StopSequence int    `json:"stop_sequence,omitempty"`
+	StopID       string `json:"stop_id,omitempty"`
+	Delay        int    `json:"delay"` // arrival delay, or departure delay at the first stop
+	HasDelay     bool   `json:"-"`
+	Skipped      bool   `json:"skipped,omitempty"`
+}
+
+// Feed is a decoded FeedMessage.
+type Feed struct {
+	Timestamp   time.Time
+	Vehicles    []VehiclePosition
+	TripUpdates []TripUpdate
+}
+
+// Wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errTruncated = errors.New("gtfsrt: truncated message")
+
+// field is one decoded key/value from a message.
+type field struct {
+	num   int
+	wire  int
+	u     uint64 // varint, fixed32 and fixed64 values
+	bytes []byte // length-delimited values
+}
+
+// fields splits a message into its fields.
+func fields(b []byte) ([]field, error) {
+	var out []field
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, errTruncated
+		}
+		b = b[n:]
+		f := field{num: int(key >> 3), wire: int(key & 7)}
+		switch f.wire {
+		case wireVarint:
+			v, n := binary.Uvarint(b)
+			if n <= 0 {
+				return nil, errTruncated
+			}
+			f.u, b = v, b[n:]
+		case wireFixed64:
+			if len(b) < 8 {
+				return nil, errTruncated
+			}
+			f.u, b = binary.LittleEndian.Uint64(b), b[8:]
+		case wireBytes:
+			l, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < l {
+				return nil, errTruncated
+			}
+			f.bytes, b = b[n:n+int(l)], b[n+int(l):]
+		case wireFixed32:
+			if len(b) < 4 {
+				return nil, errTruncated
+			}
+			f.u, b = uint64(binary.LittleEndian.Uint32(b)), b[4:]
+		default:
+			return nil, fmt.Errorf("gtfsrt: unsupported wire type %d", f.wire)
+		}
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+func (f field) float32() float64 { return float64(math.Float32frombits(uint32(f.u))) }
+
+// int32 reads an int32 varint, which protobuf sign-extends to 64 bits.
+func (f field) int32() int { return int(int32(f.u)) }
+
+// Decode parses a FeedMessage, keeping vehicle position and trip update
+// entities.
+func Decode(b []byte) (*Feed, error) {
+	top, err := fields(b)
+	if err != nil {
+		return nil, err
+	}
+	feed := &Feed{}
+	for _, f := range top {
+		switch f.num {
+		case 1: // header
+			hdr, err := fields(f.bytes)
+			if err != nil {
+				return nil, err
+			}
+			for _, h := range hdr {
+				if h.num == 3 {
+					feed.Timestamp = time.Unix(int64(h.u), 0)
+				}
+			}
+		case 2: // entity
+			if err := decodeEntity(f.bytes, feed); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return feed, nil
+}
+
+func decodeEntity(b []byte, feed *Feed) error {
+	fs, err := fields(b)
+	if err != nil {
+		return err
+	}
+	var id string
+	for _, f := range fs {
+		switch f.num {
+		case 1:
+			id = string(f.bytes)
+		case 3: // trip_update
+			u := TripUpdate{EntityID: id}
+			if err := decodeTripUpdate(f.bytes, &u); err != nil {
+				return err
+			}
+			feed.TripUpdates = append(feed.TripUpdates, u)
+		case 4: // vehicle
+			v := VehiclePosition{EntityID: id}
+			if err := decodeVehicle(f.bytes, &v); err != nil {
+				return err
+			}
+			feed.Vehicles = append(feed.Vehicles, v)
+		}
+	}
+	return nil
+}
+
+func decodeTripUpdate(b []byte, u *TripUpdate) error {
+	fs, err := fields(b)
+	if err != nil {
+		return err
+	}
+	for _, f := range fs {
+		switch f.num {
+		case 1: // trip
+			sub, err := fields(f.bytes)
+			if err != nil {
+				return err
+			}
+			for _, s := range sub {
+				switch s.num {
+				case 1:
+					u.TripID = string(s.bytes)
+				case 5:
+					u.RouteID = string(s.bytes)
+				}
+			}
+		case 2: // stop_time_update
+			su, err := decodeStopTimeUpdate(f.bytes)
+			if err != nil {
+				return err
+			}
+			u.StopUpdates = append(u.StopUpdates, su)
+		case 3: // vehicle descriptor
+			sub, err := fields(f.bytes)
+			if err != nil {
+				return err
+			}
+			for _, s := range sub {
+				if s.num == 1 {
+					u.VehicleID = string(s.bytes)
+				}
+			}
+		case 4:
+			u.Timestamp = time.Unix(int64(f.u), 0)
+		case 5:
+			u.Delay, u.HasDelay = f.int32(), true
+		}
+	}
+	return nil
+}
+
+func decodeStopTimeUpdate(b []byte) (StopTimeUpdate, error) {
+	var su StopTimeUpdate
+	fs, err := fields(b)
+	if err != nil {
+		return su, err
+	}
+	for _, f := range fs {
+		switch f.num {
+		case 1:
+			su.StopSequence = int(f.u)
+		case 2, 3: // arrival, departure
+			sub, err := fields(f.bytes)
+			if err != nil {
+				return su, err
+			}
+			for _, s := range sub {
+				// The arrival delay wins; a first stop has only a departure.
+				if s.num == 1 && (f.num == 2 || !su.HasDelay) {
+					su.Delay, su.HasDelay = s.int32(), true
+				}
+			}
+		case 4:
+			su.StopID = string(f.bytes)
+		case 5:
+			su.Skipped = f.u == 1 // SKIPPED
+		}
+	}
+	return su, nil
+}
+
+func decodeVehicle(b []byte, v *VehiclePosition) error {
+	fs, err := fields(b)
+	if err != nil {
+		return err
+	}
+	for _, f := range fs {
+		switch f.num {
+		case 1: // trip
+			sub, err := fields(f.bytes)
+			if err != nil {
+				return err
+			}
+			for _, s := range sub {
+				switch s.num {
+				case 1:
+					v.TripID = string(s.bytes)
+				case 5:
+					v.RouteID = string(s.bytes)
+				case 6:
+					v.DirectionID = int(s.u)
+				}
+			}
+		case 2: // position
+			sub, err := fields(f.bytes)
+			if err != nil {
+				return err
+			}
+			for _, s := range sub {
+				switch s.num {
+				case 1:
+					v.Lat = s.float32()
+				case 2:
+					v.Lon = s.float32()
+				case 3:
+					v.Bearing, v.HasBearing = s.float32(), true
+				case 5:
+					v.SpeedMS = s.float32()
+				}
+			}
+		case 4:
+			v.Status = VehicleStatus(f.u)
+		case 5:
+			v.Timestamp = time.Unix(int64(f.u), 0)
+		case 7:
+			v.StopID = string(f.bytes)
+		case 8: // vehicle descriptor
+			sub, err := fields(f.bytes)
+			if err != nil {
+				return err
+			}
+			for _, s := range sub {
+				switch s.num {
+				case 1:
+					v.VehicleID = string(s.bytes)
+				case 2:
+					v.Label = string(s.bytes)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package gtfsrt
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// Minimal protobuf encoders for building test feeds.
+
+func key(num, wire int) []byte { return binary.AppendUvarint(nil, uint64(num<<3|wire)) }
+
+func varint(num int, v uint64) []byte {
+	return binary.AppendUvarint(key(num, wireVarint), v)
+}
+
+func bytesField(num int, b []byte) []byte {
+	out := binary.AppendUvarint(key(num, wireBytes), uint64(len(b)))
+	return append(out, b...)
+}
+
+func float(num int, f float32) []byte {
+	return binary.LittleEndian.AppendUint32(key(num, wireFixed32), math.Float32bits(f))
+}
+
+func concat(parts ...[]byte) []byte {
+	var out []byte
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
+
+func TestDecode_VehiclePosition(t *testing.T) {
+	vehicle := concat(
+		bytesField(1, concat(bytesField(1, []byte("trip-1")), bytesField(5, []byte("route-55")), varint(6, 1))),
+		bytesField(2, concat(float(1, 59.3143), float(2, 18.0735), float(3, 90), float(5, 8.5))),
+		varint(5, 1709280000),
+		bytesField(7, []byte("9022001010191001")),
+		bytesField(8, concat(bytesField(1, []byte("v-7")), bytesField(2, []byte("55 Tanto")))),
+		varint(99, 42), // unknown field, skipped
+	)
+	feed := concat(
+		bytesField(1, concat(bytesField(1, []byte("2.0")), varint(3, 1709280005))),
+		bytesField(2, concat(bytesField(1, []byte("e1")), bytesField(4, vehicle))),
+		bytesField(2, concat(bytesField(1, []byte("e2")))), // non-vehicle entity
+	)
+
+	got, err := Decode(feed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Timestamp.Unix() != 1709280005 {
+		t.Errorf("feed timestamp = %v", got.Timestamp)
+	}
+	if len(got.Vehicles) != 1 {
+		t.Fatalf("got %d vehicles, want 1", len(got.Vehicles))
+	}
+	v := got.Vehicles[0]
+	if v.TripID != "trip-1" || v.RouteID != "route-55" || v.DirectionID != 1 {
+		t.Errorf("trip = %+v", v)
+	}
+	if math.Abs(v.Lat-59.3143) > 1e-4 || math.Abs(v.Lon-18.0735) > 1e-4 || !v.HasBearing || v.Bearing != 90 || v.SpeedMS != 8.5 {
+		t.Errorf("position = %+v", v)
+	}
+	if v.VehicleID != "v-7" || v.Label != "55 Tanto" || v.StopID != "9022001010191001" {
+		t.Errorf("descriptor = %+v", v)
+	}
+}
+
+func TestDecode_TripUpdate(t *testing.T) {
+	early := -int64(30) // sign-extended to 64 bits, as protobuf does
+	update := concat(
+		bytesField(1, concat(bytesField(1, []byte("trip-1")), bytesField(5, []byte("route-55")))),
+		bytesField(3, bytesField(1, []byte("v-7"))),
+		bytesField(2, concat(varint(1, 4), bytesField(3, varint(1, uint64(early))), bytesField(4, []byte("stop-a")))),
+		bytesField(2, concat(varint(1, 5), bytesField(2, varint(1, 120)), bytesField(3, varint(1, 150)), bytesField(4, []byte("stop-b")))),
+		bytesField(2, concat(varint(1, 6), bytesField(4, []byte("stop-c")), varint(5, 1))),
+		varint(5, 90),
+	)
+	feed := bytesField(2, concat(bytesField(1, []byte("e1")), bytesField(3, update)))
+
+	got, err := Decode(feed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.TripUpdates) != 1 || len(got.Vehicles) != 0 {
+		t.Fatalf("got %d trip updates and %d vehicles, want 1 and 0", len(got.TripUpdates), len(got.Vehicles))
+	}
+	u := got.TripUpdates[0]
+	if u.EntityID != "e1" || u.TripID != "trip-1" || u.RouteID != "route-55" || u.VehicleID != "v-7" || !u.HasDelay || u.Delay != 90 {
+		t.Errorf("trip update = %+v", u)
+	}
+	if len(u.StopUpdates) != 3 {
+		t.Fatalf("got %d stop updates, want 3", len(u.StopUpdates))
+	}
+	if su := u.StopUpdates[0]; su.StopSequence != 4 || su.StopID != "stop-a" || su.Delay != -30 {
+		t.Errorf("first stop = %+v, want departure delay -30", su)
+	}
+	if su := u.StopUpdates[1]; su.Delay != 120 {
+		t.Errorf("second stop delay = %d, want the arrival delay 120", su.Delay)
+	}
+	if su := u.StopUpdates[2]; !su.Skipped || su.HasDelay {
+		t.Errorf("third stop = %+v, want skipped without delay", su)
+	}
+}
+
+func TestDecode_Truncated(t *testing.T) {
+	if _, err := Decode([]byte{0x12, 0x05, 0x01}); err == nil {
+		t.Error("expected error for truncated feed")
+	}
+}
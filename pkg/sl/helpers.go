@@ -0,0 +1,519 @@
+package sl
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const stockholmTZ = "Europe/Stockholm"
+
+// StockholmTime returns t in SL's time zone, or t unchanged if the zone
+// database is unavailable.
+func StockholmTime(t time.Time) time.Time {
+	if loc, err := time.LoadLocation(stockholmTZ); err == nil {
+		return t.In(loc)
+	}
+	return t
+}
+
+// ParseDepartures converts raw departures into agent-friendly parsed departures.
+func ParseDepartures(departures []Departure) []ParsedDeparture {
+	loc, _ := time.LoadLocation(stockholmTZ)
+	now := time.Now().In(loc)
+
+	var parsed []ParsedDeparture
+	for _, d := range departures {
+		pd := ParsedDeparture{
+			Destination:   d.Destination,
+			Direction:     d.Direction,
+			DirectionCode: d.DirectionCode,
+			Display:       d.Display,
+			State:         d.State,
+		}
+
+		if d.Line != nil {
+			pd.Line = d.Line.Designation
+			pd.TransportMode = d.Line.TransportMode
+			pd.GroupOfLines = d.Line.GroupOfLines
+		}
+		if d.StopArea != nil {
+			pd.StopArea = d.StopArea.Name
+		}
+		if d.StopPoint != nil {
+			pd.StopPoint = d.StopPoint.Name
+			pd.Platform = d.StopPoint.Designation
+		}
+		pd.Deviations = d.Deviations
+		pd.Extras = d.Extras
+		pd.VehicleNotes = DepartureVehicleInfo(d).Notes()
+
+		// Parse times — SL uses "2006-01-02T15:04:05" (no timezone, local Stockholm time)
+		if t, err := time.ParseInLocation("2006-01-02T15:04:05", d.Scheduled, loc); err == nil {
+			pd.Scheduled = t
+		}
+		if t, err := time.ParseInLocation("2006-01-02T15:04:05", d.Expected, loc); err == nil {
+			pd.Expected = t
+		}
+
+		// Calculate minutes left from expected time (or scheduled if no expected)
+		ref := pd.Expected
+		if ref.IsZero() {
+			ref = pd.Scheduled
+		}
+		if !ref.IsZero() {
+			mins := int(math.Ceil(ref.Sub(now).Minutes()))
+			if mins < 0 {
+				mins = 0
+			}
+			pd.MinutesLeft = mins
+		}
+		reconcileDisplay(&pd, now)
+
+		parsed = append(parsed, pd)
+	}
+
+	return parsed
+}
+
+// DelayMinutes returns how many minutes after its scheduled time a
+// departure is expected to leave, rounded to the nearest minute and
+// negative when early. It is 0 when either time is unknown.
+func DelayMinutes(d ParsedDeparture) int {
+	if d.Scheduled.IsZero() || d.Expected.IsZero() {
+		return 0
+	}
+	return int(math.Round(d.Expected.Sub(d.Scheduled).Minutes()))
+}
+
+// ParseStopEvents converts the planner's departure monitor into parsed
+// departures like ParseDepartures. Departures within the hour show minutes
+// left, later ones the clock time. The planner doesn't report direction
+// codes, so DirectionCode is 0.
+func ParseStopEvents(events []StopEvent) []ParsedDeparture {
+	now := StockholmTime(time.Now())
+
+	parsed := []ParsedDeparture{}
+	for _, e := range events {
+		pd := ParsedDeparture{
+			Scheduled: parsePlannerTime(e.DepartureTimePlanned),
+			Expected:  parsePlannerTime(e.DepartureTimeEstimated),
+		}
+		if t := e.Transport; t != nil {
+			pd.Line = t.Number
+			pd.TransportMode = LegMode(JourneyLeg{Transport: t})
+			if t.Destination != nil {
+				pd.Destination = t.Destination.Name
+			}
+		}
+		if l := e.Location; l != nil {
+			pd.StopArea = l.Name
+			if l.Parent != nil {
+				pd.StopArea = l.Parent.Name
+			}
+			pd.StopPoint = l.DisassembledName
+			if platform, ok := l.Properties["platform"].(string); ok {
+				pd.Platform = platform
+			}
+		}
+		switch {
+		case e.IsCancelled:
+			pd.State = "CANCELLED"
+		case e.IsRealtimeControlled:
+			pd.State = "EXPECTED"
+		}
+
+		ref := pd.Expected
+		if ref.IsZero() {
+			ref = pd.Scheduled
+		}
+		if ref.IsZero() {
+			continue
+		}
+		pd.MinutesLeft = max(int(math.Ceil(ref.Sub(now).Minutes())), 0)
+		switch {
+		case pd.MinutesLeft == 0:
+			pd.Display = "Nu"
+		case pd.MinutesLeft < 60:
+			pd.Display = fmt.Sprintf("%d min", pd.MinutesLeft)
+		default:
+			pd.Display = ref.Format("15:04")
+		}
+		parsed = append(parsed, pd)
+	}
+	return parsed
+}
+
+// FilterByTransportMode filters departures by transport mode.
+func FilterByTransportMode(deps []ParsedDeparture, mode string) []ParsedDeparture {
+	if mode == "" {
+		return deps
+	}
+	mode = strings.ToUpper(mode)
+	var filtered []ParsedDeparture
+	for _, d := range deps {
+		if strings.EqualFold(d.TransportMode, mode) {
+			filtered = append(filtered, d)
+		}
+	}
+	return filtered
+}
+
+// FilterByWindow keeps departures leaving no sooner than after and no
+// later than within from now, going by MinutesLeft. A zero within sets no
+// upper bound.
+func FilterByWindow(deps []ParsedDeparture, after, within time.Duration) []ParsedDeparture {
+	if after <= 0 && within <= 0 {
+		return deps
+	}
+	minMin := int(math.Ceil(after.Minutes()))
+	maxMin := int(within.Minutes())
+	var kept []ParsedDeparture
+	for _, d := range deps {
+		if d.MinutesLeft < minMin || within > 0 && d.MinutesLeft > maxMin {
+			continue
+		}
+		kept = append(kept, d)
+	}
+	return kept
+}
+
+// LimitPerLine keeps at most n departures of each line (mode and
+// designation), preserving order. n <= 0 keeps everything.
+func LimitPerLine(deps []ParsedDeparture, n int) []ParsedDeparture {
+	if n <= 0 {
+		return deps
+	}
+	type lineKey struct{ mode, line string }
+	counts := make(map[lineKey]int)
+	var kept []ParsedDeparture
+	for _, d := range deps {
+		key := lineKey{d.TransportMode, d.Line}
+		if counts[key] < n {
+			kept = append(kept, d)
+			counts[key]++
+		}
+	}
+	return kept
+}
+
+// FilterByDirectionText keeps departures travelling in a direction matched by
+// text. A departure matches when its destination or direction contains the
+// text (a leading "towards"/"mot" is ignored); every departure of the same line
+// with the same direction code is then kept, so short-turn trips are included.
+func FilterByDirectionText(deps []Departure, text string) []Departure {
+	return FilterByDestinations(deps, []string{text})
+}
+
+// FilterByDestinations is FilterByDirectionText for several destinations:
+// it keeps departures travelling in a direction matched by any of texts.
+func FilterByDestinations(deps []Departure, texts []string) []Departure {
+	needles := make([]string, len(texts))
+	for i, t := range texts {
+		needles[i] = DirectionNeedle(t)
+	}
+
+	type lineDir struct {
+		line string
+		code int
+	}
+	lineKey := func(d Departure) string {
+		if d.Line == nil {
+			return ""
+		}
+		return d.Line.TransportMode + "/" + d.Line.Designation
+	}
+
+	matched := make(map[lineDir]bool)
+	for _, d := range deps {
+		for _, needle := range needles {
+			if strings.Contains(strings.ToLower(d.Destination), needle) ||
+				strings.Contains(strings.ToLower(d.Direction), needle) {
+				matched[lineDir{lineKey(d), d.DirectionCode}] = true
+			}
+		}
+	}
+
+	filtered := []Departure{}
+	for _, d := range deps {
+		if matched[lineDir{lineKey(d), d.DirectionCode}] {
+			filtered = append(filtered, d)
+		}
+	}
+	return filtered
+}
+
+// DirectionNeedle normalizes a destination given as a filter for
+// matching: lower case, trimmed, without a leading "towards"/"mot".
+func DirectionNeedle(text string) string {
+	needle := strings.ToLower(strings.TrimSpace(text))
+	for _, prefix := range []string{"towards ", "mot "} {
+		needle = strings.TrimPrefix(needle, prefix)
+	}
+	return needle
+}
+
+// LineDirection lists the destinations served by one direction of a line at a stop.
+type LineDirection struct {
+	Line          string   `json:"line"`
+	TransportMode string   `json:"transport_mode"`
+	DirectionCode int      `json:"direction_code"`
+	Destinations  []string `json:"destinations"`
+}
+
+// GroupDirections groups departures by line and direction code, preserving
+// first-seen order for lines, directions and destinations.
+func GroupDirections(deps []ParsedDeparture) []LineDirection {
+	type key struct {
+		mode, line string
+		code       int
+	}
+	index := make(map[key]int)
+	seen := make(map[key]map[string]bool)
+	result := []LineDirection{}
+
+	for _, d := range deps {
+		k := key{d.TransportMode, d.Line, d.DirectionCode}
+		i, ok := index[k]
+		if !ok {
+			i = len(result)
+			index[k] = i
+			seen[k] = make(map[string]bool)
+			result = append(result, LineDirection{
+				Line:          d.Line,
+				TransportMode: d.TransportMode,
+				DirectionCode: d.DirectionCode,
+				Destinations:  []string{},
+			})
+		}
+		if d.Destination != "" && !seen[k][d.Destination] {
+			seen[k][d.Destination] = true
+			result[i].Destinations = append(result[i].Destinations, d.Destination)
+		}
+	}
+	return result
+}
+
+// deviationTimeLayouts are the timestamp formats seen in the deviations API.
+var deviationTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.000Z0700",
+	"2006-01-02T15:04:05",
+}
+
+// ParseDeviationTime parses a deviation publish timestamp in Stockholm time.
+func ParseDeviationTime(s string) (time.Time, bool) {
+	if s == "" {
+		return time.Time{}, false
+	}
+	loc, err := time.LoadLocation(stockholmTZ)
+	if err != nil {
+		loc = time.Local
+	}
+	for _, layout := range deviationTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t.In(loc), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// DistanceKm calculates the Haversine distance between two coordinates in km.
+func DistanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const R = 6371.0 // Earth radius in km
+	dLat := (lat2 - lat1) * math.Pi / 180
+	dLon := (lon2 - lon1) * math.Pi / 180
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*math.Pi/180)*math.Cos(lat2*math.Pi/180)*
+			math.Sin(dLon/2)*math.Sin(dLon/2)
+	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+	return R * c
+}
+
+// FindNearestSites finds sites within a given radius (km) sorted by distance.
+func FindNearestSites(sites []Site, lat, lon, radiusKm float64) []SiteWithDistance {
+	var results []SiteWithDistance
+	for _, s := range sites {
+		d := DistanceKm(lat, lon, s.Lat, s.Lon)
+		if d <= radiusKm {
+			results = append(results, SiteWithDistance{Site: s, DistanceKm: d})
+		}
+	}
+	// Sort by distance
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].DistanceKm < results[j].DistanceKm
+	})
+	return results
+}
+
+// SiteWithDistance is a site with its distance from a reference point.
+// Colocated lists other sites of the same interchange, after ClusterSites.
+type SiteWithDistance struct {
+	Site       Site               `json:"site"`
+	DistanceKm float64            `json:"distance_km"`
+	DistanceM  int                `json:"distance_m"`
+	Types      []string           `json:"types,omitempty"`
+	Colocated  []SiteWithDistance `json:"colocated,omitempty"`
+}
+
+// ColocatedKm is how close two sites must be to count as one interchange.
+const ColocatedKm = 0.05
+
+// ClusterSites groups sites that belong to the same interchange: within
+// ColocatedKm of each other or sharing a stop area. Each group is its site
+// nearest the reference point, with the others in Colocated and the types
+// of all of them. stops must be sorted by distance, as FindNearestSites
+// returns them.
+func ClusterSites(stops []SiteWithDistance) []SiteWithDistance {
+	clustered := []SiteWithDistance{}
+	taken := make([]bool, len(stops))
+	for i, s := range stops {
+		if taken[i] {
+			continue
+		}
+		members := []SiteWithDistance{s}
+		for j := i + 1; j < len(stops); j++ {
+			if taken[j] {
+				continue
+			}
+			for _, m := range members {
+				if colocated(m.Site, stops[j].Site) {
+					members = append(members, stops[j])
+					taken[j] = true
+					break
+				}
+			}
+		}
+		s.Colocated = members[1:]
+		if len(s.Colocated) == 0 {
+			s.Colocated = nil
+		}
+		s.Types = slices.Clone(s.Types)
+		for _, m := range s.Colocated {
+			for _, t := range m.Types {
+				if !slices.Contains(s.Types, t) {
+					s.Types = append(s.Types, t)
+				}
+			}
+		}
+		clustered = append(clustered, s)
+	}
+	return clustered
+}
+
+func colocated(a, b Site) bool {
+	if DistanceKm(a.Lat, a.Lon, b.Lat, b.Lon) <= ColocatedKm {
+		return true
+	}
+	for _, area := range a.StopAreas {
+		if slices.Contains(b.StopAreas, area) {
+			return true
+		}
+	}
+	return false
+}
+
+// SiteTypes returns the distinct stop area types (METROSTN, BUSTERM, ...) of a
+// site, in the order its stop areas are listed.
+func SiteTypes(site Site, areaTypes map[int]string) []string {
+	seen := make(map[string]bool)
+	var types []string
+	for _, id := range site.StopAreas {
+		t := areaTypes[id]
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		types = append(types, t)
+	}
+	return types
+}
+
+// modeStopAreaTypes maps transport modes to the stop area types that serve them.
+var modeStopAreaTypes = map[string][]string{
+	"BUS":   {"BUSTERM"},
+	"METRO": {"METROSTN"},
+	"TRAIN": {"RAILWSTN"},
+	"TRAM":  {"TRAMSTN"},
+	"SHIP":  {"SHIPBER", "FERRYBER"},
+	"FERRY": {"SHIPBER", "FERRYBER"},
+}
+
+// ServesMode reports whether a site with the given stop area types is served
+// by the transport mode, based on the static stop area classification.
+func ServesMode(types []string, mode string) bool {
+	for _, t := range modeStopAreaTypes[strings.ToUpper(mode)] {
+		if HasType(types, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasType reports whether types contains want (case-insensitive).
+func HasType(types []string, want string) bool {
+	for _, t := range types {
+		if strings.EqualFold(t, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// MessageVariantFor picks the deviation message in lang, falling back to
+// English, then Swedish, then whatever variant comes first.
+func MessageVariantFor(variants []MessageVariant, lang string) (MessageVariant, bool) {
+	for _, want := range []string{lang, "en", "sv"} {
+		for _, v := range variants {
+			if v.Language == want {
+				return v, true
+			}
+		}
+	}
+	if len(variants) > 0 {
+		return variants[0], true
+	}
+	return MessageVariant{}, false
+}
+
+// SiteAlongPath is a site near the straight line between two points.
+type SiteAlongPath struct {
+	Site     Site    `json:"site"`
+	AlongKm  float64 `json:"along_km"`  // distance from the start, projected onto the path
+	OffsetKm float64 `json:"offset_km"` // distance from the path
+}
+
+// FindSitesAlongPath finds sites within widthKm of the straight segment from
+// (lat1, lon1) to (lat2, lon2), ordered from start to end. Distances use an
+// equirectangular projection, which is accurate to well under a metre at city
+// scale.
+func FindSitesAlongPath(sites []Site, lat1, lon1, lat2, lon2, widthKm float64) []SiteAlongPath {
+	const kmPerDegLat = 6371.0 * math.Pi / 180
+	kmPerDegLon := kmPerDegLat * math.Cos((lat1+lat2)/2*math.Pi/180)
+	project := func(lat, lon float64) (x, y float64) {
+		return (lon - lon1) * kmPerDegLon, (lat - lat1) * kmPerDegLat
+	}
+
+	bx, by := project(lat2, lon2)
+	lengthSq := bx*bx + by*by
+
+	var results []SiteAlongPath
+	for _, s := range sites {
+		px, py := project(s.Lat, s.Lon)
+		t := 0.0
+		if lengthSq > 0 {
+			t = math.Max(0, math.Min(1, (px*bx+py*by)/lengthSq))
+		}
+		offset := math.Hypot(px-t*bx, py-t*by)
+		if offset <= widthKm {
+			results = append(results, SiteAlongPath{Site: s, AlongKm: t * math.Sqrt(lengthSq), OffsetKm: offset})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].AlongKm < results[j].AlongKm
+	})
+	return results
+}
@@ -0,0 +1,490 @@
+package sl
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDistanceKm(t *testing.T) {
+	tests := []struct {
+		name     string
+		lat1     float64
+		lon1     float64
+		lat2     float64
+		lon2     float64
+		wantMin  float64
+		wantMax  float64
+	}{
+		{
+			name:    "same point",
+			lat1:    59.3121, lon1: 18.0643,
+			lat2:    59.3121, lon2: 18.0643,
+			wantMin: 0, wantMax: 0.001,
+		},
+		{
+			name:    "Magnus Ladulåsgatan to Medborgarplatsen",
+			lat1:    59.3121, lon1: 18.0643,
+			lat2:    59.3143, lon2: 18.0734,
+			wantMin: 0.3, wantMax: 0.6,
+		},
+		{
+			name:    "Magnus Ladulåsgatan to T-Centralen",
+			lat1:    59.3121, lon1: 18.0643,
+			lat2:    59.3310, lon2: 18.0593,
+			wantMin: 1.5, wantMax: 2.5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DistanceKm(tt.lat1, tt.lon1, tt.lat2, tt.lon2)
+			if got < tt.wantMin || got > tt.wantMax {
+				t.Errorf("DistanceKm() = %f, want between %f and %f", got, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestFindNearestSites(t *testing.T) {
+	sites := []Site{
+		{ID: 1, Name: "Close", Lat: 59.3122, Lon: 18.0644},
+		{ID: 2, Name: "Medium", Lat: 59.3140, Lon: 18.0700},
+		{ID: 3, Name: "Far", Lat: 59.3300, Lon: 18.0600},
+		{ID: 4, Name: "Too far", Lat: 59.4000, Lon: 18.0000},
+	}
+
+	results := FindNearestSites(sites, 59.3121, 18.0643, 0.5)
+
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results within 500m, got %d", len(results))
+	}
+	if results[0].Site.Name != "Close" {
+		t.Errorf("closest should be 'Close', got %q", results[0].Site.Name)
+	}
+	if results[1].Site.Name != "Medium" {
+		t.Errorf("second should be 'Medium', got %q", results[1].Site.Name)
+	}
+
+	// Verify sorted by distance
+	if results[0].DistanceKm > results[1].DistanceKm {
+		t.Error("results should be sorted by distance ascending")
+	}
+}
+
+func TestFindNearestSites_EmptyRadius(t *testing.T) {
+	sites := []Site{
+		{ID: 1, Name: "Far", Lat: 60.0, Lon: 18.0},
+	}
+
+	results := FindNearestSites(sites, 59.3121, 18.0643, 0.1)
+	if len(results) != 0 {
+		t.Errorf("expected 0 results, got %d", len(results))
+	}
+}
+
+func TestParseDepartures(t *testing.T) {
+	deps := []Departure{
+		{
+			Destination: "Henriksdalsberget",
+			Direction:   "Henriksdalsberget",
+			Display:     "5 min",
+			State:       "EXPECTED",
+			Scheduled:   stockholmNow().Add(5 * time.Minute).Format("2006-01-02T15:04:05"),
+			Expected:    stockholmNow().Add(5 * time.Minute).Format("2006-01-02T15:04:05"),
+			Line: &Line{
+				Designation:   "55",
+				TransportMode: "BUS",
+				GroupOfLines:  "",
+			},
+			StopArea: &StopArea{
+				Name: "Timmermansgränd",
+				Type: "BUSTERM",
+			},
+			StopPoint: &StopPoint{
+				Name:        "Timmermansgränd",
+				Designation: "A",
+			},
+		},
+	}
+
+	parsed := ParseDepartures(deps)
+
+	if len(parsed) != 1 {
+		t.Fatalf("expected 1 parsed departure, got %d", len(parsed))
+	}
+
+	pd := parsed[0]
+	if pd.Line != "55" {
+		t.Errorf("line = %q, want 55", pd.Line)
+	}
+	if pd.TransportMode != "BUS" {
+		t.Errorf("transport mode = %q, want BUS", pd.TransportMode)
+	}
+	if pd.Destination != "Henriksdalsberget" {
+		t.Errorf("destination = %q, want Henriksdalsberget", pd.Destination)
+	}
+	if pd.StopArea != "Timmermansgränd" {
+		t.Errorf("stop area = %q, want Timmermansgränd", pd.StopArea)
+	}
+	if pd.Platform != "A" {
+		t.Errorf("platform = %q, want A", pd.Platform)
+	}
+	// MinutesLeft should be around 5
+	if pd.MinutesLeft < 4 || pd.MinutesLeft > 6 {
+		t.Errorf("minutes left = %d, want ~5", pd.MinutesLeft)
+	}
+}
+
+func TestParseDepartures_PastTime(t *testing.T) {
+	deps := []Departure{
+		{
+			Destination: "Test",
+			State:       "ATSTOP",
+			Scheduled:   stockholmNow().Add(-2 * time.Minute).Format("2006-01-02T15:04:05"),
+			Expected:    stockholmNow().Add(-1 * time.Minute).Format("2006-01-02T15:04:05"),
+			Line:        &Line{Designation: "1", TransportMode: "BUS"},
+		},
+	}
+
+	parsed := ParseDepartures(deps)
+	if parsed[0].MinutesLeft != 0 {
+		t.Errorf("past departure should have 0 minutes left, got %d", parsed[0].MinutesLeft)
+	}
+}
+
+func TestFilterByTransportMode(t *testing.T) {
+	deps := []ParsedDeparture{
+		{Line: "55", TransportMode: "BUS"},
+		{Line: "17", TransportMode: "METRO"},
+		{Line: "43", TransportMode: "TRAIN"},
+		{Line: "66", TransportMode: "BUS"},
+	}
+
+	buses := FilterByTransportMode(deps, "BUS")
+	if len(buses) != 2 {
+		t.Errorf("expected 2 buses, got %d", len(buses))
+	}
+
+	metros := FilterByTransportMode(deps, "METRO")
+	if len(metros) != 1 {
+		t.Errorf("expected 1 metro, got %d", len(metros))
+	}
+
+	// Case insensitive
+	trains := FilterByTransportMode(deps, "train")
+	if len(trains) != 1 {
+		t.Errorf("expected 1 train (case insensitive), got %d", len(trains))
+	}
+
+	// Empty filter returns all
+	all := FilterByTransportMode(deps, "")
+	if len(all) != 4 {
+		t.Errorf("empty filter should return all, got %d", len(all))
+	}
+}
+
+// stockholmNow returns the current time in Stockholm, matching how SL timestamps are parsed.
+func stockholmNow() time.Time {
+	loc, _ := time.LoadLocation(stockholmTZ)
+	return time.Now().In(loc)
+}
+
+func TestParseDeviationTime(t *testing.T) {
+	tests := []struct {
+		input string
+		ok    bool
+		hour  int
+	}{
+		{"2024-03-01T04:00:00.000+01:00", true, 4},
+		{"2024-03-01T04:00:00+01:00", true, 4},
+		{"2024-03-01T03:00:00Z", true, 4},
+		{"2024-03-01T04:00:00", true, 4},
+		{"", false, 0},
+		{"tomorrow", false, 0},
+	}
+	for _, tt := range tests {
+		got, ok := ParseDeviationTime(tt.input)
+		if ok != tt.ok {
+			t.Errorf("ParseDeviationTime(%q) ok = %v, want %v", tt.input, ok, tt.ok)
+			continue
+		}
+		if ok && got.Hour() != tt.hour {
+			t.Errorf("ParseDeviationTime(%q) hour = %d, want %d (Stockholm)", tt.input, got.Hour(), tt.hour)
+		}
+	}
+}
+
+func TestSiteTypes(t *testing.T) {
+	areaTypes := map[int]string{10: "METROSTN", 11: "BUSTERM", 12: "BUSTERM"}
+	site := Site{StopAreas: []int{10, 11, 12, 99}}
+
+	types := SiteTypes(site, areaTypes)
+	if len(types) != 2 || types[0] != "METROSTN" || types[1] != "BUSTERM" {
+		t.Errorf("SiteTypes() = %v, want [METROSTN BUSTERM]", types)
+	}
+	if !HasType(types, "metrostn") {
+		t.Error("HasType should be case-insensitive")
+	}
+	if HasType(types, "RAILWSTN") {
+		t.Error("HasType should not match missing type")
+	}
+}
+
+func TestFilterByDirectionText(t *testing.T) {
+	metro := &Line{Designation: "11", TransportMode: "METRO"}
+	bus := &Line{Designation: "55", TransportMode: "BUS"}
+	deps := []Departure{
+		{Destination: "Akalla", DirectionCode: 1, Line: metro},
+		{Destination: "Kungsträdgården", DirectionCode: 2, Line: metro},
+		{Destination: "Rinkeby", DirectionCode: 1, Line: metro}, // short-turn, same direction
+		{Destination: "Tanto", DirectionCode: 1, Line: bus},
+	}
+
+	got := FilterByDirectionText(deps, "towards akalla")
+	if len(got) != 2 {
+		t.Fatalf("expected 2 departures towards Akalla, got %d", len(got))
+	}
+	for _, d := range got {
+		if d.Line.Designation != "11" || d.DirectionCode != 1 {
+			t.Errorf("unexpected departure %s to %s (dir %d)", d.Line.Designation, d.Destination, d.DirectionCode)
+		}
+	}
+
+	if got := FilterByDirectionText(deps, "Mörby"); len(got) != 0 {
+		t.Errorf("expected no matches, got %d", len(got))
+	}
+}
+
+func TestFilterByDestinations(t *testing.T) {
+	metro := &Line{Designation: "11", TransportMode: "METRO"}
+	bus := &Line{Designation: "55", TransportMode: "BUS"}
+	deps := []Departure{
+		{Destination: "Akalla", DirectionCode: 1, Line: metro},
+		{Destination: "Kungsträdgården", DirectionCode: 2, Line: metro},
+		{Destination: "Tanto", DirectionCode: 1, Line: bus},
+		{Destination: "Sofia", DirectionCode: 2, Line: bus},
+	}
+
+	got := FilterByDestinations(deps, []string{"mot Tanto", " AKALLA "})
+	if len(got) != 2 || got[0].Destination != "Akalla" || got[1].Destination != "Tanto" {
+		t.Errorf("got %+v, want the departures to Akalla and Tanto", got)
+	}
+}
+
+func TestGroupDirections(t *testing.T) {
+	deps := []ParsedDeparture{
+		{Line: "11", TransportMode: "METRO", DirectionCode: 1, Destination: "Akalla"},
+		{Line: "11", TransportMode: "METRO", DirectionCode: 2, Destination: "Kungsträdgården"},
+		{Line: "11", TransportMode: "METRO", DirectionCode: 1, Destination: "Rinkeby"},
+		{Line: "11", TransportMode: "METRO", DirectionCode: 1, Destination: "Akalla"},
+	}
+
+	dirs := GroupDirections(deps)
+	if len(dirs) != 2 {
+		t.Fatalf("expected 2 directions, got %d", len(dirs))
+	}
+	if dirs[0].DirectionCode != 1 || len(dirs[0].Destinations) != 2 || dirs[0].Destinations[1] != "Rinkeby" {
+		t.Errorf("direction 1 = %+v, want Akalla, Rinkeby", dirs[0])
+	}
+	if dirs[1].DirectionCode != 2 {
+		t.Errorf("second group should be direction 2, got %d", dirs[1].DirectionCode)
+	}
+}
+
+func TestServesMode(t *testing.T) {
+	types := []string{"METROSTN", "BUSTERM"}
+	if !ServesMode(types, "metro") || !ServesMode(types, "BUS") {
+		t.Error("expected metro and bus to be served")
+	}
+	if ServesMode(types, "TRAIN") {
+		t.Error("train should not be served")
+	}
+	if !ServesMode([]string{"FERRYBER"}, "SHIP") {
+		t.Error("ferry berth should serve SHIP")
+	}
+}
+
+func TestMessageVariantFor(t *testing.T) {
+	variants := []MessageVariant{{Header: "Inställt", Language: "sv"}, {Header: "Cancelled", Language: "en"}}
+	if v, _ := MessageVariantFor(variants, "sv"); v.Header != "Inställt" {
+		t.Errorf("sv: got %q", v.Header)
+	}
+	if v, _ := MessageVariantFor(variants, "de"); v.Header != "Cancelled" {
+		t.Errorf("unknown language should fall back to English, got %q", v.Header)
+	}
+	if _, ok := MessageVariantFor(nil, "en"); ok {
+		t.Error("no variants should report not found")
+	}
+}
+
+func TestFindSitesAlongPath(t *testing.T) {
+	sites := []Site{
+		{ID: 3, Name: "Near end", Lat: 59.3200, Lon: 18.0701},
+		{ID: 1, Name: "Near start", Lat: 59.3105, Lon: 18.0699},
+		{ID: 2, Name: "Off path", Lat: 59.3150, Lon: 18.0800},
+		{ID: 4, Name: "Past the end", Lat: 59.3300, Lon: 18.0700},
+	}
+	// A ~1.1 km walk due north along longitude 18.07.
+	got := FindSitesAlongPath(sites, 59.3100, 18.0700, 59.3200, 18.0700, 0.1)
+	if len(got) != 2 || got[0].Site.ID != 1 || got[1].Site.ID != 3 {
+		t.Fatalf("got %+v, want Near start then Near end", got)
+	}
+	if got[1].AlongKm < 1.0 || got[1].AlongKm > 1.2 || got[1].OffsetKm > 0.01 {
+		t.Errorf("near end: along %.3f km, offset %.3f km", got[1].AlongKm, got[1].OffsetKm)
+	}
+}
+
+func TestLimitPerLine(t *testing.T) {
+	deps := []ParsedDeparture{
+		{Line: "55", TransportMode: "BUS", Display: "1 min"},
+		{Line: "17", TransportMode: "METRO", Display: "2 min"},
+		{Line: "55", TransportMode: "BUS", Display: "4 min"},
+		{Line: "55", TransportMode: "BUS", Display: "9 min"},
+		{Line: "17", TransportMode: "METRO", Display: "12 min"},
+		{Line: "17", TransportMode: "TRAM", Display: "13 min"},
+	}
+	got := LimitPerLine(deps, 2)
+	var displays []string
+	for _, d := range got {
+		displays = append(displays, d.Display)
+	}
+	want := "1 min,2 min,4 min,12 min,13 min"
+	if strings.Join(displays, ",") != want {
+		t.Errorf("LimitPerLine = %v, want %s", displays, want)
+	}
+	if len(LimitPerLine(deps, 0)) != len(deps) {
+		t.Error("n = 0 should keep everything")
+	}
+}
+
+func TestParseDepartures_Deviations(t *testing.T) {
+	raw := `{"destination": "Skarpnäck", "state": "EXPECTED", "line": {"designation": "17", "transport_mode": "METRO"},
+		"deviations": [{"importance_level": 5, "consequence": "INFORMATION", "message": "Ersättningsbuss mellan Gullmarsplan och Skarpnäck."}]}`
+	var dep Departure
+	if err := json.Unmarshal([]byte(raw), &dep); err != nil {
+		t.Fatal(err)
+	}
+
+	parsed := ParseDepartures([]Departure{dep})
+	want := DepartureDeviation{ImportanceLevel: 5, Consequence: "INFORMATION", Message: "Ersättningsbuss mellan Gullmarsplan och Skarpnäck."}
+	if len(parsed[0].Deviations) != 1 || parsed[0].Deviations[0] != want {
+		t.Errorf("deviations = %+v, want [%+v]", parsed[0].Deviations, want)
+	}
+}
+
+func TestParseStopEvents(t *testing.T) {
+	now := time.Now().UTC()
+	at := func(d time.Duration) string { return now.Add(d).Format(time.RFC3339) }
+	metro := &JourneyTransport{Number: "17", Product: &TransportProduct{CatOutL: "Metro"}, Destination: &TransportDest{Name: "Åkeshov"}}
+	platform := &JourneyStop{Name: "Medborgarplatsen 1", Parent: &Parent{Name: "Medborgarplatsen"}, Properties: map[string]any{"platform": "1"}}
+	events := []StopEvent{
+		{Location: platform, DepartureTimePlanned: at(4 * time.Minute), DepartureTimeEstimated: at(6*time.Minute - time.Second), Transport: metro, IsRealtimeControlled: true},
+		{Location: platform, DepartureTimePlanned: at(3 * time.Hour), Transport: metro},
+		{Location: platform, DepartureTimePlanned: at(10 * time.Minute), Transport: metro, IsCancelled: true},
+		{Location: platform, Transport: metro}, // no time
+	}
+
+	got := ParseStopEvents(events)
+	if len(got) != 3 {
+		t.Fatalf("got %d departures, want 3", len(got))
+	}
+	d := got[0]
+	if d.Line != "17" || d.TransportMode != "METRO" || d.Destination != "Åkeshov" || d.StopArea != "Medborgarplatsen" || d.Platform != "1" {
+		t.Errorf("first departure = %+v", d)
+	}
+	if d.MinutesLeft != 6 || d.Display != "6 min" || d.State != "EXPECTED" {
+		t.Errorf("first departure: %d min, display %q, state %q; want 6, \"6 min\", EXPECTED", d.MinutesLeft, d.Display, d.State)
+	}
+	if want := StockholmTime(now.Add(3 * time.Hour)).Format("15:04"); got[1].Display != want || got[1].State != "" {
+		t.Errorf("later departure display %q state %q, want %q and no state", got[1].Display, got[1].State, want)
+	}
+	if got[2].State != "CANCELLED" {
+		t.Errorf("cancelled departure state = %q", got[2].State)
+	}
+}
+
+func TestPlannerStopID(t *testing.T) {
+	if got := PlannerStopID(9191); got != "9091001000009191" {
+		t.Errorf("PlannerStopID(9191) = %s", got)
+	}
+}
+
+func TestFilterByWindow(t *testing.T) {
+	var deps []ParsedDeparture
+	for _, m := range []int{0, 4, 9, 10, 25, 30, 31} {
+		deps = append(deps, ParsedDeparture{MinutesLeft: m})
+	}
+	minutes := func(deps []ParsedDeparture) []int {
+		var ms []int
+		for _, d := range deps {
+			ms = append(ms, d.MinutesLeft)
+		}
+		return ms
+	}
+
+	tests := []struct {
+		after, within time.Duration
+		want          []int
+	}{
+		{0, 0, []int{0, 4, 9, 10, 25, 30, 31}},
+		{10 * time.Minute, 0, []int{10, 25, 30, 31}},
+		{0, 30 * time.Minute, []int{0, 4, 9, 10, 25, 30}},
+		{9*time.Minute + 30*time.Second, 30 * time.Minute, []int{10, 25, 30}},
+	}
+	for _, tt := range tests {
+		got := minutes(FilterByWindow(deps, tt.after, tt.within))
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("FilterByWindow(%s, %s) = %v, want %v", tt.after, tt.within, got, tt.want)
+		}
+	}
+}
+
+func TestClusterSites(t *testing.T) {
+	stops := []SiteWithDistance{
+		{Site: Site{ID: 1, Name: "Slussen", Lat: 59.3195, Lon: 18.0722, StopAreas: []int{10}}, Types: []string{"METROSTN"}},
+		{Site: Site{ID: 2, Name: "Slussen bussterminal", Lat: 59.3198, Lon: 18.0727}, Types: []string{"BUSTERM"}},
+		{Site: Site{ID: 3, Name: "Slussen (Saltsjöbanan)", Lat: 59.3210, Lon: 18.0760, StopAreas: []int{11, 10}}, Types: []string{"METROSTN", "RAILWSTN"}},
+		{Site: Site{ID: 4, Name: "Gamla stan", Lat: 59.3231, Lon: 18.0675, StopAreas: []int{12}}},
+	}
+	got := ClusterSites(stops)
+	if len(got) != 2 || got[0].Site.ID != 1 || got[1].Site.ID != 4 {
+		t.Fatalf("got %+v, want Slussen and Gamla stan", got)
+	}
+	var ids []int
+	for _, m := range got[0].Colocated {
+		ids = append(ids, m.Site.ID)
+	}
+	if fmt.Sprint(ids) != "[2 3]" {
+		t.Errorf("Slussen clusters %v, want [2 3]: 2 is 40m away, 3 shares a stop area", ids)
+	}
+	if fmt.Sprint(got[0].Types) != "[METROSTN BUSTERM RAILWSTN]" {
+		t.Errorf("types = %v", got[0].Types)
+	}
+	if fmt.Sprint(stops[0].Types) != "[METROSTN]" {
+		t.Errorf("ClusterSites changed its input's types: %v", stops[0].Types)
+	}
+	if got[1].Colocated != nil {
+		t.Errorf("Gamla stan clusters %+v, want nothing", got[1].Colocated)
+	}
+}
+
+func TestDelayMinutes(t *testing.T) {
+	sched := time.Date(2025, 6, 2, 12, 5, 0, 0, time.UTC)
+	tests := []struct {
+		expected time.Time
+		want     int
+	}{
+		{sched.Add(3*time.Minute + 20*time.Second), 3},
+		{sched.Add(-90 * time.Second), -2},
+		{sched, 0},
+		{time.Time{}, 0},
+	}
+	for _, tt := range tests {
+		if got := DelayMinutes(ParsedDeparture{Scheduled: sched, Expected: tt.expected}); got != tt.want {
+			t.Errorf("DelayMinutes(expected %v) = %d, want %d", tt.expected, got, tt.want)
+		}
+	}
+}
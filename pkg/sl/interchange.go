@@ -0,0 +1,184 @@
+package sl
+
+import (
+	"math"
+	"time"
+)
+
+// Interchange is a change between two vehicles within a journey.
+type Interchange struct {
+	At            string       `json:"at"`
+	FromLine      string       `json:"from_line"`
+	ToLine        string       `json:"to_line"`
+	FromPlatform  string       `json:"from_platform,omitempty"`
+	ToPlatform    string       `json:"to_platform,omitempty"`
+	ToStop        string       `json:"to_stop,omitempty"` // set when the change means walking to another stop
+	Arrive        time.Time    `json:"arrive"`
+	Depart        time.Time    `json:"depart"`
+	WalkMin       int          `json:"walk_min"`
+	SlackMin      int          `json:"slack_min"`
+	Connection    Catchability `json:"connection"`
+	RealtimeBased bool         `json:"realtime_based"`
+	// Risk is 0–100: how likely the connection is to fail, given the slack
+	// and how uncertain the times are. RiskLevel buckets it.
+	Risk      int    `json:"risk"`
+	RiskLevel string `json:"risk_level"`
+}
+
+// Connection risk levels.
+const (
+	RiskSafe  = "safe"
+	RiskTight = "tight"
+	RiskRisky = "risky"
+)
+
+// platformKeys are planner stop properties naming the platform or stop position.
+var platformKeys = []string{"platformName", "platform", "plannedPlatformName"}
+
+// Interchanges lists the changes in a journey: where they happen, which
+// platforms are involved, the walk between them and how much slack is left
+// given the current (estimated) times. A connection is catchable with two
+// minutes or more to spare, marginal with less, and missed when the next
+// vehicle leaves before you can get there.
+func Interchanges(j JourneyTrip) []Interchange {
+	result := []Interchange{}
+	var prev *JourneyLeg
+	walkSecs := 0
+	for i := range j.Legs {
+		leg := &j.Legs[i]
+		if leg.Transport == nil || leg.Transport.Name == "" {
+			if prev != nil {
+				walkSecs += leg.Duration
+			}
+			continue
+		}
+		if prev != nil && prev.Destination != nil && leg.Origin != nil {
+			result = append(result, interchange(*prev, *leg, walkSecs))
+		}
+		prev, walkSecs = leg, 0
+	}
+	return result
+}
+
+func interchange(in, out JourneyLeg, walkSecs int) Interchange {
+	x := Interchange{
+		At:           in.Destination.Name,
+		FromLine:     in.Transport.Name,
+		ToLine:       out.Transport.Name,
+		FromPlatform: stopPlatform(in.Destination),
+		ToPlatform:   stopPlatform(out.Origin),
+		Arrive:       legArrival(in),
+		Depart:       legDeparture(out),
+		RealtimeBased: in.Destination.ArrivalTimeEstimated != "" &&
+			out.Origin.DepartureTimeEstimated != "",
+	}
+	if out.Origin.Name != in.Destination.Name {
+		x.ToStop = out.Origin.Name
+	}
+
+	walk := time.Duration(walkSecs) * time.Second
+	if walk == 0 && x.ToStop != "" {
+		// No footpath leg from the planner; estimate from the coordinates.
+		walk = WalkingTime(DistanceKm(in.Destination.Coord[0], in.Destination.Coord[1],
+			out.Origin.Coord[0], out.Origin.Coord[1]))
+	}
+	x.WalkMin = int(math.Ceil(walk.Minutes()))
+
+	if !x.Arrive.IsZero() && !x.Depart.IsZero() {
+		x.SlackMin = int(math.Floor(x.Depart.Sub(x.Arrive).Minutes())) - x.WalkMin
+		switch {
+		case x.SlackMin >= catchMarginMin:
+			x.Connection = CatchYes
+		case x.SlackMin >= 0:
+			x.Connection = CatchMarginal
+		default:
+			x.Connection = CatchNo
+		}
+		x.Risk = connectionRisk(x.SlackMin, x.RealtimeBased, arrivalDelay(in))
+		x.RiskLevel = riskLevel(x.Risk)
+	}
+	return x
+}
+
+// connectionRisk scores a change from 0 (safe) to 100 (expect to miss it).
+// Slack is measured against the uncertainty of the times: a minute from a
+// timetable, or from an already late vehicle whose delay tends to grow,
+// counts for less than a minute from a vehicle running to time.
+func connectionRisk(slackMin int, realtime bool, inboundDelay time.Duration) int {
+	if slackMin < 0 {
+		return 100
+	}
+	sigma := 1.0
+	if !realtime {
+		sigma = 2
+	}
+	sigma += math.Abs(inboundDelay.Minutes()) / 2
+	return int(math.Round(100 * math.Exp(-float64(slackMin)/sigma)))
+}
+
+func riskLevel(risk int) string {
+	switch {
+	case risk >= 50:
+		return RiskRisky
+	case risk >= 20:
+		return RiskTight
+	}
+	return RiskSafe
+}
+
+// arrivalDelay is how late a leg is expected to arrive, or 0 without an estimate.
+func arrivalDelay(leg JourneyLeg) time.Duration {
+	if leg.Destination == nil || leg.Destination.ArrivalTimeEstimated == "" {
+		return 0
+	}
+	planned := parsePlannerTime(leg.Destination.ArrivalTimePlanned)
+	estimated := parsePlannerTime(leg.Destination.ArrivalTimeEstimated)
+	if planned.IsZero() || estimated.IsZero() {
+		return 0
+	}
+	return estimated.Sub(planned)
+}
+
+// FilterByMinTransfer drops journeys with a change shorter than minTransfer, counted
+// from arrival to the next departure (walking included).
+func FilterByMinTransfer(journeys []JourneyTrip, minTransfer time.Duration) []JourneyTrip {
+	if minTransfer <= 0 {
+		return journeys
+	}
+	kept := []JourneyTrip{}
+	for _, j := range journeys {
+		ok := true
+		for _, x := range Interchanges(j) {
+			if !x.Arrive.IsZero() && !x.Depart.IsZero() && x.Depart.Sub(x.Arrive) < minTransfer {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			kept = append(kept, j)
+		}
+	}
+	return kept
+}
+
+// stopPlatform returns the platform name the planner gives for a stop, if any.
+func stopPlatform(s *JourneyStop) string {
+	for _, k := range platformKeys {
+		if v, ok := s.Properties[k].(string); ok && v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// legArrival returns a leg's arrival in Stockholm time, or the zero time.
+func legArrival(leg JourneyLeg) time.Time {
+	if leg.Destination == nil {
+		return time.Time{}
+	}
+	raw := leg.Destination.ArrivalTimeEstimated
+	if raw == "" {
+		raw = leg.Destination.ArrivalTimePlanned
+	}
+	return parsePlannerTime(raw)
+}
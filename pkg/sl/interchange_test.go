@@ -0,0 +1,79 @@
+package sl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInterchanges(t *testing.T) {
+	j := JourneyTrip{Legs: []JourneyLeg{
+		{
+			Transport:   &JourneyTransport{Name: "Tunnelbana 17"},
+			Origin:      &JourneyStop{Name: "Medborgarplatsen", DepartureTimePlanned: "2024-03-01T07:03:00Z"},
+			Destination: &JourneyStop{Name: "T-Centralen", ArrivalTimePlanned: "2024-03-01T07:10:00Z", ArrivalTimeEstimated: "2024-03-01T07:12:00Z", Properties: map[string]any{"platformName": "2"}},
+		},
+		{Duration: 240, Origin: &JourneyStop{Name: "T-Centralen"}, Destination: &JourneyStop{Name: "Stockholm City"}},
+		{
+			Transport:   &JourneyTransport{Name: "Pendeltåg 41"},
+			Origin:      &JourneyStop{Name: "Stockholm City", DepartureTimePlanned: "2024-03-01T07:17:00Z", Properties: map[string]any{"platform": "1"}},
+			Destination: &JourneyStop{Name: "Södertälje C", ArrivalTimePlanned: "2024-03-01T07:50:00Z"},
+		},
+	}}
+
+	got := Interchanges(j)
+	if len(got) != 1 {
+		t.Fatalf("got %d interchanges, want 1", len(got))
+	}
+	x := got[0]
+	if x.At != "T-Centralen" || x.ToStop != "Stockholm City" || x.FromPlatform != "2" || x.ToPlatform != "1" {
+		t.Errorf("interchange = %+v", x)
+	}
+	// Arrives 07:12 (2 min late), 4 min walk, leaves 07:17: 1 min to spare.
+	if x.WalkMin != 4 || x.SlackMin != 1 || x.Connection != CatchMarginal {
+		t.Errorf("walk %d, slack %d, connection %v; want 4, 1, marginal", x.WalkMin, x.SlackMin, x.Connection)
+	}
+	if x.RealtimeBased {
+		t.Error("departure has no estimate, so the connection isn't realtime-based")
+	}
+	// Timetable departure and a late arrival make one minute of slack risky.
+	if x.RiskLevel != RiskRisky || x.Risk != 72 {
+		t.Errorf("risk %d (%s), want 72 (risky)", x.Risk, x.RiskLevel)
+	}
+
+	// Arrival is 07:12, departure 07:17: a 5 minute change.
+	if kept := FilterByMinTransfer([]JourneyTrip{j}, 5*time.Minute); len(kept) != 1 {
+		t.Error("5 min change should pass --min-transfer 5m")
+	}
+	if kept := FilterByMinTransfer([]JourneyTrip{j}, 6*time.Minute); len(kept) != 0 {
+		t.Error("5 min change should fail --min-transfer 6m")
+	}
+
+	if direct := Interchanges(JourneyTrip{Legs: j.Legs[:1]}); len(direct) != 0 {
+		t.Errorf("direct journey should have no interchanges, got %+v", direct)
+	}
+}
+
+func TestConnectionRisk(t *testing.T) {
+	tests := []struct {
+		slack    int
+		realtime bool
+		delay    time.Duration
+		level    string
+	}{
+		{4, true, 0, RiskSafe},
+		{2, true, 0, RiskSafe},
+		{1, true, 0, RiskTight},
+		{0, true, 0, RiskRisky},
+		{-1, true, 0, RiskRisky},
+		{2, false, 0, RiskTight},
+		{4, false, 0, RiskSafe},
+		{3, true, 4 * time.Minute, RiskTight},
+	}
+	for _, tt := range tests {
+		risk := connectionRisk(tt.slack, tt.realtime, tt.delay)
+		if got := riskLevel(risk); got != tt.level {
+			t.Errorf("slack %d, realtime %v, delay %s: risk %d (%s), want %s",
+				tt.slack, tt.realtime, tt.delay, risk, got, tt.level)
+		}
+	}
+}
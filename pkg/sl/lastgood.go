@@ -0,0 +1,101 @@
+package sl
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// lastGoodMaxAge bounds how old a remembered departure board may be and
+// still stand in for a failed request.
+const lastGoodMaxAge = 30 * time.Minute
+
+// lastGoodEntry is a departures response as last fetched successfully.
+type lastGoodEntry struct {
+	FetchedAt time.Time          `json:"fetched_at"`
+	Response  DeparturesResponse `json:"response"`
+}
+
+// GetDeparturesLastGood is GetDepartures for dashboards: it remembers the
+// last successful response on disk and, when SL fails to answer, returns
+// that instead of an error, with the departures that have since left
+// dropped. The returned time is when the departures were fetched — zero
+// when they are fresh — so callers can show their age.
+func (c *Client) GetDeparturesLastGood(ctx context.Context, opts DepartureOptions) (*DeparturesResponse, time.Time, error) {
+	resp, err := c.GetDepartures(ctx, opts)
+	path, pathErr := c.lastGoodPath(opts)
+	if err == nil {
+		if pathErr == nil {
+			saveLastGood(path, resp)
+		}
+		return resp, time.Time{}, nil
+	}
+	if ctx.Err() != nil || pathErr != nil {
+		return nil, time.Time{}, err
+	}
+
+	entry, ok := loadLastGood(path)
+	if !ok {
+		return nil, time.Time{}, err
+	}
+	entry.Response.Departures = upcomingDepartures(entry.Response.Departures, time.Now())
+	c.dropExtras(entry.Response.Departures, nil, nil)
+	c.Warn(WarnStaleData, "showing departures from %d min ago: %v",
+		int(time.Since(entry.FetchedAt).Minutes()), err)
+	return &entry.Response, entry.FetchedAt, nil
+}
+
+// lastGoodPath is where the last good response for a request is kept.
+func (c *Client) lastGoodPath(opts DepartureOptions) (string, error) {
+	dir, err := c.cacheDir()
+	if err != nil {
+		return "", err
+	}
+	raw := fmt.Sprintf("%d|%s|%s|%d|%s", opts.SiteID, opts.TransportMode, opts.Line, opts.Direction, opts.DirectionText)
+	if len(opts.Towards) > 0 {
+		raw += "|" + strings.Join(opts.Towards, "|")
+	}
+	sum := sha256.Sum256([]byte(raw))
+	return filepath.Join(dir, "departures", hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+func saveLastGood(path string, resp *DeparturesResponse) {
+	data, err := json.Marshal(lastGoodEntry{FetchedAt: time.Now(), Response: *resp})
+	if err != nil || os.MkdirAll(filepath.Dir(path), 0o755) != nil {
+		return
+	}
+	os.WriteFile(path, data, 0o644)
+}
+
+func loadLastGood(path string) (lastGoodEntry, bool) {
+	var entry lastGoodEntry
+	data, err := os.ReadFile(path)
+	if err != nil || json.Unmarshal(data, &entry) != nil {
+		return entry, false
+	}
+	return entry, time.Since(entry.FetchedAt) < lastGoodMaxAge
+}
+
+// upcomingDepartures drops departures expected (or, failing that,
+// scheduled) before now.
+func upcomingDepartures(deps []Departure, now time.Time) []Departure {
+	loc, _ := time.LoadLocation(stockholmTZ)
+	kept := deps[:0]
+	for _, d := range deps {
+		raw := d.Expected
+		if raw == "" {
+			raw = d.Scheduled
+		}
+		t, err := time.ParseInLocation("2006-01-02T15:04:05", raw, loc)
+		if err != nil || !t.Before(now) {
+			kept = append(kept, d)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,59 @@
+package sl
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetDeparturesLastGood(t *testing.T) {
+	dir := t.TempDir()
+	userCacheDir = func() (string, error) { return dir, nil }
+	defer func() { userCacheDir = os.UserCacheDir }()
+
+	now := StockholmTime(time.Now())
+	left := now.Add(-2 * time.Minute).Format("2006-01-02T15:04:05")
+	soon := now.Add(5 * time.Minute).Format("2006-01-02T15:04:05")
+	var down atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"departures": [{"destination": "Gone", "expected": "` + left + `"}, {"destination": "Soon", "expected": "` + soon + `"}]}`))
+	}))
+	defer srv.Close()
+
+	var warned []Warning
+	c := New(Options{BaseURLs: BaseURLs{Transport: srv.URL}})
+	c.SetWarningHandler(func(w Warning) { warned = append(warned, w) })
+	opts := DepartureOptions{SiteID: 9191}
+
+	resp, asOf, err := c.GetDeparturesLastGood(context.Background(), opts)
+	if err != nil || len(resp.Departures) != 2 || !asOf.IsZero() {
+		t.Fatalf("fresh fetch: %+v, as of %v, %v", resp, asOf, err)
+	}
+
+	down.Store(true)
+	resp, asOf, err = c.GetDeparturesLastGood(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("expected the last good board, got %v", err)
+	}
+	if asOf.IsZero() {
+		t.Error("a remembered board should report when it was fetched")
+	}
+	if len(resp.Departures) != 1 || resp.Departures[0].Destination != "Soon" {
+		t.Errorf("departures that have left should be dropped, got %+v", resp.Departures)
+	}
+	if len(warned) != 1 || warned[0].Code != WarnStaleData {
+		t.Errorf("expected a stale_data warning, got %+v", warned)
+	}
+
+	if _, _, err := c.GetDeparturesLastGood(context.Background(), DepartureOptions{SiteID: 1080}); err == nil {
+		t.Error("a site never fetched has no board to fall back to")
+	}
+}
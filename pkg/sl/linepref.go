@@ -0,0 +1,53 @@
+package sl
+
+import (
+	"slices"
+	"strings"
+)
+
+// RidesLine reports whether any leg of j rides one of lines, given by
+// designation (case-insensitive).
+func RidesLine(j JourneyTrip, lines []string) bool {
+	for _, leg := range j.Legs {
+		if leg.Transport == nil || leg.Transport.Number == "" {
+			continue
+		}
+		if slices.ContainsFunc(lines, func(l string) bool { return strings.EqualFold(l, leg.Transport.Number) }) {
+			return true
+		}
+	}
+	return false
+}
+
+// AvoidLines drops journeys riding any of lines. The planner can't be told
+// to leave out single lines, so this filters its answers afterwards.
+func AvoidLines(journeys []JourneyTrip, lines []string) []JourneyTrip {
+	if len(lines) == 0 {
+		return journeys
+	}
+	kept := []JourneyTrip{}
+	for _, j := range journeys {
+		if !RidesLine(j, lines) {
+			kept = append(kept, j)
+		}
+	}
+	return kept
+}
+
+// PreferLines stably reorders journeys so those riding one of lines come
+// first.
+func PreferLines(journeys []JourneyTrip, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	slices.SortStableFunc(journeys, func(a, b JourneyTrip) int {
+		pa, pb := RidesLine(a, lines), RidesLine(b, lines)
+		switch {
+		case pa && !pb:
+			return -1
+		case pb && !pa:
+			return 1
+		}
+		return 0
+	})
+}
@@ -0,0 +1,45 @@
+package sl
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestAvoidAndPreferLines(t *testing.T) {
+	trip := func(lines ...string) JourneyTrip {
+		j := JourneyTrip{Legs: []JourneyLeg{{Duration: 120}}} // a walk first
+		for _, l := range lines {
+			j.Legs = append(j.Legs, JourneyLeg{Transport: &JourneyTransport{Number: l}})
+		}
+		return j
+	}
+	numbers := func(journeys []JourneyTrip) string {
+		var out []string
+		for _, j := range journeys {
+			var legs string
+			for _, leg := range j.Legs {
+				if leg.Transport != nil {
+					legs += leg.Transport.Number + "+"
+				}
+			}
+			out = append(out, legs)
+		}
+		return fmt.Sprint(out)
+	}
+	journeys := []JourneyTrip{trip("55", "17"), trip("4"), trip("19"), trip("17")}
+
+	if got, want := numbers(AvoidLines(journeys, []string{"55", "4"})), "[19+ 17+]"; got != want {
+		t.Errorf("AvoidLines = %s, want %s", got, want)
+	}
+	if got := AvoidLines(journeys, nil); len(got) != len(journeys) {
+		t.Errorf("AvoidLines with no lines dropped journeys: %s", numbers(got))
+	}
+
+	PreferLines(journeys, []string{"17", "18", "19"})
+	if got, want := numbers(journeys), "[55+17+ 19+ 17+ 4+]"; got != want {
+		t.Errorf("PreferLines = %s, want %s", got, want)
+	}
+	if !RidesLine(trip("4B"), []string{"4b"}) {
+		t.Error("RidesLine should match designations case-insensitively")
+	}
+}
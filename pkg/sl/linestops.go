@@ -0,0 +1,110 @@
+package sl
+
+import (
+	"sort"
+	"strings"
+)
+
+// LineRoute is the stop sequence of a line in one direction, as run by
+// most of its scheduled trips. Variants counts the other sequences seen,
+// such as short turns and branches.
+type LineRoute struct {
+	Line      string     `json:"line"`
+	Mode      string     `json:"transport_mode"`
+	Direction int        `json:"direction_code"`
+	Headsign  string     `json:"headsign,omitempty"`
+	Trips     int        `json:"trips"`
+	Variants  int        `json:"variants"`
+	Stops     []LineStop `json:"stops"`
+}
+
+// LineStop is a stop on a LineRoute, with the scheduled minutes from the
+// first stop.
+type LineStop struct {
+	GraphStop
+	Minutes int `json:"minutes"`
+}
+
+// LineRoutes returns the route of line in each direction, or only in
+// direction when it is non-zero. A line designation can be shared by
+// several modes (bus 17 and metro 17); mode, when given, picks one.
+// Routes are sorted by mode, then direction.
+func LineRoutes(tt *Timetable, line, mode string, direction int) []LineRoute {
+	type key struct {
+		mode string
+		dir  int
+	}
+	type pattern struct {
+		trips int
+		first int // index of a trip running it
+	}
+	patterns := map[key]map[string]*pattern{}
+	for i, trip := range tt.Trips {
+		if !strings.EqualFold(trip.Line, line) || len(trip.Calls) < 2 ||
+			mode != "" && !strings.EqualFold(trip.Mode, mode) ||
+			direction != 0 && trip.Direction != direction {
+			continue
+		}
+		k := key{trip.Mode, trip.Direction}
+		if patterns[k] == nil {
+			patterns[k] = map[string]*pattern{}
+		}
+		var sig strings.Builder
+		for _, c := range trip.Calls {
+			sig.WriteString(tt.Stops[c.Stop].ID)
+			sig.WriteByte('|')
+		}
+		p := patterns[k][sig.String()]
+		if p == nil {
+			p = &pattern{first: i}
+			patterns[k][sig.String()] = p
+		}
+		p.trips++
+	}
+
+	// Ties go to the longer sequence, then to the earlier trip, so the
+	// result doesn't depend on map order.
+	better := func(p, q *pattern) bool {
+		if p.trips != q.trips {
+			return p.trips > q.trips
+		}
+		if lp, lq := len(tt.Trips[p.first].Calls), len(tt.Trips[q.first].Calls); lp != lq {
+			return lp > lq
+		}
+		return p.first < q.first
+	}
+
+	routes := []LineRoute{}
+	for k, ps := range patterns {
+		var best *pattern
+		for _, p := range ps {
+			if best == nil || better(p, best) {
+				best = p
+			}
+		}
+		trip := tt.Trips[best.first]
+		r := LineRoute{
+			Line:      trip.Line,
+			Mode:      k.mode,
+			Direction: k.dir,
+			Headsign:  trip.Headsign,
+			Trips:     best.trips,
+			Variants:  len(ps) - 1,
+		}
+		start := trip.Calls[0].Dep
+		for _, c := range trip.Calls {
+			r.Stops = append(r.Stops, LineStop{GraphStop: tt.Stops[c.Stop], Minutes: int(c.Arr-start) / 60})
+		}
+		if r.Headsign == "" {
+			r.Headsign = r.Stops[len(r.Stops)-1].Name
+		}
+		routes = append(routes, r)
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Mode != routes[j].Mode {
+			return routes[i].Mode < routes[j].Mode
+		}
+		return routes[i].Direction < routes[j].Direction
+	})
+	return routes
+}
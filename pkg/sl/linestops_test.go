@@ -0,0 +1,46 @@
+package sl
+
+import "testing"
+
+func TestLineRoutes(t *testing.T) {
+	body := gtfsZip(t, map[string]string{
+		"stops.txt": "stop_id,stop_name,stop_lat,stop_lon,parent_station\n" +
+			"A,Slussen,59.3195,18.0722,\nB,Medborgarplatsen,59.3143,18.0735,\nC,Skanstull,59.3079,18.0763,\n",
+		"routes.txt": "route_id,route_short_name,route_type\nr17,17,401\nb17,17,700\n",
+		"trips.txt": "route_id,service_id,trip_id,direction_id,trip_headsign\n" +
+			"r17,s,t1,0,Skanstull\nr17,s,t2,0,Skanstull\nr17,s,t3,0,Medborgarplatsen\nr17,s,t4,1,\nb17,s,t5,0,Skanstull\n",
+		"stop_times.txt": "trip_id,arrival_time,departure_time,stop_id,stop_sequence\n" +
+			"t1,08:00:00,08:00:00,A,1\nt1,08:02:00,08:02:00,B,2\nt1,08:04:30,08:04:30,C,3\n" +
+			"t2,08:10:00,08:10:00,A,1\nt2,08:12:00,08:12:00,B,2\nt2,08:14:00,08:14:00,C,3\n" +
+			// A short turn.
+			"t3,08:20:00,08:20:00,A,1\nt3,08:22:00,08:22:00,B,2\n" +
+			"t4,08:00:00,08:00:00,C,1\nt4,08:03:00,08:03:00,A,2\n" +
+			"t5,08:00:00,08:00:00,A,1\nt5,08:09:00,08:09:00,C,2\n",
+	})
+	tt, err := parseTimetable(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	routes := LineRoutes(tt, "17", "metro", 0)
+	if len(routes) != 2 {
+		t.Fatalf("got %d routes, want one per direction: %+v", len(routes), routes)
+	}
+	r := routes[0]
+	if r.Direction != 1 || r.Headsign != "Skanstull" || r.Trips != 2 || r.Variants != 1 || len(r.Stops) != 3 {
+		t.Errorf("direction 1 = %+v, want 3 stops to Skanstull run by 2 trips, 1 variant", r)
+	}
+	if r.Stops[1].Name != "Medborgarplatsen" || r.Stops[1].Minutes != 2 || r.Stops[2].Minutes != 4 {
+		t.Errorf("stops = %+v", r.Stops)
+	}
+	if back := routes[1]; back.Direction != 2 || back.Headsign != "Slussen" || back.Stops[0].Name != "Skanstull" {
+		t.Errorf("direction 2 = %+v, want Skanstull to Slussen headed Slussen", back)
+	}
+
+	if routes := LineRoutes(tt, "17", "", 1); len(routes) != 2 || routes[0].Mode != "BUS" || routes[1].Mode != "METRO" {
+		t.Errorf("without a mode, want bus 17 and metro 17 in direction 1, got %+v", routes)
+	}
+	if routes := LineRoutes(tt, "99", "", 0); len(routes) != 0 {
+		t.Errorf("unknown line: got %+v", routes)
+	}
+}
@@ -0,0 +1,33 @@
+package sl
+
+// entranceKeys are planner stop properties naming the station entrance or
+// exit to use, where the planner exposes them.
+var entranceKeys = []string{"entranceName", "entrance", "ENTRANCE_NAME", "exitName", "exit"}
+
+// MarkPlatforms sets Platform and Entrance on the stops of every leg from
+// what the planner reports, so a tight change shows which track to head
+// for and which way to leave the station.
+func MarkPlatforms(journeys []JourneyTrip) {
+	for i := range journeys {
+		for k := range journeys[i].Legs {
+			leg := &journeys[i].Legs[k]
+			for _, s := range []*JourneyStop{leg.Origin, leg.Destination} {
+				if s != nil {
+					s.Platform = stopPlatform(s)
+					s.Entrance = stopEntrance(s)
+				}
+			}
+		}
+	}
+}
+
+// stopEntrance returns the entrance or exit the planner names for a stop,
+// if any.
+func stopEntrance(s *JourneyStop) string {
+	for _, k := range entranceKeys {
+		if v, ok := s.Properties[k].(string); ok && v != "" {
+			return v
+		}
+	}
+	return ""
+}
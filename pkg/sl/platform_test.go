@@ -0,0 +1,25 @@
+package sl
+
+import "testing"
+
+func TestMarkPlatforms(t *testing.T) {
+	journeys := []JourneyTrip{{Legs: []JourneyLeg{{
+		Origin:      &JourneyStop{Name: "Slussen", Properties: map[string]any{"platformName": "2", "entranceName": "Götgatan"}},
+		Destination: &JourneyStop{Name: "T-Centralen", Properties: map[string]any{"platform": "1", "exit": "Sergels torg"}},
+	}, {
+		Origin:      &JourneyStop{Name: "T-Centralen"},
+		Destination: nil,
+	}}}}
+
+	MarkPlatforms(journeys)
+	leg := journeys[0].Legs[0]
+	if leg.Origin.Platform != "2" || leg.Origin.Entrance != "Götgatan" {
+		t.Errorf("origin: platform %q, entrance %q", leg.Origin.Platform, leg.Origin.Entrance)
+	}
+	if leg.Destination.Platform != "1" || leg.Destination.Entrance != "Sergels torg" {
+		t.Errorf("destination: platform %q, entrance %q", leg.Destination.Platform, leg.Destination.Entrance)
+	}
+	if s := journeys[0].Legs[1].Origin; s.Platform != "" || s.Entrance != "" {
+		t.Errorf("a stop without properties got platform %q, entrance %q", s.Platform, s.Entrance)
+	}
+}
@@ -0,0 +1,181 @@
+package sl
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// reachOriginWalkKm is how far from the starting point a first stop may
+	// be walked to.
+	reachOriginWalkKm = 0.8
+	// reachTransferWalkKm is how far apart two stations may be for a
+	// change on foot between them.
+	reachTransferWalkKm = 0.4
+	// reachChangeSecs is the time allowed to change vehicles within a
+	// station.
+	reachChangeSecs = 120
+)
+
+// ReachableStop is a stop that can be reached within the time budget.
+type ReachableStop struct {
+	GraphStop
+	Minutes int `json:"minutes"`
+}
+
+// connection is one hop of a trip between consecutive stops, with times
+// in seconds after midnight of the travel day.
+type connection struct {
+	from, to int32
+	dep, arr int32
+	trip     int32
+}
+
+// Reach estimates which stops can be reached from (lat, lon) within budget
+// when leaving at depart: walking to any stop within reachOriginWalkKm,
+// riding scheduled trips and changing, on foot between nearby stations if
+// needed. It runs the connection scan algorithm over the day's timetable,
+// so delays and disruptions are not taken into account. Stops are sorted
+// by travel time.
+func Reach(tt *Timetable, lat, lon float64, depart time.Time, budget time.Duration) []ReachableStop {
+	depart = StockholmTime(depart)
+	day := time.Date(depart.Year(), depart.Month(), depart.Day(), 0, 0, 0, 0, depart.Location())
+	t0 := int32(depart.Sub(day).Seconds())
+	limit := t0 + int32(budget.Seconds())
+
+	// Trips from yesterday's service day that run past midnight count too.
+	var conns []connection
+	for d, offset := range map[time.Time]int32{day: 0, day.AddDate(0, 0, -1): -86400} {
+		for i, trip := range tt.Trips {
+			if !tt.RunsOn(trip.Service, d) {
+				continue
+			}
+			for j := 1; j < len(trip.Calls); j++ {
+				a, b := trip.Calls[j-1], trip.Calls[j]
+				dep, arr := a.Dep+offset, b.Arr+offset
+				if dep < t0 || dep > limit || a.Stop == b.Stop {
+					continue
+				}
+				conns = append(conns, connection{a.Stop, b.Stop, dep, arr, int32(i)})
+			}
+		}
+	}
+	sort.Slice(conns, func(i, j int) bool { return conns[i].dep < conns[j].dep })
+
+	const never = math.MaxInt32
+	arrival := make([]int32, len(tt.Stops))
+	ready := make([]int32, len(tt.Stops)) // when a vehicle can be boarded
+	for i := range arrival {
+		arrival[i], ready[i] = never, never
+	}
+	for i, s := range tt.Stops {
+		if km := DistanceKm(lat, lon, s.Lat, s.Lon); km <= reachOriginWalkKm {
+			arrival[i] = t0 + int32(WalkingTime(km).Seconds())
+			ready[i] = arrival[i]
+		}
+	}
+
+	near := nearbyStops(tt.Stops, reachTransferWalkKm)
+	onboard := map[int32]bool{}
+	for _, c := range conns {
+		if c.arr > limit {
+			continue
+		}
+		if !onboard[c.trip] && ready[c.from] > c.dep {
+			continue
+		}
+		onboard[c.trip] = true
+		if c.arr >= arrival[c.to] {
+			continue
+		}
+		arrival[c.to] = c.arr
+		ready[c.to] = min(ready[c.to], c.arr+reachChangeSecs)
+		for _, n := range near[c.to] {
+			if t := c.arr + n.walkSecs; t < arrival[n.stop] {
+				arrival[n.stop] = t
+				ready[n.stop] = min(ready[n.stop], t)
+			}
+		}
+	}
+
+	result := []ReachableStop{}
+	for i, t := range arrival {
+		if t <= limit {
+			result = append(result, ReachableStop{GraphStop: tt.Stops[i], Minutes: int(math.Ceil(float64(t-t0) / 60))})
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Minutes != result[j].Minutes {
+			return result[i].Minutes < result[j].Minutes
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// footpath is a walk to a nearby stop.
+type footpath struct {
+	stop     int32
+	walkSecs int32
+}
+
+// nearbyStops lists, for each stop, the other stops within radiusKm. Stops
+// are bucketed on a grid about radiusKm wide so only neighbouring cells
+// are compared.
+func nearbyStops(stops []GraphStop, radiusKm float64) [][]footpath {
+	cell := radiusKm / 111 // degrees of latitude
+	type key struct{ x, y int }
+	grid := map[key][]int32{}
+	cellOf := func(s GraphStop) key {
+		return key{int(math.Floor(s.Lon * math.Cos(s.Lat*math.Pi/180) / cell)), int(math.Floor(s.Lat / cell))}
+	}
+	for i, s := range stops {
+		k := cellOf(s)
+		grid[k] = append(grid[k], int32(i))
+	}
+
+	near := make([][]footpath, len(stops))
+	for i, s := range stops {
+		k := cellOf(s)
+		for dx := -1; dx <= 1; dx++ {
+			for dy := -1; dy <= 1; dy++ {
+				for _, j := range grid[key{k.x + dx, k.y + dy}] {
+					if int(j) == i {
+						continue
+					}
+					o := stops[j]
+					if km := DistanceKm(s.Lat, s.Lon, o.Lat, o.Lon); km <= radiusKm {
+						near[i] = append(near[i], footpath{j, int32(WalkingTime(km).Seconds())})
+					}
+				}
+			}
+		}
+	}
+	return near
+}
+
+// PickDestination picks a random station among stops reached in at least
+// minMinutes, skipping those whose names are in seen (lower case). Stops
+// sharing a name are one station, reached when its fastest stop is.
+// intN picks an index below n, as rand.IntN does. ok is false when no
+// station is left to pick.
+func PickDestination(stops []ReachableStop, minMinutes int, seen map[string]bool, intN func(n int) int) (stop ReachableStop, ok bool) {
+	var candidates []ReachableStop
+	picked := map[string]bool{}
+	for _, s := range stops {
+		name := strings.ToLower(strings.TrimSpace(s.Name))
+		if picked[name] {
+			continue
+		}
+		picked[name] = true
+		if s.Minutes >= minMinutes && !seen[name] {
+			candidates = append(candidates, s)
+		}
+	}
+	if len(candidates) == 0 {
+		return ReachableStop{}, false
+	}
+	return candidates[intN(len(candidates))], true
+}
@@ -0,0 +1,73 @@
+package sl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReach(t *testing.T) {
+	// A: start. Line 17 runs A→B→C, line 4 runs from D (next to C) to E.
+	// F is only served after the budget runs out.
+	tt := &Timetable{
+		Stops: []GraphStop{
+			{ID: "A", Name: "Medborgarplatsen", Lat: 59.3143, Lon: 18.0735},
+			{ID: "B", Name: "Slussen", Lat: 59.3195, Lon: 18.0722},
+			{ID: "C", Name: "Gamla stan", Lat: 59.3231, Lon: 18.0675},
+			{ID: "D", Name: "Riddarhustorget", Lat: 59.3246, Lon: 18.0655},
+			{ID: "E", Name: "Kungsholmen", Lat: 59.3300, Lon: 18.0300},
+			{ID: "F", Name: "Far away", Lat: 59.4000, Lon: 18.2000},
+		},
+		Services: map[string][]int32{"wk": {20240301}, "sun": {20240303}},
+	}
+	hm := func(h, m int) int32 { return int32(h*3600 + m*60) }
+	trip := func(line, service string, calls ...TripCall) {
+		tt.Trips = append(tt.Trips, TimetableTrip{Line: line, Mode: "METRO", Service: service, Calls: calls})
+	}
+	trip("17", "wk", TripCall{0, hm(8, 5), hm(8, 5)}, TripCall{1, hm(8, 7), hm(8, 7)}, TripCall{2, hm(8, 9), hm(8, 9)})
+	trip("17", "sun", TripCall{0, hm(8, 2), hm(8, 2)}, TripCall{5, hm(8, 4), hm(8, 4)}) // not running today
+	trip("4", "wk", TripCall{3, hm(8, 14), hm(8, 14)}, TripCall{4, hm(8, 20), hm(8, 20)})
+	trip("4", "wk", TripCall{3, hm(8, 40), hm(8, 40)}, TripCall{5, hm(8, 50), hm(8, 50)})
+
+	loc, _ := time.LoadLocation("Europe/Stockholm")
+	depart := time.Date(2024, 3, 1, 8, 0, 0, 0, loc)
+	got := map[string]int{}
+	for _, s := range Reach(tt, 59.3143, 18.0735, depart, 30*time.Minute) {
+		got[s.ID] = s.Minutes
+	}
+
+	want := map[string]int{"A": 0, "B": 7, "C": 9, "D": 12, "E": 20}
+	if len(got) != len(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	for id, mins := range want {
+		if got[id] != mins {
+			t.Errorf("%s: got %d min, want %d", id, got[id], mins)
+		}
+	}
+}
+
+func TestPickDestination(t *testing.T) {
+	stops := []ReachableStop{
+		{GraphStop: GraphStop{ID: "A", Name: "Medborgarplatsen"}, Minutes: 0},
+		{GraphStop: GraphStop{ID: "B", Name: "Slussen"}, Minutes: 7},
+		{GraphStop: GraphStop{ID: "C", Name: "Gamla stan"}, Minutes: 12},
+		{GraphStop: GraphStop{ID: "A2", Name: "Medborgarplatsen"}, Minutes: 14}, // same station, other platform
+		{GraphStop: GraphStop{ID: "E", Name: "Kungsholmen"}, Minutes: 20},
+	}
+	var offered int
+	last := func(n int) int { offered = n; return n - 1 }
+
+	got, ok := PickDestination(stops, 10, map[string]bool{}, last)
+	if !ok || got.ID != "E" || offered != 2 {
+		t.Errorf("got %s of %d candidates, want Kungsholmen of 2 (Gamla stan, Kungsholmen)", got.ID, offered)
+	}
+
+	got, ok = PickDestination(stops, 10, map[string]bool{"kungsholmen": true}, last)
+	if !ok || got.ID != "C" {
+		t.Errorf("got %s, want Gamla stan once Kungsholmen is seen", got.ID)
+	}
+
+	if _, ok := PickDestination(stops, 10, map[string]bool{"kungsholmen": true, "gamla stan": true}, last); ok {
+		t.Error("expected nothing left to pick")
+	}
+}
@@ -0,0 +1,220 @@
+package sl
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/glundgren93/sl-cli/pkg/sl/gtfsrt"
+)
+
+// GTFSRealtimeBaseURL is Trafiklab's GTFS Regional realtime feed for SL.
+// Requests need a Trafiklab realtime API key.
+const GTFSRealtimeBaseURL = "https://opendata.samtrafiken.se/gtfs-rt/sl"
+
+// GetVehiclePositions fetches the current GTFS-RT vehicle positions feed.
+func (c *Client) GetVehiclePositions(ctx context.Context, key string) (*gtfsrt.Feed, error) {
+	u := c.base.GTFSRealtime + "/VehiclePositions.pb?key=" + url.QueryEscape(key)
+	body, err := c.get(ctx, u)
+	if err != nil {
+		// Transport errors quote the URL; keep the key out of them.
+		return nil, redactKey(err, key)
+	}
+	feed, err := gtfsrt.Decode(body)
+	if err != nil {
+		return nil, fmt.Errorf("parsing vehicle positions: %w", err)
+	}
+	return feed, nil
+}
+
+// Approach is a vehicle heading toward a nearby stop.
+type Approach struct {
+	Vehicle   gtfsrt.VehiclePosition `json:"vehicle"`
+	Stop      string                 `json:"stop"`
+	SiteID    int                    `json:"site_id"`
+	DistanceM int                    `json:"distance_m"`
+	ETAMin    int                    `json:"eta_min"`
+}
+
+const (
+	// approachMaxBearingDiff is how far off a vehicle's heading a stop may be
+	// and still count as ahead of it.
+	approachMaxBearingDiff = 45.0
+	// approachMaxKm bounds how far away a vehicle may be from the stop.
+	approachMaxKm = 3.0
+	// assumedSpeedMS is used for the ETA when a vehicle reports no speed
+	// (~25 km/h, a typical urban bus average including stops).
+	assumedSpeedMS = 7.0
+)
+
+// ApproachingVehicles matches vehicles to the nearby stop directly ahead of
+// them, closest first. Vehicles without a bearing, or with no stop ahead
+// within approachMaxKm, are left out. Results are sorted by ETA.
+func ApproachingVehicles(vehicles []gtfsrt.VehiclePosition, stops []SiteWithDistance) []Approach {
+	results := []Approach{}
+	for _, v := range vehicles {
+		if !v.HasBearing {
+			continue
+		}
+		best := -1
+		bestKm := math.MaxFloat64
+		for i, s := range stops {
+			km := DistanceKm(v.Lat, v.Lon, s.Site.Lat, s.Site.Lon)
+			if km > approachMaxKm || km >= bestKm {
+				continue
+			}
+			if bearingDiff(v.Bearing, BearingDeg(v.Lat, v.Lon, s.Site.Lat, s.Site.Lon)) > approachMaxBearingDiff {
+				continue
+			}
+			best, bestKm = i, km
+		}
+		if best < 0 {
+			continue
+		}
+		speed := v.SpeedMS
+		if speed <= 0.5 {
+			speed = assumedSpeedMS
+		}
+		results = append(results, Approach{
+			Vehicle:   v,
+			Stop:      stops[best].Site.Name,
+			SiteID:    stops[best].Site.ID,
+			DistanceM: int(bestKm * 1000),
+			ETAMin:    int(math.Ceil(time.Duration(bestKm * 1000 / speed * float64(time.Second)).Minutes())),
+		})
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].ETAMin < results[j].ETAMin
+	})
+	return results
+}
+
+// GetTripUpdates fetches the current GTFS-RT trip updates feed, which
+// carries predicted delays.
+func (c *Client) GetTripUpdates(ctx context.Context, key string) (*gtfsrt.Feed, error) {
+	u := c.base.GTFSRealtime + "/TripUpdates.pb?key=" + url.QueryEscape(key)
+	body, err := c.get(ctx, u)
+	if err != nil {
+		return nil, redactKey(err, key)
+	}
+	feed, err := gtfsrt.Decode(body)
+	if err != nil {
+		return nil, fmt.Errorf("parsing trip updates: %w", err)
+	}
+	return feed, nil
+}
+
+// LineVehicle is a vehicle running a trip of a line, with the stop it is
+// at or heading for and its delay, when the feeds report them.
+type LineVehicle struct {
+	Vehicle     gtfsrt.VehiclePosition `json:"vehicle"`
+	Line        string                 `json:"line"`
+	Mode        string                 `json:"transport_mode"`
+	Direction   int                    `json:"direction_code"`
+	Destination string                 `json:"destination"`
+	NextStop    string                 `json:"next_stop,omitempty"`
+	AtStop      bool                   `json:"at_stop,omitempty"` // stopped at NextStop
+	DelayMin    *int                   `json:"delay_min,omitempty"`
+}
+
+// LineVehicles picks the vehicles running trips of line out of the
+// positions feed, matching their trips against the timetable; mode, when
+// given, picks one of several modes sharing the designation. Delays come
+// from the trip updates, at the next stop if it has a prediction. Vehicles
+// are sorted by direction, then destination.
+func LineVehicles(tt *Timetable, vehicles []gtfsrt.VehiclePosition, updates []gtfsrt.TripUpdate, line, mode string) []LineVehicle {
+	trips := map[string]*TimetableTrip{}
+	for i := range tt.Trips {
+		t := &tt.Trips[i]
+		if strings.EqualFold(t.Line, line) && (mode == "" || strings.EqualFold(t.Mode, mode)) {
+			trips[t.ID] = t
+		}
+	}
+	updateOf := map[string]*gtfsrt.TripUpdate{}
+	for i := range updates {
+		if _, ok := trips[updates[i].TripID]; ok {
+			updateOf[updates[i].TripID] = &updates[i]
+		}
+	}
+	stationOf := map[string]int32{}
+	for i, s := range tt.Stops {
+		stationOf[s.ID] = int32(i)
+	}
+	maps.Copy(stationOf, tt.Platforms)
+	stopName := func(id string) string {
+		if i, ok := stationOf[id]; ok {
+			return tt.Stops[i].Name
+		}
+		return ""
+	}
+
+	results := []LineVehicle{}
+	for _, v := range vehicles {
+		trip, ok := trips[v.TripID]
+		if !ok {
+			continue
+		}
+		lv := LineVehicle{
+			Vehicle:     v,
+			Line:        trip.Line,
+			Mode:        trip.Mode,
+			Direction:   trip.Direction,
+			Destination: trip.Headsign,
+			NextStop:    stopName(v.StopID),
+			AtStop:      v.StopID != "" && v.Status == gtfsrt.StoppedAt,
+		}
+		if lv.Destination == "" && len(trip.Calls) > 0 {
+			lv.Destination = tt.Stops[trip.Calls[len(trip.Calls)-1].Stop].Name
+		}
+		if u := updateOf[v.TripID]; u != nil {
+			delay, ok := u.Delay, u.HasDelay
+			for _, su := range u.StopUpdates {
+				if su.Skipped || !su.HasDelay {
+					continue
+				}
+				if lv.NextStop == "" && su.StopID != "" {
+					lv.NextStop = stopName(su.StopID)
+				}
+				if su.StopID == v.StopID || v.StopID == "" {
+					delay, ok = su.Delay, true
+					break
+				}
+			}
+			if ok {
+				mins := int(math.Round(float64(delay) / 60))
+				lv.DelayMin = &mins
+			}
+		}
+		results = append(results, lv)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Direction != results[j].Direction {
+			return results[i].Direction < results[j].Direction
+		}
+		return results[i].Destination < results[j].Destination
+	})
+	return results
+}
+
+// BearingDeg returns the initial compass bearing (0–360°) from one point to another.
+func BearingDeg(lat1, lon1, lat2, lon2 float64) float64 {
+	φ1, φ2 := lat1*math.Pi/180, lat2*math.Pi/180
+	Δλ := (lon2 - lon1) * math.Pi / 180
+	y := math.Sin(Δλ) * math.Cos(φ2)
+	x := math.Cos(φ1)*math.Sin(φ2) - math.Sin(φ1)*math.Cos(φ2)*math.Cos(Δλ)
+	return math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+}
+
+// bearingDiff returns the absolute angle between two bearings (0–180°).
+func bearingDiff(a, b float64) float64 {
+	d := math.Mod(math.Abs(a-b), 360)
+	if d > 180 {
+		d = 360 - d
+	}
+	return d
+}
@@ -0,0 +1,74 @@
+package sl
+
+import (
+	"testing"
+
+	"github.com/glundgren93/sl-cli/pkg/sl/gtfsrt"
+)
+
+func TestApproachingVehicles(t *testing.T) {
+	stops := []SiteWithDistance{
+		{Site: Site{ID: 9191, Name: "Medborgarplatsen", Lat: 59.3143, Lon: 18.0735}},
+	}
+	vehicles := []gtfsrt.VehiclePosition{
+		// ~1 km south, heading north at 10 m/s: approaching, ETA ~2 min.
+		{VehicleID: "north", Lat: 59.3053, Lon: 18.0735, Bearing: 0, HasBearing: true, SpeedMS: 10},
+		// Same place, heading south: moving away.
+		{VehicleID: "south", Lat: 59.3053, Lon: 18.0735, Bearing: 180, HasBearing: true, SpeedMS: 10},
+		// No bearing reported.
+		{VehicleID: "unknown", Lat: 59.3053, Lon: 18.0735},
+		// Heading toward the stop but too far away.
+		{VehicleID: "far", Lat: 59.20, Lon: 18.0735, Bearing: 0, HasBearing: true},
+	}
+
+	got := ApproachingVehicles(vehicles, stops)
+	if len(got) != 1 || got[0].Vehicle.VehicleID != "north" {
+		t.Fatalf("got %+v, want only the northbound vehicle", got)
+	}
+	if got[0].ETAMin != 2 || got[0].DistanceM < 950 || got[0].DistanceM > 1050 {
+		t.Errorf("ETA %d min, distance %dm; want 2 min, ~1000m", got[0].ETAMin, got[0].DistanceM)
+	}
+}
+
+func TestLineVehicles(t *testing.T) {
+	tt := &Timetable{
+		Stops: []GraphStop{{ID: "A", Name: "Slussen"}, {ID: "B", Name: "Tanto"}, {ID: "C", Name: "Hornstull"}},
+		Trips: []TimetableTrip{
+			{ID: "t1", Line: "55", Mode: "BUS", Direction: 1, Headsign: "Tanto", Calls: []TripCall{{Stop: 0}, {Stop: 1}}},
+			{ID: "t2", Line: "55", Mode: "BUS", Direction: 2, Calls: []TripCall{{Stop: 1}, {Stop: 0}}},
+			{ID: "t3", Line: "4", Mode: "BUS", Direction: 1, Calls: []TripCall{{Stop: 2}, {Stop: 0}}},
+		},
+		Platforms: map[string]int32{"B1": 1},
+	}
+	vehicles := []gtfsrt.VehiclePosition{
+		{VehicleID: "v2", TripID: "t2", StopID: "A", Status: gtfsrt.StoppedAt},
+		{VehicleID: "v1", TripID: "t1", StopID: "B1", Status: gtfsrt.InTransitTo},
+		{VehicleID: "v3", TripID: "t3"},
+		{VehicleID: "v4", TripID: "unknown"},
+	}
+	updates := []gtfsrt.TripUpdate{
+		{TripID: "t1", StopUpdates: []gtfsrt.StopTimeUpdate{{StopID: "B1", Delay: 170, HasDelay: true}}},
+		{TripID: "t2", Delay: -60, HasDelay: true},
+	}
+
+	got := LineVehicles(tt, vehicles, updates, "55", "")
+	if len(got) != 2 {
+		t.Fatalf("got %+v, want the two vehicles on line 55", got)
+	}
+	v1, v2 := got[0], got[1]
+	if v1.Vehicle.VehicleID != "v1" || v1.NextStop != "Tanto" || v1.AtStop || v1.DelayMin == nil || *v1.DelayMin != 3 {
+		t.Errorf("v1 = %+v, want heading for Tanto, 3 min late", v1)
+	}
+	if v2.Destination != "Slussen" || v2.NextStop != "Slussen" || !v2.AtStop || v2.DelayMin == nil || *v2.DelayMin != -1 {
+		t.Errorf("v2 = %+v, want at Slussen, its last stop, 1 min early", v2)
+	}
+}
+
+func TestBearingDeg(t *testing.T) {
+	if b := BearingDeg(59.30, 18.07, 59.31, 18.07); b > 1 && b < 359 {
+		t.Errorf("due north bearing = %.1f", b)
+	}
+	if b := BearingDeg(59.30, 18.07, 59.30, 18.08); b < 89 || b > 91 {
+		t.Errorf("due east bearing = %.1f", b)
+	}
+}
@@ -0,0 +1,127 @@
+package sl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultRetries is how many times a failed request is retried unless
+// changed with SetRetries.
+const DefaultRetries = 2
+
+// DefaultRetryDelay is the backoff before the first retry unless changed
+// with Options.RetryDelay; it doubles with each further one.
+const DefaultRetryDelay = 500 * time.Millisecond
+
+// defaultRetryDelay is what NewClient starts from; swapped out in tests.
+var defaultRetryDelay = DefaultRetryDelay
+
+// maxRetryAfter bounds how long a Retry-After header may make us wait.
+// Longer waits are not retried: the error is returned at once instead.
+const maxRetryAfter = 30 * time.Second
+
+// RequestError is a request that failed for good, after any retries: the
+// API answered with an error status that isn't an outage (those are
+// APIDownError), or couldn't be reached at all, in which case StatusCode
+// is 0 and Err is the transport error.
+type RequestError struct {
+	StatusCode int
+	Body       string
+	Err        error
+	// Attempts is how many times the request was sent, retries included.
+	Attempts int
+}
+
+func (e *RequestError) Error() string {
+	var msg string
+	if e.StatusCode == 0 {
+		msg = fmt.Sprintf("request failed: %v", e.Err)
+	} else {
+		msg = fmt.Sprintf("API returned %d: %s", e.StatusCode, e.Body)
+	}
+	if e.Attempts > 1 {
+		msg += fmt.Sprintf(" (after %d attempts)", e.Attempts)
+	}
+	return msg
+}
+
+func (e *RequestError) Unwrap() error { return e.Err }
+
+// SetRetries changes how many times a request failing with 429, a 5xx
+// status or a network error is retried. Zero disables retries.
+func (c *Client) SetRetries(n int) {
+	c.retries = max(n, 0)
+}
+
+// retryDelay says whether a failed attempt should be retried and after
+// how long: the server's Retry-After when it sent one, otherwise
+// exponential backoff with jitter.
+func (c *Client) retryDelay(ctx context.Context, err error, attempt int, retryAfter time.Duration) (time.Duration, bool) {
+	if attempt > c.retries || ctx.Err() != nil || !retryable(err) {
+		return 0, false
+	}
+	if retryAfter > 0 {
+		return retryAfter, retryAfter <= maxRetryAfter
+	}
+	backoff := c.retryBase << (attempt - 1)
+	return backoff/2 + rand.N(backoff/2+1), true
+}
+
+// retryable reports whether err is worth another attempt: the API being
+// overloaded or briefly unavailable, or the network failing.
+func retryable(err error) bool {
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.StatusCode == 0 || reqErr.StatusCode == http.StatusTooManyRequests || reqErr.StatusCode >= 500
+	}
+	// An HTML maintenance page served with 200 won't go away in a second.
+	var down *APIDownError
+	return errors.As(err, &down) && down.StatusCode >= 500
+}
+
+// withAttempts records on err how many attempts were made.
+func withAttempts(err error, attempts int) error {
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		reqErr.Attempts = attempts
+	}
+	var down *APIDownError
+	if errors.As(err, &down) {
+		down.Attempts = attempts
+	}
+	return err
+}
+
+// Attempts returns how many times the request behind err was sent, or 0
+// when err didn't come from one.
+func Attempts(err error) int {
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.Attempts
+	}
+	var down *APIDownError
+	if errors.As(err, &down) {
+		return down.Attempts
+	}
+	return 0
+}
+
+// parseRetryAfter reads a Retry-After header, given either in seconds or
+// as an HTTP date. It returns 0 when there is none.
+func parseRetryAfter(h string, now time.Time) time.Duration {
+	if h == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(h); err == nil {
+		return time.Duration(max(secs, 0)) * time.Second
+	}
+	if t, err := http.ParseTime(h); err == nil {
+		return max(t.Sub(now), 0)
+	}
+	return 0
+}
@@ -0,0 +1,111 @@
+package sl
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	// Tests that make a server fail shouldn't wait out real backoff.
+	defaultRetryDelay = time.Millisecond
+	os.Exit(m.Run())
+}
+
+// failingServer answers the first failures requests with status (and
+// Retry-After, when set), then 200 with an empty JSON object.
+func failingServer(t *testing.T, failures int, status int, retryAfter string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if int(calls.Add(1)) <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			http.Error(w, `{"message":"nope"}`, status)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestGet_RetriesTransientErrors(t *testing.T) {
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusServiceUnavailable} {
+		srv, calls := failingServer(t, 2, status, "")
+		if _, err := NewClient().get(context.Background(), srv.URL); err != nil {
+			t.Errorf("%d: expected success on the third attempt, got %v", status, err)
+		}
+		if got := calls.Load(); got != 3 {
+			t.Errorf("%d: got %d attempts, want 3", status, got)
+		}
+	}
+}
+
+func TestGet_GivesUpWithAttempts(t *testing.T) {
+	srv, calls := failingServer(t, 10, http.StatusInternalServerError, "")
+	_, err := NewClient().get(context.Background(), srv.URL)
+	var reqErr *RequestError
+	if !errors.As(err, &reqErr) || reqErr.StatusCode != 500 || reqErr.Attempts != 3 {
+		t.Fatalf("got %v, want a RequestError for 500 after 3 attempts", err)
+	}
+	if !strings.Contains(err.Error(), "after 3 attempts") || calls.Load() != 3 {
+		t.Errorf("error %q after %d calls", err, calls.Load())
+	}
+
+	srv, _ = failingServer(t, 10, http.StatusBadGateway, "")
+	_, err = NewClient().get(context.Background(), srv.URL)
+	var down *APIDownError
+	if !errors.As(err, &down) || down.Attempts != 3 {
+		t.Errorf("got %v, want an APIDownError after 3 attempts", err)
+	}
+}
+
+func TestGet_NoRetry(t *testing.T) {
+	srv, calls := failingServer(t, 1, http.StatusBadRequest, "")
+	_, err := NewClient().get(context.Background(), srv.URL)
+	var reqErr *RequestError
+	if !errors.As(err, &reqErr) || reqErr.StatusCode != 400 || reqErr.Attempts != 1 || calls.Load() != 1 {
+		t.Errorf("bad request: got %v after %d calls, want no retry", err, calls.Load())
+	}
+
+	// Waiting two minutes isn't worth it: fail at once.
+	srv, calls = failingServer(t, 1, http.StatusTooManyRequests, "120")
+	if _, err := NewClient().get(context.Background(), srv.URL); err == nil || calls.Load() != 1 {
+		t.Errorf("long Retry-After: got %v after %d calls, want an error after 1", err, calls.Load())
+	}
+
+	srv, calls = failingServer(t, 1, http.StatusServiceUnavailable, "")
+	c := NewClient()
+	c.SetRetries(0)
+	if _, err := c.get(context.Background(), srv.URL); err == nil || calls.Load() != 1 {
+		t.Errorf("retries disabled: got %v after %d calls", err, calls.Load())
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"", 0},
+		{"3", 3 * time.Second},
+		{"-1", 0},
+		{"Fri, 01 Mar 2024 08:00:10 GMT", 10 * time.Second},
+		{"Fri, 01 Mar 2024 07:59:00 GMT", 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.in, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package sl
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RealtimeHorizon is roughly how far ahead the Transport API lists
+// departures. Later departures have to come from the timetable.
+const RealtimeHorizon = time.Hour
+
+// siteStopKm is how far from a site a timetable stop of the same name may
+// be and still count as part of it; siteNearestKm is the fallback radius
+// for a stop named differently.
+const (
+	siteStopKm    = 0.5
+	siteNearestKm = 0.15
+)
+
+// SiteStops returns the timetable stops that make up a site: those with
+// its name near it, or failing that the nearest stop within siteNearestKm.
+// A site served by several modes is often several stations in the feed.
+func SiteStops(tt *Timetable, site Site) []int32 {
+	var stops []int32
+	nearest, nearestKm := int32(-1), siteNearestKm
+	for i, s := range tt.Stops {
+		km := DistanceKm(site.Lat, site.Lon, s.Lat, s.Lon)
+		if km <= siteStopKm && strings.EqualFold(s.Name, site.Name) {
+			stops = append(stops, int32(i))
+		}
+		if km <= nearestKm {
+			nearest, nearestKm = int32(i), km
+		}
+	}
+	if len(stops) == 0 && nearest >= 0 {
+		stops = append(stops, nearest)
+	}
+	return stops
+}
+
+// ScheduledDepartures lists the timetabled departures from a site in the
+// window after at, soonest first. They are marked ScheduledOnly: delays,
+// cancellations and platforms are unknown that far ahead.
+func ScheduledDepartures(tt *Timetable, site Site, at time.Time, window time.Duration) []ParsedDeparture {
+	now := StockholmTime(time.Now())
+	deps := []ParsedDeparture{}
+	siteCalls(tt, site, at, window, false, func(trip *TimetableTrip, when time.Time) {
+		destination := trip.Headsign
+		if destination == "" {
+			destination = tt.Stops[trip.Calls[len(trip.Calls)-1].Stop].Name
+		}
+		deps = append(deps, ParsedDeparture{
+			Line:          trip.Line,
+			TransportMode: trip.Mode,
+			Destination:   destination,
+			DirectionCode: trip.Direction,
+			Display:       when.Format("15:04"),
+			Scheduled:     when,
+			Expected:      when,
+			MinutesLeft:   minutesUntil(when, now),
+			StopArea:      site.Name,
+			ScheduledOnly: true,
+		})
+	})
+	sort.SliceStable(deps, func(i, j int) bool {
+		if !deps[i].Scheduled.Equal(deps[j].Scheduled) {
+			return deps[i].Scheduled.Before(deps[j].Scheduled)
+		}
+		return deps[i].Line < deps[j].Line
+	})
+	return deps
+}
+
+// Arrival is a vehicle due at a stop. Arrivals come from the timetable,
+// so they carry no delays or tracks.
+type Arrival struct {
+	Line          string    `json:"line"`
+	TransportMode string    `json:"transport_mode"`
+	Origin        string    `json:"origin"`
+	Scheduled     time.Time `json:"scheduled"`
+	MinutesLeft   int       `json:"minutes_left"`
+	StopArea      string    `json:"stop_area"`
+}
+
+// ScheduledArrivals lists the timetabled arrivals at a site in the window
+// after at, soonest first. Trips starting at the site aren't arrivals.
+func ScheduledArrivals(tt *Timetable, site Site, at time.Time, window time.Duration) []Arrival {
+	now := StockholmTime(time.Now())
+	arrs := []Arrival{}
+	siteCalls(tt, site, at, window, true, func(trip *TimetableTrip, when time.Time) {
+		arrs = append(arrs, Arrival{
+			Line:          trip.Line,
+			TransportMode: trip.Mode,
+			Origin:        tt.Stops[trip.Calls[0].Stop].Name,
+			Scheduled:     when,
+			MinutesLeft:   minutesUntil(when, now),
+			StopArea:      site.Name,
+		})
+	})
+	sort.SliceStable(arrs, func(i, j int) bool {
+		if !arrs[i].Scheduled.Equal(arrs[j].Scheduled) {
+			return arrs[i].Scheduled.Before(arrs[j].Scheduled)
+		}
+		return arrs[i].Line < arrs[j].Line
+	})
+	return arrs
+}
+
+// siteCalls calls fn with each trip's call at site in the window after at:
+// its departure, or with arrive its arrival. The last call of a trip is no
+// departure and the first no arrival.
+func siteCalls(tt *Timetable, site Site, at time.Time, window time.Duration, arrive bool, fn func(trip *TimetableTrip, when time.Time)) {
+	at = StockholmTime(at)
+	day := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, at.Location())
+	t0 := int32(at.Sub(day).Seconds())
+	limit := t0 + int32(window.Seconds())
+
+	atSite := map[int32]bool{}
+	for _, s := range SiteStops(tt, site) {
+		atSite[s] = true
+	}
+
+	// Trips from yesterday's service day that run past midnight count too.
+	for d, offset := range map[time.Time]int32{day: 0, day.AddDate(0, 0, -1): -86400} {
+		for i := range tt.Trips {
+			trip := &tt.Trips[i]
+			if len(trip.Calls) < 2 || !tt.RunsOn(trip.Service, d) {
+				continue
+			}
+			calls := trip.Calls[:len(trip.Calls)-1]
+			if arrive {
+				calls = trip.Calls[1:]
+			}
+			for _, c := range calls {
+				t := c.Dep
+				if arrive {
+					t = c.Arr
+				}
+				t += offset
+				if !atSite[c.Stop] || t < t0 || t > limit {
+					continue
+				}
+				fn(trip, day.Add(time.Duration(t)*time.Second))
+				break // a trip calls at a site once
+			}
+		}
+	}
+}
+
+func minutesUntil(t, now time.Time) int {
+	return max(int(math.Ceil(t.Sub(now).Minutes())), 0)
+}
@@ -0,0 +1,95 @@
+package sl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduledDepartures(t *testing.T) {
+	// Medborgarplatsen is two stops in the feed, the metro station and the
+	// bus stop on the square; Slussen is a different site.
+	tt := &Timetable{
+		Stops: []GraphStop{
+			{ID: "A", Name: "Medborgarplatsen", Lat: 59.3143, Lon: 18.0735},
+			{ID: "A2", Name: "Medborgarplatsen", Lat: 59.3150, Lon: 18.0710},
+			{ID: "B", Name: "Slussen", Lat: 59.3195, Lon: 18.0722},
+		},
+		Services: map[string][]int32{"wk": {20240301}, "sun": {20240303}, "thu": {20240229}},
+	}
+	hm := func(h, m int) int32 { return int32(h*3600 + m*60) }
+	trip := func(line, mode, service, headsign string, calls ...TripCall) {
+		tt.Trips = append(tt.Trips, TimetableTrip{Line: line, Mode: mode, Service: service, Direction: 1, Headsign: headsign, Calls: calls})
+	}
+	trip("17", "METRO", "wk", "Åkeshov", TripCall{0, hm(7, 35), hm(7, 35)}, TripCall{2, hm(7, 37), hm(7, 37)})
+	trip("3", "BUS", "wk", "", TripCall{1, hm(7, 50), hm(7, 50)}, TripCall{2, hm(7, 55), hm(7, 55)})
+	trip("17", "METRO", "wk", "Åkeshov", TripCall{2, hm(7, 40), hm(7, 40)}, TripCall{0, hm(7, 42), hm(7, 42)})  // ends here
+	trip("17", "METRO", "wk", "Åkeshov", TripCall{0, hm(9, 0), hm(9, 0)}, TripCall{2, hm(9, 2), hm(9, 2)})      // too late
+	trip("17", "METRO", "sun", "Åkeshov", TripCall{0, hm(7, 45), hm(7, 45)}, TripCall{2, hm(7, 47), hm(7, 47)}) // not running
+	trip("19", "METRO", "thu", "Hässelby strand", TripCall{0, hm(31, 40), hm(31, 40)}, TripCall{2, hm(31, 42), hm(31, 42)})
+
+	loc, _ := time.LoadLocation("Europe/Stockholm")
+	at := time.Date(2024, 3, 1, 7, 30, 0, 0, loc)
+	site := Site{ID: 9191, Name: "Medborgarplatsen", Lat: 59.3143, Lon: 18.0735}
+	got := ScheduledDepartures(tt, site, at, time.Hour)
+
+	want := []struct{ line, dest, display string }{
+		{"17", "Åkeshov", "07:35"},
+		{"19", "Hässelby strand", "07:40"},
+		{"3", "Slussen", "07:50"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d departures, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		d := got[i]
+		if d.Line != w.line || d.Destination != w.dest || d.Display != w.display {
+			t.Errorf("departure %d = %s %s %s, want %s %s %s", i, d.Line, d.Destination, d.Display, w.line, w.dest, w.display)
+		}
+		if !d.ScheduledOnly || d.StopArea != "Medborgarplatsen" {
+			t.Errorf("departure %d: ScheduledOnly %v, StopArea %q", i, d.ScheduledOnly, d.StopArea)
+		}
+	}
+}
+
+func TestSiteStopsFallsBackToNearest(t *testing.T) {
+	tt := &Timetable{Stops: []GraphStop{
+		{ID: "A", Name: "Medborgarplatsen T-bana", Lat: 59.3143, Lon: 18.0735},
+		{ID: "B", Name: "Slussen", Lat: 59.3195, Lon: 18.0722},
+	}}
+	got := SiteStops(tt, Site{Name: "Medborgarplatsen", Lat: 59.3144, Lon: 18.0736})
+	if len(got) != 1 || got[0] != 0 {
+		t.Errorf("got %v, want [0]", got)
+	}
+}
+
+func TestScheduledArrivals(t *testing.T) {
+	tt := &Timetable{
+		Stops: []GraphStop{
+			{ID: "A", Name: "Stockholm City", Lat: 59.3310, Lon: 18.0590},
+			{ID: "B", Name: "Södertälje centrum", Lat: 59.1955, Lon: 17.6253},
+			{ID: "C", Name: "Uppsala C", Lat: 59.8586, Lon: 17.6389},
+		},
+		Services: map[string][]int32{"wk": {20240301}},
+	}
+	hm := func(h, m int) int32 { return int32(h*3600 + m*60) }
+	trip := func(line string, calls ...TripCall) {
+		tt.Trips = append(tt.Trips, TimetableTrip{Line: line, Mode: "TRAIN", Service: "wk", Calls: calls})
+	}
+	trip("40", TripCall{1, hm(7, 0), hm(7, 0)}, TripCall{0, hm(7, 40), hm(7, 42)}, TripCall{2, hm(8, 30), hm(8, 30)})
+	trip("41", TripCall{2, hm(7, 10), hm(7, 10)}, TripCall{0, hm(7, 50), hm(7, 50)})
+	trip("43", TripCall{0, hm(7, 45), hm(7, 45)}, TripCall{1, hm(8, 25), hm(8, 25)}) // starts here
+
+	loc, _ := time.LoadLocation("Europe/Stockholm")
+	site := Site{Name: "Stockholm City", Lat: 59.3310, Lon: 18.0590}
+	got := ScheduledArrivals(tt, site, time.Date(2024, 3, 1, 7, 30, 0, 0, loc), time.Hour)
+
+	if len(got) != 2 {
+		t.Fatalf("got %d arrivals, want 2: %+v", len(got), got)
+	}
+	if got[0].Line != "40" || got[0].Origin != "Södertälje centrum" || got[0].Scheduled.Format("15:04") != "07:40" {
+		t.Errorf("first arrival = %+v, want line 40 from Södertälje centrum at 07:40", got[0])
+	}
+	if got[1].Line != "41" || got[1].Origin != "Uppsala C" {
+		t.Errorf("second arrival = %+v, want line 41 from Uppsala C", got[1])
+	}
+}
@@ -0,0 +1,67 @@
+package sl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity is a normalized deviation severity derived from SL's priority triple.
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityMinor
+	SeverityMajor
+	SeverityCritical
+)
+
+var severityNames = []string{"info", "minor", "major", "critical"}
+
+func (s Severity) String() string {
+	if s < SeverityInfo || s > SeverityCritical {
+		return "unknown"
+	}
+	return severityNames[s]
+}
+
+// ParseSeverity parses a severity name (info, minor, major, critical).
+func ParseSeverity(name string) (Severity, error) {
+	for i, n := range severityNames {
+		if strings.EqualFold(name, n) {
+			return Severity(i), nil
+		}
+	}
+	return SeverityInfo, fmt.Errorf("unknown severity %q (use info, minor, major, or critical)", name)
+}
+
+// DeviationSeverity scores a deviation from its priority levels (each 1–9).
+// Importance and influence weigh double relative to urgency; deviations
+// without a priority are treated as informational.
+func DeviationSeverity(d Deviation) Severity {
+	p := d.Priority
+	if p == nil {
+		return SeverityInfo
+	}
+	score := float64(2*p.ImportanceLevel+2*p.InfluenceLevel+p.UrgencyLevel) / 5
+	switch {
+	case score >= 7:
+		return SeverityCritical
+	case score >= 5:
+		return SeverityMajor
+	case score >= 3:
+		return SeverityMinor
+	default:
+		return SeverityInfo
+	}
+}
+
+// FilterBySeverity keeps deviations at or above the given severity.
+func FilterBySeverity(devs []Deviation, min Severity) []Deviation {
+	filtered := []Deviation{}
+	for _, d := range devs {
+		if DeviationSeverity(d) >= min {
+			filtered = append(filtered, d)
+		}
+	}
+	return filtered
+}
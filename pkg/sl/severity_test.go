@@ -0,0 +1,48 @@
+package sl
+
+import (
+	"testing"
+)
+
+func TestDeviationSeverity(t *testing.T) {
+	tests := []struct {
+		name     string
+		priority *Priority
+		want     Severity
+	}{
+		{"no priority", nil, SeverityInfo},
+		{"all low", &Priority{ImportanceLevel: 1, InfluenceLevel: 1, UrgencyLevel: 1}, SeverityInfo},
+		{"minor", &Priority{ImportanceLevel: 3, InfluenceLevel: 3, UrgencyLevel: 3}, SeverityMinor},
+		{"major", &Priority{ImportanceLevel: 6, InfluenceLevel: 5, UrgencyLevel: 4}, SeverityMajor},
+		{"critical", &Priority{ImportanceLevel: 9, InfluenceLevel: 8, UrgencyLevel: 5}, SeverityCritical},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DeviationSeverity(Deviation{Priority: tt.priority})
+			if got != tt.want {
+				t.Errorf("DeviationSeverity() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	if s, err := ParseSeverity("MAJOR"); err != nil || s != SeverityMajor {
+		t.Errorf("ParseSeverity(MAJOR) = %v, %v", s, err)
+	}
+	if _, err := ParseSeverity("severe"); err == nil {
+		t.Error("expected error for unknown severity")
+	}
+}
+
+func TestFilterBySeverity(t *testing.T) {
+	devs := []Deviation{
+		{DeviationCaseID: 1},
+		{DeviationCaseID: 2, Priority: &Priority{ImportanceLevel: 6, InfluenceLevel: 5, UrgencyLevel: 4}},
+		{DeviationCaseID: 3, Priority: &Priority{ImportanceLevel: 9, InfluenceLevel: 9, UrgencyLevel: 9}},
+	}
+	got := FilterBySeverity(devs, SeverityMajor)
+	if len(got) != 2 || got[0].DeviationCaseID != 2 {
+		t.Errorf("expected cases 2 and 3, got %+v", got)
+	}
+}
@@ -0,0 +1,260 @@
+package sl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SmokeCheck is one live query of sl smoke: an endpoint and the model its
+// response is decoded into.
+type SmokeCheck struct {
+	Name  string
+	URL   string
+	Model func() any // returns a pointer to decode into
+}
+
+// SmokeChecks is the curated set of queries sl smoke runs: one small
+// request per endpoint the CLI depends on, at the client's base URLs.
+func (c *Client) SmokeChecks() []SmokeCheck {
+	return []SmokeCheck{
+		{"departures", c.base.Transport + "/sites/9191/departures", func() any { return &DeparturesResponse{} }},
+		{"lines", c.linesURL(), func() any { return &map[string][]Line{} }},
+		{"deviations", c.base.Deviations + "/messages?transport_mode=METRO", func() any { return &[]Deviation{} }},
+		{"stop-finder", c.stopFinderURL("Medborgarplatsen", "2"), func() any { return &StopFinderResponse{} }},
+		{"departure-monitor", c.departureMonitorURL(DepartureMonitorOptions{
+			StopID: PlannerStopID(9191),
+			Limit:  5,
+		}), func() any { return &DepartureMonitorResponse{} }},
+		{"trips", c.tripsURL(TripOptions{
+			OriginID:   "9091001000009191",
+			DestID:     "9091001000009001",
+			NumTrips:   1,
+			MaxChanges: -1,
+		}), func() any { return &JourneyResponse{} }},
+	}
+}
+
+// ShapeDrift is how a response differs from its model. New fields are in
+// the response but not the model: harmless until SL renames a field we
+// read. Missing fields are always set in the model but absent from every
+// object that should carry them, and decode as zero values.
+type ShapeDrift struct {
+	New     []string `json:"new,omitempty"`
+	Missing []string `json:"missing,omitempty"`
+}
+
+// Empty reports whether the response matched its model.
+func (d ShapeDrift) Empty() bool {
+	return len(d.New) == 0 && len(d.Missing) == 0
+}
+
+// SmokeResult is the outcome of one SmokeCheck.
+type SmokeResult struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"` // "ok", "drift" or "failed"
+	DurationMs int64  `json:"duration_ms"`
+	ShapeDrift
+	Error string `json:"error,omitempty"`
+}
+
+// Smoke runs checks one after another, pause apart so a smoke test never
+// bursts the API. A failed request or a response that no longer decodes
+// into its model fails the check; any other difference is drift.
+func (c *Client) Smoke(ctx context.Context, checks []SmokeCheck, pause time.Duration) []SmokeResult {
+	results := make([]SmokeResult, 0, len(checks))
+	for i, check := range checks {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(pause):
+			}
+		}
+
+		start := time.Now()
+		res := SmokeResult{Name: check.Name, Status: "ok"}
+		body, err := c.get(ctx, check.URL)
+		res.DurationMs = time.Since(start).Milliseconds()
+		if err == nil {
+			err = json.Unmarshal(body, check.Model())
+		}
+		if err == nil {
+			res.ShapeDrift, err = CompareShape(body, check.Model())
+		}
+		switch {
+		case err != nil:
+			res.Status, res.Error = "failed", err.Error()
+		case !res.ShapeDrift.Empty():
+			res.Status = "drift"
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+// CompareShape compares the fields of a JSON response with the json tags of
+// the model it is decoded into (a pointer, slice, map or struct). Paths are
+// dotted, with [] for array elements and * for map values, e.g.
+// "departures[].line.designation".
+func CompareShape(body []byte, m any) (ShapeDrift, error) {
+	var data any
+	if err := json.Unmarshal(body, &data); err != nil {
+		return ShapeDrift{}, err
+	}
+	w := shapeWalk{
+		newFields: map[string]bool{},
+		required:  map[string][]string{},
+		objects:   map[string]int{},
+		present:   map[string]int{},
+	}
+	w.walk(data, reflect.TypeOf(m), "")
+
+	var drift ShapeDrift
+	for path := range w.newFields {
+		drift.New = append(drift.New, path)
+	}
+	for path, names := range w.required {
+		if w.objects[path] == 0 {
+			continue
+		}
+		for _, name := range names {
+			if p := joinPath(path, name); w.present[p] == 0 {
+				drift.Missing = append(drift.Missing, p)
+			}
+		}
+	}
+	sort.Strings(drift.New)
+	sort.Strings(drift.Missing)
+	return drift, nil
+}
+
+// shapeWalk walks decoded JSON alongside the Go type it maps to. A field
+// counts as present at a path if any object there has it, so optional
+// fields that happen to be absent from one element aren't reported.
+type shapeWalk struct {
+	newFields map[string]bool
+	required  map[string][]string // struct path -> fields without omitempty
+	objects   map[string]int      // objects seen per struct path
+	present   map[string]int      // field path -> objects carrying it
+}
+
+var (
+	unmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+	timeType        = reflect.TypeFor[time.Time]()
+)
+
+func (w *shapeWalk) walk(v any, t reflect.Type, path string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	// Types that decode themselves, and untyped ones, accept any shape.
+	if t == timeType || t.Kind() == reflect.Interface || reflect.PointerTo(t).Implements(unmarshalerType) {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return
+		}
+		fields := jsonFields(t)
+		if _, seen := w.required[path]; !seen {
+			var required []string
+			for name, f := range fields {
+				if f.required {
+					required = append(required, name)
+				}
+			}
+			w.required[path] = required
+		}
+		w.objects[path]++
+		for name, val := range obj {
+			p := joinPath(path, name)
+			f, ok := fields[name]
+			if !ok {
+				w.newFields[p] = true
+				continue
+			}
+			w.present[p]++
+			w.walk(val, f.typ, p)
+		}
+	case reflect.Slice, reflect.Array:
+		arr, _ := v.([]any)
+		for _, e := range arr {
+			w.walk(e, t.Elem(), path+"[]")
+		}
+	case reflect.Map:
+		obj, _ := v.(map[string]any)
+		for _, e := range obj {
+			w.walk(e, t.Elem(), joinPath(path, "*"))
+		}
+	}
+}
+
+type jsonField struct {
+	typ      reflect.Type
+	required bool
+}
+
+// jsonFields returns the JSON fields of a struct by name, following
+// encoding/json's rules for tags and embedded structs.
+func jsonFields(t reflect.Type) map[string]jsonField {
+	fields := map[string]jsonField{}
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			et := f.Type
+			if et.Kind() == reflect.Pointer {
+				et = et.Elem()
+			}
+			if et.Kind() == reflect.Struct {
+				for n, ef := range jsonFields(et) {
+					fields[n] = ef
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		optional := strings.Contains(","+opts+",", ",omitempty,") || strings.Contains(","+opts+",", ",omitzero,")
+		fields[name] = jsonField{typ: f.Type, required: !optional}
+	}
+	return fields
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// SmokeSummary counts results that failed or lost fields the model expects.
+func SmokeSummary(results []SmokeResult) error {
+	failed, missing := 0, 0
+	for _, r := range results {
+		if r.Status == "failed" {
+			failed++
+		} else if len(r.Missing) > 0 {
+			missing++
+		}
+	}
+	if failed+missing == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d of %d check(s) failed, %d missing expected fields", failed, len(results), missing)
+}
@@ -0,0 +1,52 @@
+package sl
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareShape(t *testing.T) {
+	type stop struct {
+		Name string `json:"name"`
+		Lat  float64
+	}
+	type resp struct {
+		Stops    []stop            `json:"stops"`
+		Note     string            `json:"note,omitempty"`
+		Extra    map[string]any    `json:"extra,omitempty"`
+		ByMode   map[string][]stop `json:"by_mode,omitempty"`
+		Computed string            `json:"-"`
+	}
+
+	body := []byte(`{
+		"stops": [{"name": "Slussen", "Lat": 59.3, "platform": "A"}, {"Lat": 59.4}],
+		"extra": {"anything": {"goes": true}},
+		"by_mode": {"metro": [{"name": "Slussen", "Lat": 59.3, "exit": 2}]},
+		"stop_count": 2
+	}`)
+	got, err := CompareShape(body, &resp{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ShapeDrift{
+		New:     []string{"by_mode.*[].exit", "stop_count", "stops[].platform"},
+		Missing: nil,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CompareShape = %+v, want %+v", got, want)
+	}
+
+	// A required field absent from every element is missing; an empty
+	// array says nothing about its elements.
+	got, err = CompareShape([]byte(`{"stops": [{"Lat": 59.3}]}`), &resp{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"stops[].name"}; !reflect.DeepEqual(got.Missing, want) {
+		t.Errorf("Missing = %q, want %q", got.Missing, want)
+	}
+	got, _ = CompareShape([]byte(`{"stops": []}`), &resp{})
+	if !got.Empty() {
+		t.Errorf("empty array reported drift: %+v", got)
+	}
+}
@@ -0,0 +1,203 @@
+package sl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// APIDownError reports that an SL API is unavailable: it answered 502, 503
+// or 504, or served an HTML page (typically a maintenance notice) where JSON
+// was expected.
+type APIDownError struct {
+	Host       string
+	StatusCode int
+	// Since is when this host was first seen failing. It is remembered
+	// across runs, so repeated invocations during an outage agree on it.
+	Since time.Time
+	// Detail is the title of the HTML error page, if there was one.
+	Detail string
+	// Attempts is how many times the request was sent, retries included.
+	Attempts int
+}
+
+func (e *APIDownError) Error() string {
+	var b strings.Builder
+	b.WriteString("SL API is down")
+	if !e.Since.IsZero() {
+		layout := "15:04"
+		if time.Since(e.Since) > 24*time.Hour {
+			layout = "2 Jan 15:04"
+		}
+		fmt.Fprintf(&b, " (since %s)", e.Since.Local().Format(layout))
+	}
+	if e.StatusCode != http.StatusOK {
+		fmt.Fprintf(&b, ": %s returned %d", e.Host, e.StatusCode)
+	} else {
+		fmt.Fprintf(&b, ": %s returned an HTML page", e.Host)
+	}
+	if e.Detail != "" {
+		fmt.Fprintf(&b, " (%s)", e.Detail)
+	}
+	if e.Attempts > 1 {
+		fmt.Fprintf(&b, " (after %d attempts)", e.Attempts)
+	}
+	return b.String()
+}
+
+// APIStatus summarises err for machine consumers: "down" when an SL API is
+// unavailable or kept failing with a server error, "rate_limited" for 429,
+// "bad_request" for other 4xx, "unreachable" when the network failed, and
+// "" otherwise.
+func APIStatus(err error) string {
+	var down *APIDownError
+	if errors.As(err, &down) {
+		return "down"
+	}
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		switch {
+		case reqErr.StatusCode == 0:
+			return "unreachable"
+		case reqErr.StatusCode == http.StatusTooManyRequests:
+			return "rate_limited"
+		case reqErr.StatusCode >= 500:
+			return "down"
+		case reqErr.StatusCode >= 400:
+			return "bad_request"
+		}
+	}
+	return ""
+}
+
+// checkAvailability classifies a response. It returns an *APIDownError for
+// gateway/maintenance statuses and HTML bodies, and records the outcome so
+// the start of an outage can be reported.
+func (c *Client) checkAvailability(rawURL string, resp *http.Response, body []byte) error {
+	host := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		host = u.Host
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusBadGateway,
+		resp.StatusCode == http.StatusServiceUnavailable,
+		resp.StatusCode == http.StatusGatewayTimeout,
+		isHTML(resp, body):
+		return &APIDownError{
+			Host:       host,
+			StatusCode: resp.StatusCode,
+			Since:      c.markDown(host),
+			Detail:     htmlTitle(body),
+		}
+	}
+	if resp.StatusCode < 500 {
+		c.markUp(host)
+	}
+	return nil
+}
+
+func isHTML(resp *http.Response, body []byte) bool {
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		return true
+	}
+	trimmed := strings.ToLower(strings.TrimSpace(string(body[:min(len(body), 512)])))
+	return strings.HasPrefix(trimmed, "<!doctype html") || strings.HasPrefix(trimmed, "<html")
+}
+
+var titleRe = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// htmlTitle returns the collapsed <title> of an HTML page, or "".
+func htmlTitle(body []byte) string {
+	m := titleRe.FindSubmatch(body)
+	if m == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(string(m[1])), " ")
+}
+
+// Outage start times are kept in the cache dir as host → time, read once
+// per process for each cache dir.
+var (
+	outageMu sync.Mutex
+	outages  = map[string]map[string]time.Time{} // outage file → host → start
+)
+
+// outagePath is the outage file in the client's cache dir, or "" when
+// there is no cache dir and outages are only remembered in memory.
+func (c *Client) outagePath() string {
+	dir, err := c.cacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "outages.json")
+}
+
+// loadOutages returns the outages recorded at path, reading the file the
+// first time. Callers hold outageMu.
+func loadOutages(path string) map[string]time.Time {
+	if known, ok := outages[path]; ok {
+		return known
+	}
+	known := map[string]time.Time{}
+	if path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			json.Unmarshal(data, &known)
+		}
+	}
+	outages[path] = known
+	return known
+}
+
+// saveOutages writes the outage file. Failures are ignored; the file only
+// improves the error message. Callers hold outageMu.
+func saveOutages(path string, known map[string]time.Time) {
+	if path == "" {
+		return
+	}
+	if len(known) == 0 {
+		os.Remove(path)
+		return
+	}
+	data, err := json.Marshal(known)
+	if err != nil || os.MkdirAll(filepath.Dir(path), 0o755) != nil {
+		return
+	}
+	os.WriteFile(path, data, 0o644)
+}
+
+// markDown records host as failing and returns when the outage began.
+func (c *Client) markDown(host string) time.Time {
+	outageMu.Lock()
+	defer outageMu.Unlock()
+	path := c.outagePath()
+	known := loadOutages(path)
+	if since, ok := known[host]; ok {
+		return since
+	}
+	now := time.Now()
+	known[host] = now
+	saveOutages(path, known)
+	return now
+}
+
+// markUp clears any recorded outage for host.
+func (c *Client) markUp(host string) {
+	outageMu.Lock()
+	defer outageMu.Unlock()
+	path := c.outagePath()
+	known := loadOutages(path)
+	if _, ok := known[host]; !ok {
+		return
+	}
+	delete(known, host)
+	saveOutages(path, known)
+}
@@ -0,0 +1,78 @@
+package sl
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGet_MaintenancePage(t *testing.T) {
+	dir := t.TempDir()
+	userCacheDir = func() (string, error) { return dir, nil }
+	defer func() { userCacheDir = os.UserCacheDir }()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("<!DOCTYPE html><html><head><title>\n  Planned maintenance\n</title></head><body>...</body></html>"))
+	}))
+	defer srv.Close()
+
+	c := NewClient()
+	_, err := c.get(context.Background(), srv.URL)
+	var down *APIDownError
+	if !errors.As(err, &down) {
+		t.Fatalf("expected APIDownError, got %v", err)
+	}
+	if down.StatusCode != 503 || down.Detail != "Planned maintenance" || down.Since.IsZero() {
+		t.Errorf("unexpected error fields: %+v", down)
+	}
+	if strings.Contains(err.Error(), "<html") {
+		t.Errorf("error should not contain raw HTML: %q", err)
+	}
+	if APIStatus(err) != "down" {
+		t.Errorf("APIStatus = %q, want down", APIStatus(err))
+	}
+
+	// A 200 carrying an HTML page is a maintenance page too, and the outage
+	// start is remembered.
+	since := down.Since
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><body>Down for maintenance</body></html>"))
+	})
+	_, err = c.get(context.Background(), srv.URL)
+	if !errors.As(err, &down) || !down.Since.Equal(since) {
+		t.Fatalf("expected the same outage, got %v", err)
+	}
+
+	// Recovery clears the outage.
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	})
+	if _, err := c.get(context.Background(), srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	if APIStatus(nil) != "" {
+		t.Error("APIStatus(nil) should be empty")
+	}
+	if _, down := loadOutages(c.outagePath())[strings.TrimPrefix(srv.URL, "http://")]; down {
+		t.Error("outage should be cleared after a good response")
+	}
+}
+
+func TestGet_OtherErrorsAreNotOutages(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message": "bad siteId"}`))
+	}))
+	defer srv.Close()
+
+	_, err := NewClient().get(context.Background(), srv.URL)
+	if err == nil || APIStatus(err) != "bad_request" {
+		t.Errorf("400 should be a bad request, got %v (%q)", err, APIStatus(err))
+	}
+}
@@ -0,0 +1,178 @@
+package sl
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Trace levels for SetTrace.
+const (
+	TraceOff      = 0
+	TraceRequests = 1 // one line per request: URL, status, size, latency
+	TraceBodies   = 2 // also the request and response headers and bodies
+)
+
+// maxTracedBody caps how much of a response body TraceBodies writes.
+const maxTracedBody = 64 << 10
+
+// redactedParams are query parameters holding credentials, masked in traces.
+var redactedParams = []string{"key"}
+
+// SetTrace logs every HTTP request the client makes to w, at level
+// TraceRequests or TraceBodies. TraceOff (or a nil w) turns tracing off.
+func (c *Client) SetTrace(w io.Writer, level int) {
+	base := c.httpClient.Transport
+	if t, ok := base.(*tracingTransport); ok {
+		base = t.base
+	}
+	if w == nil || level <= TraceOff {
+		c.httpClient.Transport = base
+		return
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	c.httpClient.Transport = &tracingTransport{base: base, w: w, level: level}
+}
+
+// tracingTransport is the http.RoundTripper behind SetTrace.
+type tracingTransport struct {
+	base  http.RoundTripper
+	w     io.Writer
+	level int
+	mu    sync.Mutex // serializes writes to w
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		t.printf("→ %s %s: %v (%s)\n", req.Method, redactURL(req.URL), err, time.Since(start).Round(time.Millisecond))
+		return nil, err
+	}
+	if t.level < TraceBodies {
+		// The line is written once the body is read, so size and latency
+		// cover the whole download.
+		resp.Body = &countingBody{ReadCloser: resp.Body, done: func(n int64) {
+			t.printf("→ %s %s: %d, %s in %s\n", req.Method, redactURL(req.URL), resp.StatusCode, byteSize(n), time.Since(start).Round(time.Millisecond))
+		}}
+		return resp, nil
+	}
+
+	body, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "→ %s %s: %d, %s in %s\n", req.Method, redactURL(req.URL), resp.StatusCode, byteSize(int64(len(body))), time.Since(start).Round(time.Millisecond))
+	writeHeaders(&b, "  > ", req.Header)
+	writeHeaders(&b, "  < ", resp.Header)
+	if readErr != nil {
+		fmt.Fprintf(&b, "  (reading body: %v)\n", readErr)
+	}
+	writeBody(&b, body, resp.Header.Get("Content-Encoding") == "gzip")
+	t.printf("%s", b.String())
+	return resp, nil
+}
+
+func (t *tracingTransport) printf(format string, args ...any) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fmt.Fprintf(t.w, format, args...)
+}
+
+// countingBody counts the bytes read from a response body and reports
+// the total once, at EOF or on Close.
+type countingBody struct {
+	io.ReadCloser
+	n    int64
+	done func(n int64)
+	once sync.Once
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	if err == io.EOF {
+		b.once.Do(func() { b.done(b.n) })
+	}
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	b.once.Do(func() { b.done(b.n) })
+	return b.ReadCloser.Close()
+}
+
+// writeHeaders writes h sorted by name, one per line after prefix.
+func writeHeaders(b *strings.Builder, prefix string, h http.Header) {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(b, "%s%s: %s\n", prefix, name, strings.Join(h[name], ", "))
+	}
+}
+
+// writeBody writes a response body, decompressed if gzipped and cut off
+// after maxTracedBody bytes.
+func writeBody(b *strings.Builder, body []byte, gzipped bool) {
+	if gzipped {
+		if gr, err := gzip.NewReader(bytes.NewReader(body)); err == nil {
+			if plain, err := io.ReadAll(gr); err == nil {
+				body = plain
+			}
+		}
+	}
+	if len(body) == 0 {
+		return
+	}
+	more := len(body) - maxTracedBody
+	if more > 0 {
+		body = body[:maxTracedBody]
+	}
+	b.Write(body)
+	if more > 0 {
+		fmt.Fprintf(b, "\n  … %s more", byteSize(int64(more)))
+	}
+	b.WriteString("\n")
+}
+
+// redactURL is u with credentials in its query masked.
+func redactURL(u *url.URL) string {
+	q := u.Query()
+	redacted := false
+	for _, p := range redactedParams {
+		if q.Has(p) {
+			q.Set(p, "REDACTED")
+			redacted = true
+		}
+	}
+	if !redacted {
+		return u.String()
+	}
+	c := *u
+	c.RawQuery = q.Encode()
+	return c.String()
+}
+
+// byteSize formats n bytes for humans, e.g. "812 B" or "14.2 kB".
+func byteSize(n int64) string {
+	switch {
+	case n < 1000:
+		return fmt.Sprintf("%d B", n)
+	case n < 1000*1000:
+		return fmt.Sprintf("%.1f kB", float64(n)/1000)
+	}
+	return fmt.Sprintf("%.1f MB", float64(n)/1000/1000)
+}
@@ -0,0 +1,55 @@
+package sl
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSetTrace(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		gw := gzip.NewWriter(w)
+		gw.Write([]byte(`{"stop":"Medborgarplatsen"}`))
+		gw.Close()
+	}))
+	t.Cleanup(srv.Close)
+
+	var log bytes.Buffer
+	c := New(Options{Trace: &log, TraceLevel: TraceRequests})
+	body, err := c.get(context.Background(), srv.URL+"/sl.zip?key=secret")
+	if err != nil || string(body) != `{"stop":"Medborgarplatsen"}` {
+		t.Fatalf("get = %q, %v", body, err)
+	}
+	line := log.String()
+	if !strings.HasPrefix(line, "→ GET "+srv.URL+"/sl.zip?key=REDACTED: 200, ") || !strings.Contains(line, " B in ") {
+		t.Errorf("unexpected trace %q", line)
+	}
+	if strings.Contains(line, "secret") {
+		t.Errorf("trace leaks the key: %q", line)
+	}
+
+	log.Reset()
+	c.SetTrace(&log, TraceBodies)
+	if _, err := c.get(context.Background(), srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"  > Accept: application/json\n", "  < Content-Encoding: gzip\n", `{"stop":"Medborgarplatsen"}`} {
+		if !strings.Contains(log.String(), want) {
+			t.Errorf("trace lacks %q:\n%s", want, log.String())
+		}
+	}
+
+	log.Reset()
+	c.SetTrace(&log, TraceOff)
+	if _, err := c.get(context.Background(), srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	if log.Len() > 0 {
+		t.Errorf("trace after TraceOff: %q", log.String())
+	}
+}
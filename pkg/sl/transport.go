@@ -1,6 +1,9 @@
-package model
+package sl
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // Site represents a transit stop/station in SL's network.
 type Site struct {
@@ -43,7 +46,16 @@ type Departure struct {
 	StopArea      *StopArea  `json:"stop_area,omitempty"`
 	StopPoint     *StopPoint `json:"stop_point,omitempty"`
 	Line          *Line      `json:"line,omitempty"`
-	Deviations    []any      `json:"deviations,omitempty"`
+	Deviations    []DepartureDeviation `json:"deviations,omitempty"`
+	Extras        Extras     `json:"raw_extras,omitempty"`
+}
+
+// DepartureDeviation is a disruption note attached to a single departure,
+// such as a short train or a replacement bus.
+type DepartureDeviation struct {
+	ImportanceLevel int    `json:"importance_level"`
+	Consequence     string `json:"consequence,omitempty"`
+	Message         string `json:"message"`
 }
 
 type Journey struct {
@@ -64,6 +76,19 @@ type StopPoint struct {
 	Designation string `json:"designation,omitempty"`
 }
 
+// StopPointDetail is a stop point as returned by the /stop-points endpoint,
+// including the stop area (and its type) it belongs to.
+type StopPointDetail struct {
+	ID          int       `json:"id"`
+	GID         int64     `json:"gid"`
+	Name        string    `json:"name"`
+	Designation string    `json:"designation,omitempty"`
+	Type        string    `json:"type,omitempty"`
+	Lat         float64   `json:"lat"`
+	Lon         float64   `json:"lon"`
+	StopArea    *StopArea `json:"stop_area,omitempty"`
+}
+
 // DeparturesResponse is the API response for departures.
 type DeparturesResponse struct {
 	Departures    []Departure `json:"departures"`
@@ -80,6 +105,11 @@ type Deviation struct {
 	Priority        *Priority         `json:"priority,omitempty"`
 	MessageVariants []MessageVariant  `json:"message_variants,omitempty"`
 	Scope           *DeviationScope   `json:"scope,omitempty"`
+
+	// Severity is computed client-side from Priority (not part of the API response).
+	Severity string `json:"severity,omitempty"`
+
+	Extras Extras `json:"raw_extras,omitempty"`
 }
 
 type PublishWindow struct {
@@ -98,6 +128,8 @@ type MessageVariant struct {
 	Details    string `json:"details"`
 	ScopeAlias string `json:"scope_alias"`
 	Language   string `json:"language"`
+	// Weblink is SL's page about the deviation, when there is one.
+	Weblink string `json:"weblink,omitempty"`
 }
 
 type DeviationScope struct {
@@ -155,6 +187,13 @@ type JourneyTrip struct {
 	Interchanges   int           `json:"interchanges"`
 	IsAdditional   bool          `json:"isAdditional"`
 	Legs           []JourneyLeg  `json:"legs"`
+
+	// BufferedDuration is TripRtDuration (or TripDuration) padded by the
+	// user's --buffer, in seconds. Computed client-side; not part of the API.
+	BufferedDuration int `json:"bufferedDuration,omitempty"`
+	// ElevatorOutages are reported elevator failures at the journey's
+	// stations, set with --wheelchair. Computed client-side.
+	ElevatorOutages []ElevatorOutage `json:"elevatorOutages,omitempty"`
 }
 
 type JourneyLeg struct {
@@ -164,6 +203,10 @@ type JourneyLeg struct {
 	Transport   *JourneyTransport `json:"transportation,omitempty"`
 	Infos       []any            `json:"infos,omitempty"`
 	IsRealtimeControlled bool    `json:"isRealtimeControlled"`
+	// Coords is the leg's path as [lat, lon] pairs, sent when the trip
+	// is planned with TripOptions.Coords.
+	Coords      [][2]float64     `json:"coords,omitempty"`
+	Extras      Extras           `json:"raw_extras,omitempty"`
 }
 
 type JourneyStop struct {
@@ -179,6 +222,14 @@ type JourneyStop struct {
 	ArrivalTimeEstimated   string     `json:"arrivalTimeEstimated,omitempty"`
 	Parent                 *Parent    `json:"parent,omitempty"`
 	Properties             map[string]any `json:"properties,omitempty"`
+	// Accessibility is read from Properties by MarkAccessibility.
+	// Computed client-side; not part of the API.
+	Accessibility *StopAccessibility `json:"accessibility,omitempty"`
+	// Platform and Entrance are read from Properties by MarkPlatforms:
+	// the track or stop position, and the station entrance or exit.
+	// Computed client-side; not part of the API.
+	Platform string `json:"platform,omitempty"`
+	Entrance string `json:"entrance,omitempty"`
 }
 
 type JourneyTransport struct {
@@ -207,6 +258,24 @@ type TransportDest struct {
 	Type string `json:"type"`
 }
 
+// DepartureMonitorResponse is the response from the journey planner
+// departure-monitor endpoint.
+type DepartureMonitorResponse struct {
+	SystemMessages []SystemMessage `json:"systemMessages,omitempty"`
+	StopEvents     []StopEvent     `json:"stopEvents,omitempty"`
+}
+
+// StopEvent is one departure on the planner's departure monitor. Location
+// is the platform it leaves from, with the stop as its parent.
+type StopEvent struct {
+	Location               *JourneyStop      `json:"location,omitempty"`
+	DepartureTimePlanned   string            `json:"departureTimePlanned"`
+	DepartureTimeEstimated string            `json:"departureTimeEstimated,omitempty"`
+	Transport              *JourneyTransport `json:"transportation,omitempty"`
+	IsRealtimeControlled   bool              `json:"isRealtimeControlled"`
+	IsCancelled            bool              `json:"isCancelled,omitempty"`
+}
+
 // ParsedDeparture is a processed departure with parsed times.
 type ParsedDeparture struct {
 	Line          string        `json:"line"`
@@ -214,6 +283,7 @@ type ParsedDeparture struct {
 	GroupOfLines  string        `json:"group_of_lines,omitempty"`
 	Destination   string        `json:"destination"`
 	Direction     string        `json:"direction"`
+	DirectionCode int           `json:"direction_code"`
 	Display       string        `json:"display"`
 	Scheduled     time.Time     `json:"scheduled"`
 	Expected      time.Time     `json:"expected"`
@@ -222,5 +292,62 @@ type ParsedDeparture struct {
 	StopArea      string        `json:"stop_area"`
 	StopPoint     string        `json:"stop_point"`
 	Platform      string        `json:"platform,omitempty"`
-	Deviations    []string      `json:"deviations,omitempty"`
+	Deviations    []DepartureDeviation `json:"deviations,omitempty"`
+	VehicleNotes  []string      `json:"vehicle_notes,omitempty"`
+	Catchable     Catchability  `json:"catchable,omitempty"`
+	DataQuality   *DataQuality  `json:"data_quality,omitempty"`
+	// ScheduledOnly marks a departure taken from the timetable rather than
+	// real-time data, for times beyond RealtimeHorizon.
+	ScheduledOnly bool          `json:"scheduled_only,omitempty"`
+	Extras        Extras        `json:"raw_extras,omitempty"`
+}
+
+// DataQuality describes a departure whose display text and expected time
+// disagreed upstream, and which of the two was kept.
+type DataQuality struct {
+	Issue       string `json:"issue"`
+	RawDisplay  string `json:"raw_display"`
+	ExpectedMin int    `json:"expected_min"`
+	Preferred   string `json:"preferred"` // "display" or "expected"
+}
+
+// Catchability says whether a departure can be reached given the walk to the
+// stop. It encodes in JSON as true, false or "marginal"; unknown is omitted.
+type Catchability int
+
+const (
+	CatchUnknown Catchability = iota
+	CatchYes
+	CatchMarginal
+	CatchNo
+)
+
+func (c Catchability) MarshalJSON() ([]byte, error) {
+	switch c {
+	case CatchYes:
+		return []byte("true"), nil
+	case CatchNo:
+		return []byte("false"), nil
+	case CatchMarginal:
+		return []byte(`"marginal"`), nil
+	}
+	return []byte("null"), nil
+}
+
+func (c *Catchability) UnmarshalJSON(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch v {
+	case true:
+		*c = CatchYes
+	case false:
+		*c = CatchNo
+	case "marginal":
+		*c = CatchMarginal
+	default:
+		*c = CatchUnknown
+	}
+	return nil
 }
@@ -0,0 +1,125 @@
+package sl
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const tripCacheTTL = 2 * time.Minute
+
+// userCacheDir is swapped out in tests.
+var userCacheDir = os.UserCacheDir
+
+// cacheDir returns the sl-cli directory under the user's cache dir, or
+// Options.CacheDir.
+func (c *Client) cacheDir() (string, error) {
+	if c.cacheRoot != "" {
+		return c.cacheRoot, nil
+	}
+	base, err := userCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "sl-cli"), nil
+}
+
+// tripCacheKey identifies a trip request within a time bucket, so repeated
+// queries for the same journey share an entry until the bucket rolls over.
+func tripCacheKey(opts TripOptions, now time.Time) string {
+	bucket := now.Truncate(tripCacheTTL).Unix()
+	var departAt int64
+	if !opts.DepartAt.IsZero() {
+		departAt = opts.DepartAt.Unix()
+	}
+	raw := fmt.Sprintf("%s|%s|%s|%s|%d|%s|%d|%s|%s|%t|%t|%t|%t|%t|%t|%t|%d|%d",
+		opts.OriginID, opts.OriginName, opts.DestID, opts.DestName,
+		opts.NumTrips, opts.Language, opts.MaxChanges, opts.RouteType,
+		opts.MaxWalk, opts.NoStairs, opts.NoEscalators, opts.LowFloorOnly,
+		opts.Carry.Bike, opts.Carry.Stroller, opts.Carry.Wheelchair, opts.Coords,
+		departAt, bucket)
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:8])
+}
+
+// PlanTripCached returns a recently planned trip from the on-disk cache if one
+// exists for the same request, otherwise plans it and caches the result.
+// Cache failures are never fatal — they just fall through to the API.
+func (c *Client) PlanTripCached(ctx context.Context, opts TripOptions) (*JourneyResponse, error) {
+	dir, err := c.cacheDir()
+	if err != nil {
+		c.Warn(WarnCacheUnavailable, "trip cache unavailable: %v", err)
+		return c.PlanTrip(ctx, opts)
+	}
+	dir = filepath.Join(dir, "trips")
+	now := time.Now()
+	path := filepath.Join(dir, tripCacheKey(opts, now)+".json")
+
+	if resp, ok := readTripCache(path, tripCacheTTL); ok {
+		c.dropExtras(nil, nil, resp.Journeys)
+		return resp, nil
+	}
+
+	resp, err := c.PlanTrip(ctx, opts)
+	var down *APIDownError
+	if errors.As(err, &down) {
+		// During an outage a plan from the previous bucket beats nothing.
+		prev := filepath.Join(dir, tripCacheKey(opts, now.Add(-tripCacheTTL))+".json")
+		for _, p := range []string{path, prev} {
+			if stale, ok := readTripCache(p, 2*tripCacheTTL); ok {
+				c.dropExtras(nil, nil, stale.Journeys)
+				c.Warn(WarnStaleData, "showing a trip planned earlier: %v", err)
+				return stale, nil
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if data, err := json.Marshal(resp); err == nil && os.MkdirAll(dir, 0o755) == nil {
+		pruneTripCache(dir)
+		os.WriteFile(path, data, 0o644)
+	}
+	return resp, nil
+}
+
+// readTripCache returns the cached plan at path if it is younger than maxAge.
+func readTripCache(path string, maxAge time.Duration) (*JourneyResponse, bool) {
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) >= maxAge {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var resp JourneyResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, false
+	}
+	return &resp, true
+}
+
+// pruneTripCache removes expired trip entries so the cache dir doesn't grow unbounded.
+func pruneTripCache(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		if info, err := e.Info(); err == nil && time.Since(info.ModTime()) >= tripCacheTTL {
+			os.Remove(filepath.Join(dir, e.Name()))
+		}
+	}
+}
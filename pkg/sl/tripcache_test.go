@@ -0,0 +1,90 @@
+package sl
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTripCacheKey(t *testing.T) {
+	now := time.Date(2024, 3, 1, 8, 0, 10, 0, time.UTC)
+	opts := TripOptions{OriginID: "9091001000009182", DestID: "9091001000009001", NumTrips: 3, MaxChanges: -1}
+
+	if tripCacheKey(opts, now) != tripCacheKey(opts, now.Add(30*time.Second)) {
+		t.Error("same request within a bucket should share a key")
+	}
+	if tripCacheKey(opts, now) == tripCacheKey(opts, now.Add(tripCacheTTL)) {
+		t.Error("next bucket should produce a different key")
+	}
+
+	other := opts
+	other.DestID = "9091001000009117"
+	if tripCacheKey(opts, now) == tripCacheKey(other, now) {
+		t.Error("different destination should produce a different key")
+	}
+}
+
+func TestPlanTripCached_Hit(t *testing.T) {
+	dir := t.TempDir()
+	userCacheDir = func() (string, error) { return dir, nil }
+	defer func() { userCacheDir = os.UserCacheDir }()
+
+	opts := TripOptions{OriginID: "a", DestID: "b", MaxChanges: -1}
+	cached := JourneyResponse{Journeys: []JourneyTrip{{TripDuration: 1200}}}
+	data, _ := json.Marshal(cached)
+
+	tripDir := filepath.Join(dir, "sl-cli", "trips")
+	if err := os.MkdirAll(tripDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tripDir, tripCacheKey(opts, time.Now())+".json"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A nil http client would panic if the cache were bypassed.
+	c := &Client{}
+	resp, err := c.PlanTripCached(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Journeys) != 1 || resp.Journeys[0].TripDuration != 1200 {
+		t.Errorf("expected cached journey, got %+v", resp.Journeys)
+	}
+}
+
+func TestPlanTripCached_StaleDuringOutage(t *testing.T) {
+	dir := t.TempDir()
+	userCacheDir = func() (string, error) { return dir, nil }
+	defer func() { userCacheDir = os.UserCacheDir }()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	opts := TripOptions{OriginID: "a", DestID: "b", MaxChanges: -1}
+	data, _ := json.Marshal(JourneyResponse{Journeys: []JourneyTrip{{TripDuration: 900}}})
+	tripDir := filepath.Join(dir, "sl-cli", "trips")
+	os.MkdirAll(tripDir, 0o755)
+	path := filepath.Join(tripDir, tripCacheKey(opts, time.Now().Add(-tripCacheTTL))+".json")
+	os.WriteFile(path, data, 0o644)
+	// Older than the fresh TTL but within the outage grace period.
+	old := time.Now().Add(-3 * tripCacheTTL / 2)
+	os.Chtimes(path, old, old)
+
+	var warned []Warning
+	c := New(Options{BaseURLs: BaseURLs{JourneyPlanner: srv.URL}})
+	c.SetWarningHandler(func(w Warning) { warned = append(warned, w) })
+	resp, err := c.PlanTripCached(context.Background(), opts)
+	if err != nil || len(resp.Journeys) != 1 {
+		t.Fatalf("expected stale plan, got %+v, %v", resp, err)
+	}
+	if len(warned) != 1 || warned[0].Code != WarnStaleData {
+		t.Errorf("expected a stale_data warning, got %+v", warned)
+	}
+}
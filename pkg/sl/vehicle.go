@@ -0,0 +1,86 @@
+package sl
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/glundgren93/sl-cli/internal/i18n"
+)
+
+// FullTrainCars is the length of a full pendeltåg set (two coupled X60 units).
+const FullTrainCars = 12
+
+// VehicleInfo is what a departure's notices say about its train's length.
+type VehicleInfo struct {
+	// Short is set when the departure carries a short train notice.
+	Short bool `json:"short_train,omitempty"`
+	// Cars is the length the notice gives, or 0 when it gives none.
+	Cars int `json:"cars,omitempty"`
+}
+
+// ShortTrain reports whether the train runs with less than a full set.
+func (v VehicleInfo) ShortTrain() bool {
+	return v.Short
+}
+
+// Notes returns human-readable warnings in the selected language:
+// "short train (6 cars)", or "short train" when the notice has no count.
+func (v VehicleInfo) Notes() []string {
+	switch {
+	case !v.Short:
+		return nil
+	case v.Cars > 0:
+		return []string{i18n.T(i18n.ShortTrain, v.Cars)}
+	}
+	return []string{i18n.T(i18n.ShortTrainNoCount)}
+}
+
+// shortTrainPhrases mark a per-departure message as a short train notice.
+var shortTrainPhrases = []string{"kort tåg", "short train"}
+
+// IsShortTrainNotice reports whether a departure deviation message is a
+// short train notice, which DepartureVehicleInfo already turns into a
+// vehicle note.
+func IsShortTrainNotice(msg string) bool {
+	lower := strings.ToLower(msg)
+	for _, phrase := range shortTrainPhrases {
+		if strings.Contains(lower, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// DepartureVehicleInfo reads train length from a pendeltåg departure's own
+// deviation messages, e.g. "Kort tåg, 6 vagnar." The transport API has no
+// structured length field, so this is the only source; other modes are
+// skipped, as the notices are about pendeltåg sets.
+func DepartureVehicleInfo(d Departure) VehicleInfo {
+	var info VehicleInfo
+	if d.Line == nil || !strings.EqualFold(d.Line.TransportMode, "TRAIN") {
+		return info
+	}
+	for _, dev := range d.Deviations {
+		if !IsShortTrainNotice(dev.Message) {
+			continue
+		}
+		info.Short = true
+		info.Cars = carsInMessage(strings.ToLower(dev.Message))
+		return info
+	}
+	return info
+}
+
+// carsInMessage finds a count like "6 vagnar" or "6 cars" in a message.
+func carsInMessage(msg string) int {
+	fields := strings.Fields(strings.NewReplacer(",", " ", ".", " ").Replace(msg))
+	for i := 0; i+1 < len(fields); i++ {
+		if fields[i+1] != "vagnar" && fields[i+1] != "cars" {
+			continue
+		}
+		if n, err := strconv.Atoi(fields[i]); err == nil {
+			return n
+		}
+	}
+	return 0
+}
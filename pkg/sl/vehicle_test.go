@@ -0,0 +1,38 @@
+package sl
+
+import (
+	"testing"
+)
+
+func TestDepartureVehicleInfo(t *testing.T) {
+	train := &Line{Designation: "43", TransportMode: "TRAIN"}
+	dep := Departure{Line: train, Deviations: []DepartureDeviation{
+		{ImportanceLevel: 5, Message: "Kort tåg, 6 vagnar. Gå mot mitten av plattformen."},
+	}}
+	info := DepartureVehicleInfo(dep)
+	if !info.ShortTrain() || info.Cars != 6 {
+		t.Errorf("got %+v, want a short train of 6 cars", info)
+	}
+	if notes := info.Notes(); len(notes) != 1 || notes[0] != "short train (6 cars)" {
+		t.Errorf("notes = %v", notes)
+	}
+
+	noCount := Departure{Line: train, Deviations: []DepartureDeviation{{Message: "Short train"}}}
+	info = DepartureVehicleInfo(noCount)
+	if !info.ShortTrain() || info.Cars != 0 {
+		t.Errorf("got %+v, want a short train without a count", info)
+	}
+	if notes := info.Notes(); len(notes) != 1 || notes[0] != "short train" {
+		t.Errorf("notes = %v", notes)
+	}
+
+	metro := Departure{Line: &Line{Designation: "17", TransportMode: "METRO"}, Deviations: noCount.Deviations}
+	if info := DepartureVehicleInfo(metro); info.ShortTrain() || len(info.Notes()) != 0 {
+		t.Errorf("metro departure flagged as a short train: %+v", info)
+	}
+
+	other := Departure{Line: train, Deviations: []DepartureDeviation{{Message: "Inställd"}}}
+	if info := DepartureVehicleInfo(other); info.ShortTrain() {
+		t.Errorf("unrelated message flagged as short train: %+v", info)
+	}
+}
@@ -0,0 +1,39 @@
+package sl
+
+import "fmt"
+
+// Warning codes for non-fatal conditions reported through the warning handler.
+const (
+	WarnGeocoderFallback  = "geocoder_fallback"
+	WarnCacheUnavailable  = "cache_unavailable"
+	WarnPartialDeviations = "partial_deviations"
+	WarnPartialResponse   = "partial_response"
+	WarnStaleData         = "stale_data"
+)
+
+// Warning is a non-fatal condition encountered while serving a request.
+// The CLI prints these to stderr; library consumers can collect them via
+// SetWarningHandler.
+type Warning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (w Warning) String() string {
+	return w.Message
+}
+
+// SetWarningHandler registers a callback invoked for every non-fatal condition.
+// Passing nil discards warnings (the default).
+func (c *Client) SetWarningHandler(fn func(Warning)) {
+	c.onWarning = fn
+}
+
+// Warn reports a non-fatal condition to the registered handler, if any.
+// Exported so callers layering on top of the client can share the same channel.
+func (c *Client) Warn(code, format string, args ...any) {
+	if c.onWarning == nil {
+		return
+	}
+	c.onWarning(Warning{Code: code, Message: fmt.Sprintf(format, args...)})
+}
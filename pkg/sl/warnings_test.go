@@ -0,0 +1,46 @@
+package sl
+
+import "testing"
+
+func TestWarn(t *testing.T) {
+	c := NewClient()
+
+	// No handler registered: must not panic.
+	c.Warn(WarnCacheUnavailable, "ignored")
+
+	var got []Warning
+	c.SetWarningHandler(func(w Warning) { got = append(got, w) })
+	c.Warn(WarnGeocoderFallback, "no match for %q", "Slusen")
+
+	if len(got) != 1 {
+		t.Fatalf("expected 1 warning, got %d", len(got))
+	}
+	if got[0].Code != WarnGeocoderFallback {
+		t.Errorf("code = %q, want %q", got[0].Code, WarnGeocoderFallback)
+	}
+	if got[0].Message != `no match for "Slusen"` {
+		t.Errorf("message = %q", got[0].Message)
+	}
+}
+
+func TestParseLinesWarnings(t *testing.T) {
+	c := NewClient()
+	var got []Warning
+	c.SetWarningHandler(func(w Warning) { got = append(got, w) })
+
+	body := `{
+		"metro": [{"id": 17, "designation": "17", "transport_mode": "METRO"}],
+		"taxi": [{"id": 1, "name": "Sjukresor", "designation": {"unexpected": true}}],
+		"cable_car": {"unexpected": true}
+	}`
+	lines, err := c.parseLines([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 1 || lines[0].Designation != "17" {
+		t.Errorf("lines = %+v, want metro 17 only", lines)
+	}
+	if len(got) != 1 || got[0].Code != WarnPartialResponse || got[0].Message != "skipped unparseable cable_car lines" {
+		t.Errorf("warnings = %v, want only the unexpected cable_car group", got)
+	}
+}
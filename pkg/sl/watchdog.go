@@ -0,0 +1,177 @@
+package sl
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// ProbeTarget is one SL endpoint checked by the watchdog.
+type ProbeTarget struct {
+	Name string
+	URL  string
+}
+
+// ProbeTargets returns a cheap request against each of the three SL APIs.
+func (c *Client) ProbeTargets() []ProbeTarget {
+	finder := url.Values{}
+	finder.Set("name_sf", "T-Centralen")
+	finder.Set("type_sf", "any")
+	finder.Set("any_obj_filter_sf", "2")
+	return []ProbeTarget{
+		{Name: "transport", URL: c.base.Transport + "/sites/9001/departures?forecast=10"},
+		{Name: "deviations", URL: c.base.Deviations + "/messages?future=false"},
+		{Name: "journey-planner", URL: c.base.JourneyPlanner + "/stop-finder?" + finder.Encode()},
+	}
+}
+
+// Probe states.
+const (
+	ProbeUp    = "up"
+	ProbeDown  = "down"  // maintenance, 502/503/504 or an HTML error page
+	ProbeError = "error" // any other failure: network, timeout, 4xx/5xx
+)
+
+// ProbeResult is the outcome of probing one endpoint once.
+type ProbeResult struct {
+	Endpoint  string    `json:"endpoint"`
+	Time      time.Time `json:"time"`
+	Status    string    `json:"status"`
+	LatencyMs int64     `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Probe requests target once and reports whether it answered.
+func (c *Client) Probe(ctx context.Context, target ProbeTarget) ProbeResult {
+	start := time.Now()
+	_, err := c.get(ctx, target.URL)
+	res := ProbeResult{
+		Endpoint:  target.Name,
+		Time:      start,
+		Status:    ProbeUp,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		res.Status = ProbeError
+		var down *APIDownError
+		if errors.As(err, &down) {
+			res.Status = ProbeDown
+		}
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// probeLogPath is the NDJSON file the watchdog appends results to.
+func (c *Client) probeLogPath() (string, error) {
+	dir, err := c.cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "watchdog.ndjson"), nil
+}
+
+// RecordProbes appends results to the probe log in the cache dir.
+func (c *Client) RecordProbes(results []ProbeResult) error {
+	path, err := c.probeLogPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	for _, r := range results {
+		if err := enc.Encode(r); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// LoadProbes reads the probe log, keeping results newer than since.
+// A missing log is not an error.
+func (c *Client) LoadProbes(since time.Time) ([]ProbeResult, error) {
+	path, err := c.probeLogPath()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var results []ProbeResult
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var r ProbeResult
+		if json.Unmarshal(sc.Bytes(), &r) != nil {
+			continue // tolerate a line cut short by a crash
+		}
+		if !r.Time.Before(since) {
+			results = append(results, r)
+		}
+	}
+	return results, sc.Err()
+}
+
+// UptimeStats summarises the probes of one endpoint.
+type UptimeStats struct {
+	Endpoint    string  `json:"endpoint"`
+	Probes      int     `json:"probes"`
+	UptimePct   float64 `json:"uptime_pct"`
+	MedianMs    int64   `json:"median_ms"`
+	P95Ms       int64   `json:"p95_ms"`
+	LastStatus  string  `json:"last_status"`
+	LastFailure string  `json:"last_failure,omitempty"`
+}
+
+// SummarizeProbes computes per-endpoint uptime and latency, in the order
+// endpoints first appear. Latency percentiles only count successful probes.
+func SummarizeProbes(results []ProbeResult) []UptimeStats {
+	var order []string
+	byEndpoint := map[string][]ProbeResult{}
+	for _, r := range results {
+		if _, ok := byEndpoint[r.Endpoint]; !ok {
+			order = append(order, r.Endpoint)
+		}
+		byEndpoint[r.Endpoint] = append(byEndpoint[r.Endpoint], r)
+	}
+
+	stats := make([]UptimeStats, 0, len(order))
+	for _, name := range order {
+		rs := byEndpoint[name]
+		s := UptimeStats{Endpoint: name, Probes: len(rs), LastStatus: rs[len(rs)-1].Status}
+		var latencies []int64
+		for _, r := range rs {
+			if r.Status == ProbeUp {
+				latencies = append(latencies, r.LatencyMs)
+			} else {
+				s.LastFailure = r.Time.Format(time.RFC3339)
+			}
+		}
+		s.UptimePct = 100 * float64(len(latencies)) / float64(len(rs))
+		if len(latencies) > 0 {
+			slices.Sort(latencies)
+			s.MedianMs = latencies[len(latencies)/2]
+			s.P95Ms = latencies[(len(latencies)*95-1)/100]
+		}
+		stats = append(stats, s)
+	}
+	return stats
+}
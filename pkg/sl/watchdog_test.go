@@ -0,0 +1,53 @@
+package sl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummarizeProbes(t *testing.T) {
+	t0 := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	var results []ProbeResult
+	for i := range 20 {
+		results = append(results, ProbeResult{Endpoint: "transport", Time: t0.Add(time.Duration(i) * time.Minute), Status: ProbeUp, LatencyMs: int64(100 + i)})
+	}
+	results = append(results,
+		ProbeResult{Endpoint: "deviations", Time: t0, Status: ProbeUp, LatencyMs: 50},
+		ProbeResult{Endpoint: "deviations", Time: t0.Add(time.Minute), Status: ProbeDown},
+	)
+
+	stats := SummarizeProbes(results)
+	if len(stats) != 2 || stats[0].Endpoint != "transport" {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	tr := stats[0]
+	if tr.UptimePct != 100 || tr.MedianMs != 110 || tr.P95Ms != 118 || tr.LastFailure != "" {
+		t.Errorf("transport stats: %+v", tr)
+	}
+	dv := stats[1]
+	if dv.UptimePct != 50 || dv.LastStatus != ProbeDown || dv.LastFailure == "" || dv.MedianMs != 50 {
+		t.Errorf("deviations stats: %+v", dv)
+	}
+}
+
+func TestRecordAndLoadProbes(t *testing.T) {
+	c := New(Options{CacheDir: t.TempDir()})
+
+	now := time.Now()
+	old := ProbeResult{Endpoint: "transport", Time: now.Add(-48 * time.Hour), Status: ProbeUp}
+	recent := ProbeResult{Endpoint: "transport", Time: now, Status: ProbeError, Error: "timeout"}
+	if err := c.RecordProbes([]ProbeResult{old}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.RecordProbes([]ProbeResult{recent}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.LoadProbes(now.Add(-24 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Error != "timeout" {
+		t.Errorf("expected only the recent probe, got %+v", got)
+	}
+}
@@ -0,0 +1,105 @@
+package sl
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// StopBoard is the departures listed at one site.
+type StopBoard struct {
+	SiteID     int
+	Name       string
+	Departures []Departure
+}
+
+// JourneyPosition is one run of a line, placed by the boards it still
+// appears on. A run drops off a stop's board once it has left that stop,
+// so the stop where it is expected soonest is where it is heading next.
+type JourneyPosition struct {
+	JourneyID     int64  `json:"journey_id"`
+	Line          string `json:"line"`
+	Destination   string `json:"destination"`
+	DirectionCode int    `json:"direction_code"`
+	// At the reference stop; zero when the run is no longer listed there,
+	// usually because it has already left.
+	Scheduled time.Time `json:"scheduled,omitzero"`
+	Expected  time.Time `json:"expected,omitzero"`
+	DelayMin  int       `json:"delay_min"` // at the reference stop, else at NextStop
+	State     string    `json:"state,omitempty"`
+	// NextStop is the scanned stop the run reaches next.
+	NextStop     string    `json:"next_stop"`
+	NextSiteID   int       `json:"next_site_id"`
+	NextExpected time.Time `json:"next_expected"`
+
+	nextDelayMin int
+}
+
+// LocateJourneys groups the departures of a line on several boards by
+// journey and works out where each run is. boards[0] is the reference stop
+// (where the user is); the others should be stops around it on the line.
+// direction 0 means both directions. Runs listed at the reference stop come
+// first, in the order they get there, followed by those already past it.
+func LocateJourneys(boards []StopBoard, line string, direction int) []JourneyPosition {
+	loc, _ := time.LoadLocation(stockholmTZ)
+	byID := map[int64]*JourneyPosition{}
+	var order []int64
+
+	for i, b := range boards {
+		for _, d := range b.Departures {
+			if d.Journey == nil || d.Line == nil || !strings.EqualFold(d.Line.Designation, line) {
+				continue
+			}
+			if direction != 0 && d.DirectionCode != direction {
+				continue
+			}
+			scheduled, _ := time.ParseInLocation("2006-01-02T15:04:05", d.Scheduled, loc)
+			expected, _ := time.ParseInLocation("2006-01-02T15:04:05", d.Expected, loc)
+			if expected.IsZero() {
+				expected = scheduled
+			}
+
+			p, ok := byID[d.Journey.ID]
+			if !ok {
+				p = &JourneyPosition{
+					JourneyID:     d.Journey.ID,
+					Line:          d.Line.Designation,
+					Destination:   d.Destination,
+					DirectionCode: d.DirectionCode,
+				}
+				byID[d.Journey.ID] = p
+				order = append(order, d.Journey.ID)
+			}
+			delay := 0
+			if !scheduled.IsZero() {
+				delay = int(expected.Sub(scheduled).Minutes())
+			}
+			if i == 0 {
+				p.Scheduled, p.Expected, p.State, p.DelayMin = scheduled, expected, d.State, delay
+			}
+			if p.NextStop == "" || expected.Before(p.NextExpected) {
+				p.NextStop, p.NextSiteID, p.NextExpected, p.nextDelayMin = b.Name, b.SiteID, expected, delay
+			}
+		}
+	}
+
+	result := make([]JourneyPosition, 0, len(order))
+	for _, id := range order {
+		p := byID[id]
+		if p.Expected.IsZero() {
+			p.DelayMin = p.nextDelayMin
+		}
+		result = append(result, *p)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Expected.IsZero() != b.Expected.IsZero() {
+			return !a.Expected.IsZero()
+		}
+		if !a.Expected.Equal(b.Expected) {
+			return a.Expected.Before(b.Expected)
+		}
+		return a.NextExpected.Before(b.NextExpected)
+	})
+	return result
+}
@@ -0,0 +1,62 @@
+package sl
+
+import (
+	"testing"
+)
+
+func TestLocateJourneys(t *testing.T) {
+	dep := func(journey int64, line string, dir int, scheduled, expected string) Departure {
+		return Departure{
+			Destination:   "Tanto",
+			DirectionCode: dir,
+			Scheduled:     "2024-03-01T" + scheduled + ":00",
+			Expected:      "2024-03-01T" + expected + ":00",
+			Journey:       &Journey{ID: journey},
+			Line:          &Line{Designation: line},
+		}
+	}
+	boards := []StopBoard{
+		{SiteID: 1, Name: "Here", Departures: []Departure{
+			dep(3, "4", 1, "14:10", "14:10"),
+			dep(2, "4", 1, "14:02", "14:04"),
+			dep(9, "55", 1, "14:03", "14:03"),
+		}},
+		{SiteID: 2, Name: "Before", Departures: []Departure{
+			dep(3, "4", 1, "14:08", "14:08"),
+		}},
+		{SiteID: 3, Name: "After", Departures: []Departure{
+			dep(1, "4", 1, "13:57", "14:01"),
+			dep(2, "4", 1, "14:05", "14:07"),
+			dep(3, "4", 1, "14:13", "14:13"),
+			dep(4, "4", 2, "14:06", "14:06"),
+		}},
+	}
+
+	runs := LocateJourneys(boards, "4", 1)
+	if len(runs) != 3 {
+		t.Fatalf("got %d runs, want 3: %+v", len(runs), runs)
+	}
+	want := []struct {
+		id        int64
+		next      string
+		delay     int
+		atRefStop bool
+	}{
+		{2, "Here", 2, true},
+		{3, "Before", 0, true},
+		{1, "After", 4, false}, // already left Here, 4 min late at After
+	}
+	for i, w := range want {
+		r := runs[i]
+		if r.JourneyID != w.id || r.NextStop != w.next || r.DelayMin != w.delay || r.Expected.IsZero() == w.atRefStop {
+			t.Errorf("run %d = %+v, want %+v", i, r, w)
+		}
+	}
+	if got := runs[0].Scheduled.Format("15:04"); got != "14:02" {
+		t.Errorf("scheduled at reference stop = %s, want 14:02", got)
+	}
+
+	if both := LocateJourneys(boards, "4", 0); len(both) != 4 {
+		t.Errorf("both directions: got %d runs, want 4", len(both))
+	}
+}
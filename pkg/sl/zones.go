@@ -0,0 +1,54 @@
+package sl
+
+import (
+	"strings"
+)
+
+// FareZone is a fare area an SL journey can touch. SL itself is a single
+// zone covering Stockholm County; neighbouring counties' zones only matter
+// for pendeltåg stations across the border.
+type FareZone struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// FareZones lists the zones known to the CLI, SL first.
+var FareZones = []FareZone{
+	{Code: "SL", Name: "Stockholm County", Description: "All SL buses, metro, trams, pendeltåg and ferries within Stockholm County"},
+	{Code: "UL", Name: "Uppsala County", Description: "UL area; pendeltåg stations north of Arlanda and in Håbo"},
+	{Code: "SÖRMLAND", Name: "Sörmland", Description: "Länstrafiken Sörmland area; pendeltåg to Gnesta"},
+}
+
+// StopZones is the zone membership of a stop.
+type StopZones struct {
+	Zones []string `json:"zones"`
+	// Supplement is set when an SL ticket alone is not valid at the stop.
+	Supplement bool   `json:"supplement"`
+	Note       string `json:"note,omitempty"`
+}
+
+// borderStops are stations outside the plain SL zone, keyed by lowercased
+// site name. Every other SL site is in zone SL only.
+var borderStops = map[string]StopZones{
+	"bålsta":    {Zones: []string{"SL", "UL"}, Note: "SL tickets are valid to Bålsta under the Håbo agreement"},
+	"knivsta":   {Zones: []string{"UL"}, Supplement: true, Note: "UL ticket or SL+UL supplement required north of Arlanda C"},
+	"uppsala c": {Zones: []string{"UL"}, Supplement: true, Note: "UL ticket or SL+UL supplement required north of Arlanda C"},
+	"gnesta":    {Zones: []string{"SL", "SÖRMLAND"}, Note: "SL tickets are valid on pendeltåg to Gnesta"},
+	"arlanda c": {Zones: []string{"SL"}, Supplement: true, Note: "Arlanda passage fee is charged on top of SL tickets"},
+}
+
+// SiteZones returns the fare zones a site belongs to.
+func SiteZones(site Site) StopZones {
+	name := strings.ToLower(strings.TrimSpace(site.Name))
+	if z, ok := borderStops[name]; ok {
+		return z
+	}
+	// Sites are sometimes named "Bålsta station" or "Uppsala C (pendeltåg)".
+	for key, z := range borderStops {
+		if strings.HasPrefix(name, key+" ") {
+			return z
+		}
+	}
+	return StopZones{Zones: []string{"SL"}}
+}
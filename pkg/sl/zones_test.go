@@ -0,0 +1,31 @@
+package sl
+
+import (
+	"testing"
+)
+
+func TestSiteZones(t *testing.T) {
+	tests := []struct {
+		name       string
+		zones      string
+		supplement bool
+	}{
+		{"Medborgarplatsen", "SL", false},
+		{"Bålsta", "SL,UL", false},
+		{"Uppsala C", "UL", true},
+		{"Knivsta station", "UL", true},
+	}
+	for _, tt := range tests {
+		z := SiteZones(Site{Name: tt.name})
+		got := ""
+		for i, code := range z.Zones {
+			if i > 0 {
+				got += ","
+			}
+			got += code
+		}
+		if got != tt.zones || z.Supplement != tt.supplement {
+			t.Errorf("%s: got %s supplement=%v, want %s supplement=%v", tt.name, got, z.Supplement, tt.zones, tt.supplement)
+		}
+	}
+}